@@ -21,6 +21,9 @@ import (
 func main() {
 	// Cargar configuración
 	cfg := config.LoadConfig()
+	if err := cfg.ValidarPasarelas(); err != nil {
+		log.Fatalf("Configuración de pasarelas de pago incompleta: %v", err)
+	}
 
 	// Configurar modo de Gin según entorno
 	if cfg.Env == "production" {
@@ -101,6 +104,20 @@ func main() {
 	pagoRepo := repositorios.NewPagoRepository(db)
 	comprobantePagoRepo := repositorios.NewComprobantePagoRepository(db)
 	instanciaTourRepo := repositorios.NewInstanciaTourRepository(db)
+	webhookEventoRepo := repositorios.NewWebhookEventoRepository(db)
+	devolucionPagoRepo := repositorios.NewDevolucionPagoRepository(db)
+	politicaCancelacionRepo := repositorios.NewPoliticaCancelacionRepository(db)
+	listaEsperaRepo := repositorios.NewListaEsperaRepository(db)
+	pasajeroRepo := repositorios.NewPasajeroRepository(db)
+	embarqueRepo := repositorios.NewEmbarqueRepository(db)
+	comprobanteElectronicoRepo := repositorios.NewComprobanteElectronicoRepository(db)
+	serieComprobanteRepo := repositorios.NewSerieComprobanteRepository(db)
+	impuestoRepo := repositorios.NewImpuestoRepository(db)
+	codigoPromocionalRepo := repositorios.NewCodigoPromocionalRepository(db)
+	reglaPrecioRepo := repositorios.NewReglaPrecioRepository(db)
+	tipoCambioRepo := repositorios.NewTipoCambioRepository(db)
+	cajaRepo := repositorios.NewCajaRepository(db)
+	conciliacionPagoRepo := repositorios.NewConciliacionPagoRepository(db)
 
 	// Inicializar servicios
 	authService := servicios.NewAuthService(usuarioRepo, sedeRepo, cfg)
@@ -132,7 +149,21 @@ func main() {
 	tipoPasajeService := servicios.NewTipoPasajeService(tipoPasajeRepo, sedeRepo, tipoTourRepo)
 	canalVentaService := servicios.NewCanalVentaService(canalVentaRepo, sedeRepo)
 	clienteService := servicios.NewClienteService(clienteRepo, cfg)
-	mercadoPagoService := servicios.NewMercadoPagoService()
+	mercadoPagoService := servicios.NewMercadoPagoService(cfg.MercadoPagoAccessToken, cfg.MercadoPagoPublicKey, cfg.MercadoPagoWebhookSecret)
+	pasarelasPago := servicios.NuevasPasarelasPago(cfg, mercadoPagoService)
+	log.Printf("Pasarela de pago predeterminada: %s", pasarelasPago.Predeterminada().Nombre())
+
+	// Servicio de lista de espera; las ofertas de cupos se notifican por el log del servidor
+	listaEsperaService := servicios.NewListaEsperaService(listaEsperaRepo, clienteRepo, &servicios.NotificadorListaEsperaLog{})
+
+	// Códigos promocionales que se aplican al cotizar y crear reservas
+	codigoPromocionalService := servicios.NewCodigoPromocionalService(codigoPromocionalRepo)
+
+	// Tipos de cambio diarios para convertir a soles los precios y cobros en dólares
+	tipoCambioService := servicios.NewTipoCambioService(tipoCambioRepo)
+
+	// Reglas de temporada, día, horario y ocupación que fijan el precio efectivo de los pasajes
+	reglaPrecioService := servicios.NewReglaPrecioService(reglaPrecioRepo, tipoPasajeRepo, tipoCambioService)
 
 	// Servicios de reserva
 	reservaService := servicios.NewReservaService(
@@ -145,6 +176,11 @@ func main() {
 		paquetePasajesRepo,
 		usuarioRepo,
 		sedeRepo,
+		webhookEventoRepo,
+		listaEsperaService,
+		codigoPromocionalService,
+		reglaPrecioService,
+		tipoCambioService,
 	)
 
 	// Servicios de pago
@@ -154,16 +190,66 @@ func main() {
 		metodoPagoRepo,
 		canalVentaRepo,
 		sedeRepo,
+		tipoCambioService,
+		cajaRepo,
 	)
 
+	// Cajas de los vendedores a las que se asocian sus cobros
+	cajaService := servicios.NewCajaService(cajaRepo, usuarioRepo, sedeRepo, metodoPagoRepo)
+
+	// Servicio de impuestos por sede
+	impuestoService := servicios.NewImpuestoService(impuestoRepo, sedeRepo, cfg)
+
 	// Servicios de comprobante de pago
 	comprobantePagoService := servicios.NewComprobantePagoService(
 		comprobantePagoRepo,
 		reservaRepo,
 		pagoRepo,
 		sedeRepo,
+		serieComprobanteRepo,
+		impuestoService,
+		tipoCambioService,
+	)
+	serieComprobanteService := servicios.NewSerieComprobanteService(serieComprobanteRepo, sedeRepo)
+	instanciaTourService := servicios.NewInstanciaTourService(instanciaTourRepo, listaEsperaService)
+
+	// Servicio de devoluciones de pagos
+	devolucionPagoService := servicios.NewDevolucionPagoService(devolucionPagoRepo, pasarelasPago, comprobantePagoService)
+
+	// Servicio de políticas de cancelación
+	politicaCancelacionService := servicios.NewPoliticaCancelacionService(politicaCancelacionRepo, reservaRepo, listaEsperaService)
+
+	// Servicio de pasajeros y manifiestos de embarque
+	pasajeroService := servicios.NewPasajeroService(pasajeroRepo)
+
+	// Servicio de pases de abordar y check-in
+	embarqueService := servicios.NewEmbarqueService(embarqueRepo, cfg)
+
+	// Servicio de facturación electrónica SUNAT
+	firmanteSunat, err := servicios.NuevoFirmanteSunat(cfg)
+	if err != nil {
+		log.Fatalf("Error al cargar el certificado digital: %v", err)
+	}
+	comprobanteElectronicoService := servicios.NewComprobanteElectronicoService(
+		comprobanteElectronicoRepo,
+		pagoRepo,
+		firmanteSunat,
+		servicios.NuevoEnviadorSunat(cfg),
+		cfg,
 	)
-	instanciaTourService := servicios.NewInstanciaTourService(instanciaTourRepo)
+
+	// Servicio de comprobantes y vouchers en PDF
+	documentoImpresoService := servicios.NewDocumentoImpresoService(comprobanteElectronicoRepo, sedeRepo, embarqueService, cfg)
+
+	// Liberar periódicamente los cupos retenidos por reservas web no pagadas
+	servicios.IniciarLiberacionReservasExpiradas(reservaService, cfg.IntervaloLiberacionReservas)
+
+	// Vencer ofertas de lista de espera y ofrecer cupos libres a los clientes en espera
+	servicios.IniciarProcesamientoListaEspera(listaEsperaService, cfg.IntervaloLiberacionReservas)
+
+	// Conciliar periódicamente los pagos en línea con las pasarelas por si se perdió alguna notificación
+	conciliacionPagoService := servicios.NewConciliacionPagoService(conciliacionPagoRepo, reservaService, pasarelasPago, cfg.DiasConciliacionPagos)
+	servicios.IniciarConciliacionPagos(conciliacionPagoService, cfg.IntervaloConciliacionPagos)
 
 	// Middleware global para agregar la configuración al contexto
 	router.Use(func(c *gin.Context) {
@@ -190,9 +276,23 @@ func main() {
 	canalVentaController := controladores.NewCanalVentaController(canalVentaService)
 	sedeController := controladores.NewSedeController(sedeService)
 	clienteController := controladores.NewClienteController(clienteService, cfg)
-	reservaController := controladores.NewReservaController(reservaService, mercadoPagoService)
+	reservaController := controladores.NewReservaController(reservaService, pasarelasPago)
 	pagoController := controladores.NewPagoController(pagoService)
 	comprobantePagoController := controladores.NewComprobantePagoController(comprobantePagoService)
+	devolucionPagoController := controladores.NewDevolucionPagoController(devolucionPagoService)
+	politicaCancelacionController := controladores.NewPoliticaCancelacionController(politicaCancelacionService)
+	listaEsperaController := controladores.NewListaEsperaController(listaEsperaService, reservaService)
+	pasajeroController := controladores.NewPasajeroController(pasajeroService, reservaService)
+	embarqueController := controladores.NewEmbarqueController(embarqueService, reservaService)
+	comprobanteElectronicoController := controladores.NewComprobanteElectronicoController(comprobanteElectronicoService)
+	serieComprobanteController := controladores.NewSerieComprobanteController(serieComprobanteService)
+	documentoImpresoController := controladores.NewDocumentoImpresoController(documentoImpresoService, reservaService)
+	impuestoController := controladores.NewImpuestoController(impuestoService, reservaService)
+	codigoPromocionalController := controladores.NewCodigoPromocionalController(codigoPromocionalService)
+	reglaPrecioController := controladores.NewReglaPrecioController(reglaPrecioService)
+	tipoCambioController := controladores.NewTipoCambioController(tipoCambioService)
+	cajaController := controladores.NewCajaController(cajaService)
+	conciliacionPagoController := controladores.NewConciliacionPagoController(conciliacionPagoService)
 	instanciaTourController := controladores.NewInstanciaTourController(instanciaTourService)
 	mercadoPagoController := controladores.NewMercadoPagoController(
 		mercadoPagoService,
@@ -225,10 +325,25 @@ func main() {
 		sedeController,
 		instanciaTourController, // Agregar el nuevo controlador aquí
 		mercadoPagoController,   // Añadido aquí
+		devolucionPagoController,
+		politicaCancelacionController,
+		listaEsperaController,
+		pasajeroController,
+		embarqueController,
+		comprobanteElectronicoController,
+		serieComprobanteController,
+		documentoImpresoController,
+		impuestoController,
+		codigoPromocionalController,
+		reglaPrecioController,
+		tipoCambioController,
+		cajaController,
+		conciliacionPagoController,
 
 		reservaService,
 		clienteService,
-		mercadoPagoService,
+		pasarelasPago,
+		politicaCancelacionService,
 	)
 
 	// Iniciar servidor
@@ -305,5 +420,6 @@ func runMigrations(db *sql.DB) error {
 		log.Println("Base de datos ya inicializada, saltando migraciones")
 	}
 
-	return nil
+	// Migraciones incrementales (deben ser idempotentes: IF NOT EXISTS)
+	return config.AplicarMigracionesIncrementales(db, "./migrations")
 }
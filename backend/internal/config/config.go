@@ -84,8 +84,10 @@ func getEnv(key, defaultValue string) string {
 package config
 
 import (
+	"errors"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -96,6 +98,7 @@ type Config struct {
 	// Servidor
 	ServerPort string
 	ServerHost string
+	BackendURL string // URL pública del backend, a la que apuntan los enlaces que atiende el propio servidor
 
 	// Base de datos (Se conecta internamente al puerto 5432 en el contenedor)
 	DBHost     string
@@ -110,6 +113,41 @@ type Config struct {
 	JWTRefreshSecret string
 	JWTExpiration    time.Duration
 
+	// Reservas
+	IntervaloLiberacionReservas time.Duration
+
+	// Conciliación de pagos en línea
+	IntervaloConciliacionPagos time.Duration
+	DiasConciliacionPagos      int // Antigüedad máxima de las reservas cuyos pagos se concilian
+
+	// Facturación electrónica SUNAT
+	SunatRUC              string
+	SunatRazonSocial      string
+	SunatNombreComercial  string
+	SunatDireccion        string
+	SunatUbigeo           string
+	SunatCertificadoPath  string // Certificado digital X.509 en PEM
+	SunatClavePrivadaPath string // Clave privada RSA del certificado en PEM
+	SunatModo             string // stub (envío simulado) u ose
+	SunatOSEURL           string
+	SunatUsuario          string // Usuario SOL o del OSE
+	SunatClave            string
+
+	// Pasarelas de pago en línea
+	PasarelaPago             string // Pasarela de los cobros nuevos: MERCADO_PAGO, IZIPAY o FAKE (simulada, solo desarrollo)
+	MercadoPagoAccessToken   string
+	MercadoPagoPublicKey     string
+	MercadoPagoWebhookSecret string
+	IzipayURL                string
+	IzipayUsuario            string // Identificador de la tienda
+	IzipayClave              string // Contraseña de la API REST
+	IzipayClavePublica       string // Clave pública del formulario embebido
+	IzipayClaveHMAC          string // Clave con la que se firman las notificaciones IPN
+	PasarelaFakeSecreto      string // Opcional: si se define, las notificaciones simuladas deben venir firmadas
+
+	// Impuestos
+	IGVPorcentaje float64 // Tasa de IGV de las sedes sin configuración propia
+
 	// Aplicación
 	LogLevel string
 	Env      string
@@ -125,6 +163,7 @@ func LoadConfig() *Config {
 		// Servidor.
 		ServerPort: getEnv("SERVER_PORT", "8080"),
 		ServerHost: getEnv("SERVER_HOST", "0.0.0.0"),
+		BackendURL: strings.TrimRight(getEnv("BACKEND_URL", "http://localhost:"+getEnv("SERVER_PORT", "8080")), "/"),
 
 		// Base de datos.
 		// DB_HOST debe ser el nombre del servicio, en este caso "sistema-tours-db2".
@@ -141,6 +180,41 @@ func LoadConfig() *Config {
 		JWTRefreshSecret: getEnv("JWT_REFRESH_SECRET", "sistema-tours-refresh-secret-key"),
 		JWTExpiration:    time.Hour * 24, // 1 día por defecto.
 
+		// Reservas.
+		IntervaloLiberacionReservas: time.Minute, // Revisión de retenciones vencidas cada minuto.
+
+		// Conciliación de pagos en línea.
+		IntervaloConciliacionPagos: 15 * time.Minute,
+		DiasConciliacionPagos:      3,
+
+		// Facturación electrónica SUNAT.
+		SunatRUC:              getEnv("SUNAT_RUC", "20000000001"),
+		SunatRazonSocial:      getEnv("SUNAT_RAZON_SOCIAL", "SISTEMA TOURS S.A.C."),
+		SunatNombreComercial:  getEnv("SUNAT_NOMBRE_COMERCIAL", "Sistema Tours"),
+		SunatDireccion:        getEnv("SUNAT_DIRECCION", "-"),
+		SunatUbigeo:           getEnv("SUNAT_UBIGEO", "150101"),
+		SunatCertificadoPath:  getEnv("SUNAT_CERTIFICADO_PATH", ""),
+		SunatClavePrivadaPath: getEnv("SUNAT_CLAVE_PRIVADA_PATH", ""),
+		SunatModo:             getEnv("SUNAT_MODO", "stub"),
+		SunatOSEURL:           getEnv("SUNAT_OSE_URL", ""),
+		SunatUsuario:          getEnv("SUNAT_USUARIO", ""),
+		SunatClave:            getEnv("SUNAT_CLAVE", ""),
+
+		// Pasarelas de pago en línea.
+		PasarelaPago:             strings.ToUpper(getEnv("PASARELA_PAGO", "MERCADO_PAGO")),
+		MercadoPagoAccessToken:   getEnv("MERCADOPAGO_ACCESS_TOKEN", ""),
+		MercadoPagoPublicKey:     getEnv("MERCADOPAGO_PUBLIC_KEY", ""),
+		MercadoPagoWebhookSecret: getEnv("MERCADOPAGO_WEBHOOK_SECRET", ""),
+		IzipayURL:                getEnv("IZIPAY_API_URL", "https://api.micuentaweb.pe"),
+		IzipayUsuario:            getEnv("IZIPAY_USUARIO", ""),
+		IzipayClave:              getEnv("IZIPAY_CLAVE", ""),
+		IzipayClavePublica:       getEnv("IZIPAY_CLAVE_PUBLICA", ""),
+		IzipayClaveHMAC:          getEnv("IZIPAY_CLAVE_HMAC", ""),
+		PasarelaFakeSecreto:      getEnv("PASARELA_FAKE_SECRETO", ""),
+
+		// Impuestos.
+		IGVPorcentaje: 18,
+
 		// Aplicación.
 		LogLevel: getEnv("LOG_LEVEL", "info"),
 		Env:      getEnv("APP_ENV", "development"),
@@ -153,9 +227,48 @@ func LoadConfig() *Config {
 		}
 	}
 
+	// Parsear intervalo de liberación de reservas expiradas si está definido.
+	if intervalo := getEnv("RESERVA_LIBERACION_INTERVALO_SEGUNDOS", ""); intervalo != "" {
+		if segundos, err := strconv.Atoi(intervalo); err == nil && segundos > 0 {
+			config.IntervaloLiberacionReservas = time.Second * time.Duration(segundos)
+		}
+	}
+
+	// Parsear intervalo y alcance de la conciliación de pagos si están definidos.
+	if intervalo := getEnv("CONCILIACION_PAGOS_INTERVALO_MINUTOS", ""); intervalo != "" {
+		if minutos, err := strconv.Atoi(intervalo); err == nil && minutos > 0 {
+			config.IntervaloConciliacionPagos = time.Minute * time.Duration(minutos)
+		}
+	}
+	if dias := getEnv("CONCILIACION_PAGOS_DIAS", ""); dias != "" {
+		if valor, err := strconv.Atoi(dias); err == nil && valor > 0 {
+			config.DiasConciliacionPagos = valor
+		}
+	}
+
+	// Parsear tasa de IGV por defecto si está definida.
+	if igv := getEnv("IGV_PORCENTAJE", ""); igv != "" {
+		if porcentaje, err := strconv.ParseFloat(igv, 64); err == nil && porcentaje > 0 && porcentaje < 100 {
+			config.IGVPorcentaje = porcentaje
+		}
+	}
+
 	return config
 }
 
+// ValidarPasarelas verifica que cada pasarela habilitada tenga la clave con la que se verifican sus notificaciones.
+// Sin ella se rechazan todos los webhooks: ningún pago en línea se registra y las reservas web solo expiran.
+func (c *Config) ValidarPasarelas() error {
+	var errs []error
+	if c.MercadoPagoAccessToken != "" && c.MercadoPagoWebhookSecret == "" {
+		errs = append(errs, errors.New("MERCADOPAGO_WEBHOOK_SECRET es obligatoria si se define MERCADOPAGO_ACCESS_TOKEN"))
+	}
+	if c.IzipayUsuario != "" && c.IzipayClaveHMAC == "" {
+		errs = append(errs, errors.New("IZIPAY_CLAVE_HMAC es obligatoria si se define IZIPAY_USUARIO"))
+	}
+	return errors.Join(errs...)
+}
+
 // getEnv obtiene una variable de entorno o devuelve un valor por defecto.
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// AplicarMigracionesIncrementales ejecuta en orden los archivos NNN_*.sql del directorio indicado
+// Se ejecutan en cada arranque, así que cada migración debe ser idempotente y no volver a crear lo que
+// una migración posterior reemplaza
+func AplicarMigracionesIncrementales(db *sql.DB, directorio string) error {
+	archivos, err := filepath.Glob(filepath.Join(directorio, "[0-9][0-9][0-9]_*.sql"))
+	if err != nil {
+		return fmt.Errorf("error al listar migraciones incrementales: %v", err)
+	}
+	sort.Strings(archivos)
+
+	for _, archivo := range archivos {
+		contenido, err := os.ReadFile(archivo)
+		if err != nil {
+			return fmt.Errorf("error al leer migración %s: %v", archivo, err)
+		}
+
+		if _, err = db.Exec(string(contenido)); err != nil {
+			return fmt.Errorf("error al ejecutar migración %s: %v", archivo, err)
+		}
+		log.Printf("Migración aplicada: %s", filepath.Base(archivo))
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package controladores
+
+import (
+	"errors"
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CajaController maneja los endpoints de apertura y cierre de caja y del reporte de cierres
+type CajaController struct {
+	cajaService *servicios.CajaService
+}
+
+// NewCajaController crea una nueva instancia de CajaController
+func NewCajaController(cajaService *servicios.CajaService) *CajaController {
+	return &CajaController{
+		cajaService: cajaService,
+	}
+}
+
+// Abrir abre la caja del usuario autenticado
+func (c *CajaController) Abrir(ctx *gin.Context) {
+	var cajaReq entidades.AbrirCajaRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&cajaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(cajaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	id, err := c.cajaService.Abrir(ctx.GetInt("user_id"), &cajaReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al abrir la caja", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Caja abierta exitosamente", gin.H{"id": id}))
+}
+
+// GetAbierta obtiene la caja abierta del usuario autenticado con lo esperado hasta el momento
+func (c *CajaController) GetAbierta(ctx *gin.Context) {
+	caja, err := c.cajaService.GetAbierta(ctx.GetInt("user_id"))
+	if err != nil {
+		if errors.Is(err, repositorios.ErrSinCajaAbierta) {
+			ctx.JSON(http.StatusNotFound, utils.ErrorResponse("No tiene una caja abierta", err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al obtener la caja", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Caja obtenida exitosamente", caja))
+}
+
+// CerrarAbierta cierra la caja abierta del usuario autenticado con los montos contados
+func (c *CajaController) CerrarAbierta(ctx *gin.Context) {
+	var cierreReq entidades.CerrarCajaRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&cierreReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(cierreReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	caja, err := c.cajaService.CerrarAbierta(ctx.GetInt("user_id"), &cierreReq)
+	if err != nil {
+		if errors.Is(err, repositorios.ErrSinCajaAbierta) {
+			ctx.JSON(http.StatusNotFound, utils.ErrorResponse("No tiene una caja abierta", err))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al cerrar la caja", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Caja cerrada exitosamente", caja))
+}
+
+// GetByID obtiene una caja por su ID
+func (c *CajaController) GetByID(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	caja, err := c.cajaService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Caja no encontrada", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Caja obtenida exitosamente", caja))
+}
+
+// Cerrar cierra cualquier caja abierta, por ejemplo la que un vendedor dejó sin cerrar
+func (c *CajaController) Cerrar(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	var cierreReq entidades.CerrarCajaRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&cierreReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(cierreReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	caja, err := c.cajaService.Cerrar(id, &cierreReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al cerrar la caja", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Caja cerrada exitosamente", caja))
+}
+
+// ReporteCierres presenta los cierres de caja por sede y día
+// Acepta ?fecha_inicio=&fecha_fin= (YYYY-MM-DD, por defecto hoy) e ?id_sede=
+func (c *CajaController) ReporteCierres(ctx *gin.Context) {
+	hoy := time.Now()
+	fechaInicio, fechaFin, err := parsearRangoFechas(ctx, hoy, hoy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error(), nil))
+		return
+	}
+
+	var idSede *int
+	if valor := ctx.Query("id_sede"); valor != "" {
+		id, err := strconv.Atoi(valor)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de sede inválido", err))
+			return
+		}
+		idSede = &id
+	}
+
+	reporte, err := c.cajaService.ReporteCierres(fechaInicio, fechaFin, idSede)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al generar el reporte de cierres de caja", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reporte de cierres de caja generado", reporte))
+}
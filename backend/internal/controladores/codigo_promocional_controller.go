@@ -0,0 +1,167 @@
+package controladores
+
+import (
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CodigoPromocionalController maneja los endpoints de códigos promocionales
+type CodigoPromocionalController struct {
+	codigoService *servicios.CodigoPromocionalService
+}
+
+// NewCodigoPromocionalController crea una nueva instancia de CodigoPromocionalController
+func NewCodigoPromocionalController(codigoService *servicios.CodigoPromocionalService) *CodigoPromocionalController {
+	return &CodigoPromocionalController{
+		codigoService: codigoService,
+	}
+}
+
+// Create crea un nuevo código promocional
+func (c *CodigoPromocionalController) Create(ctx *gin.Context) {
+	var codigoReq entidades.NuevoCodigoPromocionalRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&codigoReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(codigoReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Crear código
+	id, err := c.codigoService.Create(&codigoReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al crear código promocional", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Código promocional creado exitosamente", gin.H{"id": id}))
+}
+
+// GetByID obtiene un código promocional por su ID
+func (c *CodigoPromocionalController) GetByID(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	// Obtener código
+	codigo, err := c.codigoService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Código promocional no encontrado", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Código promocional obtenido", codigo))
+}
+
+// Update actualiza un código promocional
+func (c *CodigoPromocionalController) Update(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	var codigoReq entidades.ActualizarCodigoPromocionalRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&codigoReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(codigoReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Actualizar código
+	err = c.codigoService.Update(id, &codigoReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al actualizar código promocional", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Código promocional actualizado exitosamente", nil))
+}
+
+// Delete elimina un código promocional
+func (c *CodigoPromocionalController) Delete(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	// Eliminar código
+	err = c.codigoService.Delete(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al eliminar código promocional", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Código promocional eliminado exitosamente", nil))
+}
+
+// List lista todos los códigos promocionales con sus usos vigentes
+func (c *CodigoPromocionalController) List(ctx *gin.Context) {
+	codigos, err := c.codigoService.List()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar códigos promocionales", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Códigos promocionales listados exitosamente", codigos))
+}
+
+// ListCanjes lista las reservas en las que se canjeó un código promocional
+func (c *CodigoPromocionalController) ListCanjes(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	canjes, err := c.codigoService.ListCanjes(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Error al listar canjes del código promocional", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Canjes listados exitosamente", canjes))
+}
+
+// ListReportes resume los canjes y descuentos otorgados por cada código promocional
+func (c *CodigoPromocionalController) ListReportes(ctx *gin.Context) {
+	reportes, err := c.codigoService.ListReportes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al generar el reporte de códigos promocionales", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reporte de códigos promocionales generado exitosamente", reportes))
+}
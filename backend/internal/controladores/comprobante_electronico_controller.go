@@ -0,0 +1,120 @@
+package controladores
+
+import (
+	"fmt"
+	"net/http"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ComprobanteElectronicoController maneja los endpoints de facturación electrónica SUNAT
+type ComprobanteElectronicoController struct {
+	electronicoService *servicios.ComprobanteElectronicoService
+}
+
+// NewComprobanteElectronicoController crea una nueva instancia de ComprobanteElectronicoController
+func NewComprobanteElectronicoController(electronicoService *servicios.ComprobanteElectronicoService) *ComprobanteElectronicoController {
+	return &ComprobanteElectronicoController{
+		electronicoService: electronicoService,
+	}
+}
+
+// obtenerIDComprobanteAutorizado lee el ID del comprobante de la ruta y verifica que pertenezca a la sede del usuario
+func (c *ComprobanteElectronicoController) obtenerIDComprobanteAutorizado(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de comprobante inválido", err))
+		return 0, false
+	}
+
+	comprobante, err := c.electronicoService.GetComprobante(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Comprobante no encontrado", err))
+		return 0, false
+	}
+
+	if ctx.GetString("rol") != "ADMIN" && comprobante.IDSede != ctx.GetInt("sede_id") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para acceder a este comprobante", nil))
+		return 0, false
+	}
+
+	return id, true
+}
+
+// Emitir genera, firma y envía a SUNAT el comprobante electrónico
+func (c *ComprobanteElectronicoController) Emitir(ctx *gin.Context) {
+	id, ok := c.obtenerIDComprobanteAutorizado(ctx)
+	if !ok {
+		return
+	}
+
+	electronico, err := c.electronicoService.Emitir(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al emitir el comprobante electrónico", err))
+		return
+	}
+
+	// Respuesta exitosa
+	mensaje := "Comprobante electrónico enviado a SUNAT"
+	if electronico.EstadoSunat == "ERROR" {
+		mensaje = "El comprobante se generó pero no se pudo enviar; vuelva a intentarlo"
+	}
+	ctx.JSON(http.StatusOK, utils.SuccessResponse(mensaje, electronico))
+}
+
+// GetByComprobante obtiene el estado de la emisión electrónica de un comprobante
+func (c *ComprobanteElectronicoController) GetByComprobante(ctx *gin.Context) {
+	id, ok := c.obtenerIDComprobanteAutorizado(ctx)
+	if !ok {
+		return
+	}
+
+	electronico, err := c.electronicoService.GetByComprobante(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Comprobante electrónico no encontrado", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Comprobante electrónico obtenido", electronico))
+}
+
+// DescargarXML descarga el XML firmado del comprobante
+func (c *ComprobanteElectronicoController) DescargarXML(ctx *gin.Context) {
+	id, ok := c.obtenerIDComprobanteAutorizado(ctx)
+	if !ok {
+		return
+	}
+
+	electronico, err := c.electronicoService.GetByComprobante(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Comprobante electrónico no encontrado", err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.xml", electronico.NombreArchivo))
+	ctx.Data(http.StatusOK, "application/xml", electronico.XMLFirmado)
+}
+
+// DescargarCDR descarga la constancia de recepción devuelta por SUNAT o el OSE
+func (c *ComprobanteElectronicoController) DescargarCDR(ctx *gin.Context) {
+	id, ok := c.obtenerIDComprobanteAutorizado(ctx)
+	if !ok {
+		return
+	}
+
+	electronico, err := c.electronicoService.GetByComprobante(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Comprobante electrónico no encontrado", err))
+		return
+	}
+	if len(electronico.CDR) == 0 {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("El comprobante todavía no tiene constancia de recepción", nil))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=R-%s.zip", electronico.NombreArchivo))
+	ctx.Data(http.StatusOK, "application/zip", electronico.CDR)
+}
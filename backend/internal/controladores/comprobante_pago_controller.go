@@ -52,7 +52,10 @@ func (c *ComprobantePagoController) Create(ctx *gin.Context) {
 	}
 
 	// Respuesta exitosa
-	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Comprobante de pago creado exitosamente", gin.H{"id": id}))
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Comprobante de pago creado exitosamente", gin.H{
+		"id":                 id,
+		"numero_comprobante": comprobanteReq.NumeroComprobante,
+	}))
 }
 
 // GetByID obtiene un comprobante de pago por su ID
@@ -463,3 +466,77 @@ func (c *ComprobantePagoController) ListBySede(ctx *gin.Context) {
 	// Respuesta exitosa
 	ctx.JSON(http.StatusOK, utils.SuccessResponse("Comprobantes de la sede listados exitosamente", comprobantes))
 }
+
+// CrearNota emite una nota de crédito o débito sobre el comprobante de la ruta
+func (c *ComprobantePagoController) CrearNota(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	// Verificar que el comprobante existe y el usuario tiene acceso
+	resumen, err := c.comprobantePagoService.GetResumenNotas(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Comprobante de pago no encontrado", err))
+		return
+	}
+	if ctx.GetString("rol") != "ADMIN" && resumen.IDSede != ctx.GetInt("sede_id") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para modificar este comprobante", nil))
+		return
+	}
+
+	var notaReq entidades.NuevaNotaComprobanteRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&notaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+	notaReq.IDComprobanteReferencia = id
+
+	// Validar datos
+	if err := utils.ValidateStruct(notaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Emitir nota
+	idNota, err := c.comprobantePagoService.CrearNota(&notaReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al emitir la nota", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Nota emitida exitosamente", gin.H{
+		"id":                 idNota,
+		"numero_comprobante": notaReq.NumeroComprobante,
+	}))
+}
+
+// ListNotas lista las notas de un comprobante con el total acreditado y el saldo que aún puede acreditarse
+func (c *ComprobantePagoController) ListNotas(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	resumen, err := c.comprobantePagoService.GetResumenNotas(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Comprobante de pago no encontrado", err))
+		return
+	}
+
+	// Verificar acceso según el rol
+	if ctx.GetString("rol") != "ADMIN" && resumen.IDSede != ctx.GetInt("sede_id") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para ver este comprobante", nil))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Notas del comprobante obtenidas", resumen))
+}
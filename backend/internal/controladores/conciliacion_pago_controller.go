@@ -0,0 +1,92 @@
+package controladores
+
+import (
+	"errors"
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConciliacionPagoController maneja los endpoints de la conciliación de pagos en línea y su reporte
+type ConciliacionPagoController struct {
+	conciliacionService *servicios.ConciliacionPagoService
+}
+
+// NewConciliacionPagoController crea una nueva instancia de ConciliacionPagoController
+func NewConciliacionPagoController(conciliacionService *servicios.ConciliacionPagoService) *ConciliacionPagoController {
+	return &ConciliacionPagoController{
+		conciliacionService: conciliacionService,
+	}
+}
+
+// Ejecutar concilia en el momento los pagos en línea, sin esperar a la ejecución programada
+func (c *ConciliacionPagoController) Ejecutar(ctx *gin.Context) {
+	idUsuario := ctx.GetInt("user_id")
+
+	conciliacion, err := c.conciliacionService.Conciliar(entidades.OrigenConciliacionManual, &idUsuario)
+	if err != nil {
+		if errors.Is(err, repositorios.ErrConciliacionEnCurso) {
+			ctx.JSON(http.StatusConflict, utils.ErrorResponse("Ya hay una conciliación de pagos en curso", err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al conciliar los pagos", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Conciliación de pagos ejecutada", conciliacion))
+}
+
+// GetByID obtiene una conciliación con las diferencias que encontró
+func (c *ConciliacionPagoController) GetByID(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	conciliacion, err := c.conciliacionService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Conciliación no encontrada", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Conciliación obtenida exitosamente", conciliacion))
+}
+
+// Reporte presenta para finanzas las diferencias con las pasarelas encontradas en un periodo
+// Acepta ?fecha_inicio=&fecha_fin= (YYYY-MM-DD, por defecto los últimos 7 días) y ?resultado=
+// (CORREGIDO, PENDIENTE_REVISION o ERROR)
+func (c *ConciliacionPagoController) Reporte(ctx *gin.Context) {
+	hoy := time.Now()
+	fechaInicio, fechaFin, err := parsearRangoFechas(ctx, hoy.AddDate(0, 0, -7), hoy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error(), nil))
+		return
+	}
+
+	resultado := ctx.Query("resultado")
+	switch resultado {
+	case "", entidades.ResultadoConciliacionCorregido, entidades.ResultadoConciliacionRevisar, entidades.ResultadoConciliacionError:
+	default:
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Resultado inválido", nil))
+		return
+	}
+
+	reporte, err := c.conciliacionService.Reporte(fechaInicio, fechaFin, resultado)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al generar el reporte de conciliación de pagos", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reporte de conciliación de pagos generado", reporte))
+}
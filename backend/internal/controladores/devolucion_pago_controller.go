@@ -0,0 +1,319 @@
+package controladores
+
+import (
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DevolucionPagoController maneja los endpoints de devoluciones de pagos
+type DevolucionPagoController struct {
+	devolucionService *servicios.DevolucionPagoService
+}
+
+// NewDevolucionPagoController crea una nueva instancia de DevolucionPagoController
+func NewDevolucionPagoController(devolucionService *servicios.DevolucionPagoService) *DevolucionPagoController {
+	return &DevolucionPagoController{
+		devolucionService: devolucionService,
+	}
+}
+
+// filtrarDevolucionesPorSede deja solo las devoluciones de la sede del usuario cuando no es administrador
+func filtrarDevolucionesPorSede(ctx *gin.Context, devoluciones []*entidades.DevolucionPago) []*entidades.DevolucionPago {
+	if ctx.GetString("rol") == "ADMIN" {
+		return devoluciones
+	}
+
+	sedeUsuario := ctx.GetInt("sede_id")
+	devolucionesFiltradas := []*entidades.DevolucionPago{}
+	for _, devolucion := range devoluciones {
+		if devolucion.IDSede == sedeUsuario {
+			devolucionesFiltradas = append(devolucionesFiltradas, devolucion)
+		}
+	}
+	return devolucionesFiltradas
+}
+
+// Create registra una solicitud de devolución
+func (c *DevolucionPagoController) Create(ctx *gin.Context) {
+	var devolucionReq entidades.NuevaDevolucionPagoRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&devolucionReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(devolucionReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Verificar acceso al pago según el rol
+	idSedePago, err := c.devolucionService.GetSedePago(devolucionReq.IDPago)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Pago no encontrado", err))
+		return
+	}
+
+	if ctx.GetString("rol") != "ADMIN" && idSedePago != ctx.GetInt("sede_id") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para devolver pagos de otra sede", nil))
+		return
+	}
+
+	// Registrar solicitud
+	id, err := c.devolucionService.Solicitar(&devolucionReq, ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al solicitar devolución", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Devolución solicitada exitosamente", gin.H{"id": id}))
+}
+
+// GetByID obtiene una devolución por su ID
+func (c *DevolucionPagoController) GetByID(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	// Obtener devolución
+	devolucion, err := c.devolucionService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Devolución no encontrada", err))
+		return
+	}
+
+	// Verificar acceso según el rol
+	if ctx.GetString("rol") != "ADMIN" && devolucion.IDSede != ctx.GetInt("sede_id") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para ver esta devolución", nil))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Devolución obtenida", devolucion))
+}
+
+// Aprobar aprueba una devolución pendiente
+func (c *DevolucionPagoController) Aprobar(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	// Aprobar devolución
+	err = c.devolucionService.Aprobar(id, ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al aprobar devolución", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Devolución aprobada exitosamente", nil))
+}
+
+// Rechazar rechaza una devolución pendiente
+func (c *DevolucionPagoController) Rechazar(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	var rechazoReq entidades.RechazarDevolucionRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&rechazoReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(rechazoReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Rechazar devolución
+	err = c.devolucionService.Rechazar(id, ctx.GetInt("user_id"), rechazoReq.Observaciones)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al rechazar devolución", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Devolución rechazada exitosamente", nil))
+}
+
+// Ejecutar ejecuta una devolución aprobada
+func (c *DevolucionPagoController) Ejecutar(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	// Verificar que la devolución existe y el usuario tiene acceso
+	devolucion, err := c.devolucionService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Devolución no encontrada", err))
+		return
+	}
+
+	if ctx.GetString("rol") != "ADMIN" && devolucion.IDSede != ctx.GetInt("sede_id") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para ejecutar esta devolución", nil))
+		return
+	}
+
+	var ejecucionReq entidades.EjecutarDevolucionRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&ejecucionReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(ejecucionReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Ejecutar devolución
+	err = c.devolucionService.Ejecutar(id, &ejecucionReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al ejecutar devolución", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Devolución ejecutada exitosamente", nil))
+}
+
+// List lista todas las devoluciones
+func (c *DevolucionPagoController) List(ctx *gin.Context) {
+	var devoluciones []*entidades.DevolucionPago
+	var err error
+
+	// Los usuarios que no son administradores solo ven las devoluciones de su sede
+	if ctx.GetString("rol") != "ADMIN" {
+		devoluciones, err = c.devolucionService.ListBySede(ctx.GetInt("sede_id"))
+	} else {
+		devoluciones, err = c.devolucionService.List()
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar devoluciones", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Devoluciones listadas exitosamente", devoluciones))
+}
+
+// ListBySede lista las devoluciones de una sede
+func (c *DevolucionPagoController) ListBySede(ctx *gin.Context) {
+	// Parsear ID de sede de la URL
+	idSede, err := strconv.Atoi(ctx.Param("idSede"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de sede inválido", err))
+		return
+	}
+
+	// Verificar permisos
+	if ctx.GetString("rol") != "ADMIN" && idSede != ctx.GetInt("sede_id") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para ver devoluciones de otra sede", nil))
+		return
+	}
+
+	// Listar devoluciones por sede
+	devoluciones, err := c.devolucionService.ListBySede(idSede)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar devoluciones por sede", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Devoluciones de la sede listadas exitosamente", devoluciones))
+}
+
+// ListByFecha lista las devoluciones de una fecha, con filtro opcional ?id_sede= para administradores
+func (c *DevolucionPagoController) ListByFecha(ctx *gin.Context) {
+	// Parsear fecha de la URL (formato: YYYY-MM-DD)
+	fecha, err := time.Parse("2006-01-02", ctx.Param("fecha"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Formato de fecha inválido, debe ser YYYY-MM-DD", err))
+		return
+	}
+
+	// Determinar la sede a filtrar
+	var idSede *int
+	if ctx.GetString("rol") != "ADMIN" {
+		sedeUsuario := ctx.GetInt("sede_id")
+		idSede = &sedeUsuario
+	} else if sedeStr := ctx.Query("id_sede"); sedeStr != "" {
+		sedeFiltro, err := strconv.Atoi(sedeStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de sede inválido", err))
+			return
+		}
+		idSede = &sedeFiltro
+	}
+
+	// Listar devoluciones por fecha
+	devoluciones, err := c.devolucionService.ListByFecha(fecha, idSede)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar devoluciones por fecha", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Devoluciones listadas exitosamente", devoluciones))
+}
+
+// ListByPago lista las devoluciones de un pago
+func (c *DevolucionPagoController) ListByPago(ctx *gin.Context) {
+	// Parsear ID de pago de la URL
+	idPago, err := strconv.Atoi(ctx.Param("idPago"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de pago inválido", err))
+		return
+	}
+
+	// Listar devoluciones del pago
+	devoluciones, err := c.devolucionService.ListByPago(idPago)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar devoluciones del pago", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Devoluciones del pago listadas exitosamente", filtrarDevolucionesPorSede(ctx, devoluciones)))
+}
+
+// ListByEstado lista las devoluciones por estado
+func (c *DevolucionPagoController) ListByEstado(ctx *gin.Context) {
+	// Listar devoluciones por estado
+	devoluciones, err := c.devolucionService.ListByEstado(ctx.Param("estado"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al listar devoluciones por estado", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Devoluciones listadas exitosamente", filtrarDevolucionesPorSede(ctx, devoluciones)))
+}
@@ -0,0 +1,78 @@
+package controladores
+
+import (
+	"fmt"
+	"net/http"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocumentoImpresoController maneja la descarga de comprobantes y vouchers en PDF
+type DocumentoImpresoController struct {
+	documentoService *servicios.DocumentoImpresoService
+	reservaService   *servicios.ReservaService
+}
+
+// NewDocumentoImpresoController crea una nueva instancia de DocumentoImpresoController
+func NewDocumentoImpresoController(documentoService *servicios.DocumentoImpresoService, reservaService *servicios.ReservaService) *DocumentoImpresoController {
+	return &DocumentoImpresoController{
+		documentoService: documentoService,
+		reservaService:   reservaService,
+	}
+}
+
+// ComprobantePDF descarga la representación impresa de un comprobante
+// Los clientes solo descargan sus comprobantes; vendedores los de su sede
+func (c *DocumentoImpresoController) ComprobantePDF(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de comprobante inválido", err))
+		return
+	}
+
+	comprobante, cliente, err := c.documentoService.GetDatosComprobante(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Comprobante no encontrado", err))
+		return
+	}
+
+	rol := ctx.GetString("rol")
+	if rol == "CLIENTE" {
+		if cliente.ID != ctx.GetInt("userID") {
+			ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene acceso a este comprobante", nil))
+			return
+		}
+	} else if rol != "ADMIN" && comprobante.IDSede != ctx.GetInt("sede_id") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para acceder a este comprobante", nil))
+		return
+	}
+
+	pdf, err := c.documentoService.GenerarComprobantePDF(comprobante, cliente)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al generar el PDF del comprobante", err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=comprobante_%s.pdf", comprobante.NumeroComprobante))
+	ctx.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// VoucherReservaPDF descarga el voucher de una reserva, con el pase de abordar si está confirmada
+func (c *DocumentoImpresoController) VoucherReservaPDF(ctx *gin.Context) {
+	reserva, ok := obtenerReservaAutorizada(ctx, c.reservaService)
+	if !ok {
+		return
+	}
+
+	pdf, err := c.documentoService.GenerarVoucherPDF(reserva)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al generar el voucher de la reserva", err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=voucher_reserva_%d.pdf", reserva.ID))
+	ctx.Data(http.StatusOK, "application/pdf", pdf)
+}
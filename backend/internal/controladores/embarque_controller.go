@@ -0,0 +1,156 @@
+package controladores
+
+import (
+	"fmt"
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmbarqueController maneja los endpoints de pases de abordar, check-in y no-show
+type EmbarqueController struct {
+	embarqueService *servicios.EmbarqueService
+	reservaService  *servicios.ReservaService
+}
+
+// NewEmbarqueController crea una nueva instancia de EmbarqueController
+func NewEmbarqueController(embarqueService *servicios.EmbarqueService, reservaService *servicios.ReservaService) *EmbarqueController {
+	return &EmbarqueController{
+		embarqueService: embarqueService,
+		reservaService:  reservaService,
+	}
+}
+
+// GetPaseAbordar obtiene el pase de abordar de una reserva confirmada
+// Por defecto responde la imagen PNG del QR; con formato=token responde el token firmado en JSON
+func (c *EmbarqueController) GetPaseAbordar(ctx *gin.Context) {
+	reserva, ok := obtenerReservaAutorizada(ctx, c.reservaService)
+	if !ok {
+		return
+	}
+
+	switch ctx.DefaultQuery("formato", "png") {
+	case "png":
+		imagen, err := c.embarqueService.GenerarPaseQR(reserva.ID)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al generar el pase de abordar", err))
+			return
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=pase_reserva_%d.png", reserva.ID))
+		ctx.Data(http.StatusOK, "image/png", imagen)
+	case "token":
+		pase, err := c.embarqueService.GenerarPase(reserva.ID)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al generar el pase de abordar", err))
+			return
+		}
+		ctx.JSON(http.StatusOK, utils.SuccessResponse("Pase de abordar generado exitosamente", pase))
+	default:
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Formato inválido, use png o token", nil))
+	}
+}
+
+// Checkin registra el embarque de la reserva del pase escaneado
+// El chofer solo puede embarcar reservas de las instancias de hoy que tiene asignadas
+func (c *EmbarqueController) Checkin(ctx *gin.Context) {
+	var solicitud entidades.CheckinRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&solicitud); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(solicitud); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	idUsuario := ctx.GetInt("user_id")
+	var idChofer *int
+	if ctx.GetString("rol") == "CHOFER" {
+		idChofer = &idUsuario
+	}
+
+	embarque, err := c.embarqueService.Checkin(&solicitud, idUsuario, idChofer)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al registrar el embarque", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Embarque registrado exitosamente", embarque))
+}
+
+// GetReporteNoShow obtiene el reporte de embarque y no-show de una instancia de tour
+func (c *EmbarqueController) GetReporteNoShow(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	reporte, err := c.embarqueService.GetReporteNoShow(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Error al obtener el reporte de no-show", err))
+		return
+	}
+
+	if !accesoInstanciaPermitido(ctx, reporte.IDSede, reporte.IDChofer) {
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reporte de no-show obtenido exitosamente", reporte))
+}
+
+// GetPaqueteOffline descarga el manifiesto firmado de las instancias de hoy del chofer
+// para validar pases en el muelle sin conexión
+func (c *EmbarqueController) GetPaqueteOffline(ctx *gin.Context) {
+	paquete, err := c.embarqueService.GenerarPaqueteOffline(ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al generar el paquete sin conexión", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Paquete sin conexión generado exitosamente", paquete))
+}
+
+// SincronizarEmbarques recibe los escaneos hechos sin conexión y devuelve cómo se concilió cada uno
+func (c *EmbarqueController) SincronizarEmbarques(ctx *gin.Context) {
+	var solicitud entidades.SincronizarEmbarquesRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&solicitud); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(solicitud); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	idUsuario := ctx.GetInt("user_id")
+	var idChofer *int
+	if ctx.GetString("rol") == "CHOFER" {
+		idChofer = &idUsuario
+	}
+
+	resultados, err := c.embarqueService.SincronizarEmbarques(&solicitud, idUsuario, idChofer)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al sincronizar los embarques", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Embarques sincronizados", resultados))
+}
@@ -0,0 +1,120 @@
+package controladores
+
+import (
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpuestoController maneja la configuración de IGV por sede y el cálculo de impuestos de reservas
+type ImpuestoController struct {
+	impuestoService *servicios.ImpuestoService
+	reservaService  *servicios.ReservaService
+}
+
+// NewImpuestoController crea una nueva instancia de ImpuestoController
+func NewImpuestoController(impuestoService *servicios.ImpuestoService, reservaService *servicios.ReservaService) *ImpuestoController {
+	return &ImpuestoController{
+		impuestoService: impuestoService,
+		reservaService:  reservaService,
+	}
+}
+
+// ListConfiguraciones lista la configuración de IGV de todas las sedes
+func (c *ImpuestoController) ListConfiguraciones(ctx *gin.Context) {
+	configuraciones, err := c.impuestoService.ListConfiguraciones()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar la configuración de impuestos", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Configuración de impuestos listada exitosamente", configuraciones))
+}
+
+// GetConfiguracion obtiene la configuración de IGV de una sede
+func (c *ImpuestoController) GetConfiguracion(ctx *gin.Context) {
+	idSede, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de sede inválido", err))
+		return
+	}
+
+	configuracion, err := c.impuestoService.GetConfiguracion(idSede)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Configuración de impuestos no encontrada", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Configuración de impuestos obtenida exitosamente", configuracion))
+}
+
+// ActualizarConfiguracion guarda la configuración de IGV de una sede
+func (c *ImpuestoController) ActualizarConfiguracion(ctx *gin.Context) {
+	idSede, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de sede inválido", err))
+		return
+	}
+
+	var configuracionReq entidades.ActualizarConfiguracionImpuestoRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&configuracionReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(configuracionReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	if err := c.impuestoService.ActualizarConfiguracion(idSede, &configuracionReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al actualizar la configuración de impuestos", err))
+		return
+	}
+
+	configuracion, err := c.impuestoService.GetConfiguracion(idSede)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al obtener la configuración de impuestos", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Configuración de impuestos actualizada exitosamente", configuracion))
+}
+
+// CalcularReserva muestra el desglose de impuestos con el que se emitiría el comprobante de una reserva
+// Por defecto usa la sede de la reserva; el administrador puede indicar otra con ?id_sede=
+func (c *ImpuestoController) CalcularReserva(ctx *gin.Context) {
+	reserva, ok := obtenerReservaAutorizada(ctx, c.reservaService)
+	if !ok {
+		return
+	}
+
+	idSede := reserva.IDSede
+	if valor := ctx.Query("id_sede"); valor != "" && ctx.GetString("rol") == "ADMIN" {
+		id, err := strconv.Atoi(valor)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de sede inválido", err))
+			return
+		}
+		idSede = id
+	}
+
+	calculo, err := c.impuestoService.CalcularReserva(reserva.ID, idSede)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al calcular los impuestos de la reserva", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Impuestos calculados exitosamente", calculo))
+}
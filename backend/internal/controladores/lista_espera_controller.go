@@ -0,0 +1,319 @@
+package controladores
+
+import (
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListaEsperaController maneja los endpoints de la lista de espera
+type ListaEsperaController struct {
+	listaEsperaService *servicios.ListaEsperaService
+	reservaService     *servicios.ReservaService
+}
+
+// NewListaEsperaController crea una nueva instancia de ListaEsperaController
+func NewListaEsperaController(listaEsperaService *servicios.ListaEsperaService, reservaService *servicios.ReservaService) *ListaEsperaController {
+	return &ListaEsperaController{
+		listaEsperaService: listaEsperaService,
+		reservaService:     reservaService,
+	}
+}
+
+// verificarAccesoSede comprueba que el usuario pueda operar sobre la sede indicada
+func (c *ListaEsperaController) verificarAccesoSede(ctx *gin.Context, idSede int) bool {
+	if ctx.GetString("rol") != "ADMIN" && idSede != ctx.GetInt("sede_id") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para acceder a esta lista de espera", nil))
+		return false
+	}
+	return true
+}
+
+// obtenerEntradaCliente obtiene una entrada de la URL verificando que pertenezca al cliente autenticado
+func (c *ListaEsperaController) obtenerEntradaCliente(ctx *gin.Context) (*entidades.ListaEspera, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return nil, false
+	}
+
+	entrada, err := c.listaEsperaService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Entrada de lista de espera no encontrada", err))
+		return nil, false
+	}
+
+	if entrada.IDCliente != ctx.GetInt("userID") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene acceso a esta entrada de lista de espera", nil))
+		return nil, false
+	}
+
+	return entrada, true
+}
+
+// Create inscribe a un cliente en la lista de espera de una instancia
+func (c *ListaEsperaController) Create(ctx *gin.Context) {
+	var solicitud entidades.NuevaListaEsperaRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&solicitud); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(solicitud); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Verificar acceso a la sede de la instancia
+	idSede, err := c.listaEsperaService.GetSedeInstancia(solicitud.IDInstancia)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Instancia de tour no encontrada", err))
+		return
+	}
+	if !c.verificarAccesoSede(ctx, idSede) {
+		return
+	}
+
+	idUsuario := ctx.GetInt("user_id")
+	id, err := c.listaEsperaService.Registrar(&solicitud, &idUsuario)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al registrar en la lista de espera", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Cliente registrado en la lista de espera", gin.H{"id": id}))
+}
+
+// GetByID obtiene una entrada de la lista de espera por su ID
+func (c *ListaEsperaController) GetByID(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	entrada, err := c.listaEsperaService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Entrada de lista de espera no encontrada", err))
+		return
+	}
+
+	if !c.verificarAccesoSede(ctx, entrada.IDSede) {
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Entrada de lista de espera obtenida", entrada))
+}
+
+// ListByInstancia lista la lista de espera de una instancia en orden de llegada
+func (c *ListaEsperaController) ListByInstancia(ctx *gin.Context) {
+	// Parsear ID de instancia de la URL
+	idInstancia, err := strconv.Atoi(ctx.Param("idInstancia"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de instancia inválido", err))
+		return
+	}
+
+	idSede, err := c.listaEsperaService.GetSedeInstancia(idInstancia)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Instancia de tour no encontrada", err))
+		return
+	}
+	if !c.verificarAccesoSede(ctx, idSede) {
+		return
+	}
+
+	entradas, err := c.listaEsperaService.ListByInstancia(idInstancia)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar la lista de espera", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Lista de espera obtenida exitosamente", entradas))
+}
+
+// Cancelar retira una entrada de la lista de espera
+func (c *ListaEsperaController) Cancelar(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	entrada, err := c.listaEsperaService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Entrada de lista de espera no encontrada", err))
+		return
+	}
+
+	if !c.verificarAccesoSede(ctx, entrada.IDSede) {
+		return
+	}
+
+	if err := c.listaEsperaService.Cancelar(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al cancelar la entrada de lista de espera", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Entrada de lista de espera cancelada exitosamente", nil))
+}
+
+// Convertir crea la reserva de una entrada con oferta vigente
+func (c *ListaEsperaController) Convertir(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	entrada, err := c.listaEsperaService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Entrada de lista de espera no encontrada", err))
+		return
+	}
+
+	if !c.verificarAccesoSede(ctx, entrada.IDSede) {
+		return
+	}
+
+	var reservaReq entidades.NuevaReservaRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&reservaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// La reserva siempre corresponde al cliente y la instancia de la entrada
+	reservaReq.IDCliente = entrada.IDCliente
+	reservaReq.IDInstancia = entrada.IDInstancia
+
+	// Validar datos
+	if err := utils.ValidateStruct(reservaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// El total se calcula con los precios vigentes, igual que en una reserva nueva
+	idReserva, err := c.reservaService.ConvertirListaEspera(id, &reservaReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al convertir la entrada en reserva", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Reserva creada desde la lista de espera", gin.H{"id_reserva": idReserva}))
+}
+
+// CreateMine inscribe al cliente autenticado en la lista de espera de una instancia
+func (c *ListaEsperaController) CreateMine(ctx *gin.Context) {
+	var solicitud entidades.NuevaListaEsperaRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&solicitud); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Forzar el ID del cliente al usuario autenticado
+	solicitud.IDCliente = ctx.GetInt("userID")
+
+	// Validar datos
+	if err := utils.ValidateStruct(solicitud); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	id, err := c.listaEsperaService.Registrar(&solicitud, nil)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al registrar en la lista de espera", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Registrado en la lista de espera", gin.H{"id": id}))
+}
+
+// ListMine lista las entradas de lista de espera del cliente autenticado
+func (c *ListaEsperaController) ListMine(ctx *gin.Context) {
+	clienteID := ctx.GetInt("userID")
+	if clienteID == 0 {
+		ctx.JSON(http.StatusUnauthorized, utils.ErrorResponse("Cliente no autenticado", nil))
+		return
+	}
+
+	entradas, err := c.listaEsperaService.ListByCliente(clienteID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar la lista de espera del cliente", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Mis listas de espera listadas exitosamente", entradas))
+}
+
+// CancelarMine retira al cliente autenticado de una lista de espera
+func (c *ListaEsperaController) CancelarMine(ctx *gin.Context) {
+	entrada, ok := c.obtenerEntradaCliente(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.listaEsperaService.Cancelar(entrada.ID); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al cancelar la entrada de lista de espera", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Entrada de lista de espera cancelada exitosamente", nil))
+}
+
+// ConvertirMine crea la reserva del cliente autenticado a partir de una oferta vigente
+func (c *ListaEsperaController) ConvertirMine(ctx *gin.Context) {
+	entrada, ok := c.obtenerEntradaCliente(ctx)
+	if !ok {
+		return
+	}
+
+	var reservaReq entidades.NuevaReservaRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&reservaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// La reserva del cliente no tiene vendedor y corresponde a la instancia de la entrada
+	reservaReq.IDCliente = entrada.IDCliente
+	reservaReq.IDInstancia = entrada.IDInstancia
+	reservaReq.IDVendedor = nil
+
+	// Validar datos
+	if err := utils.ValidateStruct(reservaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	idReserva, err := c.listaEsperaService.Convertir(entrada.ID, &reservaReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al convertir la entrada en reserva", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Reserva creada desde la lista de espera", gin.H{"id_reserva": idReserva}))
+}
@@ -38,10 +38,10 @@ func NewMercadoPagoController(
 
 // CreatePreferenceRequest estructura para la solicitud de creación de preferencia
 type CreatePreferenceRequest struct {
-	ReservaID   int     `json:"id_reserva" validate:"required"`
-	TourNombre  string  `json:"tour_nombre" validate:"required"`
-	Monto       float64 `json:"monto" validate:"required,min=0"`
-	FrontendURL string  `json:"frontend_url" validate:"required,url"`
+	ReservaID   int              `json:"id_reserva" validate:"required"`
+	TourNombre  string           `json:"tour_nombre" validate:"required"`
+	Monto       entidades.Dinero `json:"monto" validate:"required,min=0"`
+	FrontendURL string           `json:"frontend_url" validate:"required,url"`
 }
 
 // CreatePreferenceResponse estructura para la respuesta de creación de preferencia
@@ -163,7 +163,7 @@ func (c *MercadoPagoController) ProcessWebhook(w http.ResponseWriter, r *http.Re
 		IDMetodoPago: 1, // Asumimos que 1 es Mercado Pago en tu sistema
 		IDCanal:      1, // Canal web/online
 		IDSede:       1, // Sede principal o predeterminada
-		Monto:        paymentInfo.TransactionAmount,
+		Monto:        entidades.DineroDesdeFloat(paymentInfo.TransactionAmount),
 		Comprobante:  fmt.Sprintf("MP-%d", paymentInfo.ID), // Usar ID de MP como referencia
 	}
 
@@ -44,6 +44,10 @@ func (c *PagoController) Create(ctx *gin.Context) {
 		pagoReq.IDSede = ctx.GetInt("sede_id")
 	}
 
+	// El pago queda en la caja abierta del usuario autenticado
+	pagoReq.IDUsuario = ctx.GetInt("user_id")
+	pagoReq.RolUsuario = ctx.GetString("rol")
+
 	// Crear pago
 	id, err := c.pagoService.Create(&pagoReq)
 	if err != nil {
@@ -333,6 +337,26 @@ func (c *PagoController) GetTotalPagadoByReserva(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, utils.SuccessResponse("Total pagado obtenido exitosamente", gin.H{"total_pagado": totalPagado}))
 }
 
+// GetSaldoByReserva obtiene el saldo pendiente de una reserva específica
+func (c *PagoController) GetSaldoByReserva(ctx *gin.Context) {
+	// Parsear ID de reserva de la URL
+	idReserva, err := strconv.Atoi(ctx.Param("idReserva"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de reserva inválido", err))
+		return
+	}
+
+	// Obtener saldo
+	saldo, err := c.pagoService.GetSaldoByReserva(idReserva)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Error al obtener saldo de la reserva", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Saldo de la reserva obtenido exitosamente", saldo))
+}
+
 // ListByEstado lista todos los pagos con un estado específico
 func (c *PagoController) ListByEstado(ctx *gin.Context) {
 	// Parsear estado de la URL
@@ -0,0 +1,162 @@
+package controladores
+
+import (
+	"fmt"
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasajeroController maneja los endpoints de pasajeros y manifiestos de embarque
+type PasajeroController struct {
+	pasajeroService *servicios.PasajeroService
+	reservaService  *servicios.ReservaService
+}
+
+// NewPasajeroController crea una nueva instancia de PasajeroController
+func NewPasajeroController(pasajeroService *servicios.PasajeroService, reservaService *servicios.ReservaService) *PasajeroController {
+	return &PasajeroController{
+		pasajeroService: pasajeroService,
+		reservaService:  reservaService,
+	}
+}
+
+// obtenerReservaAutorizada obtiene la reserva de la URL verificando que el usuario pueda acceder a ella
+// Los clientes solo acceden a sus reservas; vendedores a las de su sede
+func obtenerReservaAutorizada(ctx *gin.Context, reservaService *servicios.ReservaService) (*entidades.Reserva, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return nil, false
+	}
+
+	reserva, err := reservaService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Reserva no encontrada", err))
+		return nil, false
+	}
+
+	rol := ctx.GetString("rol")
+	if rol == "CLIENTE" {
+		if reserva.IDCliente != ctx.GetInt("userID") {
+			ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene acceso a esta reserva", nil))
+			return nil, false
+		}
+	} else if rol != "ADMIN" && reserva.IDSede != ctx.GetInt("sede_id") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para ver esta reserva", nil))
+		return nil, false
+	}
+
+	return reserva, true
+}
+
+// accesoInstanciaPermitido verifica que el usuario pueda ver una instancia de tour
+// El chofer solo ve las instancias que tiene asignadas; vendedores las de su sede
+func accesoInstanciaPermitido(ctx *gin.Context, idSede int, idChofer *int) bool {
+	switch ctx.GetString("rol") {
+	case "ADMIN":
+	case "CHOFER":
+		if idChofer == nil || *idChofer != ctx.GetInt("user_id") {
+			ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene asignada esta instancia de tour", nil))
+			return false
+		}
+	default:
+		if idSede != ctx.GetInt("sede_id") {
+			ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para ver esta instancia de tour", nil))
+			return false
+		}
+	}
+	return true
+}
+
+// RegistrarPasajeros reemplaza los pasajeros de una reserva
+func (c *PasajeroController) RegistrarPasajeros(ctx *gin.Context) {
+	reserva, ok := obtenerReservaAutorizada(ctx, c.reservaService)
+	if !ok {
+		return
+	}
+
+	var solicitud entidades.RegistrarPasajerosRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&solicitud); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(solicitud); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	if err := c.pasajeroService.RegistrarPasajeros(reserva.ID, &solicitud); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al registrar pasajeros", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Pasajeros registrados exitosamente", nil))
+}
+
+// ListByReserva lista los pasajeros de una reserva
+func (c *PasajeroController) ListByReserva(ctx *gin.Context) {
+	reserva, ok := obtenerReservaAutorizada(ctx, c.reservaService)
+	if !ok {
+		return
+	}
+
+	pasajeros, err := c.pasajeroService.ListByReserva(reserva.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar pasajeros", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Pasajeros listados exitosamente", pasajeros))
+}
+
+// GetManifiesto obtiene el manifiesto de pasajeros de una instancia de tour
+// El parámetro formato admite json (por defecto), csv o pdf
+func (c *PasajeroController) GetManifiesto(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	manifiesto, err := c.pasajeroService.GetManifiesto(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Error al obtener el manifiesto", err))
+		return
+	}
+
+	if !accesoInstanciaPermitido(ctx, manifiesto.IDSede, manifiesto.IDChofer) {
+		return
+	}
+
+	nombreArchivo := fmt.Sprintf("manifiesto_instancia_%d", manifiesto.IDInstancia)
+
+	switch ctx.DefaultQuery("formato", "json") {
+	case "csv":
+		contenido, err := servicios.GenerarManifiestoCSV(manifiesto)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al generar el manifiesto", err))
+			return
+		}
+		ctx.Header("Content-Disposition", "attachment; filename="+nombreArchivo+".csv")
+		ctx.Data(http.StatusOK, "text/csv; charset=utf-8", contenido)
+	case "pdf":
+		ctx.Header("Content-Disposition", "attachment; filename="+nombreArchivo+".pdf")
+		ctx.Data(http.StatusOK, "application/pdf", servicios.GenerarManifiestoPDF(manifiesto))
+	case "json":
+		ctx.JSON(http.StatusOK, utils.SuccessResponse("Manifiesto obtenido exitosamente", manifiesto))
+	default:
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Formato inválido, use json, csv o pdf", nil))
+	}
+}
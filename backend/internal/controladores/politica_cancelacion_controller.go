@@ -0,0 +1,199 @@
+package controladores
+
+import (
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PoliticaCancelacionController maneja los endpoints de políticas de cancelación
+type PoliticaCancelacionController struct {
+	politicaService *servicios.PoliticaCancelacionService
+}
+
+// NewPoliticaCancelacionController crea una nueva instancia de PoliticaCancelacionController
+func NewPoliticaCancelacionController(politicaService *servicios.PoliticaCancelacionService) *PoliticaCancelacionController {
+	return &PoliticaCancelacionController{
+		politicaService: politicaService,
+	}
+}
+
+// Create crea un nuevo tramo de política de cancelación
+func (c *PoliticaCancelacionController) Create(ctx *gin.Context) {
+	var politicaReq entidades.NuevaPoliticaCancelacionRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&politicaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(politicaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Crear tramo
+	id, err := c.politicaService.Create(&politicaReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al crear política de cancelación", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Política de cancelación creada exitosamente", gin.H{"id": id}))
+}
+
+// GetByID obtiene un tramo de política de cancelación por su ID
+func (c *PoliticaCancelacionController) GetByID(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	// Obtener tramo
+	politica, err := c.politicaService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Política de cancelación no encontrada", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Política de cancelación obtenida", politica))
+}
+
+// Update actualiza un tramo de política de cancelación
+func (c *PoliticaCancelacionController) Update(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	var politicaReq entidades.ActualizarPoliticaCancelacionRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&politicaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(politicaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Actualizar tramo
+	err = c.politicaService.Update(id, &politicaReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al actualizar política de cancelación", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Política de cancelación actualizada exitosamente", nil))
+}
+
+// Delete elimina un tramo de política de cancelación
+func (c *PoliticaCancelacionController) Delete(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	// Eliminar tramo
+	err = c.politicaService.Delete(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al eliminar política de cancelación", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Política de cancelación eliminada exitosamente", nil))
+}
+
+// List lista todos los tramos de políticas de cancelación
+func (c *PoliticaCancelacionController) List(ctx *gin.Context) {
+	politicas, err := c.politicaService.List()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar políticas de cancelación", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Políticas de cancelación listadas exitosamente", politicas))
+}
+
+// ListByTipoTour lista los tramos de un tipo de tour
+func (c *PoliticaCancelacionController) ListByTipoTour(ctx *gin.Context) {
+	// Parsear ID de tipo de tour de la URL
+	idTipoTour, err := strconv.Atoi(ctx.Param("idTipoTour"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de tipo de tour inválido", err))
+		return
+	}
+
+	politicas, err := c.politicaService.ListByTipoTour(idTipoTour)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar políticas de cancelación", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Políticas de cancelación listadas exitosamente", politicas))
+}
+
+// ListBySede lista los tramos de una sede
+func (c *PoliticaCancelacionController) ListBySede(ctx *gin.Context) {
+	// Parsear ID de sede de la URL
+	idSede, err := strconv.Atoi(ctx.Param("idSede"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de sede inválido", err))
+		return
+	}
+
+	politicas, err := c.politicaService.ListBySede(idSede)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar políticas de cancelación", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Políticas de cancelación listadas exitosamente", politicas))
+}
+
+// CotizarCancelacion calcula la devolución que correspondería al cancelar una reserva
+func (c *PoliticaCancelacionController) CotizarCancelacion(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	cotizacion, err := c.politicaService.CotizarCancelacion(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Error al cotizar cancelación", err))
+		return
+	}
+
+	// Verificar acceso según el rol
+	if ctx.GetString("rol") != "ADMIN" && cotizacion.IDSede != ctx.GetInt("sede_id") {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para ver esta reserva", nil))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Cotización de cancelación calculada", cotizacion))
+}
@@ -0,0 +1,174 @@
+package controladores
+
+import (
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReglaPrecioController maneja los endpoints de reglas de precio y precios efectivos
+type ReglaPrecioController struct {
+	reglaPrecioService *servicios.ReglaPrecioService
+}
+
+// NewReglaPrecioController crea una nueva instancia de ReglaPrecioController
+func NewReglaPrecioController(reglaPrecioService *servicios.ReglaPrecioService) *ReglaPrecioController {
+	return &ReglaPrecioController{
+		reglaPrecioService: reglaPrecioService,
+	}
+}
+
+// Create crea una nueva regla de precio
+func (c *ReglaPrecioController) Create(ctx *gin.Context) {
+	var reglaReq entidades.NuevaReglaPrecioRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&reglaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(reglaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Crear regla
+	id, err := c.reglaPrecioService.Create(&reglaReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al crear regla de precio", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Regla de precio creada exitosamente", gin.H{"id": id}))
+}
+
+// GetByID obtiene una regla de precio por su ID
+func (c *ReglaPrecioController) GetByID(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	// Obtener regla
+	regla, err := c.reglaPrecioService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Regla de precio no encontrada", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Regla de precio obtenida", regla))
+}
+
+// Update actualiza una regla de precio
+func (c *ReglaPrecioController) Update(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	var reglaReq entidades.ActualizarReglaPrecioRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&reglaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(reglaReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Actualizar regla
+	err = c.reglaPrecioService.Update(id, &reglaReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al actualizar regla de precio", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Regla de precio actualizada exitosamente", nil))
+}
+
+// Delete elimina una regla de precio
+func (c *ReglaPrecioController) Delete(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	// Eliminar regla
+	err = c.reglaPrecioService.Delete(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al eliminar regla de precio", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Regla de precio eliminada exitosamente", nil))
+}
+
+// List lista todas las reglas de precio en el orden en que se aplican
+func (c *ReglaPrecioController) List(ctx *gin.Context) {
+	reglas, err := c.reglaPrecioService.List()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar reglas de precio", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reglas de precio listadas exitosamente", reglas))
+}
+
+// ListAplicadasByReserva lista las reglas que fijaron el precio de los pasajes de una reserva
+func (c *ReglaPrecioController) ListAplicadasByReserva(ctx *gin.Context) {
+	// Parsear ID de la URL
+	idReserva, err := strconv.Atoi(ctx.Param("idReserva"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de reserva inválido", err))
+		return
+	}
+
+	registros, err := c.reglaPrecioService.ListAplicadasByReserva(idReserva)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar reglas aplicadas a la reserva", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reglas aplicadas listadas exitosamente", registros))
+}
+
+// PreciosInstancia obtiene el precio efectivo de los tipos de pasaje de una instancia de tour
+func (c *ReglaPrecioController) PreciosInstancia(ctx *gin.Context) {
+	// Parsear ID de la URL
+	idInstancia, err := strconv.Atoi(ctx.Param("idInstancia"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de instancia inválido", err))
+		return
+	}
+
+	precios, err := c.reglaPrecioService.PreciosInstancia(idInstancia)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Error al obtener los precios de la instancia", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Precios de la instancia obtenidos exitosamente", precios))
+}
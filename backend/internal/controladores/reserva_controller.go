@@ -1,6 +1,8 @@
 package controladores
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"sistema-toursseft/internal/entidades"
 	"sistema-toursseft/internal/servicios"
@@ -13,18 +15,18 @@ import (
 
 // ReservaController maneja los endpoints de reservas
 type ReservaController struct {
-	reservaService     *servicios.ReservaService
-	mercadoPagoService *servicios.MercadoPagoService
+	reservaService *servicios.ReservaService
+	pasarelas      *servicios.PasarelasPago
 }
 
 // NewReservaController crea una nueva instancia de ReservaController
 func NewReservaController(
 	reservaService *servicios.ReservaService,
-	mercadoPagoService *servicios.MercadoPagoService,
+	pasarelas *servicios.PasarelasPago,
 ) *ReservaController {
 	return &ReservaController{
-		reservaService:     reservaService,
-		mercadoPagoService: mercadoPagoService,
+		reservaService: reservaService,
+		pasarelas:      pasarelas,
 	}
 }
 
@@ -73,6 +75,38 @@ func (c *ReservaController) Create(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Reserva creada exitosamente", reserva))
 }
 
+// Cotizar calcula el total de una reserva con los precios vigentes, detallado por pasaje y paquete,
+// y el descuento del código promocional si se indica
+func (c *ReservaController) Cotizar(ctx *gin.Context) {
+	var cotizacionReq entidades.CotizarReservaRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&cotizacionReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(cotizacionReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Si no se especifica la sede, usar la sede del usuario autenticado
+	if cotizacionReq.IDSede == 0 && ctx.GetInt("sede_id") != 0 {
+		cotizacionReq.IDSede = ctx.GetInt("sede_id")
+	}
+
+	cotizacion, err := c.reservaService.CotizarReserva(&cotizacionReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al cotizar la reserva", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reserva cotizada exitosamente", cotizacion))
+}
+
 // GetByID obtiene una reserva por su ID
 func (c *ReservaController) GetByID(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
@@ -234,6 +268,74 @@ func (c *ReservaController) CambiarEstado(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, utils.SuccessResponse("Estado de la reserva actualizado exitosamente", reservaActualizada))
 }
 
+// Reprogramar mueve una reserva a otra instancia del mismo tipo de tour
+func (c *ReservaController) Reprogramar(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	reserva, err := c.reservaService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Reserva no encontrada", err))
+		return
+	}
+
+	if !c.tieneAccesoAReserva(ctx, reserva) {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para reprogramar esta reserva", nil))
+		return
+	}
+
+	var reprogramarReq entidades.ReprogramarReservaRequest
+	if err := ctx.ShouldBindJSON(&reprogramarReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	if err := utils.ValidateStruct(reprogramarReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	idUsuario := ctx.GetInt("user_id")
+	resultado, err := c.reservaService.Reprogramar(id, &reprogramarReq, ctx.GetString("rol"), &idUsuario)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al reprogramar la reserva", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reserva reprogramada exitosamente", resultado))
+}
+
+// ListReprogramaciones obtiene el historial de reprogramaciones de una reserva
+func (c *ReservaController) ListReprogramaciones(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	reserva, err := c.reservaService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Reserva no encontrada", err))
+		return
+	}
+
+	if !c.tieneAccesoAReserva(ctx, reserva) {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene permiso para ver esta reserva", nil))
+		return
+	}
+
+	reprogramaciones, err := c.reservaService.ListReprogramaciones(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar reprogramaciones", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reprogramaciones listadas exitosamente", reprogramaciones))
+}
+
 // Delete realiza una eliminación lógica de una reserva
 func (c *ReservaController) Delete(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
@@ -450,6 +552,47 @@ func (c *ReservaController) ListMyReservas(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, utils.SuccessResponse("Mis reservas listadas exitosamente", reservas))
 }
 
+// ListSaldosPendientes lista las reservas con saldo por cobrar antes de su salida
+// Acepta ?dias= (por defecto 7) y, para ADMIN, ?id_sede=; los demás roles ven solo su sede
+func (c *ReservaController) ListSaldosPendientes(ctx *gin.Context) {
+	dias := servicios.DiasSaldosPendientesPorDefecto
+	if diasStr := ctx.Query("dias"); diasStr != "" {
+		var err error
+		dias, err = strconv.Atoi(diasStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Cantidad de días inválida", err))
+			return
+		}
+	}
+
+	var idSede *int
+	if ctx.GetString("rol") == "ADMIN" {
+		if sedeStr := ctx.Query("id_sede"); sedeStr != "" {
+			sedeID, err := strconv.Atoi(sedeStr)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de sede inválido", err))
+				return
+			}
+			idSede = &sedeID
+		}
+	} else {
+		sedeID := ctx.GetInt("sede_id")
+		if sedeID == 0 {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Usuario no tiene sede asignada", nil))
+			return
+		}
+		idSede = &sedeID
+	}
+
+	saldos, err := c.reservaService.ListSaldosPendientes(idSede, dias)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al listar saldos pendientes", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Saldos pendientes listados exitosamente", saldos))
+}
+
 // VerificarDisponibilidadInstancia verifica si hay suficiente cupo para una cantidad de pasajeros
 func (c *ReservaController) VerificarDisponibilidadInstancia(ctx *gin.Context) {
 	idInstancia, err := strconv.Atoi(ctx.Param("idInstancia"))
@@ -476,12 +619,20 @@ func (c *ReservaController) VerificarDisponibilidadInstancia(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, utils.SuccessResponse("Verificación de disponibilidad exitosa", map[string]interface{}{
+	respuesta := map[string]interface{}{
 		"disponible": disponible,
-	}))
+	}
+
+	// Incluir el precio efectivo de los pasajes; la disponibilidad se responde aunque no se puedan calcular
+	if precios, err := c.reservaService.PreciosInstancia(idInstancia); err == nil {
+		respuesta["precios"] = precios.Precios
+		respuesta["ocupacion"] = precios.Ocupacion
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Verificación de disponibilidad exitosa", respuesta))
 }
 
-// ReservarConMercadoPago crea una reserva y genera una preferencia de pago
+// ReservarConMercadoPago crea una reserva y genera su cobro en la pasarela elegida o en la predeterminada
 func (c *ReservaController) ReservarConMercadoPago(ctx *gin.Context) {
 	var request entidades.ReservaMercadoPagoRequest
 
@@ -501,7 +652,17 @@ func (c *ReservaController) ReservarConMercadoPago(ctx *gin.Context) {
 		frontendURL = "https://tours-peru.com" // URL predeterminada si no se proporciona Origin
 	}
 
-	response, err := c.reservaService.ReservarConMercadoPago(&request, c.mercadoPagoService, frontendURL)
+	pasarela := c.pasarelas.Predeterminada()
+	if request.Pasarela != "" {
+		var err error
+		pasarela, err = c.pasarelas.Obtener(request.Pasarela)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Pasarela de pago no disponible", err))
+			return
+		}
+	}
+
+	response, err := c.reservaService.ReservarConPasarela(&request, pasarela, frontendURL)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al crear reserva con Mercado Pago", err))
 		return
@@ -513,9 +674,11 @@ func (c *ReservaController) ReservarConMercadoPago(ctx *gin.Context) {
 // ConfirmarPagoReserva confirma una reserva después de recibir el pago
 func (c *ReservaController) ConfirmarPagoReserva(ctx *gin.Context) {
 	var request struct {
-		IDReserva     int     `json:"id_reserva" validate:"required"`
-		IDTransaccion string  `json:"id_transaccion" validate:"required"`
-		Monto         float64 `json:"monto" validate:"required,min=0"`
+		IDReserva     int              `json:"id_reserva" validate:"required"`
+		IDTransaccion string           `json:"id_transaccion" validate:"required"`
+		Monto         entidades.Dinero `json:"monto" validate:"required,min=0"`
+		Moneda        string           `json:"moneda" validate:"omitempty,oneof=PEN USD"`                    // Por defecto PEN
+		Pasarela      string           `json:"pasarela" validate:"omitempty,oneof=MERCADO_PAGO IZIPAY FAKE"` // Por defecto MERCADO_PAGO
 	}
 
 	if err := ctx.ShouldBindJSON(&request); err != nil {
@@ -528,7 +691,11 @@ func (c *ReservaController) ConfirmarPagoReserva(ctx *gin.Context) {
 		return
 	}
 
-	err := c.reservaService.ConfirmarPagoReserva(request.IDReserva, request.IDTransaccion, request.Monto)
+	if request.Pasarela == "" {
+		request.Pasarela = entidades.PasarelaMercadoPago
+	}
+
+	_, err := c.reservaService.ConfirmarPagoReserva(request.IDReserva, request.Pasarela, request.IDTransaccion, request.Monto.EnMoneda(request.Moneda))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al confirmar pago de la reserva", err))
 		return
@@ -544,56 +711,105 @@ func (c *ReservaController) ConfirmarPagoReserva(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, utils.SuccessResponse("Pago confirmado exitosamente", reserva))
 }
 
+// maxCuerpoWebhook es el tamaño máximo aceptado para el cuerpo de una notificación de pasarela
+const maxCuerpoWebhook = 64 << 10
+
 // WebhookMercadoPago procesa las notificaciones de webhook de Mercado Pago
 func (c *ReservaController) WebhookMercadoPago(ctx *gin.Context) {
-	// Los webhooks de Mercado Pago pueden ser notificaciones de pagos u otros eventos
-	topic := ctx.Query("topic")
-	id := ctx.Query("id")
+	c.procesarWebhook(ctx, entidades.PasarelaMercadoPago)
+}
 
-	if topic == "" || id == "" {
-		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Parámetros inválidos", nil))
+// WebhookPasarela procesa las notificaciones de webhook de la pasarela indicada en la URL
+func (c *ReservaController) WebhookPasarela(ctx *gin.Context) {
+	c.procesarWebhook(ctx, ctx.Param("pasarela"))
+}
+
+// procesarWebhook verifica una notificación de una pasarela y aplica el pago sobre su reserva
+func (c *ReservaController) procesarWebhook(ctx *gin.Context, nombrePasarela string) {
+	pasarela, err := c.pasarelas.Obtener(nombrePasarela)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Pasarela de pago no disponible", err))
 		return
 	}
 
-	// Si es una notificación de pago, procesar el pago
-	if topic == "payment" {
-		paymentInfo, err := c.mercadoPagoService.GetPaymentInfo(id)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al obtener información del pago", err))
-			return
-		}
-
-		// Extraer ID de reserva del external_reference (formato "RESERVA-12345")
-		idReservaStr := ""
-		if len(paymentInfo.ExternalReference) > 8 {
-			idReservaStr = paymentInfo.ExternalReference[8:]
-		}
-
-		if idReservaStr == "" {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Referencia externa inválida", nil))
+	// El endpoint no requiere autenticación: se limita el cuerpo antes de leerlo
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxCuerpoWebhook)
+	cuerpo, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		var errTamano *http.MaxBytesError
+		if errors.As(err, &errTamano) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, utils.ErrorResponse("Notificación demasiado grande", err))
 			return
 		}
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
 
-		idReserva, err := strconv.Atoi(idReservaStr)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de reserva inválido", err))
+	// Verificar que la notificación fue firmada por la pasarela
+	evento, err := pasarela.VerificarWebhook(&entidades.NotificacionPasarela{
+		Cabeceras:  ctx.Request.Header,
+		Parametros: ctx.Request.URL.Query(),
+		Cuerpo:     cuerpo,
+	})
+	if err != nil {
+		if errors.Is(err, servicios.ErrNotificacionIncompleta) {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Parámetros inválidos", err))
 			return
 		}
+		ctx.JSON(http.StatusUnauthorized, utils.ErrorResponse("Notificación no autorizada", err))
+		return
+	}
 
-		// Si el pago está aprobado, confirmar la reserva
-		if paymentInfo.Status == "approved" {
-			err = c.reservaService.ConfirmarPagoReserva(idReserva, id, paymentInfo.TransactionAmount)
+	// Si es una notificación de pago, procesar el pago
+	if evento.Tipo == "payment" {
+		pago := evento.Pago
+		if pago == nil {
+			pago, err = pasarela.ObtenerPago(evento.IDPago)
 			if err != nil {
-				ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al confirmar reserva", err))
+				ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al obtener información del pago", err))
 				return
 			}
 		}
+
+		// Aplicar el pago sobre la reserva (los reintentos ya procesados no tienen efecto)
+		err = c.reservaService.ProcesarNotificacionPago(pago, evento.IDNotificacion)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al procesar el pago de la reserva", err))
+			return
+		}
 	}
 
-	// Siempre responder con éxito para que Mercado Pago no reintente
+	// Siempre responder con éxito para que la pasarela no reintente
 	ctx.JSON(http.StatusOK, utils.SuccessResponse("Webhook procesado exitosamente", nil))
 }
 
+// SimularPagoPasarela hace las veces de la página de pago de la pasarela simulada: paga el cobro indicado
+// y aplica el pago sobre la reserva como lo haría su notificación. Acepta ?estado=APROBADO|RECHAZADO|PENDIENTE
+// Solo responde si la pasarela simulada está configurada (PASARELA_PAGO=FAKE)
+func (c *ReservaController) SimularPagoPasarela(ctx *gin.Context) {
+	pasarela, err := c.pasarelas.Obtener(entidades.PasarelaFake)
+	fake, ok := pasarela.(*servicios.PasarelaFake)
+	if err != nil || !ok {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Pasarela simulada no habilitada", err))
+		return
+	}
+
+	estado := ctx.DefaultQuery("estado", entidades.EstadoPasarelaAprobado)
+	pago, err := fake.SimularPago(ctx.Param("checkout"), estado)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al simular el pago", err))
+		return
+	}
+
+	err = c.reservaService.ProcesarNotificacionPago(pago, "SIMULADO-"+pago.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al procesar el pago de la reserva", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Pago simulado exitosamente", pago))
+}
+
 // tieneAccesoAReserva verifica si el usuario tiene acceso a una reserva específica
 func (c *ReservaController) tieneAccesoAReserva(ctx *gin.Context, reserva *entidades.Reserva) bool {
 	// Los administradores tienen acceso a todas las reservas sin importar la sede
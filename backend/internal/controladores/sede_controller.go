@@ -103,6 +103,74 @@ func (c *SedeController) Update(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, utils.SuccessResponse("Sede actualizada exitosamente", nil))
 }
 
+// UpdateRetencionReserva configura el tiempo de retención de cupos de reservas web de una sede
+func (c *SedeController) UpdateRetencionReserva(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	var request entidades.ActualizarRetencionReservaRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Actualizar retención
+	err = c.sedeService.UpdateRetencionReserva(id, &request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al actualizar retención de reservas", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Retención de reservas actualizada exitosamente", nil))
+}
+
+// UpdateAdelantoReserva configura el porcentaje de adelanto de las reservas de una sede
+func (c *SedeController) UpdateAdelantoReserva(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	var request entidades.ActualizarAdelantoReservaRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Actualizar adelanto
+	err = c.sedeService.UpdateAdelantoReserva(id, &request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al actualizar adelanto de reservas", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Adelanto de reservas actualizado exitosamente", nil))
+}
+
 // Delete elimina una sede (borrado lógico)
 func (c *SedeController) Delete(ctx *gin.Context) {
 	// Parsear ID de la URL
@@ -0,0 +1,108 @@
+package controladores
+
+import (
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SerieComprobanteController maneja los endpoints de series de comprobantes
+type SerieComprobanteController struct {
+	serieService *servicios.SerieComprobanteService
+}
+
+// NewSerieComprobanteController crea una nueva instancia de SerieComprobanteController
+func NewSerieComprobanteController(serieService *servicios.SerieComprobanteService) *SerieComprobanteController {
+	return &SerieComprobanteController{
+		serieService: serieService,
+	}
+}
+
+// Create registra una nueva serie
+func (c *SerieComprobanteController) Create(ctx *gin.Context) {
+	var serieReq entidades.NuevaSerieComprobanteRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&serieReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(serieReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	id, err := c.serieService.Create(&serieReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al registrar la serie", err))
+		return
+	}
+
+	serie, err := c.serieService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al obtener la serie registrada", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Serie registrada exitosamente", serie))
+}
+
+// CambiarEstado activa o desactiva una serie
+func (c *SerieComprobanteController) CambiarEstado(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	var estadoReq entidades.CambiarEstadoSerieRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&estadoReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(estadoReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	if err := c.serieService.CambiarEstado(id, *estadoReq.Activo); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al cambiar el estado de la serie", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Estado de la serie actualizado exitosamente", nil))
+}
+
+// ListUso lista las series con los comprobantes emitidos y anulados de cada una
+// Se puede filtrar por sede con el parámetro id_sede
+func (c *SerieComprobanteController) ListUso(ctx *gin.Context) {
+	idSede := 0
+	if valor := ctx.Query("id_sede"); valor != "" {
+		var err error
+		idSede, err = strconv.Atoi(valor)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de sede inválido", err))
+			return
+		}
+	}
+
+	series, err := c.serieService.ListUso(idSede)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al listar las series", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Series de comprobantes", series))
+}
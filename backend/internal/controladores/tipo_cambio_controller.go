@@ -0,0 +1,206 @@
+package controladores
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TipoCambioController maneja los endpoints de tipos de cambio y del reporte de ingresos por moneda
+type TipoCambioController struct {
+	tipoCambioService *servicios.TipoCambioService
+}
+
+// NewTipoCambioController crea una nueva instancia de TipoCambioController
+func NewTipoCambioController(tipoCambioService *servicios.TipoCambioService) *TipoCambioController {
+	return &TipoCambioController{
+		tipoCambioService: tipoCambioService,
+	}
+}
+
+// parsearRangoFechas lee los parámetros fecha_inicio y fecha_fin (YYYY-MM-DD); los ausentes toman
+// los valores por defecto indicados
+func parsearRangoFechas(ctx *gin.Context, inicioPorDefecto time.Time, finPorDefecto time.Time) (time.Time, time.Time, error) {
+	fechaInicio, fechaFin := inicioPorDefecto, finPorDefecto
+
+	if valor := ctx.Query("fecha_inicio"); valor != "" {
+		fecha, err := time.Parse("2006-01-02", valor)
+		if err != nil {
+			return fechaInicio, fechaFin, errors.New("formato de fecha_inicio inválido, debe ser YYYY-MM-DD")
+		}
+		fechaInicio = fecha
+	}
+
+	if valor := ctx.Query("fecha_fin"); valor != "" {
+		fecha, err := time.Parse("2006-01-02", valor)
+		if err != nil {
+			return fechaInicio, fechaFin, errors.New("formato de fecha_fin inválido, debe ser YYYY-MM-DD")
+		}
+		fechaFin = fecha
+	}
+
+	return fechaInicio, fechaFin, nil
+}
+
+// Registrar guarda manualmente el tipo de cambio de un día
+func (c *TipoCambioController) Registrar(ctx *gin.Context) {
+	var tipoCambioReq entidades.NuevoTipoCambioRequest
+
+	// Parsear request
+	if err := ctx.ShouldBindJSON(&tipoCambioReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	// Validar datos
+	if err := utils.ValidateStruct(tipoCambioReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Registrar tipo de cambio
+	id, err := c.tipoCambioService.Registrar(&tipoCambioReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al registrar tipo de cambio", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusCreated, utils.SuccessResponse("Tipo de cambio registrado exitosamente", gin.H{"id": id}))
+}
+
+// Importar registra los tipos de cambio de un archivo CSV (fecha, moneda, valor)
+// El archivo se envía en el campo "archivo" de un formulario multipart o como cuerpo de la petición
+func (c *TipoCambioController) Importar(ctx *gin.Context) {
+	var archivo io.Reader
+
+	if encabezado, err := ctx.FormFile("archivo"); err == nil {
+		abierto, err := encabezado.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("No se pudo leer el archivo", err))
+			return
+		}
+		defer abierto.Close()
+		archivo = abierto
+	} else {
+		contenido, err := io.ReadAll(ctx.Request.Body)
+		if err != nil || len(bytes.TrimSpace(contenido)) == 0 {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Debe enviar un archivo CSV con los tipos de cambio", err))
+			return
+		}
+		archivo = bytes.NewReader(contenido)
+	}
+
+	resultado, err := c.tipoCambioService.Importar(archivo)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al importar tipos de cambio", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Tipos de cambio importados", resultado))
+}
+
+// List lista los tipos de cambio de una moneda; por defecto los de los últimos 30 días
+func (c *TipoCambioController) List(ctx *gin.Context) {
+	moneda := ctx.DefaultQuery("moneda", entidades.MonedaDolares)
+
+	hoy := time.Now()
+	fechaInicio, fechaFin, err := parsearRangoFechas(ctx, hoy.AddDate(0, 0, -30), hoy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error(), nil))
+		return
+	}
+
+	tiposCambio, err := c.tipoCambioService.List(moneda, fechaInicio, fechaFin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al listar tipos de cambio", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Tipos de cambio listados exitosamente", tiposCambio))
+}
+
+// GetVigente obtiene el tipo de cambio aplicable a una moneda en una fecha (por defecto hoy)
+func (c *TipoCambioController) GetVigente(ctx *gin.Context) {
+	moneda := ctx.DefaultQuery("moneda", entidades.MonedaDolares)
+
+	fecha := time.Now()
+	if valor := ctx.Query("fecha"); valor != "" {
+		fechaParseada, err := time.Parse("2006-01-02", valor)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Formato de fecha inválido, debe ser YYYY-MM-DD", err))
+			return
+		}
+		fecha = fechaParseada
+	}
+
+	tipoCambio, err := c.tipoCambioService.GetVigente(moneda, fecha)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Tipo de cambio no encontrado", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Tipo de cambio obtenido", tipoCambio))
+}
+
+// Delete elimina un tipo de cambio
+func (c *TipoCambioController) Delete(ctx *gin.Context) {
+	// Parsear ID de la URL
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID inválido", err))
+		return
+	}
+
+	// Eliminar tipo de cambio
+	err = c.tipoCambioService.Delete(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al eliminar tipo de cambio", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Tipo de cambio eliminado exitosamente", nil))
+}
+
+// ReporteIngresosMoneda consolida los ingresos por moneda de un periodo (por defecto el mes en curso),
+// opcionalmente de una sede
+func (c *TipoCambioController) ReporteIngresosMoneda(ctx *gin.Context) {
+	hoy := time.Now()
+	inicioMes := time.Date(hoy.Year(), hoy.Month(), 1, 0, 0, 0, 0, hoy.Location())
+	fechaInicio, fechaFin, err := parsearRangoFechas(ctx, inicioMes, hoy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error(), nil))
+		return
+	}
+
+	var idSede *int
+	if valor := ctx.Query("id_sede"); valor != "" {
+		id, err := strconv.Atoi(valor)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("ID de sede inválido", err))
+			return
+		}
+		idSede = &id
+	}
+
+	reporte, err := c.tipoCambioService.ReporteIngresosMoneda(fechaInicio, fechaFin, idSede)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al generar el reporte de ingresos por moneda", err))
+		return
+	}
+
+	// Respuesta exitosa
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reporte de ingresos por moneda generado", reporte))
+}
@@ -0,0 +1,209 @@
+package entidades
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Estados de una sesión de caja
+const (
+	EstadoCajaAbierta = "ABIERTA"
+	EstadoCajaCerrada = "CERRADA"
+)
+
+// CajaSesion representa el turno de caja de un usuario, desde su apertura con un fondo inicial hasta su cierre
+type CajaSesion struct {
+	ID                    int        `json:"id_caja_sesion" db:"id_caja_sesion"`
+	IDUsuario             int        `json:"id_usuario" db:"id_usuario"`
+	IDSede                int        `json:"id_sede" db:"id_sede"`
+	IDMetodoEfectivo      int        `json:"id_metodo_efectivo" db:"id_metodo_efectivo"` // Método de pago en el que está el fondo inicial
+	MontoInicial          Dinero     `json:"monto_inicial" db:"monto_inicial"`           // Fondo inicial en soles
+	FechaApertura         time.Time  `json:"fecha_apertura" db:"fecha_apertura"`
+	FechaCierre           *time.Time `json:"fecha_cierre,omitempty" db:"fecha_cierre"`
+	Estado                string     `json:"estado" db:"estado"` // ABIERTA, CERRADA
+	ObservacionesApertura string     `json:"observaciones_apertura,omitempty" db:"observaciones_apertura"`
+	ObservacionesCierre   string     `json:"observaciones_cierre,omitempty" db:"observaciones_cierre"`
+	// Campos adicionales para mostrar información relacionada
+	NombreUsuario        string               `json:"nombre_usuario,omitempty" db:"-"`
+	NombreSede           string               `json:"nombre_sede,omitempty" db:"-"`
+	NombreMetodoEfectivo string               `json:"nombre_metodo_efectivo,omitempty" db:"-"`
+	Detalle              []*DetalleCierreCaja `json:"detalle,omitempty" db:"-"` // Cuadre del cierre, o lo esperado si sigue abierta
+}
+
+// AbrirCajaRequest representa los datos para abrir la caja del usuario autenticado en su sede
+type AbrirCajaRequest struct {
+	IDSede           int    `json:"id_sede"` // Solo para usuarios sin sede asignada
+	IDMetodoEfectivo int    `json:"id_metodo_efectivo" validate:"required"`
+	MontoInicial     Dinero `json:"monto_inicial" validate:"min=0"`
+	Observaciones    string `json:"observaciones"`
+}
+
+// ConteoCajaRequest representa lo contado al cerrar la caja para un método de pago y moneda
+type ConteoCajaRequest struct {
+	IDMetodoPago int    `json:"id_metodo_pago" validate:"required"`
+	Moneda       string `json:"moneda" validate:"omitempty,oneof=PEN USD"` // Por defecto PEN
+	MontoContado Dinero `json:"monto_contado" validate:"min=0"`
+}
+
+// CerrarCajaRequest representa los montos contados al cerrar la caja
+// Los métodos de pago esperados que no se informen se consideran contados en cero
+type CerrarCajaRequest struct {
+	Conteos       []ConteoCajaRequest `json:"conteos" validate:"dive"`
+	Observaciones string              `json:"observaciones"`
+}
+
+// MovimientoCaja agrupa los pagos procesados de una caja por método de pago y moneda
+type MovimientoCaja struct {
+	IDMetodoPago  int
+	Moneda        string
+	CantidadPagos int
+	Total         Dinero // En la moneda de los pagos
+}
+
+// DetalleCierreCaja representa el cuadre de un método de pago y moneda al cerrar la caja
+type DetalleCierreCaja struct {
+	ID               int    `json:"id_caja_cierre_detalle,omitempty" db:"id_caja_cierre_detalle"`
+	IDCajaSesion     int    `json:"id_caja_sesion" db:"id_caja_sesion"`
+	IDMetodoPago     int    `json:"id_metodo_pago" db:"id_metodo_pago"`
+	Moneda           string `json:"moneda" db:"moneda"`
+	CantidadPagos    int    `json:"cantidad_pagos" db:"cantidad_pagos"`
+	MontoEsperado    Dinero `json:"monto_esperado" db:"monto_esperado"`
+	MontoContado     Dinero `json:"monto_contado" db:"monto_contado"`
+	Diferencia       Dinero `json:"diferencia" db:"diferencia"` // Contado menos esperado: negativo es faltante
+	NombreMetodoPago string `json:"nombre_metodo_pago,omitempty" db:"-"`
+}
+
+// CalcularCierreCaja cuadra lo contado contra lo esperado por método de pago y moneda
+// Lo esperado son los pagos procesados de la caja más el fondo inicial, que se espera en soles en el
+// método de efectivo. Los conteos sin movimientos esperados se registran como sobrantes
+func CalcularCierreCaja(movimientos []MovimientoCaja, montoInicial Dinero, idMetodoEfectivo int, conteos []ConteoCajaRequest) ([]*DetalleCierreCaja, error) {
+	type clave struct {
+		idMetodoPago int
+		moneda       string
+	}
+
+	detalles := map[clave]*DetalleCierreCaja{}
+	obtener := func(idMetodoPago int, moneda string) *DetalleCierreCaja {
+		if moneda == "" {
+			moneda = MonedaPorDefecto
+		}
+		k := clave{idMetodoPago, moneda}
+		if detalles[k] == nil {
+			detalles[k] = &DetalleCierreCaja{
+				IDMetodoPago:  idMetodoPago,
+				Moneda:        moneda,
+				MontoEsperado: NuevoDinero(0, moneda),
+				MontoContado:  NuevoDinero(0, moneda),
+			}
+		}
+		return detalles[k]
+	}
+
+	// El fondo inicial se espera aunque no haya cobros en efectivo
+	efectivo := obtener(idMetodoEfectivo, MonedaPorDefecto)
+	efectivo.MontoEsperado = efectivo.MontoEsperado.Sumar(montoInicial.EnMoneda(MonedaPorDefecto))
+
+	for _, movimiento := range movimientos {
+		detalle := obtener(movimiento.IDMetodoPago, movimiento.Moneda)
+		detalle.CantidadPagos += movimiento.CantidadPagos
+		detalle.MontoEsperado = detalle.MontoEsperado.Sumar(movimiento.Total.EnMoneda(detalle.Moneda))
+	}
+
+	contados := map[clave]bool{}
+	for _, conteo := range conteos {
+		detalle := obtener(conteo.IDMetodoPago, conteo.Moneda)
+		k := clave{detalle.IDMetodoPago, detalle.Moneda}
+		if contados[k] {
+			return nil, errors.New("hay más de un conteo para el mismo método de pago y moneda")
+		}
+		contados[k] = true
+		detalle.MontoContado = conteo.MontoContado.EnMoneda(detalle.Moneda)
+	}
+
+	resultado := make([]*DetalleCierreCaja, 0, len(detalles))
+	for _, detalle := range detalles {
+		detalle.Diferencia = detalle.MontoContado.Restar(detalle.MontoEsperado)
+		resultado = append(resultado, detalle)
+	}
+
+	sort.Slice(resultado, func(i, j int) bool {
+		if resultado[i].IDMetodoPago != resultado[j].IDMetodoPago {
+			return resultado[i].IDMetodoPago < resultado[j].IDMetodoPago
+		}
+		return resultado[i].Moneda < resultado[j].Moneda // PEN antes que USD
+	})
+
+	return resultado, nil
+}
+
+// TotalCajaMoneda suma el cuadre de varias cajas en una moneda
+type TotalCajaMoneda struct {
+	Moneda     string `json:"moneda"`
+	Esperado   Dinero `json:"esperado"`
+	Contado    Dinero `json:"contado"`
+	Diferencia Dinero `json:"diferencia"`
+}
+
+// CierresCajaDia agrupa las cajas cerradas de una sede en un día
+type CierresCajaDia struct {
+	Fecha      time.Time          `json:"fecha"`
+	IDSede     int                `json:"id_sede"`
+	NombreSede string             `json:"nombre_sede"`
+	Cierres    []*CajaSesion      `json:"cierres"`
+	Totales    []*TotalCajaMoneda `json:"totales"`
+}
+
+// ReporteCierresCaja presenta los cierres de caja de un periodo por sede y día
+type ReporteCierresCaja struct {
+	FechaInicio time.Time         `json:"fecha_inicio"`
+	FechaFin    time.Time         `json:"fecha_fin"`
+	IDSede      *int              `json:"id_sede,omitempty"`
+	Dias        []*CierresCajaDia `json:"dias"`
+}
+
+// TotalizarCierresCaja suma por moneda el cuadre de las cajas indicadas
+func TotalizarCierresCaja(cierres []*CajaSesion) []*TotalCajaMoneda {
+	totales := []*TotalCajaMoneda{}
+	porMoneda := map[string]*TotalCajaMoneda{}
+	for _, cierre := range cierres {
+		for _, detalle := range cierre.Detalle {
+			total := porMoneda[detalle.Moneda]
+			if total == nil {
+				cero := NuevoDinero(0, detalle.Moneda)
+				total = &TotalCajaMoneda{Moneda: detalle.Moneda, Esperado: cero, Contado: cero, Diferencia: cero}
+				porMoneda[detalle.Moneda] = total
+				totales = append(totales, total)
+			}
+			total.Esperado = total.Esperado.Sumar(detalle.MontoEsperado)
+			total.Contado = total.Contado.Sumar(detalle.MontoContado)
+			total.Diferencia = total.Diferencia.Sumar(detalle.Diferencia)
+		}
+	}
+
+	sort.Slice(totales, func(i, j int) bool { return totales[i].Moneda < totales[j].Moneda })
+	return totales
+}
+
+// AgruparCierresCaja agrupa por sede y día de cierre las cajas cerradas, ordenadas por sede y fecha de cierre
+func AgruparCierresCaja(cierres []*CajaSesion) []*CierresCajaDia {
+	dias := []*CierresCajaDia{}
+	var actual *CierresCajaDia
+	for _, cierre := range cierres {
+		if cierre.FechaCierre == nil {
+			continue
+		}
+		fecha := time.Date(cierre.FechaCierre.Year(), cierre.FechaCierre.Month(), cierre.FechaCierre.Day(), 0, 0, 0, 0, cierre.FechaCierre.Location())
+		if actual == nil || actual.IDSede != cierre.IDSede || !actual.Fecha.Equal(fecha) {
+			actual = &CierresCajaDia{Fecha: fecha, IDSede: cierre.IDSede, NombreSede: cierre.NombreSede}
+			dias = append(dias, actual)
+		}
+		actual.Cierres = append(actual.Cierres, cierre)
+	}
+
+	for _, dia := range dias {
+		dia.Totales = TotalizarCierresCaja(dia.Cierres)
+	}
+
+	return dias
+}
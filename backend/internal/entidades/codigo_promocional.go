@@ -0,0 +1,115 @@
+package entidades
+
+import "time"
+
+// CodigoPromocional representa un código de descuento de campaña
+// Las restricciones nulas no limitan el uso del código
+type CodigoPromocional struct {
+	ID             int       `json:"id_codigo_promocional" db:"id_codigo_promocional"`
+	Codigo         string    `json:"codigo" db:"codigo"`
+	Descripcion    string    `json:"descripcion" db:"descripcion"`
+	TipoDescuento  string    `json:"tipo_descuento" db:"tipo_descuento"` // PORCENTAJE, MONTO_FIJO
+	Porcentaje     float64   `json:"porcentaje" db:"porcentaje"`
+	MontoDescuento Dinero    `json:"monto_descuento" db:"monto_descuento"`
+	FechaInicio    time.Time `json:"fecha_inicio" db:"fecha_inicio"`
+	FechaFin       time.Time `json:"fecha_fin" db:"fecha_fin"`
+	UsosMaximos    *int      `json:"usos_maximos,omitempty" db:"usos_maximos"`
+	UsosPorCliente *int      `json:"usos_por_cliente,omitempty" db:"usos_por_cliente"`
+	IDTipoTour     *int      `json:"id_tipo_tour,omitempty" db:"id_tipo_tour"`
+	IDSede         *int      `json:"id_sede,omitempty" db:"id_sede"`
+	IDCanal        *int      `json:"id_canal,omitempty" db:"id_canal"`
+	IDTipoPasaje   *int      `json:"id_tipo_pasaje,omitempty" db:"id_tipo_pasaje"`
+	Activo         bool      `json:"activo" db:"activo"`
+	Eliminado      bool      `json:"eliminado" db:"eliminado"`
+	FechaRegistro  time.Time `json:"fecha_registro" db:"fecha_registro"`
+
+	// Campos adicionales para mostrar información relacionada
+	UsosRegistrados int  `json:"usos_registrados" db:"-"`
+	Vigente         bool `json:"vigente" db:"-"` // La fecha actual de la base de datos está dentro de la vigencia
+}
+
+// NuevoCodigoPromocionalRequest representa los datos para crear un código promocional
+type NuevoCodigoPromocionalRequest struct {
+	Codigo         string    `json:"codigo" validate:"required,min=3,max=40"`
+	Descripcion    string    `json:"descripcion"`
+	TipoDescuento  string    `json:"tipo_descuento" validate:"required,oneof=PORCENTAJE MONTO_FIJO"`
+	Porcentaje     float64   `json:"porcentaje" validate:"required_if=TipoDescuento PORCENTAJE,min=0,max=100"`
+	MontoDescuento Dinero    `json:"monto_descuento" validate:"required_if=TipoDescuento MONTO_FIJO,min=0"`
+	FechaInicio    time.Time `json:"fecha_inicio" validate:"required"`
+	FechaFin       time.Time `json:"fecha_fin" validate:"required,gtfield=FechaInicio"`
+	UsosMaximos    *int      `json:"usos_maximos" validate:"omitempty,min=1"`
+	UsosPorCliente *int      `json:"usos_por_cliente" validate:"omitempty,min=1"`
+	IDTipoTour     *int      `json:"id_tipo_tour"`
+	IDSede         *int      `json:"id_sede"`
+	IDCanal        *int      `json:"id_canal"`
+	IDTipoPasaje   *int      `json:"id_tipo_pasaje"`
+}
+
+// ActualizarCodigoPromocionalRequest representa los datos para actualizar un código promocional
+type ActualizarCodigoPromocionalRequest struct {
+	Codigo         string    `json:"codigo" validate:"required,min=3,max=40"`
+	Descripcion    string    `json:"descripcion"`
+	TipoDescuento  string    `json:"tipo_descuento" validate:"required,oneof=PORCENTAJE MONTO_FIJO"`
+	Porcentaje     float64   `json:"porcentaje" validate:"required_if=TipoDescuento PORCENTAJE,min=0,max=100"`
+	MontoDescuento Dinero    `json:"monto_descuento" validate:"required_if=TipoDescuento MONTO_FIJO,min=0"`
+	FechaInicio    time.Time `json:"fecha_inicio" validate:"required"`
+	FechaFin       time.Time `json:"fecha_fin" validate:"required,gtfield=FechaInicio"`
+	UsosMaximos    *int      `json:"usos_maximos" validate:"omitempty,min=1"`
+	UsosPorCliente *int      `json:"usos_por_cliente" validate:"omitempty,min=1"`
+	IDTipoTour     *int      `json:"id_tipo_tour"`
+	IDSede         *int      `json:"id_sede"`
+	IDCanal        *int      `json:"id_canal"`
+	IDTipoPasaje   *int      `json:"id_tipo_pasaje"`
+	Activo         bool      `json:"activo"`
+}
+
+// ContextoPromocion reúne los datos de la reserva contra los que se validan las restricciones de un código
+type ContextoPromocion struct {
+	IDTipoTour int
+	IDSede     int
+	IDCanal    int
+	IDCliente  int // Cero si aún no se conoce al cliente (cotización pública)
+}
+
+// UsoPromocion contiene los canjes vigentes de un código, en total y del cliente que lo quiere usar
+type UsoPromocion struct {
+	Totales int
+	Cliente int
+}
+
+// AplicacionPromocion representa el descuento de un código aplicado a una reserva antes de guardarla
+type AplicacionPromocion struct {
+	IDCodigoPromocional int    `json:"id_codigo_promocional"`
+	Codigo              string `json:"codigo"`
+	TotalAntes          Dinero `json:"total_antes"`
+	Descuento           Dinero `json:"descuento"`
+}
+
+// CanjePromocion representa el uso de un código promocional en una reserva
+type CanjePromocion struct {
+	ID                  int       `json:"id_canje" db:"id_canje"`
+	IDCodigoPromocional int       `json:"id_codigo_promocional" db:"id_codigo_promocional"`
+	IDReserva           int       `json:"id_reserva" db:"id_reserva"`
+	IDCliente           int       `json:"id_cliente" db:"id_cliente"`
+	TotalAntes          Dinero    `json:"total_antes" db:"total_antes"`
+	MontoDescuento      Dinero    `json:"monto_descuento" db:"monto_descuento"`
+	FechaCanje          time.Time `json:"fecha_canje" db:"fecha_canje"`
+
+	// Campos adicionales para mostrar información relacionada
+	Codigo        string `json:"codigo,omitempty" db:"-"`
+	NombreCliente string `json:"nombre_cliente,omitempty" db:"-"`
+	EstadoReserva string `json:"estado_reserva,omitempty" db:"-"`
+}
+
+// ReportePromocion resume los canjes de un código promocional
+// Los canjes de reservas canceladas o expiradas se cuentan aparte y no consumen usos
+type ReportePromocion struct {
+	IDCodigoPromocional int    `json:"id_codigo_promocional"`
+	Codigo              string `json:"codigo"`
+	Canjes              int    `json:"canjes"`
+	CanjesAnulados      int    `json:"canjes_anulados"`
+	ClientesDistintos   int    `json:"clientes_distintos"`
+	TotalAntes          Dinero `json:"total_antes"`
+	TotalDescuento      Dinero `json:"total_descuento"`
+	TotalVendido        Dinero `json:"total_vendido"`
+}
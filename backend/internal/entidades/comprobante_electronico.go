@@ -0,0 +1,79 @@
+package entidades
+
+import "time"
+
+// ComprobanteElectronico representa el envío a SUNAT de un comprobante de pago en formato UBL 2.1
+type ComprobanteElectronico struct {
+	ID                   int        `json:"id_comprobante_electronico" db:"id_comprobante_electronico"`
+	IDComprobante        int        `json:"id_comprobante" db:"id_comprobante"`
+	NombreArchivo        string     `json:"nombre_archivo" db:"nombre_archivo"` // RUC-TIPO-SERIE-CORRELATIVO
+	HashCPE              string     `json:"hash_cpe" db:"hash_cpe"`             // DigestValue de la firma
+	EstadoSunat          string     `json:"estado_sunat" db:"estado_sunat"`     // PENDIENTE, ACEPTADO, OBSERVADO, RECHAZADO, ERROR
+	CodigoRespuesta      string     `json:"codigo_respuesta,omitempty" db:"codigo_respuesta"`
+	DescripcionRespuesta string     `json:"descripcion_respuesta,omitempty" db:"descripcion_respuesta"`
+	Enviador             string     `json:"enviador" db:"enviador"` // STUB, OSE
+	Intentos             int        `json:"intentos" db:"intentos"`
+	FechaGeneracion      time.Time  `json:"fecha_generacion" db:"fecha_generacion"`
+	FechaEnvio           *time.Time `json:"fecha_envio,omitempty" db:"fecha_envio"`
+
+	// Contenido descargable, no se incluye en las respuestas JSON
+	XMLFirmado []byte `json:"-" db:"xml_firmado"`
+	CDR        []byte `json:"-" db:"cdr_zip"` // Constancia de recepción (ZIP devuelto por SUNAT/OSE)
+}
+
+// EmisorElectronico representa los datos del emisor que van en el comprobante electrónico
+type EmisorElectronico struct {
+	RUC             string
+	RazonSocial     string
+	NombreComercial string
+	Direccion       string
+	Ubigeo          string
+}
+
+// ClienteElectronico representa al adquiriente del comprobante electrónico
+type ClienteElectronico struct {
+	TipoDocumento   string // Código de catálogo 06: 1 DNI, 4 CE, 6 RUC, 7 Pasaporte
+	NumeroDocumento string
+	Nombre          string // Razón social o nombres y apellidos
+	Direccion       string
+}
+
+// LineaElectronica representa un ítem del comprobante electrónico
+type LineaElectronica struct {
+	Descripcion string
+	Cantidad    int
+	ValorVenta  Dinero // Sin IGV
+	IGV         Dinero
+	Total       Dinero // Con IGV
+}
+
+// DocumentoElectronico reúne los datos con los que se genera el XML UBL 2.1 de un comprobante
+type DocumentoElectronico struct {
+	TipoDocumento string // Código de catálogo 01: 01 factura, 03 boleta, 07 nota de crédito, 08 nota de débito
+	Serie         string
+	Correlativo   int
+	FechaEmision  time.Time
+	Moneda        string
+	Emisor        EmisorElectronico
+	Cliente       ClienteElectronico
+	Lineas        []LineaElectronica
+	Subtotal      Dinero
+	IGV           Dinero
+	Total         Dinero
+	PorcentajeIGV float64
+	Afectacion    string // GRAVADO, EXONERADO o INAFECTO
+
+	// Solo en notas: comprobante que se modifica y motivo (catálogo 09 o 10)
+	TipoDocumentoReferencia string
+	NumeroReferencia        string
+	CodigoMotivo            string
+	Motivo                  string
+}
+
+// RespuestaSunat representa la constancia de recepción (CDR) devuelta por SUNAT o el OSE
+type RespuestaSunat struct {
+	Estado      string // ACEPTADO, OBSERVADO, RECHAZADO
+	Codigo      string
+	Descripcion string
+	CDR         []byte
+}
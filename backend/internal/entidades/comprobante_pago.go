@@ -10,12 +10,21 @@ type ComprobantePago struct {
 	Tipo              string    `json:"tipo" db:"tipo"`
 	NumeroComprobante string    `json:"numero_comprobante" db:"numero_comprobante"`
 	FechaEmision      time.Time `json:"fecha_emision" db:"fecha_emision"`
-	Subtotal          float64   `json:"subtotal" db:"subtotal"`
-	IGV               float64   `json:"igv" db:"igv"`
-	Total             float64   `json:"total" db:"total"`
+	Subtotal          Dinero    `json:"subtotal" db:"subtotal"`
+	IGV               Dinero    `json:"igv" db:"igv"`
+	Total             Dinero    `json:"total" db:"total"`
+	Moneda            string    `json:"moneda" db:"moneda"`
+	TipoCambio        float64   `json:"tipo_cambio" db:"tipo_cambio"` // Soles por unidad; 1 en comprobantes en soles
 	Estado            string    `json:"estado" db:"estado"`
+	Afectacion        string    `json:"afectacion_igv" db:"afectacion_igv"` // GRAVADO, EXONERADO, INAFECTO
 	Eliminado         bool      `json:"eliminado,omitempty" db:"eliminado"` // Añadido campo Eliminado
 
+	// Solo en notas de crédito y débito
+	IDComprobanteReferencia *int   `json:"id_comprobante_referencia,omitempty" db:"id_comprobante_referencia"`
+	CodigoMotivo            string `json:"codigo_motivo,omitempty" db:"codigo_motivo"`
+	Motivo                  string `json:"motivo,omitempty" db:"motivo"`
+	IDDevolucion            *int   `json:"id_devolucion,omitempty" db:"id_devolucion"`
+
 	// Campos adicionales para mostrar información relacionada
 	NombreCliente    string    `json:"nombre_cliente,omitempty" db:"-"`
 	ApellidosCliente string    `json:"apellidos_cliente,omitempty" db:"-"`
@@ -23,31 +32,88 @@ type ComprobantePago struct {
 	NombreSede       string    `json:"nombre_sede,omitempty" db:"-"` // Añadido nombre de sede
 	TourNombre       string    `json:"tour_nombre,omitempty" db:"-"`
 	TourFecha        time.Time `json:"tour_fecha,omitempty" db:"-"`
+	TipoReferencia   string    `json:"tipo_referencia,omitempty" db:"-"`
+	NumeroReferencia string    `json:"numero_referencia,omitempty" db:"-"`
 }
 
 // NuevoComprobantePagoRequest representa los datos necesarios para crear un nuevo comprobante de pago
 type NuevoComprobantePagoRequest struct {
-	IDReserva         int     `json:"id_reserva" validate:"required"`
-	IDSede            int     `json:"id_sede" validate:"required"` // Añadido campo IDSede
-	Tipo              string  `json:"tipo" validate:"required,oneof=BOLETA FACTURA"`
-	NumeroComprobante string  `json:"numero_comprobante" validate:"required"`
-	Subtotal          float64 `json:"subtotal" validate:"required,min=0"`
-	IGV               float64 `json:"igv" validate:"required,min=0"`
-	Total             float64 `json:"total" validate:"required,min=0"`
+	IDReserva         int    `json:"id_reserva" validate:"required"`
+	IDSede            int    `json:"id_sede" validate:"required"` // Añadido campo IDSede
+	Tipo              string `json:"tipo" validate:"required,oneof=BOLETA FACTURA"`
+	NumeroComprobante string `json:"numero_comprobante" validate:"omitempty,max=20"` // Vacío: se toma de la serie activa de la sede
+
+	// Los montos se calculan con la configuración de IGV de la sede; si se envían deben coincidir con el cálculo
+	Subtotal   Dinero `json:"subtotal" validate:"omitempty,min=0"`
+	IGV        Dinero `json:"igv" validate:"omitempty,min=0"`
+	Total      Dinero `json:"total" validate:"omitempty,min=0"`
+	Afectacion string `json:"-"` // Se completa con el cálculo de impuestos
+
+	// En dólares, los montos se expresan con el tipo de cambio del día de emisión
+	Moneda     string      `json:"moneda" validate:"omitempty,oneof=PEN USD"` // Por defecto PEN
+	TipoCambio *TipoCambio `json:"-"`
 }
 
 // ActualizarComprobantePagoRequest representa los datos para actualizar un comprobante de pago
 type ActualizarComprobantePagoRequest struct {
-	IDSede            int     `json:"id_sede" validate:"required"` // Añadido campo IDSede
-	Tipo              string  `json:"tipo" validate:"required,oneof=BOLETA FACTURA"`
-	NumeroComprobante string  `json:"numero_comprobante" validate:"required"`
-	Subtotal          float64 `json:"subtotal" validate:"required,min=0"`
-	IGV               float64 `json:"igv" validate:"required,min=0"`
-	Total             float64 `json:"total" validate:"required,min=0"`
-	Estado            string  `json:"estado" validate:"required,oneof=EMITIDO ANULADO"`
+	IDSede            int    `json:"id_sede" validate:"required"` // Añadido campo IDSede
+	Tipo              string `json:"tipo" validate:"required,oneof=BOLETA FACTURA"`
+	NumeroComprobante string `json:"numero_comprobante" validate:"required"`
+	Subtotal          Dinero `json:"subtotal" validate:"omitempty,min=0"` // Se recalcula con la configuración de IGV de la sede
+	IGV               Dinero `json:"igv" validate:"omitempty,min=0"`
+	Total             Dinero `json:"total" validate:"omitempty,min=0"`
+	Afectacion        string `json:"-"`
+	Estado            string `json:"estado" validate:"required,oneof=EMITIDO ANULADO"`
 }
 
 // CambiarEstadoComprobanteRequest representa los datos para cambiar el estado de un comprobante
 type CambiarEstadoComprobanteRequest struct {
 	Estado string `json:"estado" validate:"required,oneof=EMITIDO ANULADO"`
 }
+
+// Motivos de notas de crédito (catálogo 09 de SUNAT)
+var MotivosNotaCredito = map[string]string{
+	"01": "Anulación de la operación",
+	"02": "Anulación por error en el RUC",
+	"03": "Corrección por error en la descripción",
+	"04": "Descuento global",
+	"05": "Descuento por ítem",
+	"06": "Devolución total",
+	"07": "Devolución por ítem",
+	"08": "Bonificación",
+	"09": "Disminución en el valor",
+	"10": "Otros conceptos",
+	"13": "Ajustes - montos y/o fechas de pago",
+}
+
+// Motivos de notas de débito (catálogo 10 de SUNAT)
+var MotivosNotaDebito = map[string]string{
+	"01": "Intereses por mora",
+	"02": "Aumento en el valor",
+	"03": "Penalidades/ otros conceptos",
+}
+
+// NuevaNotaComprobanteRequest representa los datos para emitir una nota de crédito o débito sobre un comprobante
+type NuevaNotaComprobanteRequest struct {
+	IDComprobanteReferencia int    `json:"-" validate:"required"` // Se toma de la ruta
+	Tipo                    string `json:"tipo" validate:"required,oneof=NOTA_CREDITO NOTA_DEBITO"`
+	NumeroComprobante       string `json:"numero_comprobante" validate:"omitempty,max=20"` // Vacío: se toma de la serie activa de la sede
+	CodigoMotivo            string `json:"codigo_motivo" validate:"required,len=2,numeric"`
+	Motivo                  string `json:"motivo" validate:"required,max=250"`
+	Subtotal                Dinero `json:"subtotal" validate:"min=0"`
+	IGV                     Dinero `json:"igv" validate:"min=0"`
+	Total                   Dinero `json:"total" validate:"required,gt=0"`
+	IDDevolucion            *int   `json:"id_devolucion,omitempty" validate:"omitempty,min=1"`
+}
+
+// ResumenNotasComprobante muestra las notas emitidas sobre un comprobante y cuánto se puede acreditar todavía
+type ResumenNotasComprobante struct {
+	IDComprobante     int                `json:"id_comprobante"`
+	IDSede            int                `json:"id_sede"`
+	NumeroComprobante string             `json:"numero_comprobante"`
+	Total             Dinero             `json:"total"`
+	TotalCreditos     Dinero             `json:"total_creditos"`
+	TotalDebitos      Dinero             `json:"total_debitos"`
+	SaldoAcreditable  Dinero             `json:"saldo_acreditable"`
+	Notas             []*ComprobantePago `json:"notas"`
+}
@@ -0,0 +1,246 @@
+package entidades
+
+import (
+	"fmt"
+	"time"
+)
+
+// Origen y estados de una ejecución de la conciliación de pagos
+const (
+	OrigenConciliacionAutomatica = "AUTOMATICA"
+	OrigenConciliacionManual     = "MANUAL"
+
+	EstadoConciliacionEnCurso    = "EN_CURSO"
+	EstadoConciliacionCompletada = "COMPLETADA"
+	EstadoConciliacionError      = "ERROR"
+)
+
+// Tipos de diferencia entre la pasarela y lo registrado
+const (
+	DiscrepanciaPagoNoRegistrado    = "PAGO_NO_REGISTRADO"      // Aprobado en la pasarela, sin pago registrado
+	DiscrepanciaPagoNoRevertido     = "PAGO_NO_REVERTIDO"       // Devuelto o contracargado en la pasarela, vigente aquí
+	DiscrepanciaPagoRevertidoLocal  = "PAGO_REVERTIDO_LOCAL"    // Devuelto aquí, aprobado en la pasarela
+	DiscrepanciaMontoDiferente      = "MONTO_DIFERENTE"         // Registrado con un monto distinto al cobrado
+	DiscrepanciaPagoNoEncontrado    = "PAGO_NO_ENCONTRADO"      // Registrado aquí, sin pago en la pasarela
+	DiscrepanciaRetencionVencida    = "RETENCION_VENCIDA"       // Retención vencida sin pago aprobado
+	DiscrepanciaErrorPasarela       = "ERROR_PASARELA"          // No se pudo consultar la pasarela
+	DiscrepanciaPasarelaDesconocida = "PASARELA_NO_CONFIGURADA" // La reserva se cobró con una pasarela no configurada
+)
+
+// Acciones de la conciliación sobre una diferencia
+const (
+	AccionConciliacionConfirmarPago  = "CONFIRMAR_PAGO"
+	AccionConciliacionRevertirPago   = "REVERTIR_PAGO"
+	AccionConciliacionExpirarReserva = "EXPIRAR_RESERVA"
+	AccionConciliacionRevisar        = "REVISAR" // Queda para revisión de finanzas
+)
+
+// Resultados de una diferencia después de la conciliación
+const (
+	ResultadoConciliacionCorregido = "CORREGIDO"
+	ResultadoConciliacionRevisar   = "PENDIENTE_REVISION"
+	ResultadoConciliacionError     = "ERROR"
+)
+
+// PagoLocalConciliacion representa un pago en línea registrado de una reserva
+type PagoLocalConciliacion struct {
+	IDPago        int
+	Pasarela      string
+	IDTransaccion string
+	Estado        string // PROCESADO, DEVUELTO...
+	Monto         Dinero // En la moneda del pago
+}
+
+// ReservaConciliacion representa una reserva web cuyos pagos se comparan con la pasarela
+type ReservaConciliacion struct {
+	IDReserva       int
+	Estado          string
+	Pasarela        string // Pasarela con la que se cobró
+	FechaExpiracion *time.Time
+	Pagos           []*PagoLocalConciliacion // Pagos registrados de la pasarela
+}
+
+// RetencionVencida indica si la reserva sigue reteniendo cupo después de vencer su plazo de pago
+func (r *ReservaConciliacion) RetencionVencida(ahora time.Time) bool {
+	return r.Estado == "RESERVADO" && r.FechaExpiracion != nil && !r.FechaExpiracion.After(ahora)
+}
+
+// ConciliacionPago representa una ejecución de la conciliación de pagos con las pasarelas
+type ConciliacionPago struct {
+	ID                int                         `json:"id_conciliacion" db:"id_conciliacion"`
+	Origen            string                      `json:"origen" db:"origen"` // AUTOMATICA, MANUAL
+	IDUsuario         *int                        `json:"id_usuario,omitempty" db:"id_usuario"`
+	DiasRevisados     int                         `json:"dias_revisados" db:"dias_revisados"`
+	FechaInicio       time.Time                   `json:"fecha_inicio" db:"fecha_inicio"`
+	FechaFin          *time.Time                  `json:"fecha_fin,omitempty" db:"fecha_fin"`
+	Estado            string                      `json:"estado" db:"estado"` // EN_CURSO, COMPLETADA, ERROR
+	ReservasRevisadas int                         `json:"reservas_revisadas" db:"reservas_revisadas"`
+	Discrepancias     int                         `json:"discrepancias" db:"discrepancias"`
+	Corregidas        int                         `json:"corregidas" db:"corregidas"`
+	Detalle           string                      `json:"detalle,omitempty" db:"detalle"`
+	Diferencias       []*DiscrepanciaConciliacion `json:"diferencias,omitempty" db:"-"`
+}
+
+// DiscrepanciaConciliacion representa una diferencia entre la pasarela y lo registrado para una reserva
+type DiscrepanciaConciliacion struct {
+	ID             int       `json:"id_discrepancia" db:"id_discrepancia"`
+	IDConciliacion int       `json:"id_conciliacion" db:"id_conciliacion"`
+	IDReserva      int       `json:"id_reserva" db:"id_reserva"`
+	Pasarela       string    `json:"pasarela" db:"pasarela"`
+	IDPagoExterno  string    `json:"id_pago_externo,omitempty" db:"id_pago_externo"`
+	IDPago         *int      `json:"id_pago,omitempty" db:"id_pago"`
+	Tipo           string    `json:"tipo" db:"tipo"`
+	EstadoPasarela string    `json:"estado_pasarela,omitempty" db:"estado_pasarela"`
+	EstadoLocal    string    `json:"estado_local,omitempty" db:"estado_local"`
+	MontoPasarela  *Dinero   `json:"monto_pasarela,omitempty" db:"monto_pasarela"`
+	MontoLocal     *Dinero   `json:"monto_local,omitempty" db:"monto_local"`
+	Accion         string    `json:"accion" db:"accion"`
+	Resultado      string    `json:"resultado" db:"resultado"` // CORREGIDO, PENDIENTE_REVISION, ERROR
+	Detalle        string    `json:"detalle,omitempty" db:"detalle"`
+	FechaRegistro  time.Time `json:"fecha_registro" db:"fecha_registro"`
+	// Pago de la pasarela con el que se corrige; no se guarda
+	PagoPasarela *PagoPasarela `json:"-" db:"-"`
+}
+
+// Corregible indica si la conciliación puede corregir la diferencia sin intervención de finanzas
+func (d *DiscrepanciaConciliacion) Corregible() bool {
+	return d.Accion != AccionConciliacionRevisar
+}
+
+// ConciliarReserva compara los pagos de una reserva en la pasarela con los registrados y devuelve las diferencias
+// con la acción que corresponde a cada una. Los pagos aprobados sin registrar se confirman y los devueltos o
+// contracargados se revierten; los montos distintos y los pagos que la pasarela no conoce quedan para revisión.
+// Una reserva cuya retención venció sin pagos pendientes ni aprobados por registrar en la pasarela se propone
+// expirar; al expirarla se aplica la misma regla que a la liberación periódica: solo sin el adelanto cubierto
+func ConciliarReserva(reserva *ReservaConciliacion, pagosPasarela []*PagoPasarela, ahora time.Time) []*DiscrepanciaConciliacion {
+	discrepancias := []*DiscrepanciaConciliacion{}
+	referencia := ReferenciaReserva(reserva.IDReserva)
+
+	locales := map[string]*PagoLocalConciliacion{}
+	for _, pago := range reserva.Pagos {
+		locales[pago.IDTransaccion] = pago
+	}
+
+	enPasarela := map[string]bool{}
+	pagoEnCurso := false
+	for _, pago := range pagosPasarela {
+		// La búsqueda por referencia solo debería traer pagos de la reserva
+		if pago.ReferenciaExterna != referencia {
+			continue
+		}
+		enPasarela[pago.ID] = true
+
+		discrepancia := &DiscrepanciaConciliacion{
+			IDReserva:      reserva.IDReserva,
+			Pasarela:       reserva.Pasarela,
+			IDPagoExterno:  pago.ID,
+			EstadoPasarela: pago.EstadoOriginal,
+			MontoPasarela:  &pago.Monto,
+			PagoPasarela:   pago,
+		}
+		local := locales[pago.ID]
+		if local != nil {
+			discrepancia.IDPago = &local.IDPago
+			discrepancia.EstadoLocal = local.Estado
+			discrepancia.MontoLocal = &local.Monto
+		}
+
+		switch {
+		case pago.Estado == EstadoPasarelaAprobado && local == nil:
+			discrepancia.Tipo = DiscrepanciaPagoNoRegistrado
+			discrepancia.Accion = AccionConciliacionConfirmarPago
+		case pago.Estado == EstadoPasarelaAprobado && local.Estado != "PROCESADO":
+			discrepancia.Tipo = DiscrepanciaPagoRevertidoLocal
+			discrepancia.Accion = AccionConciliacionRevisar
+		case (pago.Estado == EstadoPasarelaDevuelto || pago.Estado == EstadoPasarelaContracargo) &&
+			local != nil && local.Estado == "PROCESADO":
+			discrepancia.Tipo = DiscrepanciaPagoNoRevertido
+			discrepancia.Accion = AccionConciliacionRevertirPago
+		case pago.Estado == EstadoPasarelaAprobado && !local.Monto.Igual(pago.Monto):
+			discrepancia.Tipo = DiscrepanciaMontoDiferente
+			discrepancia.Accion = AccionConciliacionRevisar
+			discrepancia.Detalle = fmt.Sprintf("registrado %s %s, cobrado %s %s",
+				local.Monto.Moneda(), local.Monto, pago.Monto.Moneda(), pago.Monto)
+		default:
+			discrepancia = nil
+		}
+
+		if pago.Estado == EstadoPasarelaPendiente || pago.Estado == EstadoPasarelaAprobado && local == nil {
+			pagoEnCurso = true
+		}
+		if discrepancia != nil {
+			discrepancias = append(discrepancias, discrepancia)
+		}
+	}
+
+	// Pagos vigentes que la pasarela no reconoce para la reserva
+	for _, local := range reserva.Pagos {
+		if enPasarela[local.IDTransaccion] || local.Estado != "PROCESADO" {
+			continue
+		}
+		discrepancias = append(discrepancias, &DiscrepanciaConciliacion{
+			IDReserva:     reserva.IDReserva,
+			Pasarela:      reserva.Pasarela,
+			IDPagoExterno: local.IDTransaccion,
+			IDPago:        &local.IDPago,
+			Tipo:          DiscrepanciaPagoNoEncontrado,
+			EstadoLocal:   local.Estado,
+			MontoLocal:    &local.Monto,
+			Accion:        AccionConciliacionRevisar,
+		})
+	}
+
+	if !pagoEnCurso && reserva.RetencionVencida(ahora) {
+		discrepancias = append(discrepancias, &DiscrepanciaConciliacion{
+			IDReserva:   reserva.IDReserva,
+			Pasarela:    reserva.Pasarela,
+			Tipo:        DiscrepanciaRetencionVencida,
+			EstadoLocal: reserva.Estado,
+			Accion:      AccionConciliacionExpirarReserva,
+		})
+	}
+
+	return discrepancias
+}
+
+// ResumenDiscrepancias cuenta las diferencias de un tipo según cómo terminaron
+type ResumenDiscrepancias struct {
+	Tipo       string `json:"tipo"`
+	Cantidad   int    `json:"cantidad"`
+	Corregidas int    `json:"corregidas"`
+	Revisar    int    `json:"pendientes_revision"`
+	Errores    int    `json:"errores"`
+}
+
+// ReporteConciliacionPagos presenta para finanzas las diferencias encontradas por la conciliación en un periodo
+type ReporteConciliacionPagos struct {
+	FechaInicio   time.Time                   `json:"fecha_inicio"`
+	FechaFin      time.Time                   `json:"fecha_fin"`
+	Ejecuciones   int                         `json:"ejecuciones"`
+	Resumen       []*ResumenDiscrepancias     `json:"resumen"`
+	Discrepancias []*DiscrepanciaConciliacion `json:"discrepancias"`
+}
+
+// ResumirDiscrepancias cuenta las diferencias por tipo, en el orden en que aparece cada tipo
+func ResumirDiscrepancias(discrepancias []*DiscrepanciaConciliacion) []*ResumenDiscrepancias {
+	resumen := []*ResumenDiscrepancias{}
+	porTipo := map[string]*ResumenDiscrepancias{}
+	for _, discrepancia := range discrepancias {
+		tipo := porTipo[discrepancia.Tipo]
+		if tipo == nil {
+			tipo = &ResumenDiscrepancias{Tipo: discrepancia.Tipo}
+			porTipo[discrepancia.Tipo] = tipo
+			resumen = append(resumen, tipo)
+		}
+		tipo.Cantidad++
+		switch discrepancia.Resultado {
+		case ResultadoConciliacionCorregido:
+			tipo.Corregidas++
+		case ResultadoConciliacionError:
+			tipo.Errores++
+		default:
+			tipo.Revisar++
+		}
+	}
+	return resumen
+}
@@ -0,0 +1,53 @@
+package entidades
+
+import "time"
+
+// DevolucionPago representa la devolución total o parcial de un pago
+type DevolucionPago struct {
+	ID                  int        `json:"id_devolucion" db:"id_devolucion"`
+	IDPago              int        `json:"id_pago" db:"id_pago"`
+	FechaDevolucion     time.Time  `json:"fecha_devolucion" db:"fecha_devolucion"` // Fecha de la solicitud
+	Motivo              string     `json:"motivo" db:"motivo"`
+	MontoDevolucion     Dinero     `json:"monto_devolucion" db:"monto_devolucion"`
+	Estado              string     `json:"estado" db:"estado"` // PENDIENTE, APROBADA, EJECUTANDO, RECHAZADA, COMPLETADA
+	Observaciones       string     `json:"observaciones" db:"observaciones"`
+	CancelarReserva     bool       `json:"cancelar_reserva" db:"cancelar_reserva"`
+	MetodoEjecucion     string     `json:"metodo_ejecucion,omitempty" db:"metodo_ejecucion"` // MANUAL o la pasarela que reembolsó
+	IDDevolucionExterna string     `json:"id_devolucion_externa,omitempty" db:"id_devolucion_externa"`
+	IDUsuarioSolicita   *int       `json:"id_usuario_solicita,omitempty" db:"id_usuario_solicita"`
+	IDUsuarioAprueba    *int       `json:"id_usuario_aprueba,omitempty" db:"id_usuario_aprueba"`
+	FechaAprobacion     *time.Time `json:"fecha_aprobacion,omitempty" db:"fecha_aprobacion"`
+	FechaEjecucion      *time.Time `json:"fecha_ejecucion,omitempty" db:"fecha_ejecucion"`
+
+	// Campos adicionales para mostrar información relacionada
+	IDReserva            int     `json:"id_reserva" db:"-"`
+	IDSede               int     `json:"id_sede" db:"-"`
+	MontoPago            Dinero  `json:"monto_pago" db:"-"`
+	Moneda               string  `json:"moneda" db:"-"` // Moneda del pago; la devolución se expresa en ella
+	TipoCambioPago       float64 `json:"tipo_cambio_pago" db:"-"`
+	Pasarela             string  `json:"pasarela,omitempty" db:"-"` // Pasarela con la que se hizo el pago
+	IDTransaccionExterna string  `json:"id_transaccion_externa,omitempty" db:"-"`
+	NombreCliente        string  `json:"nombre_cliente,omitempty" db:"-"`
+	NombreMetodoPago     string  `json:"nombre_metodo_pago,omitempty" db:"-"`
+	NombreSede           string  `json:"nombre_sede,omitempty" db:"-"`
+}
+
+// NuevaDevolucionPagoRequest representa los datos para solicitar la devolución de un pago
+type NuevaDevolucionPagoRequest struct {
+	IDPago          int    `json:"id_pago" validate:"required"`
+	Motivo          string `json:"motivo" validate:"required"`
+	MontoDevolucion Dinero `json:"monto_devolucion" validate:"required,gt=0"`
+	CancelarReserva bool   `json:"cancelar_reserva"` // Cancela la reserva y libera su cupo al ejecutarse
+	Observaciones   string `json:"observaciones"`
+}
+
+// RechazarDevolucionRequest representa los datos para rechazar una solicitud de devolución
+type RechazarDevolucionRequest struct {
+	Observaciones string `json:"observaciones" validate:"required"`
+}
+
+// EjecutarDevolucionRequest representa los datos para ejecutar una devolución aprobada
+type EjecutarDevolucionRequest struct {
+	MetodoEjecucion string `json:"metodo_ejecucion" validate:"required,oneof=MANUAL PASARELA MERCADO_PAGO"` // PASARELA reembolsa por la pasarela del pago
+	Observaciones   string `json:"observaciones"`
+}
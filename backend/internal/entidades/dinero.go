@@ -0,0 +1,301 @@
+package entidades
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MonedaPorDefecto es la moneda de los montos que no indican una
+const MonedaPorDefecto = "PEN"
+
+// Dinero representa un monto exacto en céntimos con su código de moneda ISO 4217
+// Se guarda en columnas DECIMAL(10,2) y se serializa en JSON como número con dos decimales,
+// así los montos nunca pasan por float64 al sumar, comparar o persistir.
+type Dinero struct {
+	centimos int64
+	moneda   string
+}
+
+// NuevoDinero crea un monto a partir de céntimos en la moneda indicada (vacía: PEN)
+func NuevoDinero(centimos int64, moneda string) Dinero {
+	return Dinero{centimos: centimos, moneda: strings.ToUpper(moneda)}
+}
+
+// Soles crea un monto en soles a partir de céntimos
+func Soles(centimos int64) Dinero {
+	return Dinero{centimos: centimos}
+}
+
+// DineroDesdeFloat convierte un monto decimal redondeando al céntimo, con la mitad alejándose de cero
+func DineroDesdeFloat(monto float64) Dinero {
+	return Dinero{centimos: int64(math.Round(monto * 100))}
+}
+
+// ParseDinero interpreta un monto decimal como "118", "118.5" o "-20.35"
+// Con más de dos decimales se redondea al céntimo, con la mitad alejándose de cero.
+func ParseDinero(texto string) (Dinero, error) {
+	texto = strings.TrimSpace(texto)
+	if texto == "" {
+		return Dinero{}, errors.New("monto vacío")
+	}
+
+	negativo := false
+	switch texto[0] {
+	case '-':
+		negativo = true
+		texto = texto[1:]
+	case '+':
+		texto = texto[1:]
+	}
+
+	entero, decimales := texto, ""
+	if i := strings.IndexByte(texto, '.'); i >= 0 {
+		entero, decimales = texto[:i], texto[i+1:]
+	}
+	if (entero == "" && decimales == "") || !soloDigitos(entero) || !soloDigitos(decimales) {
+		return Dinero{}, fmt.Errorf("monto inválido: %q", texto)
+	}
+	if entero == "" {
+		entero = "0"
+	}
+
+	unidades, err := strconv.ParseInt(entero, 10, 64)
+	if err != nil || unidades > math.MaxInt64/100-1 {
+		return Dinero{}, fmt.Errorf("monto fuera de rango: %q", texto)
+	}
+
+	// Los dos primeros decimales son céntimos; el tercero decide el redondeo
+	centimos := unidades*100 + int64(digito(decimales, 0)*10+digito(decimales, 1))
+	if digito(decimales, 2) >= 5 {
+		centimos++
+	}
+
+	if negativo {
+		centimos = -centimos
+	}
+	return Dinero{centimos: centimos}, nil
+}
+
+// soloDigitos indica si el texto está formado solo por dígitos decimales
+func soloDigitos(texto string) bool {
+	for _, c := range texto {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// digito devuelve el dígito en la posición indicada, o cero si el texto es más corto
+func digito(texto string, posicion int) int {
+	if posicion >= len(texto) {
+		return 0
+	}
+	return int(texto[posicion] - '0')
+}
+
+// Centimos devuelve el monto en céntimos
+func (d Dinero) Centimos() int64 {
+	return d.centimos
+}
+
+// Moneda devuelve el código ISO 4217 del monto
+func (d Dinero) Moneda() string {
+	if d.moneda == "" {
+		return MonedaPorDefecto
+	}
+	return d.moneda
+}
+
+// EnMoneda devuelve el mismo monto expresado con otro código de moneda, sin convertirlo
+func (d Dinero) EnMoneda(moneda string) Dinero {
+	return NuevoDinero(d.centimos, moneda)
+}
+
+// Float64 devuelve el monto como decimal; solo para presentación o APIs externas
+func (d Dinero) Float64() float64 {
+	return float64(d.centimos) / 100
+}
+
+// String devuelve el monto con dos decimales, como "118.00"
+func (d Dinero) String() string {
+	signo := ""
+	centimos := d.centimos
+	if centimos < 0 {
+		signo = "-"
+		centimos = -centimos
+	}
+	return fmt.Sprintf("%s%d.%02d", signo, centimos/100, centimos%100)
+}
+
+// EsCero indica si el monto es cero
+func (d Dinero) EsCero() bool {
+	return d.centimos == 0
+}
+
+// EsPositivo indica si el monto es mayor a cero
+func (d Dinero) EsPositivo() bool {
+	return d.centimos > 0
+}
+
+// EsNegativo indica si el monto es menor a cero
+func (d Dinero) EsNegativo() bool {
+	return d.centimos < 0
+}
+
+// verificarMoneda detiene operaciones entre monedas distintas, que son siempre un error de programación
+func (d Dinero) verificarMoneda(otro Dinero) {
+	if d.Moneda() != otro.Moneda() {
+		panic(fmt.Sprintf("operación entre monedas distintas: %s y %s", d.Moneda(), otro.Moneda()))
+	}
+}
+
+// Sumar devuelve la suma de dos montos de la misma moneda
+func (d Dinero) Sumar(otro Dinero) Dinero {
+	d.verificarMoneda(otro)
+	return Dinero{centimos: d.centimos + otro.centimos, moneda: d.moneda}
+}
+
+// Restar devuelve la diferencia de dos montos de la misma moneda
+func (d Dinero) Restar(otro Dinero) Dinero {
+	d.verificarMoneda(otro)
+	return Dinero{centimos: d.centimos - otro.centimos, moneda: d.moneda}
+}
+
+// Multiplicar devuelve el monto multiplicado por una cantidad entera
+func (d Dinero) Multiplicar(cantidad int) Dinero {
+	return Dinero{centimos: d.centimos * int64(cantidad), moneda: d.moneda}
+}
+
+// Prorratear devuelve numerador/denominador del monto redondeado al céntimo, con la mitad alejándose de cero
+func (d Dinero) Prorratear(numerador, denominador int64) Dinero {
+	if denominador == 0 {
+		return Dinero{moneda: d.moneda}
+	}
+	return Dinero{centimos: dividirRedondeando(d.centimos*numerador, denominador), moneda: d.moneda}
+}
+
+// Porcentaje devuelve el porcentaje indicado del monto (con hasta dos decimales) redondeado al céntimo
+func (d Dinero) Porcentaje(porcentaje float64) Dinero {
+	return d.Prorratear(int64(math.Round(porcentaje*100)), 10000)
+}
+
+// Comparar devuelve -1, 0 o 1 según el monto sea menor, igual o mayor que otro de la misma moneda
+func (d Dinero) Comparar(otro Dinero) int {
+	d.verificarMoneda(otro)
+	switch {
+	case d.centimos < otro.centimos:
+		return -1
+	case d.centimos > otro.centimos:
+		return 1
+	}
+	return 0
+}
+
+// MenorQue indica si el monto es menor que otro de la misma moneda
+func (d Dinero) MenorQue(otro Dinero) bool {
+	return d.Comparar(otro) < 0
+}
+
+// MayorQue indica si el monto es mayor que otro de la misma moneda
+func (d Dinero) MayorQue(otro Dinero) bool {
+	return d.Comparar(otro) > 0
+}
+
+// Igual indica si dos montos tienen la misma moneda y el mismo valor
+func (d Dinero) Igual(otro Dinero) bool {
+	return d.Moneda() == otro.Moneda() && d.centimos == otro.centimos
+}
+
+// Max devuelve el mayor de dos montos de la misma moneda
+func (d Dinero) Max(otro Dinero) Dinero {
+	if d.MenorQue(otro) {
+		return otro
+	}
+	return d
+}
+
+// dividirRedondeando divide dos enteros redondeando la mitad alejándose de cero
+func dividirRedondeando(dividendo, divisor int64) int64 {
+	if divisor < 0 {
+		dividendo, divisor = -dividendo, -divisor
+	}
+	if dividendo < 0 {
+		return -((-dividendo*2 + divisor) / (divisor * 2))
+	}
+	return (dividendo*2 + divisor) / (divisor * 2)
+}
+
+// Scan implementa sql.Scanner para leer columnas DECIMAL; NULL se lee como cero
+func (d *Dinero) Scan(valor interface{}) error {
+	switch v := valor.(type) {
+	case nil:
+		*d = Dinero{}
+	case []byte:
+		leido, err := ParseDinero(string(v))
+		if err != nil {
+			return err
+		}
+		*d = leido
+	case string:
+		leido, err := ParseDinero(v)
+		if err != nil {
+			return err
+		}
+		*d = leido
+	case int64:
+		*d = Dinero{centimos: v * 100}
+	case float64:
+		*d = DineroDesdeFloat(v)
+	default:
+		return fmt.Errorf("no se puede leer un monto desde %T", valor)
+	}
+	return nil
+}
+
+// Value implementa driver.Valuer; el monto se envía como texto decimal exacto
+func (d Dinero) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// MarshalJSON serializa el monto como número con dos decimales, por ejemplo 118.00
+func (d Dinero) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON acepta el monto como número o como texto decimal; null se lee como cero
+func (d *Dinero) UnmarshalJSON(datos []byte) error {
+	texto := strings.TrimSpace(string(datos))
+	if texto == "null" {
+		*d = Dinero{}
+		return nil
+	}
+	if strings.HasPrefix(texto, `"`) {
+		valor, err := strconv.Unquote(texto)
+		if err != nil {
+			return fmt.Errorf("monto inválido: %s", texto)
+		}
+		texto = valor
+	}
+
+	// Números en notación exponencial, como 1e2, se aceptan pasando por float64
+	if strings.ContainsAny(texto, "eE") {
+		valor, err := strconv.ParseFloat(texto, 64)
+		if err != nil {
+			return fmt.Errorf("monto inválido: %s", texto)
+		}
+		*d = DineroDesdeFloat(valor)
+		return nil
+	}
+
+	leido, err := ParseDinero(texto)
+	if err != nil {
+		return err
+	}
+	*d = leido
+	return nil
+}
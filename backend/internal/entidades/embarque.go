@@ -0,0 +1,128 @@
+package entidades
+
+import "time"
+
+// Embarque representa el check-in de una reserva al subir a la embarcación
+type Embarque struct {
+	ID                  int       `json:"id_embarque" db:"id_embarque"`
+	IDReserva           int       `json:"id_reserva" db:"id_reserva"`
+	IDInstancia         int       `json:"id_instancia" db:"id_instancia"`
+	IDUsuario           int       `json:"id_usuario" db:"id_usuario"` // Chofer o administrador que escaneó el pase
+	PasajerosEmbarcados int       `json:"pasajeros_embarcados" db:"pasajeros_embarcados"`
+	FechaEmbarque       time.Time `json:"fecha_embarque" db:"fecha_embarque"` // Hora del escaneo, aunque se sincronice después
+	Origen              string    `json:"origen" db:"origen"`                 // ONLINE, OFFLINE
+	IDEvento            *string   `json:"id_evento,omitempty" db:"id_evento"` // Identificador del escaneo sin conexión
+
+	// Campos adicionales para mostrar información relacionada
+	NombreCliente string `json:"nombre_cliente,omitempty" db:"-"`
+	TotalPasajes  int    `json:"total_pasajes" db:"-"`
+}
+
+// PaseAbordar representa el pase de abordar firmado de una reserva confirmada
+type PaseAbordar struct {
+	IDReserva   int       `json:"id_reserva"`
+	IDInstancia int       `json:"id_instancia"`
+	Token       string    `json:"token"`
+	Expira      time.Time `json:"expira"`
+}
+
+// CheckinRequest representa el escaneo de un pase de abordar en el muelle
+// Si se envían pasajeros, solo esos quedan embarcados; si no, se embarca la reserva completa
+type CheckinRequest struct {
+	Token       string `json:"token" validate:"required"`
+	IDPasajeros []int  `json:"id_pasajeros" validate:"omitempty,dive,min=1"`
+}
+
+// ReservaNoShow representa una reserva confirmada que no se presentó al embarque
+type ReservaNoShow struct {
+	IDReserva     int    `json:"id_reserva"`
+	NombreCliente string `json:"nombre_cliente"`
+	Celular       string `json:"celular"`
+	Correo        string `json:"correo"`
+	Estado        string `json:"estado"`
+	TotalPasajes  int    `json:"total_pasajes"`
+}
+
+// ReporteNoShow resume el embarque de una instancia de tour y lista las reservas que no se presentaron
+type ReporteNoShow struct {
+	IDInstancia         int              `json:"id_instancia"`
+	IDSede              int              `json:"id_sede"`
+	IDChofer            *int             `json:"id_chofer,omitempty"`
+	NombreTour          string           `json:"nombre_tour"`
+	FechaTour           string           `json:"fecha_tour"`
+	HoraInicio          string           `json:"hora_inicio"`
+	TotalReservas       int              `json:"total_reservas"`       // Reservas confirmadas de la instancia
+	ReservasEmbarcadas  int              `json:"reservas_embarcadas"`  // Reservas con check-in
+	TotalPasajes        int              `json:"total_pasajes"`        // Pasajes de las reservas confirmadas
+	PasajerosEmbarcados int              `json:"pasajeros_embarcados"` // Pasajeros que subieron a bordo
+	Embarques           []*Embarque      `json:"embarques"`
+	NoShows             []*ReservaNoShow `json:"no_shows"`
+}
+
+// PasajeroOffline representa a un pasajero registrado dentro del paquete sin conexión
+type PasajeroOffline struct {
+	ID              int    `json:"id_pasajero"`
+	Nombres         string `json:"nombres"`
+	Apellidos       string `json:"apellidos"`
+	TipoDocumento   string `json:"tipo_documento"`
+	NumeroDocumento string `json:"numero_documento"`
+	Embarcado       bool   `json:"embarcado"`
+}
+
+// ReservaOffline representa una reserva confirmada dentro del paquete sin conexión
+// HuellaPase es el SHA-256 del token del QR: el dispositivo lo compara con el pase escaneado
+type ReservaOffline struct {
+	IDReserva     int                `json:"id_reserva"`
+	NombreTitular string             `json:"nombre_titular"`
+	TotalPasajes  int                `json:"total_pasajes"`
+	Embarcada     bool               `json:"embarcada"`
+	HuellaPase    string             `json:"huella_pase"`
+	Pasajeros     []*PasajeroOffline `json:"pasajeros"`
+}
+
+// InstanciaOffline representa una instancia de tour del día dentro del paquete sin conexión
+type InstanciaOffline struct {
+	IDInstancia       int               `json:"id_instancia"`
+	NombreTour        string            `json:"nombre_tour"`
+	NombreEmbarcacion string            `json:"nombre_embarcacion"`
+	HoraInicio        string            `json:"hora_inicio"`
+	HoraFin           string            `json:"hora_fin"`
+	FinTour           time.Time         `json:"-"` // Define el vencimiento de los pases
+	Reservas          []*ReservaOffline `json:"reservas"`
+}
+
+// PaqueteOffline es el manifiesto que descarga el chofer para validar pases sin señal
+type PaqueteOffline struct {
+	IDChofer   int                 `json:"id_chofer"`
+	Fecha      string              `json:"fecha"`
+	GeneradoEn time.Time           `json:"generado_en"`
+	Instancias []*InstanciaOffline `json:"instancias"`
+}
+
+// PaqueteOfflineFirmado acompaña el paquete con su firma HMAC para detectar alteraciones
+type PaqueteOfflineFirmado struct {
+	Paquete *PaqueteOffline `json:"paquete"`
+	Firma   string          `json:"firma"`
+}
+
+// EventoEmbarqueOffline representa un escaneo hecho sin conexión
+type EventoEmbarqueOffline struct {
+	IDEvento     string    `json:"id_evento" validate:"required,max=64"`
+	Token        string    `json:"token" validate:"required"`
+	IDPasajeros  []int     `json:"id_pasajeros" validate:"omitempty,dive,min=1"`
+	FechaEscaneo time.Time `json:"fecha_escaneo" validate:"required"`
+}
+
+// SincronizarEmbarquesRequest representa el lote de escaneos que el chofer sube al recuperar señal
+type SincronizarEmbarquesRequest struct {
+	Eventos []EventoEmbarqueOffline `json:"eventos" validate:"required,min=1,max=500,dive"`
+}
+
+// ResultadoSincronizacion indica cómo se concilió cada escaneo con el estado del servidor
+type ResultadoSincronizacion struct {
+	IDEvento  string    `json:"id_evento"`
+	IDReserva int       `json:"id_reserva,omitempty"`
+	Estado    string    `json:"estado"` // REGISTRADO, DUPLICADO, RECHAZADO
+	Mensaje   string    `json:"mensaje,omitempty"`
+	Embarque  *Embarque `json:"embarque,omitempty"`
+}
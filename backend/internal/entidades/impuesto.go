@@ -0,0 +1,54 @@
+package entidades
+
+import "time"
+
+// ConfiguracionImpuesto representa el tratamiento del IGV en las ventas de una sede
+type ConfiguracionImpuesto struct {
+	IDSede             int        `json:"id_sede" db:"id_sede"`
+	Afectacion         string     `json:"afectacion_igv" db:"afectacion_igv"` // GRAVADO, EXONERADO, INAFECTO
+	TasaIGV            float64    `json:"tasa_igv" db:"tasa_igv"`             // Porcentaje, por ejemplo 18
+	PreciosIncluyenIGV bool       `json:"precios_incluyen_igv" db:"precios_incluyen_igv"`
+	FechaActualizacion *time.Time `json:"fecha_actualizacion,omitempty" db:"fecha_actualizacion"`
+
+	// Campos adicionales para mostrar información relacionada
+	NombreSede string `json:"nombre_sede,omitempty" db:"-"`
+	PorDefecto bool   `json:"por_defecto" db:"-"` // La sede no tiene configuración propia
+}
+
+// ActualizarConfiguracionImpuestoRequest representa los datos para configurar el IGV de una sede
+type ActualizarConfiguracionImpuestoRequest struct {
+	Afectacion         string  `json:"afectacion_igv" validate:"required,oneof=GRAVADO EXONERADO INAFECTO"`
+	TasaIGV            float64 `json:"tasa_igv" validate:"required,gt=0,lt=100"`
+	PreciosIncluyenIGV *bool   `json:"precios_incluyen_igv" validate:"required"`
+}
+
+// LineaImpuesto es un concepto vendido sobre el que se calculan los impuestos
+type LineaImpuesto struct {
+	Descripcion    string `json:"descripcion"`
+	Cantidad       int    `json:"cantidad"`
+	PrecioUnitario Dinero `json:"precio_unitario"` // Precio de lista, con o sin IGV según la configuración
+}
+
+// DetalleLineaImpuesto es una línea con sus montos calculados
+type DetalleLineaImpuesto struct {
+	Descripcion      string `json:"descripcion"`
+	Cantidad         int    `json:"cantidad"`
+	CodigoAfectacion string `json:"codigo_afectacion"` // Catálogo 07 de SUNAT: 10, 20 o 30
+	ValorVenta       Dinero `json:"valor_venta"`       // Base imponible de la línea
+	IGV              Dinero `json:"igv"`
+	Total            Dinero `json:"total"`
+}
+
+// CalculoImpuestos es el resultado del cálculo de impuestos de una venta
+type CalculoImpuestos struct {
+	IDSede                int                    `json:"id_sede"`
+	Afectacion            string                 `json:"afectacion_igv"`
+	TasaIGV               float64                `json:"tasa_igv"`
+	Lineas                []DetalleLineaImpuesto `json:"lineas"`
+	OperacionesGravadas   Dinero                 `json:"operaciones_gravadas"`
+	OperacionesExoneradas Dinero                 `json:"operaciones_exoneradas"`
+	OperacionesInafectas  Dinero                 `json:"operaciones_inafectas"`
+	Subtotal              Dinero                 `json:"subtotal"`
+	IGV                   Dinero                 `json:"igv"`
+	Total                 Dinero                 `json:"total"`
+}
@@ -0,0 +1,35 @@
+package entidades
+
+import "time"
+
+// ListaEspera representa la inscripción de un cliente en la lista de espera de una instancia agotada
+type ListaEspera struct {
+	ID                    int        `json:"id_lista_espera" db:"id_lista_espera"`
+	IDInstancia           int        `json:"id_instancia" db:"id_instancia"`
+	IDCliente             int        `json:"id_cliente" db:"id_cliente"`
+	IDUsuarioRegistra     *int       `json:"id_usuario_registra,omitempty" db:"id_usuario_registra"`
+	CantidadPasajeros     int        `json:"cantidad_pasajeros" db:"cantidad_pasajeros"`
+	Estado                string     `json:"estado" db:"estado"` // EN_ESPERA, OFERTADA, CONVERTIDA, EXPIRADA, CANCELADA
+	Notas                 string     `json:"notas" db:"notas"`
+	FechaRegistro         time.Time  `json:"fecha_registro" db:"fecha_registro"`
+	FechaOferta           *time.Time `json:"fecha_oferta,omitempty" db:"fecha_oferta"`
+	FechaExpiracionOferta *time.Time `json:"fecha_expiracion_oferta,omitempty" db:"fecha_expiracion_oferta"`
+	IDReserva             *int       `json:"id_reserva,omitempty" db:"id_reserva"`
+
+	// Campos adicionales para mostrar información relacionada
+	NombreCliente  string `json:"nombre_cliente,omitempty" db:"-"`
+	CorreoCliente  string `json:"correo_cliente,omitempty" db:"-"`
+	CelularCliente string `json:"celular_cliente,omitempty" db:"-"`
+	NombreTour     string `json:"nombre_tour,omitempty" db:"-"`
+	FechaTour      string `json:"fecha_tour,omitempty" db:"-"`
+	HoraTour       string `json:"hora_tour,omitempty" db:"-"`
+	IDSede         int    `json:"id_sede" db:"-"`
+}
+
+// NuevaListaEsperaRequest representa los datos para inscribirse en la lista de espera
+type NuevaListaEsperaRequest struct {
+	IDInstancia       int    `json:"id_instancia" validate:"required"`
+	IDCliente         int    `json:"id_cliente" validate:"required"`
+	CantidadPasajeros int    `json:"cantidad_pasajeros" validate:"required,min=1"`
+	Notas             string `json:"notas"`
+}
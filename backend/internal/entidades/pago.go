@@ -1,19 +1,27 @@
 package entidades
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // Pago representa la estructura de un pago en el sistema
 type Pago struct {
-	ID           int       `json:"id_pago" db:"id_pago"`
-	IDReserva    int       `json:"id_reserva" db:"id_reserva"`
-	IDMetodoPago int       `json:"id_metodo_pago" db:"id_metodo_pago"`
-	IDCanal      int       `json:"id_canal" db:"id_canal"`
-	IDSede       int       `json:"id_sede" db:"id_sede"` // Añadido campo IDSede
-	Monto        float64   `json:"monto" db:"monto"`
-	FechaPago    time.Time `json:"fecha_pago" db:"fecha_pago"`
-	Comprobante  string    `json:"comprobante" db:"comprobante"`
-	Estado       string    `json:"estado" db:"estado"`
-	Eliminado    bool      `json:"eliminado,omitempty" db:"eliminado"`
+	ID              int       `json:"id_pago" db:"id_pago"`
+	IDReserva       int       `json:"id_reserva" db:"id_reserva"`
+	IDMetodoPago    int       `json:"id_metodo_pago" db:"id_metodo_pago"`
+	IDCanal         int       `json:"id_canal" db:"id_canal"`
+	IDSede          int       `json:"id_sede" db:"id_sede"` // Añadido campo IDSede
+	Monto           Dinero    `json:"monto" db:"monto"`
+	Moneda          string    `json:"moneda" db:"moneda"`
+	TipoCambio      float64   `json:"tipo_cambio" db:"tipo_cambio"`           // Soles por unidad de la moneda del pago
+	MontoConvertido Dinero    `json:"monto_convertido" db:"monto_convertido"` // Equivalente en soles
+	FechaPago       time.Time `json:"fecha_pago" db:"fecha_pago"`
+	Comprobante     string    `json:"comprobante" db:"comprobante"`
+	IDCajaSesion    *int      `json:"id_caja_sesion,omitempty" db:"id_caja_sesion"` // Caja en la que se cobró
+	Estado          string    `json:"estado" db:"estado"`
+	Eliminado       bool      `json:"eliminado,omitempty" db:"eliminado"`
 
 	// Campos adicionales para mostrar información relacionada
 	NombreCliente    string    `json:"nombre_cliente,omitempty" db:"-"`
@@ -28,25 +36,144 @@ type Pago struct {
 
 // NuevoPagoRequest representa los datos necesarios para crear un nuevo pago
 type NuevoPagoRequest struct {
-	IDReserva    int     `json:"id_reserva" validate:"required"`
-	IDMetodoPago int     `json:"id_metodo_pago" validate:"required"`
-	IDCanal      int     `json:"id_canal" validate:"required"`
-	IDSede       int     `json:"id_sede" validate:"required"` // Añadido campo IDSede
-	Monto        float64 `json:"monto" validate:"required,min=0"`
-	Comprobante  string  `json:"comprobante"`
+	IDReserva    int    `json:"id_reserva" validate:"required"`
+	IDMetodoPago int    `json:"id_metodo_pago" validate:"required"`
+	IDCanal      int    `json:"id_canal" validate:"required"`
+	IDSede       int    `json:"id_sede" validate:"required"` // Añadido campo IDSede
+	Monto        Dinero `json:"monto" validate:"required,min=0"`
+	Moneda       string `json:"moneda" validate:"omitempty,oneof=PEN USD"` // Por defecto PEN
+	Comprobante  string `json:"comprobante"`
+
+	Conversion ConversionMoneda `json:"-"` // Equivalente en soles, fijado por el servicio
+
+	// Usuario que registra el pago, fijado por el controlador, y su caja abierta, fijada por el servicio
+	IDUsuario    int    `json:"-"`
+	RolUsuario   string `json:"-"`
+	IDCajaSesion *int   `json:"-"`
 }
 
 // ActualizarPagoRequest representa los datos para actualizar un pago
 type ActualizarPagoRequest struct {
-	IDMetodoPago int     `json:"id_metodo_pago" validate:"required"`
-	IDCanal      int     `json:"id_canal" validate:"required"`
-	IDSede       int     `json:"id_sede" validate:"required"` // Añadido campo IDSede
-	Monto        float64 `json:"monto" validate:"required,min=0"`
-	Comprobante  string  `json:"comprobante"`
-	Estado       string  `json:"estado" validate:"required,oneof=PROCESADO ANULADO"`
+	IDMetodoPago int    `json:"id_metodo_pago" validate:"required"`
+	IDCanal      int    `json:"id_canal" validate:"required"`
+	IDSede       int    `json:"id_sede" validate:"required"` // Añadido campo IDSede
+	Monto        Dinero `json:"monto" validate:"required,min=0"`
+	Moneda       string `json:"moneda" validate:"omitempty,oneof=PEN USD"` // Por defecto PEN
+	Comprobante  string `json:"comprobante"`
+	Estado       string `json:"estado" validate:"required,oneof=PROCESADO ANULADO"`
+
+	Conversion ConversionMoneda `json:"-"` // Equivalente en soles, fijado por el servicio
 }
 
 // CambiarEstadoPagoRequest representa los datos para cambiar el estado de un pago
 type CambiarEstadoPagoRequest struct {
 	Estado string `json:"estado" validate:"required,oneof=PROCESADO ANULADO"`
 }
+
+// ResultadoPagoReserva resume el efecto de registrar o revertir un pago sobre su reserva
+type ResultadoPagoReserva struct {
+	IDPago        int    `json:"id_pago"`
+	IDReserva     int    `json:"id_reserva"`
+	EstadoReserva string `json:"estado_reserva"`
+	TotalPagar    Dinero `json:"total_pagar"`
+	TotalPagado   Dinero `json:"total_pagado"`
+	Saldo         Dinero `json:"saldo"` // Positivo: pendiente de pago; negativo: pagado en exceso
+	EstadoPago    string `json:"estado_pago,omitempty"`
+	IDDevolucion  int    `json:"id_devolucion,omitempty"` // Devolución solicitada por un pago que no recuperó la reserva
+	IDInstancia   int    `json:"-"`
+	CupoLiberado  bool   `json:"-"` // La operación devolvió a la instancia el cupo de la reserva
+}
+
+// ErrPagoNoAplicable indica que un pago aprobado por una pasarela no puede registrarse sobre su reserva;
+// no se registra y queda para revisión y devolución
+var ErrPagoNoAplicable = errors.New("el pago no puede aplicarse a la reserva")
+
+// ValidarPagoPasarela verifica que un pago aprobado de una pasarela pueda registrarse sobre una reserva
+// Los pagos parciales se aceptan, pero no los de reservas canceladas ni los que superan el saldo pendiente.
+// El saldo, en soles, se expresa en la moneda del pago con el tipo de cambio con que se registra (el del cobro
+// de la reserva), redondeado hacia arriba como al cobrar en esa moneda
+func ValidarPagoPasarela(estadoReserva string, saldo Dinero, pago ConversionMoneda) error {
+	if estadoReserva == "CANCELADA" {
+		return fmt.Errorf("%w: la reserva está cancelada", ErrPagoNoAplicable)
+	}
+
+	maximo := saldo.Max(NuevoDinero(0, saldo.Moneda()))
+	if pago.Monto.Moneda() != saldo.Moneda() {
+		tipoCambio := &TipoCambio{Moneda: pago.Monto.Moneda(), Valor: pago.TipoCambio}
+		maximo = tipoCambio.DesdeMonedaBase(maximo)
+	}
+	if pago.Monto.Comparar(maximo) > 0 {
+		return fmt.Errorf("%w: el pago de %s %s supera el saldo pendiente de %s %s", ErrPagoNoAplicable,
+			pago.Monto.Moneda(), pago.Monto, maximo.Moneda(), maximo)
+	}
+
+	return nil
+}
+
+// Estados de pago de una reserva según lo pagado frente al total y al adelanto
+const (
+	EstadoPagoSinPago  = "SIN_PAGO"
+	EstadoPagoParcial  = "PARCIAL"  // Pagado por debajo del adelanto
+	EstadoPagoAdelanto = "ADELANTO" // Adelanto cubierto, con saldo pendiente
+	EstadoPagoPagado   = "PAGADO"
+)
+
+// SaldoReserva resume lo pagado y lo pendiente de una reserva, en soles
+type SaldoReserva struct {
+	TotalPagar         Dinero  `json:"total_pagar"`
+	TotalPagado        Dinero  `json:"total_pagado"`
+	Saldo              Dinero  `json:"saldo"` // Positivo: pendiente de pago; negativo: pagado en exceso
+	PorcentajeAdelanto float64 `json:"porcentaje_adelanto"`
+	AdelantoRequerido  Dinero  `json:"adelanto_requerido"`
+	AdelantoPendiente  Dinero  `json:"adelanto_pendiente"` // Lo que falta pagar para cubrir el adelanto
+	EstadoPago         string  `json:"estado_pago"`
+}
+
+// CalcularSaldoReserva calcula el saldo de una reserva y su estado de pago
+// El adelanto requerido es el porcentaje indicado del total, redondeado al céntimo
+func CalcularSaldoReserva(totalPagar, totalPagado Dinero, porcentajeAdelanto float64) SaldoReserva {
+	saldo := SaldoReserva{
+		TotalPagar:         totalPagar,
+		TotalPagado:        totalPagado,
+		Saldo:              totalPagar.Restar(totalPagado),
+		PorcentajeAdelanto: porcentajeAdelanto,
+		AdelantoRequerido:  totalPagar.Porcentaje(porcentajeAdelanto),
+	}
+	saldo.AdelantoPendiente = saldo.AdelantoRequerido.Restar(totalPagado).Max(NuevoDinero(0, totalPagar.Moneda()))
+
+	switch {
+	case !totalPagado.EsPositivo():
+		saldo.EstadoPago = EstadoPagoSinPago
+	case !saldo.Saldo.EsPositivo():
+		saldo.EstadoPago = EstadoPagoPagado
+	case saldo.AdelantoPendiente.EsCero():
+		saldo.EstadoPago = EstadoPagoAdelanto
+	default:
+		saldo.EstadoPago = EstadoPagoParcial
+	}
+
+	return saldo
+}
+
+// AdelantoCubierto indica si se pagó al menos el adelanto; una reserva sin pagos nunca lo cubre
+func (s SaldoReserva) AdelantoCubierto() bool {
+	return s.TotalPagado.EsPositivo() && s.AdelantoPendiente.EsCero()
+}
+
+// SaldoPendienteReserva representa una reserva vigente con saldo por cobrar antes de la salida
+type SaldoPendienteReserva struct {
+	IDReserva       int          `json:"id_reserva"`
+	Estado          string       `json:"estado"`
+	IDSede          int          `json:"id_sede"`
+	NombreSede      string       `json:"nombre_sede"`
+	IDCliente       int          `json:"id_cliente"`
+	NombreCliente   string       `json:"nombre_cliente"`
+	TelefonoCliente string       `json:"telefono_cliente,omitempty"`
+	CorreoCliente   string       `json:"correo_cliente,omitempty"`
+	IDVendedor      *int         `json:"id_vendedor,omitempty"`
+	NombreTour      string       `json:"nombre_tour"`
+	FechaTour       time.Time    `json:"fecha_tour"`
+	HoraInicioTour  string       `json:"hora_inicio_tour"`
+	DiasParaSalida  int          `json:"dias_para_salida"`
+	Saldo           SaldoReserva `json:"saldo"`
+}
@@ -0,0 +1,66 @@
+package entidades
+
+import "time"
+
+// Pasajero representa a una persona a bordo registrada en una reserva
+type Pasajero struct {
+	ID                         int       `json:"id_pasajero" db:"id_pasajero"`
+	IDReserva                  int       `json:"id_reserva" db:"id_reserva"`
+	IDTipoPasaje               *int      `json:"id_tipo_pasaje,omitempty" db:"id_tipo_pasaje"`
+	Nombres                    string    `json:"nombres" db:"nombres"`
+	Apellidos                  string    `json:"apellidos" db:"apellidos"`
+	TipoDocumento              string    `json:"tipo_documento" db:"tipo_documento"` // DNI, CE, Pasaporte
+	NumeroDocumento            string    `json:"numero_documento" db:"numero_documento"`
+	FechaNacimiento            time.Time `json:"fecha_nacimiento" db:"fecha_nacimiento"`
+	Nacionalidad               string    `json:"nacionalidad" db:"nacionalidad"`
+	ContactoEmergenciaNombre   string    `json:"contacto_emergencia_nombre" db:"contacto_emergencia_nombre"`
+	ContactoEmergenciaTelefono string    `json:"contacto_emergencia_telefono" db:"contacto_emergencia_telefono"`
+	Eliminado                  bool      `json:"eliminado" db:"eliminado"`
+
+	// Campos adicionales para mostrar información relacionada
+	NombreTipoPasaje string `json:"nombre_tipo_pasaje,omitempty" db:"-"`
+	Edad             int    `json:"edad" db:"-"` // Edad a la fecha del tour
+}
+
+// PasajeroRequest representa los datos de un pasajero al registrarlo en una reserva
+type PasajeroRequest struct {
+	IDTipoPasaje               *int   `json:"id_tipo_pasaje"`
+	Nombres                    string `json:"nombres" validate:"required"`
+	Apellidos                  string `json:"apellidos" validate:"required"`
+	TipoDocumento              string `json:"tipo_documento" validate:"required,oneof=DNI CE Pasaporte"`
+	NumeroDocumento            string `json:"numero_documento" validate:"required"`
+	FechaNacimiento            string `json:"fecha_nacimiento" validate:"required,datetime=2006-01-02"`
+	Nacionalidad               string `json:"nacionalidad"`
+	ContactoEmergenciaNombre   string `json:"contacto_emergencia_nombre" validate:"required"`
+	ContactoEmergenciaTelefono string `json:"contacto_emergencia_telefono" validate:"required"`
+}
+
+// RegistrarPasajerosRequest reemplaza la lista completa de pasajeros de una reserva
+// La cantidad debe coincidir con los pasajes comprados
+type RegistrarPasajerosRequest struct {
+	Pasajeros []PasajeroRequest `json:"pasajeros" validate:"required,min=1,dive"`
+}
+
+// PasajeroManifiesto representa una fila del manifiesto de embarque
+type PasajeroManifiesto struct {
+	Pasajero
+	NombreTitular string `json:"nombre_titular"`
+	EstadoReserva string `json:"estado_reserva"`
+}
+
+// ManifiestoInstancia representa el manifiesto de pasajeros de una instancia de tour
+type ManifiestoInstancia struct {
+	IDInstancia          int                   `json:"id_instancia"`
+	IDSede               int                   `json:"id_sede"`
+	IDChofer             *int                  `json:"id_chofer,omitempty"`
+	NombreTour           string                `json:"nombre_tour"`
+	NombreSede           string                `json:"nombre_sede"`
+	NombreEmbarcacion    string                `json:"nombre_embarcacion"`
+	NombreChofer         string                `json:"nombre_chofer"`
+	FechaTour            string                `json:"fecha_tour"`
+	HoraInicio           string                `json:"hora_inicio"`
+	HoraFin              string                `json:"hora_fin"`
+	TotalPasajes         int                   `json:"total_pasajes"`         // Pasajes vendidos en reservas vigentes
+	PasajerosRegistrados int                   `json:"pasajeros_registrados"` // Pasajeros con datos completos
+	Pasajeros            []*PasajeroManifiesto `json:"pasajeros"`
+}
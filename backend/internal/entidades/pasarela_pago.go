@@ -0,0 +1,98 @@
+package entidades
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pasarelas de pago en línea soportadas
+const (
+	PasarelaMercadoPago = "MERCADO_PAGO"
+	PasarelaIzipay      = "IZIPAY"
+	PasarelaFake        = "FAKE" // Pasarela en memoria para pruebas y desarrollo local
+)
+
+// Estados de un pago en la pasarela, comunes a todas las pasarelas
+const (
+	EstadoPasarelaAprobado    = "APROBADO"
+	EstadoPasarelaPendiente   = "PENDIENTE"
+	EstadoPasarelaRechazado   = "RECHAZADO"
+	EstadoPasarelaDevuelto    = "DEVUELTO"
+	EstadoPasarelaContracargo = "CONTRACARGO"
+)
+
+// prefijoReferenciaReserva identifica a qué reserva corresponde un pago en la pasarela
+const prefijoReferenciaReserva = "RESERVA-"
+
+// ReferenciaReserva arma la referencia externa con la que se envía una reserva a la pasarela
+func ReferenciaReserva(idReserva int) string {
+	return fmt.Sprintf("%s%d", prefijoReferenciaReserva, idReserva)
+}
+
+// ParsearReferenciaReserva obtiene el ID de reserva de una referencia externa con formato "RESERVA-12345"
+func ParsearReferenciaReserva(referencia string) (int, error) {
+	if !strings.HasPrefix(referencia, prefijoReferenciaReserva) {
+		return 0, errors.New("referencia externa del pago inválida")
+	}
+	idReserva, err := strconv.Atoi(strings.TrimPrefix(referencia, prefijoReferenciaReserva))
+	if err != nil || idReserva <= 0 {
+		return 0, errors.New("referencia externa del pago inválida")
+	}
+	return idReserva, nil
+}
+
+// SolicitudCheckout representa el cobro de una reserva que se inicia en la pasarela
+type SolicitudCheckout struct {
+	IDReserva   int
+	Descripcion string
+	Monto       Dinero // En la moneda del cobro
+	Cliente     *Cliente
+	FrontendURL string // Base de las URLs de retorno y notificación
+}
+
+// CheckoutPasarela representa el cobro creado en la pasarela al que se redirige al cliente
+type CheckoutPasarela struct {
+	Pasarela      string `json:"pasarela"`
+	ID            string `json:"id"`                        // Preferencia, formToken u orden según la pasarela
+	URLPago       string `json:"url_pago,omitempty"`        // Página de pago a la que se redirige al cliente
+	URLPagoPrueba string `json:"url_pago_prueba,omitempty"` // Página de pago del entorno de pruebas
+	ClavePublica  string `json:"clave_publica,omitempty"`   // Para pasarelas que embeben el formulario de pago
+}
+
+// PagoPasarela representa un pago consultado en la pasarela
+type PagoPasarela struct {
+	Pasarela          string    `json:"pasarela"`
+	ID                string    `json:"id"`
+	Estado            string    `json:"estado"`          // APROBADO, PENDIENTE, RECHAZADO, DEVUELTO, CONTRACARGO
+	EstadoOriginal    string    `json:"estado_original"` // Estado tal como lo informa la pasarela
+	Monto             Dinero    `json:"monto"`
+	ReferenciaExterna string    `json:"referencia_externa"`
+	Fecha             time.Time `json:"fecha"`
+}
+
+// ReembolsoPasarela representa un reembolso solicitado a la pasarela
+type ReembolsoPasarela struct {
+	ID     string `json:"id"`
+	Monto  Dinero `json:"monto"`
+	Estado string `json:"estado"`
+}
+
+// NotificacionPasarela representa una notificación recibida de una pasarela, tal como llegó
+type NotificacionPasarela struct {
+	Cabeceras  http.Header
+	Parametros url.Values // Parámetros de la URL
+	Cuerpo     []byte
+}
+
+// EventoPasarela representa una notificación ya verificada: el pago al que se refiere y cómo identificarla
+type EventoPasarela struct {
+	IDPago         string
+	IDNotificacion string
+	Tipo           string        // Solo las notificaciones de tipo "payment" se aplican sobre las reservas
+	Pago           *PagoPasarela // Si la notificación trae el pago completo no hace falta consultarlo
+}
@@ -0,0 +1,62 @@
+package entidades
+
+import "time"
+
+// PoliticaCancelacion representa un tramo de una política de cancelación
+// Cancelando con al menos HorasAntesMinimo horas de anticipación se devuelve PorcentajeDevolucion del monto pagado
+type PoliticaCancelacion struct {
+	ID                   int     `json:"id_politica" db:"id_politica"`
+	IDTipoTour           *int    `json:"id_tipo_tour,omitempty" db:"id_tipo_tour"`
+	IDSede               *int    `json:"id_sede,omitempty" db:"id_sede"`
+	Nombre               string  `json:"nombre" db:"nombre"`
+	HorasAntesMinimo     int     `json:"horas_antes_minimo" db:"horas_antes_minimo"`
+	PorcentajeDevolucion float64 `json:"porcentaje_devolucion" db:"porcentaje_devolucion"`
+	Eliminado            bool    `json:"eliminado" db:"eliminado"`
+
+	// Campos adicionales para mostrar información relacionada
+	NombreTipoTour string `json:"nombre_tipo_tour,omitempty" db:"-"`
+	NombreSede     string `json:"nombre_sede,omitempty" db:"-"`
+}
+
+// NuevaPoliticaCancelacionRequest representa los datos para crear un tramo de política de cancelación
+// Debe indicarse el tipo de tour o la sede a la que aplica
+type NuevaPoliticaCancelacionRequest struct {
+	IDTipoTour           *int    `json:"id_tipo_tour" validate:"required_without=IDSede"`
+	IDSede               *int    `json:"id_sede" validate:"required_without=IDTipoTour"`
+	Nombre               string  `json:"nombre" validate:"required"`
+	HorasAntesMinimo     int     `json:"horas_antes_minimo" validate:"min=0"`
+	PorcentajeDevolucion float64 `json:"porcentaje_devolucion" validate:"min=0,max=100"`
+}
+
+// ActualizarPoliticaCancelacionRequest representa los datos para actualizar un tramo de política de cancelación
+type ActualizarPoliticaCancelacionRequest struct {
+	Nombre               string  `json:"nombre" validate:"required"`
+	HorasAntesMinimo     int     `json:"horas_antes_minimo" validate:"min=0"`
+	PorcentajeDevolucion float64 `json:"porcentaje_devolucion" validate:"min=0,max=100"`
+}
+
+// DatosCancelacionReserva contiene los datos de una reserva necesarios para cotizar su cancelación
+type DatosCancelacionReserva struct {
+	IDReserva         int
+	IDTipoTour        int
+	IDSede            int
+	Estado            string
+	InicioTour        time.Time // Fecha y hora de inicio de la instancia
+	Ahora             time.Time // Hora actual según la base de datos
+	TotalReembolsable Dinero    // Pagos vigentes menos devoluciones ya solicitadas
+}
+
+// CotizacionCancelacion representa el resultado de cotizar la cancelación de una reserva
+type CotizacionCancelacion struct {
+	IDReserva            int       `json:"id_reserva"`
+	IDSede               int       `json:"id_sede"`
+	Permitida            bool      `json:"permitida"`
+	Motivo               string    `json:"motivo,omitempty"` // Razón por la que no se permite cancelar
+	InicioTour           time.Time `json:"inicio_tour"`
+	HorasAntes           float64   `json:"horas_antes"`
+	IDPolitica           *int      `json:"id_politica,omitempty"` // Tramo aplicado
+	NombrePolitica       string    `json:"nombre_politica,omitempty"`
+	PorcentajeDevolucion float64   `json:"porcentaje_devolucion"`
+	TotalPagado          Dinero    `json:"total_pagado"`
+	MontoDevolucion      Dinero    `json:"monto_devolucion"`
+}
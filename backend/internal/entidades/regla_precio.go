@@ -0,0 +1,121 @@
+package entidades
+
+import "time"
+
+// ReglaPrecio representa un ajuste del precio de los tipos de pasaje según la temporada, el día,
+// el horario o la ocupación de la instancia. Las restricciones nulas no limitan la regla
+type ReglaPrecio struct {
+	ID              int        `json:"id_regla_precio" db:"id_regla_precio"`
+	Nombre          string     `json:"nombre" db:"nombre"`
+	TipoRegla       string     `json:"tipo_regla" db:"tipo_regla"` // TEMPORADA, DIA_SEMANA, HORARIO, OCUPACION
+	IDTipoPasaje    *int       `json:"id_tipo_pasaje,omitempty" db:"id_tipo_pasaje"`
+	IDTipoTour      *int       `json:"id_tipo_tour,omitempty" db:"id_tipo_tour"`
+	IDSede          *int       `json:"id_sede,omitempty" db:"id_sede"`
+	FechaInicio     *time.Time `json:"fecha_inicio,omitempty" db:"fecha_inicio"`
+	FechaFin        *time.Time `json:"fecha_fin,omitempty" db:"fecha_fin"`
+	DiasSemana      string     `json:"dias_semana,omitempty" db:"dias_semana"` // ISO: 1 lunes ... 7 domingo, separados por comas
+	HoraInicio      string     `json:"hora_inicio,omitempty" db:"hora_inicio"` // HH:MM
+	HoraFin         string     `json:"hora_fin,omitempty" db:"hora_fin"`       // HH:MM, exclusiva
+	OcupacionMinima *float64   `json:"ocupacion_minima,omitempty" db:"ocupacion_minima"`
+	Porcentaje      float64    `json:"porcentaje" db:"porcentaje"` // Negativo para rebajas
+	Monto           Dinero     `json:"monto" db:"monto"`           // Negativo para rebajas
+	Prioridad       int        `json:"prioridad" db:"prioridad"`
+	Activo          bool       `json:"activo" db:"activo"`
+	Eliminado       bool       `json:"eliminado" db:"eliminado"`
+	FechaRegistro   time.Time  `json:"fecha_registro" db:"fecha_registro"`
+}
+
+// NuevaReglaPrecioRequest representa los datos para crear una regla de precio
+type NuevaReglaPrecioRequest struct {
+	Nombre          string     `json:"nombre" validate:"required,max=100"`
+	TipoRegla       string     `json:"tipo_regla" validate:"required,oneof=TEMPORADA DIA_SEMANA HORARIO OCUPACION"`
+	IDTipoPasaje    *int       `json:"id_tipo_pasaje"`
+	IDTipoTour      *int       `json:"id_tipo_tour"`
+	IDSede          *int       `json:"id_sede"`
+	FechaInicio     *time.Time `json:"fecha_inicio" validate:"required_if=TipoRegla TEMPORADA"`
+	FechaFin        *time.Time `json:"fecha_fin" validate:"required_if=TipoRegla TEMPORADA,omitempty,gtefield=FechaInicio"`
+	DiasSemana      string     `json:"dias_semana" validate:"required_if=TipoRegla DIA_SEMANA"`
+	HoraInicio      string     `json:"hora_inicio" validate:"required_if=TipoRegla HORARIO,omitempty,datetime=15:04"`
+	HoraFin         string     `json:"hora_fin" validate:"required_if=TipoRegla HORARIO,omitempty,datetime=15:04"`
+	OcupacionMinima *float64   `json:"ocupacion_minima" validate:"required_if=TipoRegla OCUPACION,omitempty,min=0,max=100"`
+	Porcentaje      float64    `json:"porcentaje" validate:"gt=-100,max=1000"`
+	Monto           Dinero     `json:"monto"`
+	Prioridad       int        `json:"prioridad"`
+}
+
+// ActualizarReglaPrecioRequest representa los datos para actualizar una regla de precio
+type ActualizarReglaPrecioRequest struct {
+	Nombre          string     `json:"nombre" validate:"required,max=100"`
+	TipoRegla       string     `json:"tipo_regla" validate:"required,oneof=TEMPORADA DIA_SEMANA HORARIO OCUPACION"`
+	IDTipoPasaje    *int       `json:"id_tipo_pasaje"`
+	IDTipoTour      *int       `json:"id_tipo_tour"`
+	IDSede          *int       `json:"id_sede"`
+	FechaInicio     *time.Time `json:"fecha_inicio" validate:"required_if=TipoRegla TEMPORADA"`
+	FechaFin        *time.Time `json:"fecha_fin" validate:"required_if=TipoRegla TEMPORADA,omitempty,gtefield=FechaInicio"`
+	DiasSemana      string     `json:"dias_semana" validate:"required_if=TipoRegla DIA_SEMANA"`
+	HoraInicio      string     `json:"hora_inicio" validate:"required_if=TipoRegla HORARIO,omitempty,datetime=15:04"`
+	HoraFin         string     `json:"hora_fin" validate:"required_if=TipoRegla HORARIO,omitempty,datetime=15:04"`
+	OcupacionMinima *float64   `json:"ocupacion_minima" validate:"required_if=TipoRegla OCUPACION,omitempty,min=0,max=100"`
+	Porcentaje      float64    `json:"porcentaje" validate:"gt=-100,max=1000"`
+	Monto           Dinero     `json:"monto"`
+	Prioridad       int        `json:"prioridad"`
+	Activo          bool       `json:"activo"`
+}
+
+// DatosPrecioInstancia reúne los datos de una instancia de tour contra los que se evalúan las reglas de precio
+type DatosPrecioInstancia struct {
+	IDInstancia    int
+	IDTipoTour     int
+	IDSede         int
+	Fecha          time.Time
+	HoraInicio     string // HH:MM
+	CupoMaximo     int
+	CupoDisponible int
+	Ocupacion      float64 // Porcentaje de cupos vendidos
+}
+
+// ReglaPrecioAplicada registra el efecto de una regla sobre el precio de un tipo de pasaje
+type ReglaPrecioAplicada struct {
+	IDReglaPrecio int    `json:"id_regla_precio"`
+	Nombre        string `json:"nombre"`
+	TipoRegla     string `json:"tipo_regla"`
+	PrecioAntes   Dinero `json:"precio_antes"`
+	PrecioDespues Dinero `json:"precio_despues"`
+}
+
+// PrecioPasaje representa el precio efectivo de un tipo de pasaje en una instancia
+type PrecioPasaje struct {
+	IDTipoPasaje   int                   `json:"id_tipo_pasaje"`
+	Nombre         string                `json:"nombre"`
+	Moneda         string                `json:"moneda"` // Moneda del costo del tipo de pasaje
+	PrecioBase     Dinero                `json:"precio_base"`
+	PrecioUnitario Dinero                `json:"precio_unitario"`
+	PrecioSoles    Dinero                `json:"precio_soles"` // Precio unitario convertido con el tipo de cambio del día
+	Reglas         []ReglaPrecioAplicada `json:"reglas"`
+}
+
+// PreciosInstancia contiene los precios efectivos de los tipos de pasaje de una instancia
+type PreciosInstancia struct {
+	IDInstancia int             `json:"id_instancia"`
+	Fecha       time.Time       `json:"fecha"`
+	HoraInicio  string          `json:"hora_inicio"`
+	Ocupacion   float64         `json:"ocupacion"`
+	Precios     []*PrecioPasaje `json:"precios"`
+}
+
+// RegistroReglaPrecio representa la auditoría de una regla aplicada a los pasajes vendidos de una reserva
+type RegistroReglaPrecio struct {
+	ID            int       `json:"id_regla_precio_aplicada" db:"id_regla_precio_aplicada"`
+	IDReserva     int       `json:"id_reserva" db:"id_reserva"`
+	IDTipoPasaje  int       `json:"id_tipo_pasaje" db:"id_tipo_pasaje"`
+	IDReglaPrecio int       `json:"id_regla_precio" db:"id_regla_precio"`
+	PrecioAntes   Dinero    `json:"precio_antes" db:"precio_antes"`
+	PrecioDespues Dinero    `json:"precio_despues" db:"precio_despues"`
+	Moneda        string    `json:"moneda" db:"moneda"`
+	FechaRegistro time.Time `json:"fecha_registro" db:"fecha_registro"`
+
+	// Campos adicionales para mostrar información relacionada
+	NombrePasaje string `json:"nombre_pasaje,omitempty" db:"-"`
+	NombreRegla  string `json:"nombre_regla,omitempty" db:"-"`
+	TipoRegla    string `json:"tipo_regla,omitempty" db:"-"`
+}
@@ -11,11 +11,18 @@ type Reserva struct {
 	IDCanal      int       `json:"id_canal" db:"id_canal"`
 	IDSede       int       `json:"id_sede" db:"id_sede"`
 	FechaReserva time.Time `json:"fecha_reserva" db:"fecha_reserva"`
-	TotalPagar   float64   `json:"total_pagar" db:"total_pagar"`
+	TotalPagar   Dinero    `json:"total_pagar" db:"total_pagar"`
+	TipoCambio   *float64  `json:"tipo_cambio,omitempty" db:"tipo_cambio"` // Usado en la venta si hubo precios o cobro en dólares
 	Notas        string    `json:"notas" db:"notas"`
-	Estado       string    `json:"estado" db:"estado"` // RESERVADO, CANCELADA, CONFIRMADA, etc.
+	Estado       string    `json:"estado" db:"estado"` // RESERVADO, CANCELADA, CONFIRMADA, EXPIRADA, etc.
 	Eliminado    bool      `json:"eliminado" db:"eliminado"`
 
+	// Fin de la retención temporal de cupos (solo reservas web pendientes de pago)
+	FechaExpiracion *time.Time `json:"fecha_expiracion,omitempty" db:"fecha_expiracion"`
+
+	// Lo pagado y lo pendiente, calculado con los pagos procesados y el adelanto de la sede
+	Saldo SaldoReserva `json:"saldo" db:"-"`
+
 	// Campos adicionales para mostrar información relacionada
 	NombreCliente   string                 `json:"nombre_cliente,omitempty" db:"-"`
 	NombreVendedor  string                 `json:"nombre_vendedor,omitempty" db:"-"`
@@ -38,25 +45,30 @@ type PasajeCantidad struct {
 
 // PaquetePasajeDetalle representa un paquete de pasajes incluido en la reserva
 type PaquetePasajeDetalle struct {
-	IDPaquete      int     `json:"id_paquete" db:"id_paquete"`
-	NombrePaquete  string  `json:"nombre_paquete" db:"nombre_paquete"`
-	Cantidad       int     `json:"cantidad" db:"cantidad"`
-	PrecioUnitario float64 `json:"precio_unitario" db:"precio_unitario"`
-	Subtotal       float64 `json:"subtotal" db:"subtotal"`
-	CantidadTotal  int     `json:"cantidad_total" db:"cantidad_total"` // Total de pasajeros en el paquete
+	IDPaquete      int    `json:"id_paquete" db:"id_paquete"`
+	NombrePaquete  string `json:"nombre_paquete" db:"nombre_paquete"`
+	Cantidad       int    `json:"cantidad" db:"cantidad"`
+	PrecioUnitario Dinero `json:"precio_unitario" db:"precio_unitario"`
+	Subtotal       Dinero `json:"subtotal" db:"subtotal"`
+	CantidadTotal  int    `json:"cantidad_total" db:"cantidad_total"` // Total de pasajeros en el paquete
 }
 
 // NuevaReservaRequest representa los datos necesarios para crear una nueva reserva
 type NuevaReservaRequest struct {
-	IDCliente       int                     `json:"id_cliente" validate:"required"`
-	IDInstancia     int                     `json:"id_instancia" validate:"required"`
-	IDCanal         int                     `json:"id_canal" validate:"required"`
-	IDSede          int                     `json:"id_sede" validate:"required"`
-	IDVendedor      *int                    `json:"id_vendedor,omitempty"` // Opcional, solo si es reserva en LOCAL
-	TotalPagar      float64                 `json:"total_pagar" validate:"required,min=0"`
-	Notas           string                  `json:"notas"`
-	CantidadPasajes []PasajeCantidadRequest `json:"cantidad_pasajes" validate:"dive"`
-	Paquetes        []PaqueteRequest        `json:"paquetes" validate:"dive"`
+	IDCliente         int                     `json:"id_cliente" validate:"required"`
+	IDInstancia       int                     `json:"id_instancia" validate:"required"`
+	IDCanal           int                     `json:"id_canal" validate:"required"`
+	IDSede            int                     `json:"id_sede" validate:"required"`
+	IDVendedor        *int                    `json:"id_vendedor,omitempty"`                  // Opcional, solo si es reserva en LOCAL
+	TotalPagar        Dinero                  `json:"total_pagar" validate:"omitempty,min=0"` // Opcional; si se envía debe coincidir con el total calculado
+	Notas             string                  `json:"notas"`
+	CantidadPasajes   []PasajeCantidadRequest `json:"cantidad_pasajes" validate:"dive"`
+	Paquetes          []PaqueteRequest        `json:"paquetes" validate:"dive"`
+	CodigoPromocional string                  `json:"codigo_promocional,omitempty"`
+	Promocion         *AplicacionPromocion    `json:"-"` // Descuento validado por el servicio; se canjea al guardar la reserva
+	Precios           map[int]*PrecioPasaje   `json:"-"` // Precios efectivos resueltos por el servicio
+	PreciosPaquetes   map[int]Dinero          `json:"-"` // Precio unitario en soles de cada paquete
+	TipoCambio        *TipoCambio             `json:"-"` // Tipo de cambio del día, si la venta usó dólares
 }
 
 // PasajeCantidadRequest representa la cantidad de pasajes de un tipo en la solicitud
@@ -77,12 +89,16 @@ type ActualizarReservaRequest struct {
 	IDInstancia     int                     `json:"id_instancia" validate:"required"`
 	IDCanal         int                     `json:"id_canal" validate:"required"`
 	IDSede          int                     `json:"id_sede" validate:"required"`
-	IDVendedor      *int                    `json:"id_vendedor,omitempty"` // Opcional, solo si es reserva en LOCAL
-	TotalPagar      float64                 `json:"total_pagar" validate:"required,min=0"`
+	IDVendedor      *int                    `json:"id_vendedor,omitempty"`                  // Opcional, solo si es reserva en LOCAL
+	TotalPagar      Dinero                  `json:"total_pagar" validate:"omitempty,min=0"` // Opcional; si se envía debe coincidir con el total calculado
 	Notas           string                  `json:"notas"`
 	Estado          string                  `json:"estado" validate:"required,oneof=RESERVADO CANCELADA CONFIRMADA"`
 	CantidadPasajes []PasajeCantidadRequest `json:"cantidad_pasajes" validate:"dive"`
 	Paquetes        []PaqueteRequest        `json:"paquetes" validate:"dive"`
+	Promocion       *AplicacionPromocion    `json:"-"` // Descuento recalculado del código ya canjeado por la reserva
+	Precios         map[int]*PrecioPasaje   `json:"-"` // Precios efectivos resueltos por el servicio
+	PreciosPaquetes map[int]Dinero          `json:"-"` // Precio unitario en soles de cada paquete
+	TipoCambio      *TipoCambio             `json:"-"` // Tipo de cambio del día, si la venta usó dólares
 }
 
 // CambiarEstadoReservaRequest representa los datos para cambiar el estado de una reserva
@@ -90,23 +106,107 @@ type CambiarEstadoReservaRequest struct {
 	Estado string `json:"estado" validate:"required,oneof=RESERVADO CANCELADA CONFIRMADA"`
 }
 
-// ReservaMercadoPagoRequest representa los datos para crear una reserva desde Mercado Pago
+// ReservaMercadoPagoRequest representa los datos para crear una reserva web con pago en línea
+// Conserva su nombre de cuando Mercado Pago era la única pasarela
 type ReservaMercadoPagoRequest struct {
-	IDCliente       int                     `json:"id_cliente" validate:"required"`
-	IDInstancia     int                     `json:"id_instancia" validate:"required"`
-	TotalPagar      float64                 `json:"total_pagar" validate:"required,min=0"`
-	CantidadPasajes []PasajeCantidadRequest `json:"cantidad_pasajes" validate:"dive"`
-	Paquetes        []PaqueteRequest        `json:"paquetes" validate:"dive"`
-	Email           string                  `json:"email" validate:"required,email"`
-	Telefono        string                  `json:"telefono"`
-	Documento       string                  `json:"documento"`
+	IDCliente         int                     `json:"id_cliente" validate:"required"`
+	IDInstancia       int                     `json:"id_instancia" validate:"required"`
+	TotalPagar        Dinero                  `json:"total_pagar" validate:"omitempty,min=0"` // Opcional; si se envía debe coincidir con el total calculado
+	CantidadPasajes   []PasajeCantidadRequest `json:"cantidad_pasajes" validate:"dive"`
+	Paquetes          []PaqueteRequest        `json:"paquetes" validate:"dive"`
+	Email             string                  `json:"email" validate:"required,email"`
+	Telefono          string                  `json:"telefono"`
+	Documento         string                  `json:"documento"`
+	CodigoPromocional string                  `json:"codigo_promocional,omitempty"`
+	Moneda            string                  `json:"moneda" validate:"omitempty,oneof=PEN USD"`                    // Moneda del cobro; por defecto PEN
+	Pasarela          string                  `json:"pasarela" validate:"omitempty,oneof=MERCADO_PAGO IZIPAY FAKE"` // Por defecto la configurada
 }
 
-// ReservaMercadoPagoResponse representa la respuesta a una solicitud de reserva por Mercado Pago
+// ReservaMercadoPagoResponse representa la respuesta a una solicitud de reserva con pago en línea
 type ReservaMercadoPagoResponse struct {
 	IDReserva        int    `json:"id_reserva"`
 	NombreTour       string `json:"nombre_tour"`
-	PreferenceID     string `json:"preference_id"`
-	InitPoint        string `json:"init_point"`
+	Pasarela         string `json:"pasarela"`
+	PreferenceID     string `json:"preference_id"` // ID del cobro: preferencia de Mercado Pago o formToken de Izipay
+	InitPoint        string `json:"init_point"`    // Página de pago, en las pasarelas que redirigen al cliente
 	SandboxInitPoint string `json:"sandbox_init_point"`
+	ClavePublica     string `json:"clave_publica,omitempty"` // Para las pasarelas con formulario embebido
+	Monto            Dinero `json:"monto"`                   // Importe del cobro, en su moneda
+	Moneda           string `json:"moneda"`
+}
+
+// CotizarReservaRequest representa los pasajes y paquetes para los que se solicita una cotización
+// La sede y el canal son los de la venta; sin ellos se cotiza como venta web
+type CotizarReservaRequest struct {
+	IDInstancia       int                     `json:"id_instancia" validate:"required"`
+	IDSede            int                     `json:"id_sede,omitempty"`
+	IDCanal           int                     `json:"id_canal,omitempty"`
+	IDCliente         int                     `json:"id_cliente,omitempty"` // Para validar los usos por cliente del código promocional
+	CantidadPasajes   []PasajeCantidadRequest `json:"cantidad_pasajes" validate:"dive"`
+	Paquetes          []PaqueteRequest        `json:"paquetes" validate:"dive"`
+	CodigoPromocional string                  `json:"codigo_promocional,omitempty"`
+}
+
+// LineaCotizacionReserva representa un tipo de pasaje o paquete cotizado con su precio vigente
+type LineaCotizacionReserva struct {
+	Tipo           string                `json:"tipo"` // PASAJE, PAQUETE
+	ID             int                   `json:"id"`
+	Descripcion    string                `json:"descripcion"`
+	Cantidad       int                   `json:"cantidad"`
+	Pasajeros      int                   `json:"pasajeros"`
+	Moneda         string                `json:"moneda"`          // Moneda de la lista de precios
+	PrecioBase     Dinero                `json:"precio_base"`     // Costo del tipo de pasaje o precio del paquete, en su moneda
+	PrecioUnitario Dinero                `json:"precio_unitario"` // Precio base ajustado por las reglas de precio, en soles
+	Subtotal       Dinero                `json:"subtotal"`
+	Reglas         []ReglaPrecioAplicada `json:"reglas,omitempty"`
+}
+
+// CotizacionReserva representa el total de una reserva calculado con los precios vigentes
+type CotizacionReserva struct {
+	IDInstancia     int                      `json:"id_instancia"`
+	IDTipoTour      int                      `json:"id_tipo_tour"`
+	Lineas          []LineaCotizacionReserva `json:"lineas"`
+	TotalPasajeros  int                      `json:"total_pasajeros"`
+	Subtotal        Dinero                   `json:"subtotal"` // Suma de las líneas a precio efectivo
+	Descuento       Dinero                   `json:"descuento"`
+	Promocion       *AplicacionPromocion     `json:"promocion,omitempty"`
+	Total           Dinero                   `json:"total"`
+	TipoCambio      *TipoCambio              `json:"tipo_cambio,omitempty"` // Usado para convertir los precios en dólares
+	Precios         map[int]*PrecioPasaje    `json:"-"`                     // Precio efectivo de cada tipo de pasaje cotizado
+	PreciosPaquetes map[int]Dinero           `json:"-"`                     // Precio unitario en soles de cada paquete cotizado
+}
+
+// ReprogramarReservaRequest representa los datos para mover una reserva a otra instancia del mismo tipo de tour
+type ReprogramarReservaRequest struct {
+	IDInstanciaDestino int    `json:"id_instancia_destino" validate:"required"`
+	Motivo             string `json:"motivo"`
+
+	Precios map[int]*PrecioPasaje `json:"-"` // Precios efectivos en la instancia de destino, resueltos por el servicio
+}
+
+// ReprogramacionReserva representa un cambio de instancia registrado en el historial de una reserva
+type ReprogramacionReserva struct {
+	ID                  int       `json:"id_reprogramacion" db:"id_reprogramacion"`
+	IDReserva           int       `json:"id_reserva" db:"id_reserva"`
+	IDInstanciaOrigen   int       `json:"id_instancia_origen" db:"id_instancia_origen"`
+	IDInstanciaDestino  int       `json:"id_instancia_destino" db:"id_instancia_destino"`
+	TotalAnterior       Dinero    `json:"total_anterior" db:"total_anterior"`
+	TotalNuevo          Dinero    `json:"total_nuevo" db:"total_nuevo"`
+	Diferencia          Dinero    `json:"diferencia" db:"diferencia"` // Positivo: el cliente debe pagar más
+	Motivo              string    `json:"motivo" db:"motivo"`
+	Origen              string    `json:"origen" db:"origen"` // ADMIN, VENDEDOR, CLIENTE
+	IDUsuario           *int      `json:"id_usuario,omitempty" db:"id_usuario"`
+	FechaReprogramacion time.Time `json:"fecha_reprogramacion" db:"fecha_reprogramacion"`
+
+	// Campos adicionales para mostrar información relacionada
+	FechaOrigen  string `json:"fecha_origen,omitempty" db:"-"`
+	FechaDestino string `json:"fecha_destino,omitempty" db:"-"`
+}
+
+// ResultadoReprogramacion resume el efecto de reprogramar una reserva
+type ResultadoReprogramacion struct {
+	ReprogramacionReserva
+	EstadoReserva string `json:"estado_reserva"`
+	TotalPagado   Dinero `json:"total_pagado"`
+	Saldo         Dinero `json:"saldo"` // Positivo: pendiente de pago; negativo: pagado en exceso
 }
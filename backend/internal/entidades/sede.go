@@ -18,6 +18,12 @@ type Sede struct {
 	Eliminado bool      `json:"eliminado" db:"eliminado"`
 	CreatedAt time.Time `json:"created_at,omitempty" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at,omitempty" db:"updated_at"`
+
+	// Minutos que una reserva web retiene cupos antes de expirar
+	MinutosRetencionReserva int `json:"minutos_retencion_reserva" db:"minutos_retencion_reserva"`
+
+	// Porcentaje del total de una reserva que se cobra como adelanto
+	PorcentajeAdelanto float64 `json:"porcentaje_adelanto" db:"porcentaje_adelanto"`
 }
 
 // NuevaSedeRequest representa los datos necesarios para crear una nueva sede
@@ -43,3 +49,14 @@ type ActualizarSedeRequest struct {
 	Pais      string `json:"pais" validate:"required"`
 	ImageURL  string `json:"image_url"` // AGREGADO
 }
+
+// ActualizarRetencionReservaRequest representa los datos para configurar el tiempo de retención de cupos
+type ActualizarRetencionReservaRequest struct {
+	MinutosRetencionReserva int `json:"minutos_retencion_reserva" validate:"required,min=1,max=1440"`
+}
+
+// ActualizarAdelantoReservaRequest representa los datos para configurar el adelanto de las reservas
+// Con 0 no se exige adelanto
+type ActualizarAdelantoReservaRequest struct {
+	PorcentajeAdelanto float64 `json:"porcentaje_adelanto" validate:"min=0,max=100"`
+}
@@ -0,0 +1,41 @@
+package entidades
+
+import "time"
+
+// SerieComprobante representa una serie de numeración de comprobantes de una sede (por ejemplo B001 o F001)
+type SerieComprobante struct {
+	ID                int       `json:"id_serie" db:"id_serie"`
+	IDSede            int       `json:"id_sede" db:"id_sede"`
+	Tipo              string    `json:"tipo" db:"tipo"` // BOLETA, FACTURA, NOTA_CREDITO, NOTA_DEBITO
+	Serie             string    `json:"serie" db:"serie"`
+	UltimoCorrelativo int       `json:"ultimo_correlativo" db:"ultimo_correlativo"`
+	Activo            bool      `json:"activo" db:"activo"`
+	FechaCreacion     time.Time `json:"fecha_creacion" db:"fecha_creacion"`
+
+	// Campos adicionales para mostrar información relacionada
+	NombreSede string `json:"nombre_sede,omitempty" db:"-"`
+}
+
+// NuevaSerieComprobanteRequest representa los datos para registrar una serie
+// CorrelativoInicial permite continuar una numeración que venía de otro sistema
+type NuevaSerieComprobanteRequest struct {
+	IDSede             int    `json:"id_sede" validate:"required"`
+	Tipo               string `json:"tipo" validate:"required,oneof=BOLETA FACTURA NOTA_CREDITO NOTA_DEBITO"`
+	Serie              string `json:"serie" validate:"required,len=4,alphanum,uppercase"`
+	CorrelativoInicial int    `json:"correlativo_inicial" validate:"min=0,max=99999998"`
+}
+
+// CambiarEstadoSerieRequest representa los datos para activar o desactivar una serie
+type CambiarEstadoSerieRequest struct {
+	Activo *bool `json:"activo" validate:"required"`
+}
+
+// UsoSerieComprobante resume la numeración consumida de una serie
+type UsoSerieComprobante struct {
+	SerieComprobante
+	Emitidos        int      `json:"emitidos"`
+	Anulados        int      `json:"anulados"`
+	NumerosAnulados []string `json:"numeros_anulados"`
+	UltimoNumero    string   `json:"ultimo_numero,omitempty"`
+	Disponibles     int      `json:"disponibles"` // Correlativos que quedan antes de agotar la serie
+}
@@ -0,0 +1,108 @@
+package entidades
+
+import (
+	"math"
+	"time"
+)
+
+// MonedaDolares es la moneda extranjera aceptada en precios y cobros
+const MonedaDolares = "USD"
+
+// TipoCambio representa el valor en soles de una unidad de moneda extranjera en un día
+type TipoCambio struct {
+	ID            int       `json:"id_tipo_cambio" db:"id_tipo_cambio"`
+	Fecha         time.Time `json:"fecha" db:"fecha"`
+	Moneda        string    `json:"moneda" db:"moneda"`
+	Valor         float64   `json:"valor" db:"valor"`   // Soles por unidad, hasta cuatro decimales
+	Origen        string    `json:"origen" db:"origen"` // MANUAL, ARCHIVO
+	FechaRegistro time.Time `json:"fecha_registro" db:"fecha_registro"`
+}
+
+// NuevoTipoCambioRequest representa los datos para registrar el tipo de cambio de un día
+// Si ya existe un tipo de cambio para la fecha y moneda, se reemplaza
+type NuevoTipoCambioRequest struct {
+	Fecha  time.Time `json:"fecha" validate:"required"`
+	Moneda string    `json:"moneda" validate:"required,oneof=USD"`
+	Valor  float64   `json:"valor" validate:"required,gt=0,max=1000"`
+	Origen string    `json:"-"` // Lo fija el servicio
+}
+
+// ResultadoImportacionTipoCambio resume la importación de un archivo de tipos de cambio
+type ResultadoImportacionTipoCambio struct {
+	Registrados int      `json:"registrados"`
+	Errores     []string `json:"errores"`
+}
+
+// ConversionMoneda registra un monto, su equivalente en soles y el tipo de cambio usado
+type ConversionMoneda struct {
+	Monto      Dinero  `json:"monto"`
+	TipoCambio float64 `json:"tipo_cambio"`
+	MontoBase  Dinero  `json:"monto_base"`
+}
+
+// IngresoMoneda consolida los pagos recibidos en una moneda y las devoluciones ejecutadas sobre ellos
+type IngresoMoneda struct {
+	Moneda         string `json:"moneda"`
+	CantidadPagos  int    `json:"cantidad_pagos"`
+	Total          Dinero `json:"total"`           // En la moneda del pago
+	Devuelto       Dinero `json:"devuelto"`        // En la moneda del pago
+	Neto           Dinero `json:"neto"`            // Total menos lo devuelto
+	NetoConvertido Dinero `json:"neto_convertido"` // En soles, con el tipo de cambio de cada pago
+}
+
+// ReporteIngresosMoneda presenta los ingresos de un periodo por moneda y su total en soles
+type ReporteIngresosMoneda struct {
+	FechaInicio      time.Time        `json:"fecha_inicio"`
+	FechaFin         time.Time        `json:"fecha_fin"`
+	IDSede           *int             `json:"id_sede,omitempty"`
+	Monedas          []*IngresoMoneda `json:"monedas"`
+	TotalConsolidado Dinero           `json:"total_consolidado"`
+}
+
+// valorDiezmilesimos expresa el tipo de cambio como entero para convertir sin errores de redondeo
+func (t *TipoCambio) valorDiezmilesimos() int64 {
+	return int64(math.Round(t.Valor * 10000))
+}
+
+// AMonedaBase convierte a soles un monto expresado en la moneda del tipo de cambio
+func (t *TipoCambio) AMonedaBase(monto Dinero) Dinero {
+	return monto.Prorratear(t.valorDiezmilesimos(), 10000).EnMoneda(MonedaPorDefecto)
+}
+
+// DesdeMonedaBase convierte un monto en soles a la moneda del tipo de cambio
+// Se redondea hacia arriba para que el cobro en moneda extranjera cubra el monto en soles
+func (t *TipoCambio) DesdeMonedaBase(monto Dinero) Dinero {
+	valor := t.valorDiezmilesimos()
+	if valor <= 0 {
+		return NuevoDinero(0, t.Moneda)
+	}
+	dividendo := monto.Centimos() * 10000
+	centimos := dividendo / valor
+	if dividendo%valor > 0 {
+		centimos++
+	}
+	return NuevoDinero(centimos, t.Moneda)
+}
+
+// EquivalenteDesdeMonedaBase convierte un monto en soles a la moneda del tipo de cambio, redondeado al céntimo
+// Se usa para expresar documentos en moneda extranjera sin favorecer a ninguna de las partes
+func (t *TipoCambio) EquivalenteDesdeMonedaBase(monto Dinero) Dinero {
+	valor := t.valorDiezmilesimos()
+	if valor <= 0 {
+		return NuevoDinero(0, t.Moneda)
+	}
+	return monto.Prorratear(10000, valor).EnMoneda(t.Moneda)
+}
+
+// ConvertirMoneda expresa un monto en otra moneda pasando por soles
+// Cada tipo de cambio indica los soles por unidad de su moneda; para soles es 1
+func ConvertirMoneda(monto Dinero, tipoCambioOrigen float64, moneda string, tipoCambioDestino float64) Dinero {
+	if monto.Moneda() == moneda {
+		return monto
+	}
+	base := (&TipoCambio{Moneda: monto.Moneda(), Valor: tipoCambioOrigen}).AMonedaBase(monto)
+	if moneda == MonedaPorDefecto {
+		return base
+	}
+	return (&TipoCambio{Moneda: moneda, Valor: tipoCambioDestino}).EquivalenteDesdeMonedaBase(base)
+}
@@ -0,0 +1,19 @@
+package entidades
+
+import "time"
+
+// WebhookEventoPasarela representa una notificación de pago recibida desde una pasarela
+type WebhookEventoPasarela struct {
+	ID             int        `json:"id_evento" db:"id_evento"`
+	Pasarela       string     `json:"pasarela" db:"pasarela"`
+	IDPagoExterno  string     `json:"id_pago_externo" db:"id_pago_externo"`
+	EstadoPago     string     `json:"estado_pago" db:"estado_pago"` // Estado tal como lo informa la pasarela
+	IDNotificacion string     `json:"id_notificacion" db:"id_notificacion"`
+	Tipo           string     `json:"tipo" db:"tipo"`
+	IDReserva      *int       `json:"id_reserva,omitempty" db:"id_reserva"`
+	Monto          Dinero     `json:"monto" db:"monto"`
+	Resultado      string     `json:"resultado" db:"resultado"` // PROCESANDO, PROCESADO, RECHAZADO, ERROR
+	Detalle        string     `json:"detalle" db:"detalle"`
+	FechaRecepcion time.Time  `json:"fecha_recepcion" db:"fecha_recepcion"`
+	FechaProcesado *time.Time `json:"fecha_procesado,omitempty" db:"fecha_procesado"`
+}
@@ -0,0 +1,312 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"sistema-toursseft/internal/entidades"
+	"time"
+)
+
+// ErrSinCajaAbierta indica que el usuario no tiene una caja abierta
+var ErrSinCajaAbierta = errors.New("el usuario no tiene una caja abierta")
+
+// CajaRepository maneja las operaciones de base de datos para las sesiones de caja
+type CajaRepository struct {
+	db *sql.DB
+}
+
+// NewCajaRepository crea una nueva instancia del repositorio
+func NewCajaRepository(db *sql.DB) *CajaRepository {
+	return &CajaRepository{
+		db: db,
+	}
+}
+
+// queryCajaSesionBase selecciona una sesión de caja con el nombre de su usuario, sede y método de efectivo
+const queryCajaSesionBase = `SELECT cs.id_caja_sesion, cs.id_usuario, cs.id_sede, cs.id_metodo_efectivo, cs.monto_inicial,
+              cs.fecha_apertura, cs.fecha_cierre, cs.estado,
+              COALESCE(cs.observaciones_apertura, ''), COALESCE(cs.observaciones_cierre, ''),
+              u.nombres || ' ' || u.apellidos as nombre_usuario,
+              s.nombre as nombre_sede,
+              mp.nombre as nombre_metodo_efectivo
+              FROM caja_sesion cs
+              INNER JOIN usuario u ON cs.id_usuario = u.id_usuario
+              INNER JOIN sede s ON cs.id_sede = s.id_sede
+              INNER JOIN metodo_pago mp ON cs.id_metodo_efectivo = mp.id_metodo_pago`
+
+// scanCajaSesion lee una fila obtenida con queryCajaSesionBase
+func scanCajaSesion(row interface{ Scan(...interface{}) error }) (*entidades.CajaSesion, error) {
+	caja := &entidades.CajaSesion{}
+	err := row.Scan(
+		&caja.ID, &caja.IDUsuario, &caja.IDSede, &caja.IDMetodoEfectivo, &caja.MontoInicial,
+		&caja.FechaApertura, &caja.FechaCierre, &caja.Estado,
+		&caja.ObservacionesApertura, &caja.ObservacionesCierre,
+		&caja.NombreUsuario, &caja.NombreSede, &caja.NombreMetodoEfectivo,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return caja, nil
+}
+
+// GetByID obtiene una sesión de caja por su ID, con el cuadre de su cierre si está cerrada
+func (r *CajaRepository) GetByID(id int) (*entidades.CajaSesion, error) {
+	caja, err := scanCajaSesion(r.db.QueryRow(queryCajaSesionBase+` WHERE cs.id_caja_sesion = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("caja no encontrada")
+		}
+		return nil, err
+	}
+
+	if caja.Estado == entidades.EstadoCajaCerrada {
+		caja.Detalle, err = r.listDetalleCierre(caja.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return caja, nil
+}
+
+// GetAbiertaByUsuario obtiene la caja abierta de un usuario
+func (r *CajaRepository) GetAbiertaByUsuario(idUsuario int) (*entidades.CajaSesion, error) {
+	query := queryCajaSesionBase + ` WHERE cs.id_usuario = $1 AND cs.estado = 'ABIERTA'`
+
+	caja, err := scanCajaSesion(r.db.QueryRow(query, idUsuario))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSinCajaAbierta
+		}
+		return nil, err
+	}
+
+	return caja, nil
+}
+
+// Abrir registra la apertura de la caja de un usuario en una sede
+func (r *CajaRepository) Abrir(idUsuario, idSede int, caja *entidades.AbrirCajaRequest) (int, error) {
+	var id int
+	query := `INSERT INTO caja_sesion (id_usuario, id_sede, id_metodo_efectivo, monto_inicial, observaciones_apertura)
+              SELECT $1, $2, $3, $4, NULLIF($5, '')
+              WHERE NOT EXISTS (SELECT 1 FROM caja_sesion WHERE id_usuario = $1 AND estado = 'ABIERTA')
+              RETURNING id_caja_sesion`
+
+	err := r.db.QueryRow(
+		query,
+		idUsuario,
+		idSede,
+		caja.IDMetodoEfectivo,
+		caja.MontoInicial,
+		caja.Observaciones,
+	).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("el usuario ya tiene una caja abierta")
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ListMovimientos agrupa los pagos procesados de una caja por método de pago y moneda
+func (r *CajaRepository) ListMovimientos(idCajaSesion int) ([]entidades.MovimientoCaja, error) {
+	return listMovimientosCaja(r.db, idCajaSesion)
+}
+
+// listMovimientosCaja agrupa los pagos procesados de una caja dentro o fuera de una transacción
+func listMovimientosCaja(q interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}, idCajaSesion int) ([]entidades.MovimientoCaja, error) {
+	query := `SELECT id_metodo_pago, moneda, COUNT(*), COALESCE(SUM(monto), 0)
+              FROM pago
+              WHERE id_caja_sesion = $1 AND estado = 'PROCESADO' AND eliminado = FALSE
+              GROUP BY id_metodo_pago, moneda`
+
+	rows, err := q.Query(query, idCajaSesion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movimientos := []entidades.MovimientoCaja{}
+	for rows.Next() {
+		var movimiento entidades.MovimientoCaja
+		err := rows.Scan(&movimiento.IDMetodoPago, &movimiento.Moneda, &movimiento.CantidadPagos, &movimiento.Total)
+		if err != nil {
+			return nil, err
+		}
+		movimiento.Total = movimiento.Total.EnMoneda(movimiento.Moneda)
+		movimientos = append(movimientos, movimiento)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movimientos, nil
+}
+
+// Cerrar cierra una caja abierta cuadrando lo contado contra sus pagos procesados y su fondo inicial
+func (r *CajaRepository) Cerrar(id int, cierre *entidades.CerrarCajaRequest) (detalles []*entidades.DetalleCierreCaja, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear la caja para que no se registren pagos en ella mientras se cuadra
+	var estado string
+	var idMetodoEfectivo int
+	var montoInicial entidades.Dinero
+	query := `SELECT estado, id_metodo_efectivo, monto_inicial FROM caja_sesion WHERE id_caja_sesion = $1 FOR UPDATE`
+	err = tx.QueryRow(query, id).Scan(&estado, &idMetodoEfectivo, &montoInicial)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = errors.New("caja no encontrada")
+		}
+		return nil, err
+	}
+
+	if estado != entidades.EstadoCajaAbierta {
+		err = errors.New("la caja ya está cerrada")
+		return nil, err
+	}
+
+	movimientos, err := listMovimientosCaja(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	detalles, err = entidades.CalcularCierreCaja(movimientos, montoInicial, idMetodoEfectivo, cierre.Conteos)
+	if err != nil {
+		return nil, err
+	}
+
+	queryDetalle := `INSERT INTO caja_cierre_detalle (id_caja_sesion, id_metodo_pago, moneda, cantidad_pagos,
+                    monto_esperado, monto_contado, diferencia)
+                    VALUES ($1, $2, $3, $4, $5, $6, $7)
+                    RETURNING id_caja_cierre_detalle`
+	for _, detalle := range detalles {
+		detalle.IDCajaSesion = id
+		err = tx.QueryRow(
+			queryDetalle,
+			id,
+			detalle.IDMetodoPago,
+			detalle.Moneda,
+			detalle.CantidadPagos,
+			detalle.MontoEsperado,
+			detalle.MontoContado,
+			detalle.Diferencia,
+		).Scan(&detalle.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	queryCierre := `UPDATE caja_sesion SET estado = 'CERRADA', fecha_cierre = CURRENT_TIMESTAMP,
+                   observaciones_cierre = NULLIF($1, '')
+                   WHERE id_caja_sesion = $2`
+	_, err = tx.Exec(queryCierre, cierre.Observaciones, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return detalles, nil
+}
+
+// listDetalleCierre obtiene el cuadre guardado al cerrar una caja
+func (r *CajaRepository) listDetalleCierre(idCajaSesion int) ([]*entidades.DetalleCierreCaja, error) {
+	query := `SELECT d.id_caja_cierre_detalle, d.id_caja_sesion, d.id_metodo_pago, d.moneda, d.cantidad_pagos,
+              d.monto_esperado, d.monto_contado, d.diferencia, mp.nombre
+              FROM caja_cierre_detalle d
+              INNER JOIN metodo_pago mp ON d.id_metodo_pago = mp.id_metodo_pago
+              WHERE d.id_caja_sesion = $1
+              ORDER BY d.id_metodo_pago, d.moneda`
+
+	rows, err := r.db.Query(query, idCajaSesion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	detalles := []*entidades.DetalleCierreCaja{}
+	for rows.Next() {
+		detalle := &entidades.DetalleCierreCaja{}
+		err := rows.Scan(
+			&detalle.ID, &detalle.IDCajaSesion, &detalle.IDMetodoPago, &detalle.Moneda, &detalle.CantidadPagos,
+			&detalle.MontoEsperado, &detalle.MontoContado, &detalle.Diferencia, &detalle.NombreMetodoPago,
+		)
+		if err != nil {
+			return nil, err
+		}
+		detalle.MontoEsperado = detalle.MontoEsperado.EnMoneda(detalle.Moneda)
+		detalle.MontoContado = detalle.MontoContado.EnMoneda(detalle.Moneda)
+		detalle.Diferencia = detalle.Diferencia.EnMoneda(detalle.Moneda)
+		detalles = append(detalles, detalle)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return detalles, nil
+}
+
+// ListCerradas lista las cajas cerradas entre dos fechas, con su cuadre, ordenadas por sede y fecha de cierre
+// Si idSede es nil se listan las de todas las sedes
+func (r *CajaRepository) ListCerradas(fechaInicio, fechaFin time.Time, idSede *int) ([]*entidades.CajaSesion, error) {
+	query := queryCajaSesionBase + ` WHERE cs.estado = 'CERRADA' AND cs.fecha_cierre::date BETWEEN $1 AND $2`
+	args := []interface{}{fechaInicio.Format("2006-01-02"), fechaFin.Format("2006-01-02")}
+
+	// Si se proporciona un ID de sede, filtrar por ella
+	if idSede != nil {
+		query += " AND cs.id_sede = $3"
+		args = append(args, *idSede)
+	}
+
+	query += " ORDER BY cs.id_sede, cs.fecha_cierre"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cajas := []*entidades.CajaSesion{}
+	for rows.Next() {
+		caja, err := scanCajaSesion(rows)
+		if err != nil {
+			return nil, err
+		}
+		cajas = append(cajas, caja)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Cargar el cuadre de cada caja
+	for _, caja := range cajas {
+		caja.Detalle, err = r.listDetalleCierre(caja.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cajas, nil
+}
@@ -0,0 +1,379 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"sistema-toursseft/internal/entidades"
+	"strings"
+)
+
+// ErrPromocionAgotada indica que el código alcanzó su límite de usos, en total o para el cliente
+var ErrPromocionAgotada = errors.New("el código promocional ya alcanzó su límite de usos")
+
+// CodigoPromocionalRepository maneja las operaciones de base de datos para códigos promocionales
+type CodigoPromocionalRepository struct {
+	db *sql.DB
+}
+
+// NewCodigoPromocionalRepository crea una nueva instancia del repositorio
+func NewCodigoPromocionalRepository(db *sql.DB) *CodigoPromocionalRepository {
+	return &CodigoPromocionalRepository{
+		db: db,
+	}
+}
+
+// condicionCanjeVigente limita los canjes a los de reservas que siguen activas
+// Una reserva cancelada o expirada devuelve su uso al código
+const condicionCanjeVigente = `r.estado NOT IN ('CANCELADA', 'EXPIRADA') AND r.eliminado = FALSE`
+
+// queryCodigoBase selecciona un código promocional con la cantidad de usos vigentes
+const queryCodigoBase = `SELECT cp.id_codigo_promocional, cp.codigo, COALESCE(cp.descripcion, ''), cp.tipo_descuento,
+              cp.porcentaje, cp.monto_descuento, cp.fecha_inicio, cp.fecha_fin, cp.usos_maximos, cp.usos_por_cliente,
+              cp.id_tipo_tour, cp.id_sede, cp.id_canal, cp.id_tipo_pasaje, cp.activo, cp.eliminado, cp.fecha_registro,
+              (SELECT COUNT(*) FROM canje_promocion c
+               INNER JOIN reserva r ON c.id_reserva = r.id_reserva
+               WHERE c.id_codigo_promocional = cp.id_codigo_promocional AND ` + condicionCanjeVigente + `),
+              LOCALTIMESTAMP >= cp.fecha_inicio AND LOCALTIMESTAMP < cp.fecha_fin
+              FROM codigo_promocional cp`
+
+// scanCodigo lee una fila obtenida con queryCodigoBase
+func scanCodigo(row interface{ Scan(...interface{}) error }) (*entidades.CodigoPromocional, error) {
+	codigo := &entidades.CodigoPromocional{}
+	err := row.Scan(
+		&codigo.ID, &codigo.Codigo, &codigo.Descripcion, &codigo.TipoDescuento,
+		&codigo.Porcentaje, &codigo.MontoDescuento, &codigo.FechaInicio, &codigo.FechaFin, &codigo.UsosMaximos, &codigo.UsosPorCliente,
+		&codigo.IDTipoTour, &codigo.IDSede, &codigo.IDCanal, &codigo.IDTipoPasaje, &codigo.Activo, &codigo.Eliminado, &codigo.FechaRegistro,
+		&codigo.UsosRegistrados, &codigo.Vigente,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return codigo, nil
+}
+
+// GetByID obtiene un código promocional por su ID
+func (r *CodigoPromocionalRepository) GetByID(id int) (*entidades.CodigoPromocional, error) {
+	query := queryCodigoBase + ` WHERE cp.id_codigo_promocional = $1 AND cp.eliminado = FALSE`
+
+	codigo, err := scanCodigo(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("código promocional no encontrado")
+		}
+		return nil, err
+	}
+
+	return codigo, nil
+}
+
+// GetByCodigo obtiene un código promocional por su texto, sin distinguir mayúsculas
+func (r *CodigoPromocionalRepository) GetByCodigo(texto string) (*entidades.CodigoPromocional, error) {
+	query := queryCodigoBase + ` WHERE UPPER(cp.codigo) = $1 AND cp.eliminado = FALSE`
+
+	codigo, err := scanCodigo(r.db.QueryRow(query, strings.ToUpper(strings.TrimSpace(texto))))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("código promocional no encontrado")
+		}
+		return nil, err
+	}
+
+	return codigo, nil
+}
+
+// ExisteCodigo verifica si ya hay otro código promocional con el mismo texto
+func (r *CodigoPromocionalRepository) ExisteCodigo(texto string, excluirID int) (bool, error) {
+	var existe bool
+	query := `SELECT EXISTS(SELECT 1 FROM codigo_promocional
+              WHERE UPPER(codigo) = $1 AND id_codigo_promocional <> $2 AND eliminado = FALSE)`
+	err := r.db.QueryRow(query, strings.ToUpper(strings.TrimSpace(texto)), excluirID).Scan(&existe)
+	return existe, err
+}
+
+// Create guarda un nuevo código promocional
+// Solo se guarda el valor que corresponde al tipo de descuento
+func (r *CodigoPromocionalRepository) Create(codigo *entidades.NuevoCodigoPromocionalRequest) (int, error) {
+	porcentaje, monto := valoresDescuento(codigo.TipoDescuento, codigo.Porcentaje, codigo.MontoDescuento)
+
+	var id int
+	query := `INSERT INTO codigo_promocional (codigo, descripcion, tipo_descuento, porcentaje, monto_descuento,
+              fecha_inicio, fecha_fin, usos_maximos, usos_por_cliente, id_tipo_tour, id_sede, id_canal, id_tipo_pasaje,
+              activo, eliminado)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, TRUE, FALSE)
+              RETURNING id_codigo_promocional`
+
+	err := r.db.QueryRow(
+		query,
+		strings.ToUpper(strings.TrimSpace(codigo.Codigo)),
+		codigo.Descripcion,
+		codigo.TipoDescuento,
+		porcentaje,
+		monto,
+		codigo.FechaInicio,
+		codigo.FechaFin,
+		codigo.UsosMaximos,
+		codigo.UsosPorCliente,
+		codigo.IDTipoTour,
+		codigo.IDSede,
+		codigo.IDCanal,
+		codigo.IDTipoPasaje,
+	).Scan(&id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Update actualiza un código promocional
+// Los canjes ya registrados conservan el descuento con el que se aplicaron
+func (r *CodigoPromocionalRepository) Update(id int, codigo *entidades.ActualizarCodigoPromocionalRequest) error {
+	porcentaje, monto := valoresDescuento(codigo.TipoDescuento, codigo.Porcentaje, codigo.MontoDescuento)
+
+	query := `UPDATE codigo_promocional SET
+              codigo = $1,
+              descripcion = $2,
+              tipo_descuento = $3,
+              porcentaje = $4,
+              monto_descuento = $5,
+              fecha_inicio = $6,
+              fecha_fin = $7,
+              usos_maximos = $8,
+              usos_por_cliente = $9,
+              id_tipo_tour = $10,
+              id_sede = $11,
+              id_canal = $12,
+              id_tipo_pasaje = $13,
+              activo = $14
+              WHERE id_codigo_promocional = $15 AND eliminado = FALSE`
+
+	_, err := r.db.Exec(
+		query,
+		strings.ToUpper(strings.TrimSpace(codigo.Codigo)),
+		codigo.Descripcion,
+		codigo.TipoDescuento,
+		porcentaje,
+		monto,
+		codigo.FechaInicio,
+		codigo.FechaFin,
+		codigo.UsosMaximos,
+		codigo.UsosPorCliente,
+		codigo.IDTipoTour,
+		codigo.IDSede,
+		codigo.IDCanal,
+		codigo.IDTipoPasaje,
+		codigo.Activo,
+		id,
+	)
+
+	return err
+}
+
+// valoresDescuento deja en cero el valor que no corresponde al tipo de descuento
+func valoresDescuento(tipo string, porcentaje float64, monto entidades.Dinero) (float64, entidades.Dinero) {
+	if tipo == "PORCENTAJE" {
+		return porcentaje, entidades.Dinero{}
+	}
+	return 0, monto
+}
+
+// Delete marca un código promocional como eliminado (borrado lógico)
+func (r *CodigoPromocionalRepository) Delete(id int) error {
+	query := `UPDATE codigo_promocional SET eliminado = TRUE WHERE id_codigo_promocional = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// List lista todos los códigos promocionales no eliminados, los más recientes primero
+func (r *CodigoPromocionalRepository) List() ([]*entidades.CodigoPromocional, error) {
+	query := queryCodigoBase + ` WHERE cp.eliminado = FALSE ORDER BY cp.fecha_inicio DESC, cp.id_codigo_promocional DESC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	codigos := []*entidades.CodigoPromocional{}
+
+	for rows.Next() {
+		codigo, err := scanCodigo(rows)
+		if err != nil {
+			return nil, err
+		}
+		codigos = append(codigos, codigo)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return codigos, nil
+}
+
+// GetUso cuenta los canjes vigentes de un código, en total y del cliente indicado
+func (r *CodigoPromocionalRepository) GetUso(idCodigo int, idCliente int) (*entidades.UsoPromocion, error) {
+	return usoPromocion(r.db, idCodigo, idCliente)
+}
+
+// usoPromocion cuenta los canjes vigentes de un código con una conexión o una transacción
+func usoPromocion(q interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}, idCodigo int, idCliente int) (*entidades.UsoPromocion, error) {
+	uso := &entidades.UsoPromocion{}
+	query := `SELECT COUNT(*), COUNT(*) FILTER (WHERE c.id_cliente = $2)
+              FROM canje_promocion c
+              INNER JOIN reserva r ON c.id_reserva = r.id_reserva
+              WHERE c.id_codigo_promocional = $1 AND ` + condicionCanjeVigente
+
+	err := q.QueryRow(query, idCodigo, idCliente).Scan(&uso.Totales, &uso.Cliente)
+	if err != nil {
+		return nil, err
+	}
+
+	return uso, nil
+}
+
+// GetCanjeByReserva obtiene el canje de código promocional de una reserva, o nil si no usó ninguno
+func (r *CodigoPromocionalRepository) GetCanjeByReserva(idReserva int) (*entidades.CanjePromocion, error) {
+	canje := &entidades.CanjePromocion{}
+	query := `SELECT c.id_canje, c.id_codigo_promocional, c.id_reserva, c.id_cliente, c.total_antes, c.monto_descuento,
+              c.fecha_canje, cp.codigo
+              FROM canje_promocion c
+              INNER JOIN codigo_promocional cp ON c.id_codigo_promocional = cp.id_codigo_promocional
+              WHERE c.id_reserva = $1`
+
+	err := r.db.QueryRow(query, idReserva).Scan(
+		&canje.ID, &canje.IDCodigoPromocional, &canje.IDReserva, &canje.IDCliente, &canje.TotalAntes, &canje.MontoDescuento,
+		&canje.FechaCanje, &canje.Codigo,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return canje, nil
+}
+
+// ListCanjes lista los canjes de un código promocional con el estado actual de cada reserva
+func (r *CodigoPromocionalRepository) ListCanjes(idCodigo int) ([]*entidades.CanjePromocion, error) {
+	query := `SELECT c.id_canje, c.id_codigo_promocional, c.id_reserva, c.id_cliente, c.total_antes, c.monto_descuento,
+              c.fecha_canje, cp.codigo, cl.nombres || ' ' || cl.apellidos, r.estado
+              FROM canje_promocion c
+              INNER JOIN codigo_promocional cp ON c.id_codigo_promocional = cp.id_codigo_promocional
+              INNER JOIN reserva r ON c.id_reserva = r.id_reserva
+              INNER JOIN cliente cl ON c.id_cliente = cl.id_cliente
+              WHERE c.id_codigo_promocional = $1
+              ORDER BY c.fecha_canje DESC`
+
+	rows, err := r.db.Query(query, idCodigo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	canjes := []*entidades.CanjePromocion{}
+
+	for rows.Next() {
+		canje := &entidades.CanjePromocion{}
+		err := rows.Scan(
+			&canje.ID, &canje.IDCodigoPromocional, &canje.IDReserva, &canje.IDCliente, &canje.TotalAntes, &canje.MontoDescuento,
+			&canje.FechaCanje, &canje.Codigo, &canje.NombreCliente, &canje.EstadoReserva,
+		)
+		if err != nil {
+			return nil, err
+		}
+		canjes = append(canjes, canje)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return canjes, nil
+}
+
+// ListReportes resume los canjes de todos los códigos promocionales no eliminados
+// Los montos solo suman canjes de reservas vigentes
+func (r *CodigoPromocionalRepository) ListReportes() ([]*entidades.ReportePromocion, error) {
+	query := `SELECT cp.id_codigo_promocional, cp.codigo,
+              COUNT(c.id_canje) FILTER (WHERE ` + condicionCanjeVigente + `),
+              COUNT(c.id_canje) FILTER (WHERE NOT (` + condicionCanjeVigente + `)),
+              COUNT(DISTINCT c.id_cliente) FILTER (WHERE ` + condicionCanjeVigente + `),
+              COALESCE(SUM(c.total_antes) FILTER (WHERE ` + condicionCanjeVigente + `), 0),
+              COALESCE(SUM(c.monto_descuento) FILTER (WHERE ` + condicionCanjeVigente + `), 0)
+              FROM codigo_promocional cp
+              LEFT JOIN canje_promocion c ON c.id_codigo_promocional = cp.id_codigo_promocional
+              LEFT JOIN reserva r ON c.id_reserva = r.id_reserva
+              WHERE cp.eliminado = FALSE
+              GROUP BY cp.id_codigo_promocional, cp.codigo
+              ORDER BY cp.id_codigo_promocional DESC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reportes := []*entidades.ReportePromocion{}
+
+	for rows.Next() {
+		reporte := &entidades.ReportePromocion{}
+		err := rows.Scan(
+			&reporte.IDCodigoPromocional, &reporte.Codigo, &reporte.Canjes, &reporte.CanjesAnulados,
+			&reporte.ClientesDistintos, &reporte.TotalAntes, &reporte.TotalDescuento,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reporte.TotalVendido = reporte.TotalAntes.Restar(reporte.TotalDescuento)
+		reportes = append(reportes, reporte)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reportes, nil
+}
+
+// registrarCanjePromocionTx registra el canje de un código en una reserva dentro de una transacción existente
+// El código queda bloqueado hasta el commit, así dos reservas simultáneas no pueden superar sus límites de usos
+func registrarCanjePromocionTx(tx *sql.Tx, promocion *entidades.AplicacionPromocion, idReserva int, idCliente int) error {
+	var usosMaximos, usosPorCliente sql.NullInt64
+	queryCodigo := `SELECT usos_maximos, usos_por_cliente FROM codigo_promocional
+                   WHERE id_codigo_promocional = $1 AND activo = TRUE AND eliminado = FALSE
+                   FOR UPDATE`
+	err := tx.QueryRow(queryCodigo, promocion.IDCodigoPromocional).Scan(&usosMaximos, &usosPorCliente)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("el código promocional no está disponible")
+		}
+		return err
+	}
+
+	uso, err := usoPromocion(tx, promocion.IDCodigoPromocional, idCliente)
+	if err != nil {
+		return err
+	}
+	if (usosMaximos.Valid && int64(uso.Totales) >= usosMaximos.Int64) ||
+		(usosPorCliente.Valid && int64(uso.Cliente) >= usosPorCliente.Int64) {
+		return ErrPromocionAgotada
+	}
+
+	queryCanje := `INSERT INTO canje_promocion (id_codigo_promocional, id_reserva, id_cliente, total_antes, monto_descuento)
+                  VALUES ($1, $2, $3, $4, $5)`
+	_, err = tx.Exec(queryCanje, promocion.IDCodigoPromocional, idReserva, idCliente, promocion.TotalAntes, promocion.Descuento)
+	return err
+}
+
+// actualizarCanjePromocionTx recalcula los montos del canje de una reserva cuyos pasajes cambiaron
+func actualizarCanjePromocionTx(tx *sql.Tx, promocion *entidades.AplicacionPromocion, idReserva int) error {
+	query := `UPDATE canje_promocion SET total_antes = $1, monto_descuento = $2
+              WHERE id_reserva = $3 AND id_codigo_promocional = $4`
+	_, err := tx.Exec(query, promocion.TotalAntes, promocion.Descuento, idReserva, promocion.IDCodigoPromocional)
+	return err
+}
@@ -0,0 +1,151 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"sistema-toursseft/internal/entidades"
+)
+
+// ComprobanteElectronicoRepository maneja las operaciones de base de datos para comprobantes electrónicos
+type ComprobanteElectronicoRepository struct {
+	db *sql.DB
+}
+
+// NewComprobanteElectronicoRepository crea una nueva instancia del repositorio
+func NewComprobanteElectronicoRepository(db *sql.DB) *ComprobanteElectronicoRepository {
+	return &ComprobanteElectronicoRepository{
+		db: db,
+	}
+}
+
+// GetDatosEmision obtiene el comprobante con los datos del cliente y del tour necesarios para el XML
+func (r *ComprobanteElectronicoRepository) GetDatosEmision(idComprobante int) (*entidades.ComprobantePago, *entidades.Cliente, error) {
+	comprobante := &entidades.ComprobantePago{}
+	cliente := &entidades.Cliente{}
+	var nombres, apellidos, razonSocial, direccionFiscal sql.NullString
+	var idReferencia sql.NullInt64
+	var tipoReferencia, numeroReferencia, codigoMotivo, motivo sql.NullString
+
+	query := `SELECT cp.id_comprobante, cp.id_reserva, cp.id_sede, cp.tipo, cp.numero_comprobante,
+              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.estado, cp.afectacion_igv,
+              c.id_cliente, c.tipo_documento, c.numero_documento, c.nombres, c.apellidos,
+              c.razon_social, c.direccion_fiscal,
+              tt.nombre, it.fecha_especifica,
+              cp.id_comprobante_referencia, ref.tipo, ref.numero_comprobante, cp.codigo_motivo, cp.motivo
+              FROM comprobante_pago cp
+              INNER JOIN reserva r ON cp.id_reserva = r.id_reserva
+              INNER JOIN cliente c ON r.id_cliente = c.id_cliente
+              INNER JOIN instancia_tour it ON r.id_instancia = it.id_instancia
+              INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+              INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
+              LEFT JOIN comprobante_pago ref ON cp.id_comprobante_referencia = ref.id_comprobante
+              WHERE cp.id_comprobante = $1 AND cp.eliminado = FALSE`
+
+	err := r.db.QueryRow(query, idComprobante).Scan(
+		&comprobante.ID, &comprobante.IDReserva, &comprobante.IDSede, &comprobante.Tipo, &comprobante.NumeroComprobante,
+		&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Estado, &comprobante.Afectacion,
+		&cliente.ID, &cliente.TipoDocumento, &cliente.NumeroDocumento, &nombres, &apellidos,
+		&razonSocial, &direccionFiscal,
+		&comprobante.TourNombre, &comprobante.TourFecha,
+		&idReferencia, &tipoReferencia, &numeroReferencia, &codigoMotivo, &motivo,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, errors.New("comprobante de pago no encontrado")
+		}
+		return nil, nil, err
+	}
+
+	cliente.Nombres = nombres.String
+	cliente.Apellidos = apellidos.String
+	cliente.RazonSocial = razonSocial.String
+	cliente.DireccionFiscal = direccionFiscal.String
+	comprobante.NombreCliente = cliente.Nombres
+	comprobante.ApellidosCliente = cliente.Apellidos
+	comprobante.DocumentoCliente = cliente.NumeroDocumento
+	if idReferencia.Valid {
+		id := int(idReferencia.Int64)
+		comprobante.IDComprobanteReferencia = &id
+	}
+	comprobante.TipoReferencia = tipoReferencia.String
+	comprobante.NumeroReferencia = numeroReferencia.String
+	comprobante.CodigoMotivo = codigoMotivo.String
+	comprobante.Motivo = motivo.String
+
+	return comprobante, cliente, nil
+}
+
+// ErrComprobanteSinEmision indica que el comprobante de pago todavía no se generó en formato electrónico
+var ErrComprobanteSinEmision = errors.New("el comprobante no tiene emisión electrónica")
+
+// GetByComprobante obtiene el comprobante electrónico generado para un comprobante de pago, con su XML y CDR
+func (r *ComprobanteElectronicoRepository) GetByComprobante(idComprobante int) (*entidades.ComprobanteElectronico, error) {
+	electronico := &entidades.ComprobanteElectronico{}
+	var codigo, descripcion sql.NullString
+	var fechaEnvio sql.NullTime
+
+	query := `SELECT id_comprobante_electronico, id_comprobante, nombre_archivo, xml_firmado, hash_cpe,
+              estado_sunat, codigo_respuesta, descripcion_respuesta, cdr_zip, enviador, intentos,
+              fecha_generacion, fecha_envio
+              FROM comprobante_electronico
+              WHERE id_comprobante = $1`
+
+	err := r.db.QueryRow(query, idComprobante).Scan(
+		&electronico.ID, &electronico.IDComprobante, &electronico.NombreArchivo, &electronico.XMLFirmado, &electronico.HashCPE,
+		&electronico.EstadoSunat, &codigo, &descripcion, &electronico.CDR, &electronico.Enviador, &electronico.Intentos,
+		&electronico.FechaGeneracion, &fechaEnvio,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrComprobanteSinEmision
+		}
+		return nil, err
+	}
+
+	electronico.CodigoRespuesta = codigo.String
+	electronico.DescripcionRespuesta = descripcion.String
+	if fechaEnvio.Valid {
+		electronico.FechaEnvio = &fechaEnvio.Time
+	}
+
+	return electronico, nil
+}
+
+// Create guarda el XML firmado de un comprobante, pendiente de envío
+func (r *ComprobanteElectronicoRepository) Create(electronico *entidades.ComprobanteElectronico) (int, error) {
+	var id int
+	query := `INSERT INTO comprobante_electronico (id_comprobante, nombre_archivo, xml_firmado, hash_cpe, estado_sunat, enviador)
+              VALUES ($1, $2, $3, $4, 'PENDIENTE', $5)
+              RETURNING id_comprobante_electronico, fecha_generacion`
+
+	err := r.db.QueryRow(query, electronico.IDComprobante, electronico.NombreArchivo, electronico.XMLFirmado,
+		electronico.HashCPE, electronico.Enviador).Scan(&id, &electronico.FechaGeneracion)
+	if err != nil {
+		return 0, err
+	}
+
+	electronico.ID = id
+	electronico.EstadoSunat = "PENDIENTE"
+	return id, nil
+}
+
+// RegistrarEnvio guarda el resultado de un intento de envío; respuesta es nil si el envío falló
+func (r *ComprobanteElectronicoRepository) RegistrarEnvio(id int, enviador string, respuesta *entidades.RespuestaSunat, errorEnvio string) error {
+	estado, codigo, descripcion := "ERROR", "", errorEnvio
+	var cdr interface{} // NULL conserva el CDR anterior
+	if respuesta != nil {
+		estado, codigo, descripcion = respuesta.Estado, respuesta.Codigo, respuesta.Descripcion
+		if len(respuesta.CDR) > 0 {
+			cdr = respuesta.CDR
+		}
+	}
+
+	query := `UPDATE comprobante_electronico SET
+              estado_sunat = $1, codigo_respuesta = NULLIF($2, ''), descripcion_respuesta = NULLIF($3, ''),
+              cdr_zip = COALESCE($4, cdr_zip), enviador = $5,
+              intentos = intentos + 1, fecha_envio = CURRENT_TIMESTAMP
+              WHERE id_comprobante_electronico = $6`
+
+	_, err := r.db.Exec(query, estado, codigo, descripcion, cdr, enviador, id)
+	return err
+}
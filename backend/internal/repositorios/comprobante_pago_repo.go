@@ -3,6 +3,7 @@ package repositorios
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"sistema-toursseft/internal/entidades"
 	"time"
 )
@@ -23,7 +24,7 @@ func NewComprobantePagoRepository(db *sql.DB) *ComprobantePagoRepository {
 func (r *ComprobantePagoRepository) GetByID(id int) (*entidades.ComprobantePago, error) {
 	comprobante := &entidades.ComprobantePago{}
 	query := `SELECT cp.id_comprobante, cp.id_reserva, cp.id_sede, cp.tipo, cp.numero_comprobante, 
-              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.estado, cp.eliminado,
+              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.moneda, cp.tipo_cambio, cp.estado, cp.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               s.nombre,
               tt.nombre, tp.fecha
@@ -37,7 +38,8 @@ func (r *ComprobantePagoRepository) GetByID(id int) (*entidades.ComprobantePago,
 
 	err := r.db.QueryRow(query, id).Scan(
 		&comprobante.ID, &comprobante.IDReserva, &comprobante.IDSede, &comprobante.Tipo, &comprobante.NumeroComprobante,
-		&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Estado, &comprobante.Eliminado,
+		&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Moneda, &comprobante.TipoCambio,
+		&comprobante.Estado, &comprobante.Eliminado,
 		&comprobante.NombreCliente, &comprobante.ApellidosCliente, &comprobante.DocumentoCliente,
 		&comprobante.NombreSede,
 		&comprobante.TourNombre, &comprobante.TourFecha,
@@ -50,6 +52,7 @@ func (r *ComprobantePagoRepository) GetByID(id int) (*entidades.ComprobantePago,
 		return nil, err
 	}
 
+	expresarEnMonedaComprobante(comprobante)
 	return comprobante, nil
 }
 
@@ -57,7 +60,7 @@ func (r *ComprobantePagoRepository) GetByID(id int) (*entidades.ComprobantePago,
 func (r *ComprobantePagoRepository) GetByTipoAndNumero(tipo, numero string) (*entidades.ComprobantePago, error) {
 	comprobante := &entidades.ComprobantePago{}
 	query := `SELECT cp.id_comprobante, cp.id_reserva, cp.id_sede, cp.tipo, cp.numero_comprobante, 
-              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.estado, cp.eliminado,
+              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.moneda, cp.tipo_cambio, cp.estado, cp.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               s.nombre,
               tt.nombre, tp.fecha
@@ -71,7 +74,8 @@ func (r *ComprobantePagoRepository) GetByTipoAndNumero(tipo, numero string) (*en
 
 	err := r.db.QueryRow(query, tipo, numero).Scan(
 		&comprobante.ID, &comprobante.IDReserva, &comprobante.IDSede, &comprobante.Tipo, &comprobante.NumeroComprobante,
-		&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Estado, &comprobante.Eliminado,
+		&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Moneda, &comprobante.TipoCambio,
+		&comprobante.Estado, &comprobante.Eliminado,
 		&comprobante.NombreCliente, &comprobante.ApellidosCliente, &comprobante.DocumentoCliente,
 		&comprobante.NombreSede,
 		&comprobante.TourNombre, &comprobante.TourFecha,
@@ -84,17 +88,45 @@ func (r *ComprobantePagoRepository) GetByTipoAndNumero(tipo, numero string) (*en
 		return nil, err
 	}
 
+	expresarEnMonedaComprobante(comprobante)
 	return comprobante, nil
 }
 
 // Create guarda un nuevo comprobante de pago en la base de datos
-func (r *ComprobantePagoRepository) Create(comprobante *entidades.NuevoComprobantePagoRequest) (int, error) {
-	var id int
-	query := `INSERT INTO comprobante_pago (id_reserva, id_sede, tipo, numero_comprobante, subtotal, igv, total, eliminado)
-              VALUES ($1, $2, $3, $4, $5, $6, $7, FALSE)
+// Si no se indica el número, se asigna el siguiente correlativo de la serie activa de la sede
+// y se devuelve en comprobante.NumeroComprobante
+func (r *ComprobantePagoRepository) Create(comprobante *entidades.NuevoComprobantePagoRequest) (id int, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var idSerie, correlativo sql.NullInt64
+	if comprobante.NumeroComprobante == "" {
+		serie, numero, siguiente, errSerie := asignarCorrelativo(tx, comprobante.IDSede, comprobante.Tipo, "")
+		if errSerie != nil {
+			err = errSerie
+			return 0, err
+		}
+		idSerie = sql.NullInt64{Int64: int64(serie), Valid: true}
+		correlativo = sql.NullInt64{Int64: int64(siguiente), Valid: true}
+		comprobante.NumeroComprobante = numero
+	}
+
+	query := `INSERT INTO comprobante_pago (id_reserva, id_sede, tipo, numero_comprobante, subtotal, igv, total, eliminado,
+              id_serie, correlativo, afectacion_igv, moneda, tipo_cambio)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, FALSE, $8, $9, $10, $11, $12)
               RETURNING id_comprobante`
 
-	err := r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		comprobante.IDReserva,
 		comprobante.IDSede,
@@ -103,15 +135,59 @@ func (r *ComprobantePagoRepository) Create(comprobante *entidades.NuevoComproban
 		comprobante.Subtotal,
 		comprobante.IGV,
 		comprobante.Total,
+		idSerie,
+		correlativo,
+		afectacionIGV(comprobante.Afectacion),
+		comprobante.Total.Moneda(),
+		tipoCambioComprobante(comprobante.TipoCambio),
 	).Scan(&id)
 
 	if err != nil {
 		return 0, err
 	}
 
+	// Commit de la transacción
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return id, nil
 }
 
+// EsNumeracionAutomatica indica si el número del comprobante se tomó de una serie
+func (r *ComprobantePagoRepository) EsNumeracionAutomatica(id int) (bool, error) {
+	var automatica bool
+	query := `SELECT id_serie IS NOT NULL FROM comprobante_pago WHERE id_comprobante = $1 AND eliminado = FALSE`
+	err := r.db.QueryRow(query, id).Scan(&automatica)
+	if err == sql.ErrNoRows {
+		return false, errors.New("comprobante de pago no encontrado")
+	}
+	return automatica, err
+}
+
+// expresarEnMonedaComprobante expresa los montos leídos en la moneda del comprobante
+func expresarEnMonedaComprobante(comprobante *entidades.ComprobantePago) {
+	comprobante.Subtotal = comprobante.Subtotal.EnMoneda(comprobante.Moneda)
+	comprobante.IGV = comprobante.IGV.EnMoneda(comprobante.Moneda)
+	comprobante.Total = comprobante.Total.EnMoneda(comprobante.Moneda)
+}
+
+// tipoCambioComprobante devuelve el tipo de cambio con el que se guarda un comprobante; 1 si está en soles
+func tipoCambioComprobante(tipoCambio *entidades.TipoCambio) float64 {
+	if tipoCambio == nil {
+		return 1
+	}
+	return tipoCambio.Valor
+}
+
+// afectacionIGV devuelve la afectación a registrar; sin cálculo previo el comprobante es gravado
+func afectacionIGV(afectacion string) string {
+	if afectacion == "" {
+		return "GRAVADO"
+	}
+	return afectacion
+}
+
 // Update actualiza la información de un comprobante de pago
 func (r *ComprobantePagoRepository) Update(id int, comprobante *entidades.ActualizarComprobantePagoRequest) error {
 	query := `UPDATE comprobante_pago SET
@@ -121,8 +197,9 @@ func (r *ComprobantePagoRepository) Update(id int, comprobante *entidades.Actual
               subtotal = $4,
               igv = $5,
               total = $6,
-              estado = $7
-              WHERE id_comprobante = $8 AND eliminado = FALSE`
+              estado = $7,
+              afectacion_igv = $8
+              WHERE id_comprobante = $9 AND eliminado = FALSE`
 
 	_, err := r.db.Exec(
 		query,
@@ -133,6 +210,7 @@ func (r *ComprobantePagoRepository) Update(id int, comprobante *entidades.Actual
 		comprobante.IGV,
 		comprobante.Total,
 		comprobante.Estado,
+		afectacionIGV(comprobante.Afectacion),
 		id,
 	)
 
@@ -156,7 +234,7 @@ func (r *ComprobantePagoRepository) Delete(id int) error {
 // List lista todos los comprobantes de pago activos
 func (r *ComprobantePagoRepository) List() ([]*entidades.ComprobantePago, error) {
 	query := `SELECT cp.id_comprobante, cp.id_reserva, cp.id_sede, cp.tipo, cp.numero_comprobante, 
-              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.estado, cp.eliminado,
+              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.moneda, cp.tipo_cambio, cp.estado, cp.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               s.nombre,
               tt.nombre, tp.fecha
@@ -181,7 +259,8 @@ func (r *ComprobantePagoRepository) List() ([]*entidades.ComprobantePago, error)
 		comprobante := &entidades.ComprobantePago{}
 		err := rows.Scan(
 			&comprobante.ID, &comprobante.IDReserva, &comprobante.IDSede, &comprobante.Tipo, &comprobante.NumeroComprobante,
-			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Estado, &comprobante.Eliminado,
+			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Moneda, &comprobante.TipoCambio,
+			&comprobante.Estado, &comprobante.Eliminado,
 			&comprobante.NombreCliente, &comprobante.ApellidosCliente, &comprobante.DocumentoCliente,
 			&comprobante.NombreSede,
 			&comprobante.TourNombre, &comprobante.TourFecha,
@@ -189,6 +268,7 @@ func (r *ComprobantePagoRepository) List() ([]*entidades.ComprobantePago, error)
 		if err != nil {
 			return nil, err
 		}
+		expresarEnMonedaComprobante(comprobante)
 		comprobantes = append(comprobantes, comprobante)
 	}
 
@@ -202,7 +282,7 @@ func (r *ComprobantePagoRepository) List() ([]*entidades.ComprobantePago, error)
 // ListByReserva lista todos los comprobantes de pago activos de una reserva específica
 func (r *ComprobantePagoRepository) ListByReserva(idReserva int) ([]*entidades.ComprobantePago, error) {
 	query := `SELECT cp.id_comprobante, cp.id_reserva, cp.id_sede, cp.tipo, cp.numero_comprobante, 
-              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.estado, cp.eliminado,
+              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.moneda, cp.tipo_cambio, cp.estado, cp.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               s.nombre,
               tt.nombre, tp.fecha
@@ -227,7 +307,8 @@ func (r *ComprobantePagoRepository) ListByReserva(idReserva int) ([]*entidades.C
 		comprobante := &entidades.ComprobantePago{}
 		err := rows.Scan(
 			&comprobante.ID, &comprobante.IDReserva, &comprobante.IDSede, &comprobante.Tipo, &comprobante.NumeroComprobante,
-			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Estado, &comprobante.Eliminado,
+			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Moneda, &comprobante.TipoCambio,
+			&comprobante.Estado, &comprobante.Eliminado,
 			&comprobante.NombreCliente, &comprobante.ApellidosCliente, &comprobante.DocumentoCliente,
 			&comprobante.NombreSede,
 			&comprobante.TourNombre, &comprobante.TourFecha,
@@ -235,6 +316,7 @@ func (r *ComprobantePagoRepository) ListByReserva(idReserva int) ([]*entidades.C
 		if err != nil {
 			return nil, err
 		}
+		expresarEnMonedaComprobante(comprobante)
 		comprobantes = append(comprobantes, comprobante)
 	}
 
@@ -248,7 +330,7 @@ func (r *ComprobantePagoRepository) ListByReserva(idReserva int) ([]*entidades.C
 // ListByFecha lista todos los comprobantes de pago activos de una fecha específica
 func (r *ComprobantePagoRepository) ListByFecha(fecha time.Time) ([]*entidades.ComprobantePago, error) {
 	query := `SELECT cp.id_comprobante, cp.id_reserva, cp.id_sede, cp.tipo, cp.numero_comprobante, 
-              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.estado, cp.eliminado,
+              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.moneda, cp.tipo_cambio, cp.estado, cp.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               s.nombre,
               tt.nombre, tp.fecha
@@ -273,7 +355,8 @@ func (r *ComprobantePagoRepository) ListByFecha(fecha time.Time) ([]*entidades.C
 		comprobante := &entidades.ComprobantePago{}
 		err := rows.Scan(
 			&comprobante.ID, &comprobante.IDReserva, &comprobante.IDSede, &comprobante.Tipo, &comprobante.NumeroComprobante,
-			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Estado, &comprobante.Eliminado,
+			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Moneda, &comprobante.TipoCambio,
+			&comprobante.Estado, &comprobante.Eliminado,
 			&comprobante.NombreCliente, &comprobante.ApellidosCliente, &comprobante.DocumentoCliente,
 			&comprobante.NombreSede,
 			&comprobante.TourNombre, &comprobante.TourFecha,
@@ -281,6 +364,7 @@ func (r *ComprobantePagoRepository) ListByFecha(fecha time.Time) ([]*entidades.C
 		if err != nil {
 			return nil, err
 		}
+		expresarEnMonedaComprobante(comprobante)
 		comprobantes = append(comprobantes, comprobante)
 	}
 
@@ -294,7 +378,7 @@ func (r *ComprobantePagoRepository) ListByFecha(fecha time.Time) ([]*entidades.C
 // ListByTipo lista todos los comprobantes de pago activos de un tipo específico
 func (r *ComprobantePagoRepository) ListByTipo(tipo string) ([]*entidades.ComprobantePago, error) {
 	query := `SELECT cp.id_comprobante, cp.id_reserva, cp.id_sede, cp.tipo, cp.numero_comprobante, 
-              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.estado, cp.eliminado,
+              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.moneda, cp.tipo_cambio, cp.estado, cp.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               s.nombre,
               tt.nombre, tp.fecha
@@ -319,7 +403,8 @@ func (r *ComprobantePagoRepository) ListByTipo(tipo string) ([]*entidades.Compro
 		comprobante := &entidades.ComprobantePago{}
 		err := rows.Scan(
 			&comprobante.ID, &comprobante.IDReserva, &comprobante.IDSede, &comprobante.Tipo, &comprobante.NumeroComprobante,
-			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Estado, &comprobante.Eliminado,
+			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Moneda, &comprobante.TipoCambio,
+			&comprobante.Estado, &comprobante.Eliminado,
 			&comprobante.NombreCliente, &comprobante.ApellidosCliente, &comprobante.DocumentoCliente,
 			&comprobante.NombreSede,
 			&comprobante.TourNombre, &comprobante.TourFecha,
@@ -327,6 +412,7 @@ func (r *ComprobantePagoRepository) ListByTipo(tipo string) ([]*entidades.Compro
 		if err != nil {
 			return nil, err
 		}
+		expresarEnMonedaComprobante(comprobante)
 		comprobantes = append(comprobantes, comprobante)
 	}
 
@@ -340,7 +426,7 @@ func (r *ComprobantePagoRepository) ListByTipo(tipo string) ([]*entidades.Compro
 // ListByEstado lista todos los comprobantes de pago activos con un estado específico
 func (r *ComprobantePagoRepository) ListByEstado(estado string) ([]*entidades.ComprobantePago, error) {
 	query := `SELECT cp.id_comprobante, cp.id_reserva, cp.id_sede, cp.tipo, cp.numero_comprobante, 
-              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.estado, cp.eliminado,
+              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.moneda, cp.tipo_cambio, cp.estado, cp.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               s.nombre,
               tt.nombre, tp.fecha
@@ -365,7 +451,8 @@ func (r *ComprobantePagoRepository) ListByEstado(estado string) ([]*entidades.Co
 		comprobante := &entidades.ComprobantePago{}
 		err := rows.Scan(
 			&comprobante.ID, &comprobante.IDReserva, &comprobante.IDSede, &comprobante.Tipo, &comprobante.NumeroComprobante,
-			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Estado, &comprobante.Eliminado,
+			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Moneda, &comprobante.TipoCambio,
+			&comprobante.Estado, &comprobante.Eliminado,
 			&comprobante.NombreCliente, &comprobante.ApellidosCliente, &comprobante.DocumentoCliente,
 			&comprobante.NombreSede,
 			&comprobante.TourNombre, &comprobante.TourFecha,
@@ -373,6 +460,7 @@ func (r *ComprobantePagoRepository) ListByEstado(estado string) ([]*entidades.Co
 		if err != nil {
 			return nil, err
 		}
+		expresarEnMonedaComprobante(comprobante)
 		comprobantes = append(comprobantes, comprobante)
 	}
 
@@ -386,7 +474,7 @@ func (r *ComprobantePagoRepository) ListByEstado(estado string) ([]*entidades.Co
 // ListByCliente lista todos los comprobantes de pago activos de un cliente específico
 func (r *ComprobantePagoRepository) ListByCliente(idCliente int) ([]*entidades.ComprobantePago, error) {
 	query := `SELECT cp.id_comprobante, cp.id_reserva, cp.id_sede, cp.tipo, cp.numero_comprobante, 
-              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.estado, cp.eliminado,
+              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.moneda, cp.tipo_cambio, cp.estado, cp.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               s.nombre,
               tt.nombre, tp.fecha
@@ -411,7 +499,8 @@ func (r *ComprobantePagoRepository) ListByCliente(idCliente int) ([]*entidades.C
 		comprobante := &entidades.ComprobantePago{}
 		err := rows.Scan(
 			&comprobante.ID, &comprobante.IDReserva, &comprobante.IDSede, &comprobante.Tipo, &comprobante.NumeroComprobante,
-			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Estado, &comprobante.Eliminado,
+			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Moneda, &comprobante.TipoCambio,
+			&comprobante.Estado, &comprobante.Eliminado,
 			&comprobante.NombreCliente, &comprobante.ApellidosCliente, &comprobante.DocumentoCliente,
 			&comprobante.NombreSede,
 			&comprobante.TourNombre, &comprobante.TourFecha,
@@ -419,6 +508,7 @@ func (r *ComprobantePagoRepository) ListByCliente(idCliente int) ([]*entidades.C
 		if err != nil {
 			return nil, err
 		}
+		expresarEnMonedaComprobante(comprobante)
 		comprobantes = append(comprobantes, comprobante)
 	}
 
@@ -432,7 +522,7 @@ func (r *ComprobantePagoRepository) ListByCliente(idCliente int) ([]*entidades.C
 // ListBySede lista todos los comprobantes de pago activos de una sede específica
 func (r *ComprobantePagoRepository) ListBySede(idSede int) ([]*entidades.ComprobantePago, error) {
 	query := `SELECT cp.id_comprobante, cp.id_reserva, cp.id_sede, cp.tipo, cp.numero_comprobante, 
-              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.estado, cp.eliminado,
+              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.moneda, cp.tipo_cambio, cp.estado, cp.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               s.nombre,
               tt.nombre, tp.fecha
@@ -457,7 +547,8 @@ func (r *ComprobantePagoRepository) ListBySede(idSede int) ([]*entidades.Comprob
 		comprobante := &entidades.ComprobantePago{}
 		err := rows.Scan(
 			&comprobante.ID, &comprobante.IDReserva, &comprobante.IDSede, &comprobante.Tipo, &comprobante.NumeroComprobante,
-			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Estado, &comprobante.Eliminado,
+			&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Moneda, &comprobante.TipoCambio,
+			&comprobante.Estado, &comprobante.Eliminado,
 			&comprobante.NombreCliente, &comprobante.ApellidosCliente, &comprobante.DocumentoCliente,
 			&comprobante.NombreSede,
 			&comprobante.TourNombre, &comprobante.TourFecha,
@@ -465,6 +556,7 @@ func (r *ComprobantePagoRepository) ListBySede(idSede int) ([]*entidades.Comprob
 		if err != nil {
 			return nil, err
 		}
+		expresarEnMonedaComprobante(comprobante)
 		comprobantes = append(comprobantes, comprobante)
 	}
 
@@ -474,3 +566,241 @@ func (r *ComprobantePagoRepository) ListBySede(idSede int) ([]*entidades.Comprob
 
 	return comprobantes, nil
 }
+
+// GetDatosReferencia obtiene los datos propios de un comprobante, sin información relacionada
+func (r *ComprobantePagoRepository) GetDatosReferencia(id int) (*entidades.ComprobantePago, error) {
+	comprobante := &entidades.ComprobantePago{}
+	query := `SELECT id_comprobante, id_reserva, id_sede, tipo, numero_comprobante, fecha_emision, subtotal, igv, total, estado,
+              moneda, tipo_cambio, afectacion_igv
+              FROM comprobante_pago
+              WHERE id_comprobante = $1 AND eliminado = FALSE`
+
+	err := r.db.QueryRow(query, id).Scan(
+		&comprobante.ID, &comprobante.IDReserva, &comprobante.IDSede, &comprobante.Tipo, &comprobante.NumeroComprobante,
+		&comprobante.FechaEmision, &comprobante.Subtotal, &comprobante.IGV, &comprobante.Total, &comprobante.Estado,
+		&comprobante.Moneda, &comprobante.TipoCambio, &comprobante.Afectacion,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("comprobante de pago no encontrado")
+		}
+		return nil, err
+	}
+
+	expresarEnMonedaComprobante(comprobante)
+	return comprobante, nil
+}
+
+// CreateNota guarda una nota de crédito o débito sobre el comprobante de referencia
+// La suma de las notas de crédito vigentes se controla con el comprobante bloqueado, para que dos notas
+// simultáneas no acrediten más que el total original. Sin número, se toma de la serie de notas de la sede
+// que empieza con la misma letra que la serie del comprobante (F o B).
+func (r *ComprobantePagoRepository) CreateNota(nota *entidades.NuevaNotaComprobanteRequest) (id int, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear el comprobante de referencia
+	var idReserva, idSede int
+	var tipo, numero, estado, afectacion, moneda string
+	var total entidades.Dinero
+	var tipoCambio float64
+	queryReferencia := `SELECT id_reserva, id_sede, tipo, numero_comprobante, estado, total, afectacion_igv, moneda, tipo_cambio
+                        FROM comprobante_pago
+                        WHERE id_comprobante = $1 AND eliminado = FALSE
+                        FOR UPDATE`
+	err = tx.QueryRow(queryReferencia, nota.IDComprobanteReferencia).Scan(
+		&idReserva, &idSede, &tipo, &numero, &estado, &total, &afectacion, &moneda, &tipoCambio,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = errors.New("el comprobante de referencia no existe")
+		}
+		return 0, err
+	}
+	total = total.EnMoneda(moneda)
+	if tipo != "FACTURA" && tipo != "BOLETA" {
+		err = errors.New("las notas solo se emiten sobre facturas o boletas")
+		return 0, err
+	}
+	if estado != "EMITIDO" {
+		err = errors.New("el comprobante de referencia no está vigente")
+		return 0, err
+	}
+
+	if nota.Tipo == "NOTA_CREDITO" {
+		var acreditado entidades.Dinero
+		queryAcreditado := `SELECT COALESCE(SUM(total), 0) FROM comprobante_pago
+                            WHERE id_comprobante_referencia = $1 AND tipo = 'NOTA_CREDITO'
+                            AND estado = 'EMITIDO' AND eliminado = FALSE`
+		if err = tx.QueryRow(queryAcreditado, nota.IDComprobanteReferencia).Scan(&acreditado); err != nil {
+			return 0, err
+		}
+		acreditado = acreditado.EnMoneda(moneda)
+		if acreditado.Sumar(nota.Total).MayorQue(total) {
+			err = fmt.Errorf("las notas de crédito no pueden superar el total del comprobante: ya se acreditó %s de %s", acreditado, total)
+			return 0, err
+		}
+	}
+
+	// La devolución vinculada debe estar completada y corresponder a un pago de la misma reserva
+	if nota.IDDevolucion != nil {
+		var estadoDevolucion, monedaPago string
+		var montoDevolucion entidades.Dinero
+		var tipoCambioPago float64
+		var idReservaDevolucion int
+		queryDevolucion := `SELECT d.estado, d.monto_devolucion, p.id_reserva, p.moneda, p.tipo_cambio
+                            FROM devolucion_pago d
+                            INNER JOIN pago p ON d.id_pago = p.id_pago
+                            WHERE d.id_devolucion = $1`
+		err = tx.QueryRow(queryDevolucion, *nota.IDDevolucion).Scan(
+			&estadoDevolucion, &montoDevolucion, &idReservaDevolucion, &monedaPago, &tipoCambioPago,
+		)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				err = errors.New("la devolución no existe")
+			}
+			return 0, err
+		}
+		if idReservaDevolucion != idReserva {
+			err = errors.New("la devolución no corresponde a la reserva del comprobante")
+			return 0, err
+		}
+		if estadoDevolucion != "COMPLETADA" {
+			err = errors.New("solo se vinculan devoluciones completadas")
+			return 0, err
+		}
+		// La devolución se expresa en la moneda del pago; se compara en la del comprobante
+		montoDevolucion = entidades.ConvertirMoneda(montoDevolucion.EnMoneda(monedaPago), tipoCambioPago, moneda, tipoCambio)
+		if nota.Total.MayorQue(montoDevolucion) {
+			err = errors.New("la nota de crédito no puede superar el monto devuelto")
+			return 0, err
+		}
+	}
+
+	var idSerie, correlativo sql.NullInt64
+	if nota.NumeroComprobante == "" {
+		serie, numeroNota, siguiente, errSerie := asignarCorrelativo(tx, idSede, nota.Tipo, numero[:1])
+		if errSerie != nil {
+			err = errSerie
+			return 0, err
+		}
+		idSerie = sql.NullInt64{Int64: int64(serie), Valid: true}
+		correlativo = sql.NullInt64{Int64: int64(siguiente), Valid: true}
+		nota.NumeroComprobante = numeroNota
+	}
+
+	query := `INSERT INTO comprobante_pago (id_reserva, id_sede, tipo, numero_comprobante, subtotal, igv, total, eliminado,
+              id_serie, correlativo, id_comprobante_referencia, codigo_motivo, motivo, id_devolucion, afectacion_igv,
+              moneda, tipo_cambio)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, FALSE, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+              RETURNING id_comprobante`
+
+	err = tx.QueryRow(
+		query,
+		idReserva,
+		idSede,
+		nota.Tipo,
+		nota.NumeroComprobante,
+		nota.Subtotal,
+		nota.IGV,
+		nota.Total,
+		idSerie,
+		correlativo,
+		nota.IDComprobanteReferencia,
+		nota.CodigoMotivo,
+		nota.Motivo,
+		nota.IDDevolucion,
+		afectacion,
+		moneda,
+		tipoCambio,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	// Commit de la transacción
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ListNotas lista las notas de crédito y débito emitidas sobre un comprobante
+func (r *ComprobantePagoRepository) ListNotas(idComprobante int) ([]*entidades.ComprobantePago, error) {
+	query := `SELECT cp.id_comprobante, cp.id_reserva, cp.id_sede, cp.tipo, cp.numero_comprobante,
+              cp.fecha_emision, cp.subtotal, cp.igv, cp.total, cp.moneda, cp.tipo_cambio, cp.estado,
+              cp.id_comprobante_referencia, cp.codigo_motivo, cp.motivo, cp.id_devolucion,
+              ref.tipo, ref.numero_comprobante
+              FROM comprobante_pago cp
+              INNER JOIN comprobante_pago ref ON cp.id_comprobante_referencia = ref.id_comprobante
+              WHERE cp.id_comprobante_referencia = $1 AND cp.eliminado = FALSE
+              ORDER BY cp.fecha_emision, cp.id_comprobante`
+
+	rows, err := r.db.Query(query, idComprobante)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notas := []*entidades.ComprobantePago{}
+
+	for rows.Next() {
+		nota := &entidades.ComprobantePago{}
+		var idReferencia, idDevolucion sql.NullInt64
+		err := rows.Scan(
+			&nota.ID, &nota.IDReserva, &nota.IDSede, &nota.Tipo, &nota.NumeroComprobante,
+			&nota.FechaEmision, &nota.Subtotal, &nota.IGV, &nota.Total, &nota.Moneda, &nota.TipoCambio, &nota.Estado,
+			&idReferencia, &nota.CodigoMotivo, &nota.Motivo, &idDevolucion,
+			&nota.TipoReferencia, &nota.NumeroReferencia,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if idReferencia.Valid {
+			id := int(idReferencia.Int64)
+			nota.IDComprobanteReferencia = &id
+		}
+		if idDevolucion.Valid {
+			id := int(idDevolucion.Int64)
+			nota.IDDevolucion = &id
+		}
+		expresarEnMonedaComprobante(nota)
+		notas = append(notas, nota)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notas, nil
+}
+
+// GetComprobanteVigenteReserva obtiene la factura o boleta emitida más reciente de una reserva
+func (r *ComprobantePagoRepository) GetComprobanteVigenteReserva(idReserva int) (*entidades.ComprobantePago, error) {
+	var id int
+	query := `SELECT id_comprobante FROM comprobante_pago
+              WHERE id_reserva = $1 AND tipo IN ('FACTURA', 'BOLETA') AND estado = 'EMITIDO' AND eliminado = FALSE
+              ORDER BY fecha_emision DESC, id_comprobante DESC
+              LIMIT 1`
+
+	err := r.db.QueryRow(query, idReserva).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("la reserva no tiene comprobantes emitidos")
+		}
+		return nil, err
+	}
+
+	return r.GetDatosReferencia(id)
+}
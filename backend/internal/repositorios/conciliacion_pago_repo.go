@@ -0,0 +1,307 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"sistema-toursseft/internal/entidades"
+	"time"
+)
+
+// ErrConciliacionEnCurso indica que ya hay una conciliación de pagos ejecutándose
+var ErrConciliacionEnCurso = errors.New("ya hay una conciliación de pagos en curso")
+
+// ConciliacionPagoRepository maneja las operaciones de base de datos para la conciliación de pagos en línea
+type ConciliacionPagoRepository struct {
+	db *sql.DB
+}
+
+// NewConciliacionPagoRepository crea una nueva instancia del repositorio
+func NewConciliacionPagoRepository(db *sql.DB) *ConciliacionPagoRepository {
+	return &ConciliacionPagoRepository{
+		db: db,
+	}
+}
+
+// Iniciar registra el inicio de una conciliación si no hay otra en curso
+// Una conciliación que lleva más de una hora en curso se da por interrumpida
+func (r *ConciliacionPagoRepository) Iniciar(origen string, idUsuario *int, dias int) (int, error) {
+	queryInterrumpidas := `UPDATE conciliacion_pago SET estado = 'ERROR', fecha_fin = CURRENT_TIMESTAMP,
+                          detalle = 'conciliación interrumpida'
+                          WHERE estado = 'EN_CURSO' AND fecha_inicio < CURRENT_TIMESTAMP - INTERVAL '1 hour'`
+	if _, err := r.db.Exec(queryInterrumpidas); err != nil {
+		return 0, err
+	}
+
+	// Si otra conciliación empieza a la vez, el índice único de la que está en curso rechaza esta sin error:
+	// no se devuelve fila y se informa como ErrConciliacionEnCurso
+	var id int
+	query := `INSERT INTO conciliacion_pago (origen, id_usuario, dias_revisados)
+              SELECT $1, $2, $3
+              WHERE NOT EXISTS (SELECT 1 FROM conciliacion_pago WHERE estado = 'EN_CURSO')
+              ON CONFLICT ((estado)) WHERE estado = 'EN_CURSO' DO NOTHING
+              RETURNING id_conciliacion`
+
+	err := r.db.QueryRow(query, origen, idUsuario, dias).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrConciliacionEnCurso
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Finalizar registra el resultado de una conciliación
+func (r *ConciliacionPagoRepository) Finalizar(conciliacion *entidades.ConciliacionPago) error {
+	query := `UPDATE conciliacion_pago SET estado = $1, fecha_fin = CURRENT_TIMESTAMP, reservas_revisadas = $2,
+              discrepancias = $3, corregidas = $4, detalle = NULLIF($5, '')
+              WHERE id_conciliacion = $6`
+
+	_, err := r.db.Exec(
+		query,
+		conciliacion.Estado,
+		conciliacion.ReservasRevisadas,
+		conciliacion.Discrepancias,
+		conciliacion.Corregidas,
+		conciliacion.Detalle,
+		conciliacion.ID,
+	)
+	return err
+}
+
+// ListReservasPendientes lista las reservas web de los últimos días cuyos pagos en línea se concilian:
+// las que siguen esperando el pago (RESERVADO o EXPIRADA) y las que tienen pagos vigentes de una pasarela,
+// que pueden haberse devuelto o contracargado. Cada reserva trae sus pagos registrados de la pasarela
+// Las reservas web a las que no se pudo registrar la pasarela al crear el cobro vienen con la pasarela vacía
+func (r *ConciliacionPagoRepository) ListReservasPendientes(dias int) ([]*entidades.ReservaConciliacion, error) {
+	query := `SELECT r.id_reserva, r.estado, COALESCE(r.pasarela, ''), r.fecha_expiracion
+              FROM reserva r
+              WHERE (r.pasarela IS NOT NULL OR r.fecha_expiracion IS NOT NULL) AND r.eliminado = FALSE
+              AND r.fecha_reserva >= CURRENT_TIMESTAMP - $1 * INTERVAL '1 day'
+              AND (r.estado IN ('RESERVADO', 'EXPIRADA')
+                   OR EXISTS (SELECT 1 FROM pago p
+                              WHERE p.id_reserva = r.id_reserva AND p.pasarela = r.pasarela
+                              AND p.estado = 'PROCESADO' AND p.eliminado = FALSE))
+              ORDER BY r.id_reserva`
+
+	rows, err := r.db.Query(query, dias)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservas := []*entidades.ReservaConciliacion{}
+	for rows.Next() {
+		reserva := &entidades.ReservaConciliacion{}
+		err := rows.Scan(&reserva.IDReserva, &reserva.Estado, &reserva.Pasarela, &reserva.FechaExpiracion)
+		if err != nil {
+			return nil, err
+		}
+		reservas = append(reservas, reserva)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Cargar los pagos de cada reserva
+	for _, reserva := range reservas {
+		reserva.Pagos, err = r.ListPagosReserva(reserva.IDReserva, reserva.Pasarela)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return reservas, nil
+}
+
+// AsignarPasarela registra la pasarela con la que se cobró una reserva que aún no la tenía
+func (r *ConciliacionPagoRepository) AsignarPasarela(idReserva int, pasarela string) error {
+	query := `UPDATE reserva SET pasarela = $2 WHERE id_reserva = $1 AND pasarela IS NULL`
+	_, err := r.db.Exec(query, idReserva, pasarela)
+	return err
+}
+
+// ListPagosReserva lista los pagos registrados de una reserva con una pasarela
+func (r *ConciliacionPagoRepository) ListPagosReserva(idReserva int, pasarela string) ([]*entidades.PagoLocalConciliacion, error) {
+	query := `SELECT id_pago, pasarela, id_transaccion_externa, estado, monto, moneda
+              FROM pago
+              WHERE id_reserva = $1 AND pasarela = $2 AND id_transaccion_externa IS NOT NULL AND eliminado = FALSE
+              ORDER BY id_pago`
+
+	rows, err := r.db.Query(query, idReserva, pasarela)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pagos := []*entidades.PagoLocalConciliacion{}
+	for rows.Next() {
+		pago := &entidades.PagoLocalConciliacion{}
+		var moneda string
+		err := rows.Scan(&pago.IDPago, &pago.Pasarela, &pago.IDTransaccion, &pago.Estado, &pago.Monto, &moneda)
+		if err != nil {
+			return nil, err
+		}
+		pago.Monto = pago.Monto.EnMoneda(moneda)
+		pagos = append(pagos, pago)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pagos, nil
+}
+
+// RegistrarDiscrepancia guarda una diferencia encontrada por una conciliación
+func (r *ConciliacionPagoRepository) RegistrarDiscrepancia(discrepancia *entidades.DiscrepanciaConciliacion) (int, error) {
+	var monedaPasarela, monedaLocal *string
+	if discrepancia.MontoPasarela != nil {
+		moneda := discrepancia.MontoPasarela.Moneda()
+		monedaPasarela = &moneda
+	}
+	if discrepancia.MontoLocal != nil {
+		moneda := discrepancia.MontoLocal.Moneda()
+		monedaLocal = &moneda
+	}
+
+	var id int
+	query := `INSERT INTO conciliacion_discrepancia (id_conciliacion, id_reserva, pasarela, id_pago_externo, id_pago,
+              tipo, estado_pasarela, estado_local, monto_pasarela, moneda_pasarela, monto_local, moneda_local,
+              accion, resultado, detalle)
+              VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11, $12, $13, $14, NULLIF($15, ''))
+              RETURNING id_discrepancia, fecha_registro`
+
+	err := r.db.QueryRow(
+		query,
+		discrepancia.IDConciliacion,
+		discrepancia.IDReserva,
+		discrepancia.Pasarela,
+		discrepancia.IDPagoExterno,
+		discrepancia.IDPago,
+		discrepancia.Tipo,
+		discrepancia.EstadoPasarela,
+		discrepancia.EstadoLocal,
+		discrepancia.MontoPasarela,
+		monedaPasarela,
+		discrepancia.MontoLocal,
+		monedaLocal,
+		discrepancia.Accion,
+		discrepancia.Resultado,
+		discrepancia.Detalle,
+	).Scan(&id, &discrepancia.FechaRegistro)
+	if err != nil {
+		return 0, err
+	}
+
+	discrepancia.ID = id
+	return id, nil
+}
+
+// GetByID obtiene una conciliación con las diferencias que encontró
+func (r *ConciliacionPagoRepository) GetByID(id int) (*entidades.ConciliacionPago, error) {
+	conciliacion := &entidades.ConciliacionPago{}
+	query := `SELECT id_conciliacion, origen, id_usuario, dias_revisados, fecha_inicio, fecha_fin, estado,
+              reservas_revisadas, discrepancias, corregidas, COALESCE(detalle, '')
+              FROM conciliacion_pago
+              WHERE id_conciliacion = $1`
+
+	err := r.db.QueryRow(query, id).Scan(
+		&conciliacion.ID, &conciliacion.Origen, &conciliacion.IDUsuario, &conciliacion.DiasRevisados,
+		&conciliacion.FechaInicio, &conciliacion.FechaFin, &conciliacion.Estado,
+		&conciliacion.ReservasRevisadas, &conciliacion.Discrepancias, &conciliacion.Corregidas, &conciliacion.Detalle,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("conciliación no encontrada")
+		}
+		return nil, err
+	}
+
+	conciliacion.Diferencias, err = r.listDiscrepancias(queryDiscrepanciaBase+` WHERE d.id_conciliacion = $1 ORDER BY d.id_discrepancia`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return conciliacion, nil
+}
+
+// queryDiscrepanciaBase selecciona las diferencias registradas por las conciliaciones
+const queryDiscrepanciaBase = `SELECT d.id_discrepancia, d.id_conciliacion, d.id_reserva, d.pasarela,
+              COALESCE(d.id_pago_externo, ''), d.id_pago, d.tipo, COALESCE(d.estado_pasarela, ''),
+              COALESCE(d.estado_local, ''), d.monto_pasarela, COALESCE(d.moneda_pasarela, ''),
+              d.monto_local, COALESCE(d.moneda_local, ''), d.accion, d.resultado, COALESCE(d.detalle, ''),
+              d.fecha_registro
+              FROM conciliacion_discrepancia d`
+
+// listDiscrepancias lee las diferencias obtenidas con queryDiscrepanciaBase
+func (r *ConciliacionPagoRepository) listDiscrepancias(query string, args ...interface{}) ([]*entidades.DiscrepanciaConciliacion, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discrepancias := []*entidades.DiscrepanciaConciliacion{}
+	for rows.Next() {
+		discrepancia := &entidades.DiscrepanciaConciliacion{}
+		var monedaPasarela, monedaLocal string
+		err := rows.Scan(
+			&discrepancia.ID, &discrepancia.IDConciliacion, &discrepancia.IDReserva, &discrepancia.Pasarela,
+			&discrepancia.IDPagoExterno, &discrepancia.IDPago, &discrepancia.Tipo, &discrepancia.EstadoPasarela,
+			&discrepancia.EstadoLocal, &discrepancia.MontoPasarela, &monedaPasarela,
+			&discrepancia.MontoLocal, &monedaLocal, &discrepancia.Accion, &discrepancia.Resultado, &discrepancia.Detalle,
+			&discrepancia.FechaRegistro,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if discrepancia.MontoPasarela != nil {
+			monto := discrepancia.MontoPasarela.EnMoneda(monedaPasarela)
+			discrepancia.MontoPasarela = &monto
+		}
+		if discrepancia.MontoLocal != nil {
+			monto := discrepancia.MontoLocal.EnMoneda(monedaLocal)
+			discrepancia.MontoLocal = &monto
+		}
+		discrepancias = append(discrepancias, discrepancia)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return discrepancias, nil
+}
+
+// ListDiscrepancias lista las diferencias registradas entre dos fechas, opcionalmente de un resultado
+// Retorna también cuántas conciliaciones se ejecutaron en el periodo
+func (r *ConciliacionPagoRepository) ListDiscrepancias(fechaInicio, fechaFin time.Time, resultado string) ([]*entidades.DiscrepanciaConciliacion, int, error) {
+	desde, hasta := fechaInicio.Format("2006-01-02"), fechaFin.Format("2006-01-02")
+
+	var ejecuciones int
+	queryEjecuciones := `SELECT COUNT(*) FROM conciliacion_pago WHERE fecha_inicio::date BETWEEN $1 AND $2`
+	if err := r.db.QueryRow(queryEjecuciones, desde, hasta).Scan(&ejecuciones); err != nil {
+		return nil, 0, err
+	}
+
+	query := queryDiscrepanciaBase + ` WHERE d.fecha_registro::date BETWEEN $1 AND $2`
+	args := []interface{}{desde, hasta}
+
+	// Si se proporciona un resultado, filtrar por él
+	if resultado != "" {
+		query += " AND d.resultado = $3"
+		args = append(args, resultado)
+	}
+
+	query += " ORDER BY d.fecha_registro, d.id_discrepancia"
+
+	discrepancias, err := r.listDiscrepancias(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return discrepancias, ejecuciones, nil
+}
@@ -0,0 +1,390 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"sistema-toursseft/internal/entidades"
+	"time"
+)
+
+// DevolucionPagoRepository maneja las operaciones de base de datos para devoluciones de pagos
+type DevolucionPagoRepository struct {
+	db *sql.DB
+}
+
+// NewDevolucionPagoRepository crea una nueva instancia del repositorio
+func NewDevolucionPagoRepository(db *sql.DB) *DevolucionPagoRepository {
+	return &DevolucionPagoRepository{
+		db: db,
+	}
+}
+
+// queryDevolucionBase selecciona una devolución con los datos de su pago, reserva, cliente y sede
+const queryDevolucionBase = `SELECT d.id_devolucion, d.id_pago, d.fecha_devolucion, d.motivo, d.monto_devolucion,
+              d.estado, COALESCE(d.observaciones, ''), d.cancelar_reserva,
+              COALESCE(d.metodo_ejecucion, ''), COALESCE(d.id_devolucion_externa, ''),
+              d.id_usuario_solicita, d.id_usuario_aprueba, d.fecha_aprobacion, d.fecha_ejecucion,
+              p.id_reserva, p.id_sede, p.monto, p.moneda, p.tipo_cambio, COALESCE(p.pasarela, ''), COALESCE(p.id_transaccion_externa, ''),
+              COALESCE(NULLIF(TRIM(COALESCE(c.nombres, '') || ' ' || COALESCE(c.apellidos, '')), ''), c.razon_social, ''),
+              mp.nombre, s.nombre
+              FROM devolucion_pago d
+              INNER JOIN pago p ON d.id_pago = p.id_pago
+              INNER JOIN reserva r ON p.id_reserva = r.id_reserva
+              INNER JOIN cliente c ON r.id_cliente = c.id_cliente
+              INNER JOIN metodo_pago mp ON p.id_metodo_pago = mp.id_metodo_pago
+              INNER JOIN sede s ON p.id_sede = s.id_sede`
+
+// scanDevolucion lee una fila obtenida con queryDevolucionBase
+func scanDevolucion(scanner interface{ Scan(dest ...any) error }) (*entidades.DevolucionPago, error) {
+	devolucion := &entidades.DevolucionPago{}
+	err := scanner.Scan(
+		&devolucion.ID, &devolucion.IDPago, &devolucion.FechaDevolucion, &devolucion.Motivo, &devolucion.MontoDevolucion,
+		&devolucion.Estado, &devolucion.Observaciones, &devolucion.CancelarReserva,
+		&devolucion.MetodoEjecucion, &devolucion.IDDevolucionExterna,
+		&devolucion.IDUsuarioSolicita, &devolucion.IDUsuarioAprueba, &devolucion.FechaAprobacion, &devolucion.FechaEjecucion,
+		&devolucion.IDReserva, &devolucion.IDSede, &devolucion.MontoPago, &devolucion.Moneda, &devolucion.TipoCambioPago,
+		&devolucion.Pasarela, &devolucion.IDTransaccionExterna, &devolucion.NombreCliente, &devolucion.NombreMetodoPago, &devolucion.NombreSede,
+	)
+	if err != nil {
+		return nil, err
+	}
+	devolucion.MontoDevolucion = devolucion.MontoDevolucion.EnMoneda(devolucion.Moneda)
+	devolucion.MontoPago = devolucion.MontoPago.EnMoneda(devolucion.Moneda)
+	return devolucion, nil
+}
+
+// listDevoluciones ejecuta una consulta basada en queryDevolucionBase y devuelve todas las filas
+func (r *DevolucionPagoRepository) listDevoluciones(query string, args ...interface{}) ([]*entidades.DevolucionPago, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devoluciones := []*entidades.DevolucionPago{}
+
+	for rows.Next() {
+		devolucion, err := scanDevolucion(rows)
+		if err != nil {
+			return nil, err
+		}
+		devoluciones = append(devoluciones, devolucion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return devoluciones, nil
+}
+
+// GetByID obtiene una devolución por su ID
+func (r *DevolucionPagoRepository) GetByID(id int) (*entidades.DevolucionPago, error) {
+	query := queryDevolucionBase + ` WHERE d.id_devolucion = $1`
+
+	devolucion, err := scanDevolucion(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("devolución no encontrada")
+		}
+		return nil, err
+	}
+
+	return devolucion, nil
+}
+
+// GetSedePago obtiene la sede de un pago, usada para validar el acceso antes de solicitar una devolución
+func (r *DevolucionPagoRepository) GetSedePago(idPago int) (int, error) {
+	var idSede int
+	query := `SELECT id_sede FROM pago WHERE id_pago = $1 AND eliminado = FALSE`
+	err := r.db.QueryRow(query, idPago).Scan(&idSede)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("pago no encontrado")
+		}
+		return 0, err
+	}
+	return idSede, nil
+}
+
+// Create registra una solicitud de devolución en estado PENDIENTE
+// El pago se bloquea para que solicitudes simultáneas no superen en conjunto el monto pagado
+func (r *DevolucionPagoRepository) Create(devolucion *entidades.NuevaDevolucionPagoRequest, idUsuario int) (id int, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear el pago
+	var montoPago entidades.Dinero
+	var estadoPago string
+	queryPago := `SELECT monto, estado FROM pago
+                 WHERE id_pago = $1 AND eliminado = FALSE
+                 FOR UPDATE`
+	err = tx.QueryRow(queryPago, devolucion.IDPago).Scan(&montoPago, &estadoPago)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("pago no encontrado")
+		}
+		return 0, err
+	}
+
+	if estadoPago != "PROCESADO" {
+		return 0, errors.New("solo se pueden devolver pagos en estado PROCESADO")
+	}
+
+	// Las devoluciones vigentes más la nueva no pueden superar el monto pagado
+	var montoComprometido entidades.Dinero
+	queryComprometido := `SELECT COALESCE(SUM(monto_devolucion), 0) FROM devolucion_pago
+                         WHERE id_pago = $1 AND estado IN ('PENDIENTE', 'APROBADA', 'EJECUTANDO', 'COMPLETADA')`
+	err = tx.QueryRow(queryComprometido, devolucion.IDPago).Scan(&montoComprometido)
+	if err != nil {
+		return 0, err
+	}
+
+	if montoComprometido.Sumar(devolucion.MontoDevolucion).MayorQue(montoPago) {
+		return 0, errors.New("el monto de la devolución supera el saldo disponible del pago")
+	}
+
+	query := `INSERT INTO devolucion_pago (id_pago, motivo, monto_devolucion, estado, observaciones,
+              cancelar_reserva, id_usuario_solicita)
+              VALUES ($1, $2, $3, 'PENDIENTE', $4, $5, $6)
+              RETURNING id_devolucion`
+	err = tx.QueryRow(
+		query,
+		devolucion.IDPago,
+		devolucion.Motivo,
+		devolucion.MontoDevolucion,
+		devolucion.Observaciones,
+		devolucion.CancelarReserva,
+		idUsuario,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Aprobar aprueba una devolución pendiente
+func (r *DevolucionPagoRepository) Aprobar(id int, idUsuario int) error {
+	query := `UPDATE devolucion_pago SET estado = 'APROBADA', id_usuario_aprueba = $1, fecha_aprobacion = CURRENT_TIMESTAMP
+              WHERE id_devolucion = $2 AND estado = 'PENDIENTE'`
+	result, err := r.db.Exec(query, idUsuario, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("la devolución no existe o no está pendiente")
+	}
+
+	return nil
+}
+
+// Rechazar rechaza una devolución pendiente
+func (r *DevolucionPagoRepository) Rechazar(id int, idUsuario int, observaciones string) error {
+	query := `UPDATE devolucion_pago SET estado = 'RECHAZADA', id_usuario_aprueba = $1, fecha_aprobacion = CURRENT_TIMESTAMP,
+              observaciones = $2
+              WHERE id_devolucion = $3 AND estado = 'PENDIENTE'`
+	result, err := r.db.Exec(query, idUsuario, observaciones, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("la devolución no existe o no está pendiente")
+	}
+
+	return nil
+}
+
+// IniciarEjecucion marca una devolución aprobada como EJECUTANDO antes de pedir el reembolso a la pasarela
+// Si el reembolso se hace pero la devolución no llega a completarse, queda en EJECUTANDO para reintentarla
+func (r *DevolucionPagoRepository) IniciarEjecucion(id int, metodoEjecucion string) error {
+	query := `UPDATE devolucion_pago SET estado = 'EJECUTANDO', metodo_ejecucion = $1
+              WHERE id_devolucion = $2 AND estado = 'APROBADA'`
+	result, err := r.db.Exec(query, metodoEjecucion, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("la devolución no existe o no está aprobada")
+	}
+
+	return nil
+}
+
+// Completar marca una devolución aprobada o en ejecución como ejecutada y actualiza el pago y la reserva en la misma transacción
+// El pago pasa a DEVUELTO cuando las devoluciones completadas cubren su monto. Si la devolución lo indica,
+// la reserva se cancela y su cupo vuelve a la instancia
+func (r *DevolucionPagoRepository) Completar(id int, metodoEjecucion string, idDevolucionExterna string, observaciones string) (err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear la devolución
+	var idPago int
+	var estado string
+	var cancelarReserva bool
+	queryDevolucion := `SELECT id_pago, estado, cancelar_reserva FROM devolucion_pago
+                       WHERE id_devolucion = $1
+                       FOR UPDATE`
+	err = tx.QueryRow(queryDevolucion, id).Scan(&idPago, &estado, &cancelarReserva)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("devolución no encontrada")
+		}
+		return err
+	}
+
+	if estado != "APROBADA" && estado != "EJECUTANDO" {
+		return errors.New("solo se pueden ejecutar devoluciones aprobadas")
+	}
+
+	// Bloquear la reserva y luego el pago (mismo orden que el registro de pagos)
+	var idReserva, idInstancia int
+	var estadoReserva string
+	queryReserva := `SELECT r.id_reserva, r.id_instancia, r.estado FROM reserva r
+                    WHERE r.id_reserva = (SELECT id_reserva FROM pago WHERE id_pago = $1)
+                    FOR UPDATE`
+	err = tx.QueryRow(queryReserva, idPago).Scan(&idReserva, &idInstancia, &estadoReserva)
+	if err != nil {
+		return err
+	}
+
+	var montoPago entidades.Dinero
+	queryPago := `SELECT monto FROM pago WHERE id_pago = $1 FOR UPDATE`
+	err = tx.QueryRow(queryPago, idPago).Scan(&montoPago)
+	if err != nil {
+		return err
+	}
+
+	queryCompletar := `UPDATE devolucion_pago SET estado = 'COMPLETADA', metodo_ejecucion = $1,
+                      id_devolucion_externa = NULLIF($2, ''), fecha_ejecucion = CURRENT_TIMESTAMP,
+                      observaciones = COALESCE(NULLIF($3, ''), observaciones)
+                      WHERE id_devolucion = $4`
+	_, err = tx.Exec(queryCompletar, metodoEjecucion, idDevolucionExterna, observaciones, id)
+	if err != nil {
+		return err
+	}
+
+	// Marcar el pago como devuelto si ya se devolvió por completo
+	var totalDevuelto entidades.Dinero
+	queryDevuelto := `SELECT COALESCE(SUM(monto_devolucion), 0) FROM devolucion_pago
+                     WHERE id_pago = $1 AND estado = 'COMPLETADA'`
+	err = tx.QueryRow(queryDevuelto, idPago).Scan(&totalDevuelto)
+	if err != nil {
+		return err
+	}
+
+	if !totalDevuelto.MenorQue(montoPago) {
+		_, err = tx.Exec(`UPDATE pago SET estado = 'DEVUELTO' WHERE id_pago = $1`, idPago)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Cancelar la reserva y liberar su cupo si se solicitó
+	if cancelarReserva && !estadoLiberaCupo(estadoReserva) {
+		var totalPasajeros int
+		totalPasajeros, err = cantidadPasajerosReservaTx(tx, idReserva)
+		if err != nil {
+			return err
+		}
+
+		queryRestauraCupo := `UPDATE instancia_tour
+                             SET cupo_disponible = cupo_disponible + $1
+                             WHERE id_instancia = $2`
+		_, err = tx.Exec(queryRestauraCupo, totalPasajeros, idInstancia)
+		if err != nil {
+			return err
+		}
+
+		queryCancelar := `UPDATE reserva SET estado = 'CANCELADA', fecha_expiracion = NULL WHERE id_reserva = $1`
+		_, err = tx.Exec(queryCancelar, idReserva)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// List lista todas las devoluciones
+func (r *DevolucionPagoRepository) List() ([]*entidades.DevolucionPago, error) {
+	query := queryDevolucionBase + ` ORDER BY d.fecha_devolucion DESC`
+	return r.listDevoluciones(query)
+}
+
+// ListBySede lista las devoluciones de pagos de una sede
+func (r *DevolucionPagoRepository) ListBySede(idSede int) ([]*entidades.DevolucionPago, error) {
+	query := queryDevolucionBase + ` WHERE p.id_sede = $1 ORDER BY d.fecha_devolucion DESC`
+	return r.listDevoluciones(query, idSede)
+}
+
+// ListByPago lista las devoluciones de un pago
+func (r *DevolucionPagoRepository) ListByPago(idPago int) ([]*entidades.DevolucionPago, error) {
+	query := queryDevolucionBase + ` WHERE d.id_pago = $1 ORDER BY d.fecha_devolucion DESC`
+	return r.listDevoluciones(query, idPago)
+}
+
+// ListByEstado lista las devoluciones por estado
+func (r *DevolucionPagoRepository) ListByEstado(estado string) ([]*entidades.DevolucionPago, error) {
+	query := queryDevolucionBase + ` WHERE d.estado = $1 ORDER BY d.fecha_devolucion DESC`
+	return r.listDevoluciones(query, estado)
+}
+
+// ListByFecha lista las devoluciones de una fecha, tomando la fecha de ejecución o, si aún no se ejecutó, la de solicitud
+// Si idSede no es nil solo se incluyen las devoluciones de esa sede
+func (r *DevolucionPagoRepository) ListByFecha(fecha time.Time, idSede *int) ([]*entidades.DevolucionPago, error) {
+	query := queryDevolucionBase + ` WHERE DATE(COALESCE(d.fecha_ejecucion, d.fecha_devolucion)) = $1
+              AND ($2::INT IS NULL OR p.id_sede = $2)
+              ORDER BY d.fecha_devolucion DESC`
+	return r.listDevoluciones(query, fecha.Format("2006-01-02"), idSede)
+}
@@ -0,0 +1,459 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sistema-toursseft/internal/entidades"
+	"time"
+)
+
+// EmbarqueRepository maneja las operaciones de base de datos para el check-in de embarque
+type EmbarqueRepository struct {
+	db *sql.DB
+}
+
+// NewEmbarqueRepository crea una nueva instancia del repositorio
+func NewEmbarqueRepository(db *sql.DB) *EmbarqueRepository {
+	return &EmbarqueRepository{
+		db: db,
+	}
+}
+
+// GetDatosPase obtiene lo necesario para emitir el pase de abordar de una reserva:
+// su estado, la instancia y el fin del tour, que marca el vencimiento del pase
+func (r *EmbarqueRepository) GetDatosPase(idReserva int) (estado string, idInstancia int, finTour time.Time, err error) {
+	query := `SELECT r.estado, r.id_instancia, it.fecha_especifica + it.hora_fin
+              FROM reserva r
+              INNER JOIN instancia_tour it ON r.id_instancia = it.id_instancia
+              WHERE r.id_reserva = $1 AND r.eliminado = FALSE`
+
+	err = r.db.QueryRow(query, idReserva).Scan(&estado, &idInstancia, &finTour)
+	if err == sql.ErrNoRows {
+		err = errors.New("reserva no encontrada")
+	}
+	return estado, idInstancia, finTour, err
+}
+
+// ErrPaseUtilizado indica que la reserva ya tiene un check-in registrado
+var ErrPaseUtilizado = errors.New("el pase de abordar ya fue utilizado")
+
+// Registrar hace el check-in de una reserva escaneada en el muelle
+// La reserva debe estar confirmada, pertenecer a la instancia del pase y el tour debe ser de hoy.
+// Si idChofer no es nil, la instancia debe estar asignada a ese chofer. Un pase solo se usa una vez.
+func (r *EmbarqueRepository) Registrar(idReserva int, idInstancia int, idUsuario int, idChofer *int, idPasajeros []int) (*entidades.Embarque, error) {
+	return r.registrar(idReserva, idInstancia, idUsuario, idChofer, idPasajeros, nil)
+}
+
+// RegistrarOffline concilia un escaneo hecho sin conexión con las mismas reglas que Registrar,
+// tomando como fecha de embarque la del escaneo y exigiendo que el tour sea del día en que se escaneó
+func (r *EmbarqueRepository) RegistrarOffline(idReserva int, idInstancia int, idUsuario int, idChofer *int, evento *entidades.EventoEmbarqueOffline) (*entidades.Embarque, error) {
+	return r.registrar(idReserva, idInstancia, idUsuario, idChofer, evento.IDPasajeros, evento)
+}
+
+// registrar hace el check-in dentro de una transacción; evento es nil para escaneos en línea
+func (r *EmbarqueRepository) registrar(idReserva int, idInstancia int, idUsuario int, idChofer *int, idPasajeros []int, evento *entidades.EventoEmbarqueOffline) (embarque *entidades.Embarque, err error) {
+	// Los escaneos en línea usan la hora del servidor
+	var fechaEscaneo *time.Time
+	origen := "ONLINE"
+	if evento != nil {
+		fechaEscaneo = &evento.FechaEscaneo
+		origen = "OFFLINE"
+	}
+
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear la reserva para que dos escaneos simultáneos no la embarquen dos veces
+	var estado, estadoInstancia, nombreCliente string
+	var idInstanciaActual int
+	var esHoy bool
+	var choferAsignado sql.NullInt64
+	queryReserva := `SELECT r.estado, r.id_instancia, it.estado,
+                    it.fecha_especifica = COALESCE($2::timestamptz, CURRENT_TIMESTAMP)::date, it.id_chofer,
+                    c.nombres || ' ' || c.apellidos
+                    FROM reserva r
+                    INNER JOIN instancia_tour it ON r.id_instancia = it.id_instancia
+                    INNER JOIN cliente c ON r.id_cliente = c.id_cliente
+                    WHERE r.id_reserva = $1 AND r.eliminado = FALSE
+                    FOR UPDATE OF r`
+	err = tx.QueryRow(queryReserva, idReserva, fechaEscaneo).Scan(&estado, &idInstanciaActual, &estadoInstancia, &esHoy, &choferAsignado, &nombreCliente)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("reserva no encontrada")
+		}
+		return nil, err
+	}
+
+	// Un pase emitido antes de reprogramar la reserva queda invalidado
+	if idInstanciaActual != idInstancia {
+		return nil, errors.New("el pase no corresponde a la instancia actual de la reserva")
+	}
+	if estado != "CONFIRMADA" {
+		return nil, fmt.Errorf("la reserva está en estado %s, solo se embarcan reservas confirmadas", estado)
+	}
+	if estadoInstancia == "CANCELADO" || estadoInstancia == "COMPLETADO" {
+		return nil, fmt.Errorf("la instancia de tour está en estado %s", estadoInstancia)
+	}
+	if !esHoy {
+		if evento != nil {
+			return nil, errors.New("el pase no corresponde a un tour del día del escaneo")
+		}
+		return nil, errors.New("el pase no corresponde a un tour de hoy")
+	}
+	if idChofer != nil && (!choferAsignado.Valid || int(choferAsignado.Int64) != *idChofer) {
+		return nil, errors.New("la instancia de tour no está asignada a este chofer")
+	}
+
+	var yaEmbarcada bool
+	err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM embarque WHERE id_reserva = $1)`, idReserva).Scan(&yaEmbarcada)
+	if err != nil {
+		return nil, err
+	}
+	if yaEmbarcada {
+		return nil, ErrPaseUtilizado
+	}
+
+	totalPasajes, err := cantidadPasajerosReservaTx(tx, idReserva)
+	if err != nil {
+		return nil, err
+	}
+
+	// Marcar pasajeros a bordo: los indicados o todos los registrados en la reserva
+	pasajerosEmbarcados := totalPasajes
+	if len(idPasajeros) > 0 {
+		vistos := map[int]bool{}
+		for _, idPasajero := range idPasajeros {
+			if vistos[idPasajero] {
+				continue
+			}
+			vistos[idPasajero] = true
+
+			var resultado sql.Result
+			resultado, err = tx.Exec(`UPDATE pasajero SET embarcado = TRUE, fecha_embarque = COALESCE($3::timestamptz, CURRENT_TIMESTAMP)
+                                     WHERE id_pasajero = $1 AND id_reserva = $2 AND eliminado = FALSE`,
+				idPasajero, idReserva, fechaEscaneo)
+			if err != nil {
+				return nil, err
+			}
+
+			var filas int64
+			filas, err = resultado.RowsAffected()
+			if err != nil {
+				return nil, err
+			}
+			if filas == 0 {
+				return nil, fmt.Errorf("el pasajero %d no pertenece a la reserva", idPasajero)
+			}
+		}
+		pasajerosEmbarcados = len(vistos)
+	} else {
+		_, err = tx.Exec(`UPDATE pasajero SET embarcado = TRUE, fecha_embarque = COALESCE($2::timestamptz, CURRENT_TIMESTAMP)
+                         WHERE id_reserva = $1 AND eliminado = FALSE`, idReserva, fechaEscaneo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	embarque = &entidades.Embarque{
+		IDReserva:           idReserva,
+		IDInstancia:         idInstancia,
+		IDUsuario:           idUsuario,
+		PasajerosEmbarcados: pasajerosEmbarcados,
+		Origen:              origen,
+		NombreCliente:       nombreCliente,
+		TotalPasajes:        totalPasajes,
+	}
+
+	var idEvento *string
+	if evento != nil {
+		idEvento = &evento.IDEvento
+		embarque.IDEvento = idEvento
+	}
+
+	// Los escaneos sin conexión guardan la hora real del embarque y la de sincronización
+	query := `INSERT INTO embarque (id_reserva, id_instancia, id_usuario, pasajeros_embarcados, fecha_embarque,
+              origen, id_evento, fecha_sincronizacion)
+              VALUES ($1, $2, $3, $4, COALESCE($5::timestamptz, CURRENT_TIMESTAMP), $6, $7,
+              CASE WHEN $7::varchar IS NULL THEN NULL ELSE CURRENT_TIMESTAMP END)
+              RETURNING id_embarque, fecha_embarque`
+	err = tx.QueryRow(query, idReserva, idInstancia, idUsuario, pasajerosEmbarcados, fechaEscaneo, origen, idEvento).Scan(&embarque.ID, &embarque.FechaEmbarque)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return embarque, nil
+}
+
+// queryEmbarqueBase selecciona los check-in con el titular de la reserva
+const queryEmbarqueBase = `SELECT e.id_embarque, e.id_reserva, e.id_instancia, e.id_usuario, e.pasajeros_embarcados,
+              e.fecha_embarque, e.origen, e.id_evento, c.nombres || ' ' || c.apellidos
+              FROM embarque e
+              INNER JOIN reserva r ON e.id_reserva = r.id_reserva
+              INNER JOIN cliente c ON r.id_cliente = c.id_cliente`
+
+// listEmbarques ejecuta una consulta sobre queryEmbarqueBase y escanea los resultados
+func (r *EmbarqueRepository) listEmbarques(condicion string, args ...interface{}) ([]*entidades.Embarque, error) {
+	rows, err := r.db.Query(queryEmbarqueBase+" "+condicion, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	embarques := []*entidades.Embarque{}
+
+	for rows.Next() {
+		embarque := &entidades.Embarque{}
+		err := rows.Scan(
+			&embarque.ID, &embarque.IDReserva, &embarque.IDInstancia, &embarque.IDUsuario,
+			&embarque.PasajerosEmbarcados, &embarque.FechaEmbarque, &embarque.Origen, &embarque.IDEvento,
+			&embarque.NombreCliente,
+		)
+		if err != nil {
+			return nil, err
+		}
+		embarques = append(embarques, embarque)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return embarques, nil
+}
+
+// ListByInstancia lista los check-in registrados en una instancia de tour
+func (r *EmbarqueRepository) ListByInstancia(idInstancia int) ([]*entidades.Embarque, error) {
+	return r.listEmbarques("WHERE e.id_instancia = $1 ORDER BY e.fecha_embarque", idInstancia)
+}
+
+// GetByReserva obtiene el check-in de una reserva, o nil si aún no embarca
+func (r *EmbarqueRepository) GetByReserva(idReserva int) (*entidades.Embarque, error) {
+	embarques, err := r.listEmbarques("WHERE e.id_reserva = $1", idReserva)
+	if err != nil || len(embarques) == 0 {
+		return nil, err
+	}
+	return embarques[0], nil
+}
+
+// GetByEvento obtiene el check-in creado por un escaneo sin conexión, o nil si no se sincronizó
+func (r *EmbarqueRepository) GetByEvento(idEvento string) (*entidades.Embarque, error) {
+	embarques, err := r.listEmbarques("WHERE e.id_evento = $1", idEvento)
+	if err != nil || len(embarques) == 0 {
+		return nil, err
+	}
+	return embarques[0], nil
+}
+
+// GetReporteNoShow obtiene el resumen de embarque de una instancia y las reservas confirmadas sin check-in
+func (r *EmbarqueRepository) GetReporteNoShow(idInstancia int) (*entidades.ReporteNoShow, error) {
+	reporte := &entidades.ReporteNoShow{NoShows: []*entidades.ReservaNoShow{}}
+	var idChofer sql.NullInt64
+
+	queryInstancia := `SELECT it.id_instancia, tp.id_sede, it.id_chofer, tt.nombre,
+                      to_char(it.fecha_especifica, 'DD/MM/YYYY'), to_char(it.hora_inicio, 'HH24:MI')
+                      FROM instancia_tour it
+                      INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+                      INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
+                      WHERE it.id_instancia = $1 AND it.eliminado = FALSE`
+	err := r.db.QueryRow(queryInstancia, idInstancia).Scan(
+		&reporte.IDInstancia, &reporte.IDSede, &idChofer, &reporte.NombreTour, &reporte.FechaTour, &reporte.HoraInicio,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("instancia de tour no encontrada")
+		}
+		return nil, err
+	}
+	if idChofer.Valid {
+		id := int(idChofer.Int64)
+		reporte.IDChofer = &id
+	}
+
+	// Reservas confirmadas con sus pasajes y, si embarcaron, los pasajeros a bordo
+	query := `SELECT r.id_reserva, r.estado, c.nombres || ' ' || c.apellidos, COALESCE(c.numero_celular, ''),
+              COALESCE(c.correo, ''),
+              COALESCE((SELECT SUM(pc.cantidad) FROM pasajes_cantidad pc
+                        WHERE pc.id_reserva = r.id_reserva AND pc.eliminado = FALSE), 0)
+              + COALESCE((SELECT SUM(ppd.cantidad * pp.cantidad_total) FROM paquete_pasaje_detalle ppd
+                          INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
+                          WHERE ppd.id_reserva = r.id_reserva AND ppd.eliminado = FALSE), 0),
+              e.pasajeros_embarcados
+              FROM reserva r
+              INNER JOIN cliente c ON r.id_cliente = c.id_cliente
+              LEFT JOIN embarque e ON e.id_reserva = r.id_reserva
+              WHERE r.id_instancia = $1 AND r.eliminado = FALSE AND r.estado = 'CONFIRMADA'
+              ORDER BY c.apellidos, c.nombres`
+
+	rows, err := r.db.Query(query, idInstancia)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		fila := &entidades.ReservaNoShow{}
+		var embarcados sql.NullInt64
+		err := rows.Scan(
+			&fila.IDReserva, &fila.Estado, &fila.NombreCliente, &fila.Celular, &fila.Correo,
+			&fila.TotalPasajes, &embarcados,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		reporte.TotalReservas++
+		reporte.TotalPasajes += fila.TotalPasajes
+		if embarcados.Valid {
+			reporte.ReservasEmbarcadas++
+			reporte.PasajerosEmbarcados += int(embarcados.Int64)
+		} else {
+			reporte.NoShows = append(reporte.NoShows, fila)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reporte, nil
+}
+
+// GetPaqueteOffline arma el manifiesto de las instancias de hoy asignadas a un chofer,
+// con las reservas confirmadas, sus pasajeros y si ya embarcaron
+func (r *EmbarqueRepository) GetPaqueteOffline(idChofer int) (*entidades.PaqueteOffline, error) {
+	paquete := &entidades.PaqueteOffline{
+		IDChofer:   idChofer,
+		Instancias: []*entidades.InstanciaOffline{},
+	}
+
+	err := r.db.QueryRow(`SELECT to_char(CURRENT_DATE, 'YYYY-MM-DD'), LOCALTIMESTAMP`).Scan(&paquete.Fecha, &paquete.GeneradoEn)
+	if err != nil {
+		return nil, err
+	}
+
+	queryInstancias := `SELECT it.id_instancia, tt.nombre, e.nombre, to_char(it.hora_inicio, 'HH24:MI'),
+                       to_char(it.hora_fin, 'HH24:MI'), it.fecha_especifica + it.hora_fin
+                       FROM instancia_tour it
+                       INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+                       INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
+                       INNER JOIN embarcacion e ON it.id_embarcacion = e.id_embarcacion
+                       WHERE it.id_chofer = $1 AND it.fecha_especifica = CURRENT_DATE AND it.eliminado = FALSE
+                       AND it.estado NOT IN ('CANCELADO', 'COMPLETADO')
+                       ORDER BY it.hora_inicio`
+
+	rows, err := r.db.Query(queryInstancias, idChofer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	instancias := map[int]*entidades.InstanciaOffline{}
+	for rows.Next() {
+		instancia := &entidades.InstanciaOffline{Reservas: []*entidades.ReservaOffline{}}
+		err := rows.Scan(
+			&instancia.IDInstancia, &instancia.NombreTour, &instancia.NombreEmbarcacion,
+			&instancia.HoraInicio, &instancia.HoraFin, &instancia.FinTour,
+		)
+		if err != nil {
+			return nil, err
+		}
+		instancias[instancia.IDInstancia] = instancia
+		paquete.Instancias = append(paquete.Instancias, instancia)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Reservas confirmadas de esas instancias
+	queryReservas := `SELECT r.id_reserva, r.id_instancia, c.nombres || ' ' || c.apellidos,
+                     COALESCE((SELECT SUM(pc.cantidad) FROM pasajes_cantidad pc
+                               WHERE pc.id_reserva = r.id_reserva AND pc.eliminado = FALSE), 0)
+                     + COALESCE((SELECT SUM(ppd.cantidad * pp.cantidad_total) FROM paquete_pasaje_detalle ppd
+                                 INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
+                                 WHERE ppd.id_reserva = r.id_reserva AND ppd.eliminado = FALSE), 0),
+                     EXISTS(SELECT 1 FROM embarque e WHERE e.id_reserva = r.id_reserva)
+                     FROM reserva r
+                     INNER JOIN instancia_tour it ON r.id_instancia = it.id_instancia
+                     INNER JOIN cliente c ON r.id_cliente = c.id_cliente
+                     WHERE it.id_chofer = $1 AND it.fecha_especifica = CURRENT_DATE
+                     AND r.eliminado = FALSE AND r.estado = 'CONFIRMADA'
+                     ORDER BY c.apellidos, c.nombres`
+
+	rowsReservas, err := r.db.Query(queryReservas, idChofer)
+	if err != nil {
+		return nil, err
+	}
+	defer rowsReservas.Close()
+
+	reservas := map[int]*entidades.ReservaOffline{}
+	for rowsReservas.Next() {
+		reserva := &entidades.ReservaOffline{Pasajeros: []*entidades.PasajeroOffline{}}
+		var idInstancia int
+		err := rowsReservas.Scan(&reserva.IDReserva, &idInstancia, &reserva.NombreTitular, &reserva.TotalPasajes, &reserva.Embarcada)
+		if err != nil {
+			return nil, err
+		}
+		instancia, ok := instancias[idInstancia]
+		if !ok {
+			continue
+		}
+		reservas[reserva.IDReserva] = reserva
+		instancia.Reservas = append(instancia.Reservas, reserva)
+	}
+	if err = rowsReservas.Err(); err != nil {
+		return nil, err
+	}
+
+	// Pasajeros registrados en esas reservas
+	queryPasajeros := `SELECT p.id_pasajero, p.id_reserva, p.nombres, p.apellidos, p.tipo_documento, p.numero_documento,
+                      COALESCE(p.embarcado, FALSE)
+                      FROM pasajero p
+                      INNER JOIN reserva r ON p.id_reserva = r.id_reserva
+                      INNER JOIN instancia_tour it ON r.id_instancia = it.id_instancia
+                      WHERE it.id_chofer = $1 AND it.fecha_especifica = CURRENT_DATE
+                      AND r.eliminado = FALSE AND r.estado = 'CONFIRMADA' AND p.eliminado = FALSE
+                      ORDER BY p.apellidos, p.nombres`
+
+	rowsPasajeros, err := r.db.Query(queryPasajeros, idChofer)
+	if err != nil {
+		return nil, err
+	}
+	defer rowsPasajeros.Close()
+
+	for rowsPasajeros.Next() {
+		pasajero := &entidades.PasajeroOffline{}
+		var idReserva int
+		err := rowsPasajeros.Scan(
+			&pasajero.ID, &idReserva, &pasajero.Nombres, &pasajero.Apellidos,
+			&pasajero.TipoDocumento, &pasajero.NumeroDocumento, &pasajero.Embarcado,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if reserva, ok := reservas[idReserva]; ok {
+			reserva.Pasajeros = append(reserva.Pasajeros, pasajero)
+		}
+	}
+	if err = rowsPasajeros.Err(); err != nil {
+		return nil, err
+	}
+
+	return paquete, nil
+}
@@ -0,0 +1,161 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sistema-toursseft/internal/entidades"
+)
+
+// ErrSinConfiguracionImpuesto indica que la sede usa la configuración de IGV por defecto
+var ErrSinConfiguracionImpuesto = errors.New("la sede no tiene configuración de impuestos propia")
+
+// ImpuestoRepository maneja las operaciones de base de datos para la configuración de impuestos
+type ImpuestoRepository struct {
+	db *sql.DB
+}
+
+// NewImpuestoRepository crea una nueva instancia del repositorio
+func NewImpuestoRepository(db *sql.DB) *ImpuestoRepository {
+	return &ImpuestoRepository{
+		db: db,
+	}
+}
+
+// GetBySede obtiene la configuración de IGV de una sede
+func (r *ImpuestoRepository) GetBySede(idSede int) (*entidades.ConfiguracionImpuesto, error) {
+	configuracion := &entidades.ConfiguracionImpuesto{}
+	var fecha sql.NullTime
+	query := `SELECT ci.id_sede, ci.afectacion_igv, ci.tasa_igv, ci.precios_incluyen_igv, ci.fecha_actualizacion, s.nombre
+              FROM configuracion_impuesto ci
+              INNER JOIN sede s ON ci.id_sede = s.id_sede
+              WHERE ci.id_sede = $1`
+
+	err := r.db.QueryRow(query, idSede).Scan(
+		&configuracion.IDSede, &configuracion.Afectacion, &configuracion.TasaIGV, &configuracion.PreciosIncluyenIGV,
+		&fecha, &configuracion.NombreSede,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSinConfiguracionImpuesto
+		}
+		return nil, err
+	}
+	if fecha.Valid {
+		configuracion.FechaActualizacion = &fecha.Time
+	}
+
+	return configuracion, nil
+}
+
+// Guardar crea o reemplaza la configuración de IGV de una sede
+func (r *ImpuestoRepository) Guardar(idSede int, configuracion *entidades.ActualizarConfiguracionImpuestoRequest) error {
+	query := `INSERT INTO configuracion_impuesto (id_sede, afectacion_igv, tasa_igv, precios_incluyen_igv)
+              VALUES ($1, $2, $3, $4)
+              ON CONFLICT (id_sede) DO UPDATE SET
+              afectacion_igv = EXCLUDED.afectacion_igv,
+              tasa_igv = EXCLUDED.tasa_igv,
+              precios_incluyen_igv = EXCLUDED.precios_incluyen_igv,
+              fecha_actualizacion = CURRENT_TIMESTAMP`
+
+	_, err := r.db.Exec(query, idSede, configuracion.Afectacion, configuracion.TasaIGV, *configuracion.PreciosIncluyenIGV)
+	return err
+}
+
+// List lista las sedes activas con su configuración de IGV; las sedes sin configuración propia traen id_sede y nombre
+// con la configuración vacía y PorDefecto en true
+func (r *ImpuestoRepository) List() ([]*entidades.ConfiguracionImpuesto, error) {
+	query := `SELECT s.id_sede, s.nombre, ci.id_sede IS NULL,
+              COALESCE(ci.afectacion_igv, ''), COALESCE(ci.tasa_igv, 0), COALESCE(ci.precios_incluyen_igv, TRUE),
+              ci.fecha_actualizacion
+              FROM sede s
+              LEFT JOIN configuracion_impuesto ci ON ci.id_sede = s.id_sede
+              WHERE s.eliminado = FALSE
+              ORDER BY s.nombre`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	configuraciones := []*entidades.ConfiguracionImpuesto{}
+
+	for rows.Next() {
+		configuracion := &entidades.ConfiguracionImpuesto{}
+		var fecha sql.NullTime
+		err := rows.Scan(
+			&configuracion.IDSede, &configuracion.NombreSede, &configuracion.PorDefecto,
+			&configuracion.Afectacion, &configuracion.TasaIGV, &configuracion.PreciosIncluyenIGV, &fecha,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if fecha.Valid {
+			configuracion.FechaActualizacion = &fecha.Time
+		}
+		configuraciones = append(configuraciones, configuracion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return configuraciones, nil
+}
+
+// GetLineasReserva obtiene los pasajes y paquetes de una reserva con su precio de lista, el total registrado
+// en la reserva y la descripción del tour
+func (r *ImpuestoRepository) GetLineasReserva(idReserva int) ([]entidades.LineaImpuesto, entidades.Dinero, string, error) {
+	var totalPagar entidades.Dinero
+	var nombreTour, fechaTour string
+	queryReserva := `SELECT r.total_pagar, tt.nombre, to_char(it.fecha_especifica, 'DD/MM/YYYY')
+                     FROM reserva r
+                     INNER JOIN instancia_tour it ON r.id_instancia = it.id_instancia
+                     INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+                     INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
+                     WHERE r.id_reserva = $1 AND r.eliminado = FALSE`
+
+	err := r.db.QueryRow(queryReserva, idReserva).Scan(&totalPagar, &nombreTour, &fechaTour)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entidades.Dinero{}, "", errors.New("reserva no encontrada")
+		}
+		return nil, entidades.Dinero{}, "", err
+	}
+	descripcionTour := fmt.Sprintf("Tour %s del %s", nombreTour, fechaTour)
+
+	query := `SELECT tp.nombre, pc.cantidad, COALESCE(pc.precio_unitario, tp.costo)
+              FROM pasajes_cantidad pc
+              INNER JOIN tipo_pasaje tp ON pc.id_tipo_pasaje = tp.id_tipo_pasaje
+              WHERE pc.id_reserva = $1 AND pc.eliminado = FALSE AND pc.cantidad > 0
+              UNION ALL
+              SELECT 'Paquete ' || pp.nombre, ppd.cantidad, COALESCE(ppd.precio_unitario, pp.precio_total)
+              FROM paquete_pasaje_detalle ppd
+              INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
+              WHERE ppd.id_reserva = $1 AND ppd.eliminado = FALSE AND ppd.cantidad > 0`
+
+	rows, err := r.db.Query(query, idReserva)
+	if err != nil {
+		return nil, entidades.Dinero{}, "", err
+	}
+	defer rows.Close()
+
+	lineas := []entidades.LineaImpuesto{}
+
+	for rows.Next() {
+		var linea entidades.LineaImpuesto
+		var concepto string
+		if err := rows.Scan(&concepto, &linea.Cantidad, &linea.PrecioUnitario); err != nil {
+			return nil, entidades.Dinero{}, "", err
+		}
+		linea.Descripcion = descripcionTour + " - " + concepto
+		lineas = append(lineas, linea)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, entidades.Dinero{}, "", err
+	}
+
+	return lineas, totalPagar, descripcionTour, nil
+}
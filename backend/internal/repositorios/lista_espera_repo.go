@@ -0,0 +1,526 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"sistema-toursseft/internal/entidades"
+)
+
+// ListaEsperaRepository maneja las operaciones de base de datos para la lista de espera
+type ListaEsperaRepository struct {
+	db *sql.DB
+}
+
+// NewListaEsperaRepository crea una nueva instancia del repositorio
+func NewListaEsperaRepository(db *sql.DB) *ListaEsperaRepository {
+	return &ListaEsperaRepository{
+		db: db,
+	}
+}
+
+// queryListaEsperaBase selecciona una entrada de la lista de espera con los datos del cliente y del tour
+const queryListaEsperaBase = `SELECT le.id_lista_espera, le.id_instancia, le.id_cliente, le.id_usuario_registra,
+              le.cantidad_pasajeros, le.estado, COALESCE(le.notas, ''), le.fecha_registro, le.fecha_oferta,
+              le.fecha_expiracion_oferta, le.id_reserva,
+              c.nombres || ' ' || c.apellidos, COALESCE(c.correo, ''), COALESCE(c.numero_celular, ''),
+              tt.nombre, to_char(it.fecha_especifica, 'DD/MM/YYYY'), to_char(it.hora_inicio, 'HH24:MI'),
+              tp.id_sede
+              FROM lista_espera le
+              INNER JOIN cliente c ON le.id_cliente = c.id_cliente
+              INNER JOIN instancia_tour it ON le.id_instancia = it.id_instancia
+              INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+              INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour`
+
+// scanListaEspera lee una fila obtenida con queryListaEsperaBase
+func scanListaEspera(scanner interface{ Scan(...interface{}) error }) (*entidades.ListaEspera, error) {
+	entrada := &entidades.ListaEspera{}
+	err := scanner.Scan(
+		&entrada.ID, &entrada.IDInstancia, &entrada.IDCliente, &entrada.IDUsuarioRegistra,
+		&entrada.CantidadPasajeros, &entrada.Estado, &entrada.Notas, &entrada.FechaRegistro, &entrada.FechaOferta,
+		&entrada.FechaExpiracionOferta, &entrada.IDReserva,
+		&entrada.NombreCliente, &entrada.CorreoCliente, &entrada.CelularCliente,
+		&entrada.NombreTour, &entrada.FechaTour, &entrada.HoraTour,
+		&entrada.IDSede,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return entrada, nil
+}
+
+// listListaEspera ejecuta una consulta basada en queryListaEsperaBase y devuelve todas las filas
+func (r *ListaEsperaRepository) listListaEspera(query string, args ...interface{}) ([]*entidades.ListaEspera, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entradas := []*entidades.ListaEspera{}
+
+	for rows.Next() {
+		entrada, err := scanListaEspera(rows)
+		if err != nil {
+			return nil, err
+		}
+		entradas = append(entradas, entrada)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entradas, nil
+}
+
+// GetByID obtiene una entrada de la lista de espera por su ID
+func (r *ListaEsperaRepository) GetByID(id int) (*entidades.ListaEspera, error) {
+	query := queryListaEsperaBase + ` WHERE le.id_lista_espera = $1`
+
+	entrada, err := scanListaEspera(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("entrada de lista de espera no encontrada")
+		}
+		return nil, err
+	}
+
+	return entrada, nil
+}
+
+// GetSedeInstancia obtiene la sede a la que pertenece una instancia de tour
+func (r *ListaEsperaRepository) GetSedeInstancia(idInstancia int) (int, error) {
+	var idSede int
+	query := `SELECT tp.id_sede FROM instancia_tour it
+              INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+              WHERE it.id_instancia = $1 AND it.eliminado = FALSE`
+
+	err := r.db.QueryRow(query, idInstancia).Scan(&idSede)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("la instancia de tour especificada no existe")
+		}
+		return 0, err
+	}
+
+	return idSede, nil
+}
+
+// Create inscribe a un cliente en la lista de espera de una instancia
+// Solo se permite cuando la instancia no tiene cupo suficiente y el cliente no tiene otra entrada activa en ella
+func (r *ListaEsperaRepository) Create(solicitud *entidades.NuevaListaEsperaRequest, idUsuario *int) (id int, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear la instancia para que el cupo no cambie mientras se registra la entrada
+	var cupoDisponible int
+	queryInstancia := `SELECT cupo_disponible FROM instancia_tour
+                      WHERE id_instancia = $1 AND eliminado = FALSE AND estado = 'PROGRAMADO'
+                      AND fecha_especifica + hora_inicio > LOCALTIMESTAMP
+                      FOR UPDATE`
+	err = tx.QueryRow(queryInstancia, solicitud.IDInstancia).Scan(&cupoDisponible)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("la instancia del tour no existe, no está programada o ya inició")
+		}
+		return 0, err
+	}
+
+	if cupoDisponible >= solicitud.CantidadPasajeros {
+		return 0, errors.New("la instancia tiene cupo suficiente, realice la reserva directamente")
+	}
+
+	// Verificar que el cliente no tenga otra entrada activa para la misma instancia
+	var existe bool
+	queryExiste := `SELECT EXISTS(SELECT 1 FROM lista_espera
+                   WHERE id_instancia = $1 AND id_cliente = $2 AND estado IN ('EN_ESPERA', 'OFERTADA'))`
+	err = tx.QueryRow(queryExiste, solicitud.IDInstancia, solicitud.IDCliente).Scan(&existe)
+	if err != nil {
+		return 0, err
+	}
+	if existe {
+		return 0, errors.New("el cliente ya está en la lista de espera de esta instancia")
+	}
+
+	query := `INSERT INTO lista_espera (id_instancia, id_cliente, id_usuario_registra, cantidad_pasajeros, estado, notas)
+              VALUES ($1, $2, $3, $4, 'EN_ESPERA', $5)
+              RETURNING id_lista_espera`
+	err = tx.QueryRow(
+		query,
+		solicitud.IDInstancia,
+		solicitud.IDCliente,
+		idUsuario,
+		solicitud.CantidadPasajeros,
+		solicitud.Notas,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Cancelar retira una entrada activa de la lista de espera
+// Si tenía una oferta vigente, los cupos retenidos vuelven a la instancia
+func (r *ListaEsperaRepository) Cancelar(id int) (err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var idInstancia, cantidad int
+	var estado string
+	queryEntrada := `SELECT id_instancia, cantidad_pasajeros, estado FROM lista_espera
+                    WHERE id_lista_espera = $1
+                    FOR UPDATE`
+	err = tx.QueryRow(queryEntrada, id).Scan(&idInstancia, &cantidad, &estado)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("entrada de lista de espera no encontrada")
+		}
+		return err
+	}
+
+	if estado != "EN_ESPERA" && estado != "OFERTADA" {
+		return errors.New("solo se pueden cancelar entradas en espera u ofertadas")
+	}
+
+	if estado == "OFERTADA" {
+		// Devolver los cupos retenidos por la oferta
+		queryRestauraCupo := `UPDATE instancia_tour
+                             SET cupo_disponible = cupo_disponible + $1
+                             WHERE id_instancia = $2`
+		_, err = tx.Exec(queryRestauraCupo, cantidad, idInstancia)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE lista_espera SET estado = 'CANCELADA' WHERE id_lista_espera = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	// Commit de la transacción
+	return tx.Commit()
+}
+
+// OfrecerCupos recorre en orden de llegada las entradas EN_ESPERA de una instancia y ofrece los cupos
+// libres a las que caben. Cada oferta retiene sus cupos durante los minutos de retención de la sede
+// Retorna los IDs de las entradas ofertadas
+func (r *ListaEsperaRepository) OfrecerCupos(idInstancia int) (ofertadas []int, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear la instancia; solo se ofrecen cupos de instancias programadas que aún no inician
+	var cupoDisponible, minutosRetencion int
+	queryInstancia := `SELECT it.cupo_disponible, s.minutos_retencion_reserva
+                      FROM instancia_tour it
+                      INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+                      INNER JOIN sede s ON tp.id_sede = s.id_sede
+                      WHERE it.id_instancia = $1 AND it.eliminado = FALSE AND it.estado = 'PROGRAMADO'
+                      AND it.fecha_especifica + it.hora_inicio > LOCALTIMESTAMP
+                      FOR UPDATE OF it`
+	err = tx.QueryRow(queryInstancia, idInstancia).Scan(&cupoDisponible, &minutosRetencion)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = nil
+			tx.Rollback()
+			return []int{}, nil
+		}
+		return nil, err
+	}
+
+	ofertadas = []int{}
+	if cupoDisponible <= 0 {
+		err = tx.Commit()
+		return ofertadas, err
+	}
+
+	queryEspera := `SELECT id_lista_espera, cantidad_pasajeros FROM lista_espera
+                   WHERE id_instancia = $1 AND estado = 'EN_ESPERA'
+                   ORDER BY fecha_registro, id_lista_espera
+                   FOR UPDATE SKIP LOCKED`
+	rows, err := tx.Query(queryEspera, idInstancia)
+	if err != nil {
+		return nil, err
+	}
+
+	type entradaEspera struct {
+		id       int
+		cantidad int
+	}
+	enEspera := []entradaEspera{}
+
+	for rows.Next() {
+		var entrada entradaEspera
+		if err = rows.Scan(&entrada.id, &entrada.cantidad); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		enEspera = append(enEspera, entrada)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, entrada := range enEspera {
+		// Las entradas que no caben conservan su lugar para la próxima liberación
+		if entrada.cantidad > cupoDisponible {
+			continue
+		}
+
+		queryOfertar := `UPDATE lista_espera SET estado = 'OFERTADA', fecha_oferta = CURRENT_TIMESTAMP,
+                        fecha_expiracion_oferta = CURRENT_TIMESTAMP + make_interval(mins => $1)
+                        WHERE id_lista_espera = $2`
+		_, err = tx.Exec(queryOfertar, minutosRetencion, entrada.id)
+		if err != nil {
+			return nil, err
+		}
+
+		cupoDisponible -= entrada.cantidad
+		ofertadas = append(ofertadas, entrada.id)
+
+		if cupoDisponible == 0 {
+			break
+		}
+	}
+
+	// Retener los cupos ofertados
+	_, err = tx.Exec(`UPDATE instancia_tour SET cupo_disponible = $1 WHERE id_instancia = $2`, cupoDisponible, idInstancia)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return ofertadas, nil
+}
+
+// ExpirarOfertas pasa a EXPIRADA las ofertas vencidas y devuelve sus cupos a las instancias
+// Retorna los IDs de las instancias que recuperaron cupo
+func (r *ListaEsperaRepository) ExpirarOfertas() (instancias []int, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Las ofertas que otra transacción tenga tomadas se procesan en la siguiente pasada
+	query := `SELECT id_lista_espera, id_instancia, cantidad_pasajeros FROM lista_espera
+              WHERE estado = 'OFERTADA' AND fecha_expiracion_oferta <= CURRENT_TIMESTAMP
+              ORDER BY id_instancia
+              FOR UPDATE SKIP LOCKED`
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	type ofertaVencida struct {
+		id          int
+		idInstancia int
+		cantidad    int
+	}
+	vencidas := []ofertaVencida{}
+
+	for rows.Next() {
+		var vencida ofertaVencida
+		if err = rows.Scan(&vencida.id, &vencida.idInstancia, &vencida.cantidad); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		vencidas = append(vencidas, vencida)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	instancias = []int{}
+	for _, vencida := range vencidas {
+		// Restaurar cupo
+		queryRestauraCupo := `UPDATE instancia_tour
+                             SET cupo_disponible = cupo_disponible + $1
+                             WHERE id_instancia = $2`
+		_, err = tx.Exec(queryRestauraCupo, vencida.cantidad, vencida.idInstancia)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(`UPDATE lista_espera SET estado = 'EXPIRADA' WHERE id_lista_espera = $1`, vencida.id)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(instancias) == 0 || instancias[len(instancias)-1] != vencida.idInstancia {
+			instancias = append(instancias, vencida.idInstancia)
+		}
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return instancias, nil
+}
+
+// ListInstanciasPendientes obtiene las instancias con cupo libre y clientes en espera
+func (r *ListaEsperaRepository) ListInstanciasPendientes() ([]int, error) {
+	query := `SELECT DISTINCT it.id_instancia
+              FROM lista_espera le
+              INNER JOIN instancia_tour it ON le.id_instancia = it.id_instancia
+              WHERE le.estado = 'EN_ESPERA' AND it.cupo_disponible > 0
+              AND it.eliminado = FALSE AND it.estado = 'PROGRAMADO'
+              AND it.fecha_especifica + it.hora_inicio > LOCALTIMESTAMP
+              ORDER BY it.id_instancia`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	instancias := []int{}
+	for rows.Next() {
+		var idInstancia int
+		if err := rows.Scan(&idInstancia); err != nil {
+			return nil, err
+		}
+		instancias = append(instancias, idInstancia)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return instancias, nil
+}
+
+// ConvertirEnReserva crea la reserva de una entrada con oferta vigente usando los cupos que retenía
+// La reserva siempre se crea para el cliente y la instancia de la entrada
+func (r *ListaEsperaRepository) ConvertirEnReserva(id int, reserva *entidades.NuevaReservaRequest) (idReserva int, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var idInstancia, idCliente, cantidad int
+	var vigente bool
+	queryEntrada := `SELECT id_instancia, id_cliente, cantidad_pasajeros,
+                    estado = 'OFERTADA' AND fecha_expiracion_oferta > CURRENT_TIMESTAMP
+                    FROM lista_espera
+                    WHERE id_lista_espera = $1
+                    FOR UPDATE`
+	err = tx.QueryRow(queryEntrada, id).Scan(&idInstancia, &idCliente, &cantidad, &vigente)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("entrada de lista de espera no encontrada")
+		}
+		return 0, err
+	}
+
+	if !vigente {
+		return 0, errors.New("la entrada no tiene una oferta vigente")
+	}
+
+	// Devolver los cupos retenidos para que la reserva los tome con la instancia bloqueada
+	queryRestauraCupo := `UPDATE instancia_tour
+                         SET cupo_disponible = cupo_disponible + $1
+                         WHERE id_instancia = $2`
+	_, err = tx.Exec(queryRestauraCupo, cantidad, idInstancia)
+	if err != nil {
+		return 0, err
+	}
+
+	reserva.IDInstancia = idInstancia
+	reserva.IDCliente = idCliente
+	idReserva, err = crearReservaTx(tx, reserva)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`UPDATE lista_espera SET estado = 'CONVERTIDA', id_reserva = $1 WHERE id_lista_espera = $2`, idReserva, id)
+	if err != nil {
+		return 0, err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return idReserva, nil
+}
+
+// ListByInstancia lista la lista de espera de una instancia en orden de llegada
+func (r *ListaEsperaRepository) ListByInstancia(idInstancia int) ([]*entidades.ListaEspera, error) {
+	query := queryListaEsperaBase + ` WHERE le.id_instancia = $1
+              ORDER BY le.fecha_registro, le.id_lista_espera`
+	return r.listListaEspera(query, idInstancia)
+}
+
+// ListByCliente lista las entradas de lista de espera de un cliente
+func (r *ListaEsperaRepository) ListByCliente(idCliente int) ([]*entidades.ListaEspera, error) {
+	query := queryListaEsperaBase + ` WHERE le.id_cliente = $1
+              ORDER BY le.fecha_registro DESC`
+	return r.listListaEspera(query, idCliente)
+}
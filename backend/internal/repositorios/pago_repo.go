@@ -23,7 +23,7 @@ func NewPagoRepository(db *sql.DB) *PagoRepository {
 func (r *PagoRepository) GetByID(id int) (*entidades.Pago, error) {
 	pago := &entidades.Pago{}
 	query := `SELECT p.id_pago, p.id_reserva, p.id_metodo_pago, p.id_canal, p.id_sede,
-              p.monto, p.fecha_pago, p.comprobante, p.estado, p.eliminado,
+              p.monto, p.moneda, p.tipo_cambio, COALESCE(p.monto_convertido, p.monto), p.fecha_pago, p.comprobante, p.id_caja_sesion, p.estado, p.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               mp.nombre, cv.nombre, s.nombre,
               tt.nombre, tp.fecha
@@ -39,7 +39,7 @@ func (r *PagoRepository) GetByID(id int) (*entidades.Pago, error) {
 
 	err := r.db.QueryRow(query, id).Scan(
 		&pago.ID, &pago.IDReserva, &pago.IDMetodoPago, &pago.IDCanal, &pago.IDSede,
-		&pago.Monto, &pago.FechaPago, &pago.Comprobante, &pago.Estado, &pago.Eliminado,
+		&pago.Monto, &pago.Moneda, &pago.TipoCambio, &pago.MontoConvertido, &pago.FechaPago, &pago.Comprobante, &pago.IDCajaSesion, &pago.Estado, &pago.Eliminado,
 		&pago.NombreCliente, &pago.ApellidosCliente, &pago.DocumentoCliente,
 		&pago.NombreMetodoPago, &pago.NombreCanalVenta, &pago.NombreSede,
 		&pago.TourNombre, &pago.TourFecha,
@@ -52,26 +52,81 @@ func (r *PagoRepository) GetByID(id int) (*entidades.Pago, error) {
 		return nil, err
 	}
 
+	pago.Monto = pago.Monto.EnMoneda(pago.Moneda)
 	return pago, nil
 }
 
-// Create guarda un nuevo pago en la base de datos
-func (r *PagoRepository) Create(pago *entidades.NuevoPagoRequest) (int, error) {
-	var id int
-	query := `INSERT INTO pago (id_reserva, id_metodo_pago, id_canal, id_sede, monto, comprobante, eliminado)
-              VALUES ($1, $2, $3, $4, $5, $6, FALSE)
+// Create guarda un nuevo pago en la base de datos y actualiza el estado de la reserva según lo pagado
+func (r *PagoRepository) Create(pago *entidades.NuevoPagoRequest) (id int, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear la reserva para que dos pagos simultáneos no excedan el total a pagar
+	estado, err := bloquearReservaPagoTx(tx, pago.IDReserva)
+	if err != nil {
+		return 0, err
+	}
+	if estado == "CANCELADA" {
+		return 0, errors.New("no se puede registrar un pago para una reserva cancelada")
+	}
+
+	// Bloquear la caja para que no se cierre mientras se registra el pago
+	if pago.IDCajaSesion != nil {
+		var estadoCaja string
+		queryCaja := `SELECT estado FROM caja_sesion WHERE id_caja_sesion = $1 FOR SHARE`
+		err = tx.QueryRow(queryCaja, *pago.IDCajaSesion).Scan(&estadoCaja)
+		if err != nil {
+			return 0, err
+		}
+		if estadoCaja != entidades.EstadoCajaAbierta {
+			err = errors.New("la caja del pago ya está cerrada")
+			return 0, err
+		}
+	}
+
+	query := `INSERT INTO pago (id_reserva, id_metodo_pago, id_canal, id_sede, monto, moneda, tipo_cambio, monto_convertido,
+              comprobante, id_caja_sesion, eliminado)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, FALSE)
               RETURNING id_pago`
 
-	err := r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		pago.IDReserva,
 		pago.IDMetodoPago,
 		pago.IDCanal,
 		pago.IDSede,
 		pago.Monto,
+		pago.Monto.Moneda(),
+		pago.Conversion.TipoCambio,
+		pago.Conversion.MontoBase,
 		pago.Comprobante,
+		pago.IDCajaSesion,
 	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
 
+	resultado, err := actualizarEstadoPorSaldoTx(tx, pago.IDReserva)
+	if err != nil {
+		return 0, err
+	}
+	if resultado.Saldo.EsNegativo() {
+		err = errors.New("el monto total pagado excedería el total a pagar de la reserva")
+		return 0, err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
 	if err != nil {
 		return 0, err
 	}
@@ -79,69 +134,184 @@ func (r *PagoRepository) Create(pago *entidades.NuevoPagoRequest) (int, error) {
 	return id, nil
 }
 
-// Update actualiza la información de un pago
-func (r *PagoRepository) Update(id int, pago *entidades.ActualizarPagoRequest) error {
+// Update actualiza la información de un pago y el estado de su reserva según lo pagado
+func (r *PagoRepository) Update(id int, pago *entidades.ActualizarPagoRequest) (err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	idReserva, err := reservaDePagoTx(tx, id)
+	if err != nil {
+		return err
+	}
+
 	query := `UPDATE pago SET
               id_metodo_pago = $1,
               id_canal = $2,
               id_sede = $3,
               monto = $4,
-              comprobante = $5,
-              estado = $6
-              WHERE id_pago = $7 AND eliminado = FALSE`
-
-	_, err := r.db.Exec(
+              moneda = $5,
+              tipo_cambio = $6,
+              monto_convertido = $7,
+              comprobante = $8,
+              estado = $9
+              WHERE id_pago = $10 AND eliminado = FALSE`
+
+	_, err = tx.Exec(
 		query,
 		pago.IDMetodoPago,
 		pago.IDCanal,
 		pago.IDSede,
 		pago.Monto,
+		pago.Monto.Moneda(),
+		pago.Conversion.TipoCambio,
+		pago.Conversion.MontoBase,
 		pago.Comprobante,
 		pago.Estado,
 		id,
 	)
+	if err != nil {
+		return err
+	}
+
+	_, err = actualizarEstadoPorSaldoTx(tx, idReserva)
+	if err != nil {
+		return err
+	}
 
+	// Commit de la transacción
+	err = tx.Commit()
 	return err
 }
 
-// UpdateEstado actualiza solo el estado de un pago
-func (r *PagoRepository) UpdateEstado(id int, estado string) error {
+// UpdateEstado actualiza solo el estado de un pago y el estado de su reserva según lo pagado
+func (r *PagoRepository) UpdateEstado(id int, estado string) (err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	idReserva, err := reservaDePagoTx(tx, id)
+	if err != nil {
+		return err
+	}
+
 	query := `UPDATE pago SET estado = $1 WHERE id_pago = $2 AND eliminado = FALSE`
-	_, err := r.db.Exec(query, estado, id)
+	_, err = tx.Exec(query, estado, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = actualizarEstadoPorSaldoTx(tx, idReserva)
+	if err != nil {
+		return err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
 	return err
 }
 
 // Delete elimina un pago
-func (r *PagoRepository) Delete(id int) error {
+func (r *PagoRepository) Delete(id int) (err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
 	// Verificar si hay comprobantes asociados a este pago a través de la reserva
-	var idReserva int
-	queryGetReserva := `SELECT id_reserva FROM pago WHERE id_pago = $1 AND eliminado = FALSE`
-	err := r.db.QueryRow(queryGetReserva, id).Scan(&idReserva)
+	idReserva, err := reservaDePagoTx(tx, id)
 	if err != nil {
 		return err
 	}
 
 	var countComprobantes int
 	queryCheckComprobantes := `SELECT COUNT(*) FROM comprobante_pago WHERE id_reserva = $1 AND eliminado = FALSE`
-	err = r.db.QueryRow(queryCheckComprobantes, idReserva).Scan(&countComprobantes)
+	err = tx.QueryRow(queryCheckComprobantes, idReserva).Scan(&countComprobantes)
 	if err != nil {
 		return err
 	}
 
 	if countComprobantes > 0 {
-		return errors.New("no se puede eliminar este pago porque la reserva tiene comprobantes asociados")
+		err = errors.New("no se puede eliminar este pago porque la reserva tiene comprobantes asociados")
+		return err
 	}
 
 	// Eliminación lógica
 	query := `UPDATE pago SET eliminado = TRUE WHERE id_pago = $1`
-	_, err = r.db.Exec(query, id)
+	_, err = tx.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	// Un pago eliminado deja de contar para el saldo de la reserva
+	_, err = actualizarEstadoPorSaldoTx(tx, idReserva)
+	if err != nil {
+		return err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
 	return err
 }
 
+// reservaDePagoTx obtiene la reserva de un pago y la bloquea antes de modificarlo
+// Los pagos de una caja cerrada ya están cuadrados y no se pueden modificar
+func reservaDePagoTx(tx *sql.Tx, idPago int) (int, error) {
+	var idReserva int
+	var estadoCaja sql.NullString
+	query := `SELECT p.id_reserva, cs.estado FROM pago p
+              LEFT JOIN caja_sesion cs ON p.id_caja_sesion = cs.id_caja_sesion
+              WHERE p.id_pago = $1 AND p.eliminado = FALSE`
+	err := tx.QueryRow(query, idPago).Scan(&idReserva, &estadoCaja)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("pago no encontrado")
+		}
+		return 0, err
+	}
+
+	if estadoCaja.Valid && estadoCaja.String == entidades.EstadoCajaCerrada {
+		return 0, errors.New("no se puede modificar un pago de una caja cerrada")
+	}
+
+	_, err = bloquearReservaPagoTx(tx, idReserva)
+	if err != nil {
+		return 0, err
+	}
+
+	return idReserva, nil
+}
+
 // List lista todos los pagos activos
 func (r *PagoRepository) List() ([]*entidades.Pago, error) {
 	query := `SELECT p.id_pago, p.id_reserva, p.id_metodo_pago, p.id_canal, p.id_sede,
-              p.monto, p.fecha_pago, p.comprobante, p.estado, p.eliminado,
+              p.monto, p.moneda, p.tipo_cambio, COALESCE(p.monto_convertido, p.monto), p.fecha_pago, p.comprobante, p.id_caja_sesion, p.estado, p.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               mp.nombre, cv.nombre, s.nombre,
               tt.nombre, tp.fecha
@@ -168,7 +338,7 @@ func (r *PagoRepository) List() ([]*entidades.Pago, error) {
 		pago := &entidades.Pago{}
 		err := rows.Scan(
 			&pago.ID, &pago.IDReserva, &pago.IDMetodoPago, &pago.IDCanal, &pago.IDSede,
-			&pago.Monto, &pago.FechaPago, &pago.Comprobante, &pago.Estado, &pago.Eliminado,
+			&pago.Monto, &pago.Moneda, &pago.TipoCambio, &pago.MontoConvertido, &pago.FechaPago, &pago.Comprobante, &pago.IDCajaSesion, &pago.Estado, &pago.Eliminado,
 			&pago.NombreCliente, &pago.ApellidosCliente, &pago.DocumentoCliente,
 			&pago.NombreMetodoPago, &pago.NombreCanalVenta, &pago.NombreSede,
 			&pago.TourNombre, &pago.TourFecha,
@@ -176,6 +346,7 @@ func (r *PagoRepository) List() ([]*entidades.Pago, error) {
 		if err != nil {
 			return nil, err
 		}
+		pago.Monto = pago.Monto.EnMoneda(pago.Moneda)
 		pagos = append(pagos, pago)
 	}
 
@@ -189,7 +360,7 @@ func (r *PagoRepository) List() ([]*entidades.Pago, error) {
 // ListByReserva lista todos los pagos de una reserva específica
 func (r *PagoRepository) ListByReserva(idReserva int) ([]*entidades.Pago, error) {
 	query := `SELECT p.id_pago, p.id_reserva, p.id_metodo_pago, p.id_canal, p.id_sede,
-              p.monto, p.fecha_pago, p.comprobante, p.estado, p.eliminado,
+              p.monto, p.moneda, p.tipo_cambio, COALESCE(p.monto_convertido, p.monto), p.fecha_pago, p.comprobante, p.id_caja_sesion, p.estado, p.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               mp.nombre, cv.nombre, s.nombre,
               tt.nombre, tp.fecha
@@ -216,7 +387,7 @@ func (r *PagoRepository) ListByReserva(idReserva int) ([]*entidades.Pago, error)
 		pago := &entidades.Pago{}
 		err := rows.Scan(
 			&pago.ID, &pago.IDReserva, &pago.IDMetodoPago, &pago.IDCanal, &pago.IDSede,
-			&pago.Monto, &pago.FechaPago, &pago.Comprobante, &pago.Estado, &pago.Eliminado,
+			&pago.Monto, &pago.Moneda, &pago.TipoCambio, &pago.MontoConvertido, &pago.FechaPago, &pago.Comprobante, &pago.IDCajaSesion, &pago.Estado, &pago.Eliminado,
 			&pago.NombreCliente, &pago.ApellidosCliente, &pago.DocumentoCliente,
 			&pago.NombreMetodoPago, &pago.NombreCanalVenta, &pago.NombreSede,
 			&pago.TourNombre, &pago.TourFecha,
@@ -224,6 +395,7 @@ func (r *PagoRepository) ListByReserva(idReserva int) ([]*entidades.Pago, error)
 		if err != nil {
 			return nil, err
 		}
+		pago.Monto = pago.Monto.EnMoneda(pago.Moneda)
 		pagos = append(pagos, pago)
 	}
 
@@ -237,7 +409,7 @@ func (r *PagoRepository) ListByReserva(idReserva int) ([]*entidades.Pago, error)
 // ListByFecha lista todos los pagos de una fecha específica
 func (r *PagoRepository) ListByFecha(fecha time.Time) ([]*entidades.Pago, error) {
 	query := `SELECT p.id_pago, p.id_reserva, p.id_metodo_pago, p.id_canal, p.id_sede,
-              p.monto, p.fecha_pago, p.comprobante, p.estado, p.eliminado,
+              p.monto, p.moneda, p.tipo_cambio, COALESCE(p.monto_convertido, p.monto), p.fecha_pago, p.comprobante, p.id_caja_sesion, p.estado, p.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               mp.nombre, cv.nombre, s.nombre,
               tt.nombre, tp.fecha
@@ -264,7 +436,7 @@ func (r *PagoRepository) ListByFecha(fecha time.Time) ([]*entidades.Pago, error)
 		pago := &entidades.Pago{}
 		err := rows.Scan(
 			&pago.ID, &pago.IDReserva, &pago.IDMetodoPago, &pago.IDCanal, &pago.IDSede,
-			&pago.Monto, &pago.FechaPago, &pago.Comprobante, &pago.Estado, &pago.Eliminado,
+			&pago.Monto, &pago.Moneda, &pago.TipoCambio, &pago.MontoConvertido, &pago.FechaPago, &pago.Comprobante, &pago.IDCajaSesion, &pago.Estado, &pago.Eliminado,
 			&pago.NombreCliente, &pago.ApellidosCliente, &pago.DocumentoCliente,
 			&pago.NombreMetodoPago, &pago.NombreCanalVenta, &pago.NombreSede,
 			&pago.TourNombre, &pago.TourFecha,
@@ -272,6 +444,7 @@ func (r *PagoRepository) ListByFecha(fecha time.Time) ([]*entidades.Pago, error)
 		if err != nil {
 			return nil, err
 		}
+		pago.Monto = pago.Monto.EnMoneda(pago.Moneda)
 		pagos = append(pagos, pago)
 	}
 
@@ -282,14 +455,14 @@ func (r *PagoRepository) ListByFecha(fecha time.Time) ([]*entidades.Pago, error)
 	return pagos, nil
 }
 
-// GetTotalPagadoByReserva obtiene el total pagado de una reserva específica
-func (r *PagoRepository) GetTotalPagadoByReserva(idReserva int) (float64, error) {
-	var totalPagado float64
-	query := `SELECT COALESCE(SUM(monto), 0) FROM pago WHERE id_reserva = $1 AND estado = 'PROCESADO' AND eliminado = FALSE`
+// GetTotalPagadoByReserva obtiene el total pagado de una reserva específica, en soles
+func (r *PagoRepository) GetTotalPagadoByReserva(idReserva int) (entidades.Dinero, error) {
+	var totalPagado entidades.Dinero
+	query := `SELECT COALESCE(SUM(COALESCE(monto_convertido, monto)), 0) FROM pago WHERE id_reserva = $1 AND estado = 'PROCESADO' AND eliminado = FALSE`
 
 	err := r.db.QueryRow(query, idReserva).Scan(&totalPagado)
 	if err != nil {
-		return 0, err
+		return entidades.Dinero{}, err
 	}
 
 	return totalPagado, nil
@@ -298,7 +471,7 @@ func (r *PagoRepository) GetTotalPagadoByReserva(idReserva int) (float64, error)
 // ListByEstado lista todos los pagos con un estado específico
 func (r *PagoRepository) ListByEstado(estado string) ([]*entidades.Pago, error) {
 	query := `SELECT p.id_pago, p.id_reserva, p.id_metodo_pago, p.id_canal, p.id_sede,
-              p.monto, p.fecha_pago, p.comprobante, p.estado, p.eliminado,
+              p.monto, p.moneda, p.tipo_cambio, COALESCE(p.monto_convertido, p.monto), p.fecha_pago, p.comprobante, p.id_caja_sesion, p.estado, p.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               mp.nombre, cv.nombre, s.nombre,
               tt.nombre, tp.fecha
@@ -325,7 +498,7 @@ func (r *PagoRepository) ListByEstado(estado string) ([]*entidades.Pago, error)
 		pago := &entidades.Pago{}
 		err := rows.Scan(
 			&pago.ID, &pago.IDReserva, &pago.IDMetodoPago, &pago.IDCanal, &pago.IDSede,
-			&pago.Monto, &pago.FechaPago, &pago.Comprobante, &pago.Estado, &pago.Eliminado,
+			&pago.Monto, &pago.Moneda, &pago.TipoCambio, &pago.MontoConvertido, &pago.FechaPago, &pago.Comprobante, &pago.IDCajaSesion, &pago.Estado, &pago.Eliminado,
 			&pago.NombreCliente, &pago.ApellidosCliente, &pago.DocumentoCliente,
 			&pago.NombreMetodoPago, &pago.NombreCanalVenta, &pago.NombreSede,
 			&pago.TourNombre, &pago.TourFecha,
@@ -333,6 +506,7 @@ func (r *PagoRepository) ListByEstado(estado string) ([]*entidades.Pago, error)
 		if err != nil {
 			return nil, err
 		}
+		pago.Monto = pago.Monto.EnMoneda(pago.Moneda)
 		pagos = append(pagos, pago)
 	}
 
@@ -346,7 +520,7 @@ func (r *PagoRepository) ListByEstado(estado string) ([]*entidades.Pago, error)
 // ListByCliente lista todos los pagos de un cliente específico
 func (r *PagoRepository) ListByCliente(idCliente int) ([]*entidades.Pago, error) {
 	query := `SELECT p.id_pago, p.id_reserva, p.id_metodo_pago, p.id_canal, p.id_sede,
-              p.monto, p.fecha_pago, p.comprobante, p.estado, p.eliminado,
+              p.monto, p.moneda, p.tipo_cambio, COALESCE(p.monto_convertido, p.monto), p.fecha_pago, p.comprobante, p.id_caja_sesion, p.estado, p.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               mp.nombre, cv.nombre, s.nombre,
               tt.nombre, tp.fecha
@@ -373,7 +547,7 @@ func (r *PagoRepository) ListByCliente(idCliente int) ([]*entidades.Pago, error)
 		pago := &entidades.Pago{}
 		err := rows.Scan(
 			&pago.ID, &pago.IDReserva, &pago.IDMetodoPago, &pago.IDCanal, &pago.IDSede,
-			&pago.Monto, &pago.FechaPago, &pago.Comprobante, &pago.Estado, &pago.Eliminado,
+			&pago.Monto, &pago.Moneda, &pago.TipoCambio, &pago.MontoConvertido, &pago.FechaPago, &pago.Comprobante, &pago.IDCajaSesion, &pago.Estado, &pago.Eliminado,
 			&pago.NombreCliente, &pago.ApellidosCliente, &pago.DocumentoCliente,
 			&pago.NombreMetodoPago, &pago.NombreCanalVenta, &pago.NombreSede,
 			&pago.TourNombre, &pago.TourFecha,
@@ -381,6 +555,7 @@ func (r *PagoRepository) ListByCliente(idCliente int) ([]*entidades.Pago, error)
 		if err != nil {
 			return nil, err
 		}
+		pago.Monto = pago.Monto.EnMoneda(pago.Moneda)
 		pagos = append(pagos, pago)
 	}
 
@@ -394,7 +569,7 @@ func (r *PagoRepository) ListByCliente(idCliente int) ([]*entidades.Pago, error)
 // ListBySede lista todos los pagos de una sede específica
 func (r *PagoRepository) ListBySede(idSede int) ([]*entidades.Pago, error) {
 	query := `SELECT p.id_pago, p.id_reserva, p.id_metodo_pago, p.id_canal, p.id_sede,
-              p.monto, p.fecha_pago, p.comprobante, p.estado, p.eliminado,
+              p.monto, p.moneda, p.tipo_cambio, COALESCE(p.monto_convertido, p.monto), p.fecha_pago, p.comprobante, p.id_caja_sesion, p.estado, p.eliminado,
               c.nombres, c.apellidos, c.numero_documento,
               mp.nombre, cv.nombre, s.nombre,
               tt.nombre, tp.fecha
@@ -421,7 +596,7 @@ func (r *PagoRepository) ListBySede(idSede int) ([]*entidades.Pago, error) {
 		pago := &entidades.Pago{}
 		err := rows.Scan(
 			&pago.ID, &pago.IDReserva, &pago.IDMetodoPago, &pago.IDCanal, &pago.IDSede,
-			&pago.Monto, &pago.FechaPago, &pago.Comprobante, &pago.Estado, &pago.Eliminado,
+			&pago.Monto, &pago.Moneda, &pago.TipoCambio, &pago.MontoConvertido, &pago.FechaPago, &pago.Comprobante, &pago.IDCajaSesion, &pago.Estado, &pago.Eliminado,
 			&pago.NombreCliente, &pago.ApellidosCliente, &pago.DocumentoCliente,
 			&pago.NombreMetodoPago, &pago.NombreCanalVenta, &pago.NombreSede,
 			&pago.TourNombre, &pago.TourFecha,
@@ -429,6 +604,7 @@ func (r *PagoRepository) ListBySede(idSede int) ([]*entidades.Pago, error) {
 		if err != nil {
 			return nil, err
 		}
+		pago.Monto = pago.Monto.EnMoneda(pago.Moneda)
 		pagos = append(pagos, pago)
 	}
 
@@ -21,13 +21,13 @@ func NewPaquetePasajesRepository(db *sql.DB) *PaquetePasajesRepository {
 // GetByID obtiene un paquete de pasajes por su ID
 func (r *PaquetePasajesRepository) GetByID(id int) (*entidades.PaquetePasajes, error) {
 	paquete := &entidades.PaquetePasajes{}
-	query := `SELECT id_paquete, id_sede, id_tipo_tour, nombre, descripcion, precio_total, cantidad_total, eliminado
+	query := `SELECT id_paquete, id_sede, id_tipo_tour, nombre, descripcion, precio_total, moneda, cantidad_total, eliminado
               FROM paquete_pasajes
               WHERE id_paquete = $1 AND eliminado = false`
 
 	err := r.db.QueryRow(query, id).Scan(
 		&paquete.ID, &paquete.IDSede, &paquete.IDTipoTour, &paquete.Nombre,
-		&paquete.Descripcion, &paquete.PrecioTotal, &paquete.CantidadTotal, &paquete.Eliminado,
+		&paquete.Descripcion, &paquete.PrecioTotal, &paquete.Moneda, &paquete.CantidadTotal, &paquete.Eliminado,
 	)
 
 	if err != nil {
@@ -37,19 +37,20 @@ func (r *PaquetePasajesRepository) GetByID(id int) (*entidades.PaquetePasajes, e
 		return nil, err
 	}
 
+	paquete.PrecioTotal = paquete.PrecioTotal.EnMoneda(paquete.Moneda)
 	return paquete, nil
 }
 
 // GetByNombre obtiene un paquete de pasajes por su nombre y sede
 func (r *PaquetePasajesRepository) GetByNombre(nombre string, idSede int) (*entidades.PaquetePasajes, error) {
 	paquete := &entidades.PaquetePasajes{}
-	query := `SELECT id_paquete, id_sede, id_tipo_tour, nombre, descripcion, precio_total, cantidad_total, eliminado
+	query := `SELECT id_paquete, id_sede, id_tipo_tour, nombre, descripcion, precio_total, moneda, cantidad_total, eliminado
               FROM paquete_pasajes
               WHERE nombre = $1 AND id_sede = $2 AND eliminado = false`
 
 	err := r.db.QueryRow(query, nombre, idSede).Scan(
 		&paquete.ID, &paquete.IDSede, &paquete.IDTipoTour, &paquete.Nombre,
-		&paquete.Descripcion, &paquete.PrecioTotal, &paquete.CantidadTotal, &paquete.Eliminado,
+		&paquete.Descripcion, &paquete.PrecioTotal, &paquete.Moneda, &paquete.CantidadTotal, &paquete.Eliminado,
 	)
 
 	if err != nil {
@@ -59,14 +60,15 @@ func (r *PaquetePasajesRepository) GetByNombre(nombre string, idSede int) (*enti
 		return nil, err
 	}
 
+	paquete.PrecioTotal = paquete.PrecioTotal.EnMoneda(paquete.Moneda)
 	return paquete, nil
 }
 
 // Create guarda un nuevo paquete de pasajes en la base de datos
 func (r *PaquetePasajesRepository) Create(paquete *entidades.NuevoPaquetePasajesRequest) (int, error) {
 	var id int
-	query := `INSERT INTO paquete_pasajes (id_sede, id_tipo_tour, nombre, descripcion, precio_total, cantidad_total, eliminado)
-              VALUES ($1, $2, $3, $4, $5, $6, false)
+	query := `INSERT INTO paquete_pasajes (id_sede, id_tipo_tour, nombre, descripcion, precio_total, moneda, cantidad_total, eliminado)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, false)
               RETURNING id_paquete`
 
 	err := r.db.QueryRow(
@@ -76,6 +78,7 @@ func (r *PaquetePasajesRepository) Create(paquete *entidades.NuevoPaquetePasajes
 		paquete.Nombre,
 		paquete.Descripcion,
 		paquete.PrecioTotal,
+		monedaPrecio(paquete.Moneda),
 		paquete.CantidadTotal,
 	).Scan(&id)
 
@@ -93,8 +96,9 @@ func (r *PaquetePasajesRepository) Update(id int, paquete *entidades.ActualizarP
               nombre = $2,
               descripcion = $3,
               precio_total = $4,
-              cantidad_total = $5
-              WHERE id_paquete = $6 AND eliminado = false`
+              moneda = $5,
+              cantidad_total = $6
+              WHERE id_paquete = $7 AND eliminado = false`
 
 	result, err := r.db.Exec(
 		query,
@@ -102,6 +106,7 @@ func (r *PaquetePasajesRepository) Update(id int, paquete *entidades.ActualizarP
 		paquete.Nombre,
 		paquete.Descripcion,
 		paquete.PrecioTotal,
+		monedaPrecio(paquete.Moneda),
 		paquete.CantidadTotal,
 		id,
 	)
@@ -158,7 +163,7 @@ func (r *PaquetePasajesRepository) Delete(id int) error {
 
 // ListBySede lista todos los paquetes de pasajes de una sede específica
 func (r *PaquetePasajesRepository) ListBySede(idSede int) ([]*entidades.PaquetePasajes, error) {
-	query := `SELECT id_paquete, id_sede, id_tipo_tour, nombre, descripcion, precio_total, cantidad_total, eliminado
+	query := `SELECT id_paquete, id_sede, id_tipo_tour, nombre, descripcion, precio_total, moneda, cantidad_total, eliminado
               FROM paquete_pasajes
               WHERE id_sede = $1 AND eliminado = false
               ORDER BY precio_total ASC`
@@ -175,11 +180,12 @@ func (r *PaquetePasajesRepository) ListBySede(idSede int) ([]*entidades.PaqueteP
 		paquete := &entidades.PaquetePasajes{}
 		err := rows.Scan(
 			&paquete.ID, &paquete.IDSede, &paquete.IDTipoTour, &paquete.Nombre,
-			&paquete.Descripcion, &paquete.PrecioTotal, &paquete.CantidadTotal, &paquete.Eliminado,
+			&paquete.Descripcion, &paquete.PrecioTotal, &paquete.Moneda, &paquete.CantidadTotal, &paquete.Eliminado,
 		)
 		if err != nil {
 			return nil, err
 		}
+		paquete.PrecioTotal = paquete.PrecioTotal.EnMoneda(paquete.Moneda)
 		paquetes = append(paquetes, paquete)
 	}
 
@@ -192,7 +198,7 @@ func (r *PaquetePasajesRepository) ListBySede(idSede int) ([]*entidades.PaqueteP
 
 // ListByTipoTour lista todos los paquetes de pasajes asociados a un tipo de tour específico
 func (r *PaquetePasajesRepository) ListByTipoTour(idTipoTour int) ([]*entidades.PaquetePasajes, error) {
-	query := `SELECT id_paquete, id_sede, id_tipo_tour, nombre, descripcion, precio_total, cantidad_total, eliminado
+	query := `SELECT id_paquete, id_sede, id_tipo_tour, nombre, descripcion, precio_total, moneda, cantidad_total, eliminado
               FROM paquete_pasajes
               WHERE id_tipo_tour = $1 AND eliminado = false
               ORDER BY precio_total ASC`
@@ -209,11 +215,12 @@ func (r *PaquetePasajesRepository) ListByTipoTour(idTipoTour int) ([]*entidades.
 		paquete := &entidades.PaquetePasajes{}
 		err := rows.Scan(
 			&paquete.ID, &paquete.IDSede, &paquete.IDTipoTour, &paquete.Nombre,
-			&paquete.Descripcion, &paquete.PrecioTotal, &paquete.CantidadTotal, &paquete.Eliminado,
+			&paquete.Descripcion, &paquete.PrecioTotal, &paquete.Moneda, &paquete.CantidadTotal, &paquete.Eliminado,
 		)
 		if err != nil {
 			return nil, err
 		}
+		paquete.PrecioTotal = paquete.PrecioTotal.EnMoneda(paquete.Moneda)
 		paquetes = append(paquetes, paquete)
 	}
 
@@ -226,7 +233,7 @@ func (r *PaquetePasajesRepository) ListByTipoTour(idTipoTour int) ([]*entidades.
 
 // List lista todos los paquetes de pasajes
 func (r *PaquetePasajesRepository) List() ([]*entidades.PaquetePasajes, error) {
-	query := `SELECT id_paquete, id_sede, id_tipo_tour, nombre, descripcion, precio_total, cantidad_total, eliminado
+	query := `SELECT id_paquete, id_sede, id_tipo_tour, nombre, descripcion, precio_total, moneda, cantidad_total, eliminado
               FROM paquete_pasajes
               WHERE eliminado = false
               ORDER BY id_sede, precio_total ASC`
@@ -243,11 +250,12 @@ func (r *PaquetePasajesRepository) List() ([]*entidades.PaquetePasajes, error) {
 		paquete := &entidades.PaquetePasajes{}
 		err := rows.Scan(
 			&paquete.ID, &paquete.IDSede, &paquete.IDTipoTour, &paquete.Nombre,
-			&paquete.Descripcion, &paquete.PrecioTotal, &paquete.CantidadTotal, &paquete.Eliminado,
+			&paquete.Descripcion, &paquete.PrecioTotal, &paquete.Moneda, &paquete.CantidadTotal, &paquete.Eliminado,
 		)
 		if err != nil {
 			return nil, err
 		}
+		paquete.PrecioTotal = paquete.PrecioTotal.EnMoneda(paquete.Moneda)
 		paquetes = append(paquetes, paquete)
 	}
 
@@ -0,0 +1,262 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sistema-toursseft/internal/entidades"
+	"time"
+)
+
+// PasajeroRepository maneja las operaciones de base de datos para pasajeros
+type PasajeroRepository struct {
+	db *sql.DB
+}
+
+// NewPasajeroRepository crea una nueva instancia del repositorio
+func NewPasajeroRepository(db *sql.DB) *PasajeroRepository {
+	return &PasajeroRepository{
+		db: db,
+	}
+}
+
+// edadEnFecha calcula los años cumplidos a una fecha dada
+func edadEnFecha(nacimiento time.Time, fecha time.Time) int {
+	edad := fecha.Year() - nacimiento.Year()
+	if fecha.Month() < nacimiento.Month() || (fecha.Month() == nacimiento.Month() && fecha.Day() < nacimiento.Day()) {
+		edad--
+	}
+	return edad
+}
+
+// RegistrarPorReserva reemplaza la lista de pasajeros de una reserva vigente
+// La cantidad de pasajeros debe coincidir con los pasajes de la reserva y el tour no debe haber iniciado
+func (r *PasajeroRepository) RegistrarPorReserva(idReserva int, pasajeros []entidades.PasajeroRequest) (err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear la reserva para que sus pasajes no cambien mientras se registran los pasajeros
+	var estado string
+	var iniciado bool
+	queryReserva := `SELECT r.estado, it.fecha_especifica + it.hora_inicio <= LOCALTIMESTAMP
+                    FROM reserva r
+                    INNER JOIN instancia_tour it ON r.id_instancia = it.id_instancia
+                    WHERE r.id_reserva = $1 AND r.eliminado = FALSE
+                    FOR UPDATE OF r`
+	err = tx.QueryRow(queryReserva, idReserva).Scan(&estado, &iniciado)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("reserva no encontrada")
+		}
+		return err
+	}
+
+	if estado != "RESERVADO" && estado != "CONFIRMADA" {
+		return errors.New("solo se pueden registrar pasajeros en reservas vigentes")
+	}
+	if iniciado {
+		return errors.New("no se pueden modificar los pasajeros de un tour que ya inició")
+	}
+
+	totalPasajes, err := cantidadPasajerosReservaTx(tx, idReserva)
+	if err != nil {
+		return err
+	}
+	if len(pasajeros) != totalPasajes {
+		return fmt.Errorf("la reserva tiene %d pasajes y se enviaron %d pasajeros", totalPasajes, len(pasajeros))
+	}
+
+	// Un mismo documento no puede repetirse dentro de la reserva
+	documentos := map[string]bool{}
+	for _, pasajero := range pasajeros {
+		clave := pasajero.TipoDocumento + ":" + pasajero.NumeroDocumento
+		if documentos[clave] {
+			return fmt.Errorf("el documento %s %s está repetido", pasajero.TipoDocumento, pasajero.NumeroDocumento)
+		}
+		documentos[clave] = true
+	}
+
+	// Reemplazar la lista anterior
+	_, err = tx.Exec(`UPDATE pasajero SET eliminado = TRUE WHERE id_reserva = $1 AND eliminado = FALSE`, idReserva)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO pasajero (id_reserva, id_tipo_pasaje, nombres, apellidos, tipo_documento, numero_documento,
+              fecha_nacimiento, nacionalidad, contacto_emergencia_nombre, contacto_emergencia_telefono, eliminado)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, FALSE)`
+	for _, pasajero := range pasajeros {
+		var fechaNacimiento time.Time
+		fechaNacimiento, err = time.Parse("2006-01-02", pasajero.FechaNacimiento)
+		if err != nil {
+			return errors.New("formato de fecha de nacimiento inválido, debe ser YYYY-MM-DD")
+		}
+
+		_, err = tx.Exec(
+			query,
+			idReserva,
+			pasajero.IDTipoPasaje,
+			pasajero.Nombres,
+			pasajero.Apellidos,
+			pasajero.TipoDocumento,
+			pasajero.NumeroDocumento,
+			fechaNacimiento,
+			pasajero.Nacionalidad,
+			pasajero.ContactoEmergenciaNombre,
+			pasajero.ContactoEmergenciaTelefono,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Commit de la transacción
+	return tx.Commit()
+}
+
+// ListByReserva lista los pasajeros registrados en una reserva con su edad a la fecha del tour
+func (r *PasajeroRepository) ListByReserva(idReserva int) ([]*entidades.Pasajero, error) {
+	query := `SELECT p.id_pasajero, p.id_reserva, p.id_tipo_pasaje, p.nombres, p.apellidos, p.tipo_documento,
+              p.numero_documento, p.fecha_nacimiento, COALESCE(p.nacionalidad, ''), p.contacto_emergencia_nombre,
+              p.contacto_emergencia_telefono, p.eliminado, COALESCE(tpa.nombre, ''), it.fecha_especifica
+              FROM pasajero p
+              INNER JOIN reserva r ON p.id_reserva = r.id_reserva
+              INNER JOIN instancia_tour it ON r.id_instancia = it.id_instancia
+              LEFT JOIN tipo_pasaje tpa ON p.id_tipo_pasaje = tpa.id_tipo_pasaje
+              WHERE p.id_reserva = $1 AND p.eliminado = FALSE
+              ORDER BY p.id_pasajero`
+
+	rows, err := r.db.Query(query, idReserva)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pasajeros := []*entidades.Pasajero{}
+
+	for rows.Next() {
+		pasajero := &entidades.Pasajero{}
+		var fechaTour time.Time
+		err := rows.Scan(
+			&pasajero.ID, &pasajero.IDReserva, &pasajero.IDTipoPasaje, &pasajero.Nombres, &pasajero.Apellidos,
+			&pasajero.TipoDocumento, &pasajero.NumeroDocumento, &pasajero.FechaNacimiento, &pasajero.Nacionalidad,
+			&pasajero.ContactoEmergenciaNombre, &pasajero.ContactoEmergenciaTelefono, &pasajero.Eliminado,
+			&pasajero.NombreTipoPasaje, &fechaTour,
+		)
+		if err != nil {
+			return nil, err
+		}
+		pasajero.Edad = edadEnFecha(pasajero.FechaNacimiento, fechaTour)
+		pasajeros = append(pasajeros, pasajero)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pasajeros, nil
+}
+
+// GetManifiesto obtiene el manifiesto de pasajeros de una instancia de tour
+// Incluye solo reservas vigentes (RESERVADO o CONFIRMADA)
+func (r *PasajeroRepository) GetManifiesto(idInstancia int) (*entidades.ManifiestoInstancia, error) {
+	manifiesto := &entidades.ManifiestoInstancia{}
+	var fechaTour time.Time
+	var idChofer sql.NullInt64
+
+	queryInstancia := `SELECT it.id_instancia, tp.id_sede, it.id_chofer, tt.nombre, s.nombre, e.nombre,
+                      COALESCE(u.nombres || ' ' || u.apellidos, 'Sin asignar'),
+                      it.fecha_especifica, to_char(it.fecha_especifica, 'DD/MM/YYYY'),
+                      to_char(it.hora_inicio, 'HH24:MI'), to_char(it.hora_fin, 'HH24:MI')
+                      FROM instancia_tour it
+                      INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+                      INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
+                      INNER JOIN sede s ON tp.id_sede = s.id_sede
+                      INNER JOIN embarcacion e ON it.id_embarcacion = e.id_embarcacion
+                      LEFT JOIN usuario u ON it.id_chofer = u.id_usuario
+                      WHERE it.id_instancia = $1 AND it.eliminado = FALSE`
+	err := r.db.QueryRow(queryInstancia, idInstancia).Scan(
+		&manifiesto.IDInstancia, &manifiesto.IDSede, &idChofer, &manifiesto.NombreTour, &manifiesto.NombreSede,
+		&manifiesto.NombreEmbarcacion, &manifiesto.NombreChofer, &fechaTour, &manifiesto.FechaTour,
+		&manifiesto.HoraInicio, &manifiesto.HoraFin,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("instancia de tour no encontrada")
+		}
+		return nil, err
+	}
+	if idChofer.Valid {
+		id := int(idChofer.Int64)
+		manifiesto.IDChofer = &id
+	}
+
+	// Total de pasajes vendidos en reservas vigentes
+	queryTotal := `SELECT
+                  COALESCE((SELECT SUM(pc.cantidad) FROM pasajes_cantidad pc
+                            INNER JOIN reserva r ON pc.id_reserva = r.id_reserva
+                            WHERE r.id_instancia = $1 AND r.eliminado = FALSE AND pc.eliminado = FALSE
+                            AND r.estado IN ('RESERVADO', 'CONFIRMADA')), 0)
+                  + COALESCE((SELECT SUM(ppd.cantidad * pp.cantidad_total) FROM paquete_pasaje_detalle ppd
+                              INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
+                              INNER JOIN reserva r ON ppd.id_reserva = r.id_reserva
+                              WHERE r.id_instancia = $1 AND r.eliminado = FALSE AND ppd.eliminado = FALSE
+                              AND r.estado IN ('RESERVADO', 'CONFIRMADA')), 0)`
+	err = r.db.QueryRow(queryTotal, idInstancia).Scan(&manifiesto.TotalPasajes)
+	if err != nil {
+		return nil, err
+	}
+
+	queryPasajeros := `SELECT p.id_pasajero, p.id_reserva, p.id_tipo_pasaje, p.nombres, p.apellidos, p.tipo_documento,
+                      p.numero_documento, p.fecha_nacimiento, COALESCE(p.nacionalidad, ''), p.contacto_emergencia_nombre,
+                      p.contacto_emergencia_telefono, p.eliminado, COALESCE(tpa.nombre, ''),
+                      c.nombres || ' ' || c.apellidos, r.estado
+                      FROM pasajero p
+                      INNER JOIN reserva r ON p.id_reserva = r.id_reserva
+                      INNER JOIN cliente c ON r.id_cliente = c.id_cliente
+                      LEFT JOIN tipo_pasaje tpa ON p.id_tipo_pasaje = tpa.id_tipo_pasaje
+                      WHERE r.id_instancia = $1 AND r.eliminado = FALSE AND p.eliminado = FALSE
+                      AND r.estado IN ('RESERVADO', 'CONFIRMADA')
+                      ORDER BY p.apellidos, p.nombres`
+
+	rows, err := r.db.Query(queryPasajeros, idInstancia)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	manifiesto.Pasajeros = []*entidades.PasajeroManifiesto{}
+
+	for rows.Next() {
+		fila := &entidades.PasajeroManifiesto{}
+		err := rows.Scan(
+			&fila.ID, &fila.IDReserva, &fila.IDTipoPasaje, &fila.Nombres, &fila.Apellidos,
+			&fila.TipoDocumento, &fila.NumeroDocumento, &fila.FechaNacimiento, &fila.Nacionalidad,
+			&fila.ContactoEmergenciaNombre, &fila.ContactoEmergenciaTelefono, &fila.Eliminado,
+			&fila.NombreTipoPasaje, &fila.NombreTitular, &fila.EstadoReserva,
+		)
+		if err != nil {
+			return nil, err
+		}
+		fila.Edad = edadEnFecha(fila.FechaNacimiento, fechaTour)
+		manifiesto.Pasajeros = append(manifiesto.Pasajeros, fila)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	manifiesto.PasajerosRegistrados = len(manifiesto.Pasajeros)
+
+	return manifiesto, nil
+}
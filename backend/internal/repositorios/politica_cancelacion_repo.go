@@ -0,0 +1,166 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"sistema-toursseft/internal/entidades"
+)
+
+// PoliticaCancelacionRepository maneja las operaciones de base de datos para políticas de cancelación
+type PoliticaCancelacionRepository struct {
+	db *sql.DB
+}
+
+// NewPoliticaCancelacionRepository crea una nueva instancia del repositorio
+func NewPoliticaCancelacionRepository(db *sql.DB) *PoliticaCancelacionRepository {
+	return &PoliticaCancelacionRepository{
+		db: db,
+	}
+}
+
+// queryPoliticaBase selecciona un tramo de política con los nombres de su tipo de tour y sede
+const queryPoliticaBase = `SELECT pc.id_politica, pc.id_tipo_tour, pc.id_sede, pc.nombre, pc.horas_antes_minimo,
+              pc.porcentaje_devolucion, pc.eliminado, COALESCE(tt.nombre, ''), COALESCE(s.nombre, '')
+              FROM politica_cancelacion pc
+              LEFT JOIN tipo_tour tt ON pc.id_tipo_tour = tt.id_tipo_tour
+              LEFT JOIN sede s ON pc.id_sede = s.id_sede`
+
+// listPoliticas ejecuta una consulta basada en queryPoliticaBase y devuelve todas las filas
+func (r *PoliticaCancelacionRepository) listPoliticas(query string, args ...interface{}) ([]*entidades.PoliticaCancelacion, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	politicas := []*entidades.PoliticaCancelacion{}
+
+	for rows.Next() {
+		politica := &entidades.PoliticaCancelacion{}
+		err := rows.Scan(
+			&politica.ID, &politica.IDTipoTour, &politica.IDSede, &politica.Nombre, &politica.HorasAntesMinimo,
+			&politica.PorcentajeDevolucion, &politica.Eliminado, &politica.NombreTipoTour, &politica.NombreSede,
+		)
+		if err != nil {
+			return nil, err
+		}
+		politicas = append(politicas, politica)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return politicas, nil
+}
+
+// GetByID obtiene un tramo de política de cancelación por su ID
+func (r *PoliticaCancelacionRepository) GetByID(id int) (*entidades.PoliticaCancelacion, error) {
+	politica := &entidades.PoliticaCancelacion{}
+	query := queryPoliticaBase + ` WHERE pc.id_politica = $1 AND pc.eliminado = FALSE`
+
+	err := r.db.QueryRow(query, id).Scan(
+		&politica.ID, &politica.IDTipoTour, &politica.IDSede, &politica.Nombre, &politica.HorasAntesMinimo,
+		&politica.PorcentajeDevolucion, &politica.Eliminado, &politica.NombreTipoTour, &politica.NombreSede,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("política de cancelación no encontrada")
+		}
+		return nil, err
+	}
+
+	return politica, nil
+}
+
+// Create guarda un nuevo tramo de política de cancelación
+// Si se indica tipo de tour, el tramo aplica solo a ese tipo y no a toda la sede
+func (r *PoliticaCancelacionRepository) Create(politica *entidades.NuevaPoliticaCancelacionRequest) (int, error) {
+	idSede := politica.IDSede
+	if politica.IDTipoTour != nil {
+		idSede = nil
+	}
+
+	var id int
+	query := `INSERT INTO politica_cancelacion (id_tipo_tour, id_sede, nombre, horas_antes_minimo, porcentaje_devolucion, eliminado)
+              VALUES ($1, $2, $3, $4, $5, FALSE)
+              RETURNING id_politica`
+
+	err := r.db.QueryRow(
+		query,
+		politica.IDTipoTour,
+		idSede,
+		politica.Nombre,
+		politica.HorasAntesMinimo,
+		politica.PorcentajeDevolucion,
+	).Scan(&id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Update actualiza un tramo de política de cancelación
+func (r *PoliticaCancelacionRepository) Update(id int, politica *entidades.ActualizarPoliticaCancelacionRequest) error {
+	query := `UPDATE politica_cancelacion SET
+              nombre = $1,
+              horas_antes_minimo = $2,
+              porcentaje_devolucion = $3
+              WHERE id_politica = $4 AND eliminado = FALSE`
+
+	_, err := r.db.Exec(
+		query,
+		politica.Nombre,
+		politica.HorasAntesMinimo,
+		politica.PorcentajeDevolucion,
+		id,
+	)
+
+	return err
+}
+
+// Delete marca un tramo de política de cancelación como eliminado (borrado lógico)
+func (r *PoliticaCancelacionRepository) Delete(id int) error {
+	query := `UPDATE politica_cancelacion SET eliminado = TRUE WHERE id_politica = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// List lista todos los tramos de políticas de cancelación no eliminados
+func (r *PoliticaCancelacionRepository) List() ([]*entidades.PoliticaCancelacion, error) {
+	query := queryPoliticaBase + ` WHERE pc.eliminado = FALSE
+              ORDER BY pc.id_sede NULLS LAST, pc.id_tipo_tour NULLS FIRST, pc.horas_antes_minimo DESC`
+	return r.listPoliticas(query)
+}
+
+// ListByTipoTour lista los tramos definidos para un tipo de tour
+func (r *PoliticaCancelacionRepository) ListByTipoTour(idTipoTour int) ([]*entidades.PoliticaCancelacion, error) {
+	query := queryPoliticaBase + ` WHERE pc.id_tipo_tour = $1 AND pc.eliminado = FALSE
+              ORDER BY pc.horas_antes_minimo DESC`
+	return r.listPoliticas(query, idTipoTour)
+}
+
+// ListBySede lista los tramos definidos para toda una sede
+func (r *PoliticaCancelacionRepository) ListBySede(idSede int) ([]*entidades.PoliticaCancelacion, error) {
+	query := queryPoliticaBase + ` WHERE pc.id_sede = $1 AND pc.eliminado = FALSE
+              ORDER BY pc.horas_antes_minimo DESC`
+	return r.listPoliticas(query, idSede)
+}
+
+// ListAplicables obtiene los tramos que rigen para un tipo de tour: los propios del tipo de tour
+// o, si no tiene, los de la sede
+func (r *PoliticaCancelacionRepository) ListAplicables(idTipoTour int, idSede int) ([]*entidades.PoliticaCancelacion, error) {
+	politicas, err := r.ListByTipoTour(idTipoTour)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(politicas) > 0 {
+		return politicas, nil
+	}
+
+	return r.ListBySede(idSede)
+}
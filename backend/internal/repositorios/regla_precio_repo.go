@@ -0,0 +1,291 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"sistema-toursseft/internal/entidades"
+)
+
+// ReglaPrecioRepository maneja las operaciones de base de datos para reglas de precio
+type ReglaPrecioRepository struct {
+	db *sql.DB
+}
+
+// NewReglaPrecioRepository crea una nueva instancia del repositorio
+func NewReglaPrecioRepository(db *sql.DB) *ReglaPrecioRepository {
+	return &ReglaPrecioRepository{
+		db: db,
+	}
+}
+
+// queryReglaBase selecciona una regla de precio con las horas en formato HH:MM
+const queryReglaBase = `SELECT rp.id_regla_precio, rp.nombre, rp.tipo_regla, rp.id_tipo_pasaje, rp.id_tipo_tour, rp.id_sede,
+              rp.fecha_inicio, rp.fecha_fin, COALESCE(rp.dias_semana, ''),
+              COALESCE(TO_CHAR(rp.hora_inicio, 'HH24:MI'), ''), COALESCE(TO_CHAR(rp.hora_fin, 'HH24:MI'), ''),
+              rp.ocupacion_minima, rp.porcentaje, rp.monto, rp.prioridad, rp.activo, rp.eliminado, rp.fecha_registro
+              FROM regla_precio rp`
+
+// scanRegla lee una fila obtenida con queryReglaBase
+func scanRegla(row interface{ Scan(...interface{}) error }) (*entidades.ReglaPrecio, error) {
+	regla := &entidades.ReglaPrecio{}
+	err := row.Scan(
+		&regla.ID, &regla.Nombre, &regla.TipoRegla, &regla.IDTipoPasaje, &regla.IDTipoTour, &regla.IDSede,
+		&regla.FechaInicio, &regla.FechaFin, &regla.DiasSemana,
+		&regla.HoraInicio, &regla.HoraFin,
+		&regla.OcupacionMinima, &regla.Porcentaje, &regla.Monto, &regla.Prioridad, &regla.Activo, &regla.Eliminado, &regla.FechaRegistro,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return regla, nil
+}
+
+// listarReglas ejecuta una consulta basada en queryReglaBase y lee todas sus filas
+func (r *ReglaPrecioRepository) listarReglas(query string, args ...interface{}) ([]*entidades.ReglaPrecio, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reglas := []*entidades.ReglaPrecio{}
+
+	for rows.Next() {
+		regla, err := scanRegla(rows)
+		if err != nil {
+			return nil, err
+		}
+		reglas = append(reglas, regla)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reglas, nil
+}
+
+// valorNulo convierte un texto vacío en NULL para las columnas opcionales
+func valorNulo(texto string) interface{} {
+	if texto == "" {
+		return nil
+	}
+	return texto
+}
+
+// GetByID obtiene una regla de precio por su ID
+func (r *ReglaPrecioRepository) GetByID(id int) (*entidades.ReglaPrecio, error) {
+	query := queryReglaBase + ` WHERE rp.id_regla_precio = $1 AND rp.eliminado = FALSE`
+
+	regla, err := scanRegla(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("regla de precio no encontrada")
+		}
+		return nil, err
+	}
+
+	return regla, nil
+}
+
+// Create guarda una nueva regla de precio
+func (r *ReglaPrecioRepository) Create(regla *entidades.NuevaReglaPrecioRequest) (int, error) {
+	var id int
+	query := `INSERT INTO regla_precio (nombre, tipo_regla, id_tipo_pasaje, id_tipo_tour, id_sede, fecha_inicio, fecha_fin,
+              dias_semana, hora_inicio, hora_fin, ocupacion_minima, porcentaje, monto, prioridad, activo, eliminado)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, TRUE, FALSE)
+              RETURNING id_regla_precio`
+
+	err := r.db.QueryRow(
+		query,
+		regla.Nombre,
+		regla.TipoRegla,
+		regla.IDTipoPasaje,
+		regla.IDTipoTour,
+		regla.IDSede,
+		regla.FechaInicio,
+		regla.FechaFin,
+		valorNulo(regla.DiasSemana),
+		valorNulo(regla.HoraInicio),
+		valorNulo(regla.HoraFin),
+		regla.OcupacionMinima,
+		regla.Porcentaje,
+		regla.Monto,
+		regla.Prioridad,
+	).Scan(&id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Update actualiza una regla de precio
+// Los pasajes ya vendidos conservan el precio con el que se vendieron
+func (r *ReglaPrecioRepository) Update(id int, regla *entidades.ActualizarReglaPrecioRequest) error {
+	query := `UPDATE regla_precio SET
+              nombre = $1,
+              tipo_regla = $2,
+              id_tipo_pasaje = $3,
+              id_tipo_tour = $4,
+              id_sede = $5,
+              fecha_inicio = $6,
+              fecha_fin = $7,
+              dias_semana = $8,
+              hora_inicio = $9,
+              hora_fin = $10,
+              ocupacion_minima = $11,
+              porcentaje = $12,
+              monto = $13,
+              prioridad = $14,
+              activo = $15
+              WHERE id_regla_precio = $16 AND eliminado = FALSE`
+
+	_, err := r.db.Exec(
+		query,
+		regla.Nombre,
+		regla.TipoRegla,
+		regla.IDTipoPasaje,
+		regla.IDTipoTour,
+		regla.IDSede,
+		regla.FechaInicio,
+		regla.FechaFin,
+		valorNulo(regla.DiasSemana),
+		valorNulo(regla.HoraInicio),
+		valorNulo(regla.HoraFin),
+		regla.OcupacionMinima,
+		regla.Porcentaje,
+		regla.Monto,
+		regla.Prioridad,
+		regla.Activo,
+		id,
+	)
+
+	return err
+}
+
+// Delete marca una regla de precio como eliminada (borrado lógico)
+func (r *ReglaPrecioRepository) Delete(id int) error {
+	query := `UPDATE regla_precio SET eliminado = TRUE WHERE id_regla_precio = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// List lista todas las reglas de precio no eliminadas en el orden en que se aplican
+func (r *ReglaPrecioRepository) List() ([]*entidades.ReglaPrecio, error) {
+	query := queryReglaBase + ` WHERE rp.eliminado = FALSE ORDER BY rp.prioridad, rp.id_regla_precio`
+	return r.listarReglas(query)
+}
+
+// ListAplicables lista las reglas activas que pueden afectar a un tipo de tour operado en una sede,
+// en el orden en que se aplican. Las condiciones de cada regla se evalúan en el servicio
+func (r *ReglaPrecioRepository) ListAplicables(idTipoTour int, idSede int) ([]*entidades.ReglaPrecio, error) {
+	query := queryReglaBase + ` WHERE rp.activo = TRUE AND rp.eliminado = FALSE
+              AND (rp.id_tipo_tour IS NULL OR rp.id_tipo_tour = $1)
+              AND (rp.id_sede IS NULL OR rp.id_sede = $2)
+              ORDER BY rp.prioridad, rp.id_regla_precio`
+	return r.listarReglas(query, idTipoTour, idSede)
+}
+
+// GetDatosInstancia obtiene la fecha, el horario y la ocupación de una instancia de tour
+// La ocupación se mide contra el cupo máximo del tour programado
+func (r *ReglaPrecioRepository) GetDatosInstancia(idInstancia int) (*entidades.DatosPrecioInstancia, error) {
+	datos := &entidades.DatosPrecioInstancia{IDInstancia: idInstancia}
+	query := `SELECT tp.id_tipo_tour, tp.id_sede, it.fecha_especifica, TO_CHAR(it.hora_inicio, 'HH24:MI'),
+              GREATEST(tp.cupo_maximo, it.cupo_disponible), it.cupo_disponible
+              FROM instancia_tour it
+              INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+              WHERE it.id_instancia = $1 AND it.eliminado = FALSE`
+
+	err := r.db.QueryRow(query, idInstancia).Scan(
+		&datos.IDTipoTour, &datos.IDSede, &datos.Fecha, &datos.HoraInicio,
+		&datos.CupoMaximo, &datos.CupoDisponible,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("instancia de tour no encontrada")
+		}
+		return nil, err
+	}
+
+	if datos.CupoMaximo > 0 {
+		datos.Ocupacion = float64(datos.CupoMaximo-datos.CupoDisponible) * 100 / float64(datos.CupoMaximo)
+	}
+
+	return datos, nil
+}
+
+// ListAplicadasByReserva lista las reglas que fijaron el precio de los pasajes de una reserva
+func (r *ReglaPrecioRepository) ListAplicadasByReserva(idReserva int) ([]*entidades.RegistroReglaPrecio, error) {
+	query := `SELECT rpa.id_regla_precio_aplicada, rpa.id_reserva, rpa.id_tipo_pasaje, rpa.id_regla_precio,
+              rpa.precio_antes, rpa.precio_despues, rpa.moneda, rpa.fecha_registro, tp.nombre, rp.nombre, rp.tipo_regla
+              FROM regla_precio_aplicada rpa
+              INNER JOIN tipo_pasaje tp ON rpa.id_tipo_pasaje = tp.id_tipo_pasaje
+              INNER JOIN regla_precio rp ON rpa.id_regla_precio = rp.id_regla_precio
+              WHERE rpa.id_reserva = $1
+              ORDER BY rpa.id_tipo_pasaje, rpa.id_regla_precio_aplicada`
+
+	rows, err := r.db.Query(query, idReserva)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	registros := []*entidades.RegistroReglaPrecio{}
+
+	for rows.Next() {
+		registro := &entidades.RegistroReglaPrecio{}
+		err := rows.Scan(
+			&registro.ID, &registro.IDReserva, &registro.IDTipoPasaje, &registro.IDReglaPrecio,
+			&registro.PrecioAntes, &registro.PrecioDespues, &registro.Moneda, &registro.FechaRegistro,
+			&registro.NombrePasaje, &registro.NombreRegla, &registro.TipoRegla,
+		)
+		if err != nil {
+			return nil, err
+		}
+		registro.PrecioAntes = registro.PrecioAntes.EnMoneda(registro.Moneda)
+		registro.PrecioDespues = registro.PrecioDespues.EnMoneda(registro.Moneda)
+		registros = append(registros, registro)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return registros, nil
+}
+
+// registrarPreciosPasajesTx guarda, dentro de una transacción existente, las reglas que fijaron el precio
+// de los pasajes vendidos en una reserva. Reemplaza el registro anterior si la reserva cambió y omite
+// los tipos de pasaje que la reserva no incluye. Los precios se guardan en la moneda del tipo de pasaje
+func registrarPreciosPasajesTx(tx *sql.Tx, idReserva int, precios map[int]*entidades.PrecioPasaje) error {
+	_, err := tx.Exec(`DELETE FROM regla_precio_aplicada WHERE id_reserva = $1`, idReserva)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO regla_precio_aplicada (id_reserva, id_tipo_pasaje, id_regla_precio, precio_antes, precio_despues, moneda)
+              SELECT $1, $2, $3, $4, $5, $6
+              WHERE EXISTS (SELECT 1 FROM pasajes_cantidad
+                            WHERE id_reserva = $1 AND id_tipo_pasaje = $2 AND cantidad > 0 AND eliminado = FALSE)`
+	for _, precio := range precios {
+		for _, regla := range precio.Reglas {
+			_, err := tx.Exec(query, idReserva, precio.IDTipoPasaje, regla.IDReglaPrecio, regla.PrecioAntes, regla.PrecioDespues,
+					regla.PrecioDespues.Moneda())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// precioUnitarioPasaje devuelve el precio efectivo en soles con el que se guarda un pasaje, o NULL si no se cotizó
+func precioUnitarioPasaje(precios map[int]*entidades.PrecioPasaje, idTipoPasaje int) interface{} {
+	if precio, ok := precios[idTipoPasaje]; ok {
+		return precio.PrecioSoles
+	}
+	return nil
+}
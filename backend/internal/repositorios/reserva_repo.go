@@ -19,6 +19,11 @@ func NewReservaRepository(db *sql.DB) *ReservaRepository {
 	}
 }
 
+// estadoLiberaCupo indica si en el estado dado la reserva ya no ocupa cupo en la instancia
+func estadoLiberaCupo(estado string) bool {
+	return estado == "CANCELADA" || estado == "EXPIRADA"
+}
+
 // GetByID obtiene una reserva por su ID
 func (r *ReservaRepository) GetByID(id int) (*entidades.Reserva, error) {
 	// Inicializar objeto de reserva
@@ -26,7 +31,8 @@ func (r *ReservaRepository) GetByID(id int) (*entidades.Reserva, error) {
 
 	// Consulta para obtener datos básicos de la reserva
 	query := `SELECT r.id_reserva, r.id_vendedor, r.id_cliente, r.id_instancia, 
-              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.notas, r.estado, r.eliminado,
+              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.tipo_cambio, pg.total_pagado, s.porcentaje_adelanto, r.notas, r.estado, r.eliminado,
+              r.fecha_expiracion,
               c.nombres || ' ' || c.apellidos as nombre_cliente,
               COALESCE(u.nombres || ' ' || u.apellidos, 'Web') as nombre_vendedor,
               tt.nombre as nombre_tour,
@@ -43,12 +49,16 @@ func (r *ReservaRepository) GetByID(id int) (*entidades.Reserva, error) {
               INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
               INNER JOIN canal_venta cv ON r.id_canal = cv.id_canal
               INNER JOIN sede s ON r.id_sede = s.id_sede
+              CROSS JOIN LATERAL (SELECT COALESCE(SUM(COALESCE(p.monto_convertido, p.monto)), 0) AS total_pagado
+                                  FROM pago p
+                                  WHERE p.id_reserva = r.id_reserva AND p.estado = 'PROCESADO' AND p.eliminado = FALSE) pg
               WHERE r.id_reserva = $1 AND r.eliminado = FALSE`
 
 	err := r.db.QueryRow(query, id).Scan(
 		&reserva.ID, &reserva.IDVendedor, &reserva.IDCliente, &reserva.IDInstancia,
-		&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar,
-		&reserva.Notas, &reserva.Estado, &reserva.Eliminado,
+		&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar, &reserva.TipoCambio,
+		&reserva.Saldo.TotalPagado, &reserva.Saldo.PorcentajeAdelanto,
+		&reserva.Notas, &reserva.Estado, &reserva.Eliminado, &reserva.FechaExpiracion,
 		&reserva.NombreCliente, &reserva.NombreVendedor, &reserva.NombreTour,
 		&reserva.FechaTour, &reserva.HoraInicioTour, &reserva.HoraFinTour,
 		&reserva.NombreCanal, &reserva.NombreSede,
@@ -61,6 +71,8 @@ func (r *ReservaRepository) GetByID(id int) (*entidades.Reserva, error) {
 		return nil, err
 	}
 
+	reserva.Saldo = entidades.CalcularSaldoReserva(reserva.TotalPagar, reserva.Saldo.TotalPagado, reserva.Saldo.PorcentajeAdelanto)
+
 	// Obtener las cantidades de pasajes individuales
 	queryPasajes := `SELECT pc.id_tipo_pasaje, tp.nombre, pc.cantidad
                     FROM pasajes_cantidad pc
@@ -94,7 +106,8 @@ func (r *ReservaRepository) GetByID(id int) (*entidades.Reserva, error) {
 
 	// Obtener los paquetes de pasajes
 	queryPaquetes := `SELECT ppd.id_paquete, pp.nombre as nombre_paquete, ppd.cantidad, 
-                     pp.precio_total as precio_unitario, (ppd.cantidad * pp.precio_total) as subtotal,
+                     COALESCE(ppd.precio_unitario, pp.precio_total) as precio_unitario,
+                     (ppd.cantidad * COALESCE(ppd.precio_unitario, pp.precio_total)) as subtotal,
                      pp.cantidad_total
                      FROM paquete_pasaje_detalle ppd
                      INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
@@ -130,7 +143,7 @@ func (r *ReservaRepository) GetByID(id int) (*entidades.Reserva, error) {
 }
 
 // Create guarda una nueva reserva en la base de datos
-func (r *ReservaRepository) Create(reserva *entidades.NuevaReservaRequest) (int, error) {
+func (r *ReservaRepository) Create(reserva *entidades.NuevaReservaRequest) (idReserva int, err error) {
 	// Iniciar transacción
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -144,16 +157,35 @@ func (r *ReservaRepository) Create(reserva *entidades.NuevaReservaRequest) (int,
 		}
 	}()
 
+	idReserva, err = crearReservaTx(tx, reserva)
+	if err != nil {
+		return 0, err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return idReserva, nil
+}
+
+// crearReservaTx inserta una reserva con sus pasajes y paquetes y descuenta el cupo de la instancia
+// dentro de una transacción existente. La instancia queda bloqueada hasta el commit
+func crearReservaTx(tx *sql.Tx, reserva *entidades.NuevaReservaRequest) (idReserva int, err error) {
 	// Verificar que al menos haya un pasaje o un paquete
 	if len(reserva.CantidadPasajes) == 0 && len(reserva.Paquetes) == 0 {
 		return 0, errors.New("debe incluir al menos un pasaje o un paquete en la reserva")
 	}
 
-	// Primero, obtener información de la instancia del tour para verificar disponibilidad
+	// Primero, obtener y bloquear la instancia del tour para verificar disponibilidad
+	// El bloqueo se mantiene hasta el commit, así dos ventas simultáneas no pueden tomar el mismo cupo
 	var cupoDisponible int
 	queryInstancia := `SELECT cupo_disponible FROM instancia_tour 
                       WHERE id_instancia = $1 AND eliminado = FALSE 
-                      AND estado = 'PROGRAMADO'`
+                      AND estado = 'PROGRAMADO'
+                      FOR UPDATE`
 	err = tx.QueryRow(queryInstancia, reserva.IDInstancia).Scan(&cupoDisponible)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -191,10 +223,9 @@ func (r *ReservaRepository) Create(reserva *entidades.NuevaReservaRequest) (int,
 	}
 
 	// Consulta SQL para insertar una nueva reserva
-	var idReserva int
 	query := `INSERT INTO reserva (id_vendedor, id_cliente, id_instancia, id_canal, id_sede, 
-             total_pagar, notas, estado, eliminado)
-             VALUES ($1, $2, $3, $4, $5, $6, $7, 'RESERVADO', FALSE)
+             total_pagar, tipo_cambio, notas, estado, eliminado)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'RESERVADO', FALSE)
              RETURNING id_reserva`
 
 	// Ejecutar la consulta con los datos de la reserva
@@ -206,6 +237,7 @@ func (r *ReservaRepository) Create(reserva *entidades.NuevaReservaRequest) (int,
 		reserva.IDCanal,
 		reserva.IDSede,
 		reserva.TotalPagar,
+		valorTipoCambio(reserva.TipoCambio),
 		reserva.Notas,
 	).Scan(&idReserva)
 
@@ -217,10 +249,10 @@ func (r *ReservaRepository) Create(reserva *entidades.NuevaReservaRequest) (int,
 	for _, pasaje := range reserva.CantidadPasajes {
 		// Solo insertar si la cantidad es mayor que cero
 		if pasaje.Cantidad > 0 {
-			queryPasaje := `INSERT INTO pasajes_cantidad (id_reserva, id_tipo_pasaje, cantidad, eliminado)
-                          VALUES ($1, $2, $3, FALSE)`
+			queryPasaje := `INSERT INTO pasajes_cantidad (id_reserva, id_tipo_pasaje, cantidad, precio_unitario, eliminado)
+                          VALUES ($1, $2, $3, $4, FALSE)`
 
-			_, err = tx.Exec(queryPasaje, idReserva, pasaje.IDTipoPasaje, pasaje.Cantidad)
+			_, err = tx.Exec(queryPasaje, idReserva, pasaje.IDTipoPasaje, pasaje.Cantidad, precioUnitarioPasaje(reserva.Precios, pasaje.IDTipoPasaje))
 			if err != nil {
 				return 0, err
 			}
@@ -231,10 +263,10 @@ func (r *ReservaRepository) Create(reserva *entidades.NuevaReservaRequest) (int,
 	for _, paquete := range reserva.Paquetes {
 		// Solo insertar si la cantidad es mayor que cero
 		if paquete.Cantidad > 0 {
-			queryPaquete := `INSERT INTO paquete_pasaje_detalle (id_reserva, id_paquete, cantidad, eliminado)
-                           VALUES ($1, $2, $3, FALSE)`
+			queryPaquete := `INSERT INTO paquete_pasaje_detalle (id_reserva, id_paquete, cantidad, precio_unitario, eliminado)
+                           VALUES ($1, $2, $3, $4, FALSE)`
 
-			_, err = tx.Exec(queryPaquete, idReserva, paquete.IDPaquete, paquete.Cantidad)
+			_, err = tx.Exec(queryPaquete, idReserva, paquete.IDPaquete, paquete.Cantidad, precioUnitarioPaquete(reserva.PreciosPaquetes, paquete.IDPaquete))
 			if err != nil {
 				return 0, err
 			}
@@ -250,8 +282,16 @@ func (r *ReservaRepository) Create(reserva *entidades.NuevaReservaRequest) (int,
 		return 0, err
 	}
 
-	// Commit de la transacción
-	err = tx.Commit()
+	// Registrar el canje del código promocional aplicado al total
+	if reserva.Promocion != nil {
+		err = registrarCanjePromocionTx(tx, reserva.Promocion, idReserva, reserva.IDCliente)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Registrar las reglas que fijaron el precio de los pasajes
+	err = registrarPreciosPasajesTx(tx, idReserva, reserva.Precios)
 	if err != nil {
 		return 0, err
 	}
@@ -259,8 +299,41 @@ func (r *ReservaRepository) Create(reserva *entidades.NuevaReservaRequest) (int,
 	return idReserva, nil
 }
 
+// ajustarCupoInstanciaTx bloquea la instancia y descuenta la cantidad indicada de su cupo disponible
+// Una cantidad negativa devuelve cupo; al descontar se exige que la instancia siga programada y tenga cupo
+func ajustarCupoInstanciaTx(tx *sql.Tx, idInstancia int, cantidad int, mensajeSinCupo string) error {
+	var cupoDisponible int
+	var estado string
+	var eliminado bool
+	queryInstancia := `SELECT cupo_disponible, estado, eliminado FROM instancia_tour
+                      WHERE id_instancia = $1
+                      FOR UPDATE`
+	err := tx.QueryRow(queryInstancia, idInstancia).Scan(&cupoDisponible, &estado, &eliminado)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("la instancia del tour no existe, está eliminada o no está programada")
+		}
+		return err
+	}
+
+	if cantidad > 0 {
+		if eliminado || estado != "PROGRAMADO" {
+			return errors.New("la instancia del tour no existe, está eliminada o no está programada")
+		}
+		if cantidad > cupoDisponible {
+			return errors.New(mensajeSinCupo)
+		}
+	}
+
+	queryUpdateCupo := `UPDATE instancia_tour 
+                       SET cupo_disponible = cupo_disponible - $1 
+                       WHERE id_instancia = $2`
+	_, err = tx.Exec(queryUpdateCupo, cantidad, idInstancia)
+	return err
+}
+
 // Update actualiza la información de una reserva existente
-func (r *ReservaRepository) Update(id int, reserva *entidades.ActualizarReservaRequest) error {
+func (r *ReservaRepository) Update(id int, reserva *entidades.ActualizarReservaRequest) (err error) {
 	// Iniciar transacción
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -278,7 +351,8 @@ func (r *ReservaRepository) Update(id int, reserva *entidades.ActualizarReservaR
 	var idInstanciaActual int
 	var estadoActual string
 	queryReservaActual := `SELECT id_instancia, estado FROM reserva 
-                          WHERE id_reserva = $1 AND eliminado = FALSE`
+                          WHERE id_reserva = $1 AND eliminado = FALSE
+                          FOR UPDATE`
 
 	err = tx.QueryRow(queryReservaActual, id).Scan(&idInstanciaActual, &estadoActual)
 	if err != nil {
@@ -290,7 +364,7 @@ func (r *ReservaRepository) Update(id int, reserva *entidades.ActualizarReservaR
 
 	// Obtener cantidad actual de pasajeros
 	var totalPasajerosActual int
-	if estadoActual != "CANCELADA" { // Solo contar si la reserva no estaba cancelada
+	if !estadoLiberaCupo(estadoActual) { // Solo contar si la reserva aún ocupaba cupo
 		// Contar pasajeros de pasajes individuales
 		queryPasajesActual := `SELECT COALESCE(SUM(cantidad), 0) FROM pasajes_cantidad 
                              WHERE id_reserva = $1 AND eliminado = FALSE`
@@ -336,113 +410,39 @@ func (r *ReservaRepository) Update(id int, reserva *entidades.ActualizarReservaR
 		totalPasajerosNuevo += cantidadPorPaquete * paquete.Cantidad
 	}
 
-	// Verificar disponibilidad si cambia la cantidad o la instancia
-	if idInstanciaActual != reserva.IDInstancia || totalPasajerosActual != totalPasajerosNuevo {
-		// Si es la misma instancia, verificar solo la diferencia
-		if idInstanciaActual == reserva.IDInstancia {
-			var cupoDisponible int
-			queryInstancia := `SELECT cupo_disponible FROM instancia_tour 
-                            WHERE id_instancia = $1 AND eliminado = FALSE AND estado = 'PROGRAMADO'`
-			err := tx.QueryRow(queryInstancia, reserva.IDInstancia).Scan(&cupoDisponible)
-			if err != nil {
-				if err == sql.ErrNoRows {
-					return errors.New("la instancia del tour no existe, está eliminada o no está programada")
-				}
-				return err
-			}
-
-			// Calcular diferencia de pasajeros
-			diferenciaPasajeros := totalPasajerosNuevo - totalPasajerosActual
-
-			// Verificar si hay suficiente cupo
-			if diferenciaPasajeros > cupoDisponible {
-				return errors.New("no hay suficiente cupo disponible para la actualización de la reserva")
-			}
-
-			// Actualizar cupo si hay diferencia
-			if diferenciaPasajeros != 0 {
-				queryUpdateCupo := `UPDATE instancia_tour 
-                               SET cupo_disponible = cupo_disponible - $1 
-                               WHERE id_instancia = $2`
-				_, err = tx.Exec(queryUpdateCupo, diferenciaPasajeros, reserva.IDInstancia)
-				if err != nil {
-					return err
-				}
-			}
-		} else {
-			// Si es una instancia diferente, restaurar cupo en la instancia anterior y verificar en la nueva
-			if estadoActual != "CANCELADA" { // Solo restaurar si no estaba cancelada
-				queryRestauraCupo := `UPDATE instancia_tour 
-                                SET cupo_disponible = cupo_disponible + $1 
-                                WHERE id_instancia = $2`
-				_, err = tx.Exec(queryRestauraCupo, totalPasajerosActual, idInstanciaActual)
-				if err != nil {
-					return err
-				}
-			}
-
-			// Verificar cupo en la nueva instancia
-			var cupoDisponible int
-			queryInstancia := `SELECT cupo_disponible FROM instancia_tour 
-                            WHERE id_instancia = $1 AND eliminado = FALSE AND estado = 'PROGRAMADO'`
-			err := tx.QueryRow(queryInstancia, reserva.IDInstancia).Scan(&cupoDisponible)
-			if err != nil {
-				if err == sql.ErrNoRows {
-					return errors.New("la nueva instancia del tour no existe, está eliminada o no está programada")
-				}
-				return err
-			}
-
-			if totalPasajerosNuevo > cupoDisponible {
-				return errors.New("no hay suficiente cupo disponible en la nueva instancia seleccionada")
-			}
+	// Cupo que la reserva deja de ocupar o pasa a ocupar con el nuevo estado
+	totalPasajerosOcupados := totalPasajerosNuevo
+	if estadoLiberaCupo(reserva.Estado) {
+		totalPasajerosOcupados = 0
+	}
 
-			// Actualizar cupo en la nueva instancia
-			queryUpdateCupo := `UPDATE instancia_tour 
-                           SET cupo_disponible = cupo_disponible - $1 
-                           WHERE id_instancia = $2`
-			_, err = tx.Exec(queryUpdateCupo, totalPasajerosNuevo, reserva.IDInstancia)
-			if err != nil {
-				return err
-			}
+	// Ajustar el cupo con una sola diferencia por instancia
+	if idInstanciaActual == reserva.IDInstancia {
+		mensajeSinCupo := "no hay suficiente cupo disponible para la actualización de la reserva"
+		if estadoLiberaCupo(estadoActual) {
+			mensajeSinCupo = "no hay suficiente cupo disponible para reactivar la reserva"
 		}
-	}
 
-	// Verificar cambio de estado
-	if estadoActual != reserva.Estado {
-		// Si se cancela la reserva, restaurar cupo
-		if reserva.Estado == "CANCELADA" && estadoActual != "CANCELADA" {
-			queryRestauraCupo := `UPDATE instancia_tour 
-                               SET cupo_disponible = cupo_disponible + $1 
-                               WHERE id_instancia = $2`
-			_, err = tx.Exec(queryRestauraCupo, totalPasajerosActual, idInstanciaActual)
+		diferenciaPasajeros := totalPasajerosOcupados - totalPasajerosActual
+		if diferenciaPasajeros != 0 {
+			err = ajustarCupoInstanciaTx(tx, reserva.IDInstancia, diferenciaPasajeros, mensajeSinCupo)
 			if err != nil {
 				return err
 			}
 		}
-
-		// Si se reactiva una reserva cancelada, verificar cupo
-		if estadoActual == "CANCELADA" && reserva.Estado != "CANCELADA" {
-			var cupoDisponible int
-			queryInstancia := `SELECT cupo_disponible FROM instancia_tour 
-                            WHERE id_instancia = $1 AND eliminado = FALSE AND estado = 'PROGRAMADO'`
-			err := tx.QueryRow(queryInstancia, reserva.IDInstancia).Scan(&cupoDisponible)
+	} else {
+		// Restaurar el cupo que ocupaba en la instancia anterior
+		if totalPasajerosActual > 0 {
+			err = ajustarCupoInstanciaTx(tx, idInstanciaActual, -totalPasajerosActual, "")
 			if err != nil {
-				if err == sql.ErrNoRows {
-					return errors.New("la instancia del tour no existe, está eliminada o no está programada")
-				}
 				return err
 			}
+		}
 
-			if totalPasajerosNuevo > cupoDisponible {
-				return errors.New("no hay suficiente cupo disponible para reactivar la reserva")
-			}
-
-			// Actualizar cupo al reactivar
-			queryUpdateCupo := `UPDATE instancia_tour 
-                           SET cupo_disponible = cupo_disponible - $1 
-                           WHERE id_instancia = $2`
-			_, err = tx.Exec(queryUpdateCupo, totalPasajerosNuevo, reserva.IDInstancia)
+		// Ocupar el cupo en la nueva instancia
+		if totalPasajerosOcupados > 0 {
+			err = ajustarCupoInstanciaTx(tx, reserva.IDInstancia, totalPasajerosOcupados,
+				"no hay suficiente cupo disponible en la nueva instancia seleccionada")
 			if err != nil {
 				return err
 			}
@@ -457,9 +457,11 @@ func (r *ReservaRepository) Update(id int, reserva *entidades.ActualizarReservaR
               id_canal = $4,
               id_sede = $5,
               total_pagar = $6,
-              notas = $7,
-              estado = $8
-              WHERE id_reserva = $9 AND eliminado = FALSE`
+              tipo_cambio = $7,
+              notas = $8,
+              estado = $9,
+              fecha_expiracion = NULL
+              WHERE id_reserva = $10 AND eliminado = FALSE`
 
 	// Ejecutar la actualización
 	_, err = tx.Exec(
@@ -470,6 +472,7 @@ func (r *ReservaRepository) Update(id int, reserva *entidades.ActualizarReservaR
 		reserva.IDCanal,
 		reserva.IDSede,
 		reserva.TotalPagar,
+		valorTipoCambio(reserva.TipoCambio),
 		reserva.Notas,
 		reserva.Estado,
 		id,
@@ -497,10 +500,10 @@ func (r *ReservaRepository) Update(id int, reserva *entidades.ActualizarReservaR
 	for _, pasaje := range reserva.CantidadPasajes {
 		// Solo insertar si la cantidad es mayor que cero
 		if pasaje.Cantidad > 0 {
-			queryPasaje := `INSERT INTO pasajes_cantidad (id_reserva, id_tipo_pasaje, cantidad, eliminado)
-                         VALUES ($1, $2, $3, FALSE)`
+			queryPasaje := `INSERT INTO pasajes_cantidad (id_reserva, id_tipo_pasaje, cantidad, precio_unitario, eliminado)
+                         VALUES ($1, $2, $3, $4, FALSE)`
 
-			_, err = tx.Exec(queryPasaje, id, pasaje.IDTipoPasaje, pasaje.Cantidad)
+			_, err = tx.Exec(queryPasaje, id, pasaje.IDTipoPasaje, pasaje.Cantidad, precioUnitarioPasaje(reserva.Precios, pasaje.IDTipoPasaje))
 			if err != nil {
 				return err
 			}
@@ -511,22 +514,36 @@ func (r *ReservaRepository) Update(id int, reserva *entidades.ActualizarReservaR
 	for _, paquete := range reserva.Paquetes {
 		// Solo insertar si la cantidad es mayor que cero
 		if paquete.Cantidad > 0 {
-			queryPaquete := `INSERT INTO paquete_pasaje_detalle (id_reserva, id_paquete, cantidad, eliminado)
-                          VALUES ($1, $2, $3, FALSE)`
+			queryPaquete := `INSERT INTO paquete_pasaje_detalle (id_reserva, id_paquete, cantidad, precio_unitario, eliminado)
+                          VALUES ($1, $2, $3, $4, FALSE)`
 
-			_, err = tx.Exec(queryPaquete, id, paquete.IDPaquete, paquete.Cantidad)
+			_, err = tx.Exec(queryPaquete, id, paquete.IDPaquete, paquete.Cantidad, precioUnitarioPaquete(reserva.PreciosPaquetes, paquete.IDPaquete))
 			if err != nil {
 				return err
 			}
 		}
 	}
 
+	// Ajustar el canje del código promocional al nuevo total
+	if reserva.Promocion != nil {
+		err = actualizarCanjePromocionTx(tx, reserva.Promocion, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Reemplazar el registro de las reglas que fijaron el precio de los pasajes
+	err = registrarPreciosPasajesTx(tx, id, reserva.Precios)
+	if err != nil {
+		return err
+	}
+
 	// Commit de la transacción
 	return tx.Commit()
 }
 
 // UpdateEstado actualiza solo el estado de una reserva
-func (r *ReservaRepository) UpdateEstado(id int, estado string) error {
+func (r *ReservaRepository) UpdateEstado(id int, estado string) (err error) {
 	// Iniciar transacción
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -540,11 +557,12 @@ func (r *ReservaRepository) UpdateEstado(id int, estado string) error {
 		}
 	}()
 
-	// Obtener la reserva actual
+	// Obtener la reserva actual (bloqueada para no competir con la liberación de expiradas)
 	var idInstancia int
 	var estadoActual string
 	queryReservaActual := `SELECT id_instancia, estado FROM reserva 
-                          WHERE id_reserva = $1 AND eliminado = FALSE`
+                          WHERE id_reserva = $1 AND eliminado = FALSE
+                          FOR UPDATE`
 
 	err = tx.QueryRow(queryReservaActual, id).Scan(&idInstancia, &estadoActual)
 	if err != nil {
@@ -557,7 +575,7 @@ func (r *ReservaRepository) UpdateEstado(id int, estado string) error {
 	// Verificar cambio de estado para manejo de cupos
 	if estadoActual != estado {
 		// Si se cancela una reserva activa, restaurar cupo
-		if estado == "CANCELADA" && estadoActual != "CANCELADA" {
+		if estadoLiberaCupo(estado) && !estadoLiberaCupo(estadoActual) {
 			// Obtener total de pasajeros
 			totalPasajeros, err := r.GetCantidadPasajerosByReservaTx(tx, id)
 			if err != nil {
@@ -574,8 +592,8 @@ func (r *ReservaRepository) UpdateEstado(id int, estado string) error {
 			}
 		}
 
-		// Si se reactiva una reserva cancelada, verificar y reducir cupo
-		if estadoActual == "CANCELADA" && estado != "CANCELADA" {
+		// Si se reactiva una reserva cancelada o expirada, verificar y reducir cupo
+		if estadoLiberaCupo(estadoActual) && !estadoLiberaCupo(estado) {
 			// Obtener total de pasajeros
 			totalPasajeros, err := r.GetCantidadPasajerosByReservaTx(tx, id)
 			if err != nil {
@@ -585,7 +603,8 @@ func (r *ReservaRepository) UpdateEstado(id int, estado string) error {
 			// Verificar cupo disponible
 			var cupoDisponible int
 			queryInstancia := `SELECT cupo_disponible FROM instancia_tour 
-                            WHERE id_instancia = $1 AND eliminado = FALSE AND estado = 'PROGRAMADO'`
+                            WHERE id_instancia = $1 AND eliminado = FALSE AND estado = 'PROGRAMADO'
+                            FOR UPDATE`
 			err = tx.QueryRow(queryInstancia, idInstancia).Scan(&cupoDisponible)
 			if err != nil {
 				if err == sql.ErrNoRows {
@@ -609,8 +628,8 @@ func (r *ReservaRepository) UpdateEstado(id int, estado string) error {
 		}
 	}
 
-	// Actualizar estado
-	query := `UPDATE reserva SET estado = $1 WHERE id_reserva = $2 AND eliminado = FALSE`
+	// Actualizar estado; un cambio explícito de estado deja sin efecto la retención temporal
+	query := `UPDATE reserva SET estado = $1, fecha_expiracion = NULL WHERE id_reserva = $2 AND eliminado = FALSE`
 	_, err = tx.Exec(query, estado, id)
 	if err != nil {
 		return err
@@ -622,6 +641,11 @@ func (r *ReservaRepository) UpdateEstado(id int, estado string) error {
 
 // GetCantidadPasajerosByReservaTx obtiene la cantidad total de pasajeros dentro de una transacción
 func (r *ReservaRepository) GetCantidadPasajerosByReservaTx(tx *sql.Tx, id int) (int, error) {
+	return cantidadPasajerosReservaTx(tx, id)
+}
+
+// cantidadPasajerosReservaTx suma los pasajeros individuales y de paquetes de una reserva dentro de una transacción
+func cantidadPasajerosReservaTx(tx *sql.Tx, id int) (int, error) {
 	var totalPasajerosIndividuales int
 	queryPasajes := `SELECT COALESCE(SUM(cantidad), 0) FROM pasajes_cantidad 
                    WHERE id_reserva = $1 AND eliminado = FALSE`
@@ -667,7 +691,7 @@ func (r *ReservaRepository) GetCantidadPasajerosByReserva(id int) (int, error) {
 }
 
 // Delete realiza una eliminación lógica de una reserva
-func (r *ReservaRepository) Delete(id int) error {
+func (r *ReservaRepository) Delete(id int) (err error) {
 	// Iniciar transacción
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -708,7 +732,7 @@ func (r *ReservaRepository) Delete(id int) error {
 	// Obtener información de la reserva para restaurar cupo
 	var idInstancia int
 	var estado string
-	queryReserva := `SELECT id_instancia, estado FROM reserva WHERE id_reserva = $1 AND eliminado = FALSE`
+	queryReserva := `SELECT id_instancia, estado FROM reserva WHERE id_reserva = $1 AND eliminado = FALSE FOR UPDATE`
 	err = tx.QueryRow(queryReserva, id).Scan(&idInstancia, &estado)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -717,8 +741,8 @@ func (r *ReservaRepository) Delete(id int) error {
 		return err
 	}
 
-	// Si la reserva no está cancelada, restaurar cupo
-	if estado != "CANCELADA" {
+	// Si la reserva aún ocupa cupo, restaurarlo
+	if !estadoLiberaCupo(estado) {
 		// Obtener total de pasajeros
 		totalPasajeros, err := r.GetCantidadPasajerosByReservaTx(tx, id)
 		if err != nil {
@@ -763,7 +787,7 @@ func (r *ReservaRepository) Delete(id int) error {
 // List obtiene todas las reservas activas del sistema
 func (r *ReservaRepository) List() ([]*entidades.Reserva, error) {
 	query := `SELECT r.id_reserva, r.id_vendedor, r.id_cliente, r.id_instancia, 
-              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.notas, r.estado, r.eliminado,
+              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.tipo_cambio, pg.total_pagado, s.porcentaje_adelanto, r.notas, r.estado, r.eliminado,
               c.nombres || ' ' || c.apellidos as nombre_cliente,
               COALESCE(u.nombres || ' ' || u.apellidos, 'Web') as nombre_vendedor,
               tt.nombre as nombre_tour,
@@ -780,6 +804,9 @@ func (r *ReservaRepository) List() ([]*entidades.Reserva, error) {
               INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
               INNER JOIN canal_venta cv ON r.id_canal = cv.id_canal
               INNER JOIN sede s ON r.id_sede = s.id_sede
+              CROSS JOIN LATERAL (SELECT COALESCE(SUM(COALESCE(p.monto_convertido, p.monto)), 0) AS total_pagado
+                                  FROM pago p
+                                  WHERE p.id_reserva = r.id_reserva AND p.estado = 'PROCESADO' AND p.eliminado = FALSE) pg
               WHERE r.eliminado = FALSE
               ORDER BY r.fecha_reserva DESC`
 
@@ -796,7 +823,8 @@ func (r *ReservaRepository) List() ([]*entidades.Reserva, error) {
 		reserva := &entidades.Reserva{}
 		err := rows.Scan(
 			&reserva.ID, &reserva.IDVendedor, &reserva.IDCliente, &reserva.IDInstancia,
-			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar,
+			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar, &reserva.TipoCambio,
+			&reserva.Saldo.TotalPagado, &reserva.Saldo.PorcentajeAdelanto,
 			&reserva.Notas, &reserva.Estado, &reserva.Eliminado,
 			&reserva.NombreCliente, &reserva.NombreVendedor, &reserva.NombreTour,
 			&reserva.FechaTour, &reserva.HoraInicioTour, &reserva.HoraFinTour,
@@ -806,6 +834,8 @@ func (r *ReservaRepository) List() ([]*entidades.Reserva, error) {
 			return nil, err
 		}
 
+		reserva.Saldo = entidades.CalcularSaldoReserva(reserva.TotalPagar, reserva.Saldo.TotalPagado, reserva.Saldo.PorcentajeAdelanto)
+
 		// Obtener las cantidades de pasajes para cada reserva
 		queryPasajes := `SELECT pc.id_tipo_pasaje, tp.nombre, pc.cantidad
                        FROM pasajes_cantidad pc
@@ -839,7 +869,8 @@ func (r *ReservaRepository) List() ([]*entidades.Reserva, error) {
 
 		// Obtener los paquetes de pasajes
 		queryPaquetes := `SELECT ppd.id_paquete, pp.nombre as nombre_paquete, ppd.cantidad, 
-                        pp.precio_total as precio_unitario, (ppd.cantidad * pp.precio_total) as subtotal,
+                        COALESCE(ppd.precio_unitario, pp.precio_total) as precio_unitario,
+                        (ppd.cantidad * COALESCE(ppd.precio_unitario, pp.precio_total)) as subtotal,
                         pp.cantidad_total
                         FROM paquete_pasaje_detalle ppd
                         INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
@@ -884,7 +915,7 @@ func (r *ReservaRepository) List() ([]*entidades.Reserva, error) {
 // ListByCliente lista todas las reservas activas de un cliente específico
 func (r *ReservaRepository) ListByCliente(idCliente int) ([]*entidades.Reserva, error) {
 	query := `SELECT r.id_reserva, r.id_vendedor, r.id_cliente, r.id_instancia, 
-              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.notas, r.estado, r.eliminado,
+              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.tipo_cambio, pg.total_pagado, s.porcentaje_adelanto, r.notas, r.estado, r.eliminado,
               c.nombres || ' ' || c.apellidos as nombre_cliente,
               COALESCE(u.nombres || ' ' || u.apellidos, 'Web') as nombre_vendedor,
               tt.nombre as nombre_tour,
@@ -901,6 +932,9 @@ func (r *ReservaRepository) ListByCliente(idCliente int) ([]*entidades.Reserva,
               INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
               INNER JOIN canal_venta cv ON r.id_canal = cv.id_canal
               INNER JOIN sede s ON r.id_sede = s.id_sede
+              CROSS JOIN LATERAL (SELECT COALESCE(SUM(COALESCE(p.monto_convertido, p.monto)), 0) AS total_pagado
+                                  FROM pago p
+                                  WHERE p.id_reserva = r.id_reserva AND p.estado = 'PROCESADO' AND p.eliminado = FALSE) pg
               WHERE r.id_cliente = $1 AND r.eliminado = FALSE
               ORDER BY r.fecha_reserva DESC`
 
@@ -917,7 +951,8 @@ func (r *ReservaRepository) ListByCliente(idCliente int) ([]*entidades.Reserva,
 		reserva := &entidades.Reserva{}
 		err := rows.Scan(
 			&reserva.ID, &reserva.IDVendedor, &reserva.IDCliente, &reserva.IDInstancia,
-			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar,
+			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar, &reserva.TipoCambio,
+			&reserva.Saldo.TotalPagado, &reserva.Saldo.PorcentajeAdelanto,
 			&reserva.Notas, &reserva.Estado, &reserva.Eliminado,
 			&reserva.NombreCliente, &reserva.NombreVendedor, &reserva.NombreTour,
 			&reserva.FechaTour, &reserva.HoraInicioTour, &reserva.HoraFinTour,
@@ -927,6 +962,8 @@ func (r *ReservaRepository) ListByCliente(idCliente int) ([]*entidades.Reserva,
 			return nil, err
 		}
 
+		reserva.Saldo = entidades.CalcularSaldoReserva(reserva.TotalPagar, reserva.Saldo.TotalPagado, reserva.Saldo.PorcentajeAdelanto)
+
 		// Obtener las cantidades de pasajes para cada reserva
 		queryPasajes := `SELECT pc.id_tipo_pasaje, tp.nombre, pc.cantidad
                        FROM pasajes_cantidad pc
@@ -960,7 +997,8 @@ func (r *ReservaRepository) ListByCliente(idCliente int) ([]*entidades.Reserva,
 
 		// Obtener los paquetes de pasajes
 		queryPaquetes := `SELECT ppd.id_paquete, pp.nombre as nombre_paquete, ppd.cantidad, 
-                        pp.precio_total as precio_unitario, (ppd.cantidad * pp.precio_total) as subtotal,
+                        COALESCE(ppd.precio_unitario, pp.precio_total) as precio_unitario,
+                        (ppd.cantidad * COALESCE(ppd.precio_unitario, pp.precio_total)) as subtotal,
                         pp.cantidad_total
                         FROM paquete_pasaje_detalle ppd
                         INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
@@ -1005,7 +1043,7 @@ func (r *ReservaRepository) ListByCliente(idCliente int) ([]*entidades.Reserva,
 // ListByInstancia lista todas las reservas asociadas a una instancia específica de tour
 func (r *ReservaRepository) ListByInstancia(idInstancia int) ([]*entidades.Reserva, error) {
 	query := `SELECT r.id_reserva, r.id_vendedor, r.id_cliente, r.id_instancia, 
-              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.notas, r.estado, r.eliminado,
+              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.tipo_cambio, pg.total_pagado, s.porcentaje_adelanto, r.notas, r.estado, r.eliminado,
               c.nombres || ' ' || c.apellidos as nombre_cliente,
               COALESCE(u.nombres || ' ' || u.apellidos, 'Web') as nombre_vendedor,
               tt.nombre as nombre_tour,
@@ -1022,6 +1060,9 @@ func (r *ReservaRepository) ListByInstancia(idInstancia int) ([]*entidades.Reser
               INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
               INNER JOIN canal_venta cv ON r.id_canal = cv.id_canal
               INNER JOIN sede s ON r.id_sede = s.id_sede
+              CROSS JOIN LATERAL (SELECT COALESCE(SUM(COALESCE(p.monto_convertido, p.monto)), 0) AS total_pagado
+                                  FROM pago p
+                                  WHERE p.id_reserva = r.id_reserva AND p.estado = 'PROCESADO' AND p.eliminado = FALSE) pg
               WHERE r.id_instancia = $1 AND r.eliminado = FALSE
               ORDER BY r.fecha_reserva DESC`
 
@@ -1038,7 +1079,8 @@ func (r *ReservaRepository) ListByInstancia(idInstancia int) ([]*entidades.Reser
 		reserva := &entidades.Reserva{}
 		err := rows.Scan(
 			&reserva.ID, &reserva.IDVendedor, &reserva.IDCliente, &reserva.IDInstancia,
-			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar,
+			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar, &reserva.TipoCambio,
+			&reserva.Saldo.TotalPagado, &reserva.Saldo.PorcentajeAdelanto,
 			&reserva.Notas, &reserva.Estado, &reserva.Eliminado,
 			&reserva.NombreCliente, &reserva.NombreVendedor, &reserva.NombreTour,
 			&reserva.FechaTour, &reserva.HoraInicioTour, &reserva.HoraFinTour,
@@ -1048,6 +1090,8 @@ func (r *ReservaRepository) ListByInstancia(idInstancia int) ([]*entidades.Reser
 			return nil, err
 		}
 
+		reserva.Saldo = entidades.CalcularSaldoReserva(reserva.TotalPagar, reserva.Saldo.TotalPagado, reserva.Saldo.PorcentajeAdelanto)
+
 		// Obtener las cantidades de pasajes para cada reserva
 		queryPasajes := `SELECT pc.id_tipo_pasaje, tp.nombre, pc.cantidad
                        FROM pasajes_cantidad pc
@@ -1081,7 +1125,8 @@ func (r *ReservaRepository) ListByInstancia(idInstancia int) ([]*entidades.Reser
 
 		// Obtener los paquetes de pasajes
 		queryPaquetes := `SELECT ppd.id_paquete, pp.nombre as nombre_paquete, ppd.cantidad, 
-                        pp.precio_total as precio_unitario, (ppd.cantidad * pp.precio_total) as subtotal,
+                        COALESCE(ppd.precio_unitario, pp.precio_total) as precio_unitario,
+                        (ppd.cantidad * COALESCE(ppd.precio_unitario, pp.precio_total)) as subtotal,
                         pp.cantidad_total
                         FROM paquete_pasaje_detalle ppd
                         INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
@@ -1126,7 +1171,7 @@ func (r *ReservaRepository) ListByInstancia(idInstancia int) ([]*entidades.Reser
 // ListByFecha lista todas las reservas para una fecha específica de instancia
 func (r *ReservaRepository) ListByFecha(fecha time.Time) ([]*entidades.Reserva, error) {
 	query := `SELECT r.id_reserva, r.id_vendedor, r.id_cliente, r.id_instancia, 
-              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.notas, r.estado, r.eliminado,
+              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.tipo_cambio, pg.total_pagado, s.porcentaje_adelanto, r.notas, r.estado, r.eliminado,
               c.nombres || ' ' || c.apellidos as nombre_cliente,
               COALESCE(u.nombres || ' ' || u.apellidos, 'Web') as nombre_vendedor,
               tt.nombre as nombre_tour,
@@ -1143,6 +1188,9 @@ func (r *ReservaRepository) ListByFecha(fecha time.Time) ([]*entidades.Reserva,
               INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
               INNER JOIN canal_venta cv ON r.id_canal = cv.id_canal
               INNER JOIN sede s ON r.id_sede = s.id_sede
+              CROSS JOIN LATERAL (SELECT COALESCE(SUM(COALESCE(p.monto_convertido, p.monto)), 0) AS total_pagado
+                                  FROM pago p
+                                  WHERE p.id_reserva = r.id_reserva AND p.estado = 'PROCESADO' AND p.eliminado = FALSE) pg
               WHERE it.fecha_especifica = $1 AND r.eliminado = FALSE
               ORDER BY it.hora_inicio ASC, r.fecha_reserva DESC`
 
@@ -1159,7 +1207,8 @@ func (r *ReservaRepository) ListByFecha(fecha time.Time) ([]*entidades.Reserva,
 		reserva := &entidades.Reserva{}
 		err := rows.Scan(
 			&reserva.ID, &reserva.IDVendedor, &reserva.IDCliente, &reserva.IDInstancia,
-			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar,
+			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar, &reserva.TipoCambio,
+			&reserva.Saldo.TotalPagado, &reserva.Saldo.PorcentajeAdelanto,
 			&reserva.Notas, &reserva.Estado, &reserva.Eliminado,
 			&reserva.NombreCliente, &reserva.NombreVendedor, &reserva.NombreTour,
 			&reserva.FechaTour, &reserva.HoraInicioTour, &reserva.HoraFinTour,
@@ -1169,6 +1218,8 @@ func (r *ReservaRepository) ListByFecha(fecha time.Time) ([]*entidades.Reserva,
 			return nil, err
 		}
 
+		reserva.Saldo = entidades.CalcularSaldoReserva(reserva.TotalPagar, reserva.Saldo.TotalPagado, reserva.Saldo.PorcentajeAdelanto)
+
 		// Obtener las cantidades de pasajes para cada reserva
 		queryPasajes := `SELECT pc.id_tipo_pasaje, tp.nombre, pc.cantidad
                        FROM pasajes_cantidad pc
@@ -1202,7 +1253,8 @@ func (r *ReservaRepository) ListByFecha(fecha time.Time) ([]*entidades.Reserva,
 
 		// Obtener los paquetes de pasajes
 		queryPaquetes := `SELECT ppd.id_paquete, pp.nombre as nombre_paquete, ppd.cantidad, 
-                        pp.precio_total as precio_unitario, (ppd.cantidad * pp.precio_total) as subtotal,
+                        COALESCE(ppd.precio_unitario, pp.precio_total) as precio_unitario,
+                        (ppd.cantidad * COALESCE(ppd.precio_unitario, pp.precio_total)) as subtotal,
                         pp.cantidad_total
                         FROM paquete_pasaje_detalle ppd
                         INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
@@ -1247,7 +1299,7 @@ func (r *ReservaRepository) ListByFecha(fecha time.Time) ([]*entidades.Reserva,
 // ListByEstado lista todas las reservas por estado específico (RESERVADO, CANCELADA, CONFIRMADA)
 func (r *ReservaRepository) ListByEstado(estado string) ([]*entidades.Reserva, error) {
 	query := `SELECT r.id_reserva, r.id_vendedor, r.id_cliente, r.id_instancia, 
-              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.notas, r.estado, r.eliminado,
+              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.tipo_cambio, pg.total_pagado, s.porcentaje_adelanto, r.notas, r.estado, r.eliminado,
               c.nombres || ' ' || c.apellidos as nombre_cliente,
               COALESCE(u.nombres || ' ' || u.apellidos, 'Web') as nombre_vendedor,
               tt.nombre as nombre_tour,
@@ -1264,6 +1316,9 @@ func (r *ReservaRepository) ListByEstado(estado string) ([]*entidades.Reserva, e
               INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
               INNER JOIN canal_venta cv ON r.id_canal = cv.id_canal
               INNER JOIN sede s ON r.id_sede = s.id_sede
+              CROSS JOIN LATERAL (SELECT COALESCE(SUM(COALESCE(p.monto_convertido, p.monto)), 0) AS total_pagado
+                                  FROM pago p
+                                  WHERE p.id_reserva = r.id_reserva AND p.estado = 'PROCESADO' AND p.eliminado = FALSE) pg
               WHERE r.estado = $1 AND r.eliminado = FALSE
               ORDER BY r.fecha_reserva DESC`
 
@@ -1280,7 +1335,8 @@ func (r *ReservaRepository) ListByEstado(estado string) ([]*entidades.Reserva, e
 		reserva := &entidades.Reserva{}
 		err := rows.Scan(
 			&reserva.ID, &reserva.IDVendedor, &reserva.IDCliente, &reserva.IDInstancia,
-			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar,
+			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar, &reserva.TipoCambio,
+			&reserva.Saldo.TotalPagado, &reserva.Saldo.PorcentajeAdelanto,
 			&reserva.Notas, &reserva.Estado, &reserva.Eliminado,
 			&reserva.NombreCliente, &reserva.NombreVendedor, &reserva.NombreTour,
 			&reserva.FechaTour, &reserva.HoraInicioTour, &reserva.HoraFinTour,
@@ -1290,6 +1346,8 @@ func (r *ReservaRepository) ListByEstado(estado string) ([]*entidades.Reserva, e
 			return nil, err
 		}
 
+		reserva.Saldo = entidades.CalcularSaldoReserva(reserva.TotalPagar, reserva.Saldo.TotalPagado, reserva.Saldo.PorcentajeAdelanto)
+
 		// Obtener las cantidades de pasajes para cada reserva
 		queryPasajes := `SELECT pc.id_tipo_pasaje, tp.nombre, pc.cantidad
                        FROM pasajes_cantidad pc
@@ -1323,7 +1381,8 @@ func (r *ReservaRepository) ListByEstado(estado string) ([]*entidades.Reserva, e
 
 		// Obtener los paquetes de pasajes
 		queryPaquetes := `SELECT ppd.id_paquete, pp.nombre as nombre_paquete, ppd.cantidad, 
-                        pp.precio_total as precio_unitario, (ppd.cantidad * pp.precio_total) as subtotal,
+                        COALESCE(ppd.precio_unitario, pp.precio_total) as precio_unitario,
+                        (ppd.cantidad * COALESCE(ppd.precio_unitario, pp.precio_total)) as subtotal,
                         pp.cantidad_total
                         FROM paquete_pasaje_detalle ppd
                         INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
@@ -1368,7 +1427,7 @@ func (r *ReservaRepository) ListByEstado(estado string) ([]*entidades.Reserva, e
 // ListBySede lista todas las reservas de una sede específica o todas las reservas si es ADMIN
 func (r *ReservaRepository) ListBySede(idSede *int) ([]*entidades.Reserva, error) {
 	query := `SELECT r.id_reserva, r.id_vendedor, r.id_cliente, r.id_instancia, 
-              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.notas, r.estado, r.eliminado,
+              r.id_canal, r.id_sede, r.fecha_reserva, r.total_pagar, r.tipo_cambio, pg.total_pagado, s.porcentaje_adelanto, r.notas, r.estado, r.eliminado,
               c.nombres || ' ' || c.apellidos as nombre_cliente,
               COALESCE(u.nombres || ' ' || u.apellidos, 'Web') as nombre_vendedor,
               tt.nombre as nombre_tour,
@@ -1385,6 +1444,9 @@ func (r *ReservaRepository) ListBySede(idSede *int) ([]*entidades.Reserva, error
               INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
               INNER JOIN canal_venta cv ON r.id_canal = cv.id_canal
               INNER JOIN sede s ON r.id_sede = s.id_sede
+              CROSS JOIN LATERAL (SELECT COALESCE(SUM(COALESCE(p.monto_convertido, p.monto)), 0) AS total_pagado
+                                  FROM pago p
+                                  WHERE p.id_reserva = r.id_reserva AND p.estado = 'PROCESADO' AND p.eliminado = FALSE) pg
               WHERE r.eliminado = FALSE`
 
 	// Si se proporciona un ID de sede, filtrar por ella
@@ -1415,7 +1477,8 @@ func (r *ReservaRepository) ListBySede(idSede *int) ([]*entidades.Reserva, error
 		reserva := &entidades.Reserva{}
 		err := rows.Scan(
 			&reserva.ID, &reserva.IDVendedor, &reserva.IDCliente, &reserva.IDInstancia,
-			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar,
+			&reserva.IDCanal, &reserva.IDSede, &reserva.FechaReserva, &reserva.TotalPagar, &reserva.TipoCambio,
+			&reserva.Saldo.TotalPagado, &reserva.Saldo.PorcentajeAdelanto,
 			&reserva.Notas, &reserva.Estado, &reserva.Eliminado,
 			&reserva.NombreCliente, &reserva.NombreVendedor, &reserva.NombreTour,
 			&reserva.FechaTour, &reserva.HoraInicioTour, &reserva.HoraFinTour,
@@ -1425,6 +1488,8 @@ func (r *ReservaRepository) ListBySede(idSede *int) ([]*entidades.Reserva, error
 			return nil, err
 		}
 
+		reserva.Saldo = entidades.CalcularSaldoReserva(reserva.TotalPagar, reserva.Saldo.TotalPagado, reserva.Saldo.PorcentajeAdelanto)
+
 		// Obtener las cantidades de pasajes para cada reserva
 		queryPasajes := `SELECT pc.id_tipo_pasaje, tp.nombre, pc.cantidad
                        FROM pasajes_cantidad pc
@@ -1458,7 +1523,8 @@ func (r *ReservaRepository) ListBySede(idSede *int) ([]*entidades.Reserva, error
 
 		// Obtener los paquetes de pasajes
 		queryPaquetes := `SELECT ppd.id_paquete, pp.nombre as nombre_paquete, ppd.cantidad, 
-                        pp.precio_total as precio_unitario, (ppd.cantidad * pp.precio_total) as subtotal,
+                        COALESCE(ppd.precio_unitario, pp.precio_total) as precio_unitario,
+                        (ppd.cantidad * COALESCE(ppd.precio_unitario, pp.precio_total)) as subtotal,
                         pp.cantidad_total
                         FROM paquete_pasaje_detalle ppd
                         INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
@@ -1500,11 +1566,77 @@ func (r *ReservaRepository) ListBySede(idSede *int) ([]*entidades.Reserva, error
 	return reservas, nil
 }
 
+// ListSaldosPendientes lista las reservas activas con saldo por cobrar cuya salida es dentro de los próximos días,
+// ordenadas por fecha de salida para que los vendedores cobren primero las más próximas
+func (r *ReservaRepository) ListSaldosPendientes(idSede *int, dias int) ([]*entidades.SaldoPendienteReserva, error) {
+	query := `SELECT r.id_reserva, r.estado, r.id_sede, s.nombre, r.id_cliente,
+              COALESCE(c.nombres || ' ' || c.apellidos, c.razon_social, '') as nombre_cliente,
+              COALESCE(c.numero_celular, ''), COALESCE(c.correo, ''), r.id_vendedor,
+              tt.nombre as nombre_tour, it.fecha_especifica, to_char(it.hora_inicio, 'HH24:MI') as hora_inicio_tour,
+              it.fecha_especifica - CURRENT_DATE as dias_para_salida,
+              r.total_pagar, pg.total_pagado, s.porcentaje_adelanto
+              FROM reserva r
+              INNER JOIN cliente c ON r.id_cliente = c.id_cliente
+              INNER JOIN instancia_tour it ON r.id_instancia = it.id_instancia
+              INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+              INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
+              INNER JOIN sede s ON r.id_sede = s.id_sede
+              CROSS JOIN LATERAL (SELECT COALESCE(SUM(COALESCE(p.monto_convertido, p.monto)), 0) AS total_pagado
+                                  FROM pago p
+                                  WHERE p.id_reserva = r.id_reserva AND p.estado = 'PROCESADO' AND p.eliminado = FALSE) pg
+              WHERE r.eliminado = FALSE
+              AND r.estado IN ('RESERVADO', 'CONFIRMADA')
+              AND it.fecha_especifica BETWEEN CURRENT_DATE AND CURRENT_DATE + $1::int
+              AND pg.total_pagado < r.total_pagar`
+
+	args := []interface{}{dias}
+
+	// Si se proporciona un ID de sede, filtrar por ella
+	if idSede != nil {
+		query += " AND r.id_sede = $2"
+		args = append(args, *idSede)
+	}
+
+	query += " ORDER BY it.fecha_especifica ASC, it.hora_inicio ASC, r.id_reserva ASC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	saldos := []*entidades.SaldoPendienteReserva{}
+
+	for rows.Next() {
+		saldo := &entidades.SaldoPendienteReserva{}
+		var totalPagar, totalPagado entidades.Dinero
+		var porcentajeAdelanto float64
+		err := rows.Scan(
+			&saldo.IDReserva, &saldo.Estado, &saldo.IDSede, &saldo.NombreSede, &saldo.IDCliente,
+			&saldo.NombreCliente, &saldo.TelefonoCliente, &saldo.CorreoCliente, &saldo.IDVendedor,
+			&saldo.NombreTour, &saldo.FechaTour, &saldo.HoraInicioTour, &saldo.DiasParaSalida,
+			&totalPagar, &totalPagado, &porcentajeAdelanto,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		saldo.Saldo = entidades.CalcularSaldoReserva(totalPagar, totalPagado, porcentajeAdelanto)
+		saldos = append(saldos, saldo)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return saldos, nil
+}
+
 // GetTotalReservasByInstancia obtiene el número total de reservas para una instancia específica
 func (r *ReservaRepository) GetTotalReservasByInstancia(idInstancia int) (int, error) {
 	var total int
 	query := `SELECT COUNT(*) FROM reserva 
-             WHERE id_instancia = $1 AND estado != 'CANCELADA' AND eliminado = FALSE`
+             WHERE id_instancia = $1 AND estado NOT IN ('CANCELADA', 'EXPIRADA') AND eliminado = FALSE`
 
 	err := r.db.QueryRow(query, idInstancia).Scan(&total)
 	if err != nil {
@@ -1518,7 +1650,7 @@ func (r *ReservaRepository) GetTotalReservasByInstancia(idInstancia int) (int, e
 func (r *ReservaRepository) GetTotalPasajerosByInstancia(idInstancia int) (int, error) {
 	// Primero, obtener todas las reservas no canceladas para esta instancia
 	query := `SELECT id_reserva FROM reserva 
-             WHERE id_instancia = $1 AND estado != 'CANCELADA' AND eliminado = FALSE`
+             WHERE id_instancia = $1 AND estado NOT IN ('CANCELADA', 'EXPIRADA') AND eliminado = FALSE`
 
 	rows, err := r.db.Query(query, idInstancia)
 	if err != nil {
@@ -1570,7 +1702,7 @@ func (r *ReservaRepository) VerificarDisponibilidadInstancia(idInstancia int, ca
 }
 
 // ReservarInstanciaMercadoPago crea una reserva a través de Mercado Pago
-func (r *ReservaRepository) ReservarInstanciaMercadoPago(reserva *entidades.NuevaReservaRequest) (int, string, error) {
+func (r *ReservaRepository) ReservarInstanciaMercadoPago(reserva *entidades.NuevaReservaRequest) (idReserva int, nombreTour string, err error) {
 	// Iniciar transacción
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -1589,15 +1721,16 @@ func (r *ReservaRepository) ReservarInstanciaMercadoPago(reserva *entidades.Nuev
 		return 0, "", errors.New("debe incluir al menos un pasaje o un paquete en la reserva")
 	}
 
-	// Primero, obtener información de la instancia del tour para verificar disponibilidad
+	// Primero, obtener y bloquear la instancia del tour para verificar disponibilidad
+	// El bloqueo se mantiene hasta el commit, así dos ventas simultáneas no pueden tomar el mismo cupo
 	var cupoDisponible int
-	var nombreTour string
 	queryInstancia := `SELECT it.cupo_disponible, tt.nombre 
                       FROM instancia_tour it
                       INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
                       INNER JOIN tipo_tour tt ON tp.id_tipo_tour = tt.id_tipo_tour
                       WHERE it.id_instancia = $1 AND it.eliminado = FALSE 
-                      AND it.estado = 'PROGRAMADO'`
+                      AND it.estado = 'PROGRAMADO'
+                      FOR UPDATE OF it`
 	err = tx.QueryRow(queryInstancia, reserva.IDInstancia).Scan(&cupoDisponible, &nombreTour)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1634,11 +1767,23 @@ func (r *ReservaRepository) ReservarInstanciaMercadoPago(reserva *entidades.Nuev
 		return 0, "", errors.New("no hay suficiente cupo disponible para la reserva")
 	}
 
-	// Consulta SQL para insertar una nueva reserva
-	var idReserva int
+	// Obtener el tiempo de retención de cupos configurado para la sede
+	var minutosRetencion int
+	querySede := `SELECT minutos_retencion_reserva FROM sede 
+                 WHERE id_sede = $1 AND eliminado = FALSE`
+	err = tx.QueryRow(querySede, reserva.IDSede).Scan(&minutosRetencion)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, "", errors.New("la sede especificada no existe")
+		}
+		return 0, "", err
+	}
+
+	// Consulta SQL para insertar una nueva reserva con retención temporal de cupos
 	query := `INSERT INTO reserva (id_vendedor, id_cliente, id_instancia, id_canal, id_sede, 
-             total_pagar, notas, estado, eliminado)
-             VALUES ($1, $2, $3, $4, $5, $6, $7, 'RESERVADO', FALSE)
+             total_pagar, tipo_cambio, notas, estado, eliminado, fecha_expiracion)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'RESERVADO', FALSE, 
+             CURRENT_TIMESTAMP + make_interval(mins => $9))
              RETURNING id_reserva`
 
 	// Ejecutar la consulta con los datos de la reserva
@@ -1650,7 +1795,9 @@ func (r *ReservaRepository) ReservarInstanciaMercadoPago(reserva *entidades.Nuev
 		reserva.IDCanal,
 		reserva.IDSede,
 		reserva.TotalPagar,
+		valorTipoCambio(reserva.TipoCambio),
 		reserva.Notas,
+		minutosRetencion,
 	).Scan(&idReserva)
 
 	if err != nil {
@@ -1661,10 +1808,10 @@ func (r *ReservaRepository) ReservarInstanciaMercadoPago(reserva *entidades.Nuev
 	for _, pasaje := range reserva.CantidadPasajes {
 		// Solo insertar si la cantidad es mayor que cero
 		if pasaje.Cantidad > 0 {
-			queryPasaje := `INSERT INTO pasajes_cantidad (id_reserva, id_tipo_pasaje, cantidad, eliminado)
-                          VALUES ($1, $2, $3, FALSE)`
+			queryPasaje := `INSERT INTO pasajes_cantidad (id_reserva, id_tipo_pasaje, cantidad, precio_unitario, eliminado)
+                          VALUES ($1, $2, $3, $4, FALSE)`
 
-			_, err = tx.Exec(queryPasaje, idReserva, pasaje.IDTipoPasaje, pasaje.Cantidad)
+			_, err = tx.Exec(queryPasaje, idReserva, pasaje.IDTipoPasaje, pasaje.Cantidad, precioUnitarioPasaje(reserva.Precios, pasaje.IDTipoPasaje))
 			if err != nil {
 				return 0, "", err
 			}
@@ -1675,10 +1822,10 @@ func (r *ReservaRepository) ReservarInstanciaMercadoPago(reserva *entidades.Nuev
 	for _, paquete := range reserva.Paquetes {
 		// Solo insertar si la cantidad es mayor que cero
 		if paquete.Cantidad > 0 {
-			queryPaquete := `INSERT INTO paquete_pasaje_detalle (id_reserva, id_paquete, cantidad, eliminado)
-                           VALUES ($1, $2, $3, FALSE)`
+			queryPaquete := `INSERT INTO paquete_pasaje_detalle (id_reserva, id_paquete, cantidad, precio_unitario, eliminado)
+                           VALUES ($1, $2, $3, $4, FALSE)`
 
-			_, err = tx.Exec(queryPaquete, idReserva, paquete.IDPaquete, paquete.Cantidad)
+			_, err = tx.Exec(queryPaquete, idReserva, paquete.IDPaquete, paquete.Cantidad, precioUnitarioPaquete(reserva.PreciosPaquetes, paquete.IDPaquete))
 			if err != nil {
 				return 0, "", err
 			}
@@ -1694,6 +1841,20 @@ func (r *ReservaRepository) ReservarInstanciaMercadoPago(reserva *entidades.Nuev
 		return 0, "", err
 	}
 
+	// Registrar el canje del código promocional aplicado al total
+	if reserva.Promocion != nil {
+		err = registrarCanjePromocionTx(tx, reserva.Promocion, idReserva, reserva.IDCliente)
+		if err != nil {
+			return 0, "", err
+		}
+	}
+
+	// Registrar las reglas que fijaron el precio de los pasajes
+	err = registrarPreciosPasajesTx(tx, idReserva, reserva.Precios)
+	if err != nil {
+		return 0, "", err
+	}
+
 	// Commit de la transacción
 	err = tx.Commit()
 	if err != nil {
@@ -1702,3 +1863,954 @@ func (r *ReservaRepository) ReservarInstanciaMercadoPago(reserva *entidades.Nuev
 
 	return idReserva, nombreTour, nil
 }
+
+// condicionRetencionVencida selecciona las reservas (alias r) que siguen reteniendo cupo con el plazo de pago
+// vencido y sin el adelanto de su sede cubierto. Es la única regla para expirar reservas, la usan tanto la
+// liberación periódica como la conciliación de pagos. El adelanto se redondea al céntimo como en
+// entidades.CalcularSaldoReserva y nunca se considera cubierto sin pagos (mínimo un céntimo)
+const condicionRetencionVencida = `r.estado = 'RESERVADO' AND r.eliminado = FALSE
+              AND r.fecha_expiracion IS NOT NULL AND r.fecha_expiracion <= CURRENT_TIMESTAMP
+              AND (SELECT COALESCE(SUM(COALESCE(p.monto_convertido, p.monto)), 0) FROM pago p
+                   WHERE p.id_reserva = r.id_reserva AND p.estado = 'PROCESADO' AND p.eliminado = FALSE)
+                  < (SELECT GREATEST(ROUND(r.total_pagar * s.porcentaje_adelanto / 100, 2), 0.01)
+                     FROM sede s WHERE s.id_sede = r.id_sede)`
+
+// LiberarReservasExpiradas pasa a EXPIRADA las reservas web cuya retención venció y devuelve sus cupos
+// Retorna los IDs de las reservas liberadas
+func (r *ReservaRepository) LiberarReservasExpiradas() ([]int, error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear las reservas vencidas; las que otra transacción tenga tomadas se procesan en la siguiente pasada
+	query := `SELECT r.id_reserva, r.id_instancia FROM reserva r
+              WHERE ` + condicionRetencionVencida + `
+              ORDER BY r.fecha_expiracion
+              FOR UPDATE OF r SKIP LOCKED`
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	type reservaVencida struct {
+		idReserva   int
+		idInstancia int
+	}
+	vencidas := []reservaVencida{}
+
+	for rows.Next() {
+		var vencida reservaVencida
+		if err = rows.Scan(&vencida.idReserva, &vencida.idInstancia); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		vencidas = append(vencidas, vencida)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	liberadas := []int{}
+	for _, vencida := range vencidas {
+		err = r.expirarReservaTx(tx, vencida.idReserva, vencida.idInstancia)
+		if err != nil {
+			return nil, err
+		}
+
+		liberadas = append(liberadas, vencida.idReserva)
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return liberadas, nil
+}
+
+// expirarReservaTx devuelve el cupo de una reserva bloqueada y la marca como EXPIRADA
+func (r *ReservaRepository) expirarReservaTx(tx *sql.Tx, idReserva int, idInstancia int) error {
+	// Obtener total de pasajeros
+	totalPasajeros, err := r.GetCantidadPasajerosByReservaTx(tx, idReserva)
+	if err != nil {
+		return err
+	}
+
+	// Restaurar cupo
+	queryRestauraCupo := `UPDATE instancia_tour 
+                         SET cupo_disponible = cupo_disponible + $1 
+                         WHERE id_instancia = $2`
+	_, err = tx.Exec(queryRestauraCupo, totalPasajeros, idInstancia)
+	if err != nil {
+		return err
+	}
+
+	// Marcar la reserva como expirada
+	queryExpirar := `UPDATE reserva SET estado = 'EXPIRADA' WHERE id_reserva = $1`
+	_, err = tx.Exec(queryExpirar, idReserva)
+	return err
+}
+
+// ExpirarReservaVencida expira una reserva web cuya retención venció si sigue sin el adelanto cubierto
+// Retorna false si entretanto la reserva cambió de estado o cubrió el adelanto
+func (r *ReservaRepository) ExpirarReservaVencida(idReserva int) (expirada bool, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear la reserva en el mismo orden que al registrar sus pagos
+	var idInstancia int
+	query := `SELECT r.id_instancia FROM reserva r
+              WHERE r.id_reserva = $1 AND ` + condicionRetencionVencida + `
+              FOR UPDATE OF r`
+	err = tx.QueryRow(query, idReserva).Scan(&idInstancia)
+	if err == sql.ErrNoRows {
+		err = tx.Rollback()
+		return false, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = r.expirarReservaTx(tx, idReserva, idInstancia)
+	if err != nil {
+		return false, err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// AsignarPasarela registra la pasarela con la que se cobra una reserva web
+func (r *ReservaRepository) AsignarPasarela(idReserva int, pasarela string) error {
+	query := `UPDATE reserva SET pasarela = $1 WHERE id_reserva = $2 AND eliminado = FALSE`
+	_, err := r.db.Exec(query, pasarela, idReserva)
+	return err
+}
+
+// obtenerMetodoPagoTx obtiene el método de pago de la sede con el nombre indicado, creándolo si no existe
+func (r *ReservaRepository) obtenerMetodoPagoTx(tx *sql.Tx, idSede int, nombre string) (int, error) {
+	var idMetodoPago int
+	query := `SELECT id_metodo_pago FROM metodo_pago 
+              WHERE id_sede = $1 AND UPPER(REPLACE(nombre, ' ', '_')) = $2 AND eliminado = FALSE
+              ORDER BY id_metodo_pago LIMIT 1`
+	err := tx.QueryRow(query, idSede, nombre).Scan(&idMetodoPago)
+	if err == nil {
+		return idMetodoPago, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	queryInsert := `INSERT INTO metodo_pago (id_sede, nombre, descripcion, eliminado)
+                    VALUES ($1, $2, 'Pagos en línea registrados automáticamente', FALSE)
+                    RETURNING id_metodo_pago`
+	err = tx.QueryRow(queryInsert, idSede, nombre).Scan(&idMetodoPago)
+	if err != nil {
+		return 0, err
+	}
+
+	return idMetodoPago, nil
+}
+
+// resumenPagosReservaTx calcula el total pagado en soles y el saldo de una reserva dentro de una transacción
+func (r *ReservaRepository) resumenPagosReservaTx(tx *sql.Tx, resultado *entidades.ResultadoPagoReserva) error {
+	query := `SELECT COALESCE(SUM(COALESCE(monto_convertido, monto)), 0) FROM pago 
+              WHERE id_reserva = $1 AND estado = 'PROCESADO' AND eliminado = FALSE`
+	err := tx.QueryRow(query, resultado.IDReserva).Scan(&resultado.TotalPagado)
+	if err != nil {
+		return err
+	}
+
+	resultado.Saldo = resultado.TotalPagar.Restar(resultado.TotalPagado)
+	return nil
+}
+
+// bloquearReservaPagoTx bloquea la reserva de un pago antes de modificar sus pagos, en el mismo orden que
+// al registrarlos (reserva y luego pago), y devuelve su estado
+func bloquearReservaPagoTx(tx *sql.Tx, idReserva int) (string, error) {
+	var estado string
+	query := `SELECT estado FROM reserva WHERE id_reserva = $1 AND eliminado = FALSE FOR UPDATE`
+	err := tx.QueryRow(query, idReserva).Scan(&estado)
+	if err == sql.ErrNoRows {
+		return "", errors.New("reserva no encontrada")
+	}
+	return estado, err
+}
+
+// actualizarEstadoPorSaldoTx ajusta el estado de una reserva bloqueada a lo pagado y devuelve su saldo
+// Una reserva RESERVADO pasa a CONFIRMADA con el total pagado y deja de vencer con el adelanto de su sede
+// cubierto; una CONFIRMADA que deja de estar cubierta vuelve a RESERVADO. Las reservas canceladas o
+// expiradas no cambian de estado
+func actualizarEstadoPorSaldoTx(tx *sql.Tx, idReserva int) (*entidades.ResultadoPagoReserva, error) {
+	var totalPagar, totalPagado entidades.Dinero
+	var porcentajeAdelanto float64
+	var estado string
+	var fechaExpiracion *time.Time
+	query := `SELECT r.total_pagar, r.estado, r.fecha_expiracion, s.porcentaje_adelanto,
+              (SELECT COALESCE(SUM(COALESCE(p.monto_convertido, p.monto)), 0) FROM pago p
+               WHERE p.id_reserva = r.id_reserva AND p.estado = 'PROCESADO' AND p.eliminado = FALSE)
+              FROM reserva r
+              INNER JOIN sede s ON r.id_sede = s.id_sede
+              WHERE r.id_reserva = $1 AND r.eliminado = FALSE`
+	err := tx.QueryRow(query, idReserva).Scan(&totalPagar, &estado, &fechaExpiracion, &porcentajeAdelanto, &totalPagado)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("reserva no encontrada")
+		}
+		return nil, err
+	}
+
+	saldo := entidades.CalcularSaldoReserva(totalPagar, totalPagado, porcentajeAdelanto)
+	nuevoEstado := estado
+	switch {
+	case estado == "RESERVADO" && saldo.EstadoPago == entidades.EstadoPagoPagado:
+		nuevoEstado = "CONFIRMADA"
+	case estado == "CONFIRMADA" && saldo.Saldo.EsPositivo():
+		nuevoEstado = "RESERVADO"
+	}
+
+	// Con el adelanto cubierto la reserva ya no libera sus cupos al vencer la retención
+	quitarVencimiento := fechaExpiracion != nil && saldo.AdelantoCubierto()
+	if nuevoEstado != estado || quitarVencimiento {
+		queryEstado := `UPDATE reserva SET estado = $1,
+                       fecha_expiracion = CASE WHEN $2 THEN NULL ELSE fecha_expiracion END
+                       WHERE id_reserva = $3`
+		_, err = tx.Exec(queryEstado, nuevoEstado, quitarVencimiento, idReserva)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &entidades.ResultadoPagoReserva{
+		IDReserva:     idReserva,
+		EstadoReserva: nuevoEstado,
+		TotalPagar:    saldo.TotalPagar,
+		TotalPagado:   saldo.TotalPagado,
+		Saldo:         saldo.Saldo,
+		EstadoPago:    saldo.EstadoPago,
+	}, nil
+}
+
+// prefijoComprobantePasarela antepone al ID de transacción para identificar de qué pasarela viene un pago
+func prefijoComprobantePasarela(pasarela string) string {
+	switch pasarela {
+	case entidades.PasarelaMercadoPago:
+		return "MP-"
+	case entidades.PasarelaIzipay:
+		return "IZ-"
+	default:
+		return pasarela + "-"
+	}
+}
+
+// RegistrarPagoPasarela registra un pago aprobado de una pasarela y actualiza la reserva en la misma transacción
+// Una transacción ya registrada en la pasarela no genera otro pago. El estado sigue la misma regla que los pagos
+// en caja: la reserva se confirma cuando el total pagado cubre el total a pagar y deja de vencer solo con el
+// adelanto de su sede cubierto. Una reserva expirada intenta recuperar su cupo con una nueva retención; si no hay
+// cupo sigue expirada y se solicita la devolución completa del pago, que queda PENDIENTE de aprobación.
+// Un pago de una reserva cancelada o que supera su saldo no se registra: retorna entidades.ErrPagoNoAplicable
+// El pago se guarda en su moneda con el tipo de cambio y el monto en soles indicados
+func (r *ReservaRepository) RegistrarPagoPasarela(idReserva int, pasarela string, idTransaccion string, pago entidades.ConversionMoneda) (resultado *entidades.ResultadoPagoReserva, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Obtener y bloquear la reserva
+	resultado = &entidades.ResultadoPagoReserva{IDReserva: idReserva}
+	var idInstancia, idCanal, idSede, minutosRetencion int
+	var estado string
+	queryReserva := `SELECT r.id_instancia, r.id_canal, r.id_sede, r.total_pagar, r.estado, s.minutos_retencion_reserva
+                    FROM reserva r
+                    INNER JOIN sede s ON r.id_sede = s.id_sede
+                    WHERE r.id_reserva = $1 AND r.eliminado = FALSE
+                    FOR UPDATE OF r`
+	err = tx.QueryRow(queryReserva, idReserva).Scan(&idInstancia, &idCanal, &idSede, &resultado.TotalPagar, &estado, &minutosRetencion)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("reserva no encontrada")
+		}
+		return nil, err
+	}
+
+	// Registrar el pago solo si la transacción externa aún no existe
+	pagoNuevo := false
+	queryExiste := `SELECT id_pago FROM pago WHERE pasarela = $1 AND id_transaccion_externa = $2 AND eliminado = FALSE`
+	err = tx.QueryRow(queryExiste, pasarela, idTransaccion).Scan(&resultado.IDPago)
+	if err == sql.ErrNoRows {
+		pagoNuevo = true
+
+		// Solo se registran pagos que caben en el saldo de una reserva no cancelada
+		err = r.resumenPagosReservaTx(tx, resultado)
+		if err != nil {
+			return nil, err
+		}
+		err = entidades.ValidarPagoPasarela(estado, resultado.Saldo, pago)
+		if err != nil {
+			return nil, err
+		}
+
+		// Cada pasarela se registra como un método de pago de la sede con su mismo nombre
+		var idMetodoPago int
+		idMetodoPago, err = r.obtenerMetodoPagoTx(tx, idSede, pasarela)
+		if err != nil {
+			return nil, err
+		}
+
+		queryPago := `INSERT INTO pago (id_reserva, id_metodo_pago, id_canal, id_sede, monto, moneda, tipo_cambio, 
+                     monto_convertido, comprobante, estado, pasarela, id_transaccion_externa, eliminado)
+                     VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'PROCESADO', $10, $11, FALSE)
+                     RETURNING id_pago`
+		err = tx.QueryRow(
+			queryPago,
+			idReserva,
+			idMetodoPago,
+			idCanal,
+			idSede,
+			pago.Monto,
+			pago.Monto.Moneda(),
+			pago.TipoCambio,
+			pago.MontoBase,
+			prefijoComprobantePasarela(pasarela)+idTransaccion,
+			pasarela,
+			idTransaccion,
+		).Scan(&resultado.IDPago)
+	}
+	if err != nil {
+		return nil, err
+	}
+	idPago := resultado.IDPago
+	idDevolucion := 0
+
+	// Una reserva expirada vuelve a ocupar cupo si todavía hay disponible
+	if estado == "EXPIRADA" {
+		var totalPasajeros, cupoDisponible int
+		totalPasajeros, err = r.GetCantidadPasajerosByReservaTx(tx, idReserva)
+		if err != nil {
+			return nil, err
+		}
+
+		queryInstancia := `SELECT cupo_disponible FROM instancia_tour 
+                          WHERE id_instancia = $1 AND eliminado = FALSE AND estado = 'PROGRAMADO'
+                          FOR UPDATE`
+		err = tx.QueryRow(queryInstancia, idInstancia).Scan(&cupoDisponible)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		hayCupo := err == nil && totalPasajeros <= cupoDisponible
+		err = nil
+
+		// Sin cupo la reserva sigue expirada y se solicita la devolución completa del pago recibido
+		if !hayCupo && pagoNuevo {
+			queryDevolucion := `INSERT INTO devolucion_pago (id_pago, motivo, monto_devolucion, estado, observaciones,
+                               cancelar_reserva)
+                               VALUES ($1, 'Pago recibido con la retención vencida y sin cupo para recuperar la reserva',
+                               $2, 'PENDIENTE', 'Solicitada automáticamente al registrar el pago de la pasarela', FALSE)
+                               RETURNING id_devolucion`
+			err = tx.QueryRow(queryDevolucion, idPago, pago.Monto).Scan(&idDevolucion)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if hayCupo {
+			queryUpdateCupo := `UPDATE instancia_tour 
+                               SET cupo_disponible = cupo_disponible - $1 
+                               WHERE id_instancia = $2`
+			_, err = tx.Exec(queryUpdateCupo, totalPasajeros, idInstancia)
+			if err != nil {
+				return nil, err
+			}
+
+			// La retención vencida se renueva: si el pago no cubre el adelanto la reserva vuelve a tener
+			// el plazo de su sede para completarlo, en lugar de expirar en la siguiente pasada
+			queryRecuperar := `UPDATE reserva SET estado = 'RESERVADO',
+                              fecha_expiracion = CURRENT_TIMESTAMP + make_interval(mins => $2)
+                              WHERE id_reserva = $1`
+			_, err = tx.Exec(queryRecuperar, idReserva, minutosRetencion)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Actualizar el estado de la reserva según lo pagado y el adelanto de su sede
+	resultado, err = actualizarEstadoPorSaldoTx(tx, idReserva)
+	if err != nil {
+		return nil, err
+	}
+	resultado.IDPago = idPago
+	resultado.IDDevolucion = idDevolucion
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return resultado, nil
+}
+
+// RevertirPagoPasarela marca como DEVUELTO el pago de una transacción de una pasarela devuelta o contracargada
+// Si la reserva ya no queda cubierta vuelve a RESERVADO, o se cancela liberando su cupo cuando no queda nada pagado.
+// Una reserva que vuelve a RESERVADO sin el adelanto de su sede cubierto retiene su cupo otra vez por el tiempo
+// de retención de la sede, igual que una reserva web nueva
+// Retorna nil si la transacción no tiene un pago registrado
+func (r *ReservaRepository) RevertirPagoPasarela(pasarela string, idTransaccion string) (resultado *entidades.ResultadoPagoReserva, err error) {
+	// Obtener la reserva del pago antes de bloquear (mismo orden de bloqueo que al registrar: reserva y luego pago)
+	var idReserva int
+	queryReservaPago := `SELECT id_reserva FROM pago WHERE pasarela = $1 AND id_transaccion_externa = $2 AND eliminado = FALSE`
+	err = r.db.QueryRow(queryReservaPago, pasarela, idTransaccion).Scan(&idReserva)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear la reserva
+	resultado = &entidades.ResultadoPagoReserva{IDReserva: idReserva}
+	var idInstancia, minutosRetencion int
+	var porcentajeAdelanto float64
+	var estado string
+	queryReserva := `SELECT r.id_instancia, r.total_pagar, r.estado, s.porcentaje_adelanto, s.minutos_retencion_reserva
+                    FROM reserva r
+                    INNER JOIN sede s ON r.id_sede = s.id_sede
+                    WHERE r.id_reserva = $1 AND r.eliminado = FALSE
+                    FOR UPDATE OF r`
+	err = tx.QueryRow(queryReserva, idReserva).Scan(&idInstancia, &resultado.TotalPagar, &estado, &porcentajeAdelanto, &minutosRetencion)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("reserva no encontrada")
+		}
+		return nil, err
+	}
+
+	// Bloquear el pago y marcarlo como devuelto si seguía vigente
+	var estadoPago string
+	queryPago := `SELECT id_pago, estado FROM pago 
+                 WHERE pasarela = $1 AND id_transaccion_externa = $2 AND eliminado = FALSE
+                 FOR UPDATE`
+	err = tx.QueryRow(queryPago, pasarela, idTransaccion).Scan(&resultado.IDPago, &estadoPago)
+	if err != nil {
+		return nil, err
+	}
+
+	if estadoPago == "PROCESADO" {
+		queryDevolver := `UPDATE pago SET estado = 'DEVUELTO' WHERE id_pago = $1`
+		_, err = tx.Exec(queryDevolver, resultado.IDPago)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Calcular total pagado y saldo
+	err = r.resumenPagosReservaTx(tx, resultado)
+	if err != nil {
+		return nil, err
+	}
+
+	// Solo se revierten reservas vigentes que dejan de estar cubiertas
+	resultado.IDInstancia = idInstancia
+	if (estado == "CONFIRMADA" || estado == "RESERVADO") && resultado.Saldo.EsPositivo() {
+		// Sin el adelanto cubierto la reserva vuelve a vencer; con él deja de vencer
+		saldo := entidades.CalcularSaldoReserva(resultado.TotalPagar, resultado.TotalPagado, porcentajeAdelanto)
+		retener := false
+
+		if !resultado.TotalPagado.EsPositivo() {
+			// Sin pagos vigentes: cancelar y liberar cupo
+			var totalPasajeros int
+			totalPasajeros, err = r.GetCantidadPasajerosByReservaTx(tx, idReserva)
+			if err != nil {
+				return nil, err
+			}
+
+			queryRestauraCupo := `UPDATE instancia_tour 
+                                 SET cupo_disponible = cupo_disponible + $1 
+                                 WHERE id_instancia = $2`
+			_, err = tx.Exec(queryRestauraCupo, totalPasajeros, idInstancia)
+			if err != nil {
+				return nil, err
+			}
+			estado = "CANCELADA"
+			resultado.CupoLiberado = true
+		} else {
+			estado = "RESERVADO"
+			retener = !saldo.AdelantoCubierto()
+		}
+
+		queryEstado := `UPDATE reserva SET estado = $1,
+                       fecha_expiracion = CASE WHEN $2 THEN CURRENT_TIMESTAMP + make_interval(mins => $3) ELSE NULL END
+                       WHERE id_reserva = $4`
+		_, err = tx.Exec(queryEstado, estado, retener, minutosRetencion, idReserva)
+		if err != nil {
+			return nil, err
+		}
+	}
+	resultado.EstadoReserva = estado
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return resultado, nil
+}
+
+// GetDatosCancelacion obtiene los datos de una reserva necesarios para cotizar su cancelación
+// El total reembolsable descuenta de los pagos vigentes las devoluciones ya solicitadas o realizadas y se
+// expresa en soles con el tipo de cambio de cada pago
+func (r *ReservaRepository) GetDatosCancelacion(idReserva int) (*entidades.DatosCancelacionReserva, error) {
+	datos := &entidades.DatosCancelacionReserva{}
+	query := `SELECT r.id_reserva, tp.id_tipo_tour, r.id_sede, r.estado,
+              it.fecha_especifica + it.hora_inicio, LOCALTIMESTAMP,
+              COALESCE((SELECT SUM(ROUND((p.monto - COALESCE((SELECT SUM(d.monto_devolucion) FROM devolucion_pago d
+                                                              WHERE d.id_pago = p.id_pago
+                                                              AND d.estado IN ('PENDIENTE', 'APROBADA', 'EJECUTANDO', 'COMPLETADA')), 0))
+                                         * p.tipo_cambio, 2))
+                        FROM pago p
+                        WHERE p.id_reserva = r.id_reserva AND p.estado = 'PROCESADO' AND p.eliminado = FALSE), 0)
+              FROM reserva r
+              INNER JOIN instancia_tour it ON r.id_instancia = it.id_instancia
+              INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+              WHERE r.id_reserva = $1 AND r.eliminado = FALSE`
+
+	err := r.db.QueryRow(query, idReserva).Scan(
+		&datos.IDReserva, &datos.IDTipoTour, &datos.IDSede, &datos.Estado,
+		&datos.InicioTour, &datos.Ahora, &datos.TotalReembolsable,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("reserva no encontrada")
+		}
+		return nil, err
+	}
+
+	return datos, nil
+}
+
+// CancelarConDevolucion cancela una reserva, libera su cupo y registra como PENDIENTE la devolución que
+// corresponde según el porcentaje indicado, repartida entre los pagos vigentes de la reserva
+// Todo ocurre en una transacción con la reserva y sus pagos bloqueados, así el monto se calcula sobre
+// los pagos existentes al momento de cancelar. El monto devuelto se expresa en soles; la devolución de cada
+// pago se registra en la moneda del pago con su tipo de cambio
+func (r *ReservaRepository) CancelarConDevolucion(idReserva int, porcentajeDevolucion float64, motivo string) (montoDevolucion entidades.Dinero, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entidades.Dinero{}, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear la reserva
+	var idInstancia int
+	var estado string
+	queryReserva := `SELECT id_instancia, estado FROM reserva
+                    WHERE id_reserva = $1 AND eliminado = FALSE
+                    FOR UPDATE`
+	err = tx.QueryRow(queryReserva, idReserva).Scan(&idInstancia, &estado)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entidades.Dinero{}, errors.New("reserva no encontrada")
+		}
+		return entidades.Dinero{}, err
+	}
+
+	if estado != "RESERVADO" && estado != "CONFIRMADA" {
+		return entidades.Dinero{}, errors.New("solo se pueden cancelar reservas en estado RESERVADO o CONFIRMADA")
+	}
+
+	// Bloquear los pagos vigentes y calcular lo que queda por devolver de cada uno
+	type pagoDisponible struct {
+		id             int
+		disponible     entidades.Dinero // En la moneda del pago
+		disponibleBase entidades.Dinero // En soles
+		tipoCambio     entidades.TipoCambio
+	}
+	queryPagos := `SELECT p.id_pago, p.moneda, p.tipo_cambio,
+                  p.monto - COALESCE((SELECT SUM(d.monto_devolucion) FROM devolucion_pago d
+                                      WHERE d.id_pago = p.id_pago
+                                      AND d.estado IN ('PENDIENTE', 'APROBADA', 'EJECUTANDO', 'COMPLETADA')), 0)
+                  FROM pago p
+                  WHERE p.id_reserva = $1 AND p.estado = 'PROCESADO' AND p.eliminado = FALSE
+                  ORDER BY p.fecha_pago DESC
+                  FOR UPDATE OF p`
+	rows, err := tx.Query(queryPagos, idReserva)
+	if err != nil {
+		return entidades.Dinero{}, err
+	}
+
+	pagos := []pagoDisponible{}
+	var totalReembolsable entidades.Dinero
+	for rows.Next() {
+		var pago pagoDisponible
+		if err = rows.Scan(&pago.id, &pago.tipoCambio.Moneda, &pago.tipoCambio.Valor, &pago.disponible); err != nil {
+			rows.Close()
+			return entidades.Dinero{}, err
+		}
+		pago.disponible = pago.disponible.EnMoneda(pago.tipoCambio.Moneda)
+		pago.disponibleBase = pago.tipoCambio.AMonedaBase(pago.disponible)
+		if pago.disponible.EsPositivo() {
+			pagos = append(pagos, pago)
+			totalReembolsable = totalReembolsable.Sumar(pago.disponibleBase)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return entidades.Dinero{}, err
+	}
+
+	// Registrar las devoluciones pendientes de aprobación
+	montoDevolucion = totalReembolsable.Porcentaje(porcentajeDevolucion)
+	restante := montoDevolucion
+	queryDevolucion := `INSERT INTO devolucion_pago (id_pago, motivo, monto_devolucion, estado, cancelar_reserva)
+                       VALUES ($1, $2, $3, 'PENDIENTE', FALSE)`
+	for _, pago := range pagos {
+		if !restante.EsPositivo() {
+			break
+		}
+
+		// La parte en soles se devuelve en la moneda del pago, sin exceder lo disponible
+		parte := restante
+		devolucion := pago.disponible
+		if pago.disponibleBase.MenorQue(parte) {
+			parte = pago.disponibleBase
+		} else if parte.MenorQue(pago.disponibleBase) {
+			devolucion = pago.tipoCambio.DesdeMonedaBase(parte)
+			if pago.disponible.MenorQue(devolucion) {
+				devolucion = pago.disponible
+			}
+		}
+		_, err = tx.Exec(queryDevolucion, pago.id, motivo, devolucion)
+		if err != nil {
+			return entidades.Dinero{}, err
+		}
+		restante = restante.Restar(parte)
+	}
+
+	// Liberar el cupo y cancelar la reserva
+	totalPasajeros, err := cantidadPasajerosReservaTx(tx, idReserva)
+	if err != nil {
+		return entidades.Dinero{}, err
+	}
+
+	queryRestauraCupo := `UPDATE instancia_tour
+                         SET cupo_disponible = cupo_disponible + $1
+                         WHERE id_instancia = $2`
+	_, err = tx.Exec(queryRestauraCupo, totalPasajeros, idInstancia)
+	if err != nil {
+		return entidades.Dinero{}, err
+	}
+
+	queryCancelar := `UPDATE reserva SET estado = 'CANCELADA', fecha_expiracion = NULL WHERE id_reserva = $1`
+	_, err = tx.Exec(queryCancelar, idReserva)
+	if err != nil {
+		return entidades.Dinero{}, err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return entidades.Dinero{}, err
+	}
+
+	return montoDevolucion, nil
+}
+
+// Reprogramar mueve una reserva con sus pasajes y paquetes a otra instancia del mismo tipo de tour
+// Ambas instancias se bloquean en orden de ID para evitar interbloqueos con otra reprogramación en
+// sentido contrario. El total se recalcula con los precios efectivos de los pasajes en el destino y los de
+// paquete_pasajes, y el estado se ajusta según lo pagado: una reserva confirmada que queda con saldo vuelve a RESERVADO
+func (r *ReservaRepository) Reprogramar(idReserva int, solicitud *entidades.ReprogramarReservaRequest, origen string, idUsuario *int) (resultado *entidades.ResultadoReprogramacion, err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloquear la reserva
+	var idInstanciaOrigen int
+	var estado string
+	var totalAnterior entidades.Dinero
+	queryReserva := `SELECT id_instancia, estado, total_pagar FROM reserva
+                    WHERE id_reserva = $1 AND eliminado = FALSE
+                    FOR UPDATE`
+	err = tx.QueryRow(queryReserva, idReserva).Scan(&idInstanciaOrigen, &estado, &totalAnterior)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("reserva no encontrada")
+		}
+		return nil, err
+	}
+
+	if estado != "RESERVADO" && estado != "CONFIRMADA" {
+		return nil, errors.New("solo se pueden reprogramar reservas en estado RESERVADO o CONFIRMADA")
+	}
+
+	if solicitud.IDInstanciaDestino == idInstanciaOrigen {
+		return nil, errors.New("la reserva ya pertenece a la instancia indicada")
+	}
+
+	// Bloquear ambas instancias en orden de ID
+	type datosInstancia struct {
+		cupo       int
+		estado     string
+		idTipoTour int
+		futura     bool
+	}
+	instancias := map[int]*datosInstancia{}
+	queryInstancias := `SELECT it.id_instancia, it.cupo_disponible, it.estado, tp.id_tipo_tour,
+                       (it.fecha_especifica + it.hora_inicio) > LOCALTIMESTAMP
+                       FROM instancia_tour it
+                       INNER JOIN tour_programado tp ON it.id_tour_programado = tp.id_tour_programado
+                       WHERE it.id_instancia IN ($1, $2) AND it.eliminado = FALSE
+                       ORDER BY it.id_instancia
+                       FOR UPDATE OF it`
+	rows, err := tx.Query(queryInstancias, idInstanciaOrigen, solicitud.IDInstanciaDestino)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id int
+		datos := &datosInstancia{}
+		if err = rows.Scan(&id, &datos.cupo, &datos.estado, &datos.idTipoTour, &datos.futura); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		instancias[id] = datos
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	instanciaOrigen, instanciaDestino := instancias[idInstanciaOrigen], instancias[solicitud.IDInstanciaDestino]
+	if instanciaDestino == nil {
+		return nil, errors.New("la instancia de destino no existe")
+	}
+	if instanciaOrigen == nil {
+		return nil, errors.New("la instancia actual de la reserva no existe")
+	}
+	if instanciaDestino.estado != "PROGRAMADO" || !instanciaDestino.futura {
+		return nil, errors.New("la instancia de destino no está disponible para reservas")
+	}
+	if instanciaDestino.idTipoTour != instanciaOrigen.idTipoTour {
+		return nil, errors.New("solo se puede reprogramar a una instancia del mismo tipo de tour")
+	}
+
+	// Verificar cupo en el destino
+	totalPasajeros, err := cantidadPasajerosReservaTx(tx, idReserva)
+	if err != nil {
+		return nil, err
+	}
+
+	if instanciaDestino.cupo < totalPasajeros {
+		return nil, errors.New("no hay suficiente cupo disponible en la instancia de destino")
+	}
+
+	// Fijar los precios de los pasajes en la instancia de destino y registrar las reglas que los fijaron
+	if solicitud.Precios != nil {
+		queryPrecio := `UPDATE pasajes_cantidad SET precio_unitario = $1
+                       WHERE id_reserva = $2 AND id_tipo_pasaje = $3 AND eliminado = FALSE`
+		for _, precio := range solicitud.Precios {
+			_, err = tx.Exec(queryPrecio, precio.PrecioSoles, idReserva, precio.IDTipoPasaje)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = registrarPreciosPasajesTx(tx, idReserva, solicitud.Precios)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Recalcular el total con los precios vigentes; el descuento del código canjeado se conserva
+	var totalNuevo entidades.Dinero
+	queryTotal := `SELECT GREATEST(
+                  COALESCE((SELECT SUM(pc.cantidad * COALESCE(pc.precio_unitario, tp.costo)) FROM pasajes_cantidad pc
+                            INNER JOIN tipo_pasaje tp ON pc.id_tipo_pasaje = tp.id_tipo_pasaje
+                            WHERE pc.id_reserva = $1 AND pc.eliminado = FALSE), 0) +
+                  COALESCE((SELECT SUM(ppd.cantidad * COALESCE(ppd.precio_unitario, pp.precio_total)) FROM paquete_pasaje_detalle ppd
+                            INNER JOIN paquete_pasajes pp ON ppd.id_paquete = pp.id_paquete
+                            WHERE ppd.id_reserva = $1 AND ppd.eliminado = FALSE), 0) -
+                  COALESCE((SELECT monto_descuento FROM canje_promocion WHERE id_reserva = $1), 0), 0)`
+	err = tx.QueryRow(queryTotal, idReserva).Scan(&totalNuevo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mover el cupo
+	queryCupo := `UPDATE instancia_tour SET cupo_disponible = cupo_disponible + $1 WHERE id_instancia = $2`
+	_, err = tx.Exec(queryCupo, totalPasajeros, idInstanciaOrigen)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(queryCupo, -totalPasajeros, solicitud.IDInstanciaDestino)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ajustar el estado según lo pagado frente al nuevo total
+	resumen := &entidades.ResultadoPagoReserva{IDReserva: idReserva, TotalPagar: totalNuevo}
+	err = r.resumenPagosReservaTx(tx, resumen)
+	if err != nil {
+		return nil, err
+	}
+
+	if estado == "CONFIRMADA" && resumen.Saldo.EsPositivo() {
+		estado = "RESERVADO"
+	} else if estado == "RESERVADO" && resumen.TotalPagado.EsPositivo() && !resumen.Saldo.EsPositivo() {
+		estado = "CONFIRMADA"
+	}
+
+	queryActualizar := `UPDATE reserva SET id_instancia = $1, total_pagar = $2, estado = $3,
+                       fecha_expiracion = CASE WHEN $3 = 'RESERVADO' AND estado = 'RESERVADO' THEN fecha_expiracion ELSE NULL END
+                       WHERE id_reserva = $4`
+	_, err = tx.Exec(queryActualizar, solicitud.IDInstanciaDestino, totalNuevo, estado, idReserva)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registrar el historial
+	resultado = &entidades.ResultadoReprogramacion{
+		ReprogramacionReserva: entidades.ReprogramacionReserva{
+			IDReserva:          idReserva,
+			IDInstanciaOrigen:  idInstanciaOrigen,
+			IDInstanciaDestino: solicitud.IDInstanciaDestino,
+			TotalAnterior:      totalAnterior,
+			TotalNuevo:         totalNuevo,
+			Diferencia:         totalNuevo.Restar(totalAnterior),
+			Motivo:             solicitud.Motivo,
+			Origen:             origen,
+			IDUsuario:          idUsuario,
+		},
+		EstadoReserva: estado,
+		TotalPagado:   resumen.TotalPagado,
+		Saldo:         resumen.Saldo,
+	}
+
+	queryHistorial := `INSERT INTO reserva_reprogramacion (id_reserva, id_instancia_origen, id_instancia_destino,
+                      total_anterior, total_nuevo, diferencia, motivo, origen, id_usuario)
+                      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+                      RETURNING id_reprogramacion, fecha_reprogramacion`
+	err = tx.QueryRow(queryHistorial, idReserva, idInstanciaOrigen, solicitud.IDInstanciaDestino,
+		totalAnterior, totalNuevo, resultado.Diferencia, solicitud.Motivo, origen, idUsuario,
+	).Scan(&resultado.ID, &resultado.FechaReprogramacion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit de la transacción
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return resultado, nil
+}
+
+// ListReprogramaciones obtiene el historial de cambios de instancia de una reserva
+func (r *ReservaRepository) ListReprogramaciones(idReserva int) ([]*entidades.ReprogramacionReserva, error) {
+	query := `SELECT rr.id_reprogramacion, rr.id_reserva, rr.id_instancia_origen, rr.id_instancia_destino,
+              rr.total_anterior, rr.total_nuevo, rr.diferencia, COALESCE(rr.motivo, ''), rr.origen, rr.id_usuario,
+              rr.fecha_reprogramacion,
+              TO_CHAR(io.fecha_especifica + io.hora_inicio, 'YYYY-MM-DD HH24:MI'),
+              TO_CHAR(id.fecha_especifica + id.hora_inicio, 'YYYY-MM-DD HH24:MI')
+              FROM reserva_reprogramacion rr
+              INNER JOIN instancia_tour io ON rr.id_instancia_origen = io.id_instancia
+              INNER JOIN instancia_tour id ON rr.id_instancia_destino = id.id_instancia
+              WHERE rr.id_reserva = $1
+              ORDER BY rr.fecha_reprogramacion DESC`
+
+	rows, err := r.db.Query(query, idReserva)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reprogramaciones := []*entidades.ReprogramacionReserva{}
+
+	for rows.Next() {
+		reprogramacion := &entidades.ReprogramacionReserva{}
+		err := rows.Scan(
+			&reprogramacion.ID, &reprogramacion.IDReserva, &reprogramacion.IDInstanciaOrigen, &reprogramacion.IDInstanciaDestino,
+			&reprogramacion.TotalAnterior, &reprogramacion.TotalNuevo, &reprogramacion.Diferencia, &reprogramacion.Motivo,
+			&reprogramacion.Origen, &reprogramacion.IDUsuario, &reprogramacion.FechaReprogramacion,
+			&reprogramacion.FechaOrigen, &reprogramacion.FechaDestino,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reprogramaciones = append(reprogramaciones, reprogramacion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reprogramaciones, nil
+}
@@ -21,13 +21,15 @@ func NewSedeRepository(db *sql.DB) *SedeRepository {
 // GetByID obtiene una sede por su ID
 func (r *SedeRepository) GetByID(id int) (*entidades.Sede, error) {
 	sede := &entidades.Sede{}
-	query := `SELECT id_sede, nombre, direccion, telefono, correo, distrito, provincia, pais, image_url, eliminado 
+	query := `SELECT id_sede, nombre, direccion, telefono, correo, distrito, provincia, pais, image_url, eliminado,
+              minutos_retencion_reserva, porcentaje_adelanto
               FROM sede 
               WHERE id_sede = $1 AND eliminado = false`
 
 	err := r.db.QueryRow(query, id).Scan(
 		&sede.ID, &sede.Nombre, &sede.Direccion, &sede.Telefono,
 		&sede.Correo, &sede.Distrito, &sede.Provincia, &sede.Pais, &sede.ImageURL, &sede.Eliminado,
+		&sede.MinutosRetencionReserva, &sede.PorcentajeAdelanto,
 	)
 
 	if err != nil {
@@ -109,6 +111,50 @@ func (r *SedeRepository) Update(id int, sede *entidades.ActualizarSedeRequest) e
 	return nil
 }
 
+// UpdateRetencionReserva actualiza los minutos de retención de cupos para reservas web de una sede
+func (r *SedeRepository) UpdateRetencionReserva(id int, minutos int) error {
+	query := `UPDATE sede SET minutos_retencion_reserva = $1
+              WHERE id_sede = $2 AND eliminado = false`
+
+	result, err := r.db.Exec(query, minutos, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("sede no encontrada o ya fue eliminada")
+	}
+
+	return nil
+}
+
+// UpdateAdelantoReserva actualiza el porcentaje de adelanto de las reservas de una sede
+func (r *SedeRepository) UpdateAdelantoReserva(id int, porcentaje float64) error {
+	query := `UPDATE sede SET porcentaje_adelanto = $1
+              WHERE id_sede = $2 AND eliminado = false`
+
+	result, err := r.db.Exec(query, porcentaje, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("sede no encontrada o ya fue eliminada")
+	}
+
+	return nil
+}
+
 // SoftDelete marca una sede como eliminada (borrado lógico)
 func (r *SedeRepository) SoftDelete(id int) error {
 	query := `UPDATE sede SET eliminado = true WHERE id_sede = $1 AND eliminado = false`
@@ -0,0 +1,226 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sistema-toursseft/internal/entidades"
+)
+
+// correlativoMaximo es el mayor correlativo que admite SUNAT (8 dígitos)
+const correlativoMaximo = 99999999
+
+// SerieComprobanteRepository maneja las operaciones de base de datos para series de comprobantes
+type SerieComprobanteRepository struct {
+	db *sql.DB
+}
+
+// NewSerieComprobanteRepository crea una nueva instancia del repositorio
+func NewSerieComprobanteRepository(db *sql.DB) *SerieComprobanteRepository {
+	return &SerieComprobanteRepository{
+		db: db,
+	}
+}
+
+// asignarCorrelativo toma el siguiente número de la serie activa de la sede y tipo dentro de la transacción
+// prefijo restringe la letra inicial de la serie (F o B en notas); vacío acepta cualquiera.
+// El UPDATE bloquea la fila de la serie hasta el commit, así dos comprobantes simultáneos nunca reciben
+// el mismo número, y si la transacción se revierte el correlativo vuelve a quedar libre.
+func asignarCorrelativo(tx *sql.Tx, idSede int, tipo string, prefijo string) (idSerie int, numero string, correlativo int, err error) {
+	var serie string
+	query := `UPDATE serie_comprobante SET ultimo_correlativo = ultimo_correlativo + 1
+              WHERE id_sede = $1 AND tipo = $2 AND activo = TRUE AND ultimo_correlativo < $3
+              AND ($4 = '' OR LEFT(serie, 1) = $4)
+              RETURNING id_serie, serie, ultimo_correlativo`
+
+	err = tx.QueryRow(query, idSede, tipo, correlativoMaximo, prefijo).Scan(&idSerie, &serie, &correlativo)
+	if err == sql.ErrNoRows {
+		// Distinguir una serie agotada de una sede sin serie configurada
+		var agotada bool
+		queryAgotada := `SELECT EXISTS(SELECT 1 FROM serie_comprobante WHERE id_sede = $1 AND tipo = $2 AND activo = TRUE
+                         AND ($3 = '' OR LEFT(serie, 1) = $3))`
+		if errAgotada := tx.QueryRow(queryAgotada, idSede, tipo, prefijo).Scan(&agotada); errAgotada != nil {
+			return 0, "", 0, errAgotada
+		}
+		if agotada {
+			return 0, "", 0, errors.New("la serie activa llegó a su último correlativo, registre una nueva serie")
+		}
+		if prefijo != "" {
+			tipo = fmt.Sprintf("%s con serie %s", tipo, prefijo)
+		}
+		return 0, "", 0, fmt.Errorf("la sede no tiene una serie activa para %s, ingrese el número manualmente o registre una serie", tipo)
+	}
+	if err != nil {
+		return 0, "", 0, err
+	}
+
+	return idSerie, fmt.Sprintf("%s-%d", serie, correlativo), correlativo, nil
+}
+
+// GetByID obtiene una serie por su ID
+func (r *SerieComprobanteRepository) GetByID(id int) (*entidades.SerieComprobante, error) {
+	serie := &entidades.SerieComprobante{}
+	query := `SELECT sc.id_serie, sc.id_sede, sc.tipo, sc.serie, sc.ultimo_correlativo, sc.activo, sc.fecha_creacion, s.nombre
+              FROM serie_comprobante sc
+              INNER JOIN sede s ON sc.id_sede = s.id_sede
+              WHERE sc.id_serie = $1`
+
+	err := r.db.QueryRow(query, id).Scan(
+		&serie.ID, &serie.IDSede, &serie.Tipo, &serie.Serie, &serie.UltimoCorrelativo, &serie.Activo, &serie.FechaCreacion, &serie.NombreSede,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("serie no encontrada")
+		}
+		return nil, err
+	}
+
+	return serie, nil
+}
+
+// GetBySerie obtiene una serie por su código, por ejemplo B001
+func (r *SerieComprobanteRepository) GetBySerie(codigo string) (*entidades.SerieComprobante, error) {
+	var id int
+	err := r.db.QueryRow(`SELECT id_serie FROM serie_comprobante WHERE serie = $1`, codigo).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("serie no encontrada")
+		}
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// Create registra una serie; queda activa si la sede no tiene otra serie activa del mismo tipo y letra inicial
+func (r *SerieComprobanteRepository) Create(serie *entidades.NuevaSerieComprobanteRequest) (int, error) {
+	var id int
+	query := `INSERT INTO serie_comprobante (id_sede, tipo, serie, ultimo_correlativo, activo)
+              VALUES ($1, $2, $3, $4,
+                      NOT EXISTS(SELECT 1 FROM serie_comprobante WHERE id_sede = $1 AND tipo = $2 AND activo = TRUE
+                                 AND LEFT(serie, 1) = LEFT($3, 1)))
+              RETURNING id_serie`
+
+	err := r.db.QueryRow(query, serie.IDSede, serie.Tipo, serie.Serie, serie.CorrelativoInicial).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// CambiarEstado activa o desactiva una serie
+// Al activar una serie se desactiva la que estuviera activa para la misma sede, tipo y letra inicial
+func (r *SerieComprobanteRepository) CambiarEstado(id int, activo bool) (err error) {
+	// Iniciar transacción
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Si hay error, hacer rollback
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if activo {
+		queryDesactivar := `UPDATE serie_comprobante SET activo = FALSE
+                            WHERE activo = TRUE AND id_serie <> $1
+                            AND (id_sede, tipo, LEFT(serie, 1)) =
+                                (SELECT id_sede, tipo, LEFT(serie, 1) FROM serie_comprobante WHERE id_serie = $1)`
+		if _, err = tx.Exec(queryDesactivar, id); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.Exec(`UPDATE serie_comprobante SET activo = $1 WHERE id_serie = $2`, activo, id)
+	if err != nil {
+		return err
+	}
+	filas, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if filas == 0 {
+		err = errors.New("serie no encontrada")
+		return err
+	}
+
+	// Commit de la transacción
+	return tx.Commit()
+}
+
+// ListUso lista las series con la cantidad de comprobantes emitidos y anulados
+// Con idSede mayor a cero solo incluye las series de esa sede
+func (r *SerieComprobanteRepository) ListUso(idSede int) ([]*entidades.UsoSerieComprobante, error) {
+	query := `SELECT sc.id_serie, sc.id_sede, sc.tipo, sc.serie, sc.ultimo_correlativo, sc.activo, sc.fecha_creacion, s.nombre,
+              COUNT(cp.id_comprobante) FILTER (WHERE cp.estado = 'EMITIDO'),
+              COUNT(cp.id_comprobante) FILTER (WHERE cp.estado = 'ANULADO')
+              FROM serie_comprobante sc
+              INNER JOIN sede s ON sc.id_sede = s.id_sede
+              LEFT JOIN comprobante_pago cp ON cp.id_serie = sc.id_serie
+              WHERE ($1 = 0 OR sc.id_sede = $1)
+              GROUP BY sc.id_serie, s.nombre
+              ORDER BY s.nombre, sc.tipo, sc.serie`
+
+	rows, err := r.db.Query(query, idSede)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := []*entidades.UsoSerieComprobante{}
+	porID := map[int]*entidades.UsoSerieComprobante{}
+
+	for rows.Next() {
+		uso := &entidades.UsoSerieComprobante{NumerosAnulados: []string{}}
+		err := rows.Scan(
+			&uso.ID, &uso.IDSede, &uso.Tipo, &uso.Serie, &uso.UltimoCorrelativo, &uso.Activo, &uso.FechaCreacion, &uso.NombreSede,
+			&uso.Emitidos, &uso.Anulados,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if uso.UltimoCorrelativo > 0 {
+			uso.UltimoNumero = fmt.Sprintf("%s-%d", uso.Serie, uso.UltimoCorrelativo)
+		}
+		uso.Disponibles = correlativoMaximo - uso.UltimoCorrelativo
+		series = append(series, uso)
+		porID[uso.ID] = uso
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Números anulados de cada serie; siguen ocupando su correlativo
+	queryAnulados := `SELECT cp.id_serie, cp.numero_comprobante
+                      FROM comprobante_pago cp
+                      INNER JOIN serie_comprobante sc ON cp.id_serie = sc.id_serie
+                      WHERE cp.estado = 'ANULADO' AND ($1 = 0 OR sc.id_sede = $1)
+                      ORDER BY cp.id_serie, cp.correlativo`
+
+	rowsAnulados, err := r.db.Query(queryAnulados, idSede)
+	if err != nil {
+		return nil, err
+	}
+	defer rowsAnulados.Close()
+
+	for rowsAnulados.Next() {
+		var idSerie int
+		var numero string
+		if err := rowsAnulados.Scan(&idSerie, &numero); err != nil {
+			return nil, err
+		}
+		if uso, ok := porID[idSerie]; ok {
+			uso.NumerosAnulados = append(uso.NumerosAnulados, numero)
+		}
+	}
+
+	if err = rowsAnulados.Err(); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
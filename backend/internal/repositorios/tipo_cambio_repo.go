@@ -0,0 +1,201 @@
+package repositorios
+
+import (
+	"database/sql"
+	"errors"
+	"sistema-toursseft/internal/entidades"
+	"time"
+)
+
+// ErrSinTipoCambio indica que no hay tipo de cambio registrado para convertir una moneda
+var ErrSinTipoCambio = errors.New("no hay tipo de cambio registrado para la moneda")
+
+// TipoCambioRepository maneja las operaciones de base de datos para tipos de cambio
+type TipoCambioRepository struct {
+	db *sql.DB
+}
+
+// NewTipoCambioRepository crea una nueva instancia del repositorio
+func NewTipoCambioRepository(db *sql.DB) *TipoCambioRepository {
+	return &TipoCambioRepository{
+		db: db,
+	}
+}
+
+// queryTipoCambioBase selecciona un tipo de cambio
+const queryTipoCambioBase = `SELECT id_tipo_cambio, fecha, moneda, valor, origen, fecha_registro FROM tipo_cambio`
+
+// scanTipoCambio lee una fila obtenida con queryTipoCambioBase
+func scanTipoCambio(row interface{ Scan(...interface{}) error }) (*entidades.TipoCambio, error) {
+	tipoCambio := &entidades.TipoCambio{}
+	err := row.Scan(
+		&tipoCambio.ID, &tipoCambio.Fecha, &tipoCambio.Moneda, &tipoCambio.Valor,
+		&tipoCambio.Origen, &tipoCambio.FechaRegistro,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tipoCambio, nil
+}
+
+// GetByID obtiene un tipo de cambio por su ID
+func (r *TipoCambioRepository) GetByID(id int) (*entidades.TipoCambio, error) {
+	tipoCambio, err := scanTipoCambio(r.db.QueryRow(queryTipoCambioBase+` WHERE id_tipo_cambio = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("tipo de cambio no encontrado")
+		}
+		return nil, err
+	}
+
+	return tipoCambio, nil
+}
+
+// GetVigente obtiene el último tipo de cambio de una moneda registrado hasta la fecha indicada
+func (r *TipoCambioRepository) GetVigente(moneda string, fecha time.Time) (*entidades.TipoCambio, error) {
+	query := queryTipoCambioBase + ` WHERE moneda = $1 AND fecha <= $2 ORDER BY fecha DESC LIMIT 1`
+
+	tipoCambio, err := scanTipoCambio(r.db.QueryRow(query, moneda, fecha.Format("2006-01-02")))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSinTipoCambio
+		}
+		return nil, err
+	}
+
+	return tipoCambio, nil
+}
+
+// Registrar guarda el tipo de cambio de un día, reemplazando el valor anterior de la misma fecha y moneda
+func (r *TipoCambioRepository) Registrar(tipoCambio *entidades.NuevoTipoCambioRequest) (int, error) {
+	var id int
+	query := `INSERT INTO tipo_cambio (fecha, moneda, valor, origen)
+              VALUES ($1, $2, $3, $4)
+              ON CONFLICT (fecha, moneda) DO UPDATE SET
+              valor = EXCLUDED.valor,
+              origen = EXCLUDED.origen,
+              fecha_registro = CURRENT_TIMESTAMP
+              RETURNING id_tipo_cambio`
+
+	err := r.db.QueryRow(
+		query,
+		tipoCambio.Fecha.Format("2006-01-02"),
+		tipoCambio.Moneda,
+		tipoCambio.Valor,
+		tipoCambio.Origen,
+	).Scan(&id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Delete elimina un tipo de cambio
+// Los pagos y reservas ya convertidos conservan el tipo de cambio con el que se registraron
+func (r *TipoCambioRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM tipo_cambio WHERE id_tipo_cambio = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("tipo de cambio no encontrado")
+	}
+
+	return nil
+}
+
+// List lista los tipos de cambio de una moneda entre dos fechas, del más reciente al más antiguo
+func (r *TipoCambioRepository) List(moneda string, fechaInicio time.Time, fechaFin time.Time) ([]*entidades.TipoCambio, error) {
+	query := queryTipoCambioBase + ` WHERE moneda = $1 AND fecha BETWEEN $2 AND $3 ORDER BY fecha DESC`
+
+	rows, err := r.db.Query(query, moneda, fechaInicio.Format("2006-01-02"), fechaFin.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tiposCambio := []*entidades.TipoCambio{}
+
+	for rows.Next() {
+		tipoCambio, err := scanTipoCambio(rows)
+		if err != nil {
+			return nil, err
+		}
+		tiposCambio = append(tiposCambio, tipoCambio)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tiposCambio, nil
+}
+
+// ReporteIngresosMoneda suma por moneda los pagos procesados o devueltos entre dos fechas y las
+// devoluciones completadas sobre ellos. Las devoluciones se expresan en la moneda del pago y se
+// convierten a soles con el tipo de cambio del pago
+func (r *TipoCambioRepository) ReporteIngresosMoneda(fechaInicio time.Time, fechaFin time.Time, idSede *int) ([]*entidades.IngresoMoneda, error) {
+	query := `SELECT p.moneda, COUNT(*),
+              COALESCE(SUM(p.monto), 0),
+              COALESCE(SUM(d.devuelto), 0),
+              COALESCE(SUM(COALESCE(p.monto_convertido, p.monto) - ROUND(d.devuelto * p.tipo_cambio, 2)), 0)
+              FROM pago p
+              CROSS JOIN LATERAL (SELECT COALESCE(SUM(dp.monto_devolucion), 0) AS devuelto
+                                  FROM devolucion_pago dp
+                                  WHERE dp.id_pago = p.id_pago AND dp.estado = 'COMPLETADA') d
+              WHERE p.eliminado = FALSE AND p.estado IN ('PROCESADO', 'DEVUELTO')
+              AND p.fecha_pago >= $1 AND p.fecha_pago < $2::date + 1
+              AND ($3::int IS NULL OR p.id_sede = $3)
+              GROUP BY p.moneda
+              ORDER BY p.moneda`
+
+	rows, err := r.db.Query(query, fechaInicio.Format("2006-01-02"), fechaFin.Format("2006-01-02"), idSede)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ingresos := []*entidades.IngresoMoneda{}
+
+	for rows.Next() {
+		ingreso := &entidades.IngresoMoneda{}
+		err := rows.Scan(&ingreso.Moneda, &ingreso.CantidadPagos, &ingreso.Total, &ingreso.Devuelto, &ingreso.NetoConvertido)
+		if err != nil {
+			return nil, err
+		}
+		ingreso.Total = ingreso.Total.EnMoneda(ingreso.Moneda)
+		ingreso.Devuelto = ingreso.Devuelto.EnMoneda(ingreso.Moneda)
+		ingreso.Neto = ingreso.Total.Restar(ingreso.Devuelto)
+		ingresos = append(ingresos, ingreso)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ingresos, nil
+}
+
+// valorTipoCambio devuelve el tipo de cambio con el que se guarda una venta, o NULL si solo usó soles
+func valorTipoCambio(tipoCambio *entidades.TipoCambio) interface{} {
+	if tipoCambio == nil {
+		return nil
+	}
+	return tipoCambio.Valor
+}
+
+// precioUnitarioPaquete devuelve el precio en soles con el que se guarda un paquete, o NULL si no se cotizó
+func precioUnitarioPaquete(precios map[int]entidades.Dinero, idPaquete int) interface{} {
+	if precio, ok := precios[idPaquete]; ok {
+		return precio
+	}
+	return nil
+}
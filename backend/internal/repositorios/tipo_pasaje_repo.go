@@ -18,16 +18,24 @@ func NewTipoPasajeRepository(db *sql.DB) *TipoPasajeRepository {
 	}
 }
 
+// monedaPrecio devuelve la moneda de una lista de precios; sin moneda los precios están en soles
+func monedaPrecio(moneda string) string {
+	if moneda == "" {
+		return entidades.MonedaPorDefecto
+	}
+	return moneda
+}
+
 // GetByID obtiene un tipo de pasaje por su ID
 func (r *TipoPasajeRepository) GetByID(id int) (*entidades.TipoPasaje, error) {
 	tipoPasaje := &entidades.TipoPasaje{}
-	query := `SELECT id_tipo_pasaje, id_sede, id_tipo_tour, nombre, costo, edad, eliminado
+	query := `SELECT id_tipo_pasaje, id_sede, id_tipo_tour, nombre, costo, moneda, edad, eliminado
               FROM tipo_pasaje
               WHERE id_tipo_pasaje = $1 AND eliminado = false`
 
 	err := r.db.QueryRow(query, id).Scan(
 		&tipoPasaje.ID, &tipoPasaje.IDSede, &tipoPasaje.IDTipoTour,
-		&tipoPasaje.Nombre, &tipoPasaje.Costo, &tipoPasaje.Edad, &tipoPasaje.Eliminado,
+		&tipoPasaje.Nombre, &tipoPasaje.Costo, &tipoPasaje.Moneda, &tipoPasaje.Edad, &tipoPasaje.Eliminado,
 	)
 
 	if err != nil {
@@ -37,19 +45,20 @@ func (r *TipoPasajeRepository) GetByID(id int) (*entidades.TipoPasaje, error) {
 		return nil, err
 	}
 
+	tipoPasaje.Costo = tipoPasaje.Costo.EnMoneda(tipoPasaje.Moneda)
 	return tipoPasaje, nil
 }
 
 // GetByNombre obtiene un tipo de pasaje por su nombre y sede
 func (r *TipoPasajeRepository) GetByNombre(nombre string, idSede int) (*entidades.TipoPasaje, error) {
 	tipoPasaje := &entidades.TipoPasaje{}
-	query := `SELECT id_tipo_pasaje, id_sede, id_tipo_tour, nombre, costo, edad, eliminado
+	query := `SELECT id_tipo_pasaje, id_sede, id_tipo_tour, nombre, costo, moneda, edad, eliminado
               FROM tipo_pasaje
               WHERE nombre = $1 AND id_sede = $2 AND eliminado = false`
 
 	err := r.db.QueryRow(query, nombre, idSede).Scan(
 		&tipoPasaje.ID, &tipoPasaje.IDSede, &tipoPasaje.IDTipoTour,
-		&tipoPasaje.Nombre, &tipoPasaje.Costo, &tipoPasaje.Edad, &tipoPasaje.Eliminado,
+		&tipoPasaje.Nombre, &tipoPasaje.Costo, &tipoPasaje.Moneda, &tipoPasaje.Edad, &tipoPasaje.Eliminado,
 	)
 
 	if err != nil {
@@ -59,14 +68,15 @@ func (r *TipoPasajeRepository) GetByNombre(nombre string, idSede int) (*entidade
 		return nil, err
 	}
 
+	tipoPasaje.Costo = tipoPasaje.Costo.EnMoneda(tipoPasaje.Moneda)
 	return tipoPasaje, nil
 }
 
 // Create guarda un nuevo tipo de pasaje en la base de datos
 func (r *TipoPasajeRepository) Create(tipoPasaje *entidades.NuevoTipoPasajeRequest) (int, error) {
 	var id int
-	query := `INSERT INTO tipo_pasaje (id_sede, id_tipo_tour, nombre, costo, edad, eliminado)
-              VALUES ($1, $2, $3, $4, $5, false)
+	query := `INSERT INTO tipo_pasaje (id_sede, id_tipo_tour, nombre, costo, moneda, edad, eliminado)
+              VALUES ($1, $2, $3, $4, $5, $6, false)
               RETURNING id_tipo_pasaje`
 
 	err := r.db.QueryRow(
@@ -75,6 +85,7 @@ func (r *TipoPasajeRepository) Create(tipoPasaje *entidades.NuevoTipoPasajeReque
 		tipoPasaje.IDTipoTour,
 		tipoPasaje.Nombre,
 		tipoPasaje.Costo,
+		monedaPrecio(tipoPasaje.Moneda),
 		tipoPasaje.Edad,
 	).Scan(&id)
 
@@ -91,14 +102,16 @@ func (r *TipoPasajeRepository) Update(id int, tipoPasaje *entidades.ActualizarTi
               id_tipo_tour = $1,
               nombre = $2,
               costo = $3,
-              edad = $4
-              WHERE id_tipo_pasaje = $5 AND eliminado = false`
+              moneda = $4,
+              edad = $5
+              WHERE id_tipo_pasaje = $6 AND eliminado = false`
 
 	result, err := r.db.Exec(
 		query,
 		tipoPasaje.IDTipoTour,
 		tipoPasaje.Nombre,
 		tipoPasaje.Costo,
+		monedaPrecio(tipoPasaje.Moneda),
 		tipoPasaje.Edad,
 		id,
 	)
@@ -155,7 +168,7 @@ func (r *TipoPasajeRepository) Delete(id int) error {
 
 // ListBySede lista todos los tipos de pasaje de una sede específica
 func (r *TipoPasajeRepository) ListBySede(idSede int) ([]*entidades.TipoPasaje, error) {
-	query := `SELECT id_tipo_pasaje, id_sede, id_tipo_tour, nombre, costo, edad, eliminado
+	query := `SELECT id_tipo_pasaje, id_sede, id_tipo_tour, nombre, costo, moneda, edad, eliminado
               FROM tipo_pasaje
               WHERE id_sede = $1 AND eliminado = false
               ORDER BY costo ASC`
@@ -172,11 +185,12 @@ func (r *TipoPasajeRepository) ListBySede(idSede int) ([]*entidades.TipoPasaje,
 		tipoPasaje := &entidades.TipoPasaje{}
 		err := rows.Scan(
 			&tipoPasaje.ID, &tipoPasaje.IDSede, &tipoPasaje.IDTipoTour,
-			&tipoPasaje.Nombre, &tipoPasaje.Costo, &tipoPasaje.Edad, &tipoPasaje.Eliminado,
+			&tipoPasaje.Nombre, &tipoPasaje.Costo, &tipoPasaje.Moneda, &tipoPasaje.Edad, &tipoPasaje.Eliminado,
 		)
 		if err != nil {
 			return nil, err
 		}
+		tipoPasaje.Costo = tipoPasaje.Costo.EnMoneda(tipoPasaje.Moneda)
 		tiposPasaje = append(tiposPasaje, tipoPasaje)
 	}
 
@@ -189,7 +203,7 @@ func (r *TipoPasajeRepository) ListBySede(idSede int) ([]*entidades.TipoPasaje,
 
 // List lista todos los tipos de pasaje
 func (r *TipoPasajeRepository) List() ([]*entidades.TipoPasaje, error) {
-	query := `SELECT id_tipo_pasaje, id_sede, id_tipo_tour, nombre, costo, edad, eliminado
+	query := `SELECT id_tipo_pasaje, id_sede, id_tipo_tour, nombre, costo, moneda, edad, eliminado
               FROM tipo_pasaje
               WHERE eliminado = false
               ORDER BY id_sede, costo ASC`
@@ -206,11 +220,12 @@ func (r *TipoPasajeRepository) List() ([]*entidades.TipoPasaje, error) {
 		tipoPasaje := &entidades.TipoPasaje{}
 		err := rows.Scan(
 			&tipoPasaje.ID, &tipoPasaje.IDSede, &tipoPasaje.IDTipoTour,
-			&tipoPasaje.Nombre, &tipoPasaje.Costo, &tipoPasaje.Edad, &tipoPasaje.Eliminado,
+			&tipoPasaje.Nombre, &tipoPasaje.Costo, &tipoPasaje.Moneda, &tipoPasaje.Edad, &tipoPasaje.Eliminado,
 		)
 		if err != nil {
 			return nil, err
 		}
+		tipoPasaje.Costo = tipoPasaje.Costo.EnMoneda(tipoPasaje.Moneda)
 		tiposPasaje = append(tiposPasaje, tipoPasaje)
 	}
 
@@ -223,7 +238,7 @@ func (r *TipoPasajeRepository) List() ([]*entidades.TipoPasaje, error) {
 
 // ListByTipoTour lista todos los tipos de pasaje asociados a un tipo de tour específico
 func (r *TipoPasajeRepository) ListByTipoTour(idTipoTour int) ([]*entidades.TipoPasaje, error) {
-	query := `SELECT id_tipo_pasaje, id_sede, id_tipo_tour, nombre, costo, edad, eliminado
+	query := `SELECT id_tipo_pasaje, id_sede, id_tipo_tour, nombre, costo, moneda, edad, eliminado
               FROM tipo_pasaje
               WHERE id_tipo_tour = $1 AND eliminado = false
               ORDER BY costo ASC`
@@ -240,11 +255,12 @@ func (r *TipoPasajeRepository) ListByTipoTour(idTipoTour int) ([]*entidades.Tipo
 		tipoPasaje := &entidades.TipoPasaje{}
 		err := rows.Scan(
 			&tipoPasaje.ID, &tipoPasaje.IDSede, &tipoPasaje.IDTipoTour,
-			&tipoPasaje.Nombre, &tipoPasaje.Costo, &tipoPasaje.Edad, &tipoPasaje.Eliminado,
+			&tipoPasaje.Nombre, &tipoPasaje.Costo, &tipoPasaje.Moneda, &tipoPasaje.Edad, &tipoPasaje.Eliminado,
 		)
 		if err != nil {
 			return nil, err
 		}
+		tipoPasaje.Costo = tipoPasaje.Costo.EnMoneda(tipoPasaje.Moneda)
 		tiposPasaje = append(tiposPasaje, tipoPasaje)
 	}
 
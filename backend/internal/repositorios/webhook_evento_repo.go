@@ -0,0 +1,80 @@
+package repositorios
+
+import (
+	"database/sql"
+	"sistema-toursseft/internal/entidades"
+)
+
+// WebhookEventoRepository maneja las operaciones de base de datos para notificaciones de las pasarelas de pago
+type WebhookEventoRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookEventoRepository crea una nueva instancia del repositorio
+func NewWebhookEventoRepository(db *sql.DB) *WebhookEventoRepository {
+	return &WebhookEventoRepository{
+		db: db,
+	}
+}
+
+// Registrar guarda la recepción de una notificación y determina si debe procesarse
+// Retorna false cuando el mismo pago/estado de la pasarela ya fue procesado o lo está procesando otra petición
+func (r *WebhookEventoRepository) Registrar(evento *entidades.WebhookEventoPasarela) (int, bool, error) {
+	var id int
+	query := `INSERT INTO webhook_evento_pasarela (pasarela, id_pago_externo, estado_pago, id_notificacion, tipo, 
+              id_reserva, monto, resultado)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, 'PROCESANDO')
+              ON CONFLICT (pasarela, id_pago_externo, estado_pago) DO NOTHING
+              RETURNING id_evento`
+
+	err := r.db.QueryRow(
+		query,
+		evento.Pasarela,
+		evento.IDPagoExterno,
+		evento.EstadoPago,
+		evento.IDNotificacion,
+		evento.Tipo,
+		evento.IDReserva,
+		evento.Monto,
+	).Scan(&id)
+
+	if err == nil {
+		return id, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	// El evento ya existe: solo se vuelve a tomar si el intento anterior falló
+	// o si quedó colgado procesándose por demasiado tiempo
+	queryReintento := `UPDATE webhook_evento_pasarela SET 
+                       resultado = 'PROCESANDO', 
+                       id_notificacion = $3,
+                       fecha_recepcion = CURRENT_TIMESTAMP
+                       WHERE pasarela = $4 AND id_pago_externo = $1 AND estado_pago = $2
+                       AND (resultado = 'ERROR' 
+                            OR (resultado = 'PROCESANDO' AND fecha_recepcion < CURRENT_TIMESTAMP - INTERVAL '10 minutes'))
+                       RETURNING id_evento`
+
+	err = r.db.QueryRow(queryReintento, evento.IDPagoExterno, evento.EstadoPago, evento.IDNotificacion, evento.Pasarela).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return id, true, nil
+}
+
+// MarcarResultado registra el resultado final del procesamiento de una notificación
+func (r *WebhookEventoRepository) MarcarResultado(id int, resultado string, detalle string) error {
+	query := `UPDATE webhook_evento_pasarela SET 
+              resultado = $1, 
+              detalle = $2, 
+              fecha_procesado = CURRENT_TIMESTAMP
+              WHERE id_evento = $3`
+
+	_, err := r.db.Exec(query, resultado, detalle, id)
+	return err
+}
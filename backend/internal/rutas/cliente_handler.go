@@ -1,6 +1,7 @@
 package rutas
 
 import (
+	"fmt"
 	"net/http"
 	"sistema-toursseft/internal/entidades"
 	"sistema-toursseft/internal/servicios"
@@ -13,25 +14,28 @@ import (
 
 // ClienteHandlers contiene funciones de manejo específicas para clientes
 type ClienteHandlers struct {
-	reservaService     *servicios.ReservaService
-	clienteService     *servicios.ClienteService
-	mercadoPagoService *servicios.MercadoPagoService
-	baseURLProduccion  string
-	baseURLDesarrollo  string
+	reservaService    *servicios.ReservaService
+	clienteService    *servicios.ClienteService
+	pasarelas         *servicios.PasarelasPago
+	politicaService   *servicios.PoliticaCancelacionService
+	baseURLProduccion string
+	baseURLDesarrollo string
 }
 
 // NewClienteHandlers crea una nueva instancia de manejadores para clientes
 func NewClienteHandlers(
 	reservaService *servicios.ReservaService,
 	clienteService *servicios.ClienteService,
-	mercadoPagoService *servicios.MercadoPagoService,
+	pasarelas *servicios.PasarelasPago,
+	politicaService *servicios.PoliticaCancelacionService,
 ) *ClienteHandlers {
 	return &ClienteHandlers{
-		reservaService:     reservaService,
-		clienteService:     clienteService,
-		mercadoPagoService: mercadoPagoService,
-		baseURLProduccion:  "https://reservas.angelproyect.com",
-		baseURLDesarrollo:  "https://localhost:5174",
+		reservaService:    reservaService,
+		clienteService:    clienteService,
+		pasarelas:         pasarelas,
+		politicaService:   politicaService,
+		baseURLProduccion: "https://reservas.angelproyect.com",
+		baseURLDesarrollo: "https://localhost:5174",
 	}
 }
 
@@ -84,7 +88,7 @@ func (h *ClienteHandlers) determinarBaseURL(ctx *gin.Context) string {
 	return h.baseURLProduccion
 }
 
-// PagarReserva crea una preferencia de pago para una reserva existente
+// PagarReserva crea en la pasarela predeterminada el cobro de una reserva existente
 func (h *ClienteHandlers) PagarReserva(ctx *gin.Context) {
 	reservaID := ctx.Param("id")
 	clienteID := ctx.GetInt("userID")
@@ -116,20 +120,43 @@ func (h *ClienteHandlers) PagarReserva(ctx *gin.Context) {
 	// Usar la URL específica para el proceso de pago
 	frontendURL := baseURL + "/proceso-pago"
 
-	// Crear la preferencia de pago para esta reserva
-	response, err := h.mercadoPagoService.GeneratePreferenceForExistingReserva(
-		id, reserva.TotalPagar, cliente, frontendURL)
+	// Crear el cobro de esta reserva
+	checkout, err := h.pasarelas.Predeterminada().CrearCheckout(&entidades.SolicitudCheckout{
+		IDReserva:   id,
+		Descripcion: reserva.NombreTour,
+		Monto:       reserva.TotalPagar,
+		Cliente:     cliente,
+		FrontendURL: frontendURL,
+	})
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al generar preferencia de pago", err))
 		return
 	}
 
+	// Registrar la pasarela del cobro para conciliar luego sus pagos
+	if err := h.reservaService.AsignarPasarela(id, checkout.Pasarela); err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al registrar la pasarela de la reserva", err))
+		return
+	}
+
+	response := &entidades.ReservaMercadoPagoResponse{
+		IDReserva:        id,
+		NombreTour:       reserva.NombreTour,
+		Pasarela:         checkout.Pasarela,
+		PreferenceID:     checkout.ID,
+		InitPoint:        checkout.URLPago,
+		SandboxInitPoint: checkout.URLPagoPrueba,
+		ClavePublica:     checkout.ClavePublica,
+		Monto:            reserva.TotalPagar,
+		Moneda:           reserva.TotalPagar.Moneda(),
+	}
+
 	ctx.JSON(http.StatusOK, utils.SuccessResponse("Preferencia de pago generada exitosamente", response))
 }
 
-// CancelarReserva cancela una reserva de un cliente
-func (h *ClienteHandlers) CancelarReserva(ctx *gin.Context) {
+// CotizarCancelacion muestra al cliente cuánto se le devolvería si cancela su reserva ahora
+func (h *ClienteHandlers) CotizarCancelacion(ctx *gin.Context) {
 	reservaID := ctx.Param("id")
 	clienteID := ctx.GetInt("userID")
 
@@ -147,13 +174,37 @@ func (h *ClienteHandlers) CancelarReserva(ctx *gin.Context) {
 		return
 	}
 
-	// Crear el request para cambiar estado
-	estadoReq := entidades.CambiarEstadoReservaRequest{
-		Estado: "CANCELADA",
+	cotizacion, err := h.politicaService.CotizarCancelacion(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al cotizar la cancelación", err))
+		return
 	}
 
-	// Actualizar estado directamente con el servicio
-	err = h.reservaService.CambiarEstado(id, estadoReq.Estado)
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Cotización de cancelación calculada", cotizacion))
+}
+
+// CancelarReserva cancela una reserva de un cliente aplicando la política de cancelación
+// La devolución que corresponda queda pendiente de aprobación
+func (h *ClienteHandlers) CancelarReserva(ctx *gin.Context) {
+	reservaID := ctx.Param("id")
+	clienteID := ctx.GetInt("userID")
+
+	// Obtener la reserva
+	id, _ := strconv.Atoi(reservaID)
+	reserva, err := h.reservaService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Reserva no encontrada", err))
+		return
+	}
+
+	// Verificar que la reserva pertenece al cliente
+	if reserva.IDCliente != clienteID {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene acceso a esta reserva", nil))
+		return
+	}
+
+	// Cancelar aplicando la política vigente
+	cotizacion, err := h.politicaService.CancelarReserva(id)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al cancelar la reserva", err))
 		return
@@ -166,7 +217,66 @@ func (h *ClienteHandlers) CancelarReserva(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reserva cancelada exitosamente", reservaActualizada))
+	mensaje := "Reserva cancelada exitosamente"
+	if cotizacion.MontoDevolucion.EsPositivo() {
+		mensaje = fmt.Sprintf("Reserva cancelada exitosamente. Devolución de S/ %s (%.2f%%) pendiente de aprobación",
+			cotizacion.MontoDevolucion, cotizacion.PorcentajeDevolucion)
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse(mensaje, reservaActualizada))
+}
+
+// ReprogramarReserva permite al cliente mover su reserva a otra fecha del mismo tour
+// Solo se permite mientras la política de cancelación le devolvería el total pagado,
+// así reprogramar no sirve para evitar la penalidad de una cancelación tardía
+func (h *ClienteHandlers) ReprogramarReserva(ctx *gin.Context) {
+	reservaID := ctx.Param("id")
+	clienteID := ctx.GetInt("userID")
+
+	// Obtener la reserva
+	id, _ := strconv.Atoi(reservaID)
+	reserva, err := h.reservaService.GetByID(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, utils.ErrorResponse("Reserva no encontrada", err))
+		return
+	}
+
+	// Verificar que la reserva pertenece al cliente
+	if reserva.IDCliente != clienteID {
+		ctx.JSON(http.StatusForbidden, utils.ErrorResponse("No tiene acceso a esta reserva", nil))
+		return
+	}
+
+	var reprogramarReq entidades.ReprogramarReservaRequest
+	if err := ctx.ShouldBindJSON(&reprogramarReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Datos inválidos", err))
+		return
+	}
+
+	if err := utils.ValidateStruct(reprogramarReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error de validación", err))
+		return
+	}
+
+	// Verificar los límites de la política de cancelación
+	cotizacion, err := h.politicaService.CotizarCancelacion(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse("Error al verificar la política de cancelación", err))
+		return
+	}
+
+	if !cotizacion.Permitida || cotizacion.PorcentajeDevolucion < 100 {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("La reserva ya no se puede reprogramar, comuníquese con la agencia", nil))
+		return
+	}
+
+	resultado, err := h.reservaService.Reprogramar(id, &reprogramarReq, "CLIENTE", nil)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse("Error al reprogramar la reserva", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("Reserva reprogramada exitosamente", resultado))
 }
 
 // ReservarConMercadoPago es un wrapper para el controlador de reservas con Mercado Pago
@@ -42,15 +42,30 @@ func SetupRoutes(
 	sedeController *controladores.SedeController,
 	instanciaTourController *controladores.InstanciaTourController, // Nuevo controlador
 	mercadoPagoController *controladores.MercadoPagoController, // Añadido aquí
+	devolucionPagoController *controladores.DevolucionPagoController,
+	politicaCancelacionController *controladores.PoliticaCancelacionController,
+	listaEsperaController *controladores.ListaEsperaController,
+	pasajeroController *controladores.PasajeroController,
+	embarqueController *controladores.EmbarqueController,
+	comprobanteElectronicoController *controladores.ComprobanteElectronicoController,
+	serieComprobanteController *controladores.SerieComprobanteController,
+	documentoImpresoController *controladores.DocumentoImpresoController,
+	impuestoController *controladores.ImpuestoController,
+	codigoPromocionalController *controladores.CodigoPromocionalController,
+	reglaPrecioController *controladores.ReglaPrecioController,
+	tipoCambioController *controladores.TipoCambioController,
+	cajaController *controladores.CajaController,
+	conciliacionPagoController *controladores.ConciliacionPagoController,
 
 	// Servicios necesarios para acceso directo en rutas
 	reservaService *servicios.ReservaService,
 	clienteService *servicios.ClienteService,
-	mercadoPagoService *servicios.MercadoPagoService,
+	pasarelasPago *servicios.PasarelasPago,
+	politicaCancelacionService *servicios.PoliticaCancelacionService,
 
 ) {
 
-	clienteHandlers := NewClienteHandlers(reservaService, clienteService, mercadoPagoService)
+	clienteHandlers := NewClienteHandlers(reservaService, clienteService, pasarelasPago, politicaCancelacionService)
 
 	// Middleware global
 	router.Use(middleware.LoggerMiddleware())
@@ -151,9 +166,17 @@ func SetupRoutes(
 
 		// Webhook para recibir notificaciones de Mercado Pago
 		public.POST("/webhook/mercadopago", reservaController.WebhookMercadoPago)
+		public.POST("/webhook/:pasarela", reservaController.WebhookPasarela)
+		public.GET("/pago-simulado/:checkout", reservaController.SimularPagoPasarela) // Solo con PASARELA_PAGO=FAKE
 
 		// Verificar disponibilidad de instancia
 		public.GET("/instancias-tour/:idInstancia/verificar-disponibilidad", reservaController.VerificarDisponibilidadInstancia)
+
+		// Precio efectivo de los pasajes de una instancia (temporada, día, horario y ocupación)
+		public.GET("/instancias-tour/:idInstancia/precios", reglaPrecioController.PreciosInstancia)
+
+		// Cotizar una reserva con los precios vigentes antes de pagar
+		public.POST("/reservas/cotizar", reservaController.Cotizar)
 		// En la sección de rutas públicas (public)
 		public.GET("/mercadopago/public-key", mercadoPagoController.GetPublicKey)
 	}
@@ -343,9 +366,22 @@ func SetupRoutes(
 			admin.GET("/pagos/fecha/:fecha", pagoController.ListByFecha)
 			admin.GET("/pagos/estado/:estado", pagoController.ListByEstado)
 			admin.GET("/pagos/reserva/:idReserva/total", pagoController.GetTotalPagadoByReserva)
+			admin.GET("/pagos/reserva/:idReserva/saldo", pagoController.GetSaldoByReserva)
 			admin.GET("/pagos/cliente/:idCliente", pagoController.ListByCliente)
 			admin.GET("/pagos/sede/:idSede", pagoController.ListBySede)
 
+			// Gestión de devoluciones de pagos (solicitud, aprobación y ejecución)
+			admin.POST("/devoluciones", devolucionPagoController.Create)
+			admin.GET("/devoluciones", devolucionPagoController.List)
+			admin.GET("/devoluciones/:id", devolucionPagoController.GetByID)
+			admin.POST("/devoluciones/:id/aprobar", devolucionPagoController.Aprobar)
+			admin.POST("/devoluciones/:id/rechazar", devolucionPagoController.Rechazar)
+			admin.POST("/devoluciones/:id/ejecutar", devolucionPagoController.Ejecutar)
+			admin.GET("/devoluciones/sede/:idSede", devolucionPagoController.ListBySede)
+			admin.GET("/devoluciones/fecha/:fecha", devolucionPagoController.ListByFecha)
+			admin.GET("/devoluciones/pago/:idPago", devolucionPagoController.ListByPago)
+			admin.GET("/devoluciones/estado/:estado", devolucionPagoController.ListByEstado)
+
 			// Gestión de comprobantes de pago
 			admin.POST("/comprobantes", comprobantePagoController.Create)
 			admin.GET("/comprobantes", comprobantePagoController.List)
@@ -359,10 +395,31 @@ func SetupRoutes(
 			admin.GET("/comprobantes/tipo/:tipo", comprobantePagoController.ListByTipo)
 			admin.GET("/comprobantes/estado/:estado", comprobantePagoController.ListByEstado)
 			admin.GET("/comprobantes/cliente/:idCliente", comprobantePagoController.ListByCliente)
+			admin.POST("/comprobantes/:id/notas", comprobantePagoController.CrearNota)
+			admin.GET("/comprobantes/:id/notas", comprobantePagoController.ListNotas)
+
+			// Facturación electrónica SUNAT
+			admin.POST("/comprobantes/:id/emitir-electronico", comprobanteElectronicoController.Emitir)
+			admin.GET("/comprobantes/:id/electronico", comprobanteElectronicoController.GetByComprobante)
+			admin.GET("/comprobantes/:id/xml", comprobanteElectronicoController.DescargarXML)
+			admin.GET("/comprobantes/:id/cdr", comprobanteElectronicoController.DescargarCDR)
+			admin.GET("/comprobantes/:id/pdf", documentoImpresoController.ComprobantePDF)
+
+			// Series de numeración de comprobantes
+			admin.POST("/series-comprobante", serieComprobanteController.Create)
+			admin.GET("/series-comprobante", serieComprobanteController.ListUso)
+			admin.PUT("/series-comprobante/:id/estado", serieComprobanteController.CambiarEstado)
+
+			// Configuración de impuestos por sede
+			admin.GET("/impuestos/sedes", impuestoController.ListConfiguraciones)
+			admin.GET("/impuestos/sedes/:id", impuestoController.GetConfiguracion)
+			admin.PUT("/impuestos/sedes/:id", impuestoController.ActualizarConfiguracion)
 
 			// Gestión de sedes
 			admin.POST("/sedes", sedeController.Create)
 			admin.PUT("/sedes/:id", sedeController.Update)
+			admin.PUT("/sedes/:id/retencion-reserva", sedeController.UpdateRetencionReserva)
+			admin.PUT("/sedes/:id/adelanto-reserva", sedeController.UpdateAdelantoReserva)
 			admin.DELETE("/sedes/:id", sedeController.Delete)
 			admin.POST("/sedes/:id/restore", sedeController.Restore)
 			admin.GET("/sedes", sedeController.List)
@@ -373,6 +430,8 @@ func SetupRoutes(
 			admin.POST("/instancias-tour", instanciaTourController.Create)
 			admin.GET("/instancias-tour", instanciaTourController.List)
 			admin.GET("/instancias-tour/:id", instanciaTourController.GetByID)
+			admin.GET("/instancias-tour/:id/manifiesto", pasajeroController.GetManifiesto)
+			admin.GET("/instancias-tour/:id/no-show", embarqueController.GetReporteNoShow)
 			admin.PUT("/instancias-tour/:id", instanciaTourController.Update)
 			admin.DELETE("/instancias-tour/:id", instanciaTourController.Delete)
 			admin.POST("/instancias-tour/:id/asignar-chofer", instanciaTourController.AsignarChofer)
@@ -381,16 +440,80 @@ func SetupRoutes(
 			admin.POST("/instancias-tour/generar/:id_tour_programado", instanciaTourController.GenerarInstanciasDeTourProgramado)
 
 			admin.POST("/reservas", reservaController.Create)
+			admin.POST("/reservas/cotizar", reservaController.Cotizar)
 			admin.GET("/reservas", reservaController.List)
 			admin.GET("/reservas/:id", reservaController.GetByID)
 			admin.PUT("/reservas/:id", reservaController.Update)
 			admin.DELETE("/reservas/:id", reservaController.Delete)
 			admin.POST("/reservas/:id/estado", reservaController.CambiarEstado)
+			admin.POST("/reservas/:id/reprogramar", reservaController.Reprogramar)
+			admin.GET("/reservas/:id/reprogramaciones", reservaController.ListReprogramaciones)
+			admin.PUT("/reservas/:id/pasajeros", pasajeroController.RegistrarPasajeros)
+			admin.GET("/reservas/:id/pasajeros", pasajeroController.ListByReserva)
+			admin.GET("/reservas/:id/pase-abordar", embarqueController.GetPaseAbordar)
+			admin.GET("/reservas/:id/voucher", documentoImpresoController.VoucherReservaPDF)
+			admin.GET("/reservas/:id/impuestos", impuestoController.CalcularReserva)
 			admin.GET("/reservas/cliente/:idCliente", reservaController.ListByCliente)
 			admin.GET("/reservas/instancia/:idInstancia", reservaController.ListByInstancia)
 			admin.GET("/reservas/fecha/:fecha", reservaController.ListByFecha)
 			admin.GET("/reservas/estado/:estado", reservaController.ListByEstado)
 			admin.GET("/reservas/sede/:idSede", reservaController.ListBySede)
+			admin.GET("/reservas/saldos-pendientes", reservaController.ListSaldosPendientes)
+			admin.GET("/reservas/:id/cotizar-cancelacion", politicaCancelacionController.CotizarCancelacion)
+
+			// Lista de espera de instancias agotadas
+			admin.POST("/lista-espera", listaEsperaController.Create)
+			admin.GET("/lista-espera/:id", listaEsperaController.GetByID)
+			admin.GET("/lista-espera/instancia/:idInstancia", listaEsperaController.ListByInstancia)
+			admin.POST("/lista-espera/:id/cancelar", listaEsperaController.Cancelar)
+			admin.POST("/lista-espera/:id/convertir", listaEsperaController.Convertir)
+
+			// Gestión de políticas de cancelación (por tipo de tour o por sede)
+			admin.POST("/politicas-cancelacion", politicaCancelacionController.Create)
+			admin.GET("/politicas-cancelacion", politicaCancelacionController.List)
+			admin.GET("/politicas-cancelacion/:id", politicaCancelacionController.GetByID)
+			admin.PUT("/politicas-cancelacion/:id", politicaCancelacionController.Update)
+			admin.DELETE("/politicas-cancelacion/:id", politicaCancelacionController.Delete)
+			admin.GET("/politicas-cancelacion/tipo-tour/:idTipoTour", politicaCancelacionController.ListByTipoTour)
+			admin.GET("/politicas-cancelacion/sede/:idSede", politicaCancelacionController.ListBySede)
+
+			// Códigos promocionales
+			admin.POST("/codigos-promocionales", codigoPromocionalController.Create)
+			admin.GET("/codigos-promocionales", codigoPromocionalController.List)
+			admin.GET("/codigos-promocionales/reporte", codigoPromocionalController.ListReportes)
+			admin.GET("/codigos-promocionales/:id", codigoPromocionalController.GetByID)
+			admin.PUT("/codigos-promocionales/:id", codigoPromocionalController.Update)
+			admin.DELETE("/codigos-promocionales/:id", codigoPromocionalController.Delete)
+			admin.GET("/codigos-promocionales/:id/canjes", codigoPromocionalController.ListCanjes)
+
+			// Reglas de precio por temporada, día, horario y ocupación
+			admin.POST("/reglas-precio", reglaPrecioController.Create)
+			admin.GET("/reglas-precio", reglaPrecioController.List)
+			admin.GET("/reglas-precio/reserva/:idReserva", reglaPrecioController.ListAplicadasByReserva)
+			admin.GET("/reglas-precio/:id", reglaPrecioController.GetByID)
+			admin.PUT("/reglas-precio/:id", reglaPrecioController.Update)
+			admin.DELETE("/reglas-precio/:id", reglaPrecioController.Delete)
+
+			// Tipos de cambio y reporte de ingresos por moneda
+			admin.POST("/tipos-cambio", tipoCambioController.Registrar)
+			admin.POST("/tipos-cambio/importar", tipoCambioController.Importar)
+			admin.GET("/tipos-cambio", tipoCambioController.List)
+			admin.GET("/tipos-cambio/vigente", tipoCambioController.GetVigente)
+			admin.DELETE("/tipos-cambio/:id", tipoCambioController.Delete)
+			admin.GET("/reportes/ingresos-moneda", tipoCambioController.ReporteIngresosMoneda)
+
+			// Cajas: la propia del administrador, el cierre de cajas olvidadas y el reporte de cierres
+			admin.POST("/caja/abrir", cajaController.Abrir)
+			admin.GET("/caja/actual", cajaController.GetAbierta)
+			admin.POST("/caja/cerrar", cajaController.CerrarAbierta)
+			admin.GET("/cajas/:id", cajaController.GetByID)
+			admin.POST("/cajas/:id/cerrar", cajaController.Cerrar)
+			admin.GET("/reportes/cierres-caja", cajaController.ReporteCierres)
+
+			// Conciliación de pagos en línea con las pasarelas y reporte de diferencias para finanzas
+			admin.POST("/conciliaciones-pago", conciliacionPagoController.Ejecutar)
+			admin.GET("/conciliaciones-pago/:id", conciliacionPagoController.GetByID)
+			admin.GET("/reportes/conciliacion-pagos", conciliacionPagoController.Reporte)
 
 			// Confirmación manual de pagos con Mercado Pago
 			admin.POST("/reservas/confirmar-pago", reservaController.ConfirmarPagoReserva)
@@ -448,6 +571,14 @@ func SetupRoutes(
 			vendedor.GET("/paquetes-pasajes/sede/:id_sede", paquetePasajesController.ListBySede)
 			vendedor.GET("/paquetes-pasajes/tipo-tour/:id_tipo_tour", paquetePasajesController.ListByTipoTour)
 
+			// Tipo de cambio del día
+			vendedor.GET("/tipos-cambio/vigente", tipoCambioController.GetVigente)
+
+			// Caja del vendedor: se abre antes de cobrar y se cierra con lo contado
+			vendedor.POST("/caja/abrir", cajaController.Abrir)
+			vendedor.GET("/caja/actual", cajaController.GetAbierta)
+			vendedor.POST("/caja/cerrar", cajaController.CerrarAbierta)
+
 			// Ver métodos de pago (solo lectura)
 			vendedor.GET("/metodos-pago", metodoPagoController.List)
 			vendedor.GET("/metodos-pago/:id", metodoPagoController.GetByID)
@@ -480,6 +611,8 @@ func SetupRoutes(
 			// Ver instancias de tour (solo lectura)
 			vendedor.GET("/instancias-tour", instanciaTourController.List)
 			vendedor.GET("/instancias-tour/:id", instanciaTourController.GetByID)
+			vendedor.GET("/instancias-tour/:id/manifiesto", pasajeroController.GetManifiesto)
+			vendedor.GET("/instancias-tour/:id/no-show", embarqueController.GetReporteNoShow)
 			vendedor.GET("/instancias-tour/tour-programado/:id_tour_programado", instanciaTourController.ListByTourProgramado)
 			vendedor.POST("/instancias-tour/filtrar", instanciaTourController.ListByFiltros)
 
@@ -489,24 +622,57 @@ func SetupRoutes(
 			vendedor.GET("/pagos/:id", pagoController.GetByID)
 			vendedor.GET("/pagos/reserva/:idReserva", pagoController.ListByReserva)
 			vendedor.GET("/pagos/reserva/:idReserva/total", pagoController.GetTotalPagadoByReserva)
+			vendedor.GET("/pagos/reserva/:idReserva/saldo", pagoController.GetSaldoByReserva)
 			vendedor.GET("/pagos/sede/:idSede", pagoController.ListBySede)
 
+			// Gestión de devoluciones (vendedor solicita y ejecuta las aprobadas de su sede)
+			vendedor.POST("/devoluciones", devolucionPagoController.Create)
+			vendedor.GET("/devoluciones", devolucionPagoController.List)
+			vendedor.GET("/devoluciones/:id", devolucionPagoController.GetByID)
+			vendedor.POST("/devoluciones/:id/ejecutar", devolucionPagoController.Ejecutar)
+			vendedor.GET("/devoluciones/fecha/:fecha", devolucionPagoController.ListByFecha)
+			vendedor.GET("/devoluciones/pago/:idPago", devolucionPagoController.ListByPago)
+
 			// Gestión de comprobantes (vendedor puede emitir y ver comprobantes)
 			vendedor.POST("/comprobantes", comprobantePagoController.Create)
 			vendedor.GET("/comprobantes", comprobantePagoController.List)
 			vendedor.GET("/comprobantes/:id", comprobantePagoController.GetByID)
 			vendedor.GET("/comprobantes/buscar", comprobantePagoController.GetByTipoAndNumero)
 			vendedor.GET("/comprobantes/reserva/:idReserva", comprobantePagoController.ListByReserva)
+			vendedor.POST("/comprobantes/:id/notas", comprobantePagoController.CrearNota)
+			vendedor.GET("/comprobantes/:id/notas", comprobantePagoController.ListNotas)
+			vendedor.POST("/comprobantes/:id/emitir-electronico", comprobanteElectronicoController.Emitir)
+			vendedor.GET("/comprobantes/:id/electronico", comprobanteElectronicoController.GetByComprobante)
+			vendedor.GET("/comprobantes/:id/xml", comprobanteElectronicoController.DescargarXML)
+			vendedor.GET("/comprobantes/:id/cdr", comprobanteElectronicoController.DescargarCDR)
+			vendedor.GET("/comprobantes/:id/pdf", documentoImpresoController.ComprobantePDF)
 			//reservas mercado pago
 			vendedor.POST("/reservas", reservaController.Create)
+			vendedor.POST("/reservas/cotizar", reservaController.Cotizar)
 			vendedor.GET("/reservas", reservaController.List)
 			vendedor.GET("/reservas/:id", reservaController.GetByID)
 			vendedor.PUT("/reservas/:id", reservaController.Update)
 			vendedor.POST("/reservas/:id/estado", reservaController.CambiarEstado)
+			vendedor.POST("/reservas/:id/reprogramar", reservaController.Reprogramar)
+			vendedor.GET("/reservas/:id/reprogramaciones", reservaController.ListReprogramaciones)
+			vendedor.PUT("/reservas/:id/pasajeros", pasajeroController.RegistrarPasajeros)
+			vendedor.GET("/reservas/:id/pasajeros", pasajeroController.ListByReserva)
+			vendedor.GET("/reservas/:id/pase-abordar", embarqueController.GetPaseAbordar)
+			vendedor.GET("/reservas/:id/voucher", documentoImpresoController.VoucherReservaPDF)
+			vendedor.GET("/reservas/:id/impuestos", impuestoController.CalcularReserva)
+			vendedor.POST("/lista-espera", listaEsperaController.Create)
+			vendedor.GET("/lista-espera/:id", listaEsperaController.GetByID)
+			vendedor.GET("/lista-espera/instancia/:idInstancia", listaEsperaController.ListByInstancia)
+			vendedor.POST("/lista-espera/:id/cancelar", listaEsperaController.Cancelar)
+			vendedor.POST("/lista-espera/:id/convertir", listaEsperaController.Convertir)
 			vendedor.GET("/reservas/cliente/:idCliente", reservaController.ListByCliente)
 			vendedor.GET("/reservas/instancia/:idInstancia", reservaController.ListByInstancia)
 			vendedor.GET("/reservas/fecha/:fecha", reservaController.ListByFecha)
 			vendedor.GET("/reservas/estado/:estado", reservaController.ListByEstado)
+			vendedor.GET("/reservas/saldos-pendientes", reservaController.ListSaldosPendientes)
+			vendedor.GET("/reservas/:id/cotizar-cancelacion", politicaCancelacionController.CotizarCancelacion)
+			vendedor.GET("/politicas-cancelacion/tipo-tour/:idTipoTour", politicaCancelacionController.ListByTipoTour)
+			vendedor.GET("/politicas-cancelacion/sede/:idSede", politicaCancelacionController.ListBySede)
 
 			// Confirmación manual de pagos con Mercado Pago
 			vendedor.POST("/reservas/confirmar-pago", reservaController.ConfirmarPagoReserva)
@@ -562,8 +728,19 @@ func SetupRoutes(
 				instanciaTourController.ListByFiltros(ctx)
 			})
 
+			// Manifiesto de pasajeros de una instancia asignada (json, csv o pdf)
+			chofer.GET("/mis-instancias-tour/:id/manifiesto", pasajeroController.GetManifiesto)
+
+			// Check-in en el muelle escaneando el QR del pase de abordar
+			chofer.POST("/check-in", embarqueController.Checkin)
+			chofer.GET("/mis-instancias-tour/:id/no-show", embarqueController.GetReporteNoShow)
+
+			// Validación sin conexión: paquete firmado del día y sincronización de escaneos
+			chofer.GET("/paquete-offline", embarqueController.GetPaqueteOffline)
+			chofer.POST("/check-in/sincronizar", embarqueController.SincronizarEmbarques)
+
 		}
-		clienteHandlers := NewClienteHandlers(reservaService, clienteService, mercadoPagoService)
+		clienteHandlers := NewClienteHandlers(reservaService, clienteService, pasarelasPago, politicaCancelacionService)
 
 		// Clientes
 		cliente := protected.Group("/cliente")
@@ -640,6 +817,7 @@ func SetupRoutes(
 				ctx.Request.URL.Path = "/api/v1/admin/comprobantes/cliente/" + strconv.Itoa(clienteID)
 				router.HandleContext(ctx)
 			})
+			cliente.GET("/mis-comprobantes/:id/pdf", documentoImpresoController.ComprobantePDF)
 
 			// Ver mis reservas
 			// Ver mis reservas
@@ -668,8 +846,28 @@ func SetupRoutes(
 			})
 
 			// Cancelar una reserva - usando el handler personalizado
+			cliente.GET("/mis-reservas/:id/cotizar-cancelacion", clienteHandlers.CotizarCancelacion)
 			cliente.POST("/mis-reservas/:id/cancelar", clienteHandlers.CancelarReserva)
 
+			// Reprogramar una reserva a otra fecha dentro del plazo sin penalidad
+			cliente.POST("/mis-reservas/:id/reprogramar", clienteHandlers.ReprogramarReserva)
+
+			// Registrar los datos de los pasajeros antes de la salida
+			cliente.PUT("/mis-reservas/:id/pasajeros", pasajeroController.RegistrarPasajeros)
+			cliente.GET("/mis-reservas/:id/pasajeros", pasajeroController.ListByReserva)
+
+			// Pase de abordar con QR de una reserva confirmada
+			cliente.GET("/mis-reservas/:id/pase-abordar", embarqueController.GetPaseAbordar)
+
+			// Voucher de la reserva en PDF
+			cliente.GET("/mis-reservas/:id/voucher", documentoImpresoController.VoucherReservaPDF)
+
+			// Lista de espera de instancias agotadas
+			cliente.POST("/lista-espera", listaEsperaController.CreateMine)
+			cliente.GET("/mis-listas-espera", listaEsperaController.ListMine)
+			cliente.POST("/mis-listas-espera/:id/cancelar", listaEsperaController.CancelarMine)
+			cliente.POST("/mis-listas-espera/:id/convertir", listaEsperaController.ConvertirMine)
+
 			// Pagar una reserva con Mercado Pago - usando el handler personalizado
 			cliente.POST("/mis-reservas/:id/pagar", clienteHandlers.PagarReserva)
 		}
@@ -0,0 +1,168 @@
+package servicios
+
+import (
+	"errors"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"time"
+)
+
+// CajaService maneja la lógica de negocio para la apertura, el cierre y el cuadre de las cajas
+type CajaService struct {
+	cajaRepo       *repositorios.CajaRepository
+	usuarioRepo    *repositorios.UsuarioRepository
+	sedeRepo       *repositorios.SedeRepository
+	metodoPagoRepo *repositorios.MetodoPagoRepository
+}
+
+// NewCajaService crea una nueva instancia de CajaService
+func NewCajaService(
+	cajaRepo *repositorios.CajaRepository,
+	usuarioRepo *repositorios.UsuarioRepository,
+	sedeRepo *repositorios.SedeRepository,
+	metodoPagoRepo *repositorios.MetodoPagoRepository,
+) *CajaService {
+	return &CajaService{
+		cajaRepo:       cajaRepo,
+		usuarioRepo:    usuarioRepo,
+		sedeRepo:       sedeRepo,
+		metodoPagoRepo: metodoPagoRepo,
+	}
+}
+
+// Abrir abre la caja de un usuario en su sede con el fondo inicial indicado
+func (s *CajaService) Abrir(idUsuario int, caja *entidades.AbrirCajaRequest) (int, error) {
+	usuario, err := s.usuarioRepo.GetByID(idUsuario)
+	if err != nil {
+		return 0, errors.New("el usuario especificado no existe")
+	}
+
+	// La caja se abre en la sede del usuario; los usuarios sin sede indican en cuál cobran
+	idSede := caja.IDSede
+	if usuario.IdSede != nil {
+		idSede = *usuario.IdSede
+	}
+	if idSede == 0 {
+		return 0, errors.New("debe indicar la sede de la caja")
+	}
+
+	// Verificar que la sede existe
+	_, err = s.sedeRepo.GetByID(idSede)
+	if err != nil {
+		return 0, errors.New("la sede especificada no existe")
+	}
+
+	// Verificar que el método de efectivo existe y es de la sede
+	metodo, err := s.metodoPagoRepo.GetByID(caja.IDMetodoEfectivo)
+	if err != nil {
+		return 0, errors.New("el método de pago especificado no existe")
+	}
+	if metodo.IDSede != idSede {
+		return 0, errors.New("el método de pago especificado no pertenece a la sede de la caja")
+	}
+
+	if caja.MontoInicial.EsNegativo() {
+		return 0, errors.New("el fondo inicial no puede ser negativo")
+	}
+	caja.MontoInicial = caja.MontoInicial.EnMoneda(entidades.MonedaPorDefecto)
+
+	return s.cajaRepo.Abrir(idUsuario, idSede, caja)
+}
+
+// GetByID obtiene una caja por su ID; si sigue abierta incluye lo esperado hasta el momento
+func (s *CajaService) GetByID(id int) (*entidades.CajaSesion, error) {
+	caja, err := s.cajaRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if caja.Estado == entidades.EstadoCajaAbierta {
+		if err := s.cargarEsperado(caja); err != nil {
+			return nil, err
+		}
+	}
+
+	return caja, nil
+}
+
+// GetAbierta obtiene la caja abierta de un usuario con lo esperado hasta el momento
+func (s *CajaService) GetAbierta(idUsuario int) (*entidades.CajaSesion, error) {
+	caja, err := s.cajaRepo.GetAbiertaByUsuario(idUsuario)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cargarEsperado(caja); err != nil {
+		return nil, err
+	}
+
+	return caja, nil
+}
+
+// cargarEsperado calcula lo que debería haber en una caja abierta por método de pago y moneda
+func (s *CajaService) cargarEsperado(caja *entidades.CajaSesion) error {
+	movimientos, err := s.cajaRepo.ListMovimientos(caja.ID)
+	if err != nil {
+		return err
+	}
+
+	caja.Detalle, err = entidades.CalcularCierreCaja(movimientos, caja.MontoInicial, caja.IDMetodoEfectivo, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, detalle := range caja.Detalle {
+		detalle.IDCajaSesion = caja.ID
+		if metodo, err := s.metodoPagoRepo.GetByID(detalle.IDMetodoPago); err == nil {
+			detalle.NombreMetodoPago = metodo.Nombre
+		}
+	}
+
+	return nil
+}
+
+// CerrarAbierta cierra la caja abierta de un usuario con los montos contados
+func (s *CajaService) CerrarAbierta(idUsuario int, cierre *entidades.CerrarCajaRequest) (*entidades.CajaSesion, error) {
+	caja, err := s.cajaRepo.GetAbiertaByUsuario(idUsuario)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Cerrar(caja.ID, cierre)
+}
+
+// Cerrar cierra una caja con los montos contados y registra las diferencias por método de pago y moneda
+func (s *CajaService) Cerrar(id int, cierre *entidades.CerrarCajaRequest) (*entidades.CajaSesion, error) {
+	for _, conteo := range cierre.Conteos {
+		if conteo.MontoContado.EsNegativo() {
+			return nil, errors.New("los montos contados no pueden ser negativos")
+		}
+	}
+
+	_, err := s.cajaRepo.Cerrar(id, cierre)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.cajaRepo.GetByID(id)
+}
+
+// ReporteCierres presenta las cajas cerradas en un periodo agrupadas por sede y día, con sus totales por moneda
+// Si idSede es nil se incluyen todas las sedes
+func (s *CajaService) ReporteCierres(fechaInicio time.Time, fechaFin time.Time, idSede *int) (*entidades.ReporteCierresCaja, error) {
+	if fechaFin.Before(fechaInicio) {
+		return nil, errors.New("la fecha de fin debe ser posterior a la fecha de inicio")
+	}
+
+	cierres, err := s.cajaRepo.ListCerradas(fechaInicio, fechaFin, idSede)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entidades.ReporteCierresCaja{
+		FechaInicio: fechaInicio,
+		FechaFin:    fechaFin,
+		IDSede:      idSede,
+		Dias:        entidades.AgruparCierresCaja(cierres),
+	}, nil
+}
@@ -0,0 +1,211 @@
+package servicios
+
+import (
+	"errors"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+)
+
+// CodigoPromocionalService maneja la lógica de negocio para códigos promocionales y su canje
+type CodigoPromocionalService struct {
+	codigoRepo *repositorios.CodigoPromocionalRepository
+}
+
+// NewCodigoPromocionalService crea una nueva instancia de CodigoPromocionalService
+func NewCodigoPromocionalService(codigoRepo *repositorios.CodigoPromocionalRepository) *CodigoPromocionalService {
+	return &CodigoPromocionalService{
+		codigoRepo: codigoRepo,
+	}
+}
+
+// Create crea un nuevo código promocional
+func (s *CodigoPromocionalService) Create(codigo *entidades.NuevoCodigoPromocionalRequest) (int, error) {
+	// Verificar que el código no esté en uso
+	existe, err := s.codigoRepo.ExisteCodigo(codigo.Codigo, 0)
+	if err != nil {
+		return 0, err
+	}
+	if existe {
+		return 0, errors.New("ya existe un código promocional con ese texto")
+	}
+
+	return s.codigoRepo.Create(codigo)
+}
+
+// GetByID obtiene un código promocional por su ID
+func (s *CodigoPromocionalService) GetByID(id int) (*entidades.CodigoPromocional, error) {
+	return s.codigoRepo.GetByID(id)
+}
+
+// Update actualiza un código promocional
+func (s *CodigoPromocionalService) Update(id int, codigo *entidades.ActualizarCodigoPromocionalRequest) error {
+	// Verificar que el código existe
+	_, err := s.codigoRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	// Verificar que el nuevo texto no esté en uso por otro código
+	existe, err := s.codigoRepo.ExisteCodigo(codigo.Codigo, id)
+	if err != nil {
+		return err
+	}
+	if existe {
+		return errors.New("ya existe un código promocional con ese texto")
+	}
+
+	return s.codigoRepo.Update(id, codigo)
+}
+
+// Delete elimina un código promocional
+// Los canjes registrados se conservan para el reporte
+func (s *CodigoPromocionalService) Delete(id int) error {
+	// Verificar que el código existe
+	_, err := s.codigoRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	return s.codigoRepo.Delete(id)
+}
+
+// List lista todos los códigos promocionales
+func (s *CodigoPromocionalService) List() ([]*entidades.CodigoPromocional, error) {
+	return s.codigoRepo.List()
+}
+
+// ListCanjes lista las reservas en las que se canjeó un código promocional
+func (s *CodigoPromocionalService) ListCanjes(id int) ([]*entidades.CanjePromocion, error) {
+	// Verificar que el código existe
+	_, err := s.codigoRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.codigoRepo.ListCanjes(id)
+}
+
+// ListReportes resume los canjes y descuentos otorgados por cada código promocional
+func (s *CodigoPromocionalService) ListReportes() ([]*entidades.ReportePromocion, error) {
+	return s.codigoRepo.ListReportes()
+}
+
+// Aplicar valida un código contra una reserva cotizada y devuelve el descuento que le corresponde
+// Los límites de usos se verifican de nuevo, con el código bloqueado, al guardar la reserva
+func (s *CodigoPromocionalService) Aplicar(texto string, cotizacion *entidades.CotizacionReserva, contexto entidades.ContextoPromocion) (*entidades.AplicacionPromocion, error) {
+	codigo, err := s.codigoRepo.GetByCodigo(texto)
+	if err != nil {
+		return nil, errors.New("el código promocional no existe")
+	}
+
+	uso := &entidades.UsoPromocion{Totales: codigo.UsosRegistrados}
+	if contexto.IDCliente != 0 {
+		uso, err = s.codigoRepo.GetUso(codigo.ID, contexto.IDCliente)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	descuento, err := CalcularDescuentoPromocion(codigo, cotizacion, contexto, uso)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entidades.AplicacionPromocion{
+		IDCodigoPromocional: codigo.ID,
+		Codigo:              codigo.Codigo,
+		TotalAntes:          cotizacion.Subtotal,
+		Descuento:           descuento,
+	}, nil
+}
+
+// RecalcularCanje recalcula el descuento del código que ya canjeó una reserva cuyos pasajes cambiaron
+// No vuelve a revisar vigencia ni usos: el canje ya se hizo. Retorna nil si la reserva no usó ningún código
+func (s *CodigoPromocionalService) RecalcularCanje(idReserva int, cotizacion *entidades.CotizacionReserva) (*entidades.AplicacionPromocion, error) {
+	canje, err := s.codigoRepo.GetCanjeByReserva(idReserva)
+	if err != nil || canje == nil {
+		return nil, err
+	}
+
+	// Si el código ya no existe se conserva el monto canjeado, sin superar el nuevo subtotal
+	descuento := canje.MontoDescuento
+	if descuento.MayorQue(cotizacion.Subtotal) {
+		descuento = cotizacion.Subtotal
+	}
+	if codigo, err := s.codigoRepo.GetByID(canje.IDCodigoPromocional); err == nil {
+		descuento, err = montoDescuentoPromocion(codigo, cotizacion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &entidades.AplicacionPromocion{
+		IDCodigoPromocional: canje.IDCodigoPromocional,
+		Codigo:              canje.Codigo,
+		TotalAntes:          cotizacion.Subtotal,
+		Descuento:           descuento,
+	}, nil
+}
+
+// CalcularDescuentoPromocion verifica que un código pueda usarse en una reserva y calcula su descuento
+func CalcularDescuentoPromocion(
+	codigo *entidades.CodigoPromocional,
+	cotizacion *entidades.CotizacionReserva,
+	contexto entidades.ContextoPromocion,
+	uso *entidades.UsoPromocion,
+) (entidades.Dinero, error) {
+	if !codigo.Activo {
+		return entidades.Dinero{}, errors.New("el código promocional no está activo")
+	}
+	if !codigo.Vigente {
+		return entidades.Dinero{}, errors.New("el código promocional no está vigente")
+	}
+
+	// Restricciones de la campaña
+	if codigo.IDTipoTour != nil && *codigo.IDTipoTour != contexto.IDTipoTour {
+		return entidades.Dinero{}, errors.New("el código promocional no aplica a este tour")
+	}
+	if codigo.IDSede != nil && *codigo.IDSede != contexto.IDSede {
+		return entidades.Dinero{}, errors.New("el código promocional no aplica en esta sede")
+	}
+	if codigo.IDCanal != nil && *codigo.IDCanal != contexto.IDCanal {
+		return entidades.Dinero{}, errors.New("el código promocional no aplica en este canal de venta")
+	}
+
+	// Límites de usos
+	if codigo.UsosMaximos != nil && uso.Totales >= *codigo.UsosMaximos {
+		return entidades.Dinero{}, repositorios.ErrPromocionAgotada
+	}
+	if codigo.UsosPorCliente != nil && contexto.IDCliente != 0 && uso.Cliente >= *codigo.UsosPorCliente {
+		return entidades.Dinero{}, errors.New("el cliente ya usó este código promocional el máximo de veces permitido")
+	}
+
+	return montoDescuentoPromocion(codigo, cotizacion)
+}
+
+// montoDescuentoPromocion calcula el descuento sobre el subtotal de la reserva, o solo sobre las líneas
+// del tipo de pasaje al que está restringido el código. Un monto fijo nunca supera esa base
+func montoDescuentoPromocion(codigo *entidades.CodigoPromocional, cotizacion *entidades.CotizacionReserva) (entidades.Dinero, error) {
+	base := cotizacion.Subtotal
+	if codigo.IDTipoPasaje != nil {
+		base = entidades.NuevoDinero(0, cotizacion.Subtotal.Moneda())
+		for _, linea := range cotizacion.Lineas {
+			if linea.Tipo == "PASAJE" && linea.ID == *codigo.IDTipoPasaje {
+				base = base.Sumar(linea.Subtotal)
+			}
+		}
+	}
+	if !base.EsPositivo() {
+		return entidades.Dinero{}, errors.New("el código promocional no aplica a los pasajes de la reserva")
+	}
+
+	if codigo.TipoDescuento == "PORCENTAJE" {
+		return base.Porcentaje(codigo.Porcentaje), nil
+	}
+
+	descuento := codigo.MontoDescuento.EnMoneda(base.Moneda())
+	if descuento.MayorQue(base) {
+		descuento = base
+	}
+	return descuento, nil
+}
@@ -0,0 +1,296 @@
+package servicios
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"regexp"
+	"sistema-toursseft/internal/config"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+	"strings"
+)
+
+// numeroComprobanteSunat valida el formato SERIE-CORRELATIVO: serie de 4 caracteres y hasta 8 dígitos
+var numeroComprobanteSunat = regexp.MustCompile(`^([BF][A-Z0-9]{3})-(\d{1,8})$`)
+
+// NuevoFirmanteSunat carga el certificado digital configurado
+// Sin certificado se usa uno autofirmado, válido solo con el enviador stub
+func NuevoFirmanteSunat(cfg *config.Config) (*utils.FirmanteXML, error) {
+	if cfg.SunatCertificadoPath == "" || cfg.SunatClavePrivadaPath == "" {
+		if cfg.SunatModo == "ose" {
+			return nil, errors.New("el envío al OSE requiere SUNAT_CERTIFICADO_PATH y SUNAT_CLAVE_PRIVADA_PATH")
+		}
+		log.Println("Facturación electrónica: sin certificado configurado, se firmará con un certificado autofirmado")
+		return utils.NuevoFirmanteXMLAutofirmado(cfg.SunatRazonSocial)
+	}
+
+	certificado, err := ioutil.ReadFile(cfg.SunatCertificadoPath)
+	if err != nil {
+		return nil, err
+	}
+	clave, err := ioutil.ReadFile(cfg.SunatClavePrivadaPath)
+	if err != nil {
+		return nil, err
+	}
+	return utils.NuevoFirmanteXML(certificado, clave)
+}
+
+// NuevoEnviadorSunat elige el enviador según SUNAT_MODO
+func NuevoEnviadorSunat(cfg *config.Config) EnviadorSunat {
+	if cfg.SunatModo == "ose" {
+		return NewEnviadorOSE(cfg.SunatOSEURL, cfg.SunatUsuario, cfg.SunatClave)
+	}
+	return &EnviadorSunatStub{RUC: cfg.SunatRUC}
+}
+
+// ArmarDocumentoElectronico traduce un comprobante de pago y su cliente al documento que se convierte en XML
+func ArmarDocumentoElectronico(comprobante *entidades.ComprobantePago, cliente *entidades.Cliente, emisor entidades.EmisorElectronico) (*entidades.DocumentoElectronico, error) {
+	partes := numeroComprobanteSunat.FindStringSubmatch(comprobante.NumeroComprobante)
+	if partes == nil {
+		return nil, errors.New("el número de comprobante debe tener el formato SERIE-CORRELATIVO, por ejemplo B001-123")
+	}
+	serie := partes[1]
+	correlativo, _ := strconv.Atoi(partes[2])
+	if correlativo == 0 {
+		return nil, errors.New("el correlativo del comprobante debe ser mayor a cero")
+	}
+
+	doc := &entidades.DocumentoElectronico{
+		Serie:        serie,
+		Correlativo:  correlativo,
+		FechaEmision: comprobante.FechaEmision,
+		Moneda:       comprobante.Total.Moneda(),
+		Emisor:       emisor,
+		Subtotal:     comprobante.Subtotal,
+		IGV:          comprobante.IGV,
+		Total:        comprobante.Total,
+	}
+
+	// Una nota sigue las reglas del comprobante que modifica: serie F y cliente con RUC para facturas
+	tipoBase := comprobante.Tipo
+	if comprobante.Tipo == "NOTA_CREDITO" || comprobante.Tipo == "NOTA_DEBITO" {
+		if comprobante.IDComprobanteReferencia == nil || comprobante.CodigoMotivo == "" {
+			return nil, errors.New("la nota no tiene comprobante de referencia ni motivo")
+		}
+		if !numeroComprobanteSunat.MatchString(comprobante.NumeroReferencia) {
+			return nil, errors.New("el comprobante de referencia no tiene un número válido para SUNAT")
+		}
+		tipoBase = comprobante.TipoReferencia
+		doc.NumeroReferencia = comprobante.NumeroReferencia
+		doc.CodigoMotivo = comprobante.CodigoMotivo
+		doc.Motivo = comprobante.Motivo
+		if comprobante.NumeroReferencia[0] != serie[0] {
+			return nil, fmt.Errorf("la serie de la nota debe empezar con %c, igual que el comprobante que modifica", comprobante.NumeroReferencia[0])
+		}
+	}
+
+	switch tipoBase {
+	case "FACTURA":
+		if serie[0] != 'F' {
+			return nil, errors.New("la serie de una factura debe empezar con F")
+		}
+		if cliente.TipoDocumento != "RUC" {
+			return nil, errors.New("solo se emiten facturas a clientes con RUC")
+		}
+		if cliente.RazonSocial == "" || cliente.DireccionFiscal == "" {
+			return nil, errors.New("el cliente debe tener razón social y dirección fiscal para emitir una factura")
+		}
+		doc.TipoDocumento = TipoDocumentoFactura
+	case "BOLETA":
+		if serie[0] != 'B' {
+			return nil, errors.New("la serie de una boleta debe empezar con B")
+		}
+		doc.TipoDocumento = TipoDocumentoBoleta
+	default:
+		return nil, errors.New("tipo de comprobante no válido")
+	}
+
+	switch comprobante.Tipo {
+	case "NOTA_CREDITO":
+		doc.TipoDocumentoReferencia, doc.TipoDocumento = doc.TipoDocumento, TipoDocumentoNotaCredito
+	case "NOTA_DEBITO":
+		doc.TipoDocumentoReferencia, doc.TipoDocumento = doc.TipoDocumento, TipoDocumentoNotaDebito
+	}
+
+	doc.Cliente = entidades.ClienteElectronico{
+		TipoDocumento:   codigoDocumentoIdentidad(cliente.TipoDocumento),
+		NumeroDocumento: cliente.NumeroDocumento,
+		Nombre:          strings.TrimSpace(cliente.Nombres + " " + cliente.Apellidos),
+	}
+	if cliente.TipoDocumento == "RUC" {
+		doc.Cliente.Nombre = cliente.RazonSocial
+		doc.Cliente.Direccion = cliente.DireccionFiscal
+	}
+
+	// Los montos deben cuadrar al céntimo
+	if !doc.Subtotal.Sumar(doc.IGV).Igual(doc.Total) {
+		return nil, errors.New("el subtotal más el IGV no coincide con el total del comprobante")
+	}
+
+	// Comprobantes anteriores al registro de la afectación: sin IGV se consideran exonerados
+	doc.Afectacion = comprobante.Afectacion
+	if doc.Afectacion == "" {
+		doc.Afectacion = "GRAVADO"
+		if doc.IGV.EsCero() {
+			doc.Afectacion = "EXONERADO"
+		}
+	}
+	if doc.Afectacion != "GRAVADO" && !doc.IGV.EsCero() {
+		return nil, errors.New("un comprobante exonerado o inafecto no puede tener IGV")
+	}
+	if doc.Afectacion == "GRAVADO" && doc.Subtotal.EsPositivo() {
+		doc.PorcentajeIGV = math.Round(doc.IGV.Float64() / doc.Subtotal.Float64() * 100)
+	}
+
+	descripcion := fmt.Sprintf("Tour %s del %s - Reserva #%d", comprobante.TourNombre, comprobante.TourFecha.Format("02/01/2006"), comprobante.IDReserva)
+	if doc.Motivo != "" {
+		descripcion = doc.Motivo + " - " + descripcion
+	}
+	doc.Lineas = []entidades.LineaElectronica{{
+		Descripcion: descripcion,
+		Cantidad:    1,
+		ValorVenta:  doc.Subtotal,
+		IGV:         doc.IGV,
+		Total:       doc.Total,
+	}}
+
+	return doc, nil
+}
+
+// ComprobanteElectronicoService maneja la emisión de comprobantes electrónicos ante SUNAT
+type ComprobanteElectronicoService struct {
+	electronicoRepo *repositorios.ComprobanteElectronicoRepository
+	pagoRepo        *repositorios.PagoRepository
+	firmante        *utils.FirmanteXML
+	enviador        EnviadorSunat
+	config          *config.Config
+}
+
+// NewComprobanteElectronicoService crea una nueva instancia de ComprobanteElectronicoService
+func NewComprobanteElectronicoService(
+	electronicoRepo *repositorios.ComprobanteElectronicoRepository,
+	pagoRepo *repositorios.PagoRepository,
+	firmante *utils.FirmanteXML,
+	enviador EnviadorSunat,
+	config *config.Config,
+) *ComprobanteElectronicoService {
+	return &ComprobanteElectronicoService{
+		electronicoRepo: electronicoRepo,
+		pagoRepo:        pagoRepo,
+		firmante:        firmante,
+		enviador:        enviador,
+		config:          config,
+	}
+}
+
+// emisor devuelve los datos del emisor configurados
+func (s *ComprobanteElectronicoService) emisor() entidades.EmisorElectronico {
+	return entidades.EmisorElectronico{
+		RUC:             s.config.SunatRUC,
+		RazonSocial:     s.config.SunatRazonSocial,
+		NombreComercial: s.config.SunatNombreComercial,
+		Direccion:       s.config.SunatDireccion,
+		Ubigeo:          s.config.SunatUbigeo,
+	}
+}
+
+// Emitir genera, firma y envía el comprobante electrónico de un comprobante de pago
+// Si ya se generó y el envío anterior falló, se reenvía el mismo XML firmado
+func (s *ComprobanteElectronicoService) Emitir(idComprobante int) (*entidades.ComprobanteElectronico, error) {
+	existente, err := s.electronicoRepo.GetByComprobante(idComprobante)
+	if err == nil {
+		switch existente.EstadoSunat {
+		case "ACEPTADO", "OBSERVADO":
+			return nil, errors.New("el comprobante ya fue aceptado por SUNAT")
+		case "RECHAZADO":
+			return nil, errors.New("el comprobante fue rechazado por SUNAT; debe emitirse un nuevo comprobante")
+		}
+		return s.enviar(existente)
+	}
+	if err != repositorios.ErrComprobanteSinEmision {
+		return nil, err
+	}
+
+	comprobante, cliente, err := s.electronicoRepo.GetDatosEmision(idComprobante)
+	if err != nil {
+		return nil, err
+	}
+	if comprobante.Estado != "EMITIDO" {
+		return nil, errors.New("solo se envían a SUNAT comprobantes en estado EMITIDO")
+	}
+
+	if comprobante.IDComprobanteReferencia != nil {
+		// Una nota solo modifica un comprobante que SUNAT ya recibió
+		referencia, err := s.electronicoRepo.GetByComprobante(*comprobante.IDComprobanteReferencia)
+		if err != nil && err != repositorios.ErrComprobanteSinEmision {
+			return nil, err
+		}
+		if err != nil || (referencia.EstadoSunat != "ACEPTADO" && referencia.EstadoSunat != "OBSERVADO") {
+			return nil, errors.New("el comprobante que modifica la nota debe estar aceptado por SUNAT")
+		}
+	} else {
+		// El comprobante se emite al contado: el monto debe estar cubierto por los pagos de la reserva
+		totalPagado, err := s.pagoRepo.GetTotalPagadoByReserva(comprobante.IDReserva)
+		if err != nil {
+			return nil, err
+		}
+		if comprobante.Total.MayorQue(totalPagado) {
+			return nil, fmt.Errorf("el total del comprobante (%s) supera lo pagado en la reserva (%s)", comprobante.Total, totalPagado)
+		}
+	}
+
+	doc, err := ArmarDocumentoElectronico(comprobante, cliente, s.emisor())
+	if err != nil {
+		return nil, err
+	}
+
+	xmlFirmado, hash, err := FirmarDocumentoElectronico(doc, s.firmante)
+	if err != nil {
+		return nil, err
+	}
+
+	electronico := &entidades.ComprobanteElectronico{
+		IDComprobante: idComprobante,
+		NombreArchivo: NombreArchivoSunat(doc.Emisor.RUC, doc.TipoDocumento, doc.Serie, doc.Correlativo),
+		HashCPE:       hash,
+		Enviador:      s.enviador.Nombre(),
+		XMLFirmado:    xmlFirmado,
+	}
+	if _, err := s.electronicoRepo.Create(electronico); err != nil {
+		return nil, err
+	}
+
+	return s.enviar(electronico)
+}
+
+// enviar envía el XML firmado y registra la respuesta; una falla de comunicación deja el comprobante en ERROR
+func (s *ComprobanteElectronicoService) enviar(electronico *entidades.ComprobanteElectronico) (*entidades.ComprobanteElectronico, error) {
+	respuesta, errEnvio := s.enviador.Enviar(electronico.NombreArchivo, electronico.XMLFirmado)
+
+	mensajeError := ""
+	if errEnvio != nil {
+		mensajeError = errEnvio.Error()
+		log.Printf("Error al enviar el comprobante %s: %v", electronico.NombreArchivo, errEnvio)
+	}
+	if err := s.electronicoRepo.RegistrarEnvio(electronico.ID, s.enviador.Nombre(), respuesta, mensajeError); err != nil {
+		return nil, err
+	}
+
+	return s.electronicoRepo.GetByComprobante(electronico.IDComprobante)
+}
+
+// GetByComprobante obtiene el comprobante electrónico de un comprobante de pago
+func (s *ComprobanteElectronicoService) GetByComprobante(idComprobante int) (*entidades.ComprobanteElectronico, error) {
+	return s.electronicoRepo.GetByComprobante(idComprobante)
+}
+
+// GetComprobante obtiene el comprobante de pago con los datos usados en la emisión electrónica
+func (s *ComprobanteElectronicoService) GetComprobante(idComprobante int) (*entidades.ComprobantePago, error) {
+	comprobante, _, err := s.electronicoRepo.GetDatosEmision(idComprobante)
+	return comprobante, err
+}
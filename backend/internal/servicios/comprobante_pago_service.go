@@ -2,8 +2,10 @@ package servicios
 
 import (
 	"errors"
+	"fmt"
 	"sistema-toursseft/internal/entidades"
 	"sistema-toursseft/internal/repositorios"
+	"strings"
 	"time"
 )
 
@@ -13,6 +15,9 @@ type ComprobantePagoService struct {
 	reservaRepo         *repositorios.ReservaRepository
 	pagoRepo            *repositorios.PagoRepository
 	sedeRepo            *repositorios.SedeRepository // Añadido repositorio de sede
+	serieRepo           *repositorios.SerieComprobanteRepository
+	impuestoService     *ImpuestoService
+	tipoCambioService   *TipoCambioService
 }
 
 // NewComprobantePagoService crea una nueva instancia de ComprobantePagoService
@@ -21,12 +26,18 @@ func NewComprobantePagoService(
 	reservaRepo *repositorios.ReservaRepository,
 	pagoRepo *repositorios.PagoRepository,
 	sedeRepo *repositorios.SedeRepository, // Añadido repositorio de sede
+	serieRepo *repositorios.SerieComprobanteRepository,
+	impuestoService *ImpuestoService,
+	tipoCambioService *TipoCambioService,
 ) *ComprobantePagoService {
 	return &ComprobantePagoService{
 		comprobantePagoRepo: comprobantePagoRepo,
 		reservaRepo:         reservaRepo,
 		pagoRepo:            pagoRepo,
 		sedeRepo:            sedeRepo, // Asignado repositorio de sede
+		serieRepo:           serieRepo,
+		impuestoService:     impuestoService,
+		tipoCambioService:   tipoCambioService,
 	}
 }
 
@@ -49,20 +60,24 @@ func (s *ComprobantePagoService) Create(comprobante *entidades.NuevoComprobanteP
 		return 0, errors.New("la sede especificada no existe")
 	}
 
-	// Verificar que el número de comprobante no exista para este tipo
-	existing, err := s.comprobantePagoRepo.GetByTipoAndNumero(comprobante.Tipo, comprobante.NumeroComprobante)
-	if err == nil && existing != nil {
-		return 0, errors.New("ya existe un comprobante con este tipo y número")
-	}
+	// Sin número se asigna el correlativo de la serie al guardar; un número manual no puede
+	// usar una serie automática ni repetirse
+	if comprobante.NumeroComprobante != "" {
+		if err := s.verificarSerieManual(comprobante.NumeroComprobante); err != nil {
+			return 0, err
+		}
 
-	// Verificar que los montos sean correctos
-	if comprobante.Total != comprobante.Subtotal+comprobante.IGV {
-		return 0, errors.New("el total debe ser igual a subtotal + IGV")
+		// Verificar que el número de comprobante no exista para este tipo
+		existing, err := s.comprobantePagoRepo.GetByTipoAndNumero(comprobante.Tipo, comprobante.NumeroComprobante)
+		if err == nil && existing != nil {
+			return 0, errors.New("ya existe un comprobante con este tipo y número")
+		}
 	}
 
-	// Verificar que el total no exceda el total a pagar de la reserva
-	if comprobante.Total > reserva.TotalPagar {
-		return 0, errors.New("el total del comprobante excede el total a pagar de la reserva")
+	// Calcular los montos con la configuración de IGV de la sede
+	calculo, err := s.impuestoService.CalcularReserva(reserva.ID, comprobante.IDSede)
+	if err != nil {
+		return 0, err
 	}
 
 	// Verificar que haya pagos suficientes para cubrir el total del comprobante
@@ -71,14 +86,68 @@ func (s *ComprobantePagoService) Create(comprobante *entidades.NuevoComprobanteP
 		return 0, err
 	}
 
-	if totalPagado < comprobante.Total {
+	if totalPagado.MenorQue(calculo.Total) {
 		return 0, errors.New("no hay pagos suficientes para cubrir el total del comprobante")
 	}
 
+	// En dólares, los montos se expresan con el tipo de cambio del día de emisión
+	if comprobante.Moneda == entidades.MonedaDolares {
+		comprobante.TipoCambio, err = s.tipoCambioService.GetVigente(entidades.MonedaDolares, time.Now())
+		if err != nil {
+			return 0, err
+		}
+		calculo = ExpresarMontosComprobante(calculo, comprobante.TipoCambio)
+	}
+
+	if err := verificarMontosDeclarados(comprobante.Subtotal, comprobante.IGV, comprobante.Total, calculo); err != nil {
+		return 0, err
+	}
+	comprobante.Subtotal = calculo.Subtotal
+	comprobante.IGV = calculo.IGV
+	comprobante.Total = calculo.Total
+	comprobante.Afectacion = calculo.Afectacion
+
 	// Crear comprobante de pago
 	return s.comprobantePagoRepo.Create(comprobante)
 }
 
+// ExpresarMontosComprobante convierte a la moneda del tipo de cambio los totales de un cálculo en soles
+// El total se redondea al céntimo y el IGV se prorratea en la misma proporción; las líneas quedan en soles
+func ExpresarMontosComprobante(calculo *entidades.CalculoImpuestos, tipoCambio *entidades.TipoCambio) *entidades.CalculoImpuestos {
+	convertido := *calculo
+	convertido.Total = tipoCambio.EquivalenteDesdeMonedaBase(calculo.Total)
+	convertido.IGV = calculo.IGV.Prorratear(convertido.Total.Centimos(), calculo.Total.Centimos()).EnMoneda(tipoCambio.Moneda)
+	convertido.Subtotal = convertido.Total.Restar(convertido.IGV)
+	return &convertido
+}
+
+// verificarMontosDeclarados compara los montos enviados por el cliente con el cálculo de impuestos
+// Los montos son opcionales; los que se envían deben coincidir al céntimo.
+func verificarMontosDeclarados(subtotal, igv, total entidades.Dinero, calculo *entidades.CalculoImpuestos) error {
+	if subtotal.EsCero() && igv.EsCero() && total.EsCero() {
+		return nil
+	}
+	if subtotal.Centimos() != calculo.Subtotal.Centimos() ||
+		igv.Centimos() != calculo.IGV.Centimos() ||
+		total.Centimos() != calculo.Total.Centimos() {
+		return fmt.Errorf("los montos no coinciden con el cálculo de impuestos de la sede: subtotal %s, IGV %s, total %s",
+			calculo.Subtotal, calculo.IGV, calculo.Total)
+	}
+	return nil
+}
+
+// verificarSerieManual rechaza números manuales con una serie que se numera automáticamente
+func (s *ComprobantePagoService) verificarSerieManual(numero string) error {
+	serie := numero
+	if i := strings.Index(numero, "-"); i >= 0 {
+		serie = numero[:i]
+	}
+	if _, err := s.serieRepo.GetBySerie(strings.ToUpper(serie)); err == nil {
+		return fmt.Errorf("la serie %s se numera automáticamente, deje vacío el número de comprobante", strings.ToUpper(serie))
+	}
+	return nil
+}
+
 // GetByID obtiene un comprobante de pago por su ID
 func (s *ComprobantePagoService) GetByID(id int) (*entidades.ComprobantePago, error) {
 	return s.comprobantePagoRepo.GetByID(id)
@@ -103,45 +172,70 @@ func (s *ComprobantePagoService) Update(id int, comprobante *entidades.Actualiza
 		return err
 	}
 
+	// Las notas no se editan; se anulan y se emite una nueva
+	if existingComprobante.Tipo == "NOTA_CREDITO" || existingComprobante.Tipo == "NOTA_DEBITO" {
+		return errors.New("una nota de crédito o débito no se puede modificar")
+	}
+
 	// Verificar que la sede existe
 	_, err = s.sedeRepo.GetByID(comprobante.IDSede)
 	if err != nil {
 		return errors.New("la sede especificada no existe")
 	}
 
+	// Un comprobante numerado por serie conserva su sede, tipo y número
+	automatica, err := s.comprobantePagoRepo.EsNumeracionAutomatica(id)
+	if err != nil {
+		return err
+	}
+	cambiaNumeracion := comprobante.Tipo != existingComprobante.Tipo || comprobante.NumeroComprobante != existingComprobante.NumeroComprobante
+	if automatica && (cambiaNumeracion || comprobante.IDSede != existingComprobante.IDSede) {
+		return errors.New("no se puede cambiar la sede, el tipo ni el número de un comprobante con numeración automática")
+	}
+
 	// Si cambia el tipo o número, verificar que no exista otro comprobante con esos datos
-	if comprobante.Tipo != existingComprobante.Tipo || comprobante.NumeroComprobante != existingComprobante.NumeroComprobante {
+	if cambiaNumeracion {
+		if err := s.verificarSerieManual(comprobante.NumeroComprobante); err != nil {
+			return err
+		}
 		existing, err := s.comprobantePagoRepo.GetByTipoAndNumero(comprobante.Tipo, comprobante.NumeroComprobante)
 		if err == nil && existing != nil && existing.ID != id {
 			return errors.New("ya existe otro comprobante con este tipo y número")
 		}
 	}
 
-	// Verificar que los montos sean correctos
-	if comprobante.Total != comprobante.Subtotal+comprobante.IGV {
-		return errors.New("el total debe ser igual a subtotal + IGV")
-	}
-
-	// Verificar que el total no exceda el total a pagar de la reserva
-	reserva, err := s.reservaRepo.GetByID(existingComprobante.IDReserva)
+	// Recalcular los montos con la configuración de IGV de la sede
+	calculo, err := s.impuestoService.CalcularReserva(existingComprobante.IDReserva, comprobante.IDSede)
 	if err != nil {
 		return err
 	}
 
-	if comprobante.Total > reserva.TotalPagar {
-		return errors.New("el total del comprobante excede el total a pagar de la reserva")
-	}
-
 	// Verificar que haya pagos suficientes para cubrir el total del comprobante
 	totalPagado, err := s.pagoRepo.GetTotalPagadoByReserva(existingComprobante.IDReserva)
 	if err != nil {
 		return err
 	}
 
-	if totalPagado < comprobante.Total {
+	if totalPagado.MenorQue(calculo.Total) {
 		return errors.New("no hay pagos suficientes para cubrir el total del comprobante")
 	}
 
+	// El comprobante conserva la moneda y el tipo de cambio con que se emitió
+	if existingComprobante.Total.Moneda() != entidades.MonedaPorDefecto {
+		calculo = ExpresarMontosComprobante(calculo, &entidades.TipoCambio{
+			Moneda: existingComprobante.Total.Moneda(),
+			Valor:  existingComprobante.TipoCambio,
+		})
+	}
+
+	if err := verificarMontosDeclarados(comprobante.Subtotal, comprobante.IGV, comprobante.Total, calculo); err != nil {
+		return err
+	}
+	comprobante.Subtotal = calculo.Subtotal
+	comprobante.IGV = calculo.IGV
+	comprobante.Total = calculo.Total
+	comprobante.Afectacion = calculo.Afectacion
+
 	// Actualizar comprobante
 	return s.comprobantePagoRepo.Update(id, comprobante)
 }
@@ -164,6 +258,19 @@ func (s *ComprobantePagoService) CambiarEstado(id int, estado string) error {
 		return nil
 	}
 
+	// Un comprobante con notas vigentes no se anula; sus notas quedarían sin referencia válida
+	if estado == "ANULADO" {
+		notas, err := s.comprobantePagoRepo.ListNotas(id)
+		if err != nil {
+			return err
+		}
+		for _, nota := range notas {
+			if nota.Estado == "EMITIDO" {
+				return errors.New("el comprobante tiene notas de crédito o débito vigentes, anúlelas primero")
+			}
+		}
+	}
+
 	// Cambiar estado
 	return s.comprobantePagoRepo.UpdateEstado(id, estado)
 }
@@ -181,10 +288,172 @@ func (s *ComprobantePagoService) Delete(id int) error {
 		return errors.New("no se puede eliminar un comprobante que no está anulado")
 	}
 
+	// Los números de serie anulados se conservan para que la numeración no tenga huecos
+	automatica, err := s.comprobantePagoRepo.EsNumeracionAutomatica(id)
+	if err != nil {
+		return err
+	}
+	if automatica {
+		return errors.New("un comprobante con numeración automática no se elimina, queda registrado como anulado")
+	}
+
 	// Eliminar comprobante
 	return s.comprobantePagoRepo.Delete(id)
 }
 
+// CrearNota emite una nota de crédito o débito sobre una factura o boleta
+// Los motivos de anulación y devolución total (01, 02 y 06) exigen acreditar el total del comprobante.
+func (s *ComprobantePagoService) CrearNota(nota *entidades.NuevaNotaComprobanteRequest) (int, error) {
+	referencia, err := s.comprobantePagoRepo.GetDatosReferencia(nota.IDComprobanteReferencia)
+	if err != nil {
+		return 0, errors.New("el comprobante de referencia no existe")
+	}
+	if referencia.Tipo != "FACTURA" && referencia.Tipo != "BOLETA" {
+		return 0, errors.New("las notas solo se emiten sobre facturas o boletas")
+	}
+	if referencia.Estado != "EMITIDO" {
+		return 0, errors.New("el comprobante de referencia no está vigente")
+	}
+
+	// Verificar el motivo según el tipo de nota
+	motivos := entidades.MotivosNotaCredito
+	if nota.Tipo == "NOTA_DEBITO" {
+		motivos = entidades.MotivosNotaDebito
+		if nota.IDDevolucion != nil {
+			return 0, errors.New("solo las notas de crédito se vinculan a una devolución")
+		}
+	}
+	if _, ok := motivos[nota.CodigoMotivo]; !ok {
+		return 0, fmt.Errorf("el código de motivo %s no es válido para %s", nota.CodigoMotivo, nota.Tipo)
+	}
+
+	// Los montos de la nota se expresan en la moneda del comprobante de referencia
+	moneda := referencia.Total.Moneda()
+	nota.Subtotal = nota.Subtotal.EnMoneda(moneda)
+	nota.IGV = nota.IGV.EnMoneda(moneda)
+	nota.Total = nota.Total.EnMoneda(moneda)
+
+	// Verificar que los montos sean correctos
+	if !nota.Subtotal.Sumar(nota.IGV).Igual(nota.Total) {
+		return 0, errors.New("el total debe ser igual a subtotal + IGV")
+	}
+	if referencia.Afectacion != "" && referencia.Afectacion != "GRAVADO" && !nota.IGV.EsCero() {
+		return 0, errors.New("la nota de un comprobante exonerado o inafecto no puede tener IGV")
+	}
+
+	if nota.Tipo == "NOTA_CREDITO" && (nota.CodigoMotivo == "01" || nota.CodigoMotivo == "02" || nota.CodigoMotivo == "06") {
+		notas, err := s.comprobantePagoRepo.ListNotas(referencia.ID)
+		if err != nil {
+			return 0, err
+		}
+		if !resumirNotas(referencia, notas).TotalCreditos.EsCero() || !nota.Total.Igual(referencia.Total) {
+			return 0, errors.New("una anulación o devolución total debe acreditar el total del comprobante sin notas de crédito previas")
+		}
+	}
+
+	if nota.NumeroComprobante != "" {
+		if err := s.verificarSerieManual(nota.NumeroComprobante); err != nil {
+			return 0, err
+		}
+		existing, err := s.comprobantePagoRepo.GetByTipoAndNumero(nota.Tipo, nota.NumeroComprobante)
+		if err == nil && existing != nil {
+			return 0, errors.New("ya existe un comprobante con este tipo y número")
+		}
+	}
+
+	// El límite de créditos se vuelve a verificar al guardar, con el comprobante bloqueado
+	return s.comprobantePagoRepo.CreateNota(nota)
+}
+
+// CrearNotaPorDevolucion emite la nota de crédito que respalda una devolución completada
+// Se aplica sobre el último comprobante vigente de la reserva; si la devolución cubre todo lo que queda
+// por acreditar se usa el motivo 06 (devolución total), si no el 07, con el IGV en la misma proporción.
+func (s *ComprobantePagoService) CrearNotaPorDevolucion(devolucion *entidades.DevolucionPago) (int, error) {
+	referencia, err := s.comprobantePagoRepo.GetComprobanteVigenteReserva(devolucion.IDReserva)
+	if err != nil {
+		return 0, err
+	}
+
+	notas, err := s.comprobantePagoRepo.ListNotas(referencia.ID)
+	if err != nil {
+		return 0, err
+	}
+	resumen := resumirNotas(referencia, notas)
+	if !resumen.SaldoAcreditable.EsPositivo() {
+		return 0, errors.New("el comprobante de la reserva ya fue acreditado por completo")
+	}
+
+	// La devolución está en la moneda del pago; la nota, en la del comprobante
+	monto := entidades.ConvertirMoneda(devolucion.MontoDevolucion, devolucion.TipoCambioPago, referencia.Total.Moneda(), referencia.TipoCambio)
+	if resumen.SaldoAcreditable.MenorQue(monto) {
+		monto = resumen.SaldoAcreditable
+	}
+	nota := &entidades.NuevaNotaComprobanteRequest{
+		IDComprobanteReferencia: referencia.ID,
+		Tipo:                    "NOTA_CREDITO",
+		CodigoMotivo:            "07",
+		Motivo:                  fmt.Sprintf("Devolución #%d: %s", devolucion.ID, devolucion.Motivo),
+		Total:                   monto,
+		IDDevolucion:            &devolucion.ID,
+	}
+	if resumen.TotalCreditos.EsCero() && monto.Igual(referencia.Total) {
+		nota.CodigoMotivo = "06"
+	}
+	if len(nota.Motivo) > 250 {
+		nota.Motivo = nota.Motivo[:250]
+	}
+
+	nota.IGV = referencia.IGV.Prorratear(monto.Centimos(), referencia.Total.Centimos())
+	nota.Subtotal = monto.Restar(nota.IGV)
+
+	return s.comprobantePagoRepo.CreateNota(nota)
+}
+
+// GetResumenNotas obtiene las notas de un comprobante con los totales acreditados y debitados
+func (s *ComprobantePagoService) GetResumenNotas(id int) (*entidades.ResumenNotasComprobante, error) {
+	referencia, err := s.comprobantePagoRepo.GetDatosReferencia(id)
+	if err != nil {
+		return nil, err
+	}
+
+	notas, err := s.comprobantePagoRepo.ListNotas(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return resumirNotas(referencia, notas), nil
+}
+
+// resumirNotas suma las notas vigentes de un comprobante; las anuladas no cuentan
+func resumirNotas(referencia *entidades.ComprobantePago, notas []*entidades.ComprobantePago) *entidades.ResumenNotasComprobante {
+	resumen := &entidades.ResumenNotasComprobante{
+		IDComprobante:     referencia.ID,
+		IDSede:            referencia.IDSede,
+		NumeroComprobante: referencia.NumeroComprobante,
+		Total:             referencia.Total,
+		TotalCreditos:     entidades.NuevoDinero(0, referencia.Total.Moneda()),
+		TotalDebitos:      entidades.NuevoDinero(0, referencia.Total.Moneda()),
+		Notas:             notas,
+	}
+
+	for _, nota := range notas {
+		if nota.Estado != "EMITIDO" {
+			continue
+		}
+		if nota.Tipo == "NOTA_CREDITO" {
+			resumen.TotalCreditos = resumen.TotalCreditos.Sumar(nota.Total)
+		} else {
+			resumen.TotalDebitos = resumen.TotalDebitos.Sumar(nota.Total)
+		}
+	}
+
+	resumen.SaldoAcreditable = resumen.Total.Restar(resumen.TotalCreditos)
+	if resumen.SaldoAcreditable.EsNegativo() {
+		resumen.SaldoAcreditable = entidades.NuevoDinero(0, resumen.Total.Moneda())
+	}
+	return resumen
+}
+
 // List lista todos los comprobantes de pago
 func (s *ComprobantePagoService) List() ([]*entidades.ComprobantePago, error) {
 	return s.comprobantePagoRepo.List()
@@ -0,0 +1,268 @@
+package servicios
+
+import (
+	"errors"
+	"fmt"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"time"
+)
+
+// ConciliacionPagoService concilia los pagos en línea de las reservas web con sus pasarelas
+// Corrige lo que las notificaciones perdidas dejaron sin aplicar y registra las diferencias para finanzas.
+// Las correcciones pasan por el mismo registro de notificaciones y los mismos bloqueos que el webhook, así
+// que pueden ejecutarse a la par de las notificaciones sin duplicar pagos
+type ConciliacionPagoService struct {
+	conciliacionRepo *repositorios.ConciliacionPagoRepository
+	reservaService   *ReservaService
+	pasarelas        *PasarelasPago
+	diasRevision     int // Antigüedad máxima de las reservas que se revisan
+}
+
+// NewConciliacionPagoService crea una nueva instancia de ConciliacionPagoService
+func NewConciliacionPagoService(
+	conciliacionRepo *repositorios.ConciliacionPagoRepository,
+	reservaService *ReservaService,
+	pasarelas *PasarelasPago,
+	diasRevision int,
+) *ConciliacionPagoService {
+	return &ConciliacionPagoService{
+		conciliacionRepo: conciliacionRepo,
+		reservaService:   reservaService,
+		pasarelas:        pasarelas,
+		diasRevision:     diasRevision,
+	}
+}
+
+// Conciliar revisa en su pasarela los pagos de las reservas web recientes y corrige las diferencias
+// Retorna repositorios.ErrConciliacionEnCurso si ya hay otra conciliación ejecutándose
+func (s *ConciliacionPagoService) Conciliar(origen string, idUsuario *int) (*entidades.ConciliacionPago, error) {
+	id, err := s.conciliacionRepo.Iniciar(origen, idUsuario, s.diasRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	conciliacion := &entidades.ConciliacionPago{
+		ID:            id,
+		Origen:        origen,
+		IDUsuario:     idUsuario,
+		DiasRevisados: s.diasRevision,
+		FechaInicio:   time.Now(),
+		Estado:        entidades.EstadoConciliacionCompletada,
+		Diferencias:   []*entidades.DiscrepanciaConciliacion{},
+	}
+
+	reservas, err := s.conciliacionRepo.ListReservasPendientes(s.diasRevision)
+	if err != nil {
+		conciliacion.Estado = entidades.EstadoConciliacionError
+		conciliacion.Detalle = err.Error()
+		_ = s.conciliacionRepo.Finalizar(conciliacion)
+		return nil, fmt.Errorf("error al obtener las reservas a conciliar: %v", err)
+	}
+
+	for _, reserva := range reservas {
+		for _, discrepancia := range s.conciliarReserva(id, reserva) {
+			discrepancia.IDConciliacion = id
+			if _, err := s.conciliacionRepo.RegistrarDiscrepancia(discrepancia); err != nil {
+				conciliacion.Estado = entidades.EstadoConciliacionError
+				conciliacion.Detalle = fmt.Sprintf("error al registrar la diferencia de la reserva %d: %v", reserva.IDReserva, err)
+				continue
+			}
+			conciliacion.Diferencias = append(conciliacion.Diferencias, discrepancia)
+			if discrepancia.Resultado == entidades.ResultadoConciliacionCorregido {
+				conciliacion.Corregidas++
+			}
+		}
+		conciliacion.ReservasRevisadas++
+	}
+	conciliacion.Discrepancias = len(conciliacion.Diferencias)
+
+	if err := s.conciliacionRepo.Finalizar(conciliacion); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	conciliacion.FechaFin = &now
+	return conciliacion, nil
+}
+
+// conciliarReserva compara los pagos de una reserva con su pasarela y aplica las correcciones que correspondan
+func (s *ConciliacionPagoService) conciliarReserva(idConciliacion int, reserva *entidades.ReservaConciliacion) []*entidades.DiscrepanciaConciliacion {
+	var pagos []*entidades.PagoPasarela
+	var err error
+	if reserva.Pasarela == "" {
+		pagos, err = s.buscarEnTodas(reserva)
+	} else {
+		var pasarela PaymentGateway
+		pasarela, err = s.pasarelas.Obtener(reserva.Pasarela)
+		if err != nil {
+			return []*entidades.DiscrepanciaConciliacion{{
+				IDReserva:   reserva.IDReserva,
+				Pasarela:    reserva.Pasarela,
+				Tipo:        entidades.DiscrepanciaPasarelaDesconocida,
+				EstadoLocal: reserva.Estado,
+				Accion:      entidades.AccionConciliacionRevisar,
+				Resultado:   entidades.ResultadoConciliacionRevisar,
+				Detalle:     err.Error(),
+			}}
+		}
+		pagos, err = pasarela.BuscarPagos(entidades.ReferenciaReserva(reserva.IDReserva))
+	}
+	if err != nil {
+		return []*entidades.DiscrepanciaConciliacion{{
+			IDReserva:   reserva.IDReserva,
+			Pasarela:    reserva.Pasarela,
+			Tipo:        entidades.DiscrepanciaErrorPasarela,
+			EstadoLocal: reserva.Estado,
+			Accion:      entidades.AccionConciliacionRevisar,
+			Resultado:   entidades.ResultadoConciliacionError,
+			Detalle:     err.Error(),
+		}}
+	}
+
+	discrepancias := []*entidades.DiscrepanciaConciliacion{}
+	for _, discrepancia := range entidades.ConciliarReserva(reserva, pagos, time.Now()) {
+		if s.corregir(idConciliacion, reserva, discrepancia) {
+			discrepancias = append(discrepancias, discrepancia)
+		}
+	}
+
+	return discrepancias
+}
+
+// buscarEnTodas busca los pagos de una reserva sin pasarela registrada en todas las pasarelas configuradas
+// La reserva queda con la primera pasarela que tenga pagos suyos; si ninguna los tiene se revisa con la
+// predeterminada, que solo puede expirar la retención vencida. Una pasarela que falla no impide buscar en las
+// demás, pero si ninguna devolvió pagos el error se informa contra la que falló: sin ella no se sabe si la
+// reserva se pagó
+func (s *ConciliacionPagoService) buscarEnTodas(reserva *entidades.ReservaConciliacion) ([]*entidades.PagoPasarela, error) {
+	referencia := entidades.ReferenciaReserva(reserva.IDReserva)
+	reserva.Pasarela = s.pasarelas.Predeterminada().Nombre()
+
+	var pasarelaFallida string
+	var errBusqueda error
+	for _, pasarela := range s.pasarelas.Todas() {
+		pagos, err := pasarela.BuscarPagos(referencia)
+		if err != nil {
+			if errBusqueda == nil {
+				pasarelaFallida, errBusqueda = pasarela.Nombre(), err
+			}
+			continue
+		}
+		if len(pagos) == 0 {
+			continue
+		}
+
+		reserva.Pasarela = pasarela.Nombre()
+		if err := s.conciliacionRepo.AsignarPasarela(reserva.IDReserva, reserva.Pasarela); err != nil {
+			return nil, fmt.Errorf("error al registrar la pasarela de la reserva: %v", err)
+		}
+		return pagos, nil
+	}
+
+	if errBusqueda != nil {
+		reserva.Pasarela = pasarelaFallida
+		return nil, errBusqueda
+	}
+
+	return []*entidades.PagoPasarela{}, nil
+}
+
+// corregir aplica la acción de una diferencia y registra su resultado
+// Retorna false si la diferencia ya no existía, porque otra operación la resolvió entretanto
+func (s *ConciliacionPagoService) corregir(idConciliacion int, reserva *entidades.ReservaConciliacion, discrepancia *entidades.DiscrepanciaConciliacion) bool {
+	switch discrepancia.Accion {
+	case entidades.AccionConciliacionConfirmarPago, entidades.AccionConciliacionRevertirPago:
+		// Se aplica como una notificación más: si el webhook ya la procesó o la está procesando no tiene efecto
+		idNotificacion := fmt.Sprintf("CONCILIACION-%d", idConciliacion)
+		if err := s.reservaService.ProcesarNotificacionPago(discrepancia.PagoPasarela, idNotificacion); err != nil {
+			discrepancia.Resultado = entidades.ResultadoConciliacionError
+			discrepancia.Detalle = err.Error()
+			return true
+		}
+		s.verificarCorreccion(reserva, discrepancia)
+	case entidades.AccionConciliacionExpirarReserva:
+		expirada, err := s.reservaService.ExpirarReservaVencida(reserva.IDReserva)
+		if err != nil {
+			discrepancia.Resultado = entidades.ResultadoConciliacionError
+			discrepancia.Detalle = err.Error()
+			return true
+		}
+		if !expirada {
+			return false
+		}
+		discrepancia.Resultado = entidades.ResultadoConciliacionCorregido
+		discrepancia.Detalle = "reserva expirada y cupo liberado"
+	default:
+		discrepancia.Resultado = entidades.ResultadoConciliacionRevisar
+	}
+
+	return true
+}
+
+// verificarCorreccion comprueba en los pagos registrados que la confirmación o reversión de un pago quedó aplicada
+func (s *ConciliacionPagoService) verificarCorreccion(reserva *entidades.ReservaConciliacion, discrepancia *entidades.DiscrepanciaConciliacion) {
+	pagos, err := s.conciliacionRepo.ListPagosReserva(reserva.IDReserva, reserva.Pasarela)
+	if err != nil {
+		discrepancia.Resultado = entidades.ResultadoConciliacionError
+		discrepancia.Detalle = err.Error()
+		return
+	}
+
+	var registrado *entidades.PagoLocalConciliacion
+	for _, pago := range pagos {
+		if pago.IDTransaccion == discrepancia.IDPagoExterno {
+			registrado = pago
+		}
+	}
+
+	vigente := registrado != nil && registrado.Estado == "PROCESADO"
+	if discrepancia.Accion == entidades.AccionConciliacionConfirmarPago && !vigente ||
+		discrepancia.Accion == entidades.AccionConciliacionRevertirPago && vigente {
+		discrepancia.Resultado = entidades.ResultadoConciliacionRevisar
+		discrepancia.Detalle = "la notificación del pago está en proceso o fue rechazada; revisar el registro de notificaciones"
+		return
+	}
+
+	discrepancia.Resultado = entidades.ResultadoConciliacionCorregido
+	if registrado != nil {
+		discrepancia.IDPago = &registrado.IDPago
+	}
+
+	actual, err := s.reservaService.GetByID(reserva.IDReserva)
+	if err != nil {
+		return
+	}
+	discrepancia.Detalle = fmt.Sprintf("reserva en estado %s", actual.Estado)
+
+	// Un pago que llega a una reserva expirada sin cupo queda registrado, pero hay que devolverlo
+	if discrepancia.Accion == entidades.AccionConciliacionConfirmarPago && actual.Estado == "EXPIRADA" {
+		discrepancia.Resultado = entidades.ResultadoConciliacionRevisar
+		discrepancia.Detalle = "pago registrado en una reserva expirada sin cupo disponible; pendiente de devolución"
+	}
+}
+
+// GetByID obtiene una conciliación con las diferencias que encontró
+func (s *ConciliacionPagoService) GetByID(id int) (*entidades.ConciliacionPago, error) {
+	return s.conciliacionRepo.GetByID(id)
+}
+
+// Reporte presenta las diferencias encontradas entre dos fechas, opcionalmente solo las de un resultado
+func (s *ConciliacionPagoService) Reporte(fechaInicio, fechaFin time.Time, resultado string) (*entidades.ReporteConciliacionPagos, error) {
+	if fechaFin.Before(fechaInicio) {
+		return nil, errors.New("la fecha de fin no puede ser anterior a la de inicio")
+	}
+
+	discrepancias, ejecuciones, err := s.conciliacionRepo.ListDiscrepancias(fechaInicio, fechaFin, resultado)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entidades.ReporteConciliacionPagos{
+		FechaInicio:   fechaInicio,
+		FechaFin:      fechaFin,
+		Ejecuciones:   ejecuciones,
+		Resumen:       entidades.ResumirDiscrepancias(discrepancias),
+		Discrepancias: discrepancias,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package servicios
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"time"
+)
+
+// DevolucionPagoService maneja la lógica de negocio para devoluciones de pagos
+type DevolucionPagoService struct {
+	devolucionRepo         *repositorios.DevolucionPagoRepository
+	pasarelas              *PasarelasPago
+	comprobantePagoService *ComprobantePagoService
+}
+
+// NewDevolucionPagoService crea una nueva instancia de DevolucionPagoService
+func NewDevolucionPagoService(
+	devolucionRepo *repositorios.DevolucionPagoRepository,
+	pasarelas *PasarelasPago,
+	comprobantePagoService *ComprobantePagoService,
+) *DevolucionPagoService {
+	return &DevolucionPagoService{
+		devolucionRepo:         devolucionRepo,
+		pasarelas:              pasarelas,
+		comprobantePagoService: comprobantePagoService,
+	}
+}
+
+// Solicitar registra una solicitud de devolución pendiente de aprobación
+func (s *DevolucionPagoService) Solicitar(devolucion *entidades.NuevaDevolucionPagoRequest, idUsuario int) (int, error) {
+	return s.devolucionRepo.Create(devolucion, idUsuario)
+}
+
+// GetSedePago obtiene la sede del pago a devolver
+func (s *DevolucionPagoService) GetSedePago(idPago int) (int, error) {
+	return s.devolucionRepo.GetSedePago(idPago)
+}
+
+// GetByID obtiene una devolución por su ID
+func (s *DevolucionPagoService) GetByID(id int) (*entidades.DevolucionPago, error) {
+	return s.devolucionRepo.GetByID(id)
+}
+
+// Aprobar aprueba una devolución pendiente
+func (s *DevolucionPagoService) Aprobar(id int, idUsuario int) error {
+	return s.devolucionRepo.Aprobar(id, idUsuario)
+}
+
+// Rechazar rechaza una devolución pendiente
+func (s *DevolucionPagoService) Rechazar(id int, idUsuario int, observaciones string) error {
+	return s.devolucionRepo.Rechazar(id, idUsuario, observaciones)
+}
+
+// Ejecutar realiza una devolución aprobada, en efectivo (MANUAL) o mediante la API de reembolsos de la pasarela
+// con la que se hizo el pago (PASARELA; MERCADO_PAGO se sigue aceptando para los pagos de Mercado Pago)
+func (s *DevolucionPagoService) Ejecutar(id int, solicitud *entidades.EjecutarDevolucionRequest) error {
+	devolucion, err := s.devolucionRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	// Una devolución en EJECUTANDO ya pidió su reembolso a la pasarela sin llegar a completarse: se reintenta
+	// por la pasarela, que con la misma clave de idempotencia no vuelve a reembolsar
+	metodoEjecucion := solicitud.MetodoEjecucion
+	if devolucion.Estado == "EJECUTANDO" {
+		if metodoEjecucion == "MANUAL" {
+			return errors.New("la devolución ya se solicitó a la pasarela; reintente la ejecución por la pasarela")
+		}
+	} else if devolucion.Estado != "APROBADA" {
+		return errors.New("solo se pueden ejecutar devoluciones aprobadas")
+	}
+
+	idDevolucionExterna := ""
+	if metodoEjecucion != "MANUAL" {
+		if devolucion.IDTransaccionExterna == "" || devolucion.Pasarela == "" {
+			return errors.New("el pago no fue realizado con una pasarela de pago")
+		}
+		if metodoEjecucion == entidades.PasarelaMercadoPago && devolucion.Pasarela != entidades.PasarelaMercadoPago {
+			return errors.New("el pago no fue realizado con Mercado Pago")
+		}
+
+		pasarela, err := s.pasarelas.Obtener(devolucion.Pasarela)
+		if err != nil {
+			return err
+		}
+
+		// Registrar que se pide el reembolso antes de pedirlo: si luego falla algo, la devolución no queda
+		// como APROBADA con el dinero ya devuelto
+		if devolucion.Estado == "APROBADA" {
+			if err := s.devolucionRepo.IniciarEjecucion(id, pasarela.Nombre()); err != nil {
+				return err
+			}
+		}
+
+		// La clave de idempotencia por devolución evita un segundo reembolso si se reintenta la ejecución
+		reembolso, err := pasarela.Reembolsar(
+			devolucion.IDTransaccionExterna,
+			devolucion.MontoDevolucion,
+			fmt.Sprintf("DEVOLUCION-%d", devolucion.ID),
+		)
+		if err != nil {
+			return fmt.Errorf("error al solicitar reembolso a la pasarela %s: %v", pasarela.Nombre(), err)
+		}
+		metodoEjecucion = pasarela.Nombre()
+		idDevolucionExterna = reembolso.ID
+	}
+
+	if err := s.devolucionRepo.Completar(id, metodoEjecucion, idDevolucionExterna, solicitud.Observaciones); err != nil {
+		if idDevolucionExterna != "" {
+			log.Printf("Devolución %d reembolsada en la pasarela (%s) sin completar; queda en EJECUTANDO: %v", id, idDevolucionExterna, err)
+		}
+		return err
+	}
+
+	// El dinero ya se devolvió: si la nota de crédito no se puede emitir (reserva sin comprobante,
+	// comprobante ya acreditado) se registra en el log y puede emitirse luego manualmente
+	if _, err := s.comprobantePagoService.CrearNotaPorDevolucion(devolucion); err != nil {
+		log.Printf("Devolución %d completada sin nota de crédito: %v", devolucion.ID, err)
+	}
+
+	return nil
+}
+
+// List lista todas las devoluciones
+func (s *DevolucionPagoService) List() ([]*entidades.DevolucionPago, error) {
+	return s.devolucionRepo.List()
+}
+
+// ListBySede lista las devoluciones de una sede
+func (s *DevolucionPagoService) ListBySede(idSede int) ([]*entidades.DevolucionPago, error) {
+	return s.devolucionRepo.ListBySede(idSede)
+}
+
+// ListByPago lista las devoluciones de un pago
+func (s *DevolucionPagoService) ListByPago(idPago int) ([]*entidades.DevolucionPago, error) {
+	return s.devolucionRepo.ListByPago(idPago)
+}
+
+// ListByEstado lista las devoluciones por estado
+func (s *DevolucionPagoService) ListByEstado(estado string) ([]*entidades.DevolucionPago, error) {
+	estadosValidos := map[string]bool{
+		"PENDIENTE":  true,
+		"APROBADA":   true,
+		"EJECUTANDO": true,
+		"RECHAZADA":  true,
+		"COMPLETADA": true,
+	}
+
+	if !estadosValidos[estado] {
+		return nil, errors.New("estado de devolución inválido")
+	}
+
+	return s.devolucionRepo.ListByEstado(estado)
+}
+
+// ListByFecha lista las devoluciones de una fecha, opcionalmente filtradas por sede
+func (s *DevolucionPagoService) ListByFecha(fecha time.Time, idSede *int) ([]*entidades.DevolucionPago, error) {
+	return s.devolucionRepo.ListByFecha(fecha, idSede)
+}
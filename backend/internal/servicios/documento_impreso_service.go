@@ -0,0 +1,427 @@
+package servicios
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Formatos aceptados para el logo de la sede
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"sistema-toursseft/internal/config"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"sistema-toursseft/internal/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Límites del logo de la sede que se descarga para los documentos impresos
+const (
+	tamanoMaximoLogo  = 2 << 20 // 2 MB
+	pixelesMaximoLogo = 240
+)
+
+// nombresTipoComprobante es el título impreso de cada tipo de comprobante
+var nombresTipoComprobante = map[string]string{
+	"BOLETA":       "BOLETA DE VENTA",
+	"FACTURA":      "FACTURA",
+	"NOTA_CREDITO": "NOTA DE CRÉDITO",
+	"NOTA_DEBITO":  "NOTA DE DÉBITO",
+}
+
+// TextoQRComprobante arma el contenido del QR de la representación impresa según SUNAT:
+// RUC|TIPO|SERIE|NUMERO|IGV|TOTAL|FECHA|TIPO DOC. CLIENTE|NUMERO DOC. CLIENTE|HASH|
+func TextoQRComprobante(doc *entidades.DocumentoElectronico, hash string) string {
+	return strings.Join([]string{
+		doc.Emisor.RUC,
+		doc.TipoDocumento,
+		doc.Serie,
+		fmt.Sprintf("%d", doc.Correlativo),
+		montoUBL(doc.IGV),
+		montoUBL(doc.Total),
+		doc.FechaEmision.Format("2006-01-02"),
+		doc.Cliente.TipoDocumento,
+		doc.Cliente.NumeroDocumento,
+		hash,
+	}, "|") + "|"
+}
+
+// escribirCabeceraSede dibuja el membrete con el logo y los datos de contacto de la sede
+// Devuelve la posición vertical donde continúa el documento
+func escribirCabeceraSede(pdf *utils.DocumentoPDF, sede *entidades.Sede, logo image.Image) float64 {
+	x := 40.0
+	if logo != nil {
+		limites := logo.Bounds()
+		ancho, alto := 60.0, 60.0
+		if limites.Dx() > limites.Dy() {
+			alto = 60 * float64(limites.Dy()) / float64(limites.Dx())
+		} else if limites.Dy() > limites.Dx() {
+			ancho = 60 * float64(limites.Dx()) / float64(limites.Dy())
+		}
+		if err := pdf.Imagen(40, 40, ancho, alto, logo); err == nil {
+			x = 110
+		}
+	}
+
+	pdf.Texto(x, 54, 14, true, sede.Nombre)
+	pdf.Texto(x, 70, 9, false, sede.Direccion)
+	ubicacion := []string{}
+	for _, parte := range []string{sede.Distrito, sede.Provincia, sede.Pais} {
+		if parte != "" {
+			ubicacion = append(ubicacion, parte)
+		}
+	}
+	pdf.Texto(x, 82, 9, false, strings.Join(ubicacion, " - "))
+	contacto := []string{}
+	if sede.Telefono != "" {
+		contacto = append(contacto, "Tel. "+sede.Telefono)
+	}
+	if sede.Correo != "" {
+		contacto = append(contacto, sede.Correo)
+	}
+	pdf.Texto(x, 94, 9, false, strings.Join(contacto, "   "))
+
+	return 120
+}
+
+// ArmarComprobantePDF genera la representación impresa de un comprobante de pago en A4
+// doc y electronico son nil si el comprobante no tiene formato SUNAT o todavía no se emitió electrónicamente;
+// en ese caso se omiten el hash y el QR.
+func ArmarComprobantePDF(comprobante *entidades.ComprobantePago, cliente *entidades.Cliente, emisor entidades.EmisorElectronico,
+	sede *entidades.Sede, logo image.Image, doc *entidades.DocumentoElectronico, electronico *entidades.ComprobanteElectronico) ([]byte, error) {
+	pdf := utils.NuevoDocumentoPDF()
+	margenDerecho := utils.AnchoPaginaA4 - 40
+	esElectronico := doc != nil && electronico != nil
+
+	escribirCabeceraSede(pdf, sede, logo)
+
+	// Recuadro con el RUC, el tipo y el número del comprobante
+	titulo := nombresTipoComprobante[comprobante.Tipo]
+	if esElectronico {
+		titulo += " ELECTRÓNICA"
+	}
+	pdf.Marco(355, 36, 200, 66)
+	centro := 455.0
+	for i, linea := range []string{"RUC " + emisor.RUC, titulo, comprobante.NumeroComprobante} {
+		tamano := 11.0
+		if i == 1 && len([]rune(titulo)) > 26 {
+			tamano = 9
+		}
+		pdf.Texto(centro-utils.AnchoTexto(tamano, true, linea)/2, 56+float64(i)*18, tamano, true, linea)
+	}
+	if comprobante.Estado == "ANULADO" {
+		pdf.Texto(centro-utils.AnchoTexto(10, true, "ANULADO")/2, 116, 10, true, "ANULADO")
+	}
+
+	// Emisor y adquiriente
+	y := 140.0
+	pdf.Texto(40, y, 9, true, emisor.RazonSocial)
+	pdf.Texto(40, y+12, 9, false, emisor.Direccion)
+	y += 34
+	pdf.Linea(40, y-10, margenDerecho, y-10)
+
+	nombreCliente := strings.TrimSpace(cliente.Nombres + " " + cliente.Apellidos)
+	etiquetaNombre := "Cliente"
+	if cliente.TipoDocumento == "RUC" {
+		nombreCliente = cliente.RazonSocial
+		etiquetaNombre = "Razón social"
+	}
+	datos := [][2]string{
+		{etiquetaNombre, nombreCliente},
+		{cliente.TipoDocumento, cliente.NumeroDocumento},
+	}
+	if cliente.TipoDocumento == "RUC" && cliente.DireccionFiscal != "" {
+		datos = append(datos, [2]string{"Dirección", cliente.DireccionFiscal})
+	}
+	datos = append(datos,
+		[2]string{"Fecha de emisión", comprobante.FechaEmision.Format("02/01/2006 15:04")},
+		[2]string{"Moneda", "SOLES"},
+	)
+	if comprobante.NumeroReferencia != "" {
+		datos = append(datos,
+			[2]string{"Documento que modifica", nombresTipoComprobante[comprobante.TipoReferencia] + " " + comprobante.NumeroReferencia},
+			[2]string{"Motivo", comprobante.CodigoMotivo + " - " + comprobante.Motivo},
+		)
+	}
+	for _, dato := range datos {
+		pdf.Texto(40, y, 9, true, dato[0]+":")
+		pdf.Texto(150, y, 9, false, recortarTexto(dato[1], 80))
+		y += 13
+	}
+
+	// Detalle
+	y += 12
+	pdf.Linea(40, y-10, margenDerecho, y-10)
+	pdf.Texto(40, y, 9, true, "Cant.")
+	pdf.Texto(80, y, 9, true, "Descripción")
+	pdf.TextoDerecha(470, y, 9, true, "P. unitario")
+	pdf.TextoDerecha(margenDerecho, y, 9, true, "Importe")
+	pdf.Linea(40, y+4, margenDerecho, y+4)
+	y += 18
+
+	descripcion := fmt.Sprintf("Tour %s del %s - Reserva #%d", comprobante.TourNombre, comprobante.TourFecha.Format("02/01/2006"), comprobante.IDReserva)
+	if doc != nil && len(doc.Lineas) > 0 {
+		descripcion = doc.Lineas[0].Descripcion
+	}
+	pdf.Texto(40, y, 9, false, "1")
+	pdf.Texto(80, y, 9, false, recortarTexto(descripcion, 70))
+	pdf.TextoDerecha(470, y, 9, false, montoUBL(comprobante.Total))
+	pdf.TextoDerecha(margenDerecho, y, 9, false, montoUBL(comprobante.Total))
+	y += 10
+	pdf.Linea(40, y, margenDerecho, y)
+
+	// Totales con el desglose del IGV
+	y += 18
+	etiquetaBase := "Op. gravada"
+	etiquetaIGV := "IGV"
+	if comprobante.Afectacion == "INAFECTO" {
+		etiquetaBase = "Op. inafecta"
+	} else if comprobante.Afectacion == "EXONERADO" || comprobante.IGV.EsCero() {
+		etiquetaBase = "Op. exonerada"
+	} else if comprobante.Subtotal.EsPositivo() {
+		etiquetaIGV = fmt.Sprintf("IGV (%.0f%%)", comprobante.IGV.Float64()/comprobante.Subtotal.Float64()*100)
+	}
+	totales := [][2]string{
+		{etiquetaBase, montoUBL(comprobante.Subtotal)},
+		{etiquetaIGV, montoUBL(comprobante.IGV)},
+		{"Importe total", montoUBL(comprobante.Total)},
+	}
+	for i, total := range totales {
+		negrita := i == len(totales)-1
+		pdf.TextoDerecha(470, y, 10, negrita, total[0]+": S/")
+		pdf.TextoDerecha(margenDerecho, y, 10, negrita, total[1])
+		y += 14
+	}
+	pdf.Texto(40, y+4, 9, false, "SON: "+utils.MontoEnLetras(comprobante.Total.Float64(), comprobante.Total.Moneda()))
+	y += 30
+
+	// Código QR y hash de la firma, solo en comprobantes emitidos electrónicamente
+	if esElectronico {
+		qr, err := utils.GenerarQR(TextoQRComprobante(doc, electronico.HashCPE))
+		if err != nil {
+			return nil, err
+		}
+		pdf.QR(40, y, 100, qr)
+		pdf.Texto(150, y+30, 9, true, "Resumen: "+electronico.HashCPE)
+		pdf.Texto(150, y+44, 9, false, "Representación impresa de la "+strings.ToLower(titulo)+".")
+		pdf.Texto(150, y+56, 9, false, "Consulte su validez en www.sunat.gob.pe")
+		pdf.Texto(150, y+68, 9, false, "Estado SUNAT: "+electronico.EstadoSunat)
+	}
+
+	return pdf.Bytes(), nil
+}
+
+// ArmarVoucherReservaPDF genera el voucher de confirmación de una reserva con el detalle de pasajes
+// Si tokenPase no está vacío se imprime el QR del pase de abordar, de modo que el voucher sirve como ticket
+func ArmarVoucherReservaPDF(reserva *entidades.Reserva, sede *entidades.Sede, logo image.Image, tokenPase string) ([]byte, error) {
+	pdf := utils.NuevoDocumentoPDF()
+	margenDerecho := utils.AnchoPaginaA4 - 40
+
+	y := escribirCabeceraSede(pdf, sede, logo)
+	pdf.TextoDerecha(margenDerecho, 54, 14, true, fmt.Sprintf("RESERVA #%d", reserva.ID))
+	pdf.TextoDerecha(margenDerecho, 70, 10, true, reserva.Estado)
+	pdf.TextoDerecha(margenDerecho, 84, 9, false, "Registrada el "+reserva.FechaReserva.Format("02/01/2006 15:04"))
+
+	pdf.Linea(40, y-10, margenDerecho, y-10)
+	pdf.Texto(40, y+6, 12, true, "Voucher de reserva")
+	y += 28
+
+	horario := reserva.HoraInicioTour
+	if reserva.HoraFinTour != "" {
+		horario += " - " + reserva.HoraFinTour
+	}
+	datos := [][2]string{
+		{"Cliente", reserva.NombreCliente},
+		{"Tour", reserva.NombreTour},
+		{"Fecha", reserva.FechaTour},
+		{"Horario", horario},
+		{"Canal", reserva.NombreCanal},
+		{"Atendido por", reserva.NombreVendedor},
+	}
+	for _, dato := range datos {
+		pdf.Texto(40, y, 10, true, dato[0]+":")
+		pdf.Texto(150, y, 10, false, recortarTexto(dato[1], 70))
+		y += 14
+	}
+
+	// Pasajes y paquetes
+	y += 14
+	pdf.Texto(40, y, 9, true, "Pasajes")
+	pdf.TextoDerecha(400, y, 9, true, "Cantidad")
+	pdf.TextoDerecha(margenDerecho, y, 9, true, "Importe")
+	pdf.Linea(40, y+4, margenDerecho, y+4)
+	y += 18
+	totalPasajeros := 0
+	for _, pasaje := range reserva.CantidadPasajes {
+		pdf.Texto(40, y, 9, false, recortarTexto(pasaje.NombreTipo, 50))
+		pdf.TextoDerecha(400, y, 9, false, fmt.Sprintf("%d", pasaje.Cantidad))
+		totalPasajeros += pasaje.Cantidad
+		y += 13
+	}
+	for _, paquete := range reserva.Paquetes {
+		pdf.Texto(40, y, 9, false, recortarTexto(fmt.Sprintf("Paquete %s (%d pasajeros)", paquete.NombrePaquete, paquete.CantidadTotal), 50))
+		pdf.TextoDerecha(400, y, 9, false, fmt.Sprintf("%d", paquete.Cantidad))
+		pdf.TextoDerecha(margenDerecho, y, 9, false, montoUBL(paquete.Subtotal))
+		totalPasajeros += paquete.CantidadTotal * paquete.Cantidad
+		y += 13
+	}
+	pdf.Linea(40, y-6, margenDerecho, y-6)
+	y += 8
+	pdf.Texto(40, y, 10, true, fmt.Sprintf("Total de pasajeros: %d", totalPasajeros))
+	pdf.TextoDerecha(margenDerecho, y, 10, true, "Total: S/ "+montoUBL(reserva.TotalPagar))
+	y += 20
+
+	if reserva.Notas != "" {
+		pdf.Texto(40, y, 9, true, "Notas:")
+		pdf.Texto(80, y, 9, false, recortarTexto(reserva.Notas, 95))
+		y += 20
+	}
+
+	if tokenPase != "" {
+		qr, err := utils.GenerarQR(tokenPase)
+		if err != nil {
+			return nil, err
+		}
+		y += 10
+		pdf.QR(40, y, 150, qr)
+		pdf.Texto(200, y+60, 11, true, "Pase de abordar")
+		pdf.Texto(200, y+76, 9, false, "Presente este código al embarcar.")
+		pdf.Texto(200, y+88, 9, false, "Llegue al muelle 30 minutos antes de la salida.")
+	} else {
+		pdf.Texto(40, y, 9, false, "El pase de abordar estará disponible cuando la reserva esté confirmada.")
+	}
+
+	return pdf.Bytes(), nil
+}
+
+// DocumentoImpresoService genera los PDF que se entregan al cliente: comprobantes y vouchers de reserva
+type DocumentoImpresoService struct {
+	electronicoRepo *repositorios.ComprobanteElectronicoRepository
+	sedeRepo        *repositorios.SedeRepository
+	embarqueService *EmbarqueService
+	config          *config.Config
+	cliente         *http.Client
+
+	mutex sync.Mutex
+	logos map[string]image.Image // Logos ya descargados por URL
+}
+
+// NewDocumentoImpresoService crea una nueva instancia de DocumentoImpresoService
+func NewDocumentoImpresoService(
+	electronicoRepo *repositorios.ComprobanteElectronicoRepository,
+	sedeRepo *repositorios.SedeRepository,
+	embarqueService *EmbarqueService,
+	config *config.Config,
+) *DocumentoImpresoService {
+	return &DocumentoImpresoService{
+		electronicoRepo: electronicoRepo,
+		sedeRepo:        sedeRepo,
+		embarqueService: embarqueService,
+		config:          config,
+		cliente:         &http.Client{Timeout: 5 * time.Second},
+		logos:           map[string]image.Image{},
+	}
+}
+
+// logoSede descarga y reduce el logo de la sede; si no se puede obtener el documento se genera sin logo
+func (s *DocumentoImpresoService) logoSede(sede *entidades.Sede) image.Image {
+	if sede.ImageURL == "" {
+		return nil
+	}
+
+	s.mutex.Lock()
+	logo, ok := s.logos[sede.ImageURL]
+	s.mutex.Unlock()
+	if ok {
+		return logo
+	}
+
+	logo, err := s.descargarLogo(sede.ImageURL)
+	if err != nil {
+		log.Printf("No se pudo cargar el logo de la sede %d: %v", sede.ID, err)
+		return nil
+	}
+
+	s.mutex.Lock()
+	s.logos[sede.ImageURL] = logo
+	s.mutex.Unlock()
+	return logo
+}
+
+// descargarLogo obtiene una imagen JPEG o PNG por HTTP
+func (s *DocumentoImpresoService) descargarLogo(url string) (image.Image, error) {
+	resp, err := s.cliente.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("respuesta HTTP %d", resp.StatusCode)
+	}
+
+	img, _, err := image.Decode(io.LimitReader(resp.Body, tamanoMaximoLogo))
+	if err != nil {
+		return nil, errors.New("la imagen no es un JPEG o PNG válido")
+	}
+	return utils.ReducirImagen(img, pixelesMaximoLogo), nil
+}
+
+// GetDatosComprobante obtiene el comprobante y su cliente, para verificar el acceso antes de generar el PDF
+func (s *DocumentoImpresoService) GetDatosComprobante(idComprobante int) (*entidades.ComprobantePago, *entidades.Cliente, error) {
+	return s.electronicoRepo.GetDatosEmision(idComprobante)
+}
+
+// GenerarComprobantePDF genera la representación impresa de un comprobante
+func (s *DocumentoImpresoService) GenerarComprobantePDF(comprobante *entidades.ComprobantePago, cliente *entidades.Cliente) ([]byte, error) {
+	sede, err := s.sedeRepo.GetByID(comprobante.IDSede)
+	if err != nil {
+		return nil, err
+	}
+
+	emisor := entidades.EmisorElectronico{
+		RUC:             s.config.SunatRUC,
+		RazonSocial:     s.config.SunatRazonSocial,
+		NombreComercial: s.config.SunatNombreComercial,
+		Direccion:       s.config.SunatDireccion,
+		Ubigeo:          s.config.SunatUbigeo,
+	}
+
+	// Solo los comprobantes enviados a SUNAT llevan hash y QR
+	var doc *entidades.DocumentoElectronico
+	electronico, err := s.electronicoRepo.GetByComprobante(comprobante.ID)
+	if err == nil {
+		doc, err = ArmarDocumentoElectronico(comprobante, cliente, emisor)
+		if err != nil {
+			doc, electronico = nil, nil
+		}
+	} else if err == repositorios.ErrComprobanteSinEmision {
+		electronico = nil
+	} else {
+		return nil, err
+	}
+
+	return ArmarComprobantePDF(comprobante, cliente, emisor, sede, s.logoSede(sede), doc, electronico)
+}
+
+// GenerarVoucherPDF genera el voucher de una reserva; las reservas confirmadas incluyen el pase de abordar
+func (s *DocumentoImpresoService) GenerarVoucherPDF(reserva *entidades.Reserva) ([]byte, error) {
+	sede, err := s.sedeRepo.GetByID(reserva.IDSede)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenPase := ""
+	if reserva.Estado == "CONFIRMADA" {
+		pase, err := s.embarqueService.GenerarPase(reserva.ID)
+		if err != nil {
+			return nil, err
+		}
+		tokenPase = pase.Token
+	}
+
+	return ArmarVoucherReservaPDF(reserva, sede, s.logoSede(sede), tokenPase)
+}
@@ -0,0 +1,212 @@
+package servicios
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sistema-toursseft/internal/config"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"sistema-toursseft/internal/utils"
+	"sort"
+	"time"
+)
+
+// escalaQRPase es la cantidad de píxeles por módulo del QR del pase de abordar
+const escalaQRPase = 8
+
+// toleranciaRelojOffline es el adelanto máximo aceptado en la hora de un escaneo sin conexión
+const toleranciaRelojOffline = 5 * time.Minute
+
+// EmbarqueService maneja la lógica de negocio de pases de abordar y check-in en el muelle
+type EmbarqueService struct {
+	embarqueRepo *repositorios.EmbarqueRepository
+	config       *config.Config
+}
+
+// NewEmbarqueService crea una nueva instancia de EmbarqueService
+func NewEmbarqueService(embarqueRepo *repositorios.EmbarqueRepository, config *config.Config) *EmbarqueService {
+	return &EmbarqueService{
+		embarqueRepo: embarqueRepo,
+		config:       config,
+	}
+}
+
+// tokenPase firma el token del pase de una reserva; para los mismos datos siempre produce el mismo token
+func (s *EmbarqueService) tokenPase(idReserva int, idInstancia int, finTour time.Time) (string, time.Time, error) {
+	expira := finTour.Add(24 * time.Hour)
+	token, err := utils.GenerateBoardingPassToken(idReserva, idInstancia, expira, s.config)
+	return token, expira, err
+}
+
+// GenerarPase emite el pase de abordar firmado de una reserva confirmada
+// El pase vence un día después del fin del tour
+func (s *EmbarqueService) GenerarPase(idReserva int) (*entidades.PaseAbordar, error) {
+	estado, idInstancia, finTour, err := s.embarqueRepo.GetDatosPase(idReserva)
+	if err != nil {
+		return nil, err
+	}
+
+	if estado != "CONFIRMADA" {
+		return nil, errors.New("solo las reservas confirmadas tienen pase de abordar")
+	}
+
+	token, expira, err := s.tokenPase(idReserva, idInstancia, finTour)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entidades.PaseAbordar{
+		IDReserva:   idReserva,
+		IDInstancia: idInstancia,
+		Token:       token,
+		Expira:      expira,
+	}, nil
+}
+
+// GenerarPaseQR emite el pase de abordar de una reserva como imagen PNG con el token en un código QR
+func (s *EmbarqueService) GenerarPaseQR(idReserva int) ([]byte, error) {
+	pase, err := s.GenerarPase(idReserva)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.GenerarQRPNG(pase.Token, escalaQRPase)
+}
+
+// Checkin valida el pase escaneado y embarca la reserva
+// Si idChofer no es nil, solo se aceptan pases de instancias asignadas a ese chofer
+func (s *EmbarqueService) Checkin(solicitud *entidades.CheckinRequest, idUsuario int, idChofer *int) (*entidades.Embarque, error) {
+	claims, err := utils.ValidateBoardingPassToken(solicitud.Token, s.config)
+	if err != nil {
+		return nil, errors.New("pase de abordar inválido o vencido")
+	}
+
+	return s.embarqueRepo.Registrar(claims.IDReserva, claims.IDInstancia, idUsuario, idChofer, solicitud.IDPasajeros)
+}
+
+// GetReporteNoShow obtiene el reporte de embarque de una instancia: los check-in registrados
+// y las reservas confirmadas que no se presentaron
+func (s *EmbarqueService) GetReporteNoShow(idInstancia int) (*entidades.ReporteNoShow, error) {
+	reporte, err := s.embarqueRepo.GetReporteNoShow(idInstancia)
+	if err != nil {
+		return nil, err
+	}
+
+	reporte.Embarques, err = s.embarqueRepo.ListByInstancia(idInstancia)
+	if err != nil {
+		return nil, err
+	}
+
+	return reporte, nil
+}
+
+// GenerarPaqueteOffline arma y firma el manifiesto de las instancias de hoy del chofer
+// Cada reserva lleva la huella de su pase para validar los QR escaneados sin señal
+func (s *EmbarqueService) GenerarPaqueteOffline(idChofer int) (*entidades.PaqueteOfflineFirmado, error) {
+	paquete, err := s.embarqueRepo.GetPaqueteOffline(idChofer)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, instancia := range paquete.Instancias {
+		for _, reserva := range instancia.Reservas {
+			token, _, err := s.tokenPase(reserva.IDReserva, instancia.IDInstancia, instancia.FinTour)
+			if err != nil {
+				return nil, err
+			}
+			reserva.HuellaPase = utils.HuellaBoardingPass(token)
+		}
+	}
+
+	contenido, err := json.Marshal(paquete)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entidades.PaqueteOfflineFirmado{
+		Paquete: paquete,
+		Firma:   utils.SignOfflineBundle(contenido, s.config),
+	}, nil
+}
+
+// SincronizarEmbarques concilia un lote de escaneos hechos sin conexión con el estado del servidor
+// Los escaneos se procesan en orden cronológico, así ante dos escaneos del mismo pase gana el primero.
+// Un escaneo ya sincronizado o de una reserva que ya embarcó queda DUPLICADO; si la reserva se canceló,
+// se reprogramó o el pase no es válido queda RECHAZADO. Los resultados se devuelven en el orden recibido.
+func (s *EmbarqueService) SincronizarEmbarques(solicitud *entidades.SincronizarEmbarquesRequest, idUsuario int, idChofer *int) ([]*entidades.ResultadoSincronizacion, error) {
+	orden := make([]int, len(solicitud.Eventos))
+	for i := range orden {
+		orden[i] = i
+	}
+	sort.SliceStable(orden, func(a, b int) bool {
+		return solicitud.Eventos[orden[a]].FechaEscaneo.Before(solicitud.Eventos[orden[b]].FechaEscaneo)
+	})
+
+	resultados := make([]*entidades.ResultadoSincronizacion, len(solicitud.Eventos))
+	for _, i := range orden {
+		resultado, err := s.sincronizarEvento(&solicitud.Eventos[i], idUsuario, idChofer)
+		if err != nil {
+			return nil, err
+		}
+		resultados[i] = resultado
+	}
+
+	return resultados, nil
+}
+
+// sincronizarEvento concilia un escaneo; solo devuelve error ante fallas al consultar el servidor
+func (s *EmbarqueService) sincronizarEvento(evento *entidades.EventoEmbarqueOffline, idUsuario int, idChofer *int) (*entidades.ResultadoSincronizacion, error) {
+	resultado := &entidades.ResultadoSincronizacion{IDEvento: evento.IDEvento}
+
+	// Reenvío de un escaneo que ya se sincronizó
+	existente, err := s.embarqueRepo.GetByEvento(evento.IDEvento)
+	if err != nil {
+		return nil, err
+	}
+	if existente != nil {
+		resultado.IDReserva = existente.IDReserva
+		resultado.Estado = "DUPLICADO"
+		resultado.Mensaje = "el escaneo ya fue sincronizado"
+		resultado.Embarque = existente
+		return resultado, nil
+	}
+
+	if evento.FechaEscaneo.After(time.Now().Add(toleranciaRelojOffline)) {
+		resultado.Estado = "RECHAZADO"
+		resultado.Mensaje = "la fecha del escaneo está en el futuro"
+		return resultado, nil
+	}
+
+	claims, err := utils.ValidateBoardingPassTokenAt(evento.Token, evento.FechaEscaneo, s.config)
+	if err != nil {
+		resultado.Estado = "RECHAZADO"
+		resultado.Mensaje = "pase de abordar inválido o vencido"
+		return resultado, nil
+	}
+	resultado.IDReserva = claims.IDReserva
+
+	embarque, err := s.embarqueRepo.RegistrarOffline(claims.IDReserva, claims.IDInstancia, idUsuario, idChofer, evento)
+	if errors.Is(err, repositorios.ErrPaseUtilizado) {
+		existente, err := s.embarqueRepo.GetByReserva(claims.IDReserva)
+		if err != nil {
+			return nil, err
+		}
+		resultado.Estado = "DUPLICADO"
+		resultado.Mensaje = "la reserva ya había embarcado"
+		if existente != nil {
+			resultado.Mensaje = fmt.Sprintf("la reserva ya había embarcado el %s", existente.FechaEmbarque.Format("02/01/2006 15:04"))
+		}
+		resultado.Embarque = existente
+		return resultado, nil
+	}
+	if err != nil {
+		resultado.Estado = "RECHAZADO"
+		resultado.Mensaje = err.Error()
+		return resultado, nil
+	}
+
+	resultado.Estado = "REGISTRADO"
+	resultado.Embarque = embarque
+	return resultado, nil
+}
@@ -0,0 +1,198 @@
+package servicios
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sistema-toursseft/internal/config"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+)
+
+// codigosAfectacionIGV traduce la afectación al catálogo 07 de SUNAT
+var codigosAfectacionIGV = map[string]string{
+	"GRAVADO":   "10",
+	"EXONERADO": "20",
+	"INAFECTO":  "30",
+}
+
+// CalcularImpuestos calcula valor de venta, IGV y total de cada línea y de la venta completa
+// Los montos se redondean por línea al céntimo, con la mitad hacia arriba;
+// los totales son la suma de las líneas, como exige SUNAT. Con precios que incluyen IGV el total de cada
+// línea es exactamente precio × cantidad y el IGV es la diferencia con el valor de venta redondeado.
+func CalcularImpuestos(lineas []entidades.LineaImpuesto, configuracion *entidades.ConfiguracionImpuesto) (*entidades.CalculoImpuestos, error) {
+	codigo, ok := codigosAfectacionIGV[configuracion.Afectacion]
+	if !ok {
+		return nil, errors.New("afectación de IGV no válida")
+	}
+	if len(lineas) == 0 {
+		return nil, errors.New("no hay conceptos para calcular impuestos")
+	}
+
+	// Tasa en centésimas de punto porcentual: 18% es 1800
+	tasa := int64(math.Round(configuracion.TasaIGV * 100))
+	gravado := configuracion.Afectacion == "GRAVADO"
+	if gravado && tasa <= 0 {
+		return nil, errors.New("la tasa de IGV debe ser mayor a cero")
+	}
+
+	// Los totales se expresan en la moneda de los precios
+	cero := entidades.NuevoDinero(0, lineas[0].PrecioUnitario.Moneda())
+	calculo := &entidades.CalculoImpuestos{
+		IDSede:     configuracion.IDSede,
+		Afectacion: configuracion.Afectacion,
+		Lineas:     make([]entidades.DetalleLineaImpuesto, 0, len(lineas)),
+		Subtotal:   cero,
+		IGV:        cero,
+		Total:      cero,
+	}
+	if gravado {
+		calculo.TasaIGV = configuracion.TasaIGV
+	}
+
+	for _, linea := range lineas {
+		if linea.Cantidad <= 0 {
+			return nil, fmt.Errorf("la cantidad de %s debe ser mayor a cero", linea.Descripcion)
+		}
+		if linea.PrecioUnitario.EsNegativo() {
+			return nil, fmt.Errorf("el precio de %s no puede ser negativo", linea.Descripcion)
+		}
+
+		importe := linea.PrecioUnitario.Multiplicar(linea.Cantidad)
+		valor, igv := importe, cero
+		if gravado {
+			if configuracion.PreciosIncluyenIGV {
+				valor = importe.Prorratear(10000, 10000+tasa)
+				igv = importe.Restar(valor)
+			} else {
+				igv = importe.Prorratear(tasa, 10000)
+			}
+		}
+
+		calculo.Lineas = append(calculo.Lineas, entidades.DetalleLineaImpuesto{
+			Descripcion:      linea.Descripcion,
+			Cantidad:         linea.Cantidad,
+			CodigoAfectacion: codigo,
+			ValorVenta:       valor,
+			IGV:              igv,
+			Total:            valor.Sumar(igv),
+		})
+		calculo.Subtotal = calculo.Subtotal.Sumar(valor)
+		calculo.IGV = calculo.IGV.Sumar(igv)
+		calculo.Total = calculo.Total.Sumar(valor.Sumar(igv))
+	}
+
+	switch configuracion.Afectacion {
+	case "GRAVADO":
+		calculo.OperacionesGravadas = calculo.Subtotal
+	case "EXONERADO":
+		calculo.OperacionesExoneradas = calculo.Subtotal
+	case "INAFECTO":
+		calculo.OperacionesInafectas = calculo.Subtotal
+	}
+
+	return calculo, nil
+}
+
+// ImpuestoService maneja la configuración de IGV por sede y el cálculo de impuestos de las reservas
+type ImpuestoService struct {
+	impuestoRepo *repositorios.ImpuestoRepository
+	sedeRepo     *repositorios.SedeRepository
+	config       *config.Config
+}
+
+// NewImpuestoService crea una nueva instancia de ImpuestoService
+func NewImpuestoService(
+	impuestoRepo *repositorios.ImpuestoRepository,
+	sedeRepo *repositorios.SedeRepository,
+	config *config.Config,
+) *ImpuestoService {
+	return &ImpuestoService{
+		impuestoRepo: impuestoRepo,
+		sedeRepo:     sedeRepo,
+		config:       config,
+	}
+}
+
+// configuracionPorDefecto es la que se aplica a sedes sin configuración propia
+func (s *ImpuestoService) configuracionPorDefecto(idSede int) *entidades.ConfiguracionImpuesto {
+	return &entidades.ConfiguracionImpuesto{
+		IDSede:             idSede,
+		Afectacion:         "GRAVADO",
+		TasaIGV:            s.config.IGVPorcentaje,
+		PreciosIncluyenIGV: true,
+		PorDefecto:         true,
+	}
+}
+
+// GetConfiguracion obtiene la configuración de IGV vigente para una sede
+func (s *ImpuestoService) GetConfiguracion(idSede int) (*entidades.ConfiguracionImpuesto, error) {
+	configuracion, err := s.impuestoRepo.GetBySede(idSede)
+	if err == repositorios.ErrSinConfiguracionImpuesto {
+		sede, err := s.sedeRepo.GetByID(idSede)
+		if err != nil {
+			return nil, errors.New("la sede especificada no existe")
+		}
+		configuracion = s.configuracionPorDefecto(idSede)
+		configuracion.NombreSede = sede.Nombre
+		return configuracion, nil
+	}
+	return configuracion, err
+}
+
+// ActualizarConfiguracion guarda la configuración de IGV de una sede
+// Solo afecta a los comprobantes que se emitan después del cambio
+func (s *ImpuestoService) ActualizarConfiguracion(idSede int, configuracion *entidades.ActualizarConfiguracionImpuestoRequest) error {
+	if _, err := s.sedeRepo.GetByID(idSede); err != nil {
+		return errors.New("la sede especificada no existe")
+	}
+	return s.impuestoRepo.Guardar(idSede, configuracion)
+}
+
+// ListConfiguraciones lista la configuración vigente de todas las sedes
+func (s *ImpuestoService) ListConfiguraciones() ([]*entidades.ConfiguracionImpuesto, error) {
+	configuraciones, err := s.impuestoRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, configuracion := range configuraciones {
+		if configuracion.PorDefecto {
+			porDefecto := s.configuracionPorDefecto(configuracion.IDSede)
+			porDefecto.NombreSede = configuracion.NombreSede
+			configuraciones[i] = porDefecto
+		}
+	}
+
+	return configuraciones, nil
+}
+
+// CalcularReserva calcula los impuestos de una reserva con la configuración de la sede que emite
+// Las líneas son los pasajes y paquetes a su precio de lista; si ese precio ya no coincide con el total
+// registrado en la reserva (cambió la tarifa o hubo un descuento), se factura una sola línea por el total
+// de la reserva para no cobrar un monto distinto al pactado.
+func (s *ImpuestoService) CalcularReserva(idReserva int, idSede int) (*entidades.CalculoImpuestos, error) {
+	configuracion, err := s.GetConfiguracion(idSede)
+	if err != nil {
+		return nil, err
+	}
+
+	lineas, totalPagar, descripcionTour, err := s.impuestoRepo.GetLineasReserva(idReserva)
+	if err != nil {
+		return nil, err
+	}
+
+	suma := entidades.NuevoDinero(0, totalPagar.Moneda())
+	for _, linea := range lineas {
+		suma = suma.Sumar(linea.PrecioUnitario.Multiplicar(linea.Cantidad))
+	}
+	if len(lineas) == 0 || !suma.Igual(totalPagar) {
+		lineas = []entidades.LineaImpuesto{{
+			Descripcion:    fmt.Sprintf("%s - Reserva #%d", descripcionTour, idReserva),
+			Cantidad:       1,
+			PrecioUnitario: totalPagar,
+		}}
+	}
+
+	return CalcularImpuestos(lineas, configuracion)
+}
@@ -7,13 +7,15 @@ import (
 
 // InstanciaTourService maneja la lógica de negocio para instancias de tour
 type InstanciaTourService struct {
-	instanciaTourRepo *repositorios.InstanciaTourRepository
+	instanciaTourRepo  *repositorios.InstanciaTourRepository
+	listaEsperaService *ListaEsperaService
 }
 
 // NewInstanciaTourService crea una nueva instancia de InstanciaTourService
-func NewInstanciaTourService(instanciaTourRepo *repositorios.InstanciaTourRepository) *InstanciaTourService {
+func NewInstanciaTourService(instanciaTourRepo *repositorios.InstanciaTourRepository, listaEsperaService *ListaEsperaService) *InstanciaTourService {
 	return &InstanciaTourService{
-		instanciaTourRepo: instanciaTourRepo,
+		instanciaTourRepo:  instanciaTourRepo,
+		listaEsperaService: listaEsperaService,
 	}
 }
 
@@ -28,8 +30,17 @@ func (s *InstanciaTourService) Create(instancia *entidades.NuevaInstanciaTourReq
 }
 
 // Update actualiza una instancia de tour existente
+// Si se modificó el cupo, los cupos libres se ofrecen a la lista de espera
 func (s *InstanciaTourService) Update(id int, instancia *entidades.ActualizarInstanciaTourRequest) error {
-	return s.instanciaTourRepo.Update(id, instancia)
+	if err := s.instanciaTourRepo.Update(id, instancia); err != nil {
+		return err
+	}
+
+	if instancia.CupoDisponible != nil && s.listaEsperaService != nil {
+		s.listaEsperaService.ProcesarInstancia(id)
+	}
+
+	return nil
 }
 
 // Delete elimina una instancia de tour (soft delete)
@@ -0,0 +1,328 @@
+package servicios
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sistema-toursseft/internal/entidades"
+	"strings"
+	"time"
+)
+
+// IzipayService maneja la integración con la API REST de Izipay (pasarela de tarjetas)
+// El cobro se hace en un formulario embebido que se inicializa con el formToken de CreatePayment
+type IzipayService struct {
+	ApiBaseURL   string
+	Usuario      string // Identificador de la tienda
+	Clave        string // Contraseña de la API REST; también firma las notificaciones IPN
+	ClavePublica string
+	ClaveHMAC    string // Firma los datos que el formulario devuelve al navegador
+}
+
+// NewIzipayService crea una nueva instancia del servicio de Izipay
+func NewIzipayService(apiBaseURL, usuario, clave, clavePublica, claveHMAC string) *IzipayService {
+	return &IzipayService{
+		ApiBaseURL:   strings.TrimRight(apiBaseURL, "/"),
+		Usuario:      usuario,
+		Clave:        clave,
+		ClavePublica: clavePublica,
+		ClaveHMAC:    claveHMAC,
+	}
+}
+
+// izipayRespuesta es el sobre común de las respuestas de la API
+type izipayRespuesta struct {
+	Status string          `json:"status"` // SUCCESS o ERROR
+	Answer json.RawMessage `json:"answer"`
+}
+
+// izipayError es el contenido de answer cuando la operación falla
+type izipayError struct {
+	ErrorCode    string `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// izipayTransaccion representa una transacción de Izipay
+type izipayTransaccion struct {
+	UUID           string    `json:"uuid"`
+	Amount         int64     `json:"amount"` // En céntimos
+	Currency       string    `json:"currency"`
+	Status         string    `json:"status"`         // PAID, UNPAID, RUNNING, PARTIALLY_PAID
+	DetailedStatus string    `json:"detailedStatus"` // AUTHORISED, CAPTURED, REFUSED, CANCELLED...
+	OperationType  string    `json:"operationType"`  // DEBIT (cobro) o CREDIT (reembolso)
+	CreationDate   time.Time `json:"creationDate"`
+	OrderDetails   struct {
+		OrderID string `json:"orderId"`
+	} `json:"orderDetails"`
+	TransactionDetails struct {
+		ParentTransactionUUID string `json:"parentTransactionUuid"`
+	} `json:"transactionDetails"`
+}
+
+// izipayPago es el contenido de kr-answer en las notificaciones IPN
+type izipayPago struct {
+	OrderStatus  string `json:"orderStatus"`
+	OrderDetails struct {
+		OrderID          string `json:"orderId"`
+		OrderTotalAmount int64  `json:"orderTotalAmount"` // En céntimos
+	} `json:"orderDetails"`
+	Transactions []izipayTransaccion `json:"transactions"`
+}
+
+// llamar invoca una operación de la API REST y deserializa su respuesta en destino
+func (s *IzipayService) llamar(operacion string, solicitud interface{}, destino interface{}) error {
+	jsonData, err := json.Marshal(solicitud)
+	if err != nil {
+		return err
+	}
+
+	// Crear la solicitud HTTP
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api-payment/V4/%s", s.ApiBaseURL, operacion), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+
+	// Configurar headers
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(s.Usuario, s.Clave)
+
+	// Realizar la solicitud
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Leer respuesta
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error en %s: %s - código: %d", operacion, string(body), resp.StatusCode)
+	}
+
+	var respuesta izipayRespuesta
+	if err := json.Unmarshal(body, &respuesta); err != nil {
+		return err
+	}
+
+	// Izipay responde 200 también a las operaciones rechazadas
+	if respuesta.Status != "SUCCESS" {
+		var detalle izipayError
+		_ = json.Unmarshal(respuesta.Answer, &detalle)
+		return fmt.Errorf("error en %s: %s %s", operacion, detalle.ErrorCode, detalle.ErrorMessage)
+	}
+
+	return json.Unmarshal(respuesta.Answer, destino)
+}
+
+// Nombre identifica a Izipay entre las pasarelas de pago
+func (s *IzipayService) Nombre() string {
+	return entidades.PasarelaIzipay
+}
+
+// CrearCheckout crea el formToken con el que el frontend muestra el formulario de pago de una reserva
+func (s *IzipayService) CrearCheckout(solicitud *entidades.SolicitudCheckout) (*entidades.CheckoutPasarela, error) {
+	cliente := map[string]interface{}{}
+	if solicitud.Cliente != nil {
+		cliente["email"] = solicitud.Cliente.Correo
+		cliente["reference"] = fmt.Sprintf("%d", solicitud.Cliente.ID)
+		cliente["billingDetails"] = map[string]string{
+			"firstName":       solicitud.Cliente.Nombres,
+			"lastName":        solicitud.Cliente.Apellidos,
+			"cellPhoneNumber": solicitud.Cliente.NumeroCelular,
+			"identityCode":    solicitud.Cliente.NumeroDocumento,
+		}
+	}
+
+	pedido := map[string]interface{}{
+		"amount":       solicitud.Monto.Centimos(),
+		"currency":     solicitud.Monto.Moneda(),
+		"orderId":      entidades.ReferenciaReserva(solicitud.IDReserva),
+		"customer":     cliente,
+		"ipnTargetUrl": fmt.Sprintf("%s/api/webhook/izipay", solicitud.FrontendURL),
+		"metadata":     map[string]string{"descripcion": solicitud.Descripcion},
+	}
+
+	var respuesta struct {
+		FormToken string `json:"formToken"`
+	}
+	if err := s.llamar("Charge/CreatePayment", pedido, &respuesta); err != nil {
+		return nil, err
+	}
+
+	return &entidades.CheckoutPasarela{
+		Pasarela:     entidades.PasarelaIzipay,
+		ID:           respuesta.FormToken,
+		ClavePublica: s.ClavePublica,
+	}, nil
+}
+
+// ObtenerPago consulta una transacción en Izipay y traduce su estado
+func (s *IzipayService) ObtenerPago(idPago string) (*entidades.PagoPasarela, error) {
+	var transaccion izipayTransaccion
+	if err := s.llamar("Transaction/Get", map[string]string{"uuid": idPago}, &transaccion); err != nil {
+		return nil, err
+	}
+
+	return pagoPasarelaIzipay(&transaccion, transaccion.OrderDetails.OrderID, 0), nil
+}
+
+// BuscarPagos lista los cobros del pedido de Izipay con el que se envió una reserva
+// Los reembolsos del pedido no se listan aparte: un reembolso total deja DEVUELTO el cobro que reembolsa
+func (s *IzipayService) BuscarPagos(referencia string) ([]*entidades.PagoPasarela, error) {
+	var pedido struct {
+		OrderID      string              `json:"orderId"`
+		Transactions []izipayTransaccion `json:"transactions"`
+	}
+	if err := s.llamar("Order/Get", map[string]string{"orderId": referencia}, &pedido); err != nil {
+		return nil, err
+	}
+
+	pagos := []*entidades.PagoPasarela{}
+	cobros := map[string]*izipayTransaccion{}
+	for i := range pedido.Transactions {
+		transaccion := &pedido.Transactions[i]
+		if transaccion.OperationType != "CREDIT" {
+			cobros[transaccion.UUID] = transaccion
+			pagos = append(pagos, pagoPasarelaIzipay(transaccion, referencia, 0))
+		}
+	}
+
+	// Aplicar los reembolsos totales sobre los cobros que reembolsan
+	for i := range pedido.Transactions {
+		transaccion := &pedido.Transactions[i]
+		cobro, ok := cobros[transaccion.TransactionDetails.ParentTransactionUUID]
+		if transaccion.OperationType != "CREDIT" || !ok {
+			continue
+		}
+		reembolso := pagoPasarelaIzipay(transaccion, referencia, cobro.Amount)
+		if reembolso.Estado != entidades.EstadoPasarelaDevuelto {
+			continue
+		}
+		for _, pago := range pagos {
+			if pago.ID == reembolso.ID {
+				pago.Estado = reembolso.Estado
+				pago.EstadoOriginal = reembolso.EstadoOriginal
+			}
+		}
+	}
+
+	return pagos, nil
+}
+
+// pagoPasarelaIzipay traduce una transacción de Izipay; un reembolso (CREDIT) se informa como la devolución
+// de la transacción que reembolsa. Si se conoce el total del pedido, un reembolso parcial queda PENDIENTE:
+// los reembolsos parciales se registran con las devoluciones, no revierten el pago completo
+func pagoPasarelaIzipay(transaccion *izipayTransaccion, orderID string, totalPedido int64) *entidades.PagoPasarela {
+	pago := &entidades.PagoPasarela{
+		Pasarela:          entidades.PasarelaIzipay,
+		ID:                transaccion.UUID,
+		Estado:            estadoPasarelaIzipay(transaccion.DetailedStatus),
+		EstadoOriginal:    transaccion.DetailedStatus,
+		Monto:             entidades.NuevoDinero(transaccion.Amount, transaccion.Currency),
+		ReferenciaExterna: orderID,
+		Fecha:             transaccion.CreationDate,
+	}
+
+	if transaccion.OperationType == "CREDIT" && transaccion.TransactionDetails.ParentTransactionUUID != "" {
+		pago.ID = transaccion.TransactionDetails.ParentTransactionUUID
+		pago.EstadoOriginal = "CREDIT_" + transaccion.DetailedStatus
+		pago.Estado = entidades.EstadoPasarelaPendiente
+		parcial := totalPedido > 0 && transaccion.Amount < totalPedido
+		if estadoPasarelaIzipay(transaccion.DetailedStatus) == entidades.EstadoPasarelaAprobado && !parcial {
+			pago.Estado = entidades.EstadoPasarelaDevuelto
+		}
+	}
+
+	return pago
+}
+
+// estadoPasarelaIzipay traduce el estado detallado de una transacción de Izipay al de las pasarelas
+func estadoPasarelaIzipay(detailedStatus string) string {
+	switch detailedStatus {
+	case "AUTHORISED", "CAPTURED", "ACCEPTED":
+		return entidades.EstadoPasarelaAprobado
+	case "REFUSED", "CANCELLED", "EXPIRED", "ERROR":
+		return entidades.EstadoPasarelaRechazado
+	default:
+		return entidades.EstadoPasarelaPendiente
+	}
+}
+
+// Reembolsar reembolsa total o parcialmente una transacción; si aún no se liquidó, Izipay la anula
+// Izipay no acepta claves de idempotencia: se envían como comentario para rastrear el reembolso
+func (s *IzipayService) Reembolsar(idPago string, monto entidades.Dinero, claveIdempotencia string) (*entidades.ReembolsoPasarela, error) {
+	solicitud := map[string]interface{}{
+		"uuid":           idPago,
+		"amount":         monto.Centimos(),
+		"currency":       monto.Moneda(),
+		"resolutionMode": "AUTO",
+		"comment":        claveIdempotencia,
+	}
+
+	var transaccion izipayTransaccion
+	if err := s.llamar("Transaction/CancelOrRefund", solicitud, &transaccion); err != nil {
+		return nil, err
+	}
+
+	return &entidades.ReembolsoPasarela{
+		ID:     transaccion.UUID,
+		Monto:  entidades.NuevoDinero(transaccion.Amount, transaccion.Currency),
+		Estado: transaccion.DetailedStatus,
+	}, nil
+}
+
+// VerificarWebhook valida una notificación IPN de Izipay
+// Llega como formulario con kr-answer (el pago en JSON) y kr-hash, un HMAC-SHA256 de kr-answer con la
+// contraseña de la API (kr-hash-key=password) o con la clave HMAC (kr-hash-key=sha256_hmac)
+func (s *IzipayService) VerificarWebhook(notificacion *entidades.NotificacionPasarela) (*entidades.EventoPasarela, error) {
+	formulario, err := url.ParseQuery(string(notificacion.Cuerpo))
+	if err != nil {
+		return nil, ErrNotificacionIncompleta
+	}
+
+	respuesta := formulario.Get("kr-answer")
+	firma := formulario.Get("kr-hash")
+	if respuesta == "" || firma == "" {
+		return nil, ErrNotificacionIncompleta
+	}
+
+	clave := s.Clave
+	if formulario.Get("kr-hash-key") == "sha256_hmac" {
+		clave = s.ClaveHMAC
+	}
+	if clave == "" {
+		return nil, errors.New("clave de verificación de notificaciones de Izipay no configurada")
+	}
+
+	mac := hmac.New(sha256.New, []byte(clave))
+	mac.Write([]byte(respuesta))
+	esperada := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(esperada), []byte(strings.ToLower(firma))) {
+		return nil, errors.New("firma del webhook inválida")
+	}
+
+	var pago izipayPago
+	if err := json.Unmarshal([]byte(respuesta), &pago); err != nil || len(pago.Transactions) == 0 {
+		return nil, ErrNotificacionIncompleta
+	}
+
+	pagoPasarela := pagoPasarelaIzipay(&pago.Transactions[0], pago.OrderDetails.OrderID, pago.OrderDetails.OrderTotalAmount)
+	return &entidades.EventoPasarela{
+		IDPago:         pagoPasarela.ID,
+		IDNotificacion: pago.Transactions[0].UUID,
+		Tipo:           "payment",
+		Pago:           pagoPasarela,
+	}, nil
+}
@@ -0,0 +1,160 @@
+package servicios
+
+import (
+	"errors"
+	"log"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+)
+
+// NotificadorListaEspera envía al cliente el aviso de que tiene cupos ofertados
+type NotificadorListaEspera interface {
+	NotificarOferta(entrada *entidades.ListaEspera) error
+}
+
+// NotificadorListaEsperaLog registra las ofertas en el log del servidor
+// Se usa mientras no haya un canal de envío de correos o mensajes configurado
+type NotificadorListaEsperaLog struct{}
+
+// NotificarOferta escribe en el log los datos de contacto y la vigencia de la oferta
+func (n *NotificadorListaEsperaLog) NotificarOferta(entrada *entidades.ListaEspera) error {
+	vence := ""
+	if entrada.FechaExpiracionOferta != nil {
+		vence = entrada.FechaExpiracionOferta.Format("02/01/2006 15:04")
+	}
+	log.Printf("Lista de espera %d: oferta de %d cupos para %s (%s, %s) en %s %s %s, vence %s",
+		entrada.ID, entrada.CantidadPasajeros, entrada.NombreCliente, entrada.CorreoCliente, entrada.CelularCliente,
+		entrada.NombreTour, entrada.FechaTour, entrada.HoraTour, vence)
+	return nil
+}
+
+// ListaEsperaService maneja la lógica de negocio para la lista de espera
+type ListaEsperaService struct {
+	listaEsperaRepo *repositorios.ListaEsperaRepository
+	clienteRepo     *repositorios.ClienteRepository
+	notificador     NotificadorListaEspera
+}
+
+// NewListaEsperaService crea una nueva instancia de ListaEsperaService
+func NewListaEsperaService(
+	listaEsperaRepo *repositorios.ListaEsperaRepository,
+	clienteRepo *repositorios.ClienteRepository,
+	notificador NotificadorListaEspera,
+) *ListaEsperaService {
+	return &ListaEsperaService{
+		listaEsperaRepo: listaEsperaRepo,
+		clienteRepo:     clienteRepo,
+		notificador:     notificador,
+	}
+}
+
+// Registrar inscribe a un cliente en la lista de espera de una instancia
+func (s *ListaEsperaService) Registrar(solicitud *entidades.NuevaListaEsperaRequest, idUsuario *int) (int, error) {
+	// Verificar que el cliente existe
+	_, err := s.clienteRepo.GetByID(solicitud.IDCliente)
+	if err != nil {
+		return 0, errors.New("el cliente especificado no existe")
+	}
+
+	return s.listaEsperaRepo.Create(solicitud, idUsuario)
+}
+
+// GetByID obtiene una entrada de la lista de espera por su ID
+func (s *ListaEsperaService) GetByID(id int) (*entidades.ListaEspera, error) {
+	return s.listaEsperaRepo.GetByID(id)
+}
+
+// GetSedeInstancia obtiene la sede de una instancia de tour
+func (s *ListaEsperaService) GetSedeInstancia(idInstancia int) (int, error) {
+	return s.listaEsperaRepo.GetSedeInstancia(idInstancia)
+}
+
+// Cancelar retira una entrada de la lista de espera
+// Si liberó cupos de una oferta, se ofrecen al siguiente en la lista
+func (s *ListaEsperaService) Cancelar(id int) error {
+	entrada, err := s.listaEsperaRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.listaEsperaRepo.Cancelar(id); err != nil {
+		return err
+	}
+
+	if entrada.Estado == "OFERTADA" {
+		s.ProcesarInstancia(entrada.IDInstancia)
+	}
+
+	return nil
+}
+
+// Convertir crea la reserva de una entrada con oferta vigente
+func (s *ListaEsperaService) Convertir(id int, reserva *entidades.NuevaReservaRequest) (int, error) {
+	entrada, err := s.listaEsperaRepo.GetByID(id)
+	if err != nil {
+		return 0, err
+	}
+
+	idReserva, err := s.listaEsperaRepo.ConvertirEnReserva(id, reserva)
+	if err != nil {
+		return 0, err
+	}
+
+	// Si la reserva usó menos cupos de los ofertados, el sobrante pasa al siguiente en la lista
+	s.ProcesarInstancia(entrada.IDInstancia)
+
+	return idReserva, nil
+}
+
+// ProcesarInstancia ofrece los cupos libres de una instancia a su lista de espera y notifica a los clientes
+// Los errores se registran en el log para no afectar la operación que liberó los cupos
+func (s *ListaEsperaService) ProcesarInstancia(idInstancia int) []int {
+	ofertadas, err := s.listaEsperaRepo.OfrecerCupos(idInstancia)
+	if err != nil {
+		log.Printf("Error al ofrecer cupos de la instancia %d a la lista de espera: %v", idInstancia, err)
+		return nil
+	}
+
+	for _, id := range ofertadas {
+		entrada, err := s.listaEsperaRepo.GetByID(id)
+		if err != nil {
+			log.Printf("Error al obtener la entrada %d de la lista de espera: %v", id, err)
+			continue
+		}
+		if err := s.notificador.NotificarOferta(entrada); err != nil {
+			log.Printf("Error al notificar la oferta de la lista de espera %d: %v", id, err)
+		}
+	}
+
+	return ofertadas
+}
+
+// ProcesarPendientes vence las ofertas no aprovechadas y ofrece los cupos libres de todas las instancias
+// con clientes en espera. Retorna los IDs de las entradas ofertadas
+func (s *ListaEsperaService) ProcesarPendientes() ([]int, error) {
+	if _, err := s.listaEsperaRepo.ExpirarOfertas(); err != nil {
+		return nil, err
+	}
+
+	instancias, err := s.listaEsperaRepo.ListInstanciasPendientes()
+	if err != nil {
+		return nil, err
+	}
+
+	ofertadas := []int{}
+	for _, idInstancia := range instancias {
+		ofertadas = append(ofertadas, s.ProcesarInstancia(idInstancia)...)
+	}
+
+	return ofertadas, nil
+}
+
+// ListByInstancia lista la lista de espera de una instancia
+func (s *ListaEsperaService) ListByInstancia(idInstancia int) ([]*entidades.ListaEspera, error) {
+	return s.listaEsperaRepo.ListByInstancia(idInstancia)
+}
+
+// ListByCliente lista las entradas de lista de espera de un cliente
+func (s *ListaEsperaService) ListByCliente(idCliente int) ([]*entidades.ListaEspera, error) {
+	return s.listaEsperaRepo.ListByCliente(idCliente)
+}
@@ -2,29 +2,36 @@ package servicios
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
+	"net/url"
 	"sistema-toursseft/internal/entidades"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// MercadoPagoService maneja la integración con Mercado Pago
+// MercadoPagoService maneja la integración con Mercado Pago; es una de las pasarelas de pago (PaymentGateway)
 type MercadoPagoService struct {
-	AccessToken string
-	PublicKey   string
-	ApiBaseURL  string
+	AccessToken   string
+	PublicKey     string
+	WebhookSecret string
+	ApiBaseURL    string
 }
 
-// NewMercadoPagoService crea una nueva instancia del servicio de Mercado Pago
-func NewMercadoPagoService() *MercadoPagoService {
+// NewMercadoPagoService crea una nueva instancia del servicio de Mercado Pago con sus credenciales
+func NewMercadoPagoService(accessToken, publicKey, webhookSecret string) *MercadoPagoService {
 	return &MercadoPagoService{
-		AccessToken: os.Getenv("MERCADOPAGO_ACCESS_TOKEN"),
-		PublicKey:   os.Getenv("MERCADOPAGO_PUBLIC_KEY"),
-		ApiBaseURL:  "https://api.mercadopago.com",
+		AccessToken:   accessToken,
+		PublicKey:     publicKey,
+		WebhookSecret: webhookSecret,
+		ApiBaseURL:    "https://api.mercadopago.com",
 	}
 }
 
@@ -136,7 +143,7 @@ type PaymentResponse struct {
 // CreatePreference crea una preferencia de pago en Mercado Pago
 func (s *MercadoPagoService) CreatePreference(
 	tourNombre string,
-	monto float64,
+	monto entidades.Dinero,
 	idReserva int,
 	cliente *entidades.Cliente,
 	frontendURL string,
@@ -151,8 +158,8 @@ func (s *MercadoPagoService) CreatePreference(
 			Title:       fmt.Sprintf("Reserva: %s", tourNombre),
 			Description: "Reserva de tour en Tours Perú",
 			Quantity:    1,
-			CurrencyID:  "PEN", // Soles peruanos
-			UnitPrice:   monto,
+			CurrencyID:  monto.Moneda(),
+			UnitPrice:   monto.Float64(),
 		},
 	}
 
@@ -196,7 +203,7 @@ func (s *MercadoPagoService) CreatePreference(
 		AutoReturn:          "approved",
 		PaymentMethods:      paymentMethods,
 		NotificationURL:     fmt.Sprintf("%s/api/webhook/mercadopago", frontendURL),
-		ExternalReference:   fmt.Sprintf("RESERVA-%d", idReserva),
+		ExternalReference:   entidades.ReferenciaReserva(idReserva),
 		StatementDescriptor: "TOURS PERU",
 	}
 
@@ -288,6 +295,117 @@ func (s *MercadoPagoService) GetPaymentInfo(paymentId string) (*PaymentResponse,
 	return &paymentResp, nil
 }
 
+// PaymentSearchResponse representa la respuesta de la búsqueda de pagos de Mercado Pago
+type PaymentSearchResponse struct {
+	Results []PaymentResponse `json:"results"`
+}
+
+// SearchPaymentsByReference busca los pagos hechos con una referencia externa (external_reference)
+// Sirve para encontrar los pagos de una reserva cuando no llegó su notificación
+func (s *MercadoPagoService) SearchPaymentsByReference(externalReference string) ([]PaymentResponse, error) {
+	// Construir URL de búsqueda
+	parametros := url.Values{}
+	parametros.Set("external_reference", externalReference)
+	parametros.Set("sort", "date_created")
+	parametros.Set("criteria", "desc")
+	searchURL := fmt.Sprintf("%s/v1/payments/search?%s", s.ApiBaseURL, parametros.Encode())
+
+	// Crear la solicitud HTTP
+	req, err := http.NewRequest("GET", searchURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Configurar headers
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.AccessToken))
+
+	// Realizar la solicitud
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Leer respuesta
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verificar código de respuesta
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error al buscar pagos: %s - código: %d", string(body), resp.StatusCode)
+	}
+
+	// Deserializar respuesta
+	var searchResp PaymentSearchResponse
+	err = json.Unmarshal(body, &searchResp)
+	if err != nil {
+		return nil, err
+	}
+
+	return searchResp.Results, nil
+}
+
+// RefundResponse representa la respuesta de Mercado Pago al crear un reembolso
+type RefundResponse struct {
+	ID        int64   `json:"id"`
+	PaymentID int64   `json:"payment_id"`
+	Amount    float64 `json:"amount"`
+	Status    string  `json:"status"`
+}
+
+// CrearDevolucion solicita a Mercado Pago el reembolso total o parcial de un pago
+// La clave de idempotencia evita reembolsos duplicados si la operación se reintenta
+func (s *MercadoPagoService) CrearDevolucion(paymentId string, monto entidades.Dinero, claveIdempotencia string) (*RefundResponse, error) {
+	refundURL := fmt.Sprintf("%s/v1/payments/%s/refunds", s.ApiBaseURL, paymentId)
+
+	jsonData, err := json.Marshal(map[string]float64{"amount": monto.Float64()})
+	if err != nil {
+		return nil, err
+	}
+
+	// Crear la solicitud HTTP
+	req, err := http.NewRequest("POST", refundURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	// Configurar headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.AccessToken))
+	req.Header.Set("X-Idempotency-Key", claveIdempotencia)
+
+	// Realizar la solicitud
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Leer respuesta
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verificar código de respuesta
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("error al crear reembolso: %s - código: %d", string(body), resp.StatusCode)
+	}
+
+	// Deserializar respuesta
+	var refundResp RefundResponse
+	err = json.Unmarshal(body, &refundResp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &refundResp, nil
+}
+
 // MapMercadoPagoStatusToInternal mapea los estados de Mercado Pago a estados internos del sistema
 func (s *MercadoPagoService) MapMercadoPagoStatusToInternal(mpStatus string) string {
 	switch mpStatus {
@@ -302,6 +420,53 @@ func (s *MercadoPagoService) MapMercadoPagoStatusToInternal(mpStatus string) str
 	}
 }
 
+// VerificarFirmaWebhook valida la cabecera x-signature de una notificación de Mercado Pago
+// La firma es un HMAC-SHA256 del manifiesto "id:<data.id>;request-id:<x-request-id>;ts:<ts>;"
+func (s *MercadoPagoService) VerificarFirmaWebhook(xSignature, xRequestID, dataID string) error {
+	if s.WebhookSecret == "" {
+		return errors.New("clave secreta del webhook de Mercado Pago no configurada")
+	}
+
+	// Extraer ts y v1 de la cabecera (formato "ts=...,v1=...")
+	var ts, v1 string
+	for _, parte := range strings.Split(xSignature, ",") {
+		clave, valor, ok := strings.Cut(strings.TrimSpace(parte), "=")
+		if !ok {
+			continue
+		}
+		switch clave {
+		case "ts":
+			ts = valor
+		case "v1":
+			v1 = valor
+		}
+	}
+
+	if ts == "" || v1 == "" {
+		return errors.New("firma del webhook ausente o incompleta")
+	}
+
+	// Construir el manifiesto omitiendo las partes que no vienen en la notificación
+	manifiesto := ""
+	if dataID != "" {
+		manifiesto += fmt.Sprintf("id:%s;", strings.ToLower(dataID))
+	}
+	if xRequestID != "" {
+		manifiesto += fmt.Sprintf("request-id:%s;", xRequestID)
+	}
+	manifiesto += fmt.Sprintf("ts:%s;", ts)
+
+	mac := hmac.New(sha256.New, []byte(s.WebhookSecret))
+	mac.Write([]byte(manifiesto))
+	esperada := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(esperada), []byte(strings.ToLower(v1))) {
+		return errors.New("firma del webhook inválida")
+	}
+
+	return nil
+}
+
 // ProcessPaymentWebhook procesa la notificación de webhook de Mercado Pago
 func (s *MercadoPagoService) ProcessPaymentWebhook(notification *PaymentNotification) (*PaymentResponse, error) {
 	if notification.Type != "payment" {
@@ -320,7 +485,7 @@ func (s *MercadoPagoService) ProcessPaymentWebhook(notification *PaymentNotifica
 // GeneratePreferenceForExistingReserva genera una preferencia de pago para una reserva existente
 func (s *MercadoPagoService) GeneratePreferenceForExistingReserva(
 	idReserva int,
-	monto float64,
+	monto entidades.Dinero,
 	cliente *entidades.Cliente,
 	frontendURL string,
 ) (*PreferenceResponse, error) {
@@ -330,3 +495,127 @@ func (s *MercadoPagoService) GeneratePreferenceForExistingReserva(
 
 	return s.CreatePreference(tourNombre, monto, idReserva, cliente, frontendURL)
 }
+
+// Nombre identifica a Mercado Pago entre las pasarelas de pago
+func (s *MercadoPagoService) Nombre() string {
+	return entidades.PasarelaMercadoPago
+}
+
+// CrearCheckout crea la preferencia de pago de una reserva
+func (s *MercadoPagoService) CrearCheckout(solicitud *entidades.SolicitudCheckout) (*entidades.CheckoutPasarela, error) {
+	preferencia, err := s.CreatePreference(solicitud.Descripcion, solicitud.Monto, solicitud.IDReserva, solicitud.Cliente, solicitud.FrontendURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entidades.CheckoutPasarela{
+		Pasarela:      entidades.PasarelaMercadoPago,
+		ID:            preferencia.ID,
+		URLPago:       preferencia.InitPoint,
+		URLPagoPrueba: preferencia.SandboxInitPoint,
+		ClavePublica:  s.PublicKey,
+	}, nil
+}
+
+// ObtenerPago consulta un pago en Mercado Pago y traduce su estado
+func (s *MercadoPagoService) ObtenerPago(idPago string) (*entidades.PagoPasarela, error) {
+	pago, err := s.GetPaymentInfo(idPago)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagoPasarelaMercadoPago(pago), nil
+}
+
+// BuscarPagos lista los pagos de Mercado Pago hechos con una referencia externa, del más reciente al más antiguo
+func (s *MercadoPagoService) BuscarPagos(referencia string) ([]*entidades.PagoPasarela, error) {
+	pagos, err := s.SearchPaymentsByReference(referencia)
+	if err != nil {
+		return nil, err
+	}
+
+	resultado := make([]*entidades.PagoPasarela, 0, len(pagos))
+	for i := range pagos {
+		resultado = append(resultado, pagoPasarelaMercadoPago(&pagos[i]))
+	}
+	return resultado, nil
+}
+
+// pagoPasarelaMercadoPago traduce un pago de Mercado Pago al de las pasarelas
+func pagoPasarelaMercadoPago(pago *PaymentResponse) *entidades.PagoPasarela {
+	return &entidades.PagoPasarela{
+		Pasarela:          entidades.PasarelaMercadoPago,
+		ID:                strconv.FormatInt(pago.ID, 10),
+		Estado:            estadoPasarelaMercadoPago(pago.Status),
+		EstadoOriginal:    pago.Status,
+		Monto:             entidades.DineroDesdeFloat(pago.TransactionAmount).EnMoneda(pago.CurrencyId),
+		ReferenciaExterna: pago.ExternalReference,
+		Fecha:             pago.DateLastUpdated,
+	}
+}
+
+// estadoPasarelaMercadoPago traduce el estado de un pago de Mercado Pago al de las pasarelas
+func estadoPasarelaMercadoPago(status string) string {
+	switch status {
+	case "approved":
+		return entidades.EstadoPasarelaAprobado
+	case "refunded":
+		return entidades.EstadoPasarelaDevuelto
+	case "charged_back":
+		return entidades.EstadoPasarelaContracargo
+	case "rejected", "cancelled":
+		return entidades.EstadoPasarelaRechazado
+	default:
+		return entidades.EstadoPasarelaPendiente
+	}
+}
+
+// Reembolsar solicita el reembolso total o parcial de un pago
+func (s *MercadoPagoService) Reembolsar(idPago string, monto entidades.Dinero, claveIdempotencia string) (*entidades.ReembolsoPasarela, error) {
+	reembolso, err := s.CrearDevolucion(idPago, monto, claveIdempotencia)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entidades.ReembolsoPasarela{
+		ID:     strconv.FormatInt(reembolso.ID, 10),
+		Monto:  entidades.DineroDesdeFloat(reembolso.Amount).EnMoneda(monto.Moneda()),
+		Estado: reembolso.Status,
+	}, nil
+}
+
+// VerificarWebhook lee una notificación de Mercado Pago y valida su firma
+// Las notificaciones llegan como ?type=payment&data.id=... (o ?topic=payment&id=... en IPN); si la URL
+// no trae los parámetros se toman del cuerpo
+func (s *MercadoPagoService) VerificarWebhook(notificacion *entidades.NotificacionPasarela) (*entidades.EventoPasarela, error) {
+	topic := notificacion.Parametros.Get("type")
+	if topic == "" {
+		topic = notificacion.Parametros.Get("topic")
+	}
+	id := notificacion.Parametros.Get("data.id")
+	if id == "" {
+		id = notificacion.Parametros.Get("id")
+	}
+
+	if (topic == "" || id == "") && len(notificacion.Cuerpo) > 0 {
+		var cuerpo PaymentNotification
+		if err := json.Unmarshal(notificacion.Cuerpo, &cuerpo); err == nil {
+			topic, id = cuerpo.Type, cuerpo.Data.ID
+		}
+	}
+
+	if topic == "" || id == "" {
+		return nil, ErrNotificacionIncompleta
+	}
+
+	requestID := notificacion.Cabeceras.Get("x-request-id")
+	if err := s.VerificarFirmaWebhook(notificacion.Cabeceras.Get("x-signature"), requestID, id); err != nil {
+		return nil, err
+	}
+
+	return &entidades.EventoPasarela{
+		IDPago:         id,
+		IDNotificacion: requestID,
+		Tipo:           topic,
+	}, nil
+}
@@ -9,11 +9,13 @@ import (
 
 // PagoService maneja la lógica de negocio para pagos
 type PagoService struct {
-	pagoRepo       *repositorios.PagoRepository
-	reservaRepo    *repositorios.ReservaRepository
-	metodoPagoRepo *repositorios.MetodoPagoRepository
-	canalVentaRepo *repositorios.CanalVentaRepository
-	sedeRepo       *repositorios.SedeRepository // Añadido repositorio de sede
+	pagoRepo          *repositorios.PagoRepository
+	reservaRepo       *repositorios.ReservaRepository
+	metodoPagoRepo    *repositorios.MetodoPagoRepository
+	canalVentaRepo    *repositorios.CanalVentaRepository
+	sedeRepo          *repositorios.SedeRepository // Añadido repositorio de sede
+	tipoCambioService *TipoCambioService
+	cajaRepo          *repositorios.CajaRepository
 }
 
 // NewPagoService crea una nueva instancia de PagoService
@@ -23,13 +25,17 @@ func NewPagoService(
 	metodoPagoRepo *repositorios.MetodoPagoRepository,
 	canalVentaRepo *repositorios.CanalVentaRepository,
 	sedeRepo *repositorios.SedeRepository, // Añadido repositorio de sede
+	tipoCambioService *TipoCambioService,
+	cajaRepo *repositorios.CajaRepository,
 ) *PagoService {
 	return &PagoService{
-		pagoRepo:       pagoRepo,
-		reservaRepo:    reservaRepo,
-		metodoPagoRepo: metodoPagoRepo,
-		canalVentaRepo: canalVentaRepo,
-		sedeRepo:       sedeRepo, // Asignado repositorio de sede
+		pagoRepo:          pagoRepo,
+		reservaRepo:       reservaRepo,
+		metodoPagoRepo:    metodoPagoRepo,
+		canalVentaRepo:    canalVentaRepo,
+		sedeRepo:          sedeRepo, // Asignado repositorio de sede
+		tipoCambioService: tipoCambioService,
+		cajaRepo:          cajaRepo,
 	}
 }
 
@@ -65,20 +71,42 @@ func (s *PagoService) Create(pago *entidades.NuevoPagoRequest) (int, error) {
 	}
 
 	// Verificar que el monto sea positivo
-	if pago.Monto <= 0 {
+	if !pago.Monto.EsPositivo() {
 		return 0, errors.New("el monto del pago debe ser mayor a cero")
 	}
 
+	// Convertir a soles los pagos en moneda extranjera con el tipo de cambio del día
+	pago.Monto = pago.Monto.EnMoneda(pago.Moneda)
+	pago.Conversion, err = s.tipoCambioService.Convertir(pago.Monto, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
 	// Verificar que el monto total pagado + el nuevo pago no exceda el total a pagar de la reserva
 	totalPagado, err := s.pagoRepo.GetTotalPagadoByReserva(pago.IDReserva)
 	if err != nil {
 		return 0, err
 	}
 
-	if totalPagado+pago.Monto > reserva.TotalPagar {
+	if totalPagado.Sumar(pago.Conversion.MontoBase).MayorQue(reserva.TotalPagar) {
 		return 0, errors.New("el monto total pagado excedería el total a pagar de la reserva")
 	}
 
+	// Asociar el pago a la caja abierta de quien lo registra; los vendedores no cobran sin caja abierta
+	if pago.IDUsuario > 0 {
+		caja, err := s.cajaRepo.GetAbiertaByUsuario(pago.IDUsuario)
+		switch {
+		case err == nil:
+			pago.IDCajaSesion = &caja.ID
+		case errors.Is(err, repositorios.ErrSinCajaAbierta):
+			if pago.RolUsuario == "VENDEDOR" {
+				return 0, errors.New("debe abrir su caja antes de registrar pagos")
+			}
+		default:
+			return 0, err
+		}
+	}
+
 	// Crear pago
 	return s.pagoRepo.Create(pago)
 }
@@ -115,12 +143,19 @@ func (s *PagoService) Update(id int, pago *entidades.ActualizarPagoRequest) erro
 	}
 
 	// Verificar que el monto sea positivo
-	if pago.Monto <= 0 {
+	if !pago.Monto.EsPositivo() {
 		return errors.New("el monto del pago debe ser mayor a cero")
 	}
 
+	// Convertir a soles con el tipo de cambio vigente en la fecha del pago
+	pago.Monto = pago.Monto.EnMoneda(pago.Moneda)
+	pago.Conversion, err = s.tipoCambioService.Convertir(pago.Monto, existingPago.FechaPago)
+	if err != nil {
+		return err
+	}
+
 	// Si cambia el monto, verificar que el total pagado no exceda el total a pagar
-	if pago.Monto != existingPago.Monto {
+	if !pago.Conversion.MontoBase.Igual(existingPago.MontoConvertido) {
 		// Obtener el total pagado sin considerar este pago
 		totalPagado, err := s.pagoRepo.GetTotalPagadoByReserva(existingPago.IDReserva)
 		if err != nil {
@@ -129,7 +164,7 @@ func (s *PagoService) Update(id int, pago *entidades.ActualizarPagoRequest) erro
 
 		// Restar el monto del pago actual si está procesado
 		if existingPago.Estado == "PROCESADO" {
-			totalPagado -= existingPago.Monto
+			totalPagado = totalPagado.Restar(existingPago.MontoConvertido)
 		}
 
 		// Verificar si el nuevo monto excedería el total a pagar
@@ -138,7 +173,7 @@ func (s *PagoService) Update(id int, pago *entidades.ActualizarPagoRequest) erro
 			return err
 		}
 
-		if pago.Estado == "PROCESADO" && totalPagado+pago.Monto > reserva.TotalPagar {
+		if pago.Estado == "PROCESADO" && totalPagado.Sumar(pago.Conversion.MontoBase).MayorQue(reserva.TotalPagar) {
 			return errors.New("el monto total pagado excedería el total a pagar de la reserva")
 		}
 	}
@@ -210,17 +245,28 @@ func (s *PagoService) ListByFecha(fecha time.Time) ([]*entidades.Pago, error) {
 }
 
 // GetTotalPagadoByReserva obtiene el total pagado de una reserva específica
-func (s *PagoService) GetTotalPagadoByReserva(idReserva int) (float64, error) {
+func (s *PagoService) GetTotalPagadoByReserva(idReserva int) (entidades.Dinero, error) {
 	// Verificar que la reserva existe
 	_, err := s.reservaRepo.GetByID(idReserva)
 	if err != nil {
-		return 0, errors.New("la reserva especificada no existe")
+		return entidades.Dinero{}, errors.New("la reserva especificada no existe")
 	}
 
 	// Obtener total pagado
 	return s.pagoRepo.GetTotalPagadoByReserva(idReserva)
 }
 
+// GetSaldoByReserva obtiene el saldo de una reserva: total, pagado, pendiente y adelanto requerido por su sede
+func (s *PagoService) GetSaldoByReserva(idReserva int) (*entidades.SaldoReserva, error) {
+	// La reserva ya trae su saldo calculado con los pagos procesados
+	reserva, err := s.reservaRepo.GetByID(idReserva)
+	if err != nil {
+		return nil, errors.New("la reserva especificada no existe")
+	}
+
+	return &reserva.Saldo, nil
+}
+
 // ListByEstado lista todos los pagos con un estado específico
 func (s *PagoService) ListByEstado(estado string) ([]*entidades.Pago, error) {
 	// Verificar estado válido
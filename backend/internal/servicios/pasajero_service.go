@@ -0,0 +1,159 @@
+package servicios
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+)
+
+// PasajeroService maneja la lógica de negocio para pasajeros y manifiestos de embarque
+type PasajeroService struct {
+	pasajeroRepo *repositorios.PasajeroRepository
+}
+
+// NewPasajeroService crea una nueva instancia de PasajeroService
+func NewPasajeroService(pasajeroRepo *repositorios.PasajeroRepository) *PasajeroService {
+	return &PasajeroService{
+		pasajeroRepo: pasajeroRepo,
+	}
+}
+
+// RegistrarPasajeros reemplaza los pasajeros de una reserva
+func (s *PasajeroService) RegistrarPasajeros(idReserva int, solicitud *entidades.RegistrarPasajerosRequest) error {
+	return s.pasajeroRepo.RegistrarPorReserva(idReserva, solicitud.Pasajeros)
+}
+
+// ListByReserva lista los pasajeros de una reserva
+func (s *PasajeroService) ListByReserva(idReserva int) ([]*entidades.Pasajero, error) {
+	return s.pasajeroRepo.ListByReserva(idReserva)
+}
+
+// GetManifiesto obtiene el manifiesto de pasajeros de una instancia de tour
+func (s *PasajeroService) GetManifiesto(idInstancia int) (*entidades.ManifiestoInstancia, error) {
+	return s.pasajeroRepo.GetManifiesto(idInstancia)
+}
+
+// GenerarManifiestoCSV exporta el manifiesto en formato CSV con una fila por pasajero
+func GenerarManifiestoCSV(manifiesto *entidades.ManifiestoInstancia) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+
+	filas := [][]string{
+		{"Tour", manifiesto.NombreTour},
+		{"Fecha", manifiesto.FechaTour},
+		{"Horario", manifiesto.HoraInicio + " - " + manifiesto.HoraFin},
+		{"Embarcacion", manifiesto.NombreEmbarcacion},
+		{"Chofer", manifiesto.NombreChofer},
+		{"Pasajes vendidos", strconv.Itoa(manifiesto.TotalPasajes)},
+		{"Pasajeros registrados", strconv.Itoa(manifiesto.PasajerosRegistrados)},
+		{},
+		{"N", "Apellidos", "Nombres", "Tipo documento", "Numero documento", "Edad", "Nacionalidad",
+			"Tipo pasaje", "Contacto emergencia", "Telefono emergencia", "Reserva", "Titular", "Estado reserva"},
+	}
+
+	for i, pasajero := range manifiesto.Pasajeros {
+		filas = append(filas, []string{
+			strconv.Itoa(i + 1),
+			pasajero.Apellidos,
+			pasajero.Nombres,
+			pasajero.TipoDocumento,
+			pasajero.NumeroDocumento,
+			strconv.Itoa(pasajero.Edad),
+			pasajero.Nacionalidad,
+			pasajero.NombreTipoPasaje,
+			pasajero.ContactoEmergenciaNombre,
+			pasajero.ContactoEmergenciaTelefono,
+			strconv.Itoa(pasajero.IDReserva),
+			pasajero.NombreTitular,
+			pasajero.EstadoReserva,
+		})
+	}
+
+	if err := writer.WriteAll(filas); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// recortarTexto limita un texto a la cantidad de caracteres que entra en una columna del PDF
+func recortarTexto(texto string, maximo int) string {
+	runas := []rune(texto)
+	if len(runas) <= maximo {
+		return texto
+	}
+	return string(runas[:maximo-1]) + "."
+}
+
+// GenerarManifiestoPDF exporta el manifiesto como tabla en un PDF A4, agregando páginas si es necesario
+func GenerarManifiestoPDF(manifiesto *entidades.ManifiestoInstancia) []byte {
+	doc := utils.NuevoDocumentoPDF()
+	margenDerecho := utils.AnchoPaginaA4 - 40
+
+	// Columnas de la tabla: posición x, ancho máximo en caracteres y título
+	columnas := []struct {
+		x      float64
+		maximo int
+		titulo string
+	}{
+		{40, 4, "N"},
+		{60, 24, "Apellidos y nombres"},
+		{195, 18, "Documento"},
+		{295, 4, "Edad"},
+		{325, 12, "Nacionalidad"},
+		{395, 28, "Contacto de emergencia"},
+		{530, 6, "Reserva"},
+	}
+
+	escribirCabecera := func(y float64) float64 {
+		for _, columna := range columnas {
+			doc.Texto(columna.x, y, 8, true, columna.titulo)
+		}
+		doc.Linea(40, y+4, margenDerecho, y+4)
+		return y + 16
+	}
+
+	doc.Texto(40, 50, 14, true, "Manifiesto de pasajeros")
+	doc.Texto(40, 70, 10, false, fmt.Sprintf("Tour: %s - %s", manifiesto.NombreTour, manifiesto.NombreSede))
+	doc.Texto(40, 84, 10, false, fmt.Sprintf("Fecha: %s   Horario: %s - %s", manifiesto.FechaTour, manifiesto.HoraInicio, manifiesto.HoraFin))
+	doc.Texto(40, 98, 10, false, fmt.Sprintf("Embarcación: %s   Chofer: %s", manifiesto.NombreEmbarcacion, manifiesto.NombreChofer))
+	doc.Texto(40, 112, 10, false, fmt.Sprintf("Pasajes vendidos: %d   Pasajeros registrados: %d",
+		manifiesto.TotalPasajes, manifiesto.PasajerosRegistrados))
+
+	y := escribirCabecera(140)
+	for i, pasajero := range manifiesto.Pasajeros {
+		if y > utils.AltoPaginaA4-40 {
+			doc.AgregarPagina()
+			y = escribirCabecera(50)
+		}
+
+		valores := []string{
+			strconv.Itoa(i + 1),
+			pasajero.Apellidos + ", " + pasajero.Nombres,
+			pasajero.TipoDocumento + " " + pasajero.NumeroDocumento,
+			strconv.Itoa(pasajero.Edad),
+			pasajero.Nacionalidad,
+			pasajero.ContactoEmergenciaNombre + " " + pasajero.ContactoEmergenciaTelefono,
+			strconv.Itoa(pasajero.IDReserva),
+		}
+		for j, columna := range columnas {
+			doc.Texto(columna.x, y, 8, false, recortarTexto(valores[j], columna.maximo))
+		}
+		y += 14
+	}
+
+	if manifiesto.PasajerosRegistrados < manifiesto.TotalPasajes {
+		if y > utils.AltoPaginaA4-40 {
+			doc.AgregarPagina()
+			y = 50
+		}
+		doc.Texto(40, y+10, 9, true, fmt.Sprintf("Faltan registrar %d pasajeros",
+			manifiesto.TotalPasajes-manifiesto.PasajerosRegistrados))
+	}
+
+	return doc.Bytes()
+}
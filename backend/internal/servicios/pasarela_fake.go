@@ -0,0 +1,252 @@
+package servicios
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PasarelaFake es una pasarela de pago en memoria para pruebas y desarrollo local: no cobra ni usa la red
+// Los pagos se simulan con SimularPago y se informan con la misma notificación que enviaría una pasarela real
+type PasarelaFake struct {
+	Secreto    string // Si se define, las notificaciones deben venir firmadas con él
+	BackendURL string // URL pública del backend, donde se atiende la simulación del pago
+
+	mu          sync.Mutex
+	correlativo int
+	checkouts   map[string]*entidades.SolicitudCheckout
+	pagos       map[string]*entidades.PagoPasarela
+	reembolsado map[string]entidades.Dinero
+	reembolsos  map[string]*entidades.ReembolsoPasarela // Por clave de idempotencia
+}
+
+// NewPasarelaFake crea una pasarela simulada vacía
+func NewPasarelaFake(secreto string) *PasarelaFake {
+	return &PasarelaFake{
+		Secreto:     secreto,
+		checkouts:   map[string]*entidades.SolicitudCheckout{},
+		pagos:       map[string]*entidades.PagoPasarela{},
+		reembolsado: map[string]entidades.Dinero{},
+		reembolsos:  map[string]*entidades.ReembolsoPasarela{},
+	}
+}
+
+// notificacionFake es el cuerpo de las notificaciones simuladas
+type notificacionFake struct {
+	ID     string `json:"id"`
+	Tipo   string `json:"tipo"`
+	Estado string `json:"estado"`
+}
+
+// nuevoID genera un identificador con el prefijo indicado; debe llamarse con el mutex tomado
+func (p *PasarelaFake) nuevoID(prefijo string) string {
+	p.correlativo++
+	return fmt.Sprintf("%s-%d", prefijo, p.correlativo)
+}
+
+// Nombre identifica a la pasarela simulada entre las pasarelas de pago
+func (p *PasarelaFake) Nombre() string {
+	return entidades.PasarelaFake
+}
+
+// CrearCheckout registra el cobro de una reserva; la URL de pago apunta a la simulación del propio backend
+func (p *PasarelaFake) CrearCheckout(solicitud *entidades.SolicitudCheckout) (*entidades.CheckoutPasarela, error) {
+	if !solicitud.Monto.EsPositivo() {
+		return nil, errors.New("el monto del cobro debe ser mayor a cero")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := p.nuevoID("CHK")
+	copia := *solicitud
+	p.checkouts[id] = &copia
+
+	return &entidades.CheckoutPasarela{
+		Pasarela: entidades.PasarelaFake,
+		ID:       id,
+		URLPago:  fmt.Sprintf("%s/api/v1/pago-simulado/%s", p.BackendURL, id),
+	}, nil
+}
+
+// SimularPago simula que el cliente pagó un cobro con el resultado indicado (APROBADO, RECHAZADO o PENDIENTE)
+func (p *PasarelaFake) SimularPago(idCheckout string, estado string) (*entidades.PagoPasarela, error) {
+	switch estado {
+	case entidades.EstadoPasarelaAprobado, entidades.EstadoPasarelaRechazado, entidades.EstadoPasarelaPendiente:
+	default:
+		return nil, fmt.Errorf("estado de pago simulado no soportado: %s", estado)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	checkout, ok := p.checkouts[idCheckout]
+	if !ok {
+		return nil, errors.New("cobro simulado no encontrado")
+	}
+
+	pago := &entidades.PagoPasarela{
+		Pasarela:          entidades.PasarelaFake,
+		ID:                p.nuevoID("PAY"),
+		Estado:            estado,
+		EstadoOriginal:    estado,
+		Monto:             checkout.Monto,
+		ReferenciaExterna: entidades.ReferenciaReserva(checkout.IDReserva),
+		Fecha:             time.Now(),
+	}
+	p.pagos[pago.ID] = pago
+
+	copia := *pago
+	return &copia, nil
+}
+
+// SimularContracargo simula que el banco del cliente desconoció un pago aprobado
+func (p *PasarelaFake) SimularContracargo(idPago string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pago, ok := p.pagos[idPago]
+	if !ok {
+		return errors.New("pago simulado no encontrado")
+	}
+	if pago.Estado != entidades.EstadoPasarelaAprobado {
+		return errors.New("solo se pueden desconocer pagos aprobados")
+	}
+
+	pago.Estado = entidades.EstadoPasarelaContracargo
+	pago.EstadoOriginal = entidades.EstadoPasarelaContracargo
+	return nil
+}
+
+// ObtenerPago devuelve un pago simulado
+func (p *PasarelaFake) ObtenerPago(idPago string) (*entidades.PagoPasarela, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pago, ok := p.pagos[idPago]
+	if !ok {
+		return nil, errors.New("pago simulado no encontrado")
+	}
+
+	copia := *pago
+	return &copia, nil
+}
+
+// BuscarPagos lista los pagos simulados hechos con una referencia externa, en el orden en que se hicieron
+func (p *PasarelaFake) BuscarPagos(referencia string) ([]*entidades.PagoPasarela, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pagos := []*entidades.PagoPasarela{}
+	for _, pago := range p.pagos {
+		if pago.ReferenciaExterna == referencia {
+			copia := *pago
+			pagos = append(pagos, &copia)
+		}
+	}
+	sort.Slice(pagos, func(i, j int) bool {
+		return pagos[i].Fecha.Before(pagos[j].Fecha) || (pagos[i].Fecha.Equal(pagos[j].Fecha) && pagos[i].ID < pagos[j].ID)
+	})
+
+	return pagos, nil
+}
+
+// Reembolsar reembolsa total o parcialmente un pago aprobado; al reembolsarlo por completo queda DEVUELTO
+// Repetir la clave de idempotencia devuelve el reembolso ya hecho
+func (p *PasarelaFake) Reembolsar(idPago string, monto entidades.Dinero, claveIdempotencia string) (*entidades.ReembolsoPasarela, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if reembolso, ok := p.reembolsos[claveIdempotencia]; ok && claveIdempotencia != "" {
+		copia := *reembolso
+		return &copia, nil
+	}
+
+	pago, ok := p.pagos[idPago]
+	if !ok {
+		return nil, errors.New("pago simulado no encontrado")
+	}
+	if pago.Estado != entidades.EstadoPasarelaAprobado {
+		return nil, errors.New("solo se pueden reembolsar pagos aprobados")
+	}
+	if !monto.EsPositivo() {
+		return nil, errors.New("el monto del reembolso debe ser mayor a cero")
+	}
+
+	monto = monto.EnMoneda(pago.Monto.Moneda())
+	total := p.reembolsado[idPago].EnMoneda(pago.Monto.Moneda()).Sumar(monto)
+	if total.MayorQue(pago.Monto) {
+		return nil, errors.New("el reembolso excede el monto del pago")
+	}
+	p.reembolsado[idPago] = total
+	if total.Igual(pago.Monto) {
+		pago.Estado = entidades.EstadoPasarelaDevuelto
+		pago.EstadoOriginal = entidades.EstadoPasarelaDevuelto
+	}
+
+	reembolso := &entidades.ReembolsoPasarela{ID: p.nuevoID("REF"), Monto: monto, Estado: "APROBADO"}
+	if claveIdempotencia != "" {
+		p.reembolsos[claveIdempotencia] = reembolso
+	}
+
+	copia := *reembolso
+	return &copia, nil
+}
+
+// Notificacion arma la notificación que la pasarela simulada envía sobre un pago, firmada si hay secreto
+func (p *PasarelaFake) Notificacion(idPago string) (*entidades.NotificacionPasarela, error) {
+	pago, err := p.ObtenerPago(idPago)
+	if err != nil {
+		return nil, err
+	}
+
+	cuerpo, err := json.Marshal(notificacionFake{ID: pago.ID, Tipo: "payment", Estado: pago.Estado})
+	if err != nil {
+		return nil, err
+	}
+
+	cabeceras := http.Header{}
+	cabeceras.Set("Content-Type", "application/json")
+	if p.Secreto != "" {
+		cabeceras.Set("X-Fake-Signature", p.firmar(cuerpo))
+	}
+
+	return &entidades.NotificacionPasarela{Cabeceras: cabeceras, Cuerpo: cuerpo}, nil
+}
+
+// firmar calcula el HMAC-SHA256 del cuerpo de una notificación
+func (p *PasarelaFake) firmar(cuerpo []byte) string {
+	mac := hmac.New(sha256.New, []byte(p.Secreto))
+	mac.Write(cuerpo)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerificarWebhook valida una notificación simulada; con secreto exige la cabecera X-Fake-Signature
+func (p *PasarelaFake) VerificarWebhook(notificacion *entidades.NotificacionPasarela) (*entidades.EventoPasarela, error) {
+	if p.Secreto != "" {
+		firma := strings.ToLower(notificacion.Cabeceras.Get("X-Fake-Signature"))
+		if !hmac.Equal([]byte(p.firmar(notificacion.Cuerpo)), []byte(firma)) {
+			return nil, errors.New("firma del webhook inválida")
+		}
+	}
+
+	var cuerpo notificacionFake
+	if err := json.Unmarshal(notificacion.Cuerpo, &cuerpo); err != nil || cuerpo.ID == "" || cuerpo.Tipo == "" {
+		return nil, ErrNotificacionIncompleta
+	}
+
+	return &entidades.EventoPasarela{
+		IDPago:         cuerpo.ID,
+		IDNotificacion: fmt.Sprintf("%s-%s", cuerpo.ID, cuerpo.Estado),
+		Tipo:           cuerpo.Tipo,
+	}, nil
+}
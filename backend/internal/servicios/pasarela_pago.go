@@ -0,0 +1,95 @@
+package servicios
+
+import (
+	"errors"
+	"fmt"
+	"sistema-toursseft/internal/config"
+	"sistema-toursseft/internal/entidades"
+	"strings"
+)
+
+// ErrNotificacionIncompleta indica que la notificación no identifica el pago al que se refiere
+var ErrNotificacionIncompleta = errors.New("parámetros de la notificación inválidos")
+
+// PaymentGateway es una pasarela de pago en línea: crea el cobro al que se redirige al cliente, consulta,
+// busca por referencia y reembolsa pagos y verifica las notificaciones que envía
+type PaymentGateway interface {
+	Nombre() string
+	CrearCheckout(solicitud *entidades.SolicitudCheckout) (*entidades.CheckoutPasarela, error)
+	ObtenerPago(idPago string) (*entidades.PagoPasarela, error)
+	BuscarPagos(referencia string) ([]*entidades.PagoPasarela, error)
+	Reembolsar(idPago string, monto entidades.Dinero, claveIdempotencia string) (*entidades.ReembolsoPasarela, error)
+	VerificarWebhook(notificacion *entidades.NotificacionPasarela) (*entidades.EventoPasarela, error)
+}
+
+// PasarelasPago agrupa las pasarelas configuradas y la que se usa por defecto para los cobros nuevos
+type PasarelasPago struct {
+	pasarelas      map[string]PaymentGateway
+	orden          []string // Nombres en el orden de registro
+	predeterminada string
+}
+
+// NewPasarelasPago crea el registro de pasarelas; la primera indicada es la predeterminada
+func NewPasarelasPago(pasarelas ...PaymentGateway) *PasarelasPago {
+	registro := &PasarelasPago{pasarelas: map[string]PaymentGateway{}}
+	for _, pasarela := range pasarelas {
+		if registro.predeterminada == "" {
+			registro.predeterminada = pasarela.Nombre()
+		}
+		if _, ok := registro.pasarelas[pasarela.Nombre()]; !ok {
+			registro.orden = append(registro.orden, pasarela.Nombre())
+		}
+		registro.pasarelas[pasarela.Nombre()] = pasarela
+	}
+	return registro
+}
+
+// Obtener devuelve la pasarela con el nombre indicado, sin distinguir mayúsculas y guiones
+func (p *PasarelasPago) Obtener(nombre string) (PaymentGateway, error) {
+	nombre = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(nombre), "-", "_"))
+	pasarela, ok := p.pasarelas[nombre]
+	if !ok {
+		return nil, fmt.Errorf("la pasarela de pago %s no está configurada", nombre)
+	}
+	return pasarela, nil
+}
+
+// Predeterminada devuelve la pasarela con la que se cobran las reservas si no se indica otra
+func (p *PasarelasPago) Predeterminada() PaymentGateway {
+	return p.pasarelas[p.predeterminada]
+}
+
+// Todas devuelve las pasarelas configuradas, empezando por la predeterminada
+func (p *PasarelasPago) Todas() []PaymentGateway {
+	pasarelas := make([]PaymentGateway, 0, len(p.orden))
+	for _, nombre := range p.orden {
+		pasarelas = append(pasarelas, p.pasarelas[nombre])
+	}
+	return pasarelas
+}
+
+// NuevasPasarelasPago registra las pasarelas según la configuración; PASARELA_PAGO elige la predeterminada
+// Mercado Pago siempre se registra para atender las notificaciones y reembolsos de pagos ya hechos con ella
+func NuevasPasarelasPago(cfg *config.Config, mercadoPago *MercadoPagoService) *PasarelasPago {
+	pasarelas := []PaymentGateway{mercadoPago}
+
+	if cfg.IzipayUsuario != "" {
+		pasarelas = append(pasarelas, NewIzipayService(cfg.IzipayURL, cfg.IzipayUsuario, cfg.IzipayClave, cfg.IzipayClavePublica, cfg.IzipayClaveHMAC))
+	}
+	// La pasarela simulada confirma pagos sin cobrar: solo se registra si se elige explícitamente
+	if cfg.PasarelaPago == entidades.PasarelaFake {
+		fake := NewPasarelaFake(cfg.PasarelaFakeSecreto)
+		fake.BackendURL = cfg.BackendURL
+		pasarelas = append(pasarelas, fake)
+	}
+
+	// La pasarela predeterminada va primero
+	for i, pasarela := range pasarelas {
+		if pasarela.Nombre() == cfg.PasarelaPago {
+			pasarelas[0], pasarelas[i] = pasarelas[i], pasarelas[0]
+			break
+		}
+	}
+
+	return NewPasarelasPago(pasarelas...)
+}
@@ -0,0 +1,176 @@
+package servicios
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+)
+
+// PoliticaCancelacionService maneja la lógica de negocio para políticas de cancelación
+type PoliticaCancelacionService struct {
+	politicaRepo       *repositorios.PoliticaCancelacionRepository
+	reservaRepo        *repositorios.ReservaRepository
+	listaEsperaService *ListaEsperaService
+}
+
+// NewPoliticaCancelacionService crea una nueva instancia de PoliticaCancelacionService
+func NewPoliticaCancelacionService(
+	politicaRepo *repositorios.PoliticaCancelacionRepository,
+	reservaRepo *repositorios.ReservaRepository,
+	listaEsperaService *ListaEsperaService,
+) *PoliticaCancelacionService {
+	return &PoliticaCancelacionService{
+		politicaRepo:       politicaRepo,
+		reservaRepo:        reservaRepo,
+		listaEsperaService: listaEsperaService,
+	}
+}
+
+// Create crea un nuevo tramo de política de cancelación
+func (s *PoliticaCancelacionService) Create(politica *entidades.NuevaPoliticaCancelacionRequest) (int, error) {
+	if politica.IDTipoTour == nil && politica.IDSede == nil {
+		return 0, errors.New("debe indicar el tipo de tour o la sede de la política")
+	}
+	return s.politicaRepo.Create(politica)
+}
+
+// GetByID obtiene un tramo de política de cancelación por su ID
+func (s *PoliticaCancelacionService) GetByID(id int) (*entidades.PoliticaCancelacion, error) {
+	return s.politicaRepo.GetByID(id)
+}
+
+// Update actualiza un tramo de política de cancelación
+func (s *PoliticaCancelacionService) Update(id int, politica *entidades.ActualizarPoliticaCancelacionRequest) error {
+	// Verificar que el tramo existe
+	_, err := s.politicaRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	return s.politicaRepo.Update(id, politica)
+}
+
+// Delete elimina un tramo de política de cancelación
+func (s *PoliticaCancelacionService) Delete(id int) error {
+	// Verificar que el tramo existe
+	_, err := s.politicaRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	return s.politicaRepo.Delete(id)
+}
+
+// List lista todos los tramos de políticas de cancelación
+func (s *PoliticaCancelacionService) List() ([]*entidades.PoliticaCancelacion, error) {
+	return s.politicaRepo.List()
+}
+
+// ListByTipoTour lista los tramos definidos para un tipo de tour
+func (s *PoliticaCancelacionService) ListByTipoTour(idTipoTour int) ([]*entidades.PoliticaCancelacion, error) {
+	return s.politicaRepo.ListByTipoTour(idTipoTour)
+}
+
+// ListBySede lista los tramos definidos para una sede
+func (s *PoliticaCancelacionService) ListBySede(idSede int) ([]*entidades.PoliticaCancelacion, error) {
+	return s.politicaRepo.ListBySede(idSede)
+}
+
+// CalcularCotizacionCancelacion aplica los tramos de una política a los datos de una reserva
+// Se usa el tramo con mayor anticipación mínima que la reserva cumple. Sin tramos configurados
+// se devuelve todo lo pagado; con tramos pero ninguno cumplido no hay devolución
+func CalcularCotizacionCancelacion(datos *entidades.DatosCancelacionReserva, politicas []*entidades.PoliticaCancelacion) *entidades.CotizacionCancelacion {
+	cotizacion := &entidades.CotizacionCancelacion{
+		IDReserva:   datos.IDReserva,
+		IDSede:      datos.IDSede,
+		InicioTour:  datos.InicioTour,
+		HorasAntes:  math.Round(datos.InicioTour.Sub(datos.Ahora).Hours()*100) / 100,
+		TotalPagado: datos.TotalReembolsable,
+	}
+
+	if datos.Estado != "RESERVADO" && datos.Estado != "CONFIRMADA" {
+		cotizacion.Motivo = fmt.Sprintf("no se puede cancelar una reserva en estado %s", datos.Estado)
+		return cotizacion
+	}
+
+	if !datos.InicioTour.After(datos.Ahora) {
+		cotizacion.Motivo = "el tour ya inició, la reserva no se puede cancelar"
+		return cotizacion
+	}
+
+	cotizacion.Permitida = true
+
+	if len(politicas) == 0 {
+		cotizacion.PorcentajeDevolucion = 100
+	} else {
+		var aplicada *entidades.PoliticaCancelacion
+		for _, politica := range politicas {
+			if float64(politica.HorasAntesMinimo) <= cotizacion.HorasAntes &&
+				(aplicada == nil || politica.HorasAntesMinimo > aplicada.HorasAntesMinimo) {
+				aplicada = politica
+			}
+		}
+
+		if aplicada != nil {
+			idPolitica := aplicada.ID
+			cotizacion.IDPolitica = &idPolitica
+			cotizacion.NombrePolitica = aplicada.Nombre
+			cotizacion.PorcentajeDevolucion = aplicada.PorcentajeDevolucion
+		}
+	}
+
+	cotizacion.MontoDevolucion = cotizacion.TotalPagado.Porcentaje(cotizacion.PorcentajeDevolucion)
+	return cotizacion
+}
+
+// CotizarCancelacion calcula cuánto se devolvería si la reserva se cancela en este momento
+func (s *PoliticaCancelacionService) CotizarCancelacion(idReserva int) (*entidades.CotizacionCancelacion, error) {
+	datos, err := s.reservaRepo.GetDatosCancelacion(idReserva)
+	if err != nil {
+		return nil, err
+	}
+
+	politicas, err := s.politicaRepo.ListAplicables(datos.IDTipoTour, datos.IDSede)
+	if err != nil {
+		return nil, err
+	}
+
+	return CalcularCotizacionCancelacion(datos, politicas), nil
+}
+
+// CancelarReserva cancela una reserva aplicando la política de cancelación vigente
+// La devolución resultante queda registrada como PENDIENTE para su aprobación
+func (s *PoliticaCancelacionService) CancelarReserva(idReserva int) (*entidades.CotizacionCancelacion, error) {
+	cotizacion, err := s.CotizarCancelacion(idReserva)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cotizacion.Permitida {
+		return cotizacion, errors.New(cotizacion.Motivo)
+	}
+
+	motivo := "Cancelación de la reserva por el cliente"
+	if cotizacion.NombrePolitica != "" {
+		motivo = fmt.Sprintf("%s (política %s: %.2f%%)", motivo, cotizacion.NombrePolitica, cotizacion.PorcentajeDevolucion)
+	}
+
+	// El monto definitivo se recalcula con la reserva bloqueada
+	monto, err := s.reservaRepo.CancelarConDevolucion(idReserva, cotizacion.PorcentajeDevolucion, motivo)
+	if err != nil {
+		return nil, err
+	}
+	cotizacion.MontoDevolucion = monto
+
+	// Ofrecer los cupos liberados a la lista de espera de la instancia
+	if s.listaEsperaService != nil {
+		reserva, err := s.reservaRepo.GetByID(idReserva)
+		if err == nil {
+			s.listaEsperaService.ProcesarInstancia(reserva.IDInstancia)
+		}
+	}
+
+	return cotizacion, nil
+}
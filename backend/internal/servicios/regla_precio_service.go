@@ -0,0 +1,292 @@
+package servicios
+
+import (
+	"errors"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReglaPrecioService maneja la lógica de negocio para reglas de precio y la resolución del precio efectivo
+type ReglaPrecioService struct {
+	reglaPrecioRepo   *repositorios.ReglaPrecioRepository
+	tipoPasajeRepo    *repositorios.TipoPasajeRepository
+	tipoCambioService *TipoCambioService
+}
+
+// NewReglaPrecioService crea una nueva instancia de ReglaPrecioService
+func NewReglaPrecioService(
+	reglaPrecioRepo *repositorios.ReglaPrecioRepository,
+	tipoPasajeRepo *repositorios.TipoPasajeRepository,
+	tipoCambioService *TipoCambioService,
+) *ReglaPrecioService {
+	return &ReglaPrecioService{
+		reglaPrecioRepo:   reglaPrecioRepo,
+		tipoPasajeRepo:    tipoPasajeRepo,
+		tipoCambioService: tipoCambioService,
+	}
+}
+
+// Create crea una nueva regla de precio
+func (s *ReglaPrecioService) Create(regla *entidades.NuevaReglaPrecioRequest) (int, error) {
+	diasSemana, err := validarCondicionesRegla(regla.TipoRegla, regla.DiasSemana, regla.HoraInicio, regla.HoraFin, regla.Porcentaje, regla.Monto)
+	if err != nil {
+		return 0, err
+	}
+	regla.DiasSemana = diasSemana
+
+	return s.reglaPrecioRepo.Create(regla)
+}
+
+// GetByID obtiene una regla de precio por su ID
+func (s *ReglaPrecioService) GetByID(id int) (*entidades.ReglaPrecio, error) {
+	return s.reglaPrecioRepo.GetByID(id)
+}
+
+// Update actualiza una regla de precio
+func (s *ReglaPrecioService) Update(id int, regla *entidades.ActualizarReglaPrecioRequest) error {
+	// Verificar que la regla existe
+	_, err := s.reglaPrecioRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	diasSemana, err := validarCondicionesRegla(regla.TipoRegla, regla.DiasSemana, regla.HoraInicio, regla.HoraFin, regla.Porcentaje, regla.Monto)
+	if err != nil {
+		return err
+	}
+	regla.DiasSemana = diasSemana
+
+	return s.reglaPrecioRepo.Update(id, regla)
+}
+
+// Delete elimina una regla de precio
+// La auditoría de los pasajes vendidos con la regla se conserva
+func (s *ReglaPrecioService) Delete(id int) error {
+	// Verificar que la regla existe
+	_, err := s.reglaPrecioRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	return s.reglaPrecioRepo.Delete(id)
+}
+
+// List lista todas las reglas de precio
+func (s *ReglaPrecioService) List() ([]*entidades.ReglaPrecio, error) {
+	return s.reglaPrecioRepo.List()
+}
+
+// ListAplicadasByReserva lista las reglas que fijaron el precio de los pasajes de una reserva
+func (s *ReglaPrecioService) ListAplicadasByReserva(idReserva int) ([]*entidades.RegistroReglaPrecio, error) {
+	return s.reglaPrecioRepo.ListAplicadasByReserva(idReserva)
+}
+
+// GetDatosInstancia obtiene los datos de una instancia de tour contra los que se evalúan las reglas
+func (s *ReglaPrecioService) GetDatosInstancia(idInstancia int) (*entidades.DatosPrecioInstancia, error) {
+	return s.reglaPrecioRepo.GetDatosInstancia(idInstancia)
+}
+
+// ResolverPrecios calcula el precio efectivo de cada tipo de pasaje indicado en una instancia y su
+// equivalente en soles con el tipo de cambio de la venta
+func (s *ReglaPrecioService) ResolverPrecios(
+	datos *entidades.DatosPrecioInstancia,
+	tiposPasaje []*entidades.TipoPasaje,
+	tipoCambio *entidades.TipoCambio,
+) (map[int]*entidades.PrecioPasaje, error) {
+	reglas, err := s.reglaPrecioRepo.ListAplicables(datos.IDTipoTour, datos.IDSede)
+	if err != nil {
+		return nil, err
+	}
+
+	precios := make(map[int]*entidades.PrecioPasaje, len(tiposPasaje))
+	for _, tipoPasaje := range tiposPasaje {
+		precio := ResolverPrecioPasaje(tipoPasaje, reglas, datos)
+		precio.PrecioSoles, err = ConvertirAMonedaBase(precio.PrecioUnitario, tipoCambio)
+		if err != nil {
+			return nil, err
+		}
+		precios[tipoPasaje.ID] = precio
+	}
+
+	return precios, nil
+}
+
+// PreciosInstancia obtiene los precios efectivos de todos los tipos de pasaje del tour de una instancia
+func (s *ReglaPrecioService) PreciosInstancia(idInstancia int) (*entidades.PreciosInstancia, error) {
+	datos, err := s.reglaPrecioRepo.GetDatosInstancia(idInstancia)
+	if err != nil {
+		return nil, errors.New("la instancia de tour especificada no existe")
+	}
+
+	tiposPasaje, err := s.tipoPasajeRepo.ListByTipoTour(datos.IDTipoTour)
+	if err != nil {
+		return nil, err
+	}
+
+	tipoCambio, err := s.tipoCambioService.TipoCambioVenta(monedasTiposPasaje(tiposPasaje)...)
+	if err != nil {
+		return nil, err
+	}
+
+	precios, err := s.ResolverPrecios(datos, tiposPasaje, tipoCambio)
+	if err != nil {
+		return nil, err
+	}
+
+	resultado := &entidades.PreciosInstancia{
+		IDInstancia: idInstancia,
+		Fecha:       datos.Fecha,
+		HoraInicio:  datos.HoraInicio,
+		Ocupacion:   datos.Ocupacion,
+		Precios:     []*entidades.PrecioPasaje{},
+	}
+	for _, tipoPasaje := range tiposPasaje {
+		resultado.Precios = append(resultado.Precios, precios[tipoPasaje.ID])
+	}
+
+	return resultado, nil
+}
+
+// ResolverPrecioPasaje aplica al costo de un tipo de pasaje las reglas que se cumplen en la instancia
+// Las reglas se aplican por prioridad, cada una sobre el precio que dejó la anterior; el precio nunca
+// baja de cero
+func ResolverPrecioPasaje(
+	tipoPasaje *entidades.TipoPasaje,
+	reglas []*entidades.ReglaPrecio,
+	datos *entidades.DatosPrecioInstancia,
+) *entidades.PrecioPasaje {
+	precio := &entidades.PrecioPasaje{
+		IDTipoPasaje:   tipoPasaje.ID,
+		Nombre:         tipoPasaje.Nombre,
+		Moneda:         tipoPasaje.Costo.Moneda(),
+		PrecioBase:     tipoPasaje.Costo,
+		PrecioUnitario: tipoPasaje.Costo,
+		Reglas:         []entidades.ReglaPrecioAplicada{},
+	}
+
+	ordenadas := make([]*entidades.ReglaPrecio, len(reglas))
+	copy(ordenadas, reglas)
+	sort.SliceStable(ordenadas, func(i, j int) bool {
+		return ordenadas[i].Prioridad < ordenadas[j].Prioridad
+	})
+
+	for _, regla := range ordenadas {
+		if !reglaAplica(regla, tipoPasaje, datos) {
+			continue
+		}
+
+		antes := precio.PrecioUnitario
+		despues := antes.Sumar(antes.Porcentaje(regla.Porcentaje)).Sumar(regla.Monto.EnMoneda(antes.Moneda()))
+		if despues.EsNegativo() {
+			despues = entidades.NuevoDinero(0, antes.Moneda())
+		}
+
+		precio.PrecioUnitario = despues
+		precio.Reglas = append(precio.Reglas, entidades.ReglaPrecioAplicada{
+			IDReglaPrecio: regla.ID,
+			Nombre:        regla.Nombre,
+			TipoRegla:     regla.TipoRegla,
+			PrecioAntes:   antes,
+			PrecioDespues: despues,
+		})
+	}
+
+	return precio
+}
+
+// monedasTiposPasaje lista la moneda del costo de cada tipo de pasaje
+func monedasTiposPasaje(tiposPasaje []*entidades.TipoPasaje) []string {
+	monedas := make([]string, 0, len(tiposPasaje))
+	for _, tipoPasaje := range tiposPasaje {
+		monedas = append(monedas, tipoPasaje.Costo.Moneda())
+	}
+	return monedas
+}
+
+// reglaAplica verifica el alcance y la condición de una regla para un tipo de pasaje en una instancia
+func reglaAplica(regla *entidades.ReglaPrecio, tipoPasaje *entidades.TipoPasaje, datos *entidades.DatosPrecioInstancia) bool {
+	if !regla.Activo {
+		return false
+	}
+
+	// Alcance de la regla
+	if regla.IDTipoPasaje != nil && *regla.IDTipoPasaje != tipoPasaje.ID {
+		return false
+	}
+	if regla.IDTipoTour != nil && *regla.IDTipoTour != datos.IDTipoTour {
+		return false
+	}
+	if regla.IDSede != nil && *regla.IDSede != datos.IDSede {
+		return false
+	}
+
+	switch regla.TipoRegla {
+	case "TEMPORADA":
+		if regla.FechaInicio == nil || regla.FechaFin == nil {
+			return false
+		}
+		fecha := datos.Fecha.Format("2006-01-02")
+		return fecha >= regla.FechaInicio.Format("2006-01-02") && fecha <= regla.FechaFin.Format("2006-01-02")
+	case "DIA_SEMANA":
+		dia := strconv.Itoa(diaSemanaISO(datos.Fecha))
+		for _, valor := range strings.Split(regla.DiasSemana, ",") {
+			if strings.TrimSpace(valor) == dia {
+				return true
+			}
+		}
+		return false
+	case "HORARIO":
+		// Las horas en formato HH:MM se comparan como texto
+		return datos.HoraInicio != "" && datos.HoraInicio >= regla.HoraInicio && datos.HoraInicio < regla.HoraFin
+	case "OCUPACION":
+		return regla.OcupacionMinima != nil && datos.Ocupacion >= *regla.OcupacionMinima
+	}
+
+	return false
+}
+
+// diaSemanaISO devuelve el día de la semana de una fecha con lunes = 1 y domingo = 7
+func diaSemanaISO(fecha time.Time) int {
+	if fecha.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(fecha.Weekday())
+}
+
+// validarCondicionesRegla verifica lo que las etiquetas de validación no cubren y devuelve los días de la
+// semana normalizados (ordenados y sin repetir)
+func validarCondicionesRegla(tipo string, diasSemana string, horaInicio string, horaFin string, porcentaje float64, monto entidades.Dinero) (string, error) {
+	if porcentaje == 0 && monto.EsCero() {
+		return "", errors.New("la regla debe indicar un porcentaje o un monto de ajuste")
+	}
+
+	switch tipo {
+	case "DIA_SEMANA":
+		var marcados [8]bool
+		for _, valor := range strings.Split(diasSemana, ",") {
+			dia, err := strconv.Atoi(strings.TrimSpace(valor))
+			if err != nil || dia < 1 || dia > 7 {
+				return "", errors.New("los días de la semana deben ser números del 1 (lunes) al 7 (domingo) separados por comas")
+			}
+			marcados[dia] = true
+		}
+		dias := []string{}
+		for dia := 1; dia <= 7; dia++ {
+			if marcados[dia] {
+				dias = append(dias, strconv.Itoa(dia))
+			}
+		}
+		return strings.Join(dias, ","), nil
+	case "HORARIO":
+		if horaFin <= horaInicio {
+			return "", errors.New("la hora de fin debe ser posterior a la hora de inicio")
+		}
+	}
+
+	// Los días de la semana solo se guardan en las reglas de ese tipo
+	return "", nil
+}
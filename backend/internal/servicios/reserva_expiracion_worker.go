@@ -0,0 +1,73 @@
+package servicios
+
+import (
+	"errors"
+	"log"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"time"
+)
+
+// IniciarLiberacionReservasExpiradas ejecuta en segundo plano, cada intervalo, la liberación
+// de cupos de las reservas web cuya retención temporal venció
+func IniciarLiberacionReservasExpiradas(reservaService *ReservaService, intervalo time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			liberadas, err := reservaService.LiberarReservasExpiradas()
+			if err != nil {
+				log.Printf("Error al liberar reservas expiradas: %v", err)
+				continue
+			}
+			if len(liberadas) > 0 {
+				log.Printf("Reservas expiradas liberadas: %v", liberadas)
+			}
+		}
+	}()
+}
+
+// IniciarProcesamientoListaEspera ejecuta en segundo plano, cada intervalo, el vencimiento de las ofertas
+// de lista de espera no aprovechadas y la oferta de los cupos libres a los clientes en espera
+func IniciarProcesamientoListaEspera(listaEsperaService *ListaEsperaService, intervalo time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ofertadas, err := listaEsperaService.ProcesarPendientes()
+			if err != nil {
+				log.Printf("Error al procesar listas de espera: %v", err)
+				continue
+			}
+			if len(ofertadas) > 0 {
+				log.Printf("Entradas de lista de espera ofertadas: %v", ofertadas)
+			}
+		}
+	}()
+}
+
+// IniciarConciliacionPagos ejecuta en segundo plano, cada intervalo, la conciliación de los pagos en línea
+// con sus pasarelas; si otra instancia del backend la está ejecutando, espera a la siguiente pasada
+func IniciarConciliacionPagos(conciliacionService *ConciliacionPagoService, intervalo time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			conciliacion, err := conciliacionService.Conciliar(entidades.OrigenConciliacionAutomatica, nil)
+			if errors.Is(err, repositorios.ErrConciliacionEnCurso) {
+				continue
+			}
+			if err != nil {
+				log.Printf("Error al conciliar pagos en línea: %v", err)
+				continue
+			}
+			if conciliacion.Discrepancias > 0 {
+				log.Printf("Conciliación de pagos %d: %d diferencias, %d corregidas",
+					conciliacion.ID, conciliacion.Discrepancias, conciliacion.Corregidas)
+			}
+		}
+	}()
+}
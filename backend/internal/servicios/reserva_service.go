@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log"
 	"sistema-toursseft/internal/entidades"
 	"sistema-toursseft/internal/repositorios"
 	"time"
@@ -12,17 +13,29 @@ import (
 // ReservaService maneja la lógica de negocio para reservas
 // Coordina las operaciones entre el repositorio y las reglas de negocio
 type ReservaService struct {
-	db                 *sql.DB
-	reservaRepo        *repositorios.ReservaRepository
-	clienteRepo        *repositorios.ClienteRepository
-	instanciaTourRepo  *repositorios.InstanciaTourRepository
-	canalVentaRepo     *repositorios.CanalVentaRepository
-	tipoPasajeRepo     *repositorios.TipoPasajeRepository
-	paquetePasajesRepo *repositorios.PaquetePasajesRepository
-	usuarioRepo        *repositorios.UsuarioRepository
-	sedeRepo           *repositorios.SedeRepository
+	db                       *sql.DB
+	reservaRepo              *repositorios.ReservaRepository
+	clienteRepo              *repositorios.ClienteRepository
+	instanciaTourRepo        *repositorios.InstanciaTourRepository
+	canalVentaRepo           *repositorios.CanalVentaRepository
+	tipoPasajeRepo           *repositorios.TipoPasajeRepository
+	paquetePasajesRepo       *repositorios.PaquetePasajesRepository
+	usuarioRepo              *repositorios.UsuarioRepository
+	sedeRepo                 *repositorios.SedeRepository
+	webhookEventoRepo        *repositorios.WebhookEventoRepository
+	listaEsperaService       *ListaEsperaService
+	codigoPromocionalService *CodigoPromocionalService
+	reglaPrecioService       *ReglaPrecioService
+	tipoCambioService        *TipoCambioService
 }
 
+// Canal y sede con los que se registran las reservas web pagadas con Mercado Pago
+// (deben existir en la base de datos)
+const (
+	idCanalWeb = 1
+	idSedeWeb  = 1
+)
+
 // NewReservaService crea una nueva instancia de ReservaService
 // Inicializa el servicio con todas las dependencias necesarias
 func NewReservaService(
@@ -35,17 +48,34 @@ func NewReservaService(
 	paquetePasajesRepo *repositorios.PaquetePasajesRepository,
 	usuarioRepo *repositorios.UsuarioRepository,
 	sedeRepo *repositorios.SedeRepository,
+	webhookEventoRepo *repositorios.WebhookEventoRepository,
+	listaEsperaService *ListaEsperaService,
+	codigoPromocionalService *CodigoPromocionalService,
+	reglaPrecioService *ReglaPrecioService,
+	tipoCambioService *TipoCambioService,
 ) *ReservaService {
 	return &ReservaService{
-		db:                 db,
-		reservaRepo:        reservaRepo,
-		clienteRepo:        clienteRepo,
-		instanciaTourRepo:  instanciaTourRepo,
-		canalVentaRepo:     canalVentaRepo,
-		tipoPasajeRepo:     tipoPasajeRepo,
-		paquetePasajesRepo: paquetePasajesRepo,
-		usuarioRepo:        usuarioRepo,
-		sedeRepo:           sedeRepo,
+		db:                       db,
+		reservaRepo:              reservaRepo,
+		clienteRepo:              clienteRepo,
+		instanciaTourRepo:        instanciaTourRepo,
+		canalVentaRepo:           canalVentaRepo,
+		tipoPasajeRepo:           tipoPasajeRepo,
+		paquetePasajesRepo:       paquetePasajesRepo,
+		usuarioRepo:              usuarioRepo,
+		sedeRepo:                 sedeRepo,
+		webhookEventoRepo:        webhookEventoRepo,
+		listaEsperaService:       listaEsperaService,
+		codigoPromocionalService: codigoPromocionalService,
+		reglaPrecioService:       reglaPrecioService,
+		tipoCambioService:        tipoCambioService,
+	}
+}
+
+// ofrecerCuposLiberados ofrece a la lista de espera los cupos que una operación devolvió a la instancia
+func (s *ReservaService) ofrecerCuposLiberados(idInstancia int) {
+	if s.listaEsperaService != nil {
+		s.listaEsperaService.ProcesarInstancia(idInstancia)
 	}
 }
 
@@ -93,41 +123,278 @@ func (s *ReservaService) Create(reserva *entidades.NuevaReservaRequest) (int, er
 		}
 	}
 
-	// Verificar que los tipos de pasaje existen
-	totalPasajerosIndividuales := 0
-	for _, pasaje := range reserva.CantidadPasajes {
-		_, err := s.tipoPasajeRepo.GetByID(pasaje.IDTipoPasaje)
+	// Calcular el total con los precios vigentes y el código promocional
+	cotizacion, err := s.prepararNuevaReserva(reserva)
+	if err != nil {
+		return 0, err
+	}
+
+	// Verificación preliminar de cupo; la definitiva se hace con la instancia bloqueada
+	// dentro de la transacción del repositorio
+	if cotizacion.TotalPasajeros > instanciaTour.CupoDisponible {
+		return 0, errors.New("no hay suficiente cupo disponible para la cantidad de pasajeros solicitada")
+	}
+
+	// Crear reserva
+	id, err := s.reservaRepo.Create(reserva)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// CotizarReserva calcula el total de una reserva con los precios efectivos de los tipos de pasaje en la
+// instancia, los precios vigentes de los paquetes y el descuento del código promocional, si se indica.
+// Los pasajes y paquetes deben pertenecer al tipo de tour de la instancia
+func (s *ReservaService) CotizarReserva(solicitud *entidades.CotizarReservaRequest) (*entidades.CotizacionReserva, error) {
+	pasajes, paquetes := solicitud.CantidadPasajes, solicitud.Paquetes
+
+	// Verificar que la instancia existe y obtener los datos con los que se evalúan las reglas de precio
+	datosInstancia, err := s.reglaPrecioService.GetDatosInstancia(solicitud.IDInstancia)
+	if err != nil {
+		return nil, errors.New("la instancia de tour especificada no existe")
+	}
+	idTipoTour := datosInstancia.IDTipoTour
+
+	// Obtener los precios vigentes de los tipos de pasaje
+	tiposPasaje := make(map[int]*entidades.TipoPasaje)
+	for _, pasaje := range pasajes {
+		if _, ok := tiposPasaje[pasaje.IDTipoPasaje]; ok {
+			continue
+		}
+		tipoPasaje, err := s.tipoPasajeRepo.GetByID(pasaje.IDTipoPasaje)
 		if err != nil {
-			return 0, errors.New("uno de los tipos de pasaje especificados no existe")
+			return nil, errors.New("uno de los tipos de pasaje especificados no existe")
 		}
-		totalPasajerosIndividuales += pasaje.Cantidad
+		tiposPasaje[pasaje.IDTipoPasaje] = tipoPasaje
 	}
 
-	// Verificar que los paquetes existen y calcular total de pasajeros
-	totalPasajerosPaquetes := 0
-	for _, paquete := range reserva.Paquetes {
+	// Obtener los precios vigentes de los paquetes
+	paquetesPasajes := make(map[int]*entidades.PaquetePasajes)
+	for _, paquete := range paquetes {
+		if _, ok := paquetesPasajes[paquete.IDPaquete]; ok {
+			continue
+		}
 		paqueteInfo, err := s.paquetePasajesRepo.GetByID(paquete.IDPaquete)
 		if err != nil {
-			return 0, errors.New("uno de los paquetes especificados no existe")
+			return nil, errors.New("uno de los paquetes especificados no existe")
 		}
-		totalPasajerosPaquetes += paqueteInfo.CantidadTotal * paquete.Cantidad
+		paquetesPasajes[paquete.IDPaquete] = paqueteInfo
+	}
+
+	// Los precios en dólares se convierten a soles con el tipo de cambio del día
+	listaTiposPasaje := make([]*entidades.TipoPasaje, 0, len(tiposPasaje))
+	for _, tipoPasaje := range tiposPasaje {
+		listaTiposPasaje = append(listaTiposPasaje, tipoPasaje)
+	}
+	monedas := monedasTiposPasaje(listaTiposPasaje)
+	for _, paqueteInfo := range paquetesPasajes {
+		monedas = append(monedas, paqueteInfo.PrecioTotal.Moneda())
+	}
+	tipoCambio, err := s.tipoCambioService.TipoCambioVenta(monedas...)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calcular total de pasajeros
-	totalPasajeros := totalPasajerosIndividuales + totalPasajerosPaquetes
+	// Aplicar las reglas de temporada, día, horario y ocupación a los tipos de pasaje
+	precios, err := s.reglaPrecioService.ResolverPrecios(datosInstancia, listaTiposPasaje, tipoCambio)
+	if err != nil {
+		return nil, err
+	}
 
-	// Verificar disponibilidad de cupo
-	if totalPasajeros > instanciaTour.CupoDisponible {
-		return 0, errors.New("no hay suficiente cupo disponible para la cantidad de pasajeros solicitada")
+	cotizacion, err := CalcularCotizacionReserva(idTipoTour, pasajes, tiposPasaje, precios, paquetes, paquetesPasajes, tipoCambio)
+	if err != nil {
+		return nil, err
 	}
+	cotizacion.IDInstancia = solicitud.IDInstancia
+	cotizacion.Precios = precios
+
+	// Aplicar el código promocional; sin sede ni canal se valida como venta web
+	if solicitud.CodigoPromocional != "" {
+		contexto := entidades.ContextoPromocion{
+			IDTipoTour: idTipoTour,
+			IDSede:     solicitud.IDSede,
+			IDCanal:    solicitud.IDCanal,
+			IDCliente:  solicitud.IDCliente,
+		}
+		if contexto.IDSede == 0 {
+			contexto.IDSede = idSedeWeb
+		}
+		if contexto.IDCanal == 0 {
+			contexto.IDCanal = idCanalWeb
+		}
 
-	// Crear reserva
-	id, err := s.reservaRepo.Create(reserva)
+		promocion, err := s.codigoPromocionalService.Aplicar(solicitud.CodigoPromocional, cotizacion, contexto)
+		if err != nil {
+			return nil, err
+		}
+		aplicarPromocion(cotizacion, promocion)
+	}
+
+	return cotizacion, nil
+}
+
+// aplicarPromocion descuenta del subtotal de la cotización el monto de un código promocional
+func aplicarPromocion(cotizacion *entidades.CotizacionReserva, promocion *entidades.AplicacionPromocion) {
+	cotizacion.Promocion = promocion
+	cotizacion.Descuento = promocion.Descuento
+	cotizacion.Total = cotizacion.Subtotal.Restar(promocion.Descuento)
+}
+
+// prepararNuevaReserva cotiza una reserva nueva, verifica el total enviado y fija el total y el descuento
+// con los que se guardará
+func (s *ReservaService) prepararNuevaReserva(reserva *entidades.NuevaReservaRequest) (*entidades.CotizacionReserva, error) {
+	cotizacion, err := s.CotizarReserva(&entidades.CotizarReservaRequest{
+		IDInstancia:       reserva.IDInstancia,
+		IDSede:            reserva.IDSede,
+		IDCanal:           reserva.IDCanal,
+		IDCliente:         reserva.IDCliente,
+		CantidadPasajes:   reserva.CantidadPasajes,
+		Paquetes:          reserva.Paquetes,
+		CodigoPromocional: reserva.CodigoPromocional,
+	})
 	if err != nil {
+		return nil, err
+	}
+	if err := VerificarTotalDeclarado(reserva.TotalPagar, cotizacion); err != nil {
+		return nil, err
+	}
+
+	reserva.TotalPagar = cotizacion.Total
+	reserva.Promocion = cotizacion.Promocion
+	reserva.Precios = cotizacion.Precios
+	reserva.PreciosPaquetes = cotizacion.PreciosPaquetes
+	reserva.TipoCambio = cotizacion.TipoCambio
+	return cotizacion, nil
+}
+
+// ConvertirListaEspera crea la reserva de una entrada de lista de espera con oferta vigente
+// El total se calcula igual que en una reserva nueva
+func (s *ReservaService) ConvertirListaEspera(idEntrada int, reserva *entidades.NuevaReservaRequest) (int, error) {
+	if _, err := s.prepararNuevaReserva(reserva); err != nil {
 		return 0, err
 	}
 
-	return id, nil
+	return s.listaEsperaService.Convertir(idEntrada, reserva)
+}
+
+// CalcularCotizacionReserva arma el detalle y el total de una reserva a partir de los precios indicados
+// Los tipos de pasaje sin precio efectivo resuelto se cotizan a su costo. Los precios en dólares se
+// convierten a soles con el tipo de cambio indicado. Las líneas con cantidad cero se omiten; la reserva
+// debe incluir al menos un pasajero
+func CalcularCotizacionReserva(
+	idTipoTour int,
+	pasajes []entidades.PasajeCantidadRequest,
+	tiposPasaje map[int]*entidades.TipoPasaje,
+	precios map[int]*entidades.PrecioPasaje,
+	paquetes []entidades.PaqueteRequest,
+	paquetesPasajes map[int]*entidades.PaquetePasajes,
+	tipoCambio *entidades.TipoCambio,
+) (*entidades.CotizacionReserva, error) {
+	cotizacion := &entidades.CotizacionReserva{
+		IDTipoTour:      idTipoTour,
+		Lineas:          []entidades.LineaCotizacionReserva{},
+		PreciosPaquetes: make(map[int]entidades.Dinero),
+	}
+	usaTipoCambio := false
+
+	for _, pasaje := range pasajes {
+		tipoPasaje, ok := tiposPasaje[pasaje.IDTipoPasaje]
+		if !ok {
+			return nil, errors.New("uno de los tipos de pasaje especificados no existe")
+		}
+		if tipoPasaje.IDTipoTour != idTipoTour {
+			return nil, fmt.Errorf("el tipo de pasaje %s no corresponde al tour de la instancia", tipoPasaje.Nombre)
+		}
+		if pasaje.Cantidad <= 0 {
+			continue
+		}
+
+		precioUnitario := tipoPasaje.Costo
+		var reglas []entidades.ReglaPrecioAplicada
+		if precio, ok := precios[tipoPasaje.ID]; ok {
+			precioUnitario = precio.PrecioUnitario
+			reglas = precio.Reglas
+		}
+		usaTipoCambio = usaTipoCambio || precioUnitario.Moneda() != entidades.MonedaPorDefecto
+		precioUnitario, err := ConvertirAMonedaBase(precioUnitario, tipoCambio)
+		if err != nil {
+			return nil, err
+		}
+
+		subtotal := precioUnitario.Multiplicar(pasaje.Cantidad)
+		cotizacion.Lineas = append(cotizacion.Lineas, entidades.LineaCotizacionReserva{
+			Tipo:           "PASAJE",
+			ID:             tipoPasaje.ID,
+			Descripcion:    tipoPasaje.Nombre,
+			Cantidad:       pasaje.Cantidad,
+			Pasajeros:      pasaje.Cantidad,
+			Moneda:         tipoPasaje.Costo.Moneda(),
+			PrecioBase:     tipoPasaje.Costo,
+			PrecioUnitario: precioUnitario,
+			Subtotal:       subtotal,
+			Reglas:         reglas,
+		})
+		cotizacion.TotalPasajeros += pasaje.Cantidad
+		cotizacion.Subtotal = cotizacion.Subtotal.Sumar(subtotal)
+	}
+
+	for _, paquete := range paquetes {
+		paqueteInfo, ok := paquetesPasajes[paquete.IDPaquete]
+		if !ok {
+			return nil, errors.New("uno de los paquetes especificados no existe")
+		}
+		if paqueteInfo.IDTipoTour != idTipoTour {
+			return nil, fmt.Errorf("el paquete %s no corresponde al tour de la instancia", paqueteInfo.Nombre)
+		}
+		if paquete.Cantidad <= 0 {
+			continue
+		}
+
+		usaTipoCambio = usaTipoCambio || paqueteInfo.PrecioTotal.Moneda() != entidades.MonedaPorDefecto
+		precioUnitario, err := ConvertirAMonedaBase(paqueteInfo.PrecioTotal, tipoCambio)
+		if err != nil {
+			return nil, err
+		}
+		cotizacion.PreciosPaquetes[paqueteInfo.ID] = precioUnitario
+
+		subtotal := precioUnitario.Multiplicar(paquete.Cantidad)
+		pasajeros := paqueteInfo.CantidadTotal * paquete.Cantidad
+		cotizacion.Lineas = append(cotizacion.Lineas, entidades.LineaCotizacionReserva{
+			Tipo:           "PAQUETE",
+			ID:             paqueteInfo.ID,
+			Descripcion:    paqueteInfo.Nombre,
+			Cantidad:       paquete.Cantidad,
+			Pasajeros:      pasajeros,
+			Moneda:         paqueteInfo.PrecioTotal.Moneda(),
+			PrecioBase:     paqueteInfo.PrecioTotal,
+			PrecioUnitario: precioUnitario,
+			Subtotal:       subtotal,
+		})
+		cotizacion.TotalPasajeros += pasajeros
+		cotizacion.Subtotal = cotizacion.Subtotal.Sumar(subtotal)
+	}
+
+	if cotizacion.TotalPasajeros == 0 {
+		return nil, errors.New("la reserva debe incluir al menos un pasajero")
+	}
+	if usaTipoCambio {
+		cotizacion.TipoCambio = tipoCambio
+	}
+	cotizacion.Total = cotizacion.Subtotal
+
+	return cotizacion, nil
+}
+
+// VerificarTotalDeclarado compara el total enviado por el cliente con el calculado en el servidor
+// El total es opcional; si se envía debe coincidir al céntimo.
+func VerificarTotalDeclarado(declarado entidades.Dinero, cotizacion *entidades.CotizacionReserva) error {
+	if declarado.EsCero() || declarado.Centimos() == cotizacion.Total.Centimos() {
+		return nil
+	}
+	return fmt.Errorf("el total enviado (%s) no coincide con el total calculado con los precios vigentes (%s)",
+		declarado, cotizacion.Total)
 }
 
 // GetByID obtiene una reserva por su ID
@@ -186,22 +453,37 @@ func (s *ReservaService) Update(id int, reserva *entidades.ActualizarReservaRequ
 		}
 	}
 
-	// Verificar que los tipos de pasaje existen
-	for _, pasaje := range reserva.CantidadPasajes {
-		_, err := s.tipoPasajeRepo.GetByID(pasaje.IDTipoPasaje)
-		if err != nil {
-			return errors.New("uno de los tipos de pasaje especificados no existe")
-		}
+	// Recalcular el total con los precios vigentes; el total enviado, si existe, debe coincidir
+	cotizacion, err := s.CotizarReserva(&entidades.CotizarReservaRequest{
+		IDInstancia:     reserva.IDInstancia,
+		IDSede:          reserva.IDSede,
+		IDCanal:         reserva.IDCanal,
+		IDCliente:       reserva.IDCliente,
+		CantidadPasajes: reserva.CantidadPasajes,
+		Paquetes:        reserva.Paquetes,
+	})
+	if err != nil {
+		return err
 	}
 
-	// Verificar que los paquetes existen
-	for _, paquete := range reserva.Paquetes {
-		_, err := s.paquetePasajesRepo.GetByID(paquete.IDPaquete)
-		if err != nil {
-			return errors.New("uno de los paquetes especificados no existe")
-		}
+	// Si la reserva canjeó un código promocional, el descuento se recalcula sobre los nuevos pasajes
+	promocion, err := s.codigoPromocionalService.RecalcularCanje(id, cotizacion)
+	if err != nil {
+		return err
+	}
+	if promocion != nil {
+		aplicarPromocion(cotizacion, promocion)
 	}
 
+	if err := VerificarTotalDeclarado(reserva.TotalPagar, cotizacion); err != nil {
+		return err
+	}
+	reserva.TotalPagar = cotizacion.Total
+	reserva.Promocion = promocion
+	reserva.Precios = cotizacion.Precios
+	reserva.PreciosPaquetes = cotizacion.PreciosPaquetes
+	reserva.TipoCambio = cotizacion.TipoCambio
+
 	// El repositorio maneja internamente la lógica de verificar cupos y actualizar instancias
 	// Simplemente llamamos al método Update con todos los datos validados
 	return s.reservaRepo.Update(id, reserva)
@@ -212,7 +494,7 @@ func (s *ReservaService) Update(id int, reserva *entidades.ActualizarReservaRequ
 // El repositorio maneja internamente el cupo disponible
 func (s *ReservaService) CambiarEstado(id int, estado string) error {
 	// Verificar que la reserva existe
-	_, err := s.reservaRepo.GetByID(id)
+	reserva, err := s.reservaRepo.GetByID(id)
 	if err != nil {
 		return err
 	}
@@ -224,7 +506,59 @@ func (s *ReservaService) CambiarEstado(id int, estado string) error {
 
 	// Actualizar estado de la reserva
 	// El repositorio maneja la lógica de liberar o reservar cupos
-	return s.reservaRepo.UpdateEstado(id, estado)
+	if err := s.reservaRepo.UpdateEstado(id, estado); err != nil {
+		return err
+	}
+
+	if estado == "CANCELADA" {
+		s.ofrecerCuposLiberados(reserva.IDInstancia)
+	}
+
+	return nil
+}
+
+// Reprogramar mueve una reserva a otra instancia del mismo tipo de tour
+// Los pasajes toman el precio efectivo de la instancia de destino; el repositorio valida cupo, recalcula
+// el total y registra el cambio en el historial
+func (s *ReservaService) Reprogramar(id int, solicitud *entidades.ReprogramarReservaRequest, origen string, idUsuario *int) (*entidades.ResultadoReprogramacion, error) {
+	// Verificar que la instancia de destino existe
+	_, err := s.instanciaTourRepo.GetByID(solicitud.IDInstanciaDestino)
+	if err != nil {
+		return nil, errors.New("la instancia de tour especificada no existe")
+	}
+
+	// Resolver los precios de los tipos de pasaje en la instancia de destino
+	datosDestino, err := s.reglaPrecioService.GetDatosInstancia(solicitud.IDInstanciaDestino)
+	if err != nil {
+		return nil, errors.New("la instancia de tour especificada no existe")
+	}
+	tiposPasaje, err := s.tipoPasajeRepo.ListByTipoTour(datosDestino.IDTipoTour)
+	if err != nil {
+		return nil, err
+	}
+	tipoCambio, err := s.tipoCambioService.TipoCambioVenta(monedasTiposPasaje(tiposPasaje)...)
+	if err != nil {
+		return nil, err
+	}
+	solicitud.Precios, err = s.reglaPrecioService.ResolverPrecios(datosDestino, tiposPasaje, tipoCambio)
+	if err != nil {
+		return nil, err
+	}
+
+	resultado, err := s.reservaRepo.Reprogramar(id, solicitud, origen, idUsuario)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los cupos que dejó la reserva en la instancia de origen pasan a la lista de espera
+	s.ofrecerCuposLiberados(resultado.IDInstanciaOrigen)
+
+	return resultado, nil
+}
+
+// ListReprogramaciones obtiene el historial de reprogramaciones de una reserva
+func (s *ReservaService) ListReprogramaciones(id int) ([]*entidades.ReprogramacionReserva, error) {
+	return s.reservaRepo.ListReprogramaciones(id)
 }
 
 // Delete realiza una eliminación lógica de una reserva
@@ -232,14 +566,20 @@ func (s *ReservaService) CambiarEstado(id int, estado string) error {
 // El repositorio maneja internamente el cupo disponible
 func (s *ReservaService) Delete(id int) error {
 	// Verificar que la reserva existe
-	_, err := s.reservaRepo.GetByID(id)
+	reserva, err := s.reservaRepo.GetByID(id)
 	if err != nil {
 		return err
 	}
 
 	// Eliminar reserva (lógicamente)
 	// El repositorio maneja la lógica de liberar cupos
-	return s.reservaRepo.Delete(id)
+	if err := s.reservaRepo.Delete(id); err != nil {
+		return err
+	}
+
+	s.ofrecerCuposLiberados(reserva.IDInstancia)
+
+	return nil
 }
 
 // List obtiene todas las reservas activas del sistema
@@ -278,11 +618,11 @@ func (s *ReservaService) ListByFecha(fecha time.Time) ([]*entidades.Reserva, err
 	return s.reservaRepo.ListByFecha(fecha)
 }
 
-// ListByEstado lista todas las reservas por estado específico (RESERVADO, CANCELADA, CONFIRMADA)
+// ListByEstado lista todas las reservas por estado específico (RESERVADO, CANCELADA, CONFIRMADA, EXPIRADA)
 // Verifica que el estado sea válido antes de ejecutar la consulta
 func (s *ReservaService) ListByEstado(estado string) ([]*entidades.Reserva, error) {
 	// Verificar que el estado es válido
-	if estado != "RESERVADO" && estado != "CANCELADA" && estado != "CONFIRMADA" {
+	if estado != "RESERVADO" && estado != "CANCELADA" && estado != "CONFIRMADA" && estado != "EXPIRADA" {
 		return nil, errors.New("estado de reserva inválido")
 	}
 
@@ -321,10 +661,30 @@ func (s *ReservaService) ListAllReservas() ([]*entidades.Reserva, error) {
 	return s.reservaRepo.ListBySede(nil)
 }
 
-// ReservarConMercadoPago crea una reserva y genera una preferencia de pago para Mercado Pago
-func (s *ReservaService) ReservarConMercadoPago(
+// DiasSaldosPendientesPorDefecto es la ventana de salidas que se revisa al listar saldos por cobrar
+const DiasSaldosPendientesPorDefecto = 7
+
+// ListSaldosPendientes lista las reservas con saldo por cobrar que salen dentro de los próximos días
+// Si idSede es nil se listan las de todas las sedes
+func (s *ReservaService) ListSaldosPendientes(idSede *int, dias int) ([]*entidades.SaldoPendienteReserva, error) {
+	if dias < 0 || dias > 365 {
+		return nil, errors.New("la cantidad de días debe estar entre 0 y 365")
+	}
+
+	// Verificar que la sede existe
+	if idSede != nil {
+		if _, err := s.sedeRepo.GetByID(*idSede); err != nil {
+			return nil, errors.New("la sede especificada no existe")
+		}
+	}
+
+	return s.reservaRepo.ListSaldosPendientes(idSede, dias)
+}
+
+// ReservarConPasarela crea una reserva web y genera su cobro en la pasarela de pago indicada
+func (s *ReservaService) ReservarConPasarela(
 	request *entidades.ReservaMercadoPagoRequest,
-	mercadoPagoService *MercadoPagoService,
+	pasarela PaymentGateway,
 	frontendURL string,
 ) (*entidades.ReservaMercadoPagoResponse, error) {
 	// Verificar que el cliente existe
@@ -343,44 +703,43 @@ func (s *ReservaService) ReservarConMercadoPago(
 		return nil, errors.New("no se puede reservar en una instancia que no está programada")
 	}
 
-	// Verificar que los tipos de pasaje existen
-	totalPasajerosIndividuales := 0
-	for _, pasaje := range request.CantidadPasajes {
-		_, err := s.tipoPasajeRepo.GetByID(pasaje.IDTipoPasaje)
-		if err != nil {
-			return nil, errors.New("uno de los tipos de pasaje especificados no existe")
-		}
-		totalPasajerosIndividuales += pasaje.Cantidad
+	// Crear reserva con valores predeterminados para canal y sede web
+	nuevaReserva := &entidades.NuevaReservaRequest{
+		IDCliente:         request.IDCliente,
+		IDInstancia:       request.IDInstancia,
+		IDCanal:           idCanalWeb,
+		IDSede:            idSedeWeb,
+		TotalPagar:        request.TotalPagar,
+		CantidadPasajes:   request.CantidadPasajes,
+		Paquetes:          request.Paquetes,
+		Notas:             fmt.Sprintf("Reserva web con pago en línea (%s)", pasarela.Nombre()),
+		CodigoPromocional: request.CodigoPromocional,
 	}
 
-	// Verificar que los paquetes existen y calcular total de pasajeros
-	totalPasajerosPaquetes := 0
-	for _, paquete := range request.Paquetes {
-		paqueteInfo, err := s.paquetePasajesRepo.GetByID(paquete.IDPaquete)
-		if err != nil {
-			return nil, errors.New("uno de los paquetes especificados no existe")
-		}
-		totalPasajerosPaquetes += paqueteInfo.CantidadTotal * paquete.Cantidad
+	// Calcular el total con los precios vigentes y el código promocional
+	cotizacion, err := s.prepararNuevaReserva(nuevaReserva)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calcular total de pasajeros
-	totalPasajeros := totalPasajerosIndividuales + totalPasajerosPaquetes
-
 	// Verificar disponibilidad de cupo
-	if totalPasajeros > instancia.CupoDisponible {
+	if cotizacion.TotalPasajeros > instancia.CupoDisponible {
 		return nil, errors.New("no hay suficiente cupo disponible para la cantidad de pasajeros solicitada")
 	}
 
-	// Crear reserva con valores predeterminados para canal y sede web
-	nuevaReserva := &entidades.NuevaReservaRequest{
-		IDCliente:       request.IDCliente,
-		IDInstancia:     request.IDInstancia,
-		IDCanal:         1, // Canal web/online (debe existir en la base de datos)
-		IDSede:          1, // Sede principal (debe existir en la base de datos)
-		TotalPagar:      request.TotalPagar,
-		CantidadPasajes: request.CantidadPasajes,
-		Paquetes:        request.Paquetes,
-		Notas:           "Reserva generada a través de Mercado Pago",
+	// Cobrar en dólares si se solicita, con el tipo de cambio del día redondeado a favor de la reserva
+	// El tipo de cambio queda en la reserva para registrar el pago con el mismo con el que se cobró
+	montoCobro := cotizacion.Total
+	if request.Moneda == entidades.MonedaDolares {
+		tipoCambio := cotizacion.TipoCambio
+		if tipoCambio == nil || tipoCambio.Moneda != entidades.MonedaDolares {
+			tipoCambio, err = s.tipoCambioService.GetVigente(entidades.MonedaDolares, time.Now())
+			if err != nil {
+				return nil, err
+			}
+		}
+		nuevaReserva.TipoCambio = tipoCambio
+		montoCobro = tipoCambio.DesdeMonedaBase(cotizacion.Total)
 	}
 
 	// Crear la reserva y obtener su ID
@@ -424,55 +783,219 @@ func (s *ReservaService) ReservarConMercadoPago(
 		}
 	}
 
-	// Crear preferencia de pago en Mercado Pago
-	preferencia, err := mercadoPagoService.CreatePreference(
-		nombreTour,
-		request.TotalPagar,
-		idReserva,
-		cliente,
-		frontendURL,
-	)
+	// Crear el cobro en la pasarela
+	checkout, err := pasarela.CrearCheckout(&entidades.SolicitudCheckout{
+		IDReserva:   idReserva,
+		Descripcion: nombreTour,
+		Monto:       montoCobro,
+		Cliente:     cliente,
+		FrontendURL: frontendURL,
+	})
 	if err != nil {
-		// Si falla la creación de la preferencia, cancelamos la reserva
-		_ = s.reservaRepo.UpdateEstado(idReserva, "CANCELADA")
+		// Si falla la creación del cobro, cancelamos la reserva y ofrecemos su cupo a la lista de espera
+		if errCancelar := s.reservaRepo.UpdateEstado(idReserva, "CANCELADA"); errCancelar == nil {
+			s.ofrecerCuposLiberados(request.IDInstancia)
+		}
 		return nil, fmt.Errorf("error al crear preferencia de pago: %v", err)
 	}
 
-	// Crear respuesta con los datos de la preferencia
+	// Registrar la pasarela del cobro para conciliar luego sus pagos
+	if err := s.AsignarPasarela(idReserva, checkout.Pasarela); err != nil {
+		// No fallar la reserva por esto, solo registrar el error
+		log.Printf("Error al registrar la pasarela de la reserva %d: %v", idReserva, err)
+	}
+
+	// Crear respuesta con los datos del cobro
 	respuesta := &entidades.ReservaMercadoPagoResponse{
 		IDReserva:        idReserva,
 		NombreTour:       nombreTour,
-		PreferenceID:     preferencia.ID,
-		InitPoint:        preferencia.InitPoint,
-		SandboxInitPoint: preferencia.SandboxInitPoint,
+		Pasarela:         checkout.Pasarela,
+		PreferenceID:     checkout.ID,
+		InitPoint:        checkout.URLPago,
+		SandboxInitPoint: checkout.URLPagoPrueba,
+		ClavePublica:     checkout.ClavePublica,
+		Monto:            montoCobro,
+		Moneda:           montoCobro.Moneda(),
 	}
 
 	return respuesta, nil
 }
 
-// ConfirmarPagoReserva confirma una reserva después de recibir el pago
-func (s *ReservaService) ConfirmarPagoReserva(idReserva int, idTransaccion string, monto float64) error {
+// AsignarPasarela registra la pasarela con la que se cobra una reserva; la conciliación de pagos revisa
+// en esa pasarela las reservas que no recibieron la notificación de su pago
+func (s *ReservaService) AsignarPasarela(idReserva int, pasarela string) error {
+	return s.reservaRepo.AsignarPasarela(idReserva, pasarela)
+}
+
+// ExpirarReservaVencida expira una reserva web cuya retención venció sin el adelanto cubierto y libera su cupo
+// Retorna false si la reserva ya no estaba pendiente de pago
+func (s *ReservaService) ExpirarReservaVencida(idReserva int) (bool, error) {
+	reserva, err := s.reservaRepo.GetByID(idReserva)
+	if err != nil {
+		return false, err
+	}
+
+	expirada, err := s.reservaRepo.ExpirarReservaVencida(idReserva)
+	if err != nil {
+		return false, err
+	}
+
+	// El cupo devuelto se ofrece de inmediato a la lista de espera de la instancia
+	if expirada {
+		s.ofrecerCuposLiberados(reserva.IDInstancia)
+	}
+
+	return expirada, nil
+}
+
+// ConfirmarPagoReserva registra el pago en línea de una reserva y la confirma si queda cubierta
+// El pago y el cambio de estado se guardan en la misma transacción; una transacción repetida no duplica el pago
+func (s *ReservaService) ConfirmarPagoReserva(idReserva int, pasarela string, idTransaccion string, monto entidades.Dinero) (*entidades.ResultadoPagoReserva, error) {
 	// Verificar que la reserva existe
 	reserva, err := s.reservaRepo.GetByID(idReserva)
+	if err != nil {
+		return nil, errors.New("la reserva especificada no existe")
+	}
+
+	if !monto.EsPositivo() {
+		return nil, errors.New("el monto del pago debe ser mayor a cero")
+	}
+
+	// Los pagos en dólares se registran con el tipo de cambio con el que se cobró la reserva,
+	// o con el del día si la reserva no tiene uno
+	conversion, err := s.tipoCambioService.ConvertirConTipoCambio(monto, reserva.TipoCambio, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Registrar el pago y actualizar la reserva
+	resultado, err := s.reservaRepo.RegistrarPagoPasarela(idReserva, pasarela, idTransaccion, conversion)
+	if err != nil {
+		return nil, fmt.Errorf("error al registrar el pago de la reserva: %w", err)
+	}
+
+	return resultado, nil
+}
+
+// RevertirPagoReserva revierte el pago de una transacción de una pasarela devuelta o contracargada
+// El cupo de una reserva que se cancela por quedarse sin pagos se ofrece a la lista de espera
+func (s *ReservaService) RevertirPagoReserva(pasarela string, idTransaccion string) (*entidades.ResultadoPagoReserva, error) {
+	resultado, err := s.reservaRepo.RevertirPagoPasarela(pasarela, idTransaccion)
+	if err != nil {
+		return nil, fmt.Errorf("error al revertir el pago de la reserva: %v", err)
+	}
+
+	if resultado != nil && resultado.CupoLiberado {
+		s.ofrecerCuposLiberados(resultado.IDInstancia)
+	}
+
+	return resultado, nil
+}
+
+// ProcesarNotificacionPago aplica sobre su reserva un pago informado por el webhook de una pasarela
+// Cada combinación pasarela/pago/estado se procesa una sola vez; los reintentos de la notificación no tienen efecto
+func (s *ReservaService) ProcesarNotificacionPago(pago *entidades.PagoPasarela, idNotificacion string) error {
+	// Extraer ID de reserva de la referencia externa (formato "RESERVA-12345")
+	idReserva, err := entidades.ParsearReferenciaReserva(pago.ReferenciaExterna)
+	if err != nil {
+		return err
+	}
+
+	// Verificar que la reserva existe
+	_, err = s.reservaRepo.GetByID(idReserva)
 	if err != nil {
 		return errors.New("la reserva especificada no existe")
 	}
 
-	// Verificar que la reserva está en estado RESERVADO
-	if reserva.Estado != "RESERVADO" {
-		return errors.New("la reserva no está en estado RESERVADO")
+	// Registrar el evento; si ya fue procesado no hay nada que hacer
+	evento := &entidades.WebhookEventoPasarela{
+		Pasarela:       pago.Pasarela,
+		IDPagoExterno:  pago.ID,
+		EstadoPago:     pago.EstadoOriginal,
+		IDNotificacion: idNotificacion,
+		Tipo:           "payment",
+		IDReserva:      &idReserva,
+		Monto:          pago.Monto,
 	}
 
-	// Actualizar estado de la reserva a CONFIRMADA
-	err = s.reservaRepo.UpdateEstado(idReserva, "CONFIRMADA")
+	idEvento, procesar, err := s.webhookEventoRepo.Registrar(evento)
 	if err != nil {
-		return fmt.Errorf("error al confirmar la reserva: %v", err)
+		return fmt.Errorf("error al registrar la notificación de pago: %v", err)
+	}
+	if !procesar {
+		return nil
 	}
 
-	// Aquí se podría registrar el pago en la tabla pagos
-	// Esto dependerá de la estructura de tu sistema
+	var resultado *entidades.ResultadoPagoReserva
+	detalle := ""
+	switch pago.Estado {
+	case entidades.EstadoPasarelaAprobado:
+		// Solo se aceptan pagos en soles o dólares
+		moneda := pago.Monto.Moneda()
+		if moneda != entidades.MonedaPorDefecto && moneda != entidades.MonedaDolares {
+			err = fmt.Errorf("la moneda del pago (%s) no es aceptada", moneda)
+			_ = s.webhookEventoRepo.MarcarResultado(idEvento, "RECHAZADO", err.Error())
+			return err
+		}
 
-	return nil
+		resultado, err = s.ConfirmarPagoReserva(idReserva, pago.Pasarela, pago.ID, pago.Monto)
+		if errors.Is(err, entidades.ErrPagoNoAplicable) {
+			// El cobro no se registra: queda rechazado para que finanzas lo revise y lo devuelva
+			log.Printf("Pago %s de %s rechazado para la reserva %d: %v", pago.ID, pago.Pasarela, idReserva, err)
+			return s.webhookEventoRepo.MarcarResultado(idEvento, "RECHAZADO", err.Error()+"; pendiente de revisión y devolución")
+		}
+		if err == nil {
+			detalle = detalleResultadoPago(resultado)
+		}
+	case entidades.EstadoPasarelaDevuelto, entidades.EstadoPasarelaContracargo:
+		resultado, err = s.RevertirPagoReserva(pago.Pasarela, pago.ID)
+		if resultado == nil {
+			detalle = "sin pago registrado para la transacción"
+		} else {
+			detalle = fmt.Sprintf("pago %d devuelto; reserva en estado %s", resultado.IDPago, resultado.EstadoReserva)
+		}
+	}
+
+	if err != nil {
+		_ = s.webhookEventoRepo.MarcarResultado(idEvento, "ERROR", err.Error())
+		return err
+	}
+
+	return s.webhookEventoRepo.MarcarResultado(idEvento, "PROCESADO", detalle)
+}
+
+// detalleResultadoPago describe los casos que requieren atención: pagos parciales, en exceso o sin cupo
+func detalleResultadoPago(resultado *entidades.ResultadoPagoReserva) string {
+	switch {
+	case resultado.EstadoReserva == "EXPIRADA" && resultado.IDDevolucion != 0:
+		return fmt.Sprintf("reserva expirada sin cupo disponible; devolución %d del pago %d pendiente de aprobación",
+			resultado.IDDevolucion, resultado.IDPago)
+	case resultado.EstadoReserva == "EXPIRADA":
+		return fmt.Sprintf("reserva expirada sin cupo disponible; pago %d pendiente de devolución", resultado.IDPago)
+	case resultado.Saldo.EsPositivo() && resultado.TotalPagado.EsPositivo():
+		return fmt.Sprintf("pago parcial; saldo pendiente %s", resultado.Saldo)
+	case resultado.Saldo.EsNegativo():
+		return fmt.Sprintf("pago en exceso por %s", resultado.Saldo.Multiplicar(-1))
+	}
+
+	return ""
+}
+
+// LiberarReservasExpiradas libera los cupos de las reservas web cuya retención venció
+// Los cupos devueltos se ofrecen de inmediato a las listas de espera
+func (s *ReservaService) LiberarReservasExpiradas() ([]int, error) {
+	liberadas, err := s.reservaRepo.LiberarReservasExpiradas()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(liberadas) > 0 && s.listaEsperaService != nil {
+		if _, err := s.listaEsperaService.ProcesarPendientes(); err != nil {
+			log.Printf("Error al procesar listas de espera: %v", err)
+		}
+	}
+
+	return liberadas, nil
 }
 
 // GetTotalPasajerosByInstancia obtiene el total de pasajeros reservados para una instancia
@@ -497,11 +1020,16 @@ func (s *ReservaService) VerificarDisponibilidadInstancia(idInstancia int, canti
 	return s.reservaRepo.VerificarDisponibilidadInstancia(idInstancia, cantidadPasajeros)
 }
 
+// PreciosInstancia obtiene el precio efectivo de los tipos de pasaje de una instancia
+func (s *ReservaService) PreciosInstancia(idInstancia int) (*entidades.PreciosInstancia, error) {
+	return s.reglaPrecioService.PreciosInstancia(idInstancia)
+}
+
 // UpdateEs tadoReservaActualizaEstado actualiza el estado de una reserva
 // UpdateEstado actualiza el estado de una reserva
 func (s *ReservaService) UpdateEstado(id int, estado string) error {
-	// Verificar que existe la reserva sin almacenar el resultado
-	_, err := s.reservaRepo.GetByID(id)
+	// Verificar que existe la reserva
+	reserva, err := s.reservaRepo.GetByID(id)
 	if err != nil {
 		return errors.New("la reserva especificada no existe")
 	}
@@ -513,6 +1041,7 @@ func (s *ReservaService) UpdateEstado(id int, estado string) error {
 		"CANCELADA":  true,
 		"COMPLETADA": true,
 		"ANULADA":    true,
+		"EXPIRADA":   true,
 	}
 
 	if !estadosPermitidos[estado] {
@@ -520,5 +1049,13 @@ func (s *ReservaService) UpdateEstado(id int, estado string) error {
 	}
 
 	// Actualizar estado en la base de datos
-	return s.reservaRepo.UpdateEstado(id, estado)
+	if err := s.reservaRepo.UpdateEstado(id, estado); err != nil {
+		return err
+	}
+
+	if estado == "CANCELADA" || estado == "EXPIRADA" {
+		s.ofrecerCuposLiberados(reserva.IDInstancia)
+	}
+
+	return nil
 }
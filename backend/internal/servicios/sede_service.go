@@ -35,6 +35,16 @@ func (s *SedeService) Update(id int, sede *entidades.ActualizarSedeRequest) erro
 	return s.sedeRepo.Update(id, sede)
 }
 
+// UpdateRetencionReserva configura cuántos minutos retiene cupos una reserva web de la sede
+func (s *SedeService) UpdateRetencionReserva(id int, request *entidades.ActualizarRetencionReservaRequest) error {
+	return s.sedeRepo.UpdateRetencionReserva(id, request.MinutosRetencionReserva)
+}
+
+// UpdateAdelantoReserva configura el porcentaje del total que se cobra como adelanto en las reservas de la sede
+func (s *SedeService) UpdateAdelantoReserva(id int, request *entidades.ActualizarAdelantoReservaRequest) error {
+	return s.sedeRepo.UpdateAdelantoReserva(id, request.PorcentajeAdelanto)
+}
+
 // Delete elimina una sede (borrado lógico)
 func (s *SedeService) Delete(id int) error {
 	// Verificar que la sede existe
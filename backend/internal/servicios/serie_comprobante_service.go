@@ -0,0 +1,66 @@
+package servicios
+
+import (
+	"errors"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+)
+
+// SerieComprobanteService maneja la lógica de negocio para las series de comprobantes
+type SerieComprobanteService struct {
+	serieRepo *repositorios.SerieComprobanteRepository
+	sedeRepo  *repositorios.SedeRepository
+}
+
+// NewSerieComprobanteService crea una nueva instancia de SerieComprobanteService
+func NewSerieComprobanteService(serieRepo *repositorios.SerieComprobanteRepository, sedeRepo *repositorios.SedeRepository) *SerieComprobanteService {
+	return &SerieComprobanteService{
+		serieRepo: serieRepo,
+		sedeRepo:  sedeRepo,
+	}
+}
+
+// Create registra una nueva serie para una sede
+func (s *SerieComprobanteService) Create(serie *entidades.NuevaSerieComprobanteRequest) (int, error) {
+	// Verificar que la sede existe
+	if _, err := s.sedeRepo.GetByID(serie.IDSede); err != nil {
+		return 0, errors.New("la sede especificada no existe")
+	}
+
+	// SUNAT exige que las series de facturas empiecen con F y las de boletas con B
+	if serie.Tipo == "FACTURA" && serie.Serie[0] != 'F' {
+		return 0, errors.New("la serie de facturas debe empezar con F")
+	}
+	if serie.Tipo == "BOLETA" && serie.Serie[0] != 'B' {
+		return 0, errors.New("la serie de boletas debe empezar con B")
+	}
+	// Las notas usan F si corrigen facturas y B si corrigen boletas
+	if (serie.Tipo == "NOTA_CREDITO" || serie.Tipo == "NOTA_DEBITO") && serie.Serie[0] != 'F' && serie.Serie[0] != 'B' {
+		return 0, errors.New("la serie de notas debe empezar con F (facturas) o B (boletas)")
+	}
+
+	// La serie es única en toda la empresa
+	if _, err := s.serieRepo.GetBySerie(serie.Serie); err == nil {
+		return 0, errors.New("ya existe una serie con este código")
+	}
+
+	return s.serieRepo.Create(serie)
+}
+
+// GetByID obtiene una serie por su ID
+func (s *SerieComprobanteService) GetByID(id int) (*entidades.SerieComprobante, error) {
+	return s.serieRepo.GetByID(id)
+}
+
+// CambiarEstado activa o desactiva una serie
+func (s *SerieComprobanteService) CambiarEstado(id int, activo bool) error {
+	if _, err := s.serieRepo.GetByID(id); err != nil {
+		return err
+	}
+	return s.serieRepo.CambiarEstado(id, activo)
+}
+
+// ListUso lista el uso de las series; idSede 0 incluye todas las sedes
+func (s *SerieComprobanteService) ListUso(idSede int) ([]*entidades.UsoSerieComprobante, error) {
+	return s.serieRepo.ListUso(idSede)
+}
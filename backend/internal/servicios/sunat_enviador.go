@@ -0,0 +1,252 @@
+package servicios
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnviadorSunat envía un comprobante firmado a SUNAT o a un OSE y devuelve la constancia de recepción
+type EnviadorSunat interface {
+	Nombre() string
+	Enviar(nombreArchivo string, xmlFirmado []byte) (*entidades.RespuestaSunat, error)
+}
+
+// comprimirArchivo empaqueta un único archivo en un ZIP, el formato en que SUNAT recibe y devuelve documentos
+func comprimirArchivo(nombre string, contenido []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	zw := zip.NewWriter(&buffer)
+	w, err := zw.Create(nombre)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(contenido); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// cdrXML contiene los campos del ApplicationResponse que interesan
+type cdrXML struct {
+	Codigo      string   `xml:"DocumentResponse>Response>ResponseCode"`
+	Descripcion string   `xml:"DocumentResponse>Response>Description"`
+	Notas       []string `xml:"Note"`
+}
+
+// InterpretarCDR lee el ZIP de la constancia de recepción y clasifica la respuesta:
+// código 0 es aceptado (observado si trae notas), 2000-3999 rechazado y 4000 en adelante observado
+func InterpretarCDR(cdrZip []byte) (*entidades.RespuestaSunat, error) {
+	zr, err := zip.NewReader(bytes.NewReader(cdrZip), int64(len(cdrZip)))
+	if err != nil {
+		return nil, errors.New("la constancia de recepción no es un ZIP válido")
+	}
+
+	for _, archivo := range zr.File {
+		if !strings.HasSuffix(strings.ToLower(archivo.Name), ".xml") {
+			continue
+		}
+		rc, err := archivo.Open()
+		if err != nil {
+			return nil, err
+		}
+		contenido, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		var cdr cdrXML
+		if err := xml.Unmarshal(contenido, &cdr); err != nil {
+			return nil, errors.New("la constancia de recepción no se pudo leer")
+		}
+		codigo, err := strconv.Atoi(strings.TrimSpace(cdr.Codigo))
+		if err != nil {
+			return nil, errors.New("la constancia de recepción no tiene código de respuesta")
+		}
+
+		respuesta := &entidades.RespuestaSunat{
+			Codigo:      strconv.Itoa(codigo),
+			Descripcion: strings.TrimSpace(cdr.Descripcion),
+			CDR:         cdrZip,
+		}
+		switch {
+		case codigo == 0 && len(cdr.Notas) == 0:
+			respuesta.Estado = "ACEPTADO"
+		case codigo == 0 || codigo >= 4000:
+			respuesta.Estado = "OBSERVADO"
+			if len(cdr.Notas) > 0 {
+				respuesta.Descripcion += " (" + strings.Join(cdr.Notas, "; ") + ")"
+			}
+		default:
+			respuesta.Estado = "RECHAZADO"
+		}
+		return respuesta, nil
+	}
+
+	return nil, errors.New("la constancia de recepción no contiene el XML de respuesta")
+}
+
+// EnviadorSunatStub simula la recepción de SUNAT: acepta todo comprobante y devuelve un CDR con código 0
+// Se usa en desarrollo y pruebas, sin credenciales ni conexión
+type EnviadorSunatStub struct {
+	RUC string
+}
+
+// Nombre identifica al enviador en el registro del comprobante
+func (e *EnviadorSunatStub) Nombre() string {
+	return "STUB"
+}
+
+// Enviar genera una constancia de recepción de aceptación con la misma estructura que la de SUNAT
+func (e *EnviadorSunatStub) Enviar(nombreArchivo string, xmlFirmado []byte) (*entidades.RespuestaSunat, error) {
+	if len(xmlFirmado) == 0 {
+		return nil, errors.New("el comprobante está vacío")
+	}
+
+	// El nombre es RUC-TIPO-SERIE-CORRELATIVO; la serie y el correlativo forman el número del documento
+	partes := strings.SplitN(nombreArchivo, "-", 3)
+	if len(partes) != 3 {
+		return nil, errors.New("nombre de archivo inválido")
+	}
+	numero := partes[2]
+	ahora := time.Now()
+
+	respuesta := utils.NuevoNodoXML("ar:ApplicationResponse",
+		"xmlns:ar", "urn:oasis:names:specification:ubl:schema:xsd:ApplicationResponse-2",
+		"xmlns:cac", namespaceCAC,
+		"xmlns:cbc", namespaceCBC,
+	)
+	respuesta.HijoTexto("cbc:UBLVersionID", "2.0")
+	respuesta.HijoTexto("cbc:CustomizationID", "1.0")
+	respuesta.HijoTexto("cbc:ID", strconv.FormatInt(ahora.UnixNano(), 10))
+	respuesta.HijoTexto("cbc:IssueDate", ahora.Format("2006-01-02"))
+	respuesta.HijoTexto("cbc:IssueTime", ahora.Format("15:04:05"))
+	respuesta.HijoTexto("cbc:ResponseDate", ahora.Format("2006-01-02"))
+	respuesta.HijoTexto("cbc:ResponseTime", ahora.Format("15:04:05"))
+	respuesta.Hijo("cac:SenderParty").Hijo("cac:PartyIdentification").HijoTexto("cbc:ID", "20131312955") // RUC de SUNAT
+	respuesta.Hijo("cac:ReceiverParty").Hijo("cac:PartyIdentification").HijoTexto("cbc:ID", partes[0])
+	documento := respuesta.Hijo("cac:DocumentResponse")
+	resultado := documento.Hijo("cac:Response")
+	resultado.HijoTexto("cbc:ReferenceID", numero)
+	resultado.HijoTexto("cbc:ResponseCode", "0")
+	resultado.HijoTexto("cbc:Description", fmt.Sprintf("El comprobante numero %s, ha sido aceptado", numero))
+	documento.Hijo("cac:DocumentReference").HijoTexto("cbc:ID", numero)
+
+	cdr, err := comprimirArchivo("R-"+nombreArchivo+".xml", utils.DocumentoXML(respuesta))
+	if err != nil {
+		return nil, err
+	}
+	return InterpretarCDR(cdr)
+}
+
+// EnviadorOSE envía los comprobantes al servicio sendBill de SUNAT o de un OSE homologado
+type EnviadorOSE struct {
+	URL     string
+	Usuario string // Para SUNAT es el RUC seguido del usuario SOL
+	Clave   string
+	cliente *http.Client
+}
+
+// NewEnviadorOSE crea un enviador contra el endpoint SOAP indicado
+func NewEnviadorOSE(url, usuario, clave string) *EnviadorOSE {
+	return &EnviadorOSE{
+		URL:     url,
+		Usuario: usuario,
+		Clave:   clave,
+		cliente: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Nombre identifica al enviador en el registro del comprobante
+func (e *EnviadorOSE) Nombre() string {
+	return "OSE"
+}
+
+// respuestaSendBill contiene el CDR o el error SOAP devuelto por sendBill
+type respuestaSendBill struct {
+	ApplicationResponse string `xml:"Body>sendBillResponse>applicationResponse"`
+	FaultCode           string `xml:"Body>Fault>faultcode"`
+	FaultString         string `xml:"Body>Fault>faultstring"`
+}
+
+// Enviar comprime el XML, lo envía por SOAP con autenticación WS-Security y lee la constancia de recepción
+// Los rechazos de validación (códigos 2000-3999) se devuelven como respuesta; las demás fallas son errores
+// para que el comprobante pueda reenviarse.
+func (e *EnviadorOSE) Enviar(nombreArchivo string, xmlFirmado []byte) (*entidades.RespuestaSunat, error) {
+	if e.URL == "" {
+		return nil, errors.New("no se configuró la URL del OSE")
+	}
+
+	contenido, err := comprimirArchivo(nombreArchivo+".xml", xmlFirmado)
+	if err != nil {
+		return nil, err
+	}
+
+	sobre := utils.NuevoNodoXML("soapenv:Envelope",
+		"xmlns:soapenv", "http://schemas.xmlsoap.org/soap/envelope/",
+		"xmlns:ser", "http://service.sunat.gob.pe",
+		"xmlns:wsse", "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd",
+	)
+	token := sobre.Hijo("soapenv:Header").Hijo("wsse:Security").Hijo("wsse:UsernameToken")
+	token.HijoTexto("wsse:Username", e.Usuario)
+	token.HijoTexto("wsse:Password", e.Clave)
+	envio := sobre.Hijo("soapenv:Body").Hijo("ser:sendBill")
+	envio.HijoTexto("fileName", nombreArchivo+".zip")
+	envio.HijoTexto("contentFile", base64.StdEncoding.EncodeToString(contenido))
+
+	req, err := http.NewRequest("POST", e.URL, bytes.NewReader(utils.DocumentoXML(sobre)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("SOAPAction", "urn:sendBill")
+
+	resp, err := e.cliente.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo conectar con el OSE: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var respuesta respuestaSendBill
+	if err := xml.Unmarshal(body, &respuesta); err != nil {
+		return nil, fmt.Errorf("respuesta inválida del OSE (HTTP %d)", resp.StatusCode)
+	}
+
+	if respuesta.FaultCode != "" {
+		// El código de error va al final del faultcode, por ejemplo soap-env:Client.2335
+		codigoTexto := respuesta.FaultCode[strings.LastIndexAny(respuesta.FaultCode, ".:")+1:]
+		codigo, _ := strconv.Atoi(codigoTexto)
+		if codigo >= 2000 && codigo < 4000 {
+			return &entidades.RespuestaSunat{
+				Estado:      "RECHAZADO",
+				Codigo:      strconv.Itoa(codigo),
+				Descripcion: respuesta.FaultString,
+			}, nil
+		}
+		return nil, fmt.Errorf("error del OSE %s: %s", codigoTexto, respuesta.FaultString)
+	}
+
+	cdr, err := base64.StdEncoding.DecodeString(strings.TrimSpace(respuesta.ApplicationResponse))
+	if err != nil || len(cdr) == 0 {
+		return nil, errors.New("el OSE no devolvió la constancia de recepción")
+	}
+	return InterpretarCDR(cdr)
+}
@@ -0,0 +1,232 @@
+package servicios
+
+import (
+	"fmt"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"strconv"
+)
+
+// Namespaces de los documentos UBL 2.1 que recibe SUNAT
+const (
+	namespaceInvoice    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	namespaceCreditNote = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+	namespaceDebitNote  = "urn:oasis:names:specification:ubl:schema:xsd:DebitNote-2"
+	namespaceCAC        = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	namespaceCBC        = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+	namespaceEXT        = "urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2"
+)
+
+// Códigos de catálogos SUNAT
+const (
+	TipoDocumentoFactura     = "01"
+	TipoDocumentoBoleta      = "03"
+	TipoDocumentoNotaCredito = "07"
+	TipoDocumentoNotaDebito  = "08"
+)
+
+// estructuraUBL reúne los nombres de elementos que cambian entre factura o boleta, nota de crédito y nota de débito
+type estructuraUBL struct {
+	raiz      string
+	namespace string
+	linea     string
+	cantidad  string
+	totales   string
+}
+
+// estructuraDocumento devuelve la estructura UBL que corresponde al tipo de documento
+func estructuraDocumento(tipoDocumento string) estructuraUBL {
+	switch tipoDocumento {
+	case TipoDocumentoNotaCredito:
+		return estructuraUBL{"CreditNote", namespaceCreditNote, "cac:CreditNoteLine", "cbc:CreditedQuantity", "cac:LegalMonetaryTotal"}
+	case TipoDocumentoNotaDebito:
+		return estructuraUBL{"DebitNote", namespaceDebitNote, "cac:DebitNoteLine", "cbc:DebitedQuantity", "cac:RequestedMonetaryTotal"}
+	}
+	return estructuraUBL{"Invoice", namespaceInvoice, "cac:InvoiceLine", "cbc:InvoicedQuantity", "cac:LegalMonetaryTotal"}
+}
+
+// codigoDocumentoIdentidad traduce el tipo de documento del cliente al catálogo 06 de SUNAT
+func codigoDocumentoIdentidad(tipoDocumento string) string {
+	switch tipoDocumento {
+	case "RUC":
+		return "6"
+	case "DNI":
+		return "1"
+	case "CE":
+		return "4"
+	case "Pasaporte":
+		return "7"
+	}
+	return "0" // Sin documento
+}
+
+// NombreArchivoSunat arma el nombre con el que se envía y se guarda el comprobante: RUC-TIPO-SERIE-CORRELATIVO
+func NombreArchivoSunat(ruc string, tipoDocumento string, serie string, correlativo int) string {
+	return fmt.Sprintf("%s-%s-%s-%d", ruc, tipoDocumento, serie, correlativo)
+}
+
+// montoUBL formatea un importe con dos decimales
+func montoUBL(monto entidades.Dinero) string {
+	return monto.String()
+}
+
+// agregarMonto agrega un elemento de importe con su moneda
+func agregarMonto(padre *utils.NodoXML, nombre string, monto entidades.Dinero, moneda string) {
+	padre.HijoTexto(nombre, montoUBL(monto), "currencyID", moneda)
+}
+
+// tributosAfectacion son el código y nombre del tributo (catálogo 05) según la afectación del IGV
+var tributosAfectacion = map[string][2]string{
+	"GRAVADO":   {"1000", "IGV"},
+	"EXONERADO": {"9997", "EXO"},
+	"INAFECTO":  {"9998", "INA"},
+}
+
+// agregarImpuesto agrega un cac:TaxSubtotal con el tributo que corresponde a la afectación del IGV
+func agregarImpuesto(padre *utils.NodoXML, base entidades.Dinero, igv entidades.Dinero, porcentaje float64, afectacion string, moneda string, conCategoria bool) {
+	subtotal := padre.Hijo("cac:TaxSubtotal")
+	agregarMonto(subtotal, "cbc:TaxableAmount", base, moneda)
+	agregarMonto(subtotal, "cbc:TaxAmount", igv, moneda)
+
+	categoria := subtotal.Hijo("cac:TaxCategory")
+	tributo, ok := tributosAfectacion[afectacion]
+	if !ok {
+		afectacion, tributo = "GRAVADO", tributosAfectacion["GRAVADO"]
+	}
+	codigoTributo, nombreTributo := tributo[0], tributo[1]
+	if afectacion != "GRAVADO" {
+		porcentaje = 0
+	}
+	if conCategoria {
+		categoria.HijoTexto("cbc:Percent", strconv.FormatFloat(porcentaje, 'f', -1, 64))
+		categoria.HijoTexto("cbc:TaxExemptionReasonCode", codigosAfectacionIGV[afectacion]) // Operación onerosa
+	}
+	esquema := categoria.Hijo("cac:TaxScheme")
+	esquema.HijoTexto("cbc:ID", codigoTributo)
+	esquema.HijoTexto("cbc:Name", nombreTributo)
+	esquema.HijoTexto("cbc:TaxTypeCode", "VAT")
+}
+
+// GenerarXMLComprobante arma el árbol UBL 2.1 de una factura, boleta o nota según las especificaciones de SUNAT
+// Devuelve la raíz y el ext:ExtensionContent vacío donde va la firma
+func GenerarXMLComprobante(doc *entidades.DocumentoElectronico) (*utils.NodoXML, *utils.NodoXML) {
+	estructura := estructuraDocumento(doc.TipoDocumento)
+	esNota := estructura.raiz != "Invoice"
+
+	raiz := utils.NuevoNodoXML(estructura.raiz,
+		"xmlns", estructura.namespace,
+		"xmlns:cac", namespaceCAC,
+		"xmlns:cbc", namespaceCBC,
+		"xmlns:ds", utils.NamespaceXMLDSig,
+		"xmlns:ext", namespaceEXT,
+	)
+	contenedorFirma := raiz.Hijo("ext:UBLExtensions").Hijo("ext:UBLExtension").Hijo("ext:ExtensionContent")
+
+	raiz.HijoTexto("cbc:UBLVersionID", "2.1")
+	raiz.HijoTexto("cbc:CustomizationID", "2.0")
+	raiz.HijoTexto("cbc:ID", fmt.Sprintf("%s-%d", doc.Serie, doc.Correlativo))
+	raiz.HijoTexto("cbc:IssueDate", doc.FechaEmision.Format("2006-01-02"))
+	raiz.HijoTexto("cbc:IssueTime", doc.FechaEmision.Format("15:04:05"))
+	if !esNota {
+		raiz.HijoTexto("cbc:InvoiceTypeCode", doc.TipoDocumento,
+			"listAgencyName", "PE:SUNAT",
+			"listID", "0101", // Venta interna
+			"listName", "Tipo de Documento",
+			"listURI", "urn:pe:gob:sunat:cpe:see:gem:catalogos:catalogo01",
+		)
+	}
+	raiz.HijoTexto("cbc:Note", utils.MontoEnLetras(doc.Total.Float64(), doc.Moneda), "languageLocaleID", "1000")
+	raiz.HijoTexto("cbc:DocumentCurrencyCode", doc.Moneda)
+
+	// Las notas indican el motivo y el comprobante que modifican
+	if esNota {
+		discrepancia := raiz.Hijo("cac:DiscrepancyResponse")
+		discrepancia.HijoTexto("cbc:ReferenceID", doc.NumeroReferencia)
+		discrepancia.HijoTexto("cbc:ResponseCode", doc.CodigoMotivo)
+		discrepancia.HijoTexto("cbc:Description", doc.Motivo)
+
+		referencia := raiz.Hijo("cac:BillingReference").Hijo("cac:InvoiceDocumentReference")
+		referencia.HijoTexto("cbc:ID", doc.NumeroReferencia)
+		referencia.HijoTexto("cbc:DocumentTypeCode", doc.TipoDocumentoReferencia)
+	}
+
+	// Referencia a la firma digital
+	idFirma := "SIGN" + doc.Emisor.RUC
+	firma := raiz.Hijo("cac:Signature")
+	firma.HijoTexto("cbc:ID", idFirma)
+	firmante := firma.Hijo("cac:SignatoryParty")
+	firmante.Hijo("cac:PartyIdentification").HijoTexto("cbc:ID", doc.Emisor.RUC)
+	firmante.Hijo("cac:PartyName").HijoTexto("cbc:Name", doc.Emisor.RazonSocial)
+	firma.Hijo("cac:DigitalSignatureAttachment").Hijo("cac:ExternalReference").HijoTexto("cbc:URI", "#"+idFirma)
+
+	// Emisor
+	emisor := raiz.Hijo("cac:AccountingSupplierParty").Hijo("cac:Party")
+	emisor.Hijo("cac:PartyIdentification").HijoTexto("cbc:ID", doc.Emisor.RUC, "schemeID", "6")
+	emisor.Hijo("cac:PartyName").HijoTexto("cbc:Name", doc.Emisor.NombreComercial)
+	entidadLegal := emisor.Hijo("cac:PartyLegalEntity")
+	entidadLegal.HijoTexto("cbc:RegistrationName", doc.Emisor.RazonSocial)
+	domicilio := entidadLegal.Hijo("cac:RegistrationAddress")
+	domicilio.HijoTexto("cbc:ID", doc.Emisor.Ubigeo)
+	domicilio.HijoTexto("cbc:AddressTypeCode", "0000")
+	domicilio.Hijo("cac:AddressLine").HijoTexto("cbc:Line", doc.Emisor.Direccion)
+
+	// Adquiriente
+	cliente := raiz.Hijo("cac:AccountingCustomerParty").Hijo("cac:Party")
+	cliente.Hijo("cac:PartyIdentification").HijoTexto("cbc:ID", doc.Cliente.NumeroDocumento, "schemeID", doc.Cliente.TipoDocumento)
+	entidadCliente := cliente.Hijo("cac:PartyLegalEntity")
+	entidadCliente.HijoTexto("cbc:RegistrationName", doc.Cliente.Nombre)
+	if doc.Cliente.Direccion != "" {
+		entidadCliente.Hijo("cac:RegistrationAddress").Hijo("cac:AddressLine").HijoTexto("cbc:Line", doc.Cliente.Direccion)
+	}
+
+	// Forma de pago, obligatoria en facturas
+	if doc.TipoDocumento == TipoDocumentoFactura {
+		formaPago := raiz.Hijo("cac:PaymentTerms")
+		formaPago.HijoTexto("cbc:ID", "FormaPago")
+		formaPago.HijoTexto("cbc:PaymentMeansID", "Contado")
+	}
+
+	// Totales de impuestos y montos
+	impuestos := raiz.Hijo("cac:TaxTotal")
+	agregarMonto(impuestos, "cbc:TaxAmount", doc.IGV, doc.Moneda)
+	agregarImpuesto(impuestos, doc.Subtotal, doc.IGV, doc.PorcentajeIGV, doc.Afectacion, doc.Moneda, false)
+
+	totales := raiz.Hijo(estructura.totales)
+	agregarMonto(totales, "cbc:LineExtensionAmount", doc.Subtotal, doc.Moneda)
+	agregarMonto(totales, "cbc:TaxInclusiveAmount", doc.Total, doc.Moneda)
+	agregarMonto(totales, "cbc:PayableAmount", doc.Total, doc.Moneda)
+
+	// Ítems
+	for i, linea := range doc.Lineas {
+		item := raiz.Hijo(estructura.linea)
+		item.HijoTexto("cbc:ID", strconv.Itoa(i+1))
+		item.HijoTexto(estructura.cantidad, strconv.Itoa(linea.Cantidad), "unitCode", "ZZ") // Servicio
+		agregarMonto(item, "cbc:LineExtensionAmount", linea.ValorVenta, doc.Moneda)
+
+		precioReferencia := item.Hijo("cac:PricingReference").Hijo("cac:AlternativeConditionPrice")
+		agregarMonto(precioReferencia, "cbc:PriceAmount", linea.Total.Prorratear(1, int64(linea.Cantidad)), doc.Moneda)
+		precioReferencia.HijoTexto("cbc:PriceTypeCode", "01") // Precio unitario con IGV
+
+		impuestosLinea := item.Hijo("cac:TaxTotal")
+		agregarMonto(impuestosLinea, "cbc:TaxAmount", linea.IGV, doc.Moneda)
+		agregarImpuesto(impuestosLinea, linea.ValorVenta, linea.IGV, doc.PorcentajeIGV, doc.Afectacion, doc.Moneda, true)
+
+		item.Hijo("cac:Item").HijoTexto("cbc:Description", linea.Descripcion)
+		agregarMonto(item.Hijo("cac:Price"), "cbc:PriceAmount", linea.ValorVenta.Prorratear(1, int64(linea.Cantidad)), doc.Moneda)
+	}
+
+	return raiz, contenedorFirma
+}
+
+// FirmarDocumentoElectronico genera el XML UBL del comprobante y lo firma
+// Devuelve el XML listo para enviar y el hash (DigestValue) que se imprime en la representación impresa
+func FirmarDocumentoElectronico(doc *entidades.DocumentoElectronico, firmante *utils.FirmanteXML) ([]byte, string, error) {
+	raiz, contenedorFirma := GenerarXMLComprobante(doc)
+
+	hash, err := firmante.FirmarEnvuelto(raiz, contenedorFirma, "SIGN"+doc.Emisor.RUC)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return utils.DocumentoXML(raiz), hash, nil
+}
@@ -0,0 +1,217 @@
+package servicios
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TipoCambioService maneja la lógica de negocio para tipos de cambio y la conversión a soles
+type TipoCambioService struct {
+	tipoCambioRepo *repositorios.TipoCambioRepository
+}
+
+// NewTipoCambioService crea una nueva instancia de TipoCambioService
+func NewTipoCambioService(tipoCambioRepo *repositorios.TipoCambioRepository) *TipoCambioService {
+	return &TipoCambioService{
+		tipoCambioRepo: tipoCambioRepo,
+	}
+}
+
+// Registrar guarda manualmente el tipo de cambio de un día
+func (s *TipoCambioService) Registrar(tipoCambio *entidades.NuevoTipoCambioRequest) (int, error) {
+	tipoCambio.Origen = "MANUAL"
+	return s.tipoCambioRepo.Registrar(tipoCambio)
+}
+
+// Importar registra los tipos de cambio de un archivo CSV con las columnas fecha, moneda y valor
+// Las líneas inválidas se informan sin detener la importación del resto
+func (s *TipoCambioService) Importar(archivo io.Reader) (*entidades.ResultadoImportacionTipoCambio, error) {
+	tiposCambio, errores := ParsearTiposCambioCSV(archivo)
+	if len(tiposCambio) == 0 && len(errores) == 0 {
+		return nil, errors.New("el archivo no contiene tipos de cambio")
+	}
+
+	resultado := &entidades.ResultadoImportacionTipoCambio{Errores: errores}
+	for _, tipoCambio := range tiposCambio {
+		tipoCambio.Origen = "ARCHIVO"
+		if _, err := s.tipoCambioRepo.Registrar(tipoCambio); err != nil {
+			resultado.Errores = append(resultado.Errores, fmt.Sprintf("%s %s: %v", tipoCambio.Fecha.Format("2006-01-02"), tipoCambio.Moneda, err))
+			continue
+		}
+		resultado.Registrados++
+	}
+
+	return resultado, nil
+}
+
+// GetByID obtiene un tipo de cambio por su ID
+func (s *TipoCambioService) GetByID(id int) (*entidades.TipoCambio, error) {
+	return s.tipoCambioRepo.GetByID(id)
+}
+
+// Delete elimina un tipo de cambio
+func (s *TipoCambioService) Delete(id int) error {
+	return s.tipoCambioRepo.Delete(id)
+}
+
+// List lista los tipos de cambio de una moneda entre dos fechas
+func (s *TipoCambioService) List(moneda string, fechaInicio time.Time, fechaFin time.Time) ([]*entidades.TipoCambio, error) {
+	if fechaFin.Before(fechaInicio) {
+		return nil, errors.New("la fecha de fin debe ser posterior a la fecha de inicio")
+	}
+	return s.tipoCambioRepo.List(moneda, fechaInicio, fechaFin)
+}
+
+// GetVigente obtiene el tipo de cambio de una moneda aplicable en una fecha: el último registrado hasta ese día
+func (s *TipoCambioService) GetVigente(moneda string, fecha time.Time) (*entidades.TipoCambio, error) {
+	tipoCambio, err := s.tipoCambioRepo.GetVigente(moneda, fecha)
+	if err == repositorios.ErrSinTipoCambio {
+		return nil, fmt.Errorf("no hay tipo de cambio registrado para %s al %s", moneda, fecha.Format("2006-01-02"))
+	}
+	return tipoCambio, err
+}
+
+// TipoCambioVenta obtiene el tipo de cambio del día para convertir a soles los precios de una venta
+// Devuelve nil si todos los precios están en soles
+func (s *TipoCambioService) TipoCambioVenta(monedas ...string) (*entidades.TipoCambio, error) {
+	for _, moneda := range monedas {
+		if moneda != "" && moneda != entidades.MonedaPorDefecto {
+			return s.GetVigente(moneda, time.Now())
+		}
+	}
+	return nil, nil
+}
+
+// Convertir expresa en soles un monto con el tipo de cambio vigente en la fecha indicada
+func (s *TipoCambioService) Convertir(monto entidades.Dinero, fecha time.Time) (entidades.ConversionMoneda, error) {
+	if monto.Moneda() == entidades.MonedaPorDefecto {
+		return entidades.ConversionMoneda{Monto: monto, TipoCambio: 1, MontoBase: monto}, nil
+	}
+
+	tipoCambio, err := s.GetVigente(monto.Moneda(), fecha)
+	if err != nil {
+		return entidades.ConversionMoneda{}, err
+	}
+
+	return entidades.ConversionMoneda{Monto: monto, TipoCambio: tipoCambio.Valor, MontoBase: tipoCambio.AMonedaBase(monto)}, nil
+}
+
+// ConvertirConTipoCambio expresa en soles un monto con el tipo de cambio indicado, si lo hay, o con el vigente
+// en la fecha indicada
+func (s *TipoCambioService) ConvertirConTipoCambio(monto entidades.Dinero, valor *float64, fecha time.Time) (entidades.ConversionMoneda, error) {
+	if monto.Moneda() == entidades.MonedaPorDefecto || valor == nil {
+		return s.Convertir(monto, fecha)
+	}
+
+	tipoCambio := &entidades.TipoCambio{Moneda: monto.Moneda(), Valor: *valor}
+	return entidades.ConversionMoneda{Monto: monto, TipoCambio: tipoCambio.Valor, MontoBase: tipoCambio.AMonedaBase(monto)}, nil
+}
+
+// ReporteIngresosMoneda consolida los ingresos de un periodo por moneda y su total en soles
+func (s *TipoCambioService) ReporteIngresosMoneda(fechaInicio time.Time, fechaFin time.Time, idSede *int) (*entidades.ReporteIngresosMoneda, error) {
+	if fechaFin.Before(fechaInicio) {
+		return nil, errors.New("la fecha de fin debe ser posterior a la fecha de inicio")
+	}
+
+	ingresos, err := s.tipoCambioRepo.ReporteIngresosMoneda(fechaInicio, fechaFin, idSede)
+	if err != nil {
+		return nil, err
+	}
+
+	reporte := &entidades.ReporteIngresosMoneda{
+		FechaInicio:      fechaInicio,
+		FechaFin:         fechaFin,
+		IDSede:           idSede,
+		Monedas:          ingresos,
+		TotalConsolidado: entidades.Soles(0),
+	}
+	for _, ingreso := range ingresos {
+		reporte.TotalConsolidado = reporte.TotalConsolidado.Sumar(ingreso.NetoConvertido)
+	}
+
+	return reporte, nil
+}
+
+// ConvertirAMonedaBase expresa en soles un precio con el tipo de cambio de la venta
+// Los precios en soles no necesitan tipo de cambio
+func ConvertirAMonedaBase(monto entidades.Dinero, tipoCambio *entidades.TipoCambio) (entidades.Dinero, error) {
+	if monto.Moneda() == entidades.MonedaPorDefecto {
+		return monto, nil
+	}
+	if tipoCambio == nil || tipoCambio.Moneda != monto.Moneda() {
+		return entidades.Dinero{}, fmt.Errorf("no hay tipo de cambio para convertir precios en %s", monto.Moneda())
+	}
+	return tipoCambio.AMonedaBase(monto), nil
+}
+
+// ParsearTiposCambioCSV lee un archivo CSV con las columnas fecha, moneda y valor (soles por unidad)
+// La fecha se acepta como AAAA-MM-DD o DD/MM/AAAA y la primera línea puede ser un encabezado.
+// Devuelve los tipos de cambio válidos y un mensaje por cada línea inválida
+func ParsearTiposCambioCSV(archivo io.Reader) ([]*entidades.NuevoTipoCambioRequest, []string) {
+	lector := csv.NewReader(archivo)
+	lector.FieldsPerRecord = -1
+	lector.TrimLeadingSpace = true
+
+	tiposCambio := []*entidades.NuevoTipoCambioRequest{}
+	errores := []string{}
+
+	for primera := true; ; primera = false {
+		campos, err := lector.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errores = append(errores, err.Error())
+			break
+		}
+		linea, _ := lector.FieldPos(0)
+		if len(campos) == 1 && strings.TrimSpace(campos[0]) == "" {
+			continue
+		}
+		if len(campos) != 3 {
+			errores = append(errores, fmt.Sprintf("línea %d: se esperaban las columnas fecha, moneda y valor", linea))
+			continue
+		}
+
+		fecha, err := parsearFechaTipoCambio(strings.TrimSpace(campos[0]))
+		if err != nil {
+			if primera {
+				// Encabezado
+				continue
+			}
+			errores = append(errores, fmt.Sprintf("línea %d: fecha inválida %q", linea, campos[0]))
+			continue
+		}
+
+		moneda := strings.ToUpper(strings.TrimSpace(campos[1]))
+		if moneda != entidades.MonedaDolares {
+			errores = append(errores, fmt.Sprintf("línea %d: moneda no soportada %q", linea, campos[1]))
+			continue
+		}
+
+		valor, err := strconv.ParseFloat(strings.TrimSpace(campos[2]), 64)
+		if err != nil || valor <= 0 || valor > 1000 {
+			errores = append(errores, fmt.Sprintf("línea %d: valor inválido %q", linea, campos[2]))
+			continue
+		}
+
+		tiposCambio = append(tiposCambio, &entidades.NuevoTipoCambioRequest{Fecha: fecha, Moneda: moneda, Valor: valor})
+	}
+
+	return tiposCambio, errores
+}
+
+// parsearFechaTipoCambio interpreta las fechas de un archivo de tipos de cambio
+func parsearFechaTipoCambio(texto string) (time.Time, error) {
+	if fecha, err := time.Parse("2006-01-02", texto); err == nil {
+		return fecha, nil
+	}
+	return time.Parse("02/01/2006", texto)
+}
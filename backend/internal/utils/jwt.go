@@ -140,6 +140,9 @@ func ValidateRefreshToken(tokenString string, config *config.Config) (*TokenClai
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"sistema-toursseft/internal/config"
@@ -315,3 +318,98 @@ func GetRefreshTokenClaims(tokenString string, config *config.Config) (*TokenCla
 
 	return nil, errors.New("no se pudieron extraer los claims del token")
 }
+
+// TipoTokenEmbarque identifica los tokens de pase de abordar
+const TipoTokenEmbarque = "EMBARQUE"
+
+// BoardingPassClaims define los claims del pase de abordar de una reserva
+type BoardingPassClaims struct {
+	IDReserva   int    `json:"id_reserva"`
+	IDInstancia int    `json:"id_instancia"`
+	Tipo        string `json:"tipo"`
+	jwt.RegisteredClaims
+}
+
+// boardingPassKey deriva la llave de los pases de abordar para que no sirvan como token de sesión
+func boardingPassKey(config *config.Config) []byte {
+	return []byte(config.JWTSecret + ":" + TipoTokenEmbarque)
+}
+
+// GenerateBoardingPassToken genera el token firmado que se codifica en el QR del pase de abordar
+// No incluye fecha de emisión: el mismo pase genera siempre el mismo token, lo que permite validarlo
+// sin conexión comparando su huella con la del paquete descargado por el chofer
+func GenerateBoardingPassToken(idReserva int, idInstancia int, expira time.Time, config *config.Config) (string, error) {
+	claims := BoardingPassClaims{
+		IDReserva:   idReserva,
+		IDInstancia: idInstancia,
+		Tipo:        TipoTokenEmbarque,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expira),
+			Issuer:    "sistema-tours",
+			Subject:   fmt.Sprintf("%d", idReserva),
+		},
+	}
+
+	// Crear y firmar token con la llave de embarque
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(boardingPassKey(config))
+}
+
+// ValidateBoardingPassToken valida la firma y vigencia de un pase de abordar
+func ValidateBoardingPassToken(tokenString string, config *config.Config) (*BoardingPassClaims, error) {
+	return ValidateBoardingPassTokenAt(tokenString, time.Now(), config)
+}
+
+// ValidateBoardingPassTokenAt valida la firma de un pase de abordar y que estuviera vigente en el momento indicado
+// Permite conciliar escaneos hechos sin conexión que se sincronizan después del vencimiento
+func ValidateBoardingPassTokenAt(tokenString string, momento time.Time, config *config.Config) (*BoardingPassClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &BoardingPassClaims{}, func(token *jwt.Token) (interface{}, error) {
+		// Validar algoritmo de firma
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("método de firma inesperado: %v", token.Header["alg"])
+		}
+
+		return boardingPassKey(config), nil
+	}, jwt.WithoutClaimsValidation())
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Verificar si el token es válido
+	if !token.Valid {
+		return nil, errors.New("pase de abordar inválido")
+	}
+
+	// Obtener claims
+	claims, ok := token.Claims.(*BoardingPassClaims)
+	if !ok || claims.Tipo != TipoTokenEmbarque {
+		return nil, errors.New("el token no es un pase de abordar")
+	}
+
+	// Verificar vigencia en el momento del escaneo
+	if !claims.VerifyExpiresAt(momento, true) {
+		return nil, errors.New("pase de abordar vencido")
+	}
+
+	return claims, nil
+}
+
+// HuellaBoardingPass calcula la huella SHA-256 de un token de pase de abordar
+// Es lo que se distribuye en los paquetes sin conexión en lugar del token completo
+func HuellaBoardingPass(tokenString string) string {
+	suma := sha256.Sum256([]byte(tokenString))
+	return hex.EncodeToString(suma[:])
+}
+
+// SignOfflineBundle firma el contenido de un paquete sin conexión con HMAC-SHA256
+func SignOfflineBundle(contenido []byte, config *config.Config) string {
+	mac := hmac.New(sha256.New, []byte(config.JWTSecret+":PAQUETE_OFFLINE"))
+	mac.Write(contenido)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyOfflineBundle verifica la firma de un paquete sin conexión
+func VerifyOfflineBundle(contenido []byte, firma string, config *config.Config) bool {
+	return hmac.Equal([]byte(SignOfflineBundle(contenido, config)), []byte(firma))
+}
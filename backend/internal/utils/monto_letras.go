@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+var unidadesLetras = []string{"", "UNO", "DOS", "TRES", "CUATRO", "CINCO", "SEIS", "SIETE", "OCHO", "NUEVE",
+	"DIEZ", "ONCE", "DOCE", "TRECE", "CATORCE", "QUINCE", "DIECISEIS", "DIECISIETE", "DIECIOCHO", "DIECINUEVE",
+	"VEINTE", "VEINTIUNO", "VEINTIDOS", "VEINTITRES", "VEINTICUATRO", "VEINTICINCO", "VEINTISEIS", "VEINTISIETE",
+	"VEINTIOCHO", "VEINTINUEVE"}
+
+var decenasLetras = []string{"", "", "", "TREINTA", "CUARENTA", "CINCUENTA", "SESENTA", "SETENTA", "OCHENTA", "NOVENTA"}
+
+var centenasLetras = []string{"", "CIENTO", "DOSCIENTOS", "TRESCIENTOS", "CUATROCIENTOS", "QUINIENTOS", "SEISCIENTOS",
+	"SETECIENTOS", "OCHOCIENTOS", "NOVECIENTOS"}
+
+// centenasEnLetras convierte un número de 0 a 999
+func centenasEnLetras(n int) string {
+	if n == 100 {
+		return "CIEN"
+	}
+
+	var partes []string
+	if n >= 100 {
+		partes = append(partes, centenasLetras[n/100])
+		n %= 100
+	}
+	if n >= 30 {
+		decena := decenasLetras[n/10]
+		if n%10 > 0 {
+			decena += " Y " + unidadesLetras[n%10]
+		}
+		partes = append(partes, decena)
+	} else if n > 0 {
+		partes = append(partes, unidadesLetras[n])
+	}
+	return strings.Join(partes, " ")
+}
+
+// EnteroEnLetras convierte un entero no negativo a su expresión en letras en mayúsculas
+func EnteroEnLetras(n int64) string {
+	if n == 0 {
+		return "CERO"
+	}
+
+	var partes []string
+	millones := n / 1000000
+	miles := (n / 1000) % 1000
+	resto := int(n % 1000)
+
+	if millones > 0 {
+		if millones == 1 {
+			partes = append(partes, "UN MILLON")
+		} else {
+			partes = append(partes, apocopar(EnteroEnLetras(millones))+" MILLONES")
+		}
+	}
+	if miles > 0 {
+		if miles == 1 {
+			partes = append(partes, "MIL")
+		} else {
+			partes = append(partes, apocopar(centenasEnLetras(int(miles)))+" MIL")
+		}
+	}
+	if resto > 0 {
+		partes = append(partes, centenasEnLetras(resto))
+	}
+	return strings.Join(partes, " ")
+}
+
+// apocopar usa "UN" y "VEINTIUN" delante de MIL y MILLONES
+func apocopar(texto string) string {
+	if strings.HasSuffix(texto, "VEINTIUNO") {
+		return strings.TrimSuffix(texto, "VEINTIUNO") + "VEINTIUN"
+	}
+	if strings.HasSuffix(texto, "UNO") {
+		return strings.TrimSuffix(texto, "UNO") + "UN"
+	}
+	return texto
+}
+
+// MontoEnLetras expresa un importe como lo exige la leyenda 1000 de SUNAT: "CIENTO DIECIOCHO CON 50/100 SOLES"
+func MontoEnLetras(monto float64, moneda string) string {
+	centimos := int64(math.Round(math.Abs(monto) * 100))
+	nombreMoneda := "SOLES"
+	if moneda == "USD" {
+		nombreMoneda = "DOLARES AMERICANOS"
+	}
+	return fmt.Sprintf("%s CON %02d/100 %s", EnteroEnLetras(centimos/100), centimos%100, nombreMoneda)
+}
@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// Medidas de una página A4 en puntos PDF
+const (
+	AnchoPaginaA4 = 595.0
+	AltoPaginaA4  = 842.0
+)
+
+// DocumentoPDF genera documentos PDF simples (texto y líneas) sin dependencias externas
+// Usa las fuentes estándar Helvetica y Helvetica-Bold con codificación WinAnsi,
+// suficiente para textos en español
+type DocumentoPDF struct {
+	paginas  []*bytes.Buffer
+	actual   *bytes.Buffer
+	imagenes []imagenPDF
+}
+
+// imagenPDF es una imagen RGB comprimida, lista para incluirse como XObject
+type imagenPDF struct {
+	ancho, alto int
+	datos       []byte
+}
+
+// NuevoDocumentoPDF crea un documento vacío con una primera página
+func NuevoDocumentoPDF() *DocumentoPDF {
+	doc := &DocumentoPDF{}
+	doc.AgregarPagina()
+	return doc
+}
+
+// AgregarPagina inicia una nueva página A4; los siguientes trazos se dibujan en ella
+func (d *DocumentoPDF) AgregarPagina() {
+	d.actual = &bytes.Buffer{}
+	d.paginas = append(d.paginas, d.actual)
+}
+
+// Texto escribe una línea de texto con su línea base en (x, y), medidos desde la esquina superior izquierda
+func (d *DocumentoPDF) Texto(x, y, tamano float64, negrita bool, texto string) {
+	fuente := "F1"
+	if negrita {
+		fuente = "F2"
+	}
+	fmt.Fprintf(d.actual, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+		fuente, tamano, x, AltoPaginaA4-y, escaparTextoPDF(texto))
+}
+
+// Linea dibuja una línea recta entre dos puntos, medidos desde la esquina superior izquierda
+func (d *DocumentoPDF) Linea(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.actual, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, AltoPaginaA4-y1, x2, AltoPaginaA4-y2)
+}
+
+// Rectangulo dibuja un rectángulo relleno de negro con su esquina superior izquierda en (x, y)
+func (d *DocumentoPDF) Rectangulo(x, y, ancho, alto float64) {
+	fmt.Fprintf(d.actual, "%.2f %.2f %.2f %.2f re f\n", x, AltoPaginaA4-y-alto, ancho, alto)
+}
+
+// Marco dibuja el contorno de un rectángulo con su esquina superior izquierda en (x, y)
+func (d *DocumentoPDF) Marco(x, y, ancho, alto float64) {
+	fmt.Fprintf(d.actual, "0.5 w %.2f %.2f %.2f %.2f re S\n", x, AltoPaginaA4-y-alto, ancho, alto)
+}
+
+// QR dibuja un código QR como cuadrado de lado tamano, incluida la zona de silencio de 4 módulos
+func (d *DocumentoPDF) QR(x, y, tamano float64, qr *CodigoQR) {
+	modulo := tamano / float64(qr.Tamano+8)
+	for fila := 0; fila < qr.Tamano; fila++ {
+		for columna := 0; columna < qr.Tamano; columna++ {
+			if qr.Modulos[fila][columna] {
+				d.Rectangulo(x+float64(columna+4)*modulo, y+float64(fila+4)*modulo, modulo, modulo)
+			}
+		}
+	}
+}
+
+// Imagen dibuja una imagen escalada al rectángulo indicado; la transparencia se reemplaza por fondo blanco
+func (d *DocumentoPDF) Imagen(x, y, ancho, alto float64, img image.Image) error {
+	limites := img.Bounds()
+	rgb := make([]byte, 0, limites.Dx()*limites.Dy()*3)
+	for py := limites.Min.Y; py < limites.Max.Y; py++ {
+		for px := limites.Min.X; px < limites.Max.X; px++ {
+			r, g, b, a := img.At(px, py).RGBA()
+			// Componer sobre blanco: los valores vienen premultiplicados por alfa
+			fondo := 0xffff - a
+			rgb = append(rgb, byte((r+fondo)>>8), byte((g+fondo)>>8), byte((b+fondo)>>8))
+		}
+	}
+
+	var comprimido bytes.Buffer
+	zw := zlib.NewWriter(&comprimido)
+	if _, err := zw.Write(rgb); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	d.imagenes = append(d.imagenes, imagenPDF{ancho: limites.Dx(), alto: limites.Dy(), datos: comprimido.Bytes()})
+	fmt.Fprintf(d.actual, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", ancho, alto, x, AltoPaginaA4-y-alto, len(d.imagenes))
+	return nil
+}
+
+// anchosHelvetica son los anchos de los caracteres ASCII 32 a 126 de Helvetica, en milésimas del tamaño de fuente
+var anchosHelvetica = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// AnchoTexto calcula el ancho aproximado de un texto en puntos; la negrita se estima un 5% más ancha
+func AnchoTexto(tamano float64, negrita bool, texto string) float64 {
+	total := 0
+	for _, r := range texto {
+		if r >= 32 && r <= 126 {
+			total += anchosHelvetica[r-32]
+		} else {
+			total += 556
+		}
+	}
+	ancho := float64(total) * tamano / 1000
+	if negrita {
+		ancho *= 1.05
+	}
+	return ancho
+}
+
+// TextoDerecha escribe un texto alineado a la derecha, terminando en x
+func (d *DocumentoPDF) TextoDerecha(x, y, tamano float64, negrita bool, texto string) {
+	d.Texto(x-AnchoTexto(tamano, negrita, texto), y, tamano, negrita, texto)
+}
+
+// Bytes serializa el documento completo en formato PDF 1.4
+func (d *DocumentoPDF) Bytes() []byte {
+	var salida bytes.Buffer
+	offsets := []int{}
+
+	// escribirObjeto agrega un objeto numerado y registra su posición para la tabla xref
+	escribirObjeto := func(contenido string) {
+		offsets = append(offsets, salida.Len())
+		fmt.Fprintf(&salida, "%d 0 obj\n%s\nendobj\n", len(offsets), contenido)
+	}
+
+	salida.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objetos fijos: 1 catálogo, 2 árbol de páginas, 3 y 4 fuentes
+	kids := make([]string, len(d.paginas))
+	for i := range d.paginas {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	escribirObjeto("<< /Type /Catalog /Pages 2 0 R >>")
+	escribirObjeto(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.paginas)))
+	escribirObjeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	escribirObjeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	// Las imágenes van después de las páginas y todas las páginas pueden usarlas
+	recursosImagenes := ""
+	if len(d.imagenes) > 0 {
+		referencias := make([]string, len(d.imagenes))
+		for i := range d.imagenes {
+			referencias[i] = fmt.Sprintf("/Im%d %d 0 R", i+1, 5+len(d.paginas)*2+i)
+		}
+		recursosImagenes = fmt.Sprintf(" /XObject << %s >>", strings.Join(referencias, " "))
+	}
+
+	// Cada página ocupa dos objetos: la página y su flujo de contenido
+	for i, pagina := range d.paginas {
+		escribirObjeto(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >>%s >> /Contents %d 0 R >>",
+			AnchoPaginaA4, AltoPaginaA4, recursosImagenes, 6+i*2))
+		escribirObjeto(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", pagina.Len(), pagina.String()))
+	}
+
+	for _, imagen := range d.imagenes {
+		escribirObjeto(fmt.Sprintf(
+			"<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+			imagen.ancho, imagen.alto, len(imagen.datos), imagen.datos))
+	}
+
+	inicioXref := salida.Len()
+	fmt.Fprintf(&salida, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&salida, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&salida, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, inicioXref)
+
+	return salida.Bytes()
+}
+
+// escaparTextoPDF convierte el texto a WinAnsi y escapa los caracteres especiales de las cadenas PDF
+// Los caracteres fuera de Latin-1 se reemplazan por '?'
+func escaparTextoPDF(texto string) string {
+	var b strings.Builder
+	for _, r := range texto {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r < 0x20:
+			continue
+		case r < 0x80:
+			b.WriteByte(byte(r))
+		case r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// ReducirImagen escala una imagen para que su lado mayor no supere maximo píxeles
+// Evita que un logo de alta resolución infle el PDF; las imágenes pequeñas se devuelven sin cambios
+func ReducirImagen(img image.Image, maximo int) image.Image {
+	limites := img.Bounds()
+	ancho, alto := limites.Dx(), limites.Dy()
+	if ancho <= maximo && alto <= maximo {
+		return img
+	}
+
+	nuevoAncho, nuevoAlto := maximo, alto*maximo/ancho
+	if alto > ancho {
+		nuevoAncho, nuevoAlto = ancho*maximo/alto, maximo
+	}
+	if nuevoAncho < 1 {
+		nuevoAncho = 1
+	}
+	if nuevoAlto < 1 {
+		nuevoAlto = 1
+	}
+
+	reducida := image.NewRGBA(image.Rect(0, 0, nuevoAncho, nuevoAlto))
+	for y := 0; y < nuevoAlto; y++ {
+		for x := 0; x < nuevoAncho; x++ {
+			reducida.Set(x, y, img.At(limites.Min.X+x*ancho/nuevoAncho, limites.Min.Y+y*alto/nuevoAlto))
+		}
+	}
+	return reducida
+}
@@ -0,0 +1,522 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// Codificador de códigos QR (ISO/IEC 18004) en modo byte con nivel de corrección M
+// Se implementa aquí para no depender de librerías externas; soporta las versiones 1 a 40
+
+// qrEccPorBloque indica los codewords de corrección por bloque para el nivel M, por versión
+var qrEccPorBloque = [41]int{-1,
+	10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26,
+	26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28}
+
+// qrBloquesEcc indica la cantidad de bloques de corrección para el nivel M, por versión
+var qrBloquesEcc = [41]int{-1,
+	1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16,
+	17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49}
+
+// qrBitsFormatoNivelM son los dos bits que identifican el nivel M en la información de formato
+const qrBitsFormatoNivelM = 0
+
+// CodigoQR representa la matriz de módulos de un código QR; true es un módulo oscuro
+type CodigoQR struct {
+	Version int
+	Tamano  int
+	Modulos [][]bool
+	funcion [][]bool
+}
+
+// GenerarQR codifica un texto en un código QR de la menor versión posible
+func GenerarQR(texto string) (*CodigoQR, error) {
+	datos := []byte(texto)
+
+	// Elegir la menor versión donde entren los datos
+	version := 0
+	for v := 1; v <= 40; v++ {
+		bitsConteo := 8
+		if v >= 10 {
+			bitsConteo = 16
+		}
+		if 4+bitsConteo+len(datos)*8 <= qrCodewordsDatos(v)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, errors.New("el texto es demasiado largo para un código QR")
+	}
+
+	// Segmento en modo byte: indicador de modo, cantidad de caracteres y datos
+	bits := &qrBuffer{}
+	bits.agregar(0x4, 4)
+	if version >= 10 {
+		bits.agregar(len(datos), 16)
+	} else {
+		bits.agregar(len(datos), 8)
+	}
+	for _, b := range datos {
+		bits.agregar(int(b), 8)
+	}
+
+	// Terminador, relleno hasta completar el byte y bytes de relleno alternados
+	capacidad := qrCodewordsDatos(version) * 8
+	terminador := capacidad - len(bits.bits)
+	if terminador > 4 {
+		terminador = 4
+	}
+	bits.agregar(0, terminador)
+	bits.agregar(0, (8-len(bits.bits)%8)%8)
+	for relleno := 0xEC; len(bits.bits) < capacidad; relleno ^= 0xEC ^ 0x11 {
+		bits.agregar(relleno, 8)
+	}
+
+	codewords := make([]byte, len(bits.bits)/8)
+	for i, bit := range bits.bits {
+		if bit {
+			codewords[i>>3] |= 1 << uint(7-i&7)
+		}
+	}
+
+	qr := nuevoCodigoQR(version)
+	qr.dibujarPatronesFuncion()
+	qr.dibujarCodewords(qrAgregarEccEIntercalar(codewords, version))
+
+	// Elegir la máscara con menor penalización
+	mejorMascara, mejorPenalizacion := 0, -1
+	for mascara := 0; mascara < 8; mascara++ {
+		qr.aplicarMascara(mascara)
+		qr.dibujarFormato(mascara)
+		penalizacion := qr.penalizacion()
+		if mejorPenalizacion < 0 || penalizacion < mejorPenalizacion {
+			mejorMascara, mejorPenalizacion = mascara, penalizacion
+		}
+		qr.aplicarMascara(mascara) // La máscara es XOR, aplicarla de nuevo la revierte
+	}
+	qr.aplicarMascara(mejorMascara)
+	qr.dibujarFormato(mejorMascara)
+
+	return qr, nil
+}
+
+// GenerarQRPNG codifica un texto como imagen PNG en escala de grises
+// Cada módulo ocupa escala x escala píxeles y se deja el margen de 4 módulos que exige el estándar
+func GenerarQRPNG(texto string, escala int) ([]byte, error) {
+	qr, err := GenerarQR(texto)
+	if err != nil {
+		return nil, err
+	}
+	if escala < 1 {
+		escala = 1
+	}
+
+	const margen = 4
+	lado := (qr.Tamano + margen*2) * escala
+	img := image.NewGray(image.Rect(0, 0, lado, lado))
+	for y := 0; y < lado; y++ {
+		for x := 0; x < lado; x++ {
+			mx, my := x/escala-margen, y/escala-margen
+			oscuro := mx >= 0 && my >= 0 && mx < qr.Tamano && my < qr.Tamano && qr.Modulos[my][mx]
+			if oscuro {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			} else {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	var salida bytes.Buffer
+	if err := png.Encode(&salida, img); err != nil {
+		return nil, err
+	}
+	return salida.Bytes(), nil
+}
+
+// qrBuffer acumula bits en orden
+type qrBuffer struct {
+	bits []bool
+}
+
+// agregar añade los n bits menos significativos de valor, del más al menos significativo
+func (b *qrBuffer) agregar(valor int, n int) {
+	for i := n - 1; i >= 0; i-- {
+		b.bits = append(b.bits, (valor>>uint(i))&1 != 0)
+	}
+}
+
+// qrModulosDatos calcula cuántos módulos de una versión quedan para datos y corrección
+func qrModulosDatos(version int) int {
+	resultado := (16*version+128)*version + 64
+	if version >= 2 {
+		alineaciones := version/7 + 2
+		resultado -= (25*alineaciones-10)*alineaciones - 55
+		if version >= 7 {
+			resultado -= 36
+		}
+	}
+	return resultado
+}
+
+// qrCodewordsDatos calcula cuántos codewords de datos admite una versión en nivel M
+func qrCodewordsDatos(version int) int {
+	return qrModulosDatos(version)/8 - qrEccPorBloque[version]*qrBloquesEcc[version]
+}
+
+// qrMultiplicarGF multiplica dos elementos del campo de Galois GF(2^8) con el polinomio 0x11D
+func qrMultiplicarGF(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+// qrDivisorReedSolomon calcula el polinomio generador de Reed-Solomon del grado indicado
+func qrDivisorReedSolomon(grado int) []byte {
+	resultado := make([]byte, grado)
+	resultado[grado-1] = 1
+	raiz := byte(1)
+	for i := 0; i < grado; i++ {
+		for j := range resultado {
+			resultado[j] = qrMultiplicarGF(resultado[j], raiz)
+			if j+1 < len(resultado) {
+				resultado[j] ^= resultado[j+1]
+			}
+		}
+		raiz = qrMultiplicarGF(raiz, 0x02)
+	}
+	return resultado
+}
+
+// QRCorreccionReedSolomon calcula los codewords de corrección de un bloque de datos
+func QRCorreccionReedSolomon(datos []byte, grado int) []byte {
+	divisor := qrDivisorReedSolomon(grado)
+	resultado := make([]byte, grado)
+	for _, b := range datos {
+		factor := b ^ resultado[0]
+		copy(resultado, resultado[1:])
+		resultado[len(resultado)-1] = 0
+		for i, coeficiente := range divisor {
+			resultado[i] ^= qrMultiplicarGF(coeficiente, factor)
+		}
+	}
+	return resultado
+}
+
+// qrAgregarEccEIntercalar divide los datos en bloques, agrega la corrección de cada uno e intercala el resultado
+func qrAgregarEccEIntercalar(datos []byte, version int) []byte {
+	bloques := qrBloquesEcc[version]
+	eccPorBloque := qrEccPorBloque[version]
+	codewordsTotales := qrModulosDatos(version) / 8
+	bloquesCortos := bloques - codewordsTotales%bloques
+	largoCorto := codewordsTotales / bloques
+
+	lista := make([][]byte, 0, bloques)
+	k := 0
+	for i := 0; i < bloques; i++ {
+		largoDatos := largoCorto - eccPorBloque
+		if i >= bloquesCortos {
+			largoDatos++
+		}
+		bloque := append([]byte{}, datos[k:k+largoDatos]...)
+		k += largoDatos
+		ecc := QRCorreccionReedSolomon(bloque, eccPorBloque)
+		if i < bloquesCortos {
+			bloque = append(bloque, 0) // Relleno para igualar el largo de los bloques largos
+		}
+		lista = append(lista, append(bloque, ecc...))
+	}
+
+	resultado := make([]byte, 0, codewordsTotales)
+	for i := range lista[0] {
+		for j, bloque := range lista {
+			// Omitir el relleno de los bloques cortos
+			if i != largoCorto-eccPorBloque || j >= bloquesCortos {
+				resultado = append(resultado, bloque[i])
+			}
+		}
+	}
+	return resultado
+}
+
+// QRBitsFormato calcula los 15 bits de formato (nivel M y máscara) con su código BCH
+func QRBitsFormato(mascara int) int {
+	datos := qrBitsFormatoNivelM<<3 | mascara
+	resto := datos
+	for i := 0; i < 10; i++ {
+		resto = (resto << 1) ^ ((resto >> 9) * 0x537)
+	}
+	return (datos<<10 | resto) ^ 0x5412
+}
+
+// QRBitsVersion calcula los 18 bits de información de versión (versiones 7 en adelante)
+func QRBitsVersion(version int) int {
+	resto := version
+	for i := 0; i < 12; i++ {
+		resto = (resto << 1) ^ ((resto >> 11) * 0x1F25)
+	}
+	return version<<12 | resto
+}
+
+// nuevoCodigoQR crea una matriz vacía para la versión indicada
+func nuevoCodigoQR(version int) *CodigoQR {
+	tamano := version*4 + 17
+	qr := &CodigoQR{Version: version, Tamano: tamano}
+	qr.Modulos = make([][]bool, tamano)
+	qr.funcion = make([][]bool, tamano)
+	for i := range qr.Modulos {
+		qr.Modulos[i] = make([]bool, tamano)
+		qr.funcion[i] = make([]bool, tamano)
+	}
+	return qr
+}
+
+// fijarFuncion dibuja un módulo de un patrón de función, que no se enmascara ni lleva datos
+func (qr *CodigoQR) fijarFuncion(x, y int, oscuro bool) {
+	qr.Modulos[y][x] = oscuro
+	qr.funcion[y][x] = true
+}
+
+// posicionesAlineacion calcula las coordenadas de los centros de los patrones de alineación
+func (qr *CodigoQR) posicionesAlineacion() []int {
+	if qr.Version == 1 {
+		return nil
+	}
+	cantidad := qr.Version/7 + 2
+	paso := (qr.Version*8 + cantidad*3 + 5) / (cantidad*4 - 4) * 2
+	resultado := make([]int, cantidad)
+	resultado[0] = 6
+	for i, pos := cantidad-1, qr.Tamano-7; i >= 1; i, pos = i-1, pos-paso {
+		resultado[i] = pos
+	}
+	return resultado
+}
+
+// dibujarPatronesFuncion dibuja los patrones de temporización, localización, alineación y versión
+func (qr *CodigoQR) dibujarPatronesFuncion() {
+	// Patrones de temporización
+	for i := 0; i < qr.Tamano; i++ {
+		qr.fijarFuncion(6, i, i%2 == 0)
+		qr.fijarFuncion(i, 6, i%2 == 0)
+	}
+
+	// Patrones de localización con sus separadores en tres esquinas
+	for _, centro := range [][2]int{{3, 3}, {qr.Tamano - 4, 3}, {3, qr.Tamano - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := centro[0]+dx, centro[1]+dy
+				if x < 0 || y < 0 || x >= qr.Tamano || y >= qr.Tamano {
+					continue
+				}
+				distancia := maxAbs(dx, dy)
+				qr.fijarFuncion(x, y, distancia != 2 && distancia != 4)
+			}
+		}
+	}
+
+	// Patrones de alineación, excepto donde se superponen con los de localización
+	posiciones := qr.posicionesAlineacion()
+	ultima := len(posiciones) - 1
+	for i, px := range posiciones {
+		for j, py := range posiciones {
+			if (i == 0 && j == 0) || (i == 0 && j == ultima) || (i == ultima && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					qr.fijarFuncion(px+dx, py+dy, maxAbs(dx, dy) != 1)
+				}
+			}
+		}
+	}
+
+	// Reservar la zona de formato; sus bits se escriben al elegir la máscara
+	qr.dibujarFormato(0)
+
+	// Información de versión
+	if qr.Version >= 7 {
+		bits := QRBitsVersion(qr.Version)
+		for i := 0; i < 18; i++ {
+			oscuro := (bits>>uint(i))&1 != 0
+			a, b := qr.Tamano-11+i%3, i/3
+			qr.fijarFuncion(a, b, oscuro)
+			qr.fijarFuncion(b, a, oscuro)
+		}
+	}
+}
+
+// dibujarFormato escribe las dos copias de los bits de formato y el módulo oscuro fijo
+func (qr *CodigoQR) dibujarFormato(mascara int) {
+	bits := QRBitsFormato(mascara)
+	bit := func(i int) bool { return (bits>>uint(i))&1 != 0 }
+
+	// Primera copia, alrededor del patrón de localización superior izquierdo
+	for i := 0; i <= 5; i++ {
+		qr.fijarFuncion(8, i, bit(i))
+	}
+	qr.fijarFuncion(8, 7, bit(6))
+	qr.fijarFuncion(8, 8, bit(7))
+	qr.fijarFuncion(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		qr.fijarFuncion(14-i, 8, bit(i))
+	}
+
+	// Segunda copia, repartida entre los otros dos patrones de localización
+	for i := 0; i < 8; i++ {
+		qr.fijarFuncion(qr.Tamano-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.fijarFuncion(8, qr.Tamano-15+i, bit(i))
+	}
+	qr.fijarFuncion(8, qr.Tamano-8, true)
+}
+
+// dibujarCodewords coloca los codewords en zigzag de dos columnas, de abajo hacia arriba y viceversa
+func (qr *CodigoQR) dibujarCodewords(datos []byte) {
+	i := 0
+	for derecha := qr.Tamano - 1; derecha >= 1; derecha -= 2 {
+		if derecha == 6 {
+			derecha = 5 // Saltar la columna de temporización
+		}
+		for vertical := 0; vertical < qr.Tamano; vertical++ {
+			for j := 0; j < 2; j++ {
+				x := derecha - j
+				y := vertical
+				if (derecha+1)&2 == 0 {
+					y = qr.Tamano - 1 - vertical
+				}
+				if !qr.funcion[y][x] && i < len(datos)*8 {
+					qr.Modulos[y][x] = (datos[i>>3]>>uint(7-i&7))&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// aplicarMascara invierte los módulos de datos que cumplen la condición de la máscara
+func (qr *CodigoQR) aplicarMascara(mascara int) {
+	for y := 0; y < qr.Tamano; y++ {
+		for x := 0; x < qr.Tamano; x++ {
+			var invertir bool
+			switch mascara {
+			case 0:
+				invertir = (x+y)%2 == 0
+			case 1:
+				invertir = y%2 == 0
+			case 2:
+				invertir = x%3 == 0
+			case 3:
+				invertir = (x+y)%3 == 0
+			case 4:
+				invertir = (x/3+y/2)%2 == 0
+			case 5:
+				invertir = x*y%2+x*y%3 == 0
+			case 6:
+				invertir = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invertir = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invertir && !qr.funcion[y][x] {
+				qr.Modulos[y][x] = !qr.Modulos[y][x]
+			}
+		}
+	}
+}
+
+// penalizacion evalúa la legibilidad de la matriz con las reglas del estándar:
+// corridas del mismo color, bloques de 2x2, patrones parecidos al de localización y balance de oscuros
+func (qr *CodigoQR) penalizacion() int {
+	resultado := 0
+	n := qr.Tamano
+	modulo := func(x, y int, horizontal bool) bool {
+		if horizontal {
+			return qr.Modulos[y][x]
+		}
+		return qr.Modulos[x][y]
+	}
+
+	// Reglas 1 y 3 en filas y columnas
+	for _, horizontal := range []bool{true, false} {
+		for y := 0; y < n; y++ {
+			corrida := 1
+			for x := 1; x < n; x++ {
+				if modulo(x, y, horizontal) == modulo(x-1, y, horizontal) {
+					corrida++
+					if corrida == 5 {
+						resultado += 3
+					} else if corrida > 5 {
+						resultado++
+					}
+				} else {
+					corrida = 1
+				}
+			}
+			for x := 0; x+7 <= n; x++ {
+				// Patrón 1:1:3:1:1 con cuatro módulos claros a un lado
+				patron := modulo(x, y, horizontal) && !modulo(x+1, y, horizontal) && modulo(x+2, y, horizontal) &&
+					modulo(x+3, y, horizontal) && modulo(x+4, y, horizontal) && !modulo(x+5, y, horizontal) &&
+					modulo(x+6, y, horizontal)
+				if !patron {
+					continue
+				}
+				claroAntes := x >= 4 && !modulo(x-1, y, horizontal) && !modulo(x-2, y, horizontal) &&
+					!modulo(x-3, y, horizontal) && !modulo(x-4, y, horizontal)
+				claroDespues := x+10 < n && !modulo(x+7, y, horizontal) && !modulo(x+8, y, horizontal) &&
+					!modulo(x+9, y, horizontal) && !modulo(x+10, y, horizontal)
+				if claroAntes || claroDespues {
+					resultado += 40
+				}
+			}
+		}
+	}
+
+	// Regla 2: bloques de 2x2 del mismo color
+	for y := 0; y < n-1; y++ {
+		for x := 0; x < n-1; x++ {
+			c := qr.Modulos[y][x]
+			if c == qr.Modulos[y][x+1] && c == qr.Modulos[y+1][x] && c == qr.Modulos[y+1][x+1] {
+				resultado += 3
+			}
+		}
+	}
+
+	// Regla 4: proporción de módulos oscuros
+	oscuros := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if qr.Modulos[y][x] {
+				oscuros++
+			}
+		}
+	}
+	total := n * n
+	k := (abs(oscuros*20-total*10)+total-1)/total - 1
+	if k > 0 {
+		resultado += k * 10
+	}
+
+	return resultado
+}
+
+// maxAbs devuelve el mayor valor absoluto entre dos enteros
+func maxAbs(a, b int) int {
+	if abs(a) > abs(b) {
+		return abs(a)
+	}
+	return abs(b)
+}
+
+// abs devuelve el valor absoluto de un entero
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
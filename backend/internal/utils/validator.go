@@ -85,6 +85,7 @@ package utils
 import (
 	"fmt"
 	"reflect"
+	"sistema-toursseft/internal/entidades"
 	"strings"
 
 	"github.com/go-playground/locales/es"
@@ -147,6 +148,14 @@ func InitValidator() {
 
 	// Registrar traducciones
 	es_translations.RegisterDefaultTranslations(validate, trans)
+
+	// Los montos se validan por su valor decimal (required, min, gt, etc.)
+	validate.RegisterCustomTypeFunc(func(campo reflect.Value) interface{} {
+		if monto, ok := campo.Interface().(entidades.Dinero); ok {
+			return monto.Float64()
+		}
+		return nil
+	}, entidades.Dinero{})
 }
 
 // ValidateStruct valida una estructura utilizando etiquetas de validación
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// NodoXML representa un elemento XML que se escribe directamente en forma canónica (C14N 1.0 inclusiva):
+// sin declaraciones vacías, con atributos ordenados, etiquetas de cierre explícitas y texto escapado.
+// Así el documento firmado y el que se envía son byte a byte el mismo que se usó para calcular el digest.
+type NodoXML struct {
+	Nombre    string
+	Atributos [][2]string
+	Texto     string
+	Hijos     []*NodoXML
+}
+
+// NuevoNodoXML crea un elemento con sus atributos en pares nombre, valor
+func NuevoNodoXML(nombre string, atributos ...string) *NodoXML {
+	nodo := &NodoXML{Nombre: nombre}
+	for i := 0; i+1 < len(atributos); i += 2 {
+		nodo.Atributos = append(nodo.Atributos, [2]string{atributos[i], atributos[i+1]})
+	}
+	return nodo
+}
+
+// Agregar añade hijos al elemento y lo devuelve para encadenar llamadas
+func (n *NodoXML) Agregar(hijos ...*NodoXML) *NodoXML {
+	n.Hijos = append(n.Hijos, hijos...)
+	return n
+}
+
+// Hijo crea un elemento hijo y lo devuelve
+func (n *NodoXML) Hijo(nombre string, atributos ...string) *NodoXML {
+	hijo := NuevoNodoXML(nombre, atributos...)
+	n.Hijos = append(n.Hijos, hijo)
+	return hijo
+}
+
+// HijoTexto crea un elemento hijo con contenido de texto
+func (n *NodoXML) HijoTexto(nombre string, texto string, atributos ...string) *NodoXML {
+	hijo := n.Hijo(nombre, atributos...)
+	hijo.Texto = texto
+	return hijo
+}
+
+// Buscar devuelve el primer descendiente con el nombre indicado, o nil
+func (n *NodoXML) Buscar(nombre string) *NodoXML {
+	for _, hijo := range n.Hijos {
+		if hijo.Nombre == nombre {
+			return hijo
+		}
+		if encontrado := hijo.Buscar(nombre); encontrado != nil {
+			return encontrado
+		}
+	}
+	return nil
+}
+
+// Canonico escribe el elemento en forma canónica
+func (n *NodoXML) Canonico() string {
+	var sb strings.Builder
+	n.escribir(&sb, nil)
+	return sb.String()
+}
+
+// CanonicoConNamespaces escribe el elemento como subconjunto de un documento: en C14N inclusiva
+// el elemento raíz del subconjunto declara todos los namespaces heredados de sus ancestros
+func (n *NodoXML) CanonicoConNamespaces(heredados [][2]string) string {
+	var sb strings.Builder
+	n.escribir(&sb, heredados)
+	return sb.String()
+}
+
+// escribir agrega el elemento al builder; heredados son declaraciones de namespace a incluir en este elemento
+func (n *NodoXML) escribir(sb *strings.Builder, heredados [][2]string) {
+	var namespaces, atributos [][2]string
+	declarados := map[string]bool{}
+	for _, atributo := range n.Atributos {
+		if atributo[0] == "xmlns" || strings.HasPrefix(atributo[0], "xmlns:") {
+			declarados[atributo[0]] = true
+			namespaces = append(namespaces, atributo)
+		} else {
+			atributos = append(atributos, atributo)
+		}
+	}
+	for _, ns := range heredados {
+		if !declarados[ns[0]] {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	// Namespaces por prefijo (el predeterminado primero) y luego atributos por nombre
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i][0] < namespaces[j][0] })
+	sort.Slice(atributos, func(i, j int) bool { return atributos[i][0] < atributos[j][0] })
+
+	sb.WriteString("<")
+	sb.WriteString(n.Nombre)
+	for _, atributo := range append(namespaces, atributos...) {
+		sb.WriteString(" ")
+		sb.WriteString(atributo[0])
+		sb.WriteString(`="`)
+		sb.WriteString(escaparAtributoXML(atributo[1]))
+		sb.WriteString(`"`)
+	}
+	sb.WriteString(">")
+	sb.WriteString(escaparTextoXML(n.Texto))
+	for _, hijo := range n.Hijos {
+		hijo.escribir(sb, nil)
+	}
+	sb.WriteString("</")
+	sb.WriteString(n.Nombre)
+	sb.WriteString(">")
+}
+
+// escaparTextoXML escapa el contenido de texto según las reglas de C14N
+func escaparTextoXML(texto string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;").Replace(texto)
+}
+
+// escaparAtributoXML escapa el valor de un atributo según las reglas de C14N
+func escaparAtributoXML(valor string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;").Replace(valor)
+}
@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Algoritmos XML-DSig usados en la firma de comprobantes electrónicos
+const (
+	AlgoritmoC14N        = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	AlgoritmoRSASHA256   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	AlgoritmoSHA256      = "http://www.w3.org/2001/04/xmlenc#sha256"
+	AlgoritmoEnvuelto    = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	NamespaceXMLDSig     = "http://www.w3.org/2000/09/xmldsig#"
+	nombreFirmaXML       = "ds:Signature"
+	nombreSignedInfoXML  = "ds:SignedInfo"
+	nombreDigestValueXML = "ds:DigestValue"
+)
+
+// FirmanteXML firma documentos XML con una firma envuelta (enveloped) RSA-SHA256
+type FirmanteXML struct {
+	clave       *rsa.PrivateKey
+	certificado *x509.Certificate
+}
+
+// NuevoFirmanteXML carga el certificado X.509 y su clave privada RSA (PKCS#1 o PKCS#8) en formato PEM
+func NuevoFirmanteXML(certificadoPEM []byte, clavePEM []byte) (*FirmanteXML, error) {
+	bloqueCert, _ := pem.Decode(certificadoPEM)
+	if bloqueCert == nil {
+		return nil, errors.New("el certificado no está en formato PEM")
+	}
+	certificado, err := x509.ParseCertificate(bloqueCert.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	bloqueClave, _ := pem.Decode(clavePEM)
+	if bloqueClave == nil {
+		return nil, errors.New("la clave privada no está en formato PEM")
+	}
+
+	var clave *rsa.PrivateKey
+	if clavePKCS1, err := x509.ParsePKCS1PrivateKey(bloqueClave.Bytes); err == nil {
+		clave = clavePKCS1
+	} else {
+		clavePKCS8, err := x509.ParsePKCS8PrivateKey(bloqueClave.Bytes)
+		if err != nil {
+			return nil, errors.New("no se pudo leer la clave privada")
+		}
+		var ok bool
+		if clave, ok = clavePKCS8.(*rsa.PrivateKey); !ok {
+			return nil, errors.New("la clave privada debe ser RSA")
+		}
+	}
+
+	publica, ok := certificado.PublicKey.(*rsa.PublicKey)
+	if !ok || publica.N.Cmp(clave.N) != 0 {
+		return nil, errors.New("la clave privada no corresponde al certificado")
+	}
+
+	return &FirmanteXML{clave: clave, certificado: certificado}, nil
+}
+
+// NuevoFirmanteXMLAutofirmado genera una clave y un certificado autofirmado en memoria
+// Solo sirve para desarrollo y pruebas con el enviador local; SUNAT no acepta estas firmas
+func NuevoFirmanteXMLAutofirmado(nombre string) (*FirmanteXML, error) {
+	clave, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	plantilla := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: nombre},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, plantilla, plantilla, &clave.PublicKey, clave)
+	if err != nil {
+		return nil, err
+	}
+	certificado, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FirmanteXML{clave: clave, certificado: certificado}, nil
+}
+
+// namespacesDeclarados devuelve las declaraciones xmlns de un elemento
+func namespacesDeclarados(nodo *NodoXML) [][2]string {
+	var namespaces [][2]string
+	for _, atributo := range nodo.Atributos {
+		if atributo[0] == "xmlns" || strings.HasPrefix(atributo[0], "xmlns:") {
+			namespaces = append(namespaces, atributo)
+		}
+	}
+	return namespaces
+}
+
+// digestDocumento calcula el SHA-256 en base64 de la forma canónica del documento
+func digestDocumento(raiz *NodoXML) string {
+	suma := sha256.Sum256([]byte(raiz.Canonico()))
+	return base64.StdEncoding.EncodeToString(suma[:])
+}
+
+// FirmarEnvuelto firma el documento completo y coloca la firma dentro de contenedor, que debe estar vacío
+// Todas las declaraciones de namespace deben estar en la raíz. Devuelve el DigestValue del documento.
+func (f *FirmanteXML) FirmarEnvuelto(raiz *NodoXML, contenedor *NodoXML, idFirma string) (string, error) {
+	if len(contenedor.Hijos) > 0 || contenedor.Texto != "" {
+		return "", errors.New("el contenedor de la firma debe estar vacío")
+	}
+
+	// Con la transformación enveloped el digest se calcula sin la firma, es decir, con el contenedor vacío
+	digest := digestDocumento(raiz)
+
+	signedInfo := NuevoNodoXML(nombreSignedInfoXML)
+	signedInfo.Hijo("ds:CanonicalizationMethod", "Algorithm", AlgoritmoC14N)
+	signedInfo.Hijo("ds:SignatureMethod", "Algorithm", AlgoritmoRSASHA256)
+	referencia := signedInfo.Hijo("ds:Reference", "URI", "")
+	referencia.Hijo("ds:Transforms").Hijo("ds:Transform", "Algorithm", AlgoritmoEnvuelto)
+	referencia.Hijo("ds:DigestMethod", "Algorithm", AlgoritmoSHA256)
+	referencia.HijoTexto(nombreDigestValueXML, digest)
+
+	// SignedInfo se canoniza como subconjunto del documento, con los namespaces heredados de la raíz
+	suma := sha256.Sum256([]byte(signedInfo.CanonicoConNamespaces(namespacesDeclarados(raiz))))
+	valorFirma, err := rsa.SignPKCS1v15(rand.Reader, f.clave, crypto.SHA256, suma[:])
+	if err != nil {
+		return "", err
+	}
+
+	firma := NuevoNodoXML(nombreFirmaXML, "Id", idFirma)
+	firma.Agregar(signedInfo)
+	firma.HijoTexto("ds:SignatureValue", base64.StdEncoding.EncodeToString(valorFirma))
+	firma.Hijo("ds:KeyInfo").Hijo("ds:X509Data").HijoTexto("ds:X509Certificate", base64.StdEncoding.EncodeToString(f.certificado.Raw))
+	contenedor.Agregar(firma)
+
+	return digest, nil
+}
+
+// VerificarFirmaEnvuelta comprueba el digest y la firma RSA de un documento firmado con FirmarEnvuelto,
+// usando el certificado incluido en la propia firma
+func VerificarFirmaEnvuelta(raiz *NodoXML, contenedor *NodoXML) error {
+	if len(contenedor.Hijos) != 1 || contenedor.Hijos[0].Nombre != nombreFirmaXML {
+		return errors.New("el documento no tiene firma")
+	}
+	firma := contenedor.Hijos[0]
+
+	// Recalcular el digest quitando la firma
+	contenedor.Hijos = nil
+	digest := digestDocumento(raiz)
+	contenedor.Hijos = []*NodoXML{firma}
+
+	signedInfo := firma.Buscar(nombreSignedInfoXML)
+	digestValue := firma.Buscar(nombreDigestValueXML)
+	valorFirma := firma.Buscar("ds:SignatureValue")
+	certificadoB64 := firma.Buscar("ds:X509Certificate")
+	if signedInfo == nil || digestValue == nil || valorFirma == nil || certificadoB64 == nil {
+		return errors.New("la firma está incompleta")
+	}
+	if digestValue.Texto != digest {
+		return errors.New("el digest no coincide: el documento fue modificado")
+	}
+
+	der, err := base64.StdEncoding.DecodeString(certificadoB64.Texto)
+	if err != nil {
+		return err
+	}
+	certificado, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+	publica, ok := certificado.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("el certificado no tiene clave RSA")
+	}
+
+	firmaBytes, err := base64.StdEncoding.DecodeString(valorFirma.Texto)
+	if err != nil {
+		return err
+	}
+	suma := sha256.Sum256([]byte(signedInfo.CanonicoConNamespaces(namespacesDeclarados(raiz))))
+	if err := rsa.VerifyPKCS1v15(publica, crypto.SHA256, suma[:], firmaBytes); err != nil {
+		return errors.New("la firma no es válida")
+	}
+	return nil
+}
+
+// DocumentoXML agrega la declaración XML a la forma canónica del documento
+func DocumentoXML(raiz *NodoXML) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	buffer.WriteString(raiz.Canonico())
+	return buffer.Bytes()
+}
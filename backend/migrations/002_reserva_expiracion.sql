@@ -0,0 +1,6 @@
+-- 002. Retención temporal de cupos para reservas web
+-- Las reservas creadas desde el flujo web (Mercado Pago) bloquean cupo solo
+-- durante un tiempo limitado; pasado ese tiempo se liberan y pasan a EXPIRADA.
+ALTER TABLE sede ADD COLUMN IF NOT EXISTS minutos_retencion_reserva INT NOT NULL DEFAULT 30;
+ALTER TABLE reserva ADD COLUMN IF NOT EXISTS fecha_expiracion TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_reserva_expiracion ON reserva(fecha_expiracion) WHERE estado = 'RESERVADO';
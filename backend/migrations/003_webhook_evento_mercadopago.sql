@@ -0,0 +1,24 @@
+-- 003. Registro de notificaciones de Mercado Pago
+-- Cada combinación pago/estado se procesa una sola vez; los reintentos del webhook quedan como no-op.
+-- La 023 renombra la tabla a webhook_evento_pasarela; después de eso no se vuelve a crear
+DO $$
+BEGIN
+    IF to_regclass('webhook_evento_pasarela') IS NULL THEN
+        CREATE TABLE IF NOT EXISTS webhook_evento_mercadopago (
+            id_evento SERIAL PRIMARY KEY,
+            id_pago_externo VARCHAR(50) NOT NULL,     -- ID del pago en Mercado Pago
+            estado_pago VARCHAR(30) NOT NULL,         -- Estado informado por Mercado Pago (approved, refunded, ...)
+            id_notificacion VARCHAR(100),             -- Cabecera x-request-id de la notificación
+            tipo VARCHAR(30) NOT NULL,                -- payment, merchant_order, ...
+            id_reserva INT,
+            monto DECIMAL(10,2),
+            resultado VARCHAR(20) NOT NULL DEFAULT 'PROCESANDO',
+            detalle TEXT,
+            fecha_recepcion TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            fecha_procesado TIMESTAMP,
+            FOREIGN KEY (id_reserva) REFERENCES reserva(id_reserva) ON UPDATE CASCADE ON DELETE RESTRICT,
+            UNIQUE (id_pago_externo, estado_pago),
+            CHECK (resultado IN ('PROCESANDO', 'PROCESADO', 'RECHAZADO', 'ERROR'))
+        );
+    END IF;
+END $$;
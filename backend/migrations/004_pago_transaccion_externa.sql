@@ -0,0 +1,13 @@
+-- 004. Pagos registrados desde pasarelas externas (Mercado Pago)
+-- El ID de transacción externo permite registrar cada cobro una sola vez y revertirlo ante devoluciones.
+ALTER TABLE pago ADD COLUMN IF NOT EXISTS id_transaccion_externa VARCHAR(50);
+
+-- La 021 reemplaza este índice por uno por pasarela: no se vuelve a crear cuando ya existe el nuevo,
+-- porque dos pasarelas pueden informar el mismo ID de transacción
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_pago_pasarela_transaccion') THEN
+        CREATE UNIQUE INDEX IF NOT EXISTS idx_pago_transaccion_externa
+            ON pago(id_transaccion_externa) WHERE id_transaccion_externa IS NOT NULL AND eliminado = FALSE;
+    END IF;
+END $$;
@@ -0,0 +1,10 @@
+-- 005. Flujo de devoluciones de pagos
+-- Solicitud (PENDIENTE) -> aprobación ADMIN (APROBADA / RECHAZADA) -> ejecución manual o por Mercado Pago (COMPLETADA).
+ALTER TABLE devolucion_pago ADD COLUMN IF NOT EXISTS cancelar_reserva BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE devolucion_pago ADD COLUMN IF NOT EXISTS metodo_ejecucion VARCHAR(20);          -- MANUAL, MERCADO_PAGO
+ALTER TABLE devolucion_pago ADD COLUMN IF NOT EXISTS id_devolucion_externa VARCHAR(50);     -- ID del reembolso en Mercado Pago
+ALTER TABLE devolucion_pago ADD COLUMN IF NOT EXISTS id_usuario_solicita INT REFERENCES usuario(id_usuario) ON UPDATE CASCADE ON DELETE RESTRICT;
+ALTER TABLE devolucion_pago ADD COLUMN IF NOT EXISTS id_usuario_aprueba INT REFERENCES usuario(id_usuario) ON UPDATE CASCADE ON DELETE RESTRICT;
+ALTER TABLE devolucion_pago ADD COLUMN IF NOT EXISTS fecha_aprobacion TIMESTAMP;
+ALTER TABLE devolucion_pago ADD COLUMN IF NOT EXISTS fecha_ejecucion TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_devolucion_pago_ejecucion ON devolucion_pago(fecha_ejecucion);
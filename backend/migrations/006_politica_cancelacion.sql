@@ -0,0 +1,16 @@
+-- 006. Políticas de cancelación con porcentaje de devolución
+-- Cada fila es un tramo: cancelando con al menos horas_antes_minimo horas antes del inicio
+-- de la instancia se devuelve porcentaje_devolucion del monto pagado. Los tramos de un
+-- tipo de tour tienen prioridad sobre los de su sede.
+CREATE TABLE IF NOT EXISTS politica_cancelacion (
+    id_politica SERIAL PRIMARY KEY,
+    id_tipo_tour INT REFERENCES tipo_tour(id_tipo_tour) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_sede INT REFERENCES sede(id_sede) ON UPDATE CASCADE ON DELETE RESTRICT,
+    nombre VARCHAR(100) NOT NULL,
+    horas_antes_minimo INT NOT NULL CHECK (horas_antes_minimo >= 0),
+    porcentaje_devolucion DECIMAL(5,2) NOT NULL CHECK (porcentaje_devolucion BETWEEN 0 AND 100),
+    eliminado BOOLEAN DEFAULT FALSE,
+    CHECK (id_tipo_tour IS NOT NULL OR id_sede IS NOT NULL)
+);
+CREATE INDEX IF NOT EXISTS idx_politica_cancelacion_tipo_tour ON politica_cancelacion(id_tipo_tour);
+CREATE INDEX IF NOT EXISTS idx_politica_cancelacion_sede ON politica_cancelacion(id_sede);
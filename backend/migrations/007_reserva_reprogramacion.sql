@@ -0,0 +1,16 @@
+-- 007. Historial de reprogramaciones de reservas
+-- Cada cambio de instancia guarda origen, destino y la diferencia de precio resultante.
+CREATE TABLE IF NOT EXISTS reserva_reprogramacion (
+    id_reprogramacion SERIAL PRIMARY KEY,
+    id_reserva INT NOT NULL REFERENCES reserva(id_reserva) ON UPDATE CASCADE ON DELETE CASCADE,
+    id_instancia_origen INT NOT NULL REFERENCES instancia_tour(id_instancia) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_instancia_destino INT NOT NULL REFERENCES instancia_tour(id_instancia) ON UPDATE CASCADE ON DELETE RESTRICT,
+    total_anterior DECIMAL(10,2) NOT NULL,
+    total_nuevo DECIMAL(10,2) NOT NULL,
+    diferencia DECIMAL(10,2) NOT NULL,
+    motivo TEXT,
+    origen VARCHAR(20) NOT NULL,          -- ADMIN, VENDEDOR, CLIENTE
+    id_usuario INT REFERENCES usuario(id_usuario) ON UPDATE CASCADE ON DELETE RESTRICT,
+    fecha_reprogramacion TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_reserva_reprogramacion_reserva ON reserva_reprogramacion(id_reserva);
@@ -0,0 +1,21 @@
+-- 008. Lista de espera por instancia de tour
+-- Cuando se liberan cupos, las entradas EN_ESPERA que caben reciben una oferta (OFERTADA) que
+-- retiene esos cupos hasta fecha_expiracion_oferta. Si el cliente la convierte en reserva pasa a
+-- CONVERTIDA; si vence, los cupos vuelven a la instancia y la entrada queda EXPIRADA.
+CREATE TABLE IF NOT EXISTS lista_espera (
+    id_lista_espera SERIAL PRIMARY KEY,
+    id_instancia INT NOT NULL REFERENCES instancia_tour(id_instancia) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_cliente INT NOT NULL REFERENCES cliente(id_cliente) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_usuario_registra INT REFERENCES usuario(id_usuario) ON UPDATE CASCADE ON DELETE RESTRICT,
+    cantidad_pasajeros INT NOT NULL CHECK (cantidad_pasajeros > 0),
+    estado VARCHAR(20) NOT NULL DEFAULT 'EN_ESPERA',
+    notas TEXT,
+    fecha_registro TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    fecha_oferta TIMESTAMP,
+    fecha_expiracion_oferta TIMESTAMP,
+    id_reserva INT REFERENCES reserva(id_reserva) ON UPDATE CASCADE ON DELETE SET NULL,
+    CHECK (estado IN ('EN_ESPERA', 'OFERTADA', 'CONVERTIDA', 'EXPIRADA', 'CANCELADA'))
+);
+CREATE INDEX IF NOT EXISTS idx_lista_espera_instancia ON lista_espera(id_instancia, estado, fecha_registro);
+CREATE INDEX IF NOT EXISTS idx_lista_espera_cliente ON lista_espera(id_cliente);
+CREATE INDEX IF NOT EXISTS idx_lista_espera_oferta ON lista_espera(fecha_expiracion_oferta) WHERE estado = 'OFERTADA';
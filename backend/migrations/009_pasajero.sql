@@ -0,0 +1,18 @@
+-- 009. Pasajeros de cada reserva para el manifiesto de embarque
+-- La reserva solo registra cantidades en pasajes_cantidad; aquí se guardan los datos de cada persona a bordo
+CREATE TABLE IF NOT EXISTS pasajero (
+    id_pasajero SERIAL PRIMARY KEY,
+    id_reserva INT NOT NULL REFERENCES reserva(id_reserva) ON UPDATE CASCADE ON DELETE CASCADE,
+    id_tipo_pasaje INT REFERENCES tipo_pasaje(id_tipo_pasaje) ON UPDATE CASCADE ON DELETE RESTRICT,
+    nombres VARCHAR(100) NOT NULL,
+    apellidos VARCHAR(100) NOT NULL,
+    tipo_documento VARCHAR(20) NOT NULL,
+    numero_documento VARCHAR(20) NOT NULL,
+    fecha_nacimiento DATE NOT NULL,
+    nacionalidad VARCHAR(50),
+    contacto_emergencia_nombre VARCHAR(150) NOT NULL,
+    contacto_emergencia_telefono VARCHAR(20) NOT NULL,
+    eliminado BOOLEAN DEFAULT FALSE,
+    CHECK (tipo_documento IN ('DNI', 'CE', 'Pasaporte'))
+);
+CREATE INDEX IF NOT EXISTS idx_pasajero_reserva ON pasajero(id_reserva) WHERE eliminado = FALSE;
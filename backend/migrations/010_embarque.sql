@@ -0,0 +1,15 @@
+-- 010. Check-in de embarque con pase QR
+-- Cada reserva confirmada se embarca una sola vez; la restricción única impide reutilizar el pase
+CREATE TABLE IF NOT EXISTS embarque (
+    id_embarque SERIAL PRIMARY KEY,
+    id_reserva INT NOT NULL UNIQUE REFERENCES reserva(id_reserva) ON UPDATE CASCADE ON DELETE CASCADE,
+    id_instancia INT NOT NULL REFERENCES instancia_tour(id_instancia) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_usuario INT NOT NULL REFERENCES usuario(id_usuario) ON UPDATE CASCADE ON DELETE RESTRICT,
+    pasajeros_embarcados INT NOT NULL CHECK (pasajeros_embarcados >= 0),
+    fecha_embarque TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_embarque_instancia ON embarque(id_instancia);
+
+-- Pasajeros registrados que subieron a bordo
+ALTER TABLE pasajero ADD COLUMN IF NOT EXISTS embarcado BOOLEAN DEFAULT FALSE;
+ALTER TABLE pasajero ADD COLUMN IF NOT EXISTS fecha_embarque TIMESTAMP;
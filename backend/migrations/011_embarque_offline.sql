@@ -0,0 +1,6 @@
+-- 011. Check-in sin conexión en el muelle
+-- Los escaneos hechos sin señal se sincronizan después; id_evento hace idempotente el reenvío de un mismo escaneo
+ALTER TABLE embarque ADD COLUMN IF NOT EXISTS origen VARCHAR(10) NOT NULL DEFAULT 'ONLINE' CHECK (origen IN ('ONLINE', 'OFFLINE'));
+ALTER TABLE embarque ADD COLUMN IF NOT EXISTS id_evento VARCHAR(64);
+ALTER TABLE embarque ADD COLUMN IF NOT EXISTS fecha_sincronizacion TIMESTAMP;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_embarque_evento ON embarque(id_evento) WHERE id_evento IS NOT NULL;
@@ -0,0 +1,36 @@
+-- 012. Comprobantes electrónicos SUNAT (UBL 2.1)
+-- La tabla comprobante_pago la usa el repositorio pero no estaba en el esquema inicial
+CREATE TABLE IF NOT EXISTS comprobante_pago (
+    id_comprobante SERIAL PRIMARY KEY,
+    id_reserva INT NOT NULL REFERENCES reserva(id_reserva) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_sede INT NOT NULL REFERENCES sede(id_sede) ON UPDATE CASCADE ON DELETE RESTRICT,
+    tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('BOLETA', 'FACTURA')),
+    numero_comprobante VARCHAR(20) NOT NULL,
+    fecha_emision TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    subtotal DECIMAL(10,2) NOT NULL,
+    igv DECIMAL(10,2) NOT NULL,
+    total DECIMAL(10,2) NOT NULL,
+    estado VARCHAR(20) DEFAULT 'EMITIDO',
+    eliminado BOOLEAN DEFAULT FALSE
+);
+CREATE INDEX IF NOT EXISTS idx_comprobante_pago_reserva ON comprobante_pago(id_reserva);
+CREATE INDEX IF NOT EXISTS idx_comprobante_pago_sede ON comprobante_pago(id_sede);
+
+-- XML firmado y constancia de recepción (CDR) de cada comprobante enviado
+CREATE TABLE IF NOT EXISTS comprobante_electronico (
+    id_comprobante_electronico SERIAL PRIMARY KEY,
+    id_comprobante INT NOT NULL UNIQUE REFERENCES comprobante_pago(id_comprobante) ON UPDATE CASCADE ON DELETE RESTRICT,
+    nombre_archivo VARCHAR(60) NOT NULL,
+    xml_firmado BYTEA NOT NULL,
+    hash_cpe VARCHAR(64) NOT NULL,
+    estado_sunat VARCHAR(20) NOT NULL DEFAULT 'PENDIENTE'
+        CHECK (estado_sunat IN ('PENDIENTE', 'ACEPTADO', 'OBSERVADO', 'RECHAZADO', 'ERROR')),
+    codigo_respuesta VARCHAR(10),
+    descripcion_respuesta TEXT,
+    cdr_zip BYTEA,
+    enviador VARCHAR(10) NOT NULL,
+    intentos INT NOT NULL DEFAULT 0,
+    fecha_generacion TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    fecha_envio TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_comprobante_electronico_estado ON comprobante_electronico(estado_sunat);
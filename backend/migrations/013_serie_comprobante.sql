@@ -0,0 +1,19 @@
+-- 013. Series de comprobantes por sede y tipo con numeración correlativa automática
+-- ultimo_correlativo se incrementa dentro de la transacción que crea el comprobante,
+-- así un rollback no deja huecos en la numeración
+CREATE TABLE IF NOT EXISTS serie_comprobante (
+    id_serie SERIAL PRIMARY KEY,
+    id_sede INT NOT NULL REFERENCES sede(id_sede) ON UPDATE CASCADE ON DELETE RESTRICT,
+    tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('BOLETA', 'FACTURA')),
+    serie VARCHAR(4) NOT NULL UNIQUE,
+    ultimo_correlativo INT NOT NULL DEFAULT 0 CHECK (ultimo_correlativo BETWEEN 0 AND 99999999),
+    activo BOOLEAN NOT NULL DEFAULT TRUE,
+    fecha_creacion TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- Una sola serie activa por sede y tipo
+CREATE UNIQUE INDEX IF NOT EXISTS idx_serie_comprobante_activa ON serie_comprobante(id_sede, tipo) WHERE activo;
+
+-- Comprobantes numerados automáticamente
+ALTER TABLE comprobante_pago ADD COLUMN IF NOT EXISTS id_serie INT REFERENCES serie_comprobante(id_serie) ON UPDATE CASCADE ON DELETE RESTRICT;
+ALTER TABLE comprobante_pago ADD COLUMN IF NOT EXISTS correlativo INT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comprobante_pago_serie_correlativo ON comprobante_pago(id_serie, correlativo) WHERE id_serie IS NOT NULL;
@@ -0,0 +1,45 @@
+-- 014. Notas de crédito y débito que corrigen un comprobante emitido
+-- Las restricciones se reemplazan una sola vez: hacerlo en cada arranque bloquea y revisa toda la tabla
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint
+                   WHERE conrelid = 'comprobante_pago'::regclass AND conname = 'comprobante_pago_tipo_check'
+                   AND pg_get_constraintdef(oid) LIKE '%NOTA_DEBITO%') THEN
+        ALTER TABLE comprobante_pago DROP CONSTRAINT IF EXISTS comprobante_pago_tipo_check;
+        ALTER TABLE comprobante_pago ADD CONSTRAINT comprobante_pago_tipo_check
+            CHECK (tipo IN ('BOLETA', 'FACTURA', 'NOTA_CREDITO', 'NOTA_DEBITO'));
+    END IF;
+END $$;
+
+ALTER TABLE comprobante_pago ADD COLUMN IF NOT EXISTS id_comprobante_referencia INT REFERENCES comprobante_pago(id_comprobante) ON UPDATE CASCADE ON DELETE RESTRICT;
+ALTER TABLE comprobante_pago ADD COLUMN IF NOT EXISTS codigo_motivo VARCHAR(2);  -- Catálogo 09 (crédito) o 10 (débito) de SUNAT
+ALTER TABLE comprobante_pago ADD COLUMN IF NOT EXISTS motivo VARCHAR(250);
+ALTER TABLE comprobante_pago ADD COLUMN IF NOT EXISTS id_devolucion INT REFERENCES devolucion_pago(id_devolucion) ON UPDATE CASCADE ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS idx_comprobante_pago_referencia ON comprobante_pago(id_comprobante_referencia);
+-- Una devolución se respalda con una sola nota de crédito
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comprobante_pago_devolucion ON comprobante_pago(id_devolucion) WHERE id_devolucion IS NOT NULL;
+
+-- Las notas se numeran con series propias que empiezan con F (notas de facturas) o B (notas de boletas)
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint
+                   WHERE conrelid = 'serie_comprobante'::regclass AND conname = 'serie_comprobante_tipo_check'
+                   AND pg_get_constraintdef(oid) LIKE '%NOTA_DEBITO%') THEN
+        ALTER TABLE serie_comprobante DROP CONSTRAINT IF EXISTS serie_comprobante_tipo_check;
+        ALTER TABLE serie_comprobante ADD CONSTRAINT serie_comprobante_tipo_check
+            CHECK (tipo IN ('BOLETA', 'FACTURA', 'NOTA_CREDITO', 'NOTA_DEBITO'));
+    END IF;
+END $$;
+
+-- Boletas y facturas siguen con una sola serie activa por sede y tipo; las notas pueden tener una activa por
+-- letra inicial. El índice conserva el nombre que crea 013 para que 013 no vuelva a crear la versión anterior
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_indexes
+                   WHERE indexname = 'idx_serie_comprobante_activa' AND indexdef LIKE '%NOTA_CREDITO%') THEN
+        DROP INDEX IF EXISTS idx_serie_comprobante_activa;
+        DROP INDEX IF EXISTS idx_serie_comprobante_activa_prefijo;
+        CREATE UNIQUE INDEX idx_serie_comprobante_activa ON serie_comprobante(id_sede, tipo,
+            (CASE WHEN tipo IN ('NOTA_CREDITO', 'NOTA_DEBITO') THEN LEFT(serie, 1) ELSE '' END)) WHERE activo;
+    END IF;
+END $$;
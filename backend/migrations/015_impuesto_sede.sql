@@ -0,0 +1,22 @@
+-- 015. Configuración de IGV por sede
+-- Las operaciones turísticas pueden estar gravadas, exoneradas o inafectas según la sede;
+-- una sede sin fila usa IGV gravado con la tasa de IGV_PORCENTAJE y precios con IGV incluido
+CREATE TABLE IF NOT EXISTS configuracion_impuesto (
+    id_sede INT PRIMARY KEY REFERENCES sede(id_sede) ON UPDATE CASCADE ON DELETE CASCADE,
+    afectacion_igv VARCHAR(10) NOT NULL DEFAULT 'GRAVADO' CHECK (afectacion_igv IN ('GRAVADO', 'EXONERADO', 'INAFECTO')),
+    tasa_igv DECIMAL(5,2) NOT NULL DEFAULT 18.00 CHECK (tasa_igv > 0 AND tasa_igv < 100),
+    precios_incluyen_igv BOOLEAN NOT NULL DEFAULT TRUE,
+    fecha_actualizacion TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Afectación con la que se emitió cada comprobante (catálogo 07: 10 gravado, 20 exonerado, 30 inafecto)
+-- Al agregar la columna, los comprobantes anteriores sin IGV se registran como exonerados
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+                   WHERE table_name = 'comprobante_pago' AND column_name = 'afectacion_igv') THEN
+        ALTER TABLE comprobante_pago ADD COLUMN afectacion_igv VARCHAR(10) NOT NULL DEFAULT 'GRAVADO'
+            CHECK (afectacion_igv IN ('GRAVADO', 'EXONERADO', 'INAFECTO'));
+        UPDATE comprobante_pago SET afectacion_igv = 'EXONERADO' WHERE igv = 0;
+    END IF;
+END $$;
@@ -0,0 +1,39 @@
+-- 016. Códigos promocionales y su canje en reservas
+-- Un código descuenta un porcentaje o un monto fijo durante su vigencia. Las restricciones
+-- (tipo de tour, sede, canal de venta, tipo de pasaje) son opcionales; un código restringido a un
+-- tipo de pasaje solo descuenta sobre las líneas de ese pasaje. Los usos se cuentan por canje en
+-- reservas que no están canceladas ni expiradas.
+CREATE TABLE IF NOT EXISTS codigo_promocional (
+    id_codigo_promocional SERIAL PRIMARY KEY,
+    codigo VARCHAR(40) NOT NULL,
+    descripcion TEXT,
+    tipo_descuento VARCHAR(10) NOT NULL CHECK (tipo_descuento IN ('PORCENTAJE', 'MONTO_FIJO')),
+    porcentaje DECIMAL(5,2) NOT NULL DEFAULT 0 CHECK (porcentaje >= 0 AND porcentaje <= 100),
+    monto_descuento DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (monto_descuento >= 0),
+    fecha_inicio TIMESTAMP NOT NULL,
+    fecha_fin TIMESTAMP NOT NULL,
+    usos_maximos INT CHECK (usos_maximos > 0),
+    usos_por_cliente INT CHECK (usos_por_cliente > 0),
+    id_tipo_tour INT REFERENCES tipo_tour(id_tipo_tour) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_sede INT REFERENCES sede(id_sede) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_canal INT REFERENCES canal_venta(id_canal) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_tipo_pasaje INT REFERENCES tipo_pasaje(id_tipo_pasaje) ON UPDATE CASCADE ON DELETE RESTRICT,
+    activo BOOLEAN NOT NULL DEFAULT TRUE,
+    eliminado BOOLEAN NOT NULL DEFAULT FALSE,
+    fecha_registro TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (fecha_fin > fecha_inicio),
+    CHECK ((tipo_descuento = 'PORCENTAJE' AND porcentaje > 0) OR (tipo_descuento = 'MONTO_FIJO' AND monto_descuento > 0))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_codigo_promocional_codigo ON codigo_promocional(UPPER(codigo)) WHERE eliminado = FALSE;
+
+-- Un canje por reserva; guarda el total antes del descuento para el reporte de la campaña
+CREATE TABLE IF NOT EXISTS canje_promocion (
+    id_canje SERIAL PRIMARY KEY,
+    id_codigo_promocional INT NOT NULL REFERENCES codigo_promocional(id_codigo_promocional) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_reserva INT NOT NULL UNIQUE REFERENCES reserva(id_reserva) ON UPDATE CASCADE ON DELETE CASCADE,
+    id_cliente INT NOT NULL REFERENCES cliente(id_cliente) ON UPDATE CASCADE ON DELETE RESTRICT,
+    total_antes DECIMAL(10,2) NOT NULL,
+    monto_descuento DECIMAL(10,2) NOT NULL CHECK (monto_descuento >= 0),
+    fecha_canje TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_canje_promocion_codigo ON canje_promocion(id_codigo_promocional, id_cliente);
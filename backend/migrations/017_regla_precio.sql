@@ -0,0 +1,50 @@
+-- 017. Reglas de precio por temporada, día, horario y ocupación
+-- Las reglas ajustan tipo_pasaje.costo con un porcentaje y/o un monto (negativos para rebajas).
+-- Se aplican en orden de prioridad, cada una sobre el precio que dejó la anterior. Las columnas de
+-- alcance nulas (tipo de pasaje, tipo de tour, sede) no limitan la regla.
+--   TEMPORADA:  la fecha de la instancia está entre fecha_inicio y fecha_fin (inclusive)
+--   DIA_SEMANA: el día de la instancia está en dias_semana (ISO: 1 lunes ... 7 domingo, "6,7")
+--   HORARIO:    la hora de inicio de la instancia está entre hora_inicio y hora_fin
+--   OCUPACION:  el porcentaje de cupos vendidos de la instancia alcanza ocupacion_minima
+CREATE TABLE IF NOT EXISTS regla_precio (
+    id_regla_precio SERIAL PRIMARY KEY,
+    nombre VARCHAR(100) NOT NULL,
+    tipo_regla VARCHAR(10) NOT NULL CHECK (tipo_regla IN ('TEMPORADA', 'DIA_SEMANA', 'HORARIO', 'OCUPACION')),
+    id_tipo_pasaje INT REFERENCES tipo_pasaje(id_tipo_pasaje) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_tipo_tour INT REFERENCES tipo_tour(id_tipo_tour) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_sede INT REFERENCES sede(id_sede) ON UPDATE CASCADE ON DELETE RESTRICT,
+    fecha_inicio DATE,
+    fecha_fin DATE,
+    dias_semana VARCHAR(20),
+    hora_inicio TIME,
+    hora_fin TIME,
+    ocupacion_minima DECIMAL(5,2) CHECK (ocupacion_minima >= 0 AND ocupacion_minima <= 100),
+    porcentaje DECIMAL(6,2) NOT NULL DEFAULT 0 CHECK (porcentaje > -100),
+    monto DECIMAL(10,2) NOT NULL DEFAULT 0,
+    prioridad INT NOT NULL DEFAULT 0,
+    activo BOOLEAN NOT NULL DEFAULT TRUE,
+    eliminado BOOLEAN NOT NULL DEFAULT FALSE,
+    fecha_registro TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (porcentaje <> 0 OR monto <> 0),
+    CHECK (tipo_regla <> 'TEMPORADA' OR (fecha_inicio IS NOT NULL AND fecha_fin IS NOT NULL AND fecha_fin >= fecha_inicio)),
+    CHECK (tipo_regla <> 'DIA_SEMANA' OR dias_semana ~ '^[1-7](,[1-7])*$'),
+    CHECK (tipo_regla <> 'HORARIO' OR (hora_inicio IS NOT NULL AND hora_fin IS NOT NULL AND hora_fin > hora_inicio)),
+    CHECK (tipo_regla <> 'OCUPACION' OR ocupacion_minima IS NOT NULL)
+);
+CREATE INDEX IF NOT EXISTS idx_regla_precio_alcance ON regla_precio(id_tipo_tour, id_sede) WHERE eliminado = FALSE;
+
+-- Precio unitario con el que se vendió cada pasaje; NULL en reservas anteriores a las reglas (se usa costo)
+ALTER TABLE pasajes_cantidad ADD COLUMN IF NOT EXISTS precio_unitario DECIMAL(10,2);
+
+-- Auditoría de las reglas que fijaron el precio de los pasajes de cada reserva
+CREATE TABLE IF NOT EXISTS regla_precio_aplicada (
+    id_regla_precio_aplicada SERIAL PRIMARY KEY,
+    id_reserva INT NOT NULL REFERENCES reserva(id_reserva) ON UPDATE CASCADE ON DELETE CASCADE,
+    id_tipo_pasaje INT NOT NULL REFERENCES tipo_pasaje(id_tipo_pasaje) ON UPDATE CASCADE ON DELETE RESTRICT,
+    id_regla_precio INT NOT NULL REFERENCES regla_precio(id_regla_precio) ON UPDATE CASCADE ON DELETE RESTRICT,
+    precio_antes DECIMAL(10,2) NOT NULL,
+    precio_despues DECIMAL(10,2) NOT NULL,
+    fecha_registro TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_regla_precio_aplicada_reserva ON regla_precio_aplicada(id_reserva);
+CREATE INDEX IF NOT EXISTS idx_regla_precio_aplicada_regla ON regla_precio_aplicada(id_regla_precio);
@@ -0,0 +1,39 @@
+-- 018. Precios y cobros en soles (PEN) y dólares (USD)
+-- La moneda contable de las reservas es el sol: los pasajes y paquetes con precio en dólares se
+-- convierten al venderse con el tipo de cambio del día, y los pagos en dólares se registran en su
+-- moneda junto con el tipo de cambio usado y el monto convertido a soles.
+
+-- Tipo de cambio diario: soles por unidad de la moneda extranjera
+CREATE TABLE IF NOT EXISTS tipo_cambio (
+    id_tipo_cambio SERIAL PRIMARY KEY,
+    fecha DATE NOT NULL,
+    moneda VARCHAR(3) NOT NULL CHECK (moneda IN ('USD')),
+    valor DECIMAL(10,4) NOT NULL CHECK (valor > 0),
+    origen VARCHAR(10) NOT NULL DEFAULT 'MANUAL' CHECK (origen IN ('MANUAL', 'ARCHIVO')),
+    fecha_registro TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (fecha, moneda)
+);
+
+-- Moneda de las listas de precios
+ALTER TABLE tipo_pasaje ADD COLUMN IF NOT EXISTS moneda VARCHAR(3) NOT NULL DEFAULT 'PEN' CHECK (moneda IN ('PEN', 'USD'));
+ALTER TABLE paquete_pasajes ADD COLUMN IF NOT EXISTS moneda VARCHAR(3) NOT NULL DEFAULT 'PEN' CHECK (moneda IN ('PEN', 'USD'));
+
+-- Tipo de cambio con el que se convirtieron a soles los precios en dólares de la reserva
+ALTER TABLE reserva ADD COLUMN IF NOT EXISTS tipo_cambio DECIMAL(10,4);
+
+-- Precio unitario en soles con el que se vendió cada paquete; NULL en reservas anteriores (se usa precio_total)
+ALTER TABLE paquete_pasaje_detalle ADD COLUMN IF NOT EXISTS precio_unitario DECIMAL(10,2);
+
+-- Moneda de los precios auditados por las reglas de precio
+ALTER TABLE regla_precio_aplicada ADD COLUMN IF NOT EXISTS moneda VARCHAR(3) NOT NULL DEFAULT 'PEN';
+
+-- Moneda del pago, tipo de cambio usado y monto equivalente en soles
+ALTER TABLE pago ADD COLUMN IF NOT EXISTS moneda VARCHAR(3) NOT NULL DEFAULT 'PEN' CHECK (moneda IN ('PEN', 'USD'));
+ALTER TABLE pago ADD COLUMN IF NOT EXISTS tipo_cambio DECIMAL(10,4) NOT NULL DEFAULT 1;
+ALTER TABLE pago ADD COLUMN IF NOT EXISTS monto_convertido DECIMAL(10,2);
+UPDATE pago SET monto_convertido = monto WHERE monto_convertido IS NULL;
+CREATE INDEX IF NOT EXISTS idx_pago_moneda ON pago(moneda);
+
+-- Moneda en la que se emite el comprobante y tipo de cambio usado para convertir los importes
+ALTER TABLE comprobante_pago ADD COLUMN IF NOT EXISTS moneda VARCHAR(3) NOT NULL DEFAULT 'PEN' CHECK (moneda IN ('PEN', 'USD'));
+ALTER TABLE comprobante_pago ADD COLUMN IF NOT EXISTS tipo_cambio DECIMAL(10,4) NOT NULL DEFAULT 1;
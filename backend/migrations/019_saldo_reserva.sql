@@ -0,0 +1,8 @@
+-- 019. Adelantos y saldo de reservas
+-- Cada sede fija el porcentaje del total que se cobra como adelanto. Con el adelanto pagado la
+-- reserva deja de vencer; con el total pagado pasa a CONFIRMADA. Una reserva puede cubrirse con
+-- varios pagos, cada uno con su propio método de pago.
+ALTER TABLE sede ADD COLUMN IF NOT EXISTS porcentaje_adelanto DECIMAL(5,2) NOT NULL DEFAULT 30
+    CHECK (porcentaje_adelanto BETWEEN 0 AND 100);
+
+CREATE INDEX IF NOT EXISTS idx_pago_reserva_procesado ON pago(id_reserva) WHERE estado = 'PROCESADO' AND eliminado = FALSE;
@@ -0,0 +1,38 @@
+-- 020. Caja de los vendedores
+-- Un vendedor abre una sesión de caja con un fondo inicial en efectivo antes de cobrar; cada pago que
+-- registra queda asociado a su caja abierta. Al cerrar, se comparan por método de pago y moneda los
+-- montos esperados (pagos procesados más el fondo inicial en el método de efectivo) con los contados.
+
+CREATE TABLE IF NOT EXISTS caja_sesion (
+    id_caja_sesion SERIAL PRIMARY KEY,
+    id_usuario INT NOT NULL REFERENCES usuario(id_usuario),
+    id_sede INT NOT NULL REFERENCES sede(id_sede),
+    id_metodo_efectivo INT NOT NULL REFERENCES metodo_pago(id_metodo_pago),
+    monto_inicial DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (monto_inicial >= 0),
+    fecha_apertura TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    fecha_cierre TIMESTAMP,
+    estado VARCHAR(10) NOT NULL DEFAULT 'ABIERTA' CHECK (estado IN ('ABIERTA', 'CERRADA')),
+    observaciones_apertura TEXT,
+    observaciones_cierre TEXT
+);
+
+-- Un usuario solo puede tener una caja abierta a la vez
+CREATE UNIQUE INDEX IF NOT EXISTS idx_caja_sesion_abierta ON caja_sesion(id_usuario) WHERE estado = 'ABIERTA';
+CREATE INDEX IF NOT EXISTS idx_caja_sesion_sede_cierre ON caja_sesion(id_sede, fecha_cierre);
+
+-- Cuadre del cierre por método de pago y moneda
+CREATE TABLE IF NOT EXISTS caja_cierre_detalle (
+    id_caja_cierre_detalle SERIAL PRIMARY KEY,
+    id_caja_sesion INT NOT NULL REFERENCES caja_sesion(id_caja_sesion),
+    id_metodo_pago INT NOT NULL REFERENCES metodo_pago(id_metodo_pago),
+    moneda VARCHAR(3) NOT NULL DEFAULT 'PEN' CHECK (moneda IN ('PEN', 'USD')),
+    cantidad_pagos INT NOT NULL DEFAULT 0,
+    monto_esperado DECIMAL(10,2) NOT NULL,
+    monto_contado DECIMAL(10,2) NOT NULL,
+    diferencia DECIMAL(10,2) NOT NULL,
+    UNIQUE (id_caja_sesion, id_metodo_pago, moneda)
+);
+
+-- Caja en la que se cobró cada pago; NULL en pagos en línea o anteriores a las cajas
+ALTER TABLE pago ADD COLUMN IF NOT EXISTS id_caja_sesion INT REFERENCES caja_sesion(id_caja_sesion);
+CREATE INDEX IF NOT EXISTS idx_pago_caja_sesion ON pago(id_caja_sesion);
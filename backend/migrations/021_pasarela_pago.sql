@@ -0,0 +1,22 @@
+-- 021. Pasarelas de pago en línea
+-- Los pagos en línea pueden venir de más de una pasarela (Mercado Pago, Izipay o la simulada de desarrollo).
+-- Un ID de transacción solo es único dentro de su pasarela, así que los pagos y las notificaciones registran
+-- de qué pasarela vienen; lo existente es de Mercado Pago.
+
+ALTER TABLE pago ADD COLUMN IF NOT EXISTS pasarela VARCHAR(20);
+UPDATE pago SET pasarela = 'MERCADO_PAGO' WHERE id_transaccion_externa IS NOT NULL AND pasarela IS NULL;
+
+DROP INDEX IF EXISTS idx_pago_transaccion_externa;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pago_pasarela_transaccion
+    ON pago(pasarela, id_transaccion_externa) WHERE id_transaccion_externa IS NOT NULL AND eliminado = FALSE;
+
+-- Las notificaciones se registran en webhook_evento_mercadopago hasta que la 023 la renombra
+DO $$
+BEGIN
+    IF to_regclass('webhook_evento_mercadopago') IS NOT NULL THEN
+        ALTER TABLE webhook_evento_mercadopago ADD COLUMN IF NOT EXISTS pasarela VARCHAR(20) NOT NULL DEFAULT 'MERCADO_PAGO';
+        ALTER TABLE webhook_evento_mercadopago DROP CONSTRAINT IF EXISTS webhook_evento_mercadopago_id_pago_externo_estado_pago_key;
+        CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_evento_pasarela_pago_estado
+            ON webhook_evento_mercadopago(pasarela, id_pago_externo, estado_pago);
+    END IF;
+END $$;
@@ -0,0 +1,68 @@
+-- 022. Conciliación de pagos en línea con las pasarelas
+-- Las notificaciones de las pasarelas pueden perderse. Un proceso periódico busca en la pasarela los pagos de
+-- las reservas web recientes y corrige lo que no llegó (pagos aprobados sin registrar, devoluciones y
+-- contracargos sin revertir, retenciones vencidas); cada ejecución guarda las diferencias encontradas para
+-- que finanzas revise las que no se pudieron corregir solas.
+
+-- Pasarela con la que se cobra cada reserva web
+-- Al agregar la columna, cada reserva toma la pasarela de su primer pago en línea; las reservas web que aún no
+-- tenían pagos se cobraron con Mercado Pago
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+                   WHERE table_name = 'reserva' AND column_name = 'pasarela') THEN
+        ALTER TABLE reserva ADD COLUMN pasarela VARCHAR(20);
+        WITH primer_pago AS (
+            SELECT DISTINCT ON (id_reserva) id_reserva, pasarela
+            FROM pago
+            WHERE pasarela IS NOT NULL
+            ORDER BY id_reserva, id_pago
+        )
+        UPDATE reserva r SET pasarela = pp.pasarela
+        FROM primer_pago pp
+        WHERE pp.id_reserva = r.id_reserva;
+        UPDATE reserva SET pasarela = 'MERCADO_PAGO' WHERE pasarela IS NULL AND fecha_expiracion IS NOT NULL;
+    END IF;
+END $$;
+CREATE INDEX IF NOT EXISTS idx_reserva_pasarela_fecha ON reserva(fecha_reserva) WHERE pasarela IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS conciliacion_pago (
+    id_conciliacion SERIAL PRIMARY KEY,
+    origen VARCHAR(10) NOT NULL DEFAULT 'AUTOMATICA' CHECK (origen IN ('AUTOMATICA', 'MANUAL')),
+    id_usuario INT REFERENCES usuario(id_usuario),
+    dias_revisados INT NOT NULL,
+    fecha_inicio TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    fecha_fin TIMESTAMP,
+    estado VARCHAR(12) NOT NULL DEFAULT 'EN_CURSO' CHECK (estado IN ('EN_CURSO', 'COMPLETADA', 'ERROR')),
+    reservas_revisadas INT NOT NULL DEFAULT 0,
+    discrepancias INT NOT NULL DEFAULT 0,
+    corregidas INT NOT NULL DEFAULT 0,
+    detalle TEXT
+);
+
+-- Solo una conciliación a la vez, aunque el backend corra en varias instancias
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conciliacion_pago_en_curso ON conciliacion_pago((estado)) WHERE estado = 'EN_CURSO';
+CREATE INDEX IF NOT EXISTS idx_conciliacion_pago_fecha ON conciliacion_pago(fecha_inicio);
+
+CREATE TABLE IF NOT EXISTS conciliacion_discrepancia (
+    id_discrepancia SERIAL PRIMARY KEY,
+    id_conciliacion INT NOT NULL REFERENCES conciliacion_pago(id_conciliacion),
+    id_reserva INT NOT NULL REFERENCES reserva(id_reserva),
+    pasarela VARCHAR(20) NOT NULL,
+    id_pago_externo VARCHAR(50),
+    id_pago INT REFERENCES pago(id_pago),
+    tipo VARCHAR(30) NOT NULL,
+    estado_pasarela VARCHAR(30),
+    estado_local VARCHAR(20),
+    monto_pasarela DECIMAL(10,2),
+    moneda_pasarela VARCHAR(3),
+    monto_local DECIMAL(10,2),
+    moneda_local VARCHAR(3),
+    accion VARCHAR(20) NOT NULL,
+    resultado VARCHAR(20) NOT NULL,
+    detalle TEXT,
+    fecha_registro TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_conciliacion_discrepancia_conciliacion ON conciliacion_discrepancia(id_conciliacion);
+CREATE INDEX IF NOT EXISTS idx_conciliacion_discrepancia_reserva ON conciliacion_discrepancia(id_reserva);
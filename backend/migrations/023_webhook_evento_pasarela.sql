@@ -0,0 +1,12 @@
+-- 023. Notificaciones de todas las pasarelas de pago
+-- webhook_evento_mercadopago registra desde la 021 las notificaciones de cualquier pasarela; se renombra una
+-- sola vez a webhook_evento_pasarela junto con su secuencia y su llave primaria. La 003 y la 021 ya no actúan
+-- sobre la tabla después del cambio de nombre
+DO $$
+BEGIN
+    IF to_regclass('webhook_evento_mercadopago') IS NOT NULL AND to_regclass('webhook_evento_pasarela') IS NULL THEN
+        ALTER TABLE webhook_evento_mercadopago RENAME TO webhook_evento_pasarela;
+        ALTER SEQUENCE IF EXISTS webhook_evento_mercadopago_id_evento_seq RENAME TO webhook_evento_pasarela_id_evento_seq;
+        ALTER INDEX IF EXISTS webhook_evento_mercadopago_pkey RENAME TO webhook_evento_pasarela_pkey;
+    END IF;
+END $$;
@@ -0,0 +1,241 @@
+package entidades_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"testing"
+	"time"
+)
+
+// TestValidacionAbrirCaja prueba la validación de los datos de apertura de caja
+func TestValidacionAbrirCaja(t *testing.T) {
+	utils.InitValidator()
+
+	tests := []struct {
+		nombre        string
+		caja          entidades.AbrirCajaRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre:        "Apertura válida",
+			caja:          entidades.AbrirCajaRequest{IDMetodoEfectivo: 1, MontoInicial: entidades.Soles(20000)},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Apertura sin fondo inicial",
+			caja:          entidades.AbrirCajaRequest{IDMetodoEfectivo: 1},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Apertura sin método de efectivo",
+			caja:          entidades.AbrirCajaRequest{MontoInicial: entidades.Soles(20000)},
+			debeSerValido: false,
+			campoInvalido: "id_metodo_efectivo",
+		},
+		{
+			nombre:        "Fondo inicial negativo",
+			caja:          entidades.AbrirCajaRequest{IDMetodoEfectivo: 1, MontoInicial: entidades.Soles(-100)},
+			debeSerValido: false,
+			campoInvalido: "monto_inicial",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.caja)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
+
+// TestValidacionCerrarCaja prueba la validación de los conteos al cerrar la caja
+func TestValidacionCerrarCaja(t *testing.T) {
+	utils.InitValidator()
+
+	tests := []struct {
+		nombre        string
+		cierre        entidades.CerrarCajaRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre: "Cierre válido",
+			cierre: entidades.CerrarCajaRequest{Conteos: []entidades.ConteoCajaRequest{
+				{IDMetodoPago: 1, MontoContado: entidades.Soles(50000)},
+				{IDMetodoPago: 1, Moneda: "USD", MontoContado: entidades.NuevoDinero(4000, "USD")},
+			}},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Cierre sin conteos",
+			cierre:        entidades.CerrarCajaRequest{},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Conteo sin método de pago",
+			cierre: entidades.CerrarCajaRequest{Conteos: []entidades.ConteoCajaRequest{
+				{MontoContado: entidades.Soles(50000)},
+			}},
+			debeSerValido: false,
+			campoInvalido: "id_metodo_pago",
+		},
+		{
+			nombre: "Conteo en moneda no aceptada",
+			cierre: entidades.CerrarCajaRequest{Conteos: []entidades.ConteoCajaRequest{
+				{IDMetodoPago: 1, Moneda: "EUR", MontoContado: entidades.Soles(50000)},
+			}},
+			debeSerValido: false,
+			campoInvalido: "moneda",
+		},
+		{
+			nombre: "Conteo negativo",
+			cierre: entidades.CerrarCajaRequest{Conteos: []entidades.ConteoCajaRequest{
+				{IDMetodoPago: 1, MontoContado: entidades.Soles(-100)},
+			}},
+			debeSerValido: false,
+			campoInvalido: "monto_contado",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.cierre)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
+
+// TestCalcularCierreCaja prueba el cuadre de lo contado contra los pagos y el fondo inicial
+func TestCalcularCierreCaja(t *testing.T) {
+	const efectivo, tarjeta = 1, 2
+	movimientos := []entidades.MovimientoCaja{
+		{IDMetodoPago: efectivo, Moneda: "PEN", CantidadPagos: 3, Total: entidades.Soles(45000)},
+		{IDMetodoPago: efectivo, Moneda: "USD", CantidadPagos: 1, Total: entidades.NuevoDinero(5000, "USD")},
+		{IDMetodoPago: tarjeta, Moneda: "PEN", CantidadPagos: 2, Total: entidades.Soles(30000)},
+	}
+	conteos := []entidades.ConteoCajaRequest{
+		{IDMetodoPago: efectivo, MontoContado: entidades.Soles(54000)},
+		{IDMetodoPago: efectivo, Moneda: "USD", MontoContado: entidades.NuevoDinero(5000, "USD")},
+		{IDMetodoPago: 3, MontoContado: entidades.Soles(1000)},
+	}
+
+	detalles, err := entidades.CalcularCierreCaja(movimientos, entidades.Soles(10000), efectivo, conteos)
+	if err != nil {
+		t.Fatalf("No se esperaba error: %v", err)
+	}
+
+	esperados := []struct {
+		idMetodoPago  int
+		moneda        string
+		cantidadPagos int
+		esperado      entidades.Dinero
+		contado       entidades.Dinero
+		diferencia    entidades.Dinero
+	}{
+		// Efectivo en soles: 450.00 cobrados más 100.00 de fondo, contados 540.00
+		{efectivo, "PEN", 3, entidades.Soles(55000), entidades.Soles(54000), entidades.Soles(-1000)},
+		{efectivo, "USD", 1, entidades.NuevoDinero(5000, "USD"), entidades.NuevoDinero(5000, "USD"), entidades.NuevoDinero(0, "USD")},
+		// Tarjeta sin conteo: se considera contada en cero
+		{tarjeta, "PEN", 2, entidades.Soles(30000), entidades.Soles(0), entidades.Soles(-30000)},
+		// Conteo sin pagos esperados: sobrante
+		{3, "PEN", 0, entidades.Soles(0), entidades.Soles(1000), entidades.Soles(1000)},
+	}
+
+	if len(detalles) != len(esperados) {
+		t.Fatalf("Se esperaban %d líneas de cuadre, se obtuvieron %d", len(esperados), len(detalles))
+	}
+
+	for i, esperado := range esperados {
+		detalle := detalles[i]
+		if detalle.IDMetodoPago != esperado.idMetodoPago || detalle.Moneda != esperado.moneda {
+			t.Errorf("Línea %d: se esperaba método %d en %s, se obtuvo método %d en %s",
+				i, esperado.idMetodoPago, esperado.moneda, detalle.IDMetodoPago, detalle.Moneda)
+			continue
+		}
+		if detalle.CantidadPagos != esperado.cantidadPagos {
+			t.Errorf("Línea %d: cantidad de pagos esperada %d, obtenida %d", i, esperado.cantidadPagos, detalle.CantidadPagos)
+		}
+		if !detalle.MontoEsperado.Igual(esperado.esperado) {
+			t.Errorf("Línea %d: monto esperado %s, obtenido %s", i, esperado.esperado, detalle.MontoEsperado)
+		}
+		if !detalle.MontoContado.Igual(esperado.contado) {
+			t.Errorf("Línea %d: monto contado %s, obtenido %s", i, esperado.contado, detalle.MontoContado)
+		}
+		if !detalle.Diferencia.Igual(esperado.diferencia) {
+			t.Errorf("Línea %d: diferencia esperada %s, obtenida %s", i, esperado.diferencia, detalle.Diferencia)
+		}
+	}
+}
+
+// TestCalcularCierreCajaConteoDuplicado prueba que no se acepten dos conteos del mismo método y moneda
+func TestCalcularCierreCajaConteoDuplicado(t *testing.T) {
+	conteos := []entidades.ConteoCajaRequest{
+		{IDMetodoPago: 1, MontoContado: entidades.Soles(1000)},
+		{IDMetodoPago: 1, Moneda: "PEN", MontoContado: entidades.Soles(2000)},
+	}
+
+	if _, err := entidades.CalcularCierreCaja(nil, entidades.Soles(0), 1, conteos); err == nil {
+		t.Error("Se esperaba error por conteo duplicado")
+	}
+}
+
+// TestAgruparCierresCaja prueba la agrupación de cierres por sede y día con sus totales por moneda
+func TestAgruparCierresCaja(t *testing.T) {
+	cierre := func(idSede int, fecha time.Time, esperado, contado int64) *entidades.CajaSesion {
+		return &entidades.CajaSesion{
+			IDSede:      idSede,
+			FechaCierre: &fecha,
+			Detalle: []*entidades.DetalleCierreCaja{{
+				Moneda:        "PEN",
+				MontoEsperado: entidades.Soles(esperado),
+				MontoContado:  entidades.Soles(contado),
+				Diferencia:    entidades.Soles(contado - esperado),
+			}},
+		}
+	}
+
+	cierres := []*entidades.CajaSesion{
+		cierre(1, time.Date(2026, 3, 10, 18, 0, 0, 0, time.UTC), 10000, 10000),
+		cierre(1, time.Date(2026, 3, 10, 20, 30, 0, 0, time.UTC), 20000, 19500),
+		cierre(1, time.Date(2026, 3, 11, 18, 0, 0, 0, time.UTC), 5000, 5000),
+		cierre(2, time.Date(2026, 3, 10, 19, 0, 0, 0, time.UTC), 8000, 8100),
+	}
+
+	dias := entidades.AgruparCierresCaja(cierres)
+	if len(dias) != 3 {
+		t.Fatalf("Se esperaban 3 grupos de sede y día, se obtuvieron %d", len(dias))
+	}
+
+	primero := dias[0]
+	if primero.IDSede != 1 || len(primero.Cierres) != 2 {
+		t.Fatalf("El primer grupo debería tener los 2 cierres de la sede 1 del día 10, tiene %d de la sede %d",
+			len(primero.Cierres), primero.IDSede)
+	}
+	if len(primero.Totales) != 1 {
+		t.Fatalf("Se esperaba un total por moneda, se obtuvieron %d", len(primero.Totales))
+	}
+	total := primero.Totales[0]
+	if !total.Esperado.Igual(entidades.Soles(30000)) || !total.Contado.Igual(entidades.Soles(29500)) ||
+		!total.Diferencia.Igual(entidades.Soles(-500)) {
+		t.Errorf("Totales inesperados: esperado %s, contado %s, diferencia %s", total.Esperado, total.Contado, total.Diferencia)
+	}
+
+	if dias[2].IDSede != 2 || !dias[2].Totales[0].Diferencia.Igual(entidades.Soles(100)) {
+		t.Errorf("El último grupo debería ser la sede 2 con un sobrante de 1.00")
+	}
+}
@@ -0,0 +1,101 @@
+package entidades_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"testing"
+	"time"
+)
+
+// TestValidacionNuevoCodigoPromocional prueba la validación de un código promocional
+func TestValidacionNuevoCodigoPromocional(t *testing.T) {
+	utils.InitValidator()
+
+	inicio := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fin := inicio.AddDate(0, 3, 0)
+	cero := 0
+
+	tests := []struct {
+		nombre        string
+		codigo        entidades.NuevoCodigoPromocionalRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre: "Porcentaje válido",
+			codigo: entidades.NuevoCodigoPromocionalRequest{
+				Codigo: "VERANO10", TipoDescuento: "PORCENTAJE", Porcentaje: 10, FechaInicio: inicio, FechaFin: fin,
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Monto fijo válido",
+			codigo: entidades.NuevoCodigoPromocionalRequest{
+				Codigo: "MENOS20", TipoDescuento: "MONTO_FIJO", MontoDescuento: entidades.DineroDesdeFloat(20), FechaInicio: inicio, FechaFin: fin,
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Porcentaje sin valor",
+			codigo: entidades.NuevoCodigoPromocionalRequest{
+				Codigo: "VERANO10", TipoDescuento: "PORCENTAJE", FechaInicio: inicio, FechaFin: fin,
+			},
+			debeSerValido: false,
+			campoInvalido: "porcentaje",
+		},
+		{
+			nombre: "Monto fijo sin monto",
+			codigo: entidades.NuevoCodigoPromocionalRequest{
+				Codigo: "MENOS20", TipoDescuento: "MONTO_FIJO", Porcentaje: 20, FechaInicio: inicio, FechaFin: fin,
+			},
+			debeSerValido: false,
+			campoInvalido: "monto_descuento",
+		},
+		{
+			nombre: "Porcentaje mayor a 100",
+			codigo: entidades.NuevoCodigoPromocionalRequest{
+				Codigo: "TODO", TipoDescuento: "PORCENTAJE", Porcentaje: 150, FechaInicio: inicio, FechaFin: fin,
+			},
+			debeSerValido: false,
+			campoInvalido: "porcentaje",
+		},
+		{
+			nombre: "Fin antes del inicio",
+			codigo: entidades.NuevoCodigoPromocionalRequest{
+				Codigo: "VERANO10", TipoDescuento: "PORCENTAJE", Porcentaje: 10, FechaInicio: fin, FechaFin: inicio,
+			},
+			debeSerValido: false,
+			campoInvalido: "fecha_fin",
+		},
+		{
+			nombre: "Límite de usos en cero",
+			codigo: entidades.NuevoCodigoPromocionalRequest{
+				Codigo: "VERANO10", TipoDescuento: "PORCENTAJE", Porcentaje: 10, FechaInicio: inicio, FechaFin: fin, UsosMaximos: &cero,
+			},
+			debeSerValido: false,
+			campoInvalido: "usos_maximos",
+		},
+		{
+			nombre: "Tipo de descuento desconocido",
+			codigo: entidades.NuevoCodigoPromocionalRequest{
+				Codigo: "VERANO10", TipoDescuento: "2X1", Porcentaje: 10, FechaInicio: inicio, FechaFin: fin,
+			},
+			debeSerValido: false,
+			campoInvalido: "tipo_descuento",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.codigo)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
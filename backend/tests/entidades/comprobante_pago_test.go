@@ -23,21 +23,53 @@ func TestValidacionNuevoComprobantePago(t *testing.T) {
 				IDSede:            2,
 				Tipo:              "FACTURA",
 				NumeroComprobante: "123456",
-				Subtotal:          200.0,
-				IGV:               36.0,
-				Total:             236.0,
+				Subtotal:          entidades.DineroDesdeFloat(200.0),
+				IGV:               entidades.DineroDesdeFloat(36.0),
+				Total:             entidades.DineroDesdeFloat(236.0),
 			},
 			debeSerValido: true,
 		},
+		{
+			nombre: "Comprobante sin número (numeración automática)",
+			comprobante: entidades.NuevoComprobantePagoRequest{
+				IDReserva: 1,
+				IDSede:    2,
+				Tipo:      "BOLETA",
+				Subtotal:  entidades.DineroDesdeFloat(100.0),
+				IGV:       entidades.DineroDesdeFloat(18.0),
+				Total:     entidades.DineroDesdeFloat(118.0),
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Comprobante sin montos (se calculan con la configuración de la sede)",
+			comprobante: entidades.NuevoComprobantePagoRequest{
+				IDReserva: 1,
+				IDSede:    2,
+				Tipo:      "BOLETA",
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Comprobante con monto negativo",
+			comprobante: entidades.NuevoComprobantePagoRequest{
+				IDReserva: 1,
+				IDSede:    2,
+				Tipo:      "BOLETA",
+				Total:     entidades.DineroDesdeFloat(-10.0),
+			},
+			debeSerValido: false,
+			campoInvalido: "total",
+		},
 		{
 			nombre: "Comprobante sin Tipo",
 			comprobante: entidades.NuevoComprobantePagoRequest{
 				IDReserva:         1,
 				IDSede:            2,
 				NumeroComprobante: "123456",
-				Subtotal:          200.0,
-				IGV:               36.0,
-				Total:             236.0,
+				Subtotal:          entidades.DineroDesdeFloat(200.0),
+				IGV:               entidades.DineroDesdeFloat(36.0),
+				Total:             entidades.DineroDesdeFloat(236.0),
 			},
 			debeSerValido: false,
 			campoInvalido: "tipo",
@@ -58,3 +90,78 @@ func TestValidacionNuevoComprobantePago(t *testing.T) {
 		})
 	}
 }
+
+// TestValidacionNuevaNotaComprobante verifica las validaciones de las notas de crédito y débito
+func TestValidacionNuevaNotaComprobante(t *testing.T) {
+	utils.InitValidator()
+
+	tests := []struct {
+		nombre        string
+		nota          entidades.NuevaNotaComprobanteRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre: "Nota de crédito parcial válida",
+			nota: entidades.NuevaNotaComprobanteRequest{
+				IDComprobanteReferencia: 1, Tipo: "NOTA_CREDITO", CodigoMotivo: "07",
+				Motivo: "Devolución de un pasaje", Subtotal: entidades.DineroDesdeFloat(50.0), IGV: entidades.DineroDesdeFloat(9.0), Total: entidades.DineroDesdeFloat(59.0),
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Nota de débito con número manual",
+			nota: entidades.NuevaNotaComprobanteRequest{
+				IDComprobanteReferencia: 1, Tipo: "NOTA_DEBITO", NumeroComprobante: "FD01-3", CodigoMotivo: "02",
+				Motivo: "Aumento en el valor", Subtotal: entidades.DineroDesdeFloat(10.0), IGV: entidades.DineroDesdeFloat(1.8), Total: entidades.DineroDesdeFloat(11.8),
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Tipo de nota inválido",
+			nota: entidades.NuevaNotaComprobanteRequest{
+				IDComprobanteReferencia: 1, Tipo: "BOLETA", CodigoMotivo: "01", Motivo: "Anulación", Total: entidades.DineroDesdeFloat(118.0),
+			},
+			debeSerValido: false,
+			campoInvalido: "tipo",
+		},
+		{
+			nombre: "Código de motivo no numérico",
+			nota: entidades.NuevaNotaComprobanteRequest{
+				IDComprobanteReferencia: 1, Tipo: "NOTA_CREDITO", CodigoMotivo: "A1", Motivo: "Anulación", Total: entidades.DineroDesdeFloat(118.0),
+			},
+			debeSerValido: false,
+			campoInvalido: "codigo_motivo",
+		},
+		{
+			nombre: "Nota sin motivo",
+			nota: entidades.NuevaNotaComprobanteRequest{
+				IDComprobanteReferencia: 1, Tipo: "NOTA_CREDITO", CodigoMotivo: "01", Total: entidades.DineroDesdeFloat(118.0),
+			},
+			debeSerValido: false,
+			campoInvalido: "motivo",
+		},
+		{
+			nombre: "Nota con total cero",
+			nota: entidades.NuevaNotaComprobanteRequest{
+				IDComprobanteReferencia: 1, Tipo: "NOTA_CREDITO", CodigoMotivo: "04", Motivo: "Descuento",
+			},
+			debeSerValido: false,
+			campoInvalido: "total",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.nota)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
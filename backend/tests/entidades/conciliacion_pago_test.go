@@ -0,0 +1,216 @@
+package entidades_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"testing"
+	"time"
+)
+
+// pagoPasarelaConciliacion arma un pago de la pasarela para la reserva 10
+func pagoPasarelaConciliacion(id, estado string, monto entidades.Dinero) *entidades.PagoPasarela {
+	return &entidades.PagoPasarela{
+		Pasarela:          entidades.PasarelaMercadoPago,
+		ID:                id,
+		Estado:            estado,
+		EstadoOriginal:    estado,
+		Monto:             monto,
+		ReferenciaExterna: "RESERVA-10",
+	}
+}
+
+// TestConciliarReserva prueba la comparación de los pagos de una reserva con los de su pasarela
+func TestConciliarReserva(t *testing.T) {
+	ahora := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	vencida := ahora.Add(-time.Minute)
+	vigente := ahora.Add(time.Minute)
+
+	tests := []struct {
+		nombre      string
+		reserva     entidades.ReservaConciliacion
+		pagos       []*entidades.PagoPasarela
+		esperadas   []string // Tipos de diferencia esperados
+		accionFinal string   // Acción de la última diferencia
+	}{
+		{
+			nombre:      "Pago aprobado sin registrar",
+			reserva:     entidades.ReservaConciliacion{Estado: "RESERVADO", FechaExpiracion: &vigente},
+			pagos:       []*entidades.PagoPasarela{pagoPasarelaConciliacion("1", entidades.EstadoPasarelaAprobado, entidades.Soles(10000))},
+			esperadas:   []string{entidades.DiscrepanciaPagoNoRegistrado},
+			accionFinal: entidades.AccionConciliacionConfirmarPago,
+		},
+		{
+			nombre: "Pago aprobado sin registrar en reserva con retención vencida no se expira",
+			reserva: entidades.ReservaConciliacion{
+				Estado: "RESERVADO", FechaExpiracion: &vencida,
+			},
+			pagos:       []*entidades.PagoPasarela{pagoPasarelaConciliacion("1", entidades.EstadoPasarelaAprobado, entidades.Soles(10000))},
+			esperadas:   []string{entidades.DiscrepanciaPagoNoRegistrado},
+			accionFinal: entidades.AccionConciliacionConfirmarPago,
+		},
+		{
+			nombre: "Pago registrado y aprobado no es diferencia",
+			reserva: entidades.ReservaConciliacion{Estado: "CONFIRMADA", Pagos: []*entidades.PagoLocalConciliacion{
+				{IDPago: 5, IDTransaccion: "1", Estado: "PROCESADO", Monto: entidades.Soles(10000)},
+			}},
+			pagos:     []*entidades.PagoPasarela{pagoPasarelaConciliacion("1", entidades.EstadoPasarelaAprobado, entidades.Soles(10000))},
+			esperadas: []string{},
+		},
+		{
+			nombre: "Pago contracargado en la pasarela y vigente aquí",
+			reserva: entidades.ReservaConciliacion{Estado: "CONFIRMADA", Pagos: []*entidades.PagoLocalConciliacion{
+				{IDPago: 5, IDTransaccion: "1", Estado: "PROCESADO", Monto: entidades.Soles(10000)},
+			}},
+			pagos:       []*entidades.PagoPasarela{pagoPasarelaConciliacion("1", entidades.EstadoPasarelaContracargo, entidades.Soles(10000))},
+			esperadas:   []string{entidades.DiscrepanciaPagoNoRevertido},
+			accionFinal: entidades.AccionConciliacionRevertirPago,
+		},
+		{
+			nombre: "Pago devuelto en ambos lados no es diferencia",
+			reserva: entidades.ReservaConciliacion{Estado: "CANCELADA", Pagos: []*entidades.PagoLocalConciliacion{
+				{IDPago: 5, IDTransaccion: "1", Estado: "DEVUELTO", Monto: entidades.Soles(10000)},
+			}},
+			pagos:     []*entidades.PagoPasarela{pagoPasarelaConciliacion("1", entidades.EstadoPasarelaDevuelto, entidades.Soles(10000))},
+			esperadas: []string{},
+		},
+		{
+			nombre: "Pago devuelto aquí y aprobado en la pasarela",
+			reserva: entidades.ReservaConciliacion{Estado: "CANCELADA", Pagos: []*entidades.PagoLocalConciliacion{
+				{IDPago: 5, IDTransaccion: "1", Estado: "DEVUELTO", Monto: entidades.Soles(10000)},
+			}},
+			pagos:       []*entidades.PagoPasarela{pagoPasarelaConciliacion("1", entidades.EstadoPasarelaAprobado, entidades.Soles(10000))},
+			esperadas:   []string{entidades.DiscrepanciaPagoRevertidoLocal},
+			accionFinal: entidades.AccionConciliacionRevisar,
+		},
+		{
+			nombre: "Monto registrado distinto al cobrado",
+			reserva: entidades.ReservaConciliacion{Estado: "CONFIRMADA", Pagos: []*entidades.PagoLocalConciliacion{
+				{IDPago: 5, IDTransaccion: "1", Estado: "PROCESADO", Monto: entidades.Soles(10000)},
+			}},
+			pagos:       []*entidades.PagoPasarela{pagoPasarelaConciliacion("1", entidades.EstadoPasarelaAprobado, entidades.NuevoDinero(10000, "USD"))},
+			esperadas:   []string{entidades.DiscrepanciaMontoDiferente},
+			accionFinal: entidades.AccionConciliacionRevisar,
+		},
+		{
+			nombre: "Pago registrado que la pasarela no conoce",
+			reserva: entidades.ReservaConciliacion{Estado: "CONFIRMADA", Pagos: []*entidades.PagoLocalConciliacion{
+				{IDPago: 5, IDTransaccion: "99", Estado: "PROCESADO", Monto: entidades.Soles(10000)},
+			}},
+			pagos:       []*entidades.PagoPasarela{},
+			esperadas:   []string{entidades.DiscrepanciaPagoNoEncontrado},
+			accionFinal: entidades.AccionConciliacionRevisar,
+		},
+		{
+			nombre:      "Retención vencida con pagos rechazados",
+			reserva:     entidades.ReservaConciliacion{Estado: "RESERVADO", FechaExpiracion: &vencida},
+			pagos:       []*entidades.PagoPasarela{pagoPasarelaConciliacion("1", entidades.EstadoPasarelaRechazado, entidades.Soles(10000))},
+			esperadas:   []string{entidades.DiscrepanciaRetencionVencida},
+			accionFinal: entidades.AccionConciliacionExpirarReserva,
+		},
+		{
+			nombre: "Retención vencida con pago ya registrado se propone expirar",
+			reserva: entidades.ReservaConciliacion{Estado: "RESERVADO", FechaExpiracion: &vencida, Pagos: []*entidades.PagoLocalConciliacion{
+				{IDPago: 5, IDTransaccion: "1", Estado: "PROCESADO", Monto: entidades.Soles(1000)},
+			}},
+			pagos:       []*entidades.PagoPasarela{pagoPasarelaConciliacion("1", entidades.EstadoPasarelaAprobado, entidades.Soles(1000))},
+			esperadas:   []string{entidades.DiscrepanciaRetencionVencida},
+			accionFinal: entidades.AccionConciliacionExpirarReserva,
+		},
+		{
+			nombre:    "Retención vencida con pago pendiente en la pasarela no se expira",
+			reserva:   entidades.ReservaConciliacion{Estado: "RESERVADO", FechaExpiracion: &vencida},
+			pagos:     []*entidades.PagoPasarela{pagoPasarelaConciliacion("1", entidades.EstadoPasarelaPendiente, entidades.Soles(10000))},
+			esperadas: []string{},
+		},
+		{
+			nombre:    "Retención vigente sin pagos no es diferencia",
+			reserva:   entidades.ReservaConciliacion{Estado: "RESERVADO", FechaExpiracion: &vigente},
+			pagos:     []*entidades.PagoPasarela{},
+			esperadas: []string{},
+		},
+		{
+			nombre:  "Pagos de otra reserva se ignoran",
+			reserva: entidades.ReservaConciliacion{Estado: "RESERVADO", FechaExpiracion: &vigente},
+			pagos: []*entidades.PagoPasarela{{
+				ID: "1", Estado: entidades.EstadoPasarelaAprobado, Monto: entidades.Soles(10000), ReferenciaExterna: "RESERVA-11",
+			}},
+			esperadas: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nombre, func(t *testing.T) {
+			reserva := tt.reserva
+			reserva.IDReserva = 10
+			reserva.Pasarela = entidades.PasarelaMercadoPago
+
+			discrepancias := entidades.ConciliarReserva(&reserva, tt.pagos, ahora)
+
+			if len(discrepancias) != len(tt.esperadas) {
+				t.Fatalf("Se esperaban %d diferencias, se obtuvieron %d: %+v", len(tt.esperadas), len(discrepancias), discrepancias)
+			}
+			for i, tipo := range tt.esperadas {
+				if discrepancias[i].Tipo != tipo {
+					t.Errorf("Diferencia %d: se esperaba %s, se obtuvo %s", i, tipo, discrepancias[i].Tipo)
+				}
+				if discrepancias[i].IDReserva != 10 || discrepancias[i].Pasarela != entidades.PasarelaMercadoPago {
+					t.Errorf("Diferencia %d sin reserva o pasarela: %+v", i, discrepancias[i])
+				}
+			}
+			if len(discrepancias) > 0 {
+				ultima := discrepancias[len(discrepancias)-1]
+				if ultima.Accion != tt.accionFinal {
+					t.Errorf("Se esperaba acción %s, se obtuvo %s", tt.accionFinal, ultima.Accion)
+				}
+				if ultima.Corregible() != (tt.accionFinal != entidades.AccionConciliacionRevisar) {
+					t.Errorf("Corregible() inconsistente con la acción %s", ultima.Accion)
+				}
+			}
+		})
+	}
+}
+
+// TestConciliarReservaCorrigePago prueba que la diferencia lleva el pago de la pasarela con el que se corrige
+func TestConciliarReservaCorrigePago(t *testing.T) {
+	pago := pagoPasarelaConciliacion("1", entidades.EstadoPasarelaAprobado, entidades.NuevoDinero(3000, "USD"))
+	reserva := &entidades.ReservaConciliacion{IDReserva: 10, Estado: "EXPIRADA", Pasarela: entidades.PasarelaMercadoPago}
+
+	discrepancias := entidades.ConciliarReserva(reserva, []*entidades.PagoPasarela{pago}, time.Now())
+	if len(discrepancias) != 1 {
+		t.Fatalf("Se esperaba 1 diferencia, se obtuvieron %d", len(discrepancias))
+	}
+
+	discrepancia := discrepancias[0]
+	if discrepancia.PagoPasarela != pago || discrepancia.IDPagoExterno != "1" {
+		t.Errorf("La diferencia no lleva el pago de la pasarela: %+v", discrepancia)
+	}
+	if discrepancia.MontoPasarela == nil || !discrepancia.MontoPasarela.Igual(entidades.NuevoDinero(3000, "USD")) {
+		t.Errorf("Monto de la pasarela inesperado: %v", discrepancia.MontoPasarela)
+	}
+	if discrepancia.MontoLocal != nil || discrepancia.IDPago != nil {
+		t.Errorf("Un pago sin registrar no debe tener monto ni pago local: %+v", discrepancia)
+	}
+}
+
+// TestResumirDiscrepancias prueba el resumen por tipo del reporte de conciliación
+func TestResumirDiscrepancias(t *testing.T) {
+	discrepancias := []*entidades.DiscrepanciaConciliacion{
+		{Tipo: entidades.DiscrepanciaPagoNoRegistrado, Resultado: entidades.ResultadoConciliacionCorregido},
+		{Tipo: entidades.DiscrepanciaMontoDiferente, Resultado: entidades.ResultadoConciliacionRevisar},
+		{Tipo: entidades.DiscrepanciaPagoNoRegistrado, Resultado: entidades.ResultadoConciliacionRevisar},
+		{Tipo: entidades.DiscrepanciaPagoNoRegistrado, Resultado: entidades.ResultadoConciliacionError},
+	}
+
+	resumen := entidades.ResumirDiscrepancias(discrepancias)
+	if len(resumen) != 2 {
+		t.Fatalf("Se esperaban 2 tipos, se obtuvieron %d", len(resumen))
+	}
+
+	noRegistrado := resumen[0]
+	if noRegistrado.Tipo != entidades.DiscrepanciaPagoNoRegistrado || noRegistrado.Cantidad != 3 ||
+		noRegistrado.Corregidas != 1 || noRegistrado.Revisar != 1 || noRegistrado.Errores != 1 {
+		t.Errorf("Resumen inesperado: %+v", noRegistrado)
+	}
+	if resumen[1].Tipo != entidades.DiscrepanciaMontoDiferente || resumen[1].Revisar != 1 {
+		t.Errorf("Resumen inesperado: %+v", resumen[1])
+	}
+}
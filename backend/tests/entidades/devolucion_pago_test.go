@@ -0,0 +1,115 @@
+package entidades_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"testing"
+)
+
+// TestValidacionNuevaDevolucionPago prueba la validación de una solicitud de devolución
+func TestValidacionNuevaDevolucionPago(t *testing.T) {
+	utils.InitValidator()
+
+	tests := []struct {
+		nombre        string
+		devolucion    entidades.NuevaDevolucionPagoRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre: "Devolución válida",
+			devolucion: entidades.NuevaDevolucionPagoRequest{
+				IDPago:          1,
+				Motivo:          "Cliente no pudo viajar",
+				MontoDevolucion: entidades.DineroDesdeFloat(50.0),
+				CancelarReserva: true,
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Devolución sin pago",
+			devolucion: entidades.NuevaDevolucionPagoRequest{
+				Motivo:          "Cliente no pudo viajar",
+				MontoDevolucion: entidades.DineroDesdeFloat(50.0),
+			},
+			debeSerValido: false,
+			campoInvalido: "id_pago",
+		},
+		{
+			nombre: "Devolución sin motivo",
+			devolucion: entidades.NuevaDevolucionPagoRequest{
+				IDPago:          1,
+				MontoDevolucion: entidades.DineroDesdeFloat(50.0),
+			},
+			debeSerValido: false,
+			campoInvalido: "motivo",
+		},
+		{
+			nombre: "Devolución con monto negativo",
+			devolucion: entidades.NuevaDevolucionPagoRequest{
+				IDPago:          1,
+				Motivo:          "Cliente no pudo viajar",
+				MontoDevolucion: entidades.DineroDesdeFloat(-10.0),
+			},
+			debeSerValido: false,
+			campoInvalido: "monto_devolucion",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.devolucion)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
+
+// TestValidacionEjecutarDevolucion prueba la validación del método de ejecución de una devolución
+func TestValidacionEjecutarDevolucion(t *testing.T) {
+	utils.InitValidator()
+
+	tests := []struct {
+		nombre        string
+		ejecucion     entidades.EjecutarDevolucionRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre:        "Ejecución manual",
+			ejecucion:     entidades.EjecutarDevolucionRequest{MetodoEjecucion: "MANUAL"},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Ejecución por Mercado Pago",
+			ejecucion:     entidades.EjecutarDevolucionRequest{MetodoEjecucion: "MERCADO_PAGO"},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Método de ejecución desconocido",
+			ejecucion:     entidades.EjecutarDevolucionRequest{MetodoEjecucion: "TRANSFERENCIA"},
+			debeSerValido: false,
+			campoInvalido: "metodo_ejecucion",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.ejecucion)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package entidades_test
+
+import (
+	"encoding/json"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"testing"
+)
+
+// TestParseDinero verifica la lectura exacta de montos decimales
+func TestParseDinero(t *testing.T) {
+	tests := []struct {
+		nombre     string
+		texto      string
+		esperado   string
+		debeFallar bool
+	}{
+		{nombre: "Entero", texto: "118", esperado: "118.00"},
+		{nombre: "Un decimal", texto: "35.3", esperado: "35.30"},
+		{nombre: "Columna DECIMAL", texto: "0.10", esperado: "0.10"},
+		{nombre: "Negativo", texto: "-20.35", esperado: "-20.35"},
+		{nombre: "Sin parte entera", texto: ".5", esperado: "0.50"},
+		{nombre: "Redondeo hacia arriba", texto: "10.005", esperado: "10.01"},
+		{nombre: "Redondeo hacia abajo", texto: "10.0049", esperado: "10.00"},
+		{nombre: "Negativo con redondeo", texto: "-10.005", esperado: "-10.01"},
+		{nombre: "Vacío", texto: "", debeFallar: true},
+		{nombre: "Solo punto", texto: ".", debeFallar: true},
+		{nombre: "Texto", texto: "diez", debeFallar: true},
+		{nombre: "Separador de miles", texto: "1,200.00", debeFallar: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			monto, err := entidades.ParseDinero(tc.texto)
+			if tc.debeFallar {
+				if err == nil {
+					t.Errorf("Esperaba un error para %q, pero se leyó %s", tc.texto, monto)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error inesperado: %v", err)
+			}
+			if monto.String() != tc.esperado {
+				t.Errorf("Esperaba %s, obtuvo %s", tc.esperado, monto)
+			}
+		})
+	}
+}
+
+// TestOperacionesDinero verifica que la aritmética sea exacta al céntimo
+func TestOperacionesDinero(t *testing.T) {
+	// 0.1 + 0.2 en float64 no es 0.3; en céntimos sí
+	suma := entidades.DineroDesdeFloat(0.1).Sumar(entidades.DineroDesdeFloat(0.2))
+	if !suma.Igual(entidades.DineroDesdeFloat(0.3)) {
+		t.Errorf("Esperaba 0.30, obtuvo %s", suma)
+	}
+
+	pagado := entidades.Soles(11799)
+	total := entidades.Soles(11800)
+	if !pagado.MenorQue(total) || total.Comparar(total) != 0 {
+		t.Error("La comparación de montos no es exacta")
+	}
+	if saldo := total.Restar(pagado); saldo.Centimos() != 1 {
+		t.Errorf("Esperaba un saldo de 0.01, obtuvo %s", saldo)
+	}
+	if parte := total.Porcentaje(50); parte.String() != "59.00" {
+		t.Errorf("Esperaba 59.00, obtuvo %s", parte)
+	}
+	if parte := entidades.Soles(1001).Prorratear(1, 2); parte.String() != "5.01" {
+		t.Errorf("Esperaba 5.01 (mitad hacia arriba), obtuvo %s", parte)
+	}
+	if precio := entidades.Soles(3500).Multiplicar(3); precio.String() != "105.00" {
+		t.Errorf("Esperaba 105.00, obtuvo %s", precio)
+	}
+
+	// Un monto sin moneda es PEN
+	if !entidades.Soles(100).Igual(entidades.NuevoDinero(100, "pen")) {
+		t.Error("Un monto sin moneda debería ser igual a uno en PEN")
+	}
+}
+
+// TestOperacionDineroMonedasDistintas verifica que no se mezclen monedas
+func TestOperacionDineroMonedasDistintas(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Esperaba un pánico al sumar PEN y USD")
+		}
+	}()
+	entidades.Soles(100).Sumar(entidades.NuevoDinero(100, "USD"))
+}
+
+// TestJSONDinero verifica la serialización con dos decimales y la lectura como número o texto
+func TestJSONDinero(t *testing.T) {
+	datos, err := json.Marshal(struct {
+		Total entidades.Dinero `json:"total"`
+	}{entidades.Soles(11800)})
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if string(datos) != `{"total":118.00}` {
+		t.Errorf("JSON inesperado: %s", datos)
+	}
+
+	tests := []struct {
+		nombre     string
+		json       string
+		esperado   string
+		debeFallar bool
+	}{
+		{nombre: "Número", json: `{"total": 118.5}`, esperado: "118.50"},
+		{nombre: "Texto", json: `{"total": "118.50"}`, esperado: "118.50"},
+		{nombre: "Exponencial", json: `{"total": 1.185e2}`, esperado: "118.50"},
+		{nombre: "Nulo", json: `{"total": null}`, esperado: "0.00"},
+		{nombre: "Texto inválido", json: `{"total": "abc"}`, debeFallar: true},
+		{nombre: "Booleano", json: `{"total": true}`, debeFallar: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			var destino struct {
+				Total entidades.Dinero `json:"total"`
+			}
+			err := json.Unmarshal([]byte(tc.json), &destino)
+			if tc.debeFallar {
+				if err == nil {
+					t.Errorf("Esperaba un error, pero se leyó %s", destino.Total)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error inesperado: %v", err)
+			}
+			if destino.Total.String() != tc.esperado {
+				t.Errorf("Esperaba %s, obtuvo %s", tc.esperado, destino.Total)
+			}
+		})
+	}
+}
+
+// TestScanDinero verifica la lectura de columnas DECIMAL y su envío a la base de datos
+func TestScanDinero(t *testing.T) {
+	var monto entidades.Dinero
+	if err := monto.Scan([]byte("236.40")); err != nil || monto.Centimos() != 23640 {
+		t.Errorf("Esperaba 236.40, obtuvo %s (%v)", monto, err)
+	}
+	if err := monto.Scan(nil); err != nil || !monto.EsCero() {
+		t.Errorf("Esperaba cero para NULL, obtuvo %s (%v)", monto, err)
+	}
+	if err := monto.Scan(int64(15)); err != nil || monto.String() != "15.00" {
+		t.Errorf("Esperaba 15.00, obtuvo %s (%v)", monto, err)
+	}
+	if err := monto.Scan(true); err == nil {
+		t.Error("Esperaba un error al leer un booleano")
+	}
+
+	valor, err := entidades.Soles(-550).Value()
+	if err != nil || valor != "-5.50" {
+		t.Errorf("Esperaba -5.50, obtuvo %v (%v)", valor, err)
+	}
+}
+
+// TestValidacionDinero verifica que las reglas de validación se apliquen al valor del monto
+func TestValidacionDinero(t *testing.T) {
+	utils.InitValidator()
+
+	type solicitud struct {
+		Monto entidades.Dinero `json:"monto" validate:"required,gt=0"`
+	}
+
+	if err := utils.ValidateStruct(solicitud{Monto: entidades.Soles(1)}); err != nil {
+		t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+	}
+	if err := utils.ValidateStruct(solicitud{}); err == nil {
+		t.Error("Esperaba error de validación para un monto en cero")
+	}
+	if err := utils.ValidateStruct(solicitud{Monto: entidades.Soles(-100)}); err == nil {
+		t.Error("Esperaba error de validación para un monto negativo")
+	}
+}
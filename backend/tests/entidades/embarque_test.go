@@ -0,0 +1,117 @@
+package entidades_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"testing"
+	"time"
+)
+
+// TestValidacionCheckin prueba la validación del escaneo de un pase de abordar
+func TestValidacionCheckin(t *testing.T) {
+	utils.InitValidator()
+
+	tests := []struct {
+		nombre        string
+		solicitud     entidades.CheckinRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre:        "Reserva completa",
+			solicitud:     entidades.CheckinRequest{Token: "abc.def.ghi"},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Pasajeros indicados",
+			solicitud:     entidades.CheckinRequest{Token: "abc.def.ghi", IDPasajeros: []int{4, 5}},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Sin token",
+			solicitud:     entidades.CheckinRequest{IDPasajeros: []int{4}},
+			debeSerValido: false,
+			campoInvalido: "token",
+		},
+		{
+			nombre:        "Pasajero inválido",
+			solicitud:     entidades.CheckinRequest{Token: "abc.def.ghi", IDPasajeros: []int{0}},
+			debeSerValido: false,
+			campoInvalido: "id_pasajeros",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.solicitud)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
+
+// TestValidacionSincronizarEmbarques prueba la validación del lote de escaneos sin conexión
+func TestValidacionSincronizarEmbarques(t *testing.T) {
+	utils.InitValidator()
+
+	evento := entidades.EventoEmbarqueOffline{
+		IDEvento:     "b5f1c2d0-1",
+		Token:        "abc.def.ghi",
+		FechaEscaneo: time.Date(2025, 3, 10, 8, 5, 0, 0, time.UTC),
+	}
+	sinFecha := evento
+	sinFecha.FechaEscaneo = time.Time{}
+	sinEvento := evento
+	sinEvento.IDEvento = ""
+
+	tests := []struct {
+		nombre        string
+		solicitud     entidades.SincronizarEmbarquesRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre:        "Lote válido",
+			solicitud:     entidades.SincronizarEmbarquesRequest{Eventos: []entidades.EventoEmbarqueOffline{evento, evento}},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Lote vacío",
+			solicitud:     entidades.SincronizarEmbarquesRequest{},
+			debeSerValido: false,
+			campoInvalido: "eventos",
+		},
+		{
+			nombre:        "Escaneo sin fecha",
+			solicitud:     entidades.SincronizarEmbarquesRequest{Eventos: []entidades.EventoEmbarqueOffline{sinFecha}},
+			debeSerValido: false,
+			campoInvalido: "fecha_escaneo",
+		},
+		{
+			nombre:        "Escaneo sin identificador",
+			solicitud:     entidades.SincronizarEmbarquesRequest{Eventos: []entidades.EventoEmbarqueOffline{sinEvento}},
+			debeSerValido: false,
+			campoInvalido: "id_evento",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.solicitud)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package entidades_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"testing"
+)
+
+// TestValidacionNuevaListaEspera prueba la validación de una inscripción en la lista de espera
+func TestValidacionNuevaListaEspera(t *testing.T) {
+	utils.InitValidator()
+
+	tests := []struct {
+		nombre        string
+		solicitud     entidades.NuevaListaEsperaRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre: "Inscripción válida",
+			solicitud: entidades.NuevaListaEsperaRequest{
+				IDInstancia:       1,
+				IDCliente:         2,
+				CantidadPasajeros: 3,
+				Notas:             "Prefiere asientos juntos",
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Sin instancia",
+			solicitud: entidades.NuevaListaEsperaRequest{
+				IDCliente:         2,
+				CantidadPasajeros: 3,
+			},
+			debeSerValido: false,
+			campoInvalido: "id_instancia",
+		},
+		{
+			nombre: "Sin cliente",
+			solicitud: entidades.NuevaListaEsperaRequest{
+				IDInstancia:       1,
+				CantidadPasajeros: 3,
+			},
+			debeSerValido: false,
+			campoInvalido: "id_cliente",
+		},
+		{
+			nombre: "Cantidad de pasajeros en cero",
+			solicitud: entidades.NuevaListaEsperaRequest{
+				IDInstancia: 1,
+				IDCliente:   2,
+			},
+			debeSerValido: false,
+			campoInvalido: "cantidad_pasajeros",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.solicitud)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
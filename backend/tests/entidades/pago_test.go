@@ -23,7 +23,7 @@ func TestValidacionNuevoPago(t *testing.T) {
 				IDMetodoPago: 2,
 				IDCanal:      3,
 				IDSede:       4,
-				Monto:        100.0,
+				Monto:        entidades.DineroDesdeFloat(100.0),
 				Comprobante:  "ABC123",
 			},
 			debeSerValido: true,
@@ -34,7 +34,7 @@ func TestValidacionNuevoPago(t *testing.T) {
 				IDMetodoPago: 2,
 				IDCanal:      3,
 				IDSede:       4,
-				Monto:        100.0,
+				Monto:        entidades.DineroDesdeFloat(100.0),
 				Comprobante:  "ABC123",
 			},
 			debeSerValido: false,
@@ -56,3 +56,115 @@ func TestValidacionNuevoPago(t *testing.T) {
 		})
 	}
 }
+
+// TestCalcularSaldoReserva prueba el saldo, el adelanto requerido y el estado de pago de una reserva
+func TestCalcularSaldoReserva(t *testing.T) {
+	tests := []struct {
+		nombre             string
+		totalPagar         entidades.Dinero
+		totalPagado        entidades.Dinero
+		porcentajeAdelanto float64
+		saldo              entidades.Dinero
+		adelantoRequerido  entidades.Dinero
+		adelantoPendiente  entidades.Dinero
+		estadoPago         string
+		adelantoCubierto   bool
+	}{
+		{
+			nombre:             "Sin pagos",
+			totalPagar:         entidades.Soles(20000),
+			totalPagado:        entidades.Soles(0),
+			porcentajeAdelanto: 30,
+			saldo:              entidades.Soles(20000),
+			adelantoRequerido:  entidades.Soles(6000),
+			adelantoPendiente:  entidades.Soles(6000),
+			estadoPago:         entidades.EstadoPagoSinPago,
+		},
+		{
+			nombre:             "Pago menor al adelanto",
+			totalPagar:         entidades.Soles(20000),
+			totalPagado:        entidades.Soles(5000),
+			porcentajeAdelanto: 30,
+			saldo:              entidades.Soles(15000),
+			adelantoRequerido:  entidades.Soles(6000),
+			adelantoPendiente:  entidades.Soles(1000),
+			estadoPago:         entidades.EstadoPagoParcial,
+		},
+		{
+			nombre:             "Adelanto exacto",
+			totalPagar:         entidades.Soles(20000),
+			totalPagado:        entidades.Soles(6000),
+			porcentajeAdelanto: 30,
+			saldo:              entidades.Soles(14000),
+			adelantoRequerido:  entidades.Soles(6000),
+			adelantoPendiente:  entidades.Soles(0),
+			estadoPago:         entidades.EstadoPagoAdelanto,
+			adelantoCubierto:   true,
+		},
+		{
+			nombre:             "Pagado por completo",
+			totalPagar:         entidades.Soles(20000),
+			totalPagado:        entidades.Soles(20000),
+			porcentajeAdelanto: 30,
+			saldo:              entidades.Soles(0),
+			adelantoRequerido:  entidades.Soles(6000),
+			adelantoPendiente:  entidades.Soles(0),
+			estadoPago:         entidades.EstadoPagoPagado,
+			adelantoCubierto:   true,
+		},
+		{
+			nombre:             "Pagado en exceso",
+			totalPagar:         entidades.Soles(20000),
+			totalPagado:        entidades.Soles(25000),
+			porcentajeAdelanto: 30,
+			saldo:              entidades.Soles(-5000),
+			adelantoRequerido:  entidades.Soles(6000),
+			adelantoPendiente:  entidades.Soles(0),
+			estadoPago:         entidades.EstadoPagoPagado,
+			adelantoCubierto:   true,
+		},
+		{
+			nombre:             "Sede sin adelanto y sin pagos",
+			totalPagar:         entidades.Soles(20000),
+			totalPagado:        entidades.Soles(0),
+			porcentajeAdelanto: 0,
+			saldo:              entidades.Soles(20000),
+			adelantoRequerido:  entidades.Soles(0),
+			adelantoPendiente:  entidades.Soles(0),
+			estadoPago:         entidades.EstadoPagoSinPago,
+		},
+		{
+			nombre:             "Adelanto con céntimos redondeados",
+			totalPagar:         entidades.Soles(9999),
+			totalPagado:        entidades.Soles(3000),
+			porcentajeAdelanto: 30,
+			saldo:              entidades.Soles(6999),
+			adelantoRequerido:  entidades.Soles(3000),
+			adelantoPendiente:  entidades.Soles(0),
+			estadoPago:         entidades.EstadoPagoAdelanto,
+			adelantoCubierto:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			saldo := entidades.CalcularSaldoReserva(tc.totalPagar, tc.totalPagado, tc.porcentajeAdelanto)
+
+			if !saldo.Saldo.Igual(tc.saldo) {
+				t.Errorf("Saldo esperado %s, obtenido %s", tc.saldo, saldo.Saldo)
+			}
+			if !saldo.AdelantoRequerido.Igual(tc.adelantoRequerido) {
+				t.Errorf("Adelanto requerido esperado %s, obtenido %s", tc.adelantoRequerido, saldo.AdelantoRequerido)
+			}
+			if !saldo.AdelantoPendiente.Igual(tc.adelantoPendiente) {
+				t.Errorf("Adelanto pendiente esperado %s, obtenido %s", tc.adelantoPendiente, saldo.AdelantoPendiente)
+			}
+			if saldo.EstadoPago != tc.estadoPago {
+				t.Errorf("Estado de pago esperado %s, obtenido %s", tc.estadoPago, saldo.EstadoPago)
+			}
+			if saldo.AdelantoCubierto() != tc.adelantoCubierto {
+				t.Errorf("Adelanto cubierto esperado %v, obtenido %v", tc.adelantoCubierto, saldo.AdelantoCubierto())
+			}
+		})
+	}
+}
@@ -23,7 +23,7 @@ func TestValidacionNuevoPaquetePasajes(t *testing.T) {
 				IDTipoTour:    2,
 				Nombre:        "Paquete Familiar",
 				Descripcion:   "Incluye varias actividades.",
-				PrecioTotal:   200.0,
+				PrecioTotal:   entidades.DineroDesdeFloat(200.0),
 				CantidadTotal: 5,
 			},
 			debeSerValido: true,
@@ -34,7 +34,7 @@ func TestValidacionNuevoPaquetePasajes(t *testing.T) {
 				IDTipoTour:    2,
 				Nombre:        "Paquete Familiar",
 				Descripcion:   "Incluye varias actividades.",
-				PrecioTotal:   200.0,
+				PrecioTotal:   entidades.DineroDesdeFloat(200.0),
 				CantidadTotal: 5,
 			},
 			debeSerValido: false,
@@ -0,0 +1,83 @@
+package entidades_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"testing"
+)
+
+// TestValidacionRegistrarPasajeros prueba la validación del registro de pasajeros de una reserva
+func TestValidacionRegistrarPasajeros(t *testing.T) {
+	utils.InitValidator()
+
+	pasajeroValido := entidades.PasajeroRequest{
+		Nombres:                    "Ana",
+		Apellidos:                  "Quispe Mamani",
+		TipoDocumento:              "DNI",
+		NumeroDocumento:            "45678912",
+		FechaNacimiento:            "1990-04-15",
+		Nacionalidad:               "Peruana",
+		ContactoEmergenciaNombre:   "Luis Quispe",
+		ContactoEmergenciaTelefono: "987654321",
+	}
+
+	sinContacto := pasajeroValido
+	sinContacto.ContactoEmergenciaTelefono = ""
+
+	documentoInvalido := pasajeroValido
+	documentoInvalido.TipoDocumento = "RUC"
+
+	fechaInvalida := pasajeroValido
+	fechaInvalida.FechaNacimiento = "15/04/1990"
+
+	tests := []struct {
+		nombre        string
+		solicitud     entidades.RegistrarPasajerosRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre:        "Registro válido",
+			solicitud:     entidades.RegistrarPasajerosRequest{Pasajeros: []entidades.PasajeroRequest{pasajeroValido}},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Sin pasajeros",
+			solicitud:     entidades.RegistrarPasajerosRequest{},
+			debeSerValido: false,
+			campoInvalido: "pasajeros",
+		},
+		{
+			nombre:        "Pasajero sin teléfono de emergencia",
+			solicitud:     entidades.RegistrarPasajerosRequest{Pasajeros: []entidades.PasajeroRequest{pasajeroValido, sinContacto}},
+			debeSerValido: false,
+			campoInvalido: "contacto_emergencia_telefono",
+		},
+		{
+			nombre:        "Tipo de documento no permitido",
+			solicitud:     entidades.RegistrarPasajerosRequest{Pasajeros: []entidades.PasajeroRequest{documentoInvalido}},
+			debeSerValido: false,
+			campoInvalido: "tipo_documento",
+		},
+		{
+			nombre:        "Fecha de nacimiento con formato inválido",
+			solicitud:     entidades.RegistrarPasajerosRequest{Pasajeros: []entidades.PasajeroRequest{fechaInvalida}},
+			debeSerValido: false,
+			campoInvalido: "fecha_nacimiento",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.solicitud)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package entidades_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"testing"
+)
+
+// TestValidacionNuevaPoliticaCancelacion prueba la validación de un tramo de política de cancelación
+func TestValidacionNuevaPoliticaCancelacion(t *testing.T) {
+	utils.InitValidator()
+
+	idTipoTour := 1
+	idSede := 2
+
+	tests := []struct {
+		nombre        string
+		politica      entidades.NuevaPoliticaCancelacionRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre: "Política de tipo de tour válida",
+			politica: entidades.NuevaPoliticaCancelacionRequest{
+				IDTipoTour:           &idTipoTour,
+				Nombre:               "Flexible",
+				HorasAntesMinimo:     48,
+				PorcentajeDevolucion: 100,
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Política de sede válida",
+			politica: entidades.NuevaPoliticaCancelacionRequest{
+				IDSede:               &idSede,
+				Nombre:               "Sin devolución",
+				HorasAntesMinimo:     0,
+				PorcentajeDevolucion: 0,
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Política sin tipo de tour ni sede",
+			politica: entidades.NuevaPoliticaCancelacionRequest{
+				Nombre:               "Flexible",
+				HorasAntesMinimo:     48,
+				PorcentajeDevolucion: 100,
+			},
+			debeSerValido: false,
+			campoInvalido: "id_tipo_tour",
+		},
+		{
+			nombre: "Porcentaje mayor a 100",
+			politica: entidades.NuevaPoliticaCancelacionRequest{
+				IDSede:               &idSede,
+				Nombre:               "Flexible",
+				HorasAntesMinimo:     48,
+				PorcentajeDevolucion: 120,
+			},
+			debeSerValido: false,
+			campoInvalido: "porcentaje_devolucion",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.politica)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package entidades_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"testing"
+	"time"
+)
+
+// TestValidacionNuevaReglaPrecio prueba la validación de una regla de precio
+func TestValidacionNuevaReglaPrecio(t *testing.T) {
+	utils.InitValidator()
+
+	inicio := time.Date(2026, 12, 15, 0, 0, 0, 0, time.UTC)
+	fin := time.Date(2027, 3, 15, 0, 0, 0, 0, time.UTC)
+	ochenta := 80.0
+	ciento20 := 120.0
+
+	tests := []struct {
+		nombre        string
+		regla         entidades.NuevaReglaPrecioRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre: "Temporada alta válida",
+			regla: entidades.NuevaReglaPrecioRequest{
+				Nombre: "Verano", TipoRegla: "TEMPORADA", FechaInicio: &inicio, FechaFin: &fin, Porcentaje: 20,
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Temporada sin fechas",
+			regla: entidades.NuevaReglaPrecioRequest{
+				Nombre: "Verano", TipoRegla: "TEMPORADA", Porcentaje: 20,
+			},
+			debeSerValido: false,
+			campoInvalido: "fecha_inicio",
+		},
+		{
+			nombre: "Temporada que termina antes de empezar",
+			regla: entidades.NuevaReglaPrecioRequest{
+				Nombre: "Verano", TipoRegla: "TEMPORADA", FechaInicio: &fin, FechaFin: &inicio, Porcentaje: 20,
+			},
+			debeSerValido: false,
+			campoInvalido: "fecha_fin",
+		},
+		{
+			nombre: "Fin de semana válido",
+			regla: entidades.NuevaReglaPrecioRequest{
+				Nombre: "Fin de semana", TipoRegla: "DIA_SEMANA", DiasSemana: "6,7", Monto: entidades.DineroDesdeFloat(5),
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Horario con rebaja válido",
+			regla: entidades.NuevaReglaPrecioRequest{
+				Nombre: "Primera salida", TipoRegla: "HORARIO", HoraInicio: "06:00", HoraFin: "08:00", Porcentaje: -10,
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Horario con hora inválida",
+			regla: entidades.NuevaReglaPrecioRequest{
+				Nombre: "Primera salida", TipoRegla: "HORARIO", HoraInicio: "6 am", HoraFin: "08:00", Porcentaje: -10,
+			},
+			debeSerValido: false,
+			campoInvalido: "hora_inicio",
+		},
+		{
+			nombre: "Ocupación válida",
+			regla: entidades.NuevaReglaPrecioRequest{
+				Nombre: "Últimos cupos", TipoRegla: "OCUPACION", OcupacionMinima: &ochenta, Porcentaje: 15,
+			},
+			debeSerValido: true,
+		},
+		{
+			nombre: "Ocupación mayor a 100",
+			regla: entidades.NuevaReglaPrecioRequest{
+				Nombre: "Últimos cupos", TipoRegla: "OCUPACION", OcupacionMinima: &ciento20, Porcentaje: 15,
+			},
+			debeSerValido: false,
+			campoInvalido: "ocupacion_minima",
+		},
+		{
+			nombre: "Rebaja del 100%",
+			regla: entidades.NuevaReglaPrecioRequest{
+				Nombre: "Gratis", TipoRegla: "DIA_SEMANA", DiasSemana: "1", Porcentaje: -100,
+			},
+			debeSerValido: false,
+			campoInvalido: "porcentaje",
+		},
+		{
+			nombre: "Tipo de regla desconocido",
+			regla: entidades.NuevaReglaPrecioRequest{
+				Nombre: "Feriado", TipoRegla: "FERIADO", Porcentaje: 10,
+			},
+			debeSerValido: false,
+			campoInvalido: "tipo_regla",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.regla)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
@@ -24,7 +24,7 @@ func TestValidacionNuevaReserva(t *testing.T) {
 				IDTourProgramado: 2,
 				IDCanal:          3,
 				IDSede:           4,
-				TotalPagar:       100.0,
+				TotalPagar:       entidades.DineroDesdeFloat(100.0),
 				CantidadPasajes:  []entidades.PasajeCantidadRequest{{IDTipoPasaje: 5, Cantidad: 2}},
 			},
 			debeSerValido: true,
@@ -35,7 +35,7 @@ func TestValidacionNuevaReserva(t *testing.T) {
 				IDTourProgramado: 2,
 				IDCanal:          3,
 				IDSede:           4,
-				TotalPagar:       100.0,
+				TotalPagar:       entidades.DineroDesdeFloat(100.0),
 				CantidadPasajes:  []entidades.PasajeCantidadRequest{{IDTipoPasaje: 5, Cantidad: 2}},
 			},
 			debeSerValido: false,
@@ -121,3 +121,107 @@ func TestValidacionNuevaSede(t *testing.T) {
 		})
 	}
 }
+
+// TestValidacionRetencionReserva prueba la validación del tiempo de retención de cupos de una sede
+func TestValidacionRetencionReserva(t *testing.T) {
+	utils.InitValidator()
+
+	tests := []struct {
+		nombre        string
+		retencion     entidades.ActualizarRetencionReservaRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre:        "Retención válida",
+			retencion:     entidades.ActualizarRetencionReservaRequest{MinutosRetencionReserva: 30},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Retención sin minutos",
+			retencion:     entidades.ActualizarRetencionReservaRequest{},
+			debeSerValido: false,
+			campoInvalido: "minutos_retencion_reserva",
+		},
+		{
+			nombre:        "Retención negativa",
+			retencion:     entidades.ActualizarRetencionReservaRequest{MinutosRetencionReserva: -5},
+			debeSerValido: false,
+			campoInvalido: "minutos_retencion_reserva",
+		},
+		{
+			nombre:        "Retención mayor a un día",
+			retencion:     entidades.ActualizarRetencionReservaRequest{MinutosRetencionReserva: 1441},
+			debeSerValido: false,
+			campoInvalido: "minutos_retencion_reserva",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.retencion)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
+
+// TestValidacionAdelantoReserva prueba la validación del porcentaje de adelanto de una sede
+func TestValidacionAdelantoReserva(t *testing.T) {
+	utils.InitValidator()
+
+	tests := []struct {
+		nombre        string
+		adelanto      entidades.ActualizarAdelantoReservaRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre:        "Adelanto válido",
+			adelanto:      entidades.ActualizarAdelantoReservaRequest{PorcentajeAdelanto: 30},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Sin adelanto",
+			adelanto:      entidades.ActualizarAdelantoReservaRequest{},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Pago total para reservar",
+			adelanto:      entidades.ActualizarAdelantoReservaRequest{PorcentajeAdelanto: 100},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Adelanto negativo",
+			adelanto:      entidades.ActualizarAdelantoReservaRequest{PorcentajeAdelanto: -10},
+			debeSerValido: false,
+			campoInvalido: "porcentaje_adelanto",
+		},
+		{
+			nombre:        "Adelanto mayor al total",
+			adelanto:      entidades.ActualizarAdelantoReservaRequest{PorcentajeAdelanto: 100.5},
+			debeSerValido: false,
+			campoInvalido: "porcentaje_adelanto",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.adelanto)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package entidades_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"testing"
+)
+
+// TestValidacionNuevaSerieComprobante prueba la validación de los datos de una nueva serie
+func TestValidacionNuevaSerieComprobante(t *testing.T) {
+	utils.InitValidator()
+
+	tests := []struct {
+		nombre        string
+		serie         entidades.NuevaSerieComprobanteRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre:        "Serie válida",
+			serie:         entidades.NuevaSerieComprobanteRequest{IDSede: 1, Tipo: "BOLETA", Serie: "B001"},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Serie que continúa otra numeración",
+			serie:         entidades.NuevaSerieComprobanteRequest{IDSede: 1, Tipo: "FACTURA", Serie: "F002", CorrelativoInicial: 1520},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Serie de más de 4 caracteres",
+			serie:         entidades.NuevaSerieComprobanteRequest{IDSede: 1, Tipo: "BOLETA", Serie: "B0001"},
+			debeSerValido: false,
+			campoInvalido: "serie",
+		},
+		{
+			nombre:        "Serie en minúsculas",
+			serie:         entidades.NuevaSerieComprobanteRequest{IDSede: 1, Tipo: "BOLETA", Serie: "b001"},
+			debeSerValido: false,
+			campoInvalido: "serie",
+		},
+		{
+			nombre:        "Tipo inválido",
+			serie:         entidades.NuevaSerieComprobanteRequest{IDSede: 1, Tipo: "TICKET", Serie: "T001"},
+			debeSerValido: false,
+			campoInvalido: "tipo",
+		},
+		{
+			nombre:        "Correlativo inicial negativo",
+			serie:         entidades.NuevaSerieComprobanteRequest{IDSede: 1, Tipo: "BOLETA", Serie: "B001", CorrelativoInicial: -1},
+			debeSerValido: false,
+			campoInvalido: "correlativo_inicial",
+		},
+		{
+			nombre:        "Correlativo inicial en el límite de la serie",
+			serie:         entidades.NuevaSerieComprobanteRequest{IDSede: 1, Tipo: "BOLETA", Serie: "B001", CorrelativoInicial: 99999999},
+			debeSerValido: false,
+			campoInvalido: "correlativo_inicial",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.serie)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
+
+// TestValidacionCambiarEstadoSerie verifica que el estado sea obligatorio, incluso cuando es false
+func TestValidacionCambiarEstadoSerie(t *testing.T) {
+	utils.InitValidator()
+
+	inactivo := false
+	if err := utils.ValidateStruct(entidades.CambiarEstadoSerieRequest{Activo: &inactivo}); err != nil {
+		t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+	}
+	if err := utils.ValidateStruct(entidades.CambiarEstadoSerieRequest{}); err == nil {
+		t.Error("Esperaba error de validación en activo, pero no ocurrió")
+	}
+}
@@ -0,0 +1,86 @@
+package entidades_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"testing"
+	"time"
+)
+
+// TestValidacionNuevoTipoCambio prueba la validación del tipo de cambio de un día
+func TestValidacionNuevoTipoCambio(t *testing.T) {
+	utils.InitValidator()
+
+	fecha := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		nombre        string
+		tipoCambio    entidades.NuevoTipoCambioRequest
+		debeSerValido bool
+		campoInvalido string
+	}{
+		{
+			nombre:        "Dólar válido",
+			tipoCambio:    entidades.NuevoTipoCambioRequest{Fecha: fecha, Moneda: "USD", Valor: 3.7512},
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Sin fecha",
+			tipoCambio:    entidades.NuevoTipoCambioRequest{Moneda: "USD", Valor: 3.7512},
+			debeSerValido: false,
+			campoInvalido: "fecha",
+		},
+		{
+			nombre:        "Moneda base",
+			tipoCambio:    entidades.NuevoTipoCambioRequest{Fecha: fecha, Moneda: "PEN", Valor: 1},
+			debeSerValido: false,
+			campoInvalido: "moneda",
+		},
+		{
+			nombre:        "Valor negativo",
+			tipoCambio:    entidades.NuevoTipoCambioRequest{Fecha: fecha, Moneda: "USD", Valor: -3.75},
+			debeSerValido: false,
+			campoInvalido: "valor",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			err := utils.ValidateStruct(tc.tipoCambio)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba que fuera válido, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba error de validación en %s, pero no ocurrió", tc.campoInvalido)
+			}
+		})
+	}
+}
+
+// TestConvertirMoneda verifica la conversión entre monedas pasando por soles
+func TestConvertirMoneda(t *testing.T) {
+	tests := []struct {
+		nombre            string
+		monto             entidades.Dinero
+		tipoCambioOrigen  float64
+		moneda            string
+		tipoCambioDestino float64
+		esperado          string
+	}{
+		{nombre: "Misma moneda", monto: entidades.DineroDesdeFloat(20).EnMoneda("USD"), tipoCambioOrigen: 3.70, moneda: "USD", tipoCambioDestino: 3.80, esperado: "20.00"},
+		{nombre: "Dólares a soles", monto: entidades.DineroDesdeFloat(20).EnMoneda("USD"), tipoCambioOrigen: 3.7512, moneda: "PEN", tipoCambioDestino: 1, esperado: "75.02"},
+		{nombre: "Soles a dólares", monto: entidades.DineroDesdeFloat(100), tipoCambioOrigen: 1, moneda: "USD", tipoCambioDestino: 3.75, esperado: "26.67"},
+		{nombre: "Dólares con otro tipo de cambio", monto: entidades.DineroDesdeFloat(20).EnMoneda("USD"), tipoCambioOrigen: 3.70, moneda: "USD", tipoCambioDestino: 3.70, esperado: "20.00"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			convertido := entidades.ConvertirMoneda(tc.monto, tc.tipoCambioOrigen, tc.moneda, tc.tipoCambioDestino)
+			if convertido.String() != tc.esperado || convertido.Moneda() != tc.moneda {
+				t.Errorf("Esperaba %s %s, obtuvo %s %s", tc.esperado, tc.moneda, convertido, convertido.Moneda())
+			}
+		})
+	}
+}
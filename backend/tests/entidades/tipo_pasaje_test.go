@@ -22,7 +22,7 @@ func TestValidacionNuevoTipoPasaje(t *testing.T) {
 				IDSede:     1,
 				IDTipoTour: 2,
 				Nombre:     "Pasaje Adulto",
-				Costo:      50.0,
+				Costo:      entidades.DineroDesdeFloat(50.0),
 				Edad:       "Adulto",
 			},
 			debeSerValido: true,
@@ -32,7 +32,7 @@ func TestValidacionNuevoTipoPasaje(t *testing.T) {
 			tipoPasaje: entidades.NuevoTipoPasajeRequest{
 				IDTipoTour: 2,
 				Nombre:     "Pasaje Adulto",
-				Costo:      50.0,
+				Costo:      entidades.DineroDesdeFloat(50.0),
 				Edad:       "Adulto",
 			},
 			debeSerValido: false,
@@ -43,7 +43,7 @@ func TestValidacionNuevoTipoPasaje(t *testing.T) {
 			tipoPasaje: entidades.NuevoTipoPasajeRequest{
 				IDSede: 1,
 				Nombre: "Pasaje Adulto",
-				Costo:  50.0,
+				Costo:  entidades.DineroDesdeFloat(50.0),
 				Edad:   "Adulto",
 			},
 			debeSerValido: false,
@@ -54,7 +54,7 @@ func TestValidacionNuevoTipoPasaje(t *testing.T) {
 			tipoPasaje: entidades.NuevoTipoPasajeRequest{
 				IDSede:     1,
 				IDTipoTour: 2,
-				Costo:      50.0,
+				Costo:      entidades.DineroDesdeFloat(50.0),
 				Edad:       "Adulto",
 			},
 			debeSerValido: false,
@@ -66,7 +66,7 @@ func TestValidacionNuevoTipoPasaje(t *testing.T) {
 				IDSede:     1,
 				IDTipoTour: 2,
 				Nombre:     "Pasaje Adulto",
-				Costo:      -5.0, // El costo no puede ser negativo
+				Costo:      entidades.DineroDesdeFloat(-5.0), // El costo no puede ser negativo
 				Edad:       "Adulto",
 			},
 			debeSerValido: false,
@@ -78,7 +78,7 @@ func TestValidacionNuevoTipoPasaje(t *testing.T) {
 				IDSede:     1,
 				IDTipoTour: 2,
 				Nombre:     "Pasaje Adulto",
-				Costo:      50.0,
+				Costo:      entidades.DineroDesdeFloat(50.0),
 			},
 			debeSerValido: false,
 			campoInvalido: "edad",
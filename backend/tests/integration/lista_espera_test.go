@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"testing"
+)
+
+// TestListaEsperaOfertaYConversion registra un cliente en la lista de espera de una instancia agotada,
+// libera cupos y verifica que la oferta retenga los cupos y que al convertirla se cree la reserva
+func TestListaEsperaOfertaYConversion(t *testing.T) {
+	db := abrirBaseDatosPrueba(t)
+	defer db.Close()
+
+	// Crear una instancia futura agotada copiando una existente
+	var idInstancia int
+	err := db.QueryRow(`INSERT INTO instancia_tour (id_tour_programado, fecha_especifica, hora_inicio, hora_fin,
+                        id_chofer, id_embarcacion, cupo_disponible, estado, eliminado)
+                        SELECT id_tour_programado, CURRENT_DATE + 30, hora_inicio, hora_fin,
+                        id_chofer, id_embarcacion, 0, 'PROGRAMADO', FALSE
+                        FROM instancia_tour WHERE eliminado = FALSE ORDER BY id_instancia LIMIT 1
+                        RETURNING id_instancia`).Scan(&idInstancia)
+	if err != nil {
+		t.Skipf("No hay datos base para crear la instancia de prueba: %v", err)
+	}
+
+	// Limpiar los datos creados al terminar
+	defer func() {
+		db.Exec(`DELETE FROM lista_espera WHERE id_instancia = $1`, idInstancia)
+		db.Exec(`DELETE FROM pasajes_cantidad WHERE id_reserva IN (SELECT id_reserva FROM reserva WHERE id_instancia = $1)`, idInstancia)
+		db.Exec(`DELETE FROM reserva WHERE id_instancia = $1`, idInstancia)
+		db.Exec(`DELETE FROM instancia_tour WHERE id_instancia = $1`, idInstancia)
+	}()
+
+	// Obtener datos de referencia existentes para la reserva
+	var idCliente, idCanal, idSede, idTipoPasaje int
+	err = db.QueryRow(`SELECT
+                       (SELECT id_cliente FROM cliente WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT id_canal FROM canal_venta WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT id_sede FROM sede WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT tpa.id_tipo_pasaje FROM tipo_pasaje tpa
+                        INNER JOIN tour_programado tp ON tpa.id_tipo_tour = tp.id_tipo_tour
+                        INNER JOIN instancia_tour it ON it.id_tour_programado = tp.id_tour_programado
+                        WHERE it.id_instancia = $1 AND tpa.eliminado = FALSE LIMIT 1)`, idInstancia).
+		Scan(&idCliente, &idCanal, &idSede, &idTipoPasaje)
+	if err != nil {
+		t.Skipf("No hay datos base para crear reservas de prueba: %v", err)
+	}
+
+	listaEsperaRepo := repositorios.NewListaEsperaRepository(db)
+
+	idEntrada, err := listaEsperaRepo.Create(&entidades.NuevaListaEsperaRequest{
+		IDInstancia:       idInstancia,
+		IDCliente:         idCliente,
+		CantidadPasajeros: 2,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Error al registrar en la lista de espera: %v", err)
+	}
+
+	// Un segundo registro del mismo cliente en la misma instancia no debe ser posible
+	_, err = listaEsperaRepo.Create(&entidades.NuevaListaEsperaRequest{
+		IDInstancia:       idInstancia,
+		IDCliente:         idCliente,
+		CantidadPasajeros: 1,
+	}, nil)
+	if err == nil {
+		t.Errorf("Esperaba error al registrar dos veces al mismo cliente")
+	}
+
+	// Liberar cupos y ofrecerlos a la lista de espera
+	db.Exec(`UPDATE instancia_tour SET cupo_disponible = 3 WHERE id_instancia = $1`, idInstancia)
+
+	ofertadas, err := listaEsperaRepo.OfrecerCupos(idInstancia)
+	if err != nil {
+		t.Fatalf("Error al ofrecer cupos: %v", err)
+	}
+	if len(ofertadas) != 1 || ofertadas[0] != idEntrada {
+		t.Fatalf("Esperaba que se ofertara la entrada %d, pero se ofertaron %v", idEntrada, ofertadas)
+	}
+
+	var cupo int
+	db.QueryRow(`SELECT cupo_disponible FROM instancia_tour WHERE id_instancia = $1`, idInstancia).Scan(&cupo)
+	if cupo != 1 {
+		t.Errorf("Esperaba que la oferta retenga 2 cupos (queda 1), pero quedó %d", cupo)
+	}
+
+	idReserva, err := listaEsperaRepo.ConvertirEnReserva(idEntrada, &entidades.NuevaReservaRequest{
+		IDCanal:    idCanal,
+		IDSede:     idSede,
+		TotalPagar: entidades.DineroDesdeFloat(10),
+		CantidadPasajes: []entidades.PasajeCantidadRequest{
+			{IDTipoPasaje: idTipoPasaje, Cantidad: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error al convertir la entrada en reserva: %v", err)
+	}
+
+	entrada, err := listaEsperaRepo.GetByID(idEntrada)
+	if err != nil {
+		t.Fatalf("Error al obtener la entrada: %v", err)
+	}
+	if entrada.Estado != "CONVERTIDA" || entrada.IDReserva == nil || *entrada.IDReserva != idReserva {
+		t.Errorf("Entrada inesperada tras la conversión: %+v", entrada)
+	}
+
+	db.QueryRow(`SELECT cupo_disponible FROM instancia_tour WHERE id_instancia = $1`, idInstancia).Scan(&cupo)
+	if cupo != 1 {
+		t.Errorf("Esperaba que la reserva use los cupos ofertados (queda 1), pero quedó %d", cupo)
+	}
+}
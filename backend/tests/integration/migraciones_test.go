@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"sistema-toursseft/internal/config"
+	"testing"
+)
+
+// TestMigracionesIncrementalesReaplicables aplica dos veces las migraciones incrementales sobre una base
+// en la que dos pasarelas informaron el mismo ID de transacción, como ocurre en cada arranque del servidor,
+// y verifica que no vuelvan a crearse los objetos que una migración posterior reemplaza o renombra
+func TestMigracionesIncrementalesReaplicables(t *testing.T) {
+	db := abrirBaseDatosPrueba(t)
+	defer db.Close()
+
+	if err := config.AplicarMigracionesIncrementales(db, "../../migrations"); err != nil {
+		t.Fatalf("Error al aplicar las migraciones: %v", err)
+	}
+
+	// Registrar el mismo ID de transacción en Mercado Pago y en Izipay
+	const idTransaccion = "PRUEBA-MIGRACION-TX-1"
+	defer db.Exec(`DELETE FROM pago WHERE id_transaccion_externa = $1`, idTransaccion)
+
+	for _, pasarela := range []string{"MERCADO_PAGO", "IZIPAY"} {
+		_, err := db.Exec(`INSERT INTO pago (id_reserva, id_metodo_pago, id_canal, id_sede, monto, estado,
+                           id_transaccion_externa, pasarela)
+                           SELECT r.id_reserva, (SELECT id_metodo_pago FROM metodo_pago LIMIT 1),
+                           r.id_canal, r.id_sede, 1, 'PROCESADO', $1, $2
+                           FROM reserva r ORDER BY r.id_reserva LIMIT 1`, idTransaccion, pasarela)
+		if err != nil {
+			t.Fatalf("Error al registrar el pago de %s: %v", pasarela, err)
+		}
+	}
+
+	var registrados int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pago WHERE id_transaccion_externa = $1`, idTransaccion).Scan(&registrados); err != nil {
+		t.Fatalf("Error al contar los pagos: %v", err)
+	}
+	if registrados != 2 {
+		t.Skipf("No hay datos base para registrar los pagos de prueba")
+	}
+
+	// Volver a aplicar todo dos veces, como dos arranques seguidos
+	for i := 1; i <= 2; i++ {
+		if err := config.AplicarMigracionesIncrementales(db, "../../migrations"); err != nil {
+			t.Fatalf("Error al reaplicar las migraciones (pasada %d): %v", i, err)
+		}
+	}
+
+	var existeIndiceAnterior bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_pago_transaccion_externa')`).
+		Scan(&existeIndiceAnterior)
+	if err != nil {
+		t.Fatalf("Error al consultar los índices: %v", err)
+	}
+	if existeIndiceAnterior {
+		t.Errorf("El índice único por ID de transacción no debería volver a crearse")
+	}
+
+	// Las notificaciones quedan solo en la tabla renombrada
+	var tablaAnterior, tablaPasarela bool
+	err = db.QueryRow(`SELECT to_regclass('webhook_evento_mercadopago') IS NOT NULL,
+                       to_regclass('webhook_evento_pasarela') IS NOT NULL`).Scan(&tablaAnterior, &tablaPasarela)
+	if err != nil {
+		t.Fatalf("Error al consultar las tablas de notificaciones: %v", err)
+	}
+	if tablaAnterior || !tablaPasarela {
+		t.Errorf("Se esperaba solo webhook_evento_pasarela (anterior: %v, renombrada: %v)", tablaAnterior, tablaPasarela)
+	}
+}
@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"database/sql"
+	"os"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"sync"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// abrirBaseDatosPrueba abre la base de datos de pruebas indicada en TEST_DATABASE_URL
+// Si la variable no está definida la prueba se omite
+func abrirBaseDatosPrueba(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL no definida, se omite prueba de integración")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("Error al abrir la base de datos: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Error al conectar con la base de datos: %v", err)
+	}
+
+	return db
+}
+
+// TestReservasConcurrentesNoSobrevenden lanza reservas en paralelo sobre una misma instancia
+// y verifica que nunca se vendan más pasajes que el cupo disponible
+func TestReservasConcurrentesNoSobrevenden(t *testing.T) {
+	db := abrirBaseDatosPrueba(t)
+	defer db.Close()
+
+	const cupoInicial = 5
+	const intentos = 20
+
+	// Crear una instancia de prueba copiando una existente con cupo limitado
+	var idInstancia int
+	err := db.QueryRow(`INSERT INTO instancia_tour (id_tour_programado, fecha_especifica, hora_inicio, hora_fin,
+                        id_chofer, id_embarcacion, cupo_disponible, estado, eliminado)
+                        SELECT id_tour_programado, fecha_especifica, hora_inicio, hora_fin,
+                        id_chofer, id_embarcacion, $1, 'PROGRAMADO', FALSE
+                        FROM instancia_tour WHERE eliminado = FALSE LIMIT 1
+                        RETURNING id_instancia`, cupoInicial).Scan(&idInstancia)
+	if err != nil {
+		t.Skipf("No hay datos base para crear la instancia de prueba: %v", err)
+	}
+
+	// Obtener datos de referencia existentes para la reserva
+	var idCliente, idCanal, idSede, idTipoPasaje int
+	err = db.QueryRow(`SELECT
+                       (SELECT id_cliente FROM cliente WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT id_canal FROM canal_venta WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT id_sede FROM sede WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT id_tipo_pasaje FROM tipo_pasaje WHERE eliminado = FALSE LIMIT 1)`).
+		Scan(&idCliente, &idCanal, &idSede, &idTipoPasaje)
+	if err != nil {
+		db.Exec(`DELETE FROM instancia_tour WHERE id_instancia = $1`, idInstancia)
+		t.Skipf("No hay datos base para crear reservas de prueba: %v", err)
+	}
+
+	// Limpiar los datos creados al terminar
+	defer func() {
+		db.Exec(`DELETE FROM pasajes_cantidad WHERE id_reserva IN (SELECT id_reserva FROM reserva WHERE id_instancia = $1)`, idInstancia)
+		db.Exec(`DELETE FROM reserva WHERE id_instancia = $1`, idInstancia)
+		db.Exec(`DELETE FROM instancia_tour WHERE id_instancia = $1`, idInstancia)
+	}()
+
+	reservaRepo := repositorios.NewReservaRepository(db)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	exitosas := 0
+
+	for i := 0; i < intentos; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := reservaRepo.Create(&entidades.NuevaReservaRequest{
+				IDCliente:   idCliente,
+				IDInstancia: idInstancia,
+				IDCanal:     idCanal,
+				IDSede:      idSede,
+				TotalPagar:  entidades.DineroDesdeFloat(10),
+				CantidadPasajes: []entidades.PasajeCantidadRequest{
+					{IDTipoPasaje: idTipoPasaje, Cantidad: 1},
+				},
+			})
+			if err == nil {
+				mu.Lock()
+				exitosas++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if exitosas != cupoInicial {
+		t.Errorf("Esperaba %d reservas exitosas, pero hubo %d", cupoInicial, exitosas)
+	}
+
+	var cupoFinal, pasajerosReservados int
+	err = db.QueryRow(`SELECT cupo_disponible FROM instancia_tour WHERE id_instancia = $1`, idInstancia).Scan(&cupoFinal)
+	if err != nil {
+		t.Fatalf("Error al consultar cupo final: %v", err)
+	}
+
+	err = db.QueryRow(`SELECT COALESCE(SUM(pc.cantidad), 0) FROM pasajes_cantidad pc
+                       INNER JOIN reserva r ON pc.id_reserva = r.id_reserva
+                       WHERE r.id_instancia = $1 AND pc.eliminado = FALSE`, idInstancia).Scan(&pasajerosReservados)
+	if err != nil {
+		t.Fatalf("Error al consultar pasajeros reservados: %v", err)
+	}
+
+	if cupoFinal != 0 {
+		t.Errorf("Esperaba cupo final 0, pero quedó %d", cupoFinal)
+	}
+
+	if pasajerosReservados > cupoInicial {
+		t.Errorf("Sobreventa: %d pasajeros reservados para un cupo de %d", pasajerosReservados, cupoInicial)
+	}
+}
@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"testing"
+)
+
+// TestActualizarReservaAjustaCupoUnaVez edita una reserva cancelándola, cambiando sus pasajes mientras
+// está cancelada, reactivándola y moviéndola de instancia, y verifica el cupo de cada instancia en cada paso
+func TestActualizarReservaAjustaCupoUnaVez(t *testing.T) {
+	db := abrirBaseDatosPrueba(t)
+	defer db.Close()
+
+	// Crear dos instancias futuras de prueba copiando una existente
+	crearInstancia := func(cupo int) (int, error) {
+		var id int
+		err := db.QueryRow(`INSERT INTO instancia_tour (id_tour_programado, fecha_especifica, hora_inicio, hora_fin,
+                            id_chofer, id_embarcacion, cupo_disponible, estado, eliminado)
+                            SELECT id_tour_programado, CURRENT_DATE + 30, hora_inicio, hora_fin,
+                            id_chofer, id_embarcacion, $1, 'PROGRAMADO', FALSE
+                            FROM instancia_tour WHERE eliminado = FALSE ORDER BY id_instancia LIMIT 1
+                            RETURNING id_instancia`, cupo).Scan(&id)
+		return id, err
+	}
+
+	idInstancia, err := crearInstancia(10)
+	if err != nil {
+		t.Skipf("No hay datos base para crear la instancia de prueba: %v", err)
+	}
+	idOtraInstancia, err := crearInstancia(10)
+	if err != nil {
+		db.Exec(`DELETE FROM instancia_tour WHERE id_instancia = $1`, idInstancia)
+		t.Fatalf("Error al crear la segunda instancia: %v", err)
+	}
+
+	// Limpiar los datos creados al terminar
+	defer func() {
+		db.Exec(`DELETE FROM pasajes_cantidad WHERE id_reserva IN (SELECT id_reserva FROM reserva WHERE id_instancia IN ($1, $2))`, idInstancia, idOtraInstancia)
+		db.Exec(`DELETE FROM reserva WHERE id_instancia IN ($1, $2)`, idInstancia, idOtraInstancia)
+		db.Exec(`DELETE FROM instancia_tour WHERE id_instancia IN ($1, $2)`, idInstancia, idOtraInstancia)
+	}()
+
+	// Obtener datos de referencia existentes para la reserva
+	var idCliente, idCanal, idSede, idTipoPasaje int
+	err = db.QueryRow(`SELECT
+                       (SELECT id_cliente FROM cliente WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT id_canal FROM canal_venta WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT id_sede FROM sede WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT tpa.id_tipo_pasaje FROM tipo_pasaje tpa
+                        INNER JOIN tour_programado tp ON tpa.id_tipo_tour = tp.id_tipo_tour
+                        INNER JOIN instancia_tour it ON it.id_tour_programado = tp.id_tour_programado
+                        WHERE it.id_instancia = $1 AND tpa.eliminado = FALSE LIMIT 1)`, idInstancia).
+		Scan(&idCliente, &idCanal, &idSede, &idTipoPasaje)
+	if err != nil {
+		t.Skipf("No hay datos base para crear reservas de prueba: %v", err)
+	}
+
+	reservaRepo := repositorios.NewReservaRepository(db)
+
+	idReserva, err := reservaRepo.Create(&entidades.NuevaReservaRequest{
+		IDCliente:   idCliente,
+		IDInstancia: idInstancia,
+		IDCanal:     idCanal,
+		IDSede:      idSede,
+		TotalPagar:  entidades.DineroDesdeFloat(10),
+		CantidadPasajes: []entidades.PasajeCantidadRequest{
+			{IDTipoPasaje: idTipoPasaje, Cantidad: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error al crear la reserva: %v", err)
+	}
+
+	cupo := func(id int) int {
+		var cupoDisponible int
+		db.QueryRow(`SELECT cupo_disponible FROM instancia_tour WHERE id_instancia = $1`, id).Scan(&cupoDisponible)
+		return cupoDisponible
+	}
+
+	actualizar := func(idInstanciaNueva int, estado string, cantidad int) error {
+		return reservaRepo.Update(idReserva, &entidades.ActualizarReservaRequest{
+			IDCliente:   idCliente,
+			IDInstancia: idInstanciaNueva,
+			IDCanal:     idCanal,
+			IDSede:      idSede,
+			TotalPagar:  entidades.DineroDesdeFloat(10),
+			Estado:      estado,
+			CantidadPasajes: []entidades.PasajeCantidadRequest{
+				{IDTipoPasaje: idTipoPasaje, Cantidad: cantidad},
+			},
+		})
+	}
+
+	pasos := []struct {
+		nombre           string
+		idInstancia      int
+		estado           string
+		cantidad         int
+		cupoEsperado     int
+		cupoOtraEsperado int
+	}{
+		{"Cancelar cambiando la cantidad devuelve los pasajes anteriores", idInstancia, "CANCELADA", 3, 10, 10},
+		{"Editar una reserva cancelada no ocupa cupo", idInstancia, "CANCELADA", 4, 10, 10},
+		{"Reactivar en la misma instancia ocupa el cupo una sola vez", idInstancia, "RESERVADO", 4, 6, 10},
+		{"Cancelar moviendo de instancia devuelve el cupo a la instancia anterior", idOtraInstancia, "CANCELADA", 1, 10, 10},
+		{"Reactivar en otra instancia ocupa el cupo solo en ella", idOtraInstancia, "RESERVADO", 5, 10, 5},
+	}
+
+	for _, paso := range pasos {
+		if err := actualizar(paso.idInstancia, paso.estado, paso.cantidad); err != nil {
+			t.Fatalf("%s: error al actualizar la reserva: %v", paso.nombre, err)
+		}
+
+		if got := cupo(idInstancia); got != paso.cupoEsperado {
+			t.Errorf("%s: esperaba cupo %d en la instancia original, pero quedó %d", paso.nombre, paso.cupoEsperado, got)
+		}
+		if got := cupo(idOtraInstancia); got != paso.cupoOtraEsperado {
+			t.Errorf("%s: esperaba cupo %d en la otra instancia, pero quedó %d", paso.nombre, paso.cupoOtraEsperado, got)
+		}
+	}
+}
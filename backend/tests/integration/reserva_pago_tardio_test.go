@@ -0,0 +1,132 @@
+package integration
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"testing"
+)
+
+// TestPagoTardioReservaExpirada registra pagos aprobados que llegan después de vencer la retención:
+// con cupo la reserva se recupera; sin cupo sigue expirada y se solicita la devolución del pago
+func TestPagoTardioReservaExpirada(t *testing.T) {
+	db := abrirBaseDatosPrueba(t)
+	defer db.Close()
+
+	// Crear una instancia futura de prueba copiando una existente
+	var idInstancia int
+	err := db.QueryRow(`INSERT INTO instancia_tour (id_tour_programado, fecha_especifica, hora_inicio, hora_fin,
+                        id_chofer, id_embarcacion, cupo_disponible, estado, eliminado)
+                        SELECT id_tour_programado, CURRENT_DATE + 30, hora_inicio, hora_fin,
+                        id_chofer, id_embarcacion, 4, 'PROGRAMADO', FALSE
+                        FROM instancia_tour WHERE eliminado = FALSE ORDER BY id_instancia LIMIT 1
+                        RETURNING id_instancia`).Scan(&idInstancia)
+	if err != nil {
+		t.Skipf("No hay datos base para crear la instancia de prueba: %v", err)
+	}
+
+	// Limpiar los datos creados al terminar
+	defer func() {
+		db.Exec(`DELETE FROM devolucion_pago WHERE id_pago IN (SELECT p.id_pago FROM pago p
+                 INNER JOIN reserva r ON p.id_reserva = r.id_reserva WHERE r.id_instancia = $1)`, idInstancia)
+		db.Exec(`DELETE FROM pago WHERE id_reserva IN (SELECT id_reserva FROM reserva WHERE id_instancia = $1)`, idInstancia)
+		db.Exec(`DELETE FROM pasajes_cantidad WHERE id_reserva IN (SELECT id_reserva FROM reserva WHERE id_instancia = $1)`, idInstancia)
+		db.Exec(`DELETE FROM reserva WHERE id_instancia = $1`, idInstancia)
+		db.Exec(`DELETE FROM instancia_tour WHERE id_instancia = $1`, idInstancia)
+	}()
+
+	// Obtener datos de referencia existentes para la reserva
+	var idCliente, idCanal, idSede, idTipoPasaje int
+	err = db.QueryRow(`SELECT
+                       (SELECT id_cliente FROM cliente WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT id_canal FROM canal_venta WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT id_sede FROM sede WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT tpa.id_tipo_pasaje FROM tipo_pasaje tpa
+                        INNER JOIN tour_programado tp ON tpa.id_tipo_tour = tp.id_tipo_tour
+                        INNER JOIN instancia_tour it ON it.id_tour_programado = tp.id_tour_programado
+                        WHERE it.id_instancia = $1 AND tpa.eliminado = FALSE LIMIT 1)`, idInstancia).
+		Scan(&idCliente, &idCanal, &idSede, &idTipoPasaje)
+	if err != nil {
+		t.Skipf("No hay datos base para crear reservas de prueba: %v", err)
+	}
+
+	reservaRepo := repositorios.NewReservaRepository(db)
+
+	// Crea una reserva de 2 pasajeros y la deja expirada, con su cupo ya devuelto a la instancia
+	crearReservaExpirada := func() int {
+		idReserva, err := reservaRepo.Create(&entidades.NuevaReservaRequest{
+			IDCliente:   idCliente,
+			IDInstancia: idInstancia,
+			IDCanal:     idCanal,
+			IDSede:      idSede,
+			TotalPagar:  entidades.DineroDesdeFloat(10),
+			CantidadPasajes: []entidades.PasajeCantidadRequest{
+				{IDTipoPasaje: idTipoPasaje, Cantidad: 2},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Error al crear la reserva: %v", err)
+		}
+		db.Exec(`UPDATE reserva SET estado = 'EXPIRADA', fecha_expiracion = CURRENT_TIMESTAMP - INTERVAL '1 minute'
+                 WHERE id_reserva = $1`, idReserva)
+		db.Exec(`UPDATE instancia_tour SET cupo_disponible = cupo_disponible + 2 WHERE id_instancia = $1`, idInstancia)
+		return idReserva
+	}
+
+	pago := entidades.ConversionMoneda{Monto: entidades.Soles(1000), TipoCambio: 1, MontoBase: entidades.Soles(1000)}
+
+	// Con cupo disponible la reserva recupera sus pasajes y queda confirmada
+	idRecuperada := crearReservaExpirada()
+	resultado, err := reservaRepo.RegistrarPagoPasarela(idRecuperada, entidades.PasarelaFake, "PRUEBA-TARDIO-1", pago)
+	if err != nil {
+		t.Fatalf("Error al registrar el pago con cupo: %v", err)
+	}
+	if resultado.EstadoReserva != "CONFIRMADA" || resultado.IDDevolucion != 0 {
+		t.Errorf("Con cupo se esperaba la reserva confirmada sin devolución: %+v", resultado)
+	}
+
+	var cupo int
+	db.QueryRow(`SELECT cupo_disponible FROM instancia_tour WHERE id_instancia = $1`, idInstancia).Scan(&cupo)
+	if cupo != 2 {
+		t.Errorf("Se esperaba cupo 2 tras recuperar la reserva, quedó %d", cupo)
+	}
+
+	// Sin cupo la reserva sigue expirada y el pago queda con su devolución pendiente de aprobación
+	idSinCupo := crearReservaExpirada()
+	db.Exec(`UPDATE instancia_tour SET cupo_disponible = 1 WHERE id_instancia = $1`, idInstancia)
+
+	resultado, err = reservaRepo.RegistrarPagoPasarela(idSinCupo, entidades.PasarelaFake, "PRUEBA-TARDIO-2", pago)
+	if err != nil {
+		t.Fatalf("Error al registrar el pago sin cupo: %v", err)
+	}
+	if resultado.EstadoReserva != "EXPIRADA" || resultado.IDDevolucion == 0 {
+		t.Fatalf("Sin cupo se esperaba la reserva expirada con una devolución: %+v", resultado)
+	}
+
+	var estadoDevolucion string
+	var montoDevolucion entidades.Dinero
+	var idPagoDevolucion int
+	err = db.QueryRow(`SELECT estado, monto_devolucion, id_pago FROM devolucion_pago WHERE id_devolucion = $1`,
+		resultado.IDDevolucion).Scan(&estadoDevolucion, &montoDevolucion, &idPagoDevolucion)
+	if err != nil {
+		t.Fatalf("Error al consultar la devolución: %v", err)
+	}
+	if estadoDevolucion != "PENDIENTE" || !montoDevolucion.Igual(pago.Monto) || idPagoDevolucion != resultado.IDPago {
+		t.Errorf("Devolución inesperada: estado %s, monto %s, pago %d", estadoDevolucion, montoDevolucion, idPagoDevolucion)
+	}
+
+	db.QueryRow(`SELECT cupo_disponible FROM instancia_tour WHERE id_instancia = $1`, idInstancia).Scan(&cupo)
+	if cupo != 1 {
+		t.Errorf("El pago sin cupo no debería tomar cupo, quedó %d", cupo)
+	}
+
+	// Repetir la notificación no solicita otra devolución
+	resultado, err = reservaRepo.RegistrarPagoPasarela(idSinCupo, entidades.PasarelaFake, "PRUEBA-TARDIO-2", pago)
+	if err != nil {
+		t.Fatalf("Error al repetir el pago: %v", err)
+	}
+	var devoluciones int
+	db.QueryRow(`SELECT COUNT(*) FROM devolucion_pago WHERE id_pago = $1`, resultado.IDPago).Scan(&devoluciones)
+	if devoluciones != 1 {
+		t.Errorf("Se esperaba una sola devolución del pago, hay %d", devoluciones)
+	}
+}
@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/repositorios"
+	"testing"
+)
+
+// TestReprogramarReservaMueveCupo reprograma una reserva entre dos instancias del mismo tour
+// y verifica que el cupo pase de una a otra y quede registrado en el historial
+func TestReprogramarReservaMueveCupo(t *testing.T) {
+	db := abrirBaseDatosPrueba(t)
+	defer db.Close()
+
+	// Crear dos instancias futuras de prueba copiando una existente
+	crearInstancia := func(cupo int) (int, error) {
+		var id int
+		err := db.QueryRow(`INSERT INTO instancia_tour (id_tour_programado, fecha_especifica, hora_inicio, hora_fin,
+                            id_chofer, id_embarcacion, cupo_disponible, estado, eliminado)
+                            SELECT id_tour_programado, CURRENT_DATE + 30, hora_inicio, hora_fin,
+                            id_chofer, id_embarcacion, $1, 'PROGRAMADO', FALSE
+                            FROM instancia_tour WHERE eliminado = FALSE ORDER BY id_instancia LIMIT 1
+                            RETURNING id_instancia`, cupo).Scan(&id)
+		return id, err
+	}
+
+	idOrigen, err := crearInstancia(10)
+	if err != nil {
+		t.Skipf("No hay datos base para crear la instancia de prueba: %v", err)
+	}
+	idDestino, err := crearInstancia(3)
+	if err != nil {
+		db.Exec(`DELETE FROM instancia_tour WHERE id_instancia = $1`, idOrigen)
+		t.Fatalf("Error al crear la instancia de destino: %v", err)
+	}
+
+	// Limpiar los datos creados al terminar
+	defer func() {
+		db.Exec(`DELETE FROM reserva_reprogramacion WHERE id_instancia_origen IN ($1, $2)`, idOrigen, idDestino)
+		db.Exec(`DELETE FROM pasajes_cantidad WHERE id_reserva IN (SELECT id_reserva FROM reserva WHERE id_instancia IN ($1, $2))`, idOrigen, idDestino)
+		db.Exec(`DELETE FROM reserva WHERE id_instancia IN ($1, $2)`, idOrigen, idDestino)
+		db.Exec(`DELETE FROM instancia_tour WHERE id_instancia IN ($1, $2)`, idOrigen, idDestino)
+	}()
+
+	// Obtener datos de referencia existentes para la reserva
+	var idCliente, idCanal, idSede, idTipoPasaje int
+	err = db.QueryRow(`SELECT
+                       (SELECT id_cliente FROM cliente WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT id_canal FROM canal_venta WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT id_sede FROM sede WHERE eliminado = FALSE LIMIT 1),
+                       (SELECT tpa.id_tipo_pasaje FROM tipo_pasaje tpa
+                        INNER JOIN tour_programado tp ON tpa.id_tipo_tour = tp.id_tipo_tour
+                        INNER JOIN instancia_tour it ON it.id_tour_programado = tp.id_tour_programado
+                        WHERE it.id_instancia = $1 AND tpa.eliminado = FALSE LIMIT 1)`, idOrigen).
+		Scan(&idCliente, &idCanal, &idSede, &idTipoPasaje)
+	if err != nil {
+		t.Skipf("No hay datos base para crear reservas de prueba: %v", err)
+	}
+
+	reservaRepo := repositorios.NewReservaRepository(db)
+
+	idReserva, err := reservaRepo.Create(&entidades.NuevaReservaRequest{
+		IDCliente:   idCliente,
+		IDInstancia: idOrigen,
+		IDCanal:     idCanal,
+		IDSede:      idSede,
+		TotalPagar:  entidades.DineroDesdeFloat(10),
+		CantidadPasajes: []entidades.PasajeCantidadRequest{
+			{IDTipoPasaje: idTipoPasaje, Cantidad: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error al crear la reserva: %v", err)
+	}
+
+	resultado, err := reservaRepo.Reprogramar(idReserva, &entidades.ReprogramarReservaRequest{
+		IDInstanciaDestino: idDestino,
+		Motivo:             "Cambio de fecha solicitado",
+	}, "ADMIN", nil)
+	if err != nil {
+		t.Fatalf("Error al reprogramar la reserva: %v", err)
+	}
+
+	if resultado.IDInstanciaOrigen != idOrigen || resultado.IDInstanciaDestino != idDestino {
+		t.Errorf("Historial inesperado: %+v", resultado.ReprogramacionReserva)
+	}
+
+	var cupoOrigen, cupoDestino int
+	db.QueryRow(`SELECT cupo_disponible FROM instancia_tour WHERE id_instancia = $1`, idOrigen).Scan(&cupoOrigen)
+	db.QueryRow(`SELECT cupo_disponible FROM instancia_tour WHERE id_instancia = $1`, idDestino).Scan(&cupoDestino)
+
+	if cupoOrigen != 10 {
+		t.Errorf("Esperaba que el origen recupere su cupo (10), pero quedó %d", cupoOrigen)
+	}
+	if cupoDestino != 1 {
+		t.Errorf("Esperaba cupo 1 en el destino, pero quedó %d", cupoDestino)
+	}
+
+	// Reprogramar a la instancia en la que ya está la reserva no debe ser posible
+	_, err = reservaRepo.Reprogramar(idReserva, &entidades.ReprogramarReservaRequest{IDInstanciaDestino: idDestino}, "ADMIN", nil)
+	if err == nil {
+		t.Errorf("Esperaba error al reprogramar a la misma instancia")
+	}
+
+	historial, err := reservaRepo.ListReprogramaciones(idReserva)
+	if err != nil {
+		t.Fatalf("Error al listar reprogramaciones: %v", err)
+	}
+	if len(historial) != 1 {
+		t.Errorf("Esperaba 1 reprogramación en el historial, pero hay %d", len(historial))
+	}
+}
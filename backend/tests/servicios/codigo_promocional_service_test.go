@@ -0,0 +1,168 @@
+package servicios_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"testing"
+)
+
+// TestCalcularDescuentoPromocion verifica las restricciones, los límites de usos y el monto de un código
+func TestCalcularDescuentoPromocion(t *testing.T) {
+	entero := func(valor int) *int { return &valor }
+
+	cotizacion := &entidades.CotizacionReserva{
+		Lineas: []entidades.LineaCotizacionReserva{
+			{Tipo: "PASAJE", ID: 1, Cantidad: 2, Subtotal: entidades.DineroDesdeFloat(71)},
+			{Tipo: "PASAJE", ID: 2, Cantidad: 1, Subtotal: entidades.DineroDesdeFloat(20)},
+			{Tipo: "PAQUETE", ID: 5, Cantidad: 1, Subtotal: entidades.DineroDesdeFloat(99.90)},
+		},
+		Subtotal: entidades.DineroDesdeFloat(190.90),
+	}
+	contexto := entidades.ContextoPromocion{IDTipoTour: 10, IDSede: 1, IDCanal: 1, IDCliente: 7}
+
+	porcentaje := func(valor float64) entidades.CodigoPromocional {
+		return entidades.CodigoPromocional{TipoDescuento: "PORCENTAJE", Porcentaje: valor, Activo: true, Vigente: true}
+	}
+	montoFijo := func(valor float64) entidades.CodigoPromocional {
+		return entidades.CodigoPromocional{TipoDescuento: "MONTO_FIJO", MontoDescuento: entidades.DineroDesdeFloat(valor), Activo: true, Vigente: true}
+	}
+
+	tests := []struct {
+		nombre     string
+		codigo     func() entidades.CodigoPromocional
+		uso        entidades.UsoPromocion
+		descuento  string
+		debeFallar bool
+	}{
+		{
+			// 10% de 190.90 = 19.09
+			nombre:    "Porcentaje sobre el subtotal",
+			codigo:    func() entidades.CodigoPromocional { return porcentaje(10) },
+			descuento: "19.09",
+		},
+		{
+			nombre:    "Monto fijo",
+			codigo:    func() entidades.CodigoPromocional { return montoFijo(25) },
+			descuento: "25.00",
+		},
+		{
+			nombre:    "Monto fijo mayor al subtotal",
+			codigo:    func() entidades.CodigoPromocional { return montoFijo(500) },
+			descuento: "190.90",
+		},
+		{
+			// Solo las líneas del tipo de pasaje 1: 50% de 71.00
+			nombre: "Restringido a un tipo de pasaje",
+			codigo: func() entidades.CodigoPromocional {
+				c := porcentaje(50)
+				c.IDTipoPasaje = entero(1)
+				return c
+			},
+			descuento: "35.50",
+		},
+		{
+			nombre: "Tipo de pasaje ausente en la reserva",
+			codigo: func() entidades.CodigoPromocional {
+				c := porcentaje(50)
+				c.IDTipoPasaje = entero(3)
+				return c
+			},
+			debeFallar: true,
+		},
+		{
+			nombre: "Código inactivo",
+			codigo: func() entidades.CodigoPromocional {
+				c := porcentaje(10)
+				c.Activo = false
+				return c
+			},
+			debeFallar: true,
+		},
+		{
+			nombre: "Fuera de vigencia",
+			codigo: func() entidades.CodigoPromocional {
+				c := porcentaje(10)
+				c.Vigente = false
+				return c
+			},
+			debeFallar: true,
+		},
+		{
+			nombre: "Otro tipo de tour",
+			codigo: func() entidades.CodigoPromocional {
+				c := porcentaje(10)
+				c.IDTipoTour = entero(20)
+				return c
+			},
+			debeFallar: true,
+		},
+		{
+			nombre: "Otra sede",
+			codigo: func() entidades.CodigoPromocional {
+				c := porcentaje(10)
+				c.IDSede = entero(2)
+				return c
+			},
+			debeFallar: true,
+		},
+		{
+			nombre: "Otro canal de venta",
+			codigo: func() entidades.CodigoPromocional {
+				c := porcentaje(10)
+				c.IDCanal = entero(2)
+				return c
+			},
+			debeFallar: true,
+		},
+		{
+			nombre: "Usos globales agotados",
+			codigo: func() entidades.CodigoPromocional {
+				c := porcentaje(10)
+				c.UsosMaximos = entero(100)
+				return c
+			},
+			uso:        entidades.UsoPromocion{Totales: 100},
+			debeFallar: true,
+		},
+		{
+			nombre: "Usos del cliente agotados",
+			codigo: func() entidades.CodigoPromocional {
+				c := porcentaje(10)
+				c.UsosPorCliente = entero(1)
+				return c
+			},
+			uso:        entidades.UsoPromocion{Totales: 3, Cliente: 1},
+			debeFallar: true,
+		},
+		{
+			nombre: "Con usos disponibles",
+			codigo: func() entidades.CodigoPromocional {
+				c := montoFijo(10)
+				c.UsosMaximos = entero(100)
+				c.UsosPorCliente = entero(2)
+				return c
+			},
+			uso:       entidades.UsoPromocion{Totales: 99, Cliente: 1},
+			descuento: "10.00",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			codigo := tc.codigo()
+			descuento, err := servicios.CalcularDescuentoPromocion(&codigo, cotizacion, contexto, &tc.uso)
+			if tc.debeFallar {
+				if err == nil {
+					t.Errorf("Esperaba un error, pero se descontó %s", descuento)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error inesperado: %v", err)
+			}
+			if descuento.String() != tc.descuento {
+				t.Errorf("Esperaba un descuento de %s, obtuvo %s", tc.descuento, descuento)
+			}
+		})
+	}
+}
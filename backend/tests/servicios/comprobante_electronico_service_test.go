@@ -0,0 +1,254 @@
+package servicios_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"sistema-toursseft/internal/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+var emisorPrueba = entidades.EmisorElectronico{
+	RUC:             "20123456789",
+	RazonSocial:     "TOURS PARACAS S.A.C.",
+	NombreComercial: "Tours Paracas",
+	Direccion:       "Av. Paracas 123",
+	Ubigeo:          "110501",
+}
+
+// comprobantePrueba arma un comprobante de pago con IGV del 18%
+func comprobantePrueba(tipo, numero string) *entidades.ComprobantePago {
+	return &entidades.ComprobantePago{
+		ID:                5,
+		IDReserva:         42,
+		Tipo:              tipo,
+		NumeroComprobante: numero,
+		FechaEmision:      time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC),
+		Subtotal:          entidades.DineroDesdeFloat(100),
+		IGV:               entidades.DineroDesdeFloat(18),
+		Total:             entidades.DineroDesdeFloat(118),
+		Estado:            "EMITIDO",
+		TourNombre:        "Islas Ballestas",
+		TourFecha:         time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+var clienteDNI = &entidades.Cliente{TipoDocumento: "DNI", NumeroDocumento: "12345678", Nombres: "Ana", Apellidos: "Quispe"}
+var clienteRUC = &entidades.Cliente{TipoDocumento: "RUC", NumeroDocumento: "20987654321", RazonSocial: "Viajes Sur E.I.R.L.", DireccionFiscal: "Jr. Lima 456"}
+
+// TestArmarDocumentoElectronico verifica las reglas de serie, tipo de cliente y montos
+func TestArmarDocumentoElectronico(t *testing.T) {
+	casos := []struct {
+		nombre      string
+		comprobante *entidades.ComprobantePago
+		cliente     *entidades.Cliente
+		debeSerOK   bool
+	}{
+		{"Boleta a cliente con DNI", comprobantePrueba("BOLETA", "B001-123"), clienteDNI, true},
+		{"Factura a cliente con RUC", comprobantePrueba("FACTURA", "F001-1"), clienteRUC, true},
+		{"Boleta a cliente con RUC", comprobantePrueba("BOLETA", "B002-7"), clienteRUC, true},
+		{"Factura a cliente sin RUC", comprobantePrueba("FACTURA", "F001-1"), clienteDNI, false},
+		{"Factura con serie de boleta", comprobantePrueba("FACTURA", "B001-1"), clienteRUC, false},
+		{"Número sin serie", comprobantePrueba("BOLETA", "123"), clienteDNI, false},
+		{"Correlativo de más de 8 dígitos", comprobantePrueba("BOLETA", "B001-123456789"), clienteDNI, false},
+		{"Correlativo cero", comprobantePrueba("BOLETA", "B001-0"), clienteDNI, false},
+		{"Factura a RUC sin dirección fiscal", comprobantePrueba("FACTURA", "F001-1"),
+			&entidades.Cliente{TipoDocumento: "RUC", NumeroDocumento: "20987654321", RazonSocial: "Viajes Sur E.I.R.L."}, false},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			_, err := servicios.ArmarDocumentoElectronico(caso.comprobante, caso.cliente, emisorPrueba)
+			if caso.debeSerOK && err != nil {
+				t.Errorf("Error inesperado: %v", err)
+			}
+			if !caso.debeSerOK && err == nil {
+				t.Error("Esperaba error de validación")
+			}
+		})
+	}
+
+	descuadrado := comprobantePrueba("BOLETA", "B001-1")
+	descuadrado.Total = entidades.DineroDesdeFloat(120)
+	if _, err := servicios.ArmarDocumentoElectronico(descuadrado, clienteDNI, emisorPrueba); err == nil {
+		t.Error("Esperaba error cuando subtotal más IGV no coincide con el total")
+	}
+}
+
+// TestGenerarXMLFactura verifica los datos principales del XML UBL y su firma
+func TestGenerarXMLFactura(t *testing.T) {
+	doc, err := servicios.ArmarDocumentoElectronico(comprobantePrueba("FACTURA", "F001-25"), clienteRUC, emisorPrueba)
+	if err != nil {
+		t.Fatalf("Error al armar el documento: %v", err)
+	}
+	if doc.PorcentajeIGV != 18 {
+		t.Errorf("Esperaba IGV de 18%%, obtuvo %v", doc.PorcentajeIGV)
+	}
+
+	firmante, _ := utils.NuevoFirmanteXMLAutofirmado(emisorPrueba.RazonSocial)
+	raiz, contenedor := servicios.GenerarXMLComprobante(doc)
+	if _, err := firmante.FirmarEnvuelto(raiz, contenedor, "SIGN"+emisorPrueba.RUC); err != nil {
+		t.Fatalf("Error al firmar: %v", err)
+	}
+	if err := utils.VerificarFirmaEnvuelta(raiz, contenedor); err != nil {
+		t.Errorf("La firma del comprobante no es válida: %v", err)
+	}
+
+	xml := string(utils.DocumentoXML(raiz))
+	esperados := []string{
+		`<cbc:UBLVersionID>2.1</cbc:UBLVersionID>`,
+		`<cbc:ID>F001-25</cbc:ID>`,
+		`<cbc:IssueDate>2025-03-10</cbc:IssueDate>`,
+		`listID="0101"`,
+		`>01</cbc:InvoiceTypeCode>`,
+		`<cbc:Note languageLocaleID="1000">CIENTO DIECIOCHO CON 00/100 SOLES</cbc:Note>`,
+		`<cbc:ID schemeID="6">20123456789</cbc:ID>`,
+		`<cbc:ID schemeID="6">20987654321</cbc:ID>`,
+		`<cbc:RegistrationName>Viajes Sur E.I.R.L.</cbc:RegistrationName>`,
+		`<cbc:PaymentMeansID>Contado</cbc:PaymentMeansID>`,
+		`<cbc:TaxAmount currencyID="PEN">18.00</cbc:TaxAmount>`,
+		`<cbc:PayableAmount currencyID="PEN">118.00</cbc:PayableAmount>`,
+		`<cbc:Description>Tour Islas Ballestas del 12/03/2025 - Reserva #42</cbc:Description>`,
+		`<ds:Signature Id="SIGN20123456789">`,
+	}
+	for _, esperado := range esperados {
+		if !strings.Contains(xml, esperado) {
+			t.Errorf("El XML no contiene %s", esperado)
+		}
+	}
+
+	if nombre := servicios.NombreArchivoSunat(doc.Emisor.RUC, doc.TipoDocumento, doc.Serie, doc.Correlativo); nombre != "20123456789-01-F001-25" {
+		t.Errorf("Nombre de archivo inesperado: %s", nombre)
+	}
+}
+
+// TestGenerarXMLBoletaExonerada verifica que una boleta sin IGV se declare exonerada
+func TestGenerarXMLBoletaExonerada(t *testing.T) {
+	comprobante := comprobantePrueba("BOLETA", "B001-3")
+	comprobante.Subtotal, comprobante.IGV, comprobante.Total = entidades.DineroDesdeFloat(50), entidades.DineroDesdeFloat(0), entidades.DineroDesdeFloat(50)
+
+	doc, err := servicios.ArmarDocumentoElectronico(comprobante, clienteDNI, emisorPrueba)
+	if err != nil {
+		t.Fatalf("Error al armar el documento: %v", err)
+	}
+	raiz, _ := servicios.GenerarXMLComprobante(doc)
+	xml := raiz.Canonico()
+
+	for _, esperado := range []string{
+		`>03</cbc:InvoiceTypeCode>`,
+		`<cbc:ID schemeID="1">12345678</cbc:ID>`,
+		`<cbc:RegistrationName>Ana Quispe</cbc:RegistrationName>`,
+		`<cbc:TaxExemptionReasonCode>20</cbc:TaxExemptionReasonCode>`,
+		`<cbc:Name>EXO</cbc:Name>`,
+	} {
+		if !strings.Contains(xml, esperado) {
+			t.Errorf("El XML no contiene %s", esperado)
+		}
+	}
+	if strings.Contains(xml, "cac:PaymentTerms") {
+		t.Error("La boleta no debería incluir forma de pago")
+	}
+}
+
+// TestGenerarXMLBoletaInafecta verifica el tributo de una operación inafecta y que no admita IGV
+func TestGenerarXMLBoletaInafecta(t *testing.T) {
+	comprobante := comprobantePrueba("BOLETA", "B001-4")
+	comprobante.Subtotal, comprobante.IGV, comprobante.Total = entidades.DineroDesdeFloat(80), entidades.DineroDesdeFloat(0), entidades.DineroDesdeFloat(80)
+	comprobante.Afectacion = "INAFECTO"
+
+	doc, err := servicios.ArmarDocumentoElectronico(comprobante, clienteDNI, emisorPrueba)
+	if err != nil {
+		t.Fatalf("Error al armar el documento: %v", err)
+	}
+	raiz, _ := servicios.GenerarXMLComprobante(doc)
+	xml := raiz.Canonico()
+
+	for _, esperado := range []string{
+		`<cbc:TaxExemptionReasonCode>30</cbc:TaxExemptionReasonCode>`,
+		`<cbc:ID>9998</cbc:ID>`,
+		`<cbc:Name>INA</cbc:Name>`,
+	} {
+		if !strings.Contains(xml, esperado) {
+			t.Errorf("El XML no contiene %s", esperado)
+		}
+	}
+
+	comprobante.Subtotal, comprobante.IGV, comprobante.Total = entidades.DineroDesdeFloat(80), entidades.DineroDesdeFloat(14.4), entidades.DineroDesdeFloat(94.4)
+	if _, err := servicios.ArmarDocumentoElectronico(comprobante, clienteDNI, emisorPrueba); err == nil {
+		t.Error("Esperaba un error por IGV en un comprobante inafecto")
+	}
+}
+
+// notaPrueba arma una nota de crédito parcial sobre la factura F001-25
+func notaPrueba(numero string) *entidades.ComprobantePago {
+	idReferencia := 4
+	nota := comprobantePrueba("NOTA_CREDITO", numero)
+	nota.Subtotal, nota.IGV, nota.Total = entidades.DineroDesdeFloat(50), entidades.DineroDesdeFloat(9), entidades.DineroDesdeFloat(59)
+	nota.IDComprobanteReferencia = &idReferencia
+	nota.TipoReferencia = "FACTURA"
+	nota.NumeroReferencia = "F001-25"
+	nota.CodigoMotivo = "07"
+	nota.Motivo = "Devolución de un pasaje"
+	return nota
+}
+
+// TestGenerarXMLNotaCredito verifica la referencia al comprobante modificado y los elementos propios de la nota
+func TestGenerarXMLNotaCredito(t *testing.T) {
+	if _, err := servicios.ArmarDocumentoElectronico(notaPrueba("BC01-1"), clienteRUC, emisorPrueba); err == nil {
+		t.Error("Esperaba error cuando la serie de la nota no empieza con la letra de la factura")
+	}
+
+	doc, err := servicios.ArmarDocumentoElectronico(notaPrueba("FC01-1"), clienteRUC, emisorPrueba)
+	if err != nil {
+		t.Fatalf("Error al armar el documento: %v", err)
+	}
+	if doc.TipoDocumento != servicios.TipoDocumentoNotaCredito || doc.TipoDocumentoReferencia != servicios.TipoDocumentoFactura {
+		t.Errorf("Tipos inesperados: %s sobre %s", doc.TipoDocumento, doc.TipoDocumentoReferencia)
+	}
+
+	raiz, _ := servicios.GenerarXMLComprobante(doc)
+	xml := raiz.Canonico()
+
+	for _, esperado := range []string{
+		`<CreditNote xmlns="urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"`,
+		`<cbc:ID>FC01-1</cbc:ID>`,
+		`<cac:DiscrepancyResponse><cbc:ReferenceID>F001-25</cbc:ReferenceID><cbc:ResponseCode>07</cbc:ResponseCode>`,
+		`<cac:InvoiceDocumentReference><cbc:ID>F001-25</cbc:ID><cbc:DocumentTypeCode>01</cbc:DocumentTypeCode>`,
+		`<cbc:CreditedQuantity unitCode="ZZ">1</cbc:CreditedQuantity>`,
+		`<cbc:PayableAmount currencyID="PEN">59.00</cbc:PayableAmount>`,
+	} {
+		if !strings.Contains(xml, esperado) {
+			t.Errorf("El XML no contiene %s", esperado)
+		}
+	}
+	if strings.Contains(xml, "InvoiceTypeCode") || strings.Contains(xml, "cac:PaymentTerms") {
+		t.Error("La nota de crédito no debería incluir tipo de factura ni forma de pago")
+	}
+}
+
+// TestEnviadorSunatStub verifica que el enviador local devuelva un CDR de aceptación legible
+func TestEnviadorSunatStub(t *testing.T) {
+	enviador := &servicios.EnviadorSunatStub{RUC: emisorPrueba.RUC}
+
+	respuesta, err := enviador.Enviar("20123456789-03-B001-3", []byte("<Invoice/>"))
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if respuesta.Estado != "ACEPTADO" || respuesta.Codigo != "0" {
+		t.Errorf("Esperaba ACEPTADO con código 0, obtuvo %s %s", respuesta.Estado, respuesta.Codigo)
+	}
+	if !strings.Contains(respuesta.Descripcion, "B001-3") {
+		t.Errorf("La descripción debería mencionar el comprobante: %s", respuesta.Descripcion)
+	}
+
+	// El CDR guardado se puede volver a interpretar
+	releida, err := servicios.InterpretarCDR(respuesta.CDR)
+	if err != nil || releida.Estado != "ACEPTADO" {
+		t.Errorf("El CDR devuelto no se pudo interpretar: %v", err)
+	}
+
+	if _, err := servicios.InterpretarCDR([]byte("no es zip")); err == nil {
+		t.Error("Esperaba error con un CDR inválido")
+	}
+}
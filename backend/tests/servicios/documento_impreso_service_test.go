@@ -0,0 +1,135 @@
+package servicios_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"strings"
+	"testing"
+	"time"
+)
+
+var sedePrueba = &entidades.Sede{
+	ID:        1,
+	Nombre:    "Sede Paracas",
+	Direccion: "Av. Paracas 123",
+	Distrito:  "Paracas",
+	Provincia: "Pisco",
+	Pais:      "Perú",
+	Telefono:  "956123456",
+}
+
+// TestTextoQRComprobante verifica el orden de los campos del QR de la representación impresa
+func TestTextoQRComprobante(t *testing.T) {
+	doc, err := servicios.ArmarDocumentoElectronico(comprobantePrueba("FACTURA", "F001-25"), clienteRUC, emisorPrueba)
+	if err != nil {
+		t.Fatalf("Error al armar el documento: %v", err)
+	}
+
+	esperado := "20123456789|01|F001|25|18.00|118.00|2025-03-10|6|20987654321|abc=|"
+	if texto := servicios.TextoQRComprobante(doc, "abc="); texto != esperado {
+		t.Errorf("Esperaba %s, obtuvo %s", esperado, texto)
+	}
+}
+
+// TestArmarComprobantePDF verifica el desglose de IGV y que el QR solo aparezca en comprobantes electrónicos
+func TestArmarComprobantePDF(t *testing.T) {
+	comprobante := comprobantePrueba("FACTURA", "F001-25")
+	doc, err := servicios.ArmarDocumentoElectronico(comprobante, clienteRUC, emisorPrueba)
+	if err != nil {
+		t.Fatalf("Error al armar el documento: %v", err)
+	}
+	electronico := &entidades.ComprobanteElectronico{HashCPE: "hashDePrueba=", EstadoSunat: "ACEPTADO"}
+
+	pdf, err := servicios.ArmarComprobantePDF(comprobante, clienteRUC, emisorPrueba, sedePrueba, nil, doc, electronico)
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) {
+		t.Fatal("El documento no comienza con la cabecera PDF")
+	}
+	for _, esperado := range []string{
+		"(RUC 20123456789)",
+		"(FACTURA ELECTR\\323NICA)",
+		"(F001-25)",
+		"(IGV \\(18%\\): S/)",
+		"(118.00)",
+		"(SON: CIENTO DIECIOCHO CON 00/100 SOLES)",
+		"(Resumen: hashDePrueba=)",
+		" re f", // Módulos del QR
+	} {
+		if !bytes.Contains(pdf, []byte(esperado)) {
+			t.Errorf("El PDF no contiene %s", esperado)
+		}
+	}
+
+	// Sin emisión electrónica no hay hash ni QR
+	pdf, err = servicios.ArmarComprobantePDF(comprobante, clienteRUC, emisorPrueba, sedePrueba, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if bytes.Contains(pdf, []byte("Resumen:")) || bytes.Contains(pdf, []byte(" re f")) {
+		t.Error("Un comprobante no emitido electrónicamente no debería llevar hash ni QR")
+	}
+	if bytes.Contains(pdf, []byte("ELECTR")) {
+		t.Error("Un comprobante no emitido electrónicamente no debería titularse como electrónico")
+	}
+}
+
+// TestArmarVoucherReservaPDF verifica el detalle de pasajes, el logo de la sede y el pase de abordar
+func TestArmarVoucherReservaPDF(t *testing.T) {
+	reserva := &entidades.Reserva{
+		ID:             42,
+		Estado:         "CONFIRMADA",
+		FechaReserva:   time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		TotalPagar:     entidades.DineroDesdeFloat(250),
+		NombreCliente:  "Ana Quispe",
+		NombreTour:     "Islas Ballestas",
+		FechaTour:      "12/03/2025",
+		HoraInicioTour: "08:00",
+		HoraFinTour:    "10:00",
+		CantidadPasajes: []entidades.PasajeCantidad{
+			{NombreTipo: "Adulto", Cantidad: 2},
+			{NombreTipo: "Niño", Cantidad: 1},
+		},
+		Paquetes: []entidades.PaquetePasajeDetalle{
+			{NombrePaquete: "Familiar", Cantidad: 1, CantidadTotal: 4, Subtotal: entidades.DineroDesdeFloat(150)},
+		},
+	}
+
+	logo := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	for x := 0; x < 20; x++ {
+		logo.Set(x, 5, color.RGBA{R: 200, A: 255})
+	}
+
+	pdf, err := servicios.ArmarVoucherReservaPDF(reserva, sedePrueba, logo, "token-del-pase")
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	for _, esperado := range []string{
+		"(RESERVA #42)",
+		"(Sede Paracas)",
+		"(Ni\\361o)",
+		"(Total de pasajeros: 7)",
+		"(Total: S/ 250.00)",
+		"(Pase de abordar)",
+		"/Subtype /Image /Width 20 /Height 10",
+		"/XObject << /Im1",
+	} {
+		if !bytes.Contains(pdf, []byte(esperado)) {
+			t.Errorf("El PDF no contiene %s", esperado)
+		}
+	}
+
+	// Una reserva sin confirmar no lleva pase de abordar
+	reserva.Estado = "RESERVADO"
+	pdf, err = servicios.ArmarVoucherReservaPDF(reserva, sedePrueba, nil, "")
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if strings.Contains(string(pdf), "(Pase de abordar)") || strings.Contains(string(pdf), "/XObject") {
+		t.Error("El voucher sin pase ni logo no debería incluir QR ni imágenes")
+	}
+}
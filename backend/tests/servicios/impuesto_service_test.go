@@ -0,0 +1,100 @@
+package servicios_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"testing"
+)
+
+// TestCalcularImpuestos verifica el cálculo de IGV por línea según la configuración de la sede
+func TestCalcularImpuestos(t *testing.T) {
+	gravadoIncluido := &entidades.ConfiguracionImpuesto{IDSede: 1, Afectacion: "GRAVADO", TasaIGV: 18, PreciosIncluyenIGV: true}
+
+	tests := []struct {
+		nombre        string
+		lineas        []entidades.LineaImpuesto
+		configuracion *entidades.ConfiguracionImpuesto
+		subtotal      string
+		igv           string
+		total         string
+		codigo        string
+		debeFallar    bool
+	}{
+		{
+			nombre:        "Precio con IGV incluido",
+			lineas:        []entidades.LineaImpuesto{{Descripcion: "Adulto", Cantidad: 1, PrecioUnitario: entidades.DineroDesdeFloat(118)}},
+			configuracion: gravadoIncluido,
+			subtotal:      "100.00", igv: "18.00", total: "118.00", codigo: "10",
+		},
+		{
+			nombre:        "Precio sin IGV",
+			lineas:        []entidades.LineaImpuesto{{Descripcion: "Adulto", Cantidad: 2, PrecioUnitario: entidades.DineroDesdeFloat(50)}},
+			configuracion: &entidades.ConfiguracionImpuesto{Afectacion: "GRAVADO", TasaIGV: 18},
+			subtotal:      "100.00", igv: "18.00", total: "118.00", codigo: "10",
+		},
+		{
+			// 35 / 1.18 = 29.661 → 29.66 por línea; el total conserva el precio cobrado
+			nombre: "Redondeo por línea",
+			lineas: []entidades.LineaImpuesto{
+				{Descripcion: "Adulto", Cantidad: 1, PrecioUnitario: entidades.DineroDesdeFloat(35)},
+				{Descripcion: "Niño", Cantidad: 3, PrecioUnitario: entidades.DineroDesdeFloat(0.10)},
+			},
+			configuracion: gravadoIncluido,
+			subtotal:      "29.91", igv: "5.39", total: "35.30", codigo: "10",
+		},
+		{
+			nombre:        "Operación exonerada",
+			lineas:        []entidades.LineaImpuesto{{Descripcion: "Adulto", Cantidad: 2, PrecioUnitario: entidades.DineroDesdeFloat(45.5)}},
+			configuracion: &entidades.ConfiguracionImpuesto{Afectacion: "EXONERADO", TasaIGV: 18, PreciosIncluyenIGV: true},
+			subtotal:      "91.00", igv: "0.00", total: "91.00", codigo: "20",
+		},
+		{
+			nombre:        "Operación inafecta",
+			lineas:        []entidades.LineaImpuesto{{Descripcion: "Paquete familiar", Cantidad: 1, PrecioUnitario: entidades.DineroDesdeFloat(200)}},
+			configuracion: &entidades.ConfiguracionImpuesto{Afectacion: "INAFECTO", TasaIGV: 18},
+			subtotal:      "200.00", igv: "0.00", total: "200.00", codigo: "30",
+		},
+		{
+			nombre:        "Afectación inválida",
+			lineas:        []entidades.LineaImpuesto{{Descripcion: "Adulto", Cantidad: 1, PrecioUnitario: entidades.DineroDesdeFloat(118)}},
+			configuracion: &entidades.ConfiguracionImpuesto{Afectacion: "EXPORTACION", TasaIGV: 18},
+			debeFallar:    true,
+		},
+		{
+			nombre:        "Cantidad en cero",
+			lineas:        []entidades.LineaImpuesto{{Descripcion: "Adulto", Cantidad: 0, PrecioUnitario: entidades.DineroDesdeFloat(118)}},
+			configuracion: gravadoIncluido,
+			debeFallar:    true,
+		},
+		{
+			nombre:        "Sin líneas",
+			configuracion: gravadoIncluido,
+			debeFallar:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			calculo, err := servicios.CalcularImpuestos(tc.lineas, tc.configuracion)
+			if tc.debeFallar {
+				if err == nil {
+					t.Error("Esperaba un error, pero no ocurrió")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error inesperado: %v", err)
+			}
+
+			if calculo.Subtotal.String() != tc.subtotal || calculo.IGV.String() != tc.igv || calculo.Total.String() != tc.total {
+				t.Errorf("Esperaba %s + %s = %s, obtuvo %s + %s = %s",
+					tc.subtotal, tc.igv, tc.total, calculo.Subtotal, calculo.IGV, calculo.Total)
+			}
+			for _, linea := range calculo.Lineas {
+				if linea.CodigoAfectacion != tc.codigo {
+					t.Errorf("Esperaba código de afectación %s, obtuvo %s", tc.codigo, linea.CodigoAfectacion)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package servicios_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"testing"
+)
+
+// firmarWebhook genera la cabecera x-signature tal como la envía Mercado Pago
+func firmarWebhook(secreto, dataID, requestID, ts string) string {
+	manifiesto := fmt.Sprintf("id:%s;request-id:%s;ts:%s;", dataID, requestID, ts)
+	mac := hmac.New(sha256.New, []byte(secreto))
+	mac.Write([]byte(manifiesto))
+	return fmt.Sprintf("ts=%s,v1=%s", ts, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// TestVerificarFirmaWebhook prueba la validación de la firma de notificaciones de Mercado Pago
+func TestVerificarFirmaWebhook(t *testing.T) {
+	const secreto = "secreto-de-prueba"
+
+	tests := []struct {
+		nombre        string
+		secreto       string
+		xSignature    string
+		xRequestID    string
+		dataID        string
+		debeSerValido bool
+	}{
+		{
+			nombre:        "Firma válida",
+			secreto:       secreto,
+			xSignature:    firmarWebhook(secreto, "123456", "req-1", "1704908010"),
+			xRequestID:    "req-1",
+			dataID:        "123456",
+			debeSerValido: true,
+		},
+		{
+			nombre:        "Firma con otra clave",
+			secreto:       secreto,
+			xSignature:    firmarWebhook("otra-clave", "123456", "req-1", "1704908010"),
+			xRequestID:    "req-1",
+			dataID:        "123456",
+			debeSerValido: false,
+		},
+		{
+			nombre:        "Pago distinto al firmado",
+			secreto:       secreto,
+			xSignature:    firmarWebhook(secreto, "123456", "req-1", "1704908010"),
+			xRequestID:    "req-1",
+			dataID:        "999999",
+			debeSerValido: false,
+		},
+		{
+			nombre:        "Sin cabecera de firma",
+			secreto:       secreto,
+			xRequestID:    "req-1",
+			dataID:        "123456",
+			debeSerValido: false,
+		},
+		{
+			nombre:        "Clave secreta no configurada",
+			xSignature:    firmarWebhook(secreto, "123456", "req-1", "1704908010"),
+			xRequestID:    "req-1",
+			dataID:        "123456",
+			debeSerValido: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			mp := &servicios.MercadoPagoService{WebhookSecret: tc.secreto}
+			err := mp.VerificarFirmaWebhook(tc.xSignature, tc.xRequestID, tc.dataID)
+
+			if tc.debeSerValido && err != nil {
+				t.Errorf("Esperaba firma válida, pero hubo error: %v", err)
+			}
+
+			if !tc.debeSerValido && err == nil {
+				t.Errorf("Esperaba firma inválida, pero fue aceptada")
+			}
+		})
+	}
+}
+
+// TestGetPaymentInfoServidorFalso consulta un pago contra un servidor local que simula Mercado Pago
+func TestGetPaymentInfoServidorFalso(t *testing.T) {
+	servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-de-prueba" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/payments/123456":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id":123456,"status":"approved","currency_id":"PEN",
+				"transaction_amount":150.5,"external_reference":"RESERVA-42"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Payment not found"}`)
+		}
+	}))
+	defer servidor.Close()
+
+	mp := &servicios.MercadoPagoService{
+		AccessToken: "token-de-prueba",
+		ApiBaseURL:  servidor.URL,
+	}
+
+	pago, err := mp.GetPaymentInfo("123456")
+	if err != nil {
+		t.Fatalf("Error al obtener pago: %v", err)
+	}
+
+	if pago.ID != 123456 || pago.Status != "approved" || pago.TransactionAmount != 150.5 {
+		t.Errorf("Datos del pago inesperados: %+v", pago)
+	}
+
+	if pago.ExternalReference != "RESERVA-42" {
+		t.Errorf("Esperaba referencia RESERVA-42, pero fue %s", pago.ExternalReference)
+	}
+
+	if _, err := mp.GetPaymentInfo("000000"); err == nil {
+		t.Errorf("Esperaba error para un pago inexistente")
+	}
+}
+
+// TestValidarPagoServidorFalso consulta pagos aprobados en un servidor local que simula Mercado Pago y verifica
+// cuáles pueden registrarse sobre una reserva con S/ 300.00 de saldo
+func TestValidarPagoServidorFalso(t *testing.T) {
+	pagos := map[string]string{
+		"/v1/payments/101": `{"id":101,"status":"approved","currency_id":"PEN","transaction_amount":150,"external_reference":"RESERVA-42"}`,
+		"/v1/payments/102": `{"id":102,"status":"approved","currency_id":"PEN","transaction_amount":300,"external_reference":"RESERVA-42"}`,
+		"/v1/payments/103": `{"id":103,"status":"approved","currency_id":"PEN","transaction_amount":300.01,"external_reference":"RESERVA-42"}`,
+		"/v1/payments/104": `{"id":104,"status":"approved","currency_id":"USD","transaction_amount":80,"external_reference":"RESERVA-42"}`,
+		"/v1/payments/105": `{"id":105,"status":"approved","currency_id":"USD","transaction_amount":80.01,"external_reference":"RESERVA-42"}`,
+	}
+	servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cuerpo, ok := pagos[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, cuerpo)
+	}))
+	defer servidor.Close()
+
+	mp := &servicios.MercadoPagoService{AccessToken: "token-de-prueba", ApiBaseURL: servidor.URL}
+	saldo := entidades.Soles(30000)
+
+	tests := []struct {
+		idPago   string
+		estado   string
+		aceptado bool
+	}{
+		{"101", "RESERVADO", true},  // Pago parcial
+		{"102", "RESERVADO", true},  // Cubre exactamente el saldo
+		{"103", "RESERVADO", false}, // Supera el saldo por un céntimo
+		{"104", "RESERVADO", true},  // USD 80.00 a 3.75 cubre S/ 300.00
+		{"105", "RESERVADO", false}, // USD 80.01 supera el saldo
+		{"101", "EXPIRADA", true},   // Puede recuperar su cupo
+		{"101", "CANCELADA", false}, // No se cobra una reserva cancelada
+	}
+
+	for _, tt := range tests {
+		pago, err := mp.ObtenerPago(tt.idPago)
+		if err != nil {
+			t.Fatalf("Error al obtener el pago %s: %v", tt.idPago, err)
+		}
+
+		tipoCambio := 1.0
+		if pago.Monto.Moneda() == entidades.MonedaDolares {
+			tipoCambio = 3.75
+		}
+		conversion := entidades.ConversionMoneda{Monto: pago.Monto, TipoCambio: tipoCambio}
+
+		err = entidades.ValidarPagoPasarela(tt.estado, saldo, conversion)
+		if tt.aceptado && err != nil {
+			t.Errorf("Pago %s en reserva %s: se esperaba aceptarlo, error: %v", tt.idPago, tt.estado, err)
+		}
+		if !tt.aceptado && !errors.Is(err, entidades.ErrPagoNoAplicable) {
+			t.Errorf("Pago %s en reserva %s: se esperaba rechazarlo, error: %v", tt.idPago, tt.estado, err)
+		}
+	}
+}
+
+// TestCrearDevolucionServidorFalso solicita un reembolso contra un servidor local que simula Mercado Pago
+func TestCrearDevolucionServidorFalso(t *testing.T) {
+	var claveRecibida string
+	var cuerpoRecibido map[string]float64
+
+	servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/payments/123456/refunds" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Payment not found"}`)
+			return
+		}
+
+		claveRecibida = r.Header.Get("X-Idempotency-Key")
+		json.NewDecoder(r.Body).Decode(&cuerpoRecibido)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":987,"payment_id":123456,"amount":40,"status":"approved"}`)
+	}))
+	defer servidor.Close()
+
+	mp := &servicios.MercadoPagoService{
+		AccessToken: "token-de-prueba",
+		ApiBaseURL:  servidor.URL,
+	}
+
+	reembolso, err := mp.CrearDevolucion("123456", entidades.DineroDesdeFloat(40), "DEVOLUCION-7")
+	if err != nil {
+		t.Fatalf("Error al crear reembolso: %v", err)
+	}
+
+	if reembolso.ID != 987 || reembolso.Status != "approved" {
+		t.Errorf("Datos del reembolso inesperados: %+v", reembolso)
+	}
+
+	if claveRecibida != "DEVOLUCION-7" {
+		t.Errorf("Esperaba clave de idempotencia DEVOLUCION-7, pero fue %s", claveRecibida)
+	}
+
+	if cuerpoRecibido["amount"] != 40 {
+		t.Errorf("Esperaba monto 40 en la solicitud, pero fue %v", cuerpoRecibido["amount"])
+	}
+
+	if _, err := mp.CrearDevolucion("000000", entidades.DineroDesdeFloat(40), "DEVOLUCION-8"); err == nil {
+		t.Errorf("Esperaba error para un pago inexistente")
+	}
+}
@@ -0,0 +1,91 @@
+package servicios_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"testing"
+)
+
+// manifiestoPrueba arma un manifiesto con la cantidad de pasajeros indicada
+func manifiestoPrueba(cantidad int) *entidades.ManifiestoInstancia {
+	manifiesto := &entidades.ManifiestoInstancia{
+		IDInstancia:       7,
+		NombreTour:        "Islas Ballestas",
+		NombreSede:        "Paracas",
+		NombreEmbarcacion: "Cóndor I",
+		NombreChofer:      "Juan Pérez",
+		FechaTour:         "10/03/2025",
+		HoraInicio:        "08:00",
+		HoraFin:           "10:00",
+		TotalPasajes:      cantidad + 1,
+	}
+	for i := 0; i < cantidad; i++ {
+		pasajero := &entidades.PasajeroManifiesto{NombreTitular: "Ana Quispe", EstadoReserva: "CONFIRMADA"}
+		pasajero.IDReserva = 100 + i
+		pasajero.Nombres = "María (Nena)"
+		pasajero.Apellidos = "Ñahui"
+		pasajero.TipoDocumento = "DNI"
+		pasajero.NumeroDocumento = "12345678"
+		pasajero.Edad = 30
+		manifiesto.Pasajeros = append(manifiesto.Pasajeros, pasajero)
+	}
+	manifiesto.PasajerosRegistrados = len(manifiesto.Pasajeros)
+	return manifiesto
+}
+
+// TestGenerarManifiestoCSV verifica que el CSV tenga la cabecera y una fila por pasajero
+func TestGenerarManifiestoCSV(t *testing.T) {
+	contenido, err := servicios.GenerarManifiestoCSV(manifiestoPrueba(3))
+	if err != nil {
+		t.Fatalf("Error al generar CSV: %v", err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(contenido))
+	reader.FieldsPerRecord = -1
+	filas, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("El CSV generado no es válido: %v", err)
+	}
+
+	// 7 filas de datos generales, la cabecera y 3 pasajeros (el lector omite la fila vacía)
+	if len(filas) != 11 {
+		t.Fatalf("Esperaba 11 filas, pero hay %d", len(filas))
+	}
+	if filas[8][1] != "Ñahui" || filas[8][2] != "María (Nena)" {
+		t.Errorf("Fila de pasajero inesperada: %v", filas[8])
+	}
+}
+
+// TestGenerarManifiestoPDF verifica la estructura básica del PDF y el salto de página
+func TestGenerarManifiestoPDF(t *testing.T) {
+	tests := []struct {
+		nombre   string
+		cantidad int
+		paginas  string
+	}{
+		{nombre: "Una página", cantidad: 5, paginas: "/Count 1"},
+		{nombre: "Varias páginas", cantidad: 120, paginas: "/Count 3"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			pdf := servicios.GenerarManifiestoPDF(manifiestoPrueba(tc.cantidad))
+
+			if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) {
+				t.Errorf("El documento no comienza con la cabecera PDF")
+			}
+			if !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+				t.Errorf("El documento no termina con %%%%EOF")
+			}
+			if !bytes.Contains(pdf, []byte(tc.paginas)) {
+				t.Errorf("Esperaba %s en el árbol de páginas", tc.paginas)
+			}
+			// Los paréntesis se escapan y los acentos se codifican en WinAnsi
+			if !bytes.Contains(pdf, []byte(`\(Nena\)`)) || !bytes.Contains(pdf, []byte(`\321ahui`)) {
+				t.Errorf("El texto de los pasajeros no está escapado correctamente")
+			}
+		})
+	}
+}
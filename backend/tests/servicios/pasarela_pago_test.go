@@ -0,0 +1,432 @@
+package servicios_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sistema-toursseft/internal/config"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"testing"
+)
+
+// Todas las pasarelas implementan la misma interfaz
+var (
+	_ servicios.PaymentGateway = (*servicios.MercadoPagoService)(nil)
+	_ servicios.PaymentGateway = (*servicios.IzipayService)(nil)
+	_ servicios.PaymentGateway = (*servicios.PasarelaFake)(nil)
+)
+
+// TestPasarelaFakeFlujoCompleto recorre un cobro en la pasarela simulada: checkout, pago, notificación y reembolsos
+func TestPasarelaFakeFlujoCompleto(t *testing.T) {
+	fake := servicios.NewPasarelaFake("secreto-fake")
+	fake.BackendURL = "http://localhost:8080"
+	var pasarela servicios.PaymentGateway = fake
+
+	checkout, err := pasarela.CrearCheckout(&entidades.SolicitudCheckout{
+		IDReserva:   42,
+		Descripcion: "City Tour",
+		Monto:       entidades.Soles(15000),
+		FrontendURL: "http://localhost:3000",
+	})
+	if err != nil {
+		t.Fatalf("Error al crear el cobro: %v", err)
+	}
+	if checkout.Pasarela != entidades.PasarelaFake || checkout.ID != "CHK-1" {
+		t.Errorf("Cobro inesperado: %+v", checkout)
+	}
+	// La URL de pago apunta a la ruta pública del backend que simula el pago
+	if checkout.URLPago != "http://localhost:8080/api/v1/pago-simulado/CHK-1" {
+		t.Errorf("URL de pago inesperada: %s", checkout.URLPago)
+	}
+
+	pago, err := fake.SimularPago(checkout.ID, entidades.EstadoPasarelaAprobado)
+	if err != nil {
+		t.Fatalf("Error al simular el pago: %v", err)
+	}
+
+	// La notificación firmada identifica el pago, que se consulta en la pasarela
+	notificacion, err := fake.Notificacion(pago.ID)
+	if err != nil {
+		t.Fatalf("Error al armar la notificación: %v", err)
+	}
+	evento, err := pasarela.VerificarWebhook(notificacion)
+	if err != nil {
+		t.Fatalf("La notificación firmada debería aceptarse: %v", err)
+	}
+	if evento.IDPago != pago.ID || evento.Tipo != "payment" {
+		t.Errorf("Evento inesperado: %+v", evento)
+	}
+
+	consultado, err := pasarela.ObtenerPago(evento.IDPago)
+	if err != nil {
+		t.Fatalf("Error al obtener el pago: %v", err)
+	}
+	if consultado.Estado != entidades.EstadoPasarelaAprobado || !consultado.Monto.Igual(entidades.Soles(15000)) {
+		t.Errorf("Pago inesperado: %+v", consultado)
+	}
+	if idReserva, err := entidades.ParsearReferenciaReserva(consultado.ReferenciaExterna); err != nil || idReserva != 42 {
+		t.Errorf("Se esperaba la referencia de la reserva 42, se obtuvo %s", consultado.ReferenciaExterna)
+	}
+
+	// Reembolso parcial: el pago sigue aprobado
+	parcial, err := pasarela.Reembolsar(pago.ID, entidades.Soles(5000), "DEVOLUCION-1")
+	if err != nil {
+		t.Fatalf("Error en el reembolso parcial: %v", err)
+	}
+	repetido, err := pasarela.Reembolsar(pago.ID, entidades.Soles(5000), "DEVOLUCION-1")
+	if err != nil || repetido.ID != parcial.ID {
+		t.Errorf("Repetir la clave de idempotencia debería devolver el mismo reembolso")
+	}
+	if _, err := pasarela.Reembolsar(pago.ID, entidades.Soles(20000), "DEVOLUCION-2"); err == nil {
+		t.Errorf("Se esperaba error al reembolsar más que lo pagado")
+	}
+
+	// Reembolso del resto: el pago queda devuelto
+	if _, err := pasarela.Reembolsar(pago.ID, entidades.Soles(10000), "DEVOLUCION-3"); err != nil {
+		t.Fatalf("Error en el reembolso del resto: %v", err)
+	}
+	consultado, _ = pasarela.ObtenerPago(pago.ID)
+	if consultado.Estado != entidades.EstadoPasarelaDevuelto {
+		t.Errorf("Se esperaba el pago devuelto, está %s", consultado.Estado)
+	}
+}
+
+// TestPasarelaFakeNotificacionNoFirmada prueba que con secreto se rechacen las notificaciones sin firma válida
+func TestPasarelaFakeNotificacionNoFirmada(t *testing.T) {
+	fake := servicios.NewPasarelaFake("secreto-fake")
+	checkout, _ := fake.CrearCheckout(&entidades.SolicitudCheckout{IDReserva: 1, Monto: entidades.Soles(1000)})
+	pago, _ := fake.SimularPago(checkout.ID, entidades.EstadoPasarelaAprobado)
+
+	notificacion, _ := fake.Notificacion(pago.ID)
+	notificacion.Cabeceras.Set("X-Fake-Signature", "firma-falsa")
+	if _, err := fake.VerificarWebhook(notificacion); err == nil {
+		t.Errorf("Se esperaba rechazar la notificación con firma inválida")
+	}
+
+	if _, err := fake.SimularPago("CHK-999", entidades.EstadoPasarelaAprobado); err == nil {
+		t.Errorf("Se esperaba error al pagar un cobro inexistente")
+	}
+}
+
+// TestPasarelasPagoRegistro prueba la elección de pasarelas por nombre y la predeterminada según la configuración
+func TestPasarelasPagoRegistro(t *testing.T) {
+	mercadoPago := servicios.NewMercadoPagoService("token", "clave-publica", "secreto")
+
+	pasarelas := servicios.NuevasPasarelasPago(&config.Config{PasarelaPago: entidades.PasarelaFake, BackendURL: "https://api.tours.pe"}, mercadoPago)
+	if pasarelas.Predeterminada().Nombre() != entidades.PasarelaFake {
+		t.Errorf("Se esperaba la pasarela simulada como predeterminada, es %s", pasarelas.Predeterminada().Nombre())
+	}
+	checkout, err := pasarelas.Predeterminada().CrearCheckout(&entidades.SolicitudCheckout{IDReserva: 1, Monto: entidades.Soles(1000)})
+	if err != nil || checkout.URLPago != "https://api.tours.pe/api/v1/pago-simulado/CHK-1" {
+		t.Errorf("El pago simulado debería apuntar al backend configurado: %v %+v", err, checkout)
+	}
+	if pasarela, err := pasarelas.Obtener("mercado-pago"); err != nil || pasarela.Nombre() != entidades.PasarelaMercadoPago {
+		t.Errorf("Mercado Pago debería estar siempre registrada: %v", err)
+	}
+	if _, err := pasarelas.Obtener(entidades.PasarelaIzipay); err == nil {
+		t.Errorf("Izipay no debería registrarse sin credenciales")
+	}
+	if todas := pasarelas.Todas(); len(todas) != 2 || todas[0].Nombre() != entidades.PasarelaFake {
+		t.Errorf("Se esperaban 2 pasarelas empezando por la predeterminada, hay %d", len(todas))
+	}
+
+	// La pasarela simulada solo se registra si se elige
+	pasarelas = servicios.NuevasPasarelasPago(&config.Config{PasarelaPago: entidades.PasarelaMercadoPago, Env: "development"}, mercadoPago)
+	if pasarelas.Predeterminada().Nombre() != entidades.PasarelaMercadoPago {
+		t.Errorf("Se esperaba Mercado Pago como predeterminada, es %s", pasarelas.Predeterminada().Nombre())
+	}
+	if _, err := pasarelas.Obtener("fake"); err == nil {
+		t.Errorf("La pasarela simulada no debería registrarse si no se elige")
+	}
+}
+
+// TestMercadoPagoVerificarWebhook prueba la lectura de notificaciones de Mercado Pago a través de la interfaz
+func TestMercadoPagoVerificarWebhook(t *testing.T) {
+	const secreto = "secreto-de-prueba"
+	var pasarela servicios.PaymentGateway = servicios.NewMercadoPagoService("token", "", secreto)
+
+	cabeceras := http.Header{}
+	cabeceras.Set("x-request-id", "req-1")
+	cabeceras.Set("x-signature", firmarWebhook(secreto, "123456", "req-1", "1704908010"))
+
+	// Parámetros en la URL
+	evento, err := pasarela.VerificarWebhook(&entidades.NotificacionPasarela{
+		Cabeceras:  cabeceras,
+		Parametros: url.Values{"type": {"payment"}, "data.id": {"123456"}},
+	})
+	if err != nil {
+		t.Fatalf("La notificación debería aceptarse: %v", err)
+	}
+	if evento.IDPago != "123456" || evento.Tipo != "payment" || evento.IDNotificacion != "req-1" {
+		t.Errorf("Evento inesperado: %+v", evento)
+	}
+
+	// Parámetros solo en el cuerpo
+	evento, err = pasarela.VerificarWebhook(&entidades.NotificacionPasarela{
+		Cabeceras: cabeceras,
+		Cuerpo:    []byte(`{"type":"payment","data":{"id":"123456"}}`),
+	})
+	if err != nil || evento.IDPago != "123456" {
+		t.Errorf("La notificación con los datos en el cuerpo debería aceptarse: %v", err)
+	}
+
+	// Sin identificar el pago
+	_, err = pasarela.VerificarWebhook(&entidades.NotificacionPasarela{Cabeceras: cabeceras})
+	if !errors.Is(err, servicios.ErrNotificacionIncompleta) {
+		t.Errorf("Se esperaba ErrNotificacionIncompleta, se obtuvo %v", err)
+	}
+}
+
+// TestMercadoPagoObtenerPagoEstados prueba la traducción de los estados de Mercado Pago
+func TestMercadoPagoObtenerPagoEstados(t *testing.T) {
+	servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		estados := map[string]string{
+			"/v1/payments/1": "approved",
+			"/v1/payments/2": "refunded",
+			"/v1/payments/3": "charged_back",
+			"/v1/payments/4": "rejected",
+			"/v1/payments/5": "in_process",
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":1,"status":%q,"currency_id":"USD","transaction_amount":40.5,"external_reference":"RESERVA-7"}`, estados[r.URL.Path])
+	}))
+	defer servidor.Close()
+
+	mp := servicios.NewMercadoPagoService("token", "", "")
+	mp.ApiBaseURL = servidor.URL
+
+	esperados := map[string]string{
+		"1": entidades.EstadoPasarelaAprobado,
+		"2": entidades.EstadoPasarelaDevuelto,
+		"3": entidades.EstadoPasarelaContracargo,
+		"4": entidades.EstadoPasarelaRechazado,
+		"5": entidades.EstadoPasarelaPendiente,
+	}
+	for id, esperado := range esperados {
+		pago, err := mp.ObtenerPago(id)
+		if err != nil {
+			t.Fatalf("Error al obtener el pago %s: %v", id, err)
+		}
+		if pago.Estado != esperado {
+			t.Errorf("Pago %s: se esperaba estado %s, se obtuvo %s (%s)", id, esperado, pago.Estado, pago.EstadoOriginal)
+		}
+		if !pago.Monto.Igual(entidades.NuevoDinero(4050, "USD")) {
+			t.Errorf("Pago %s: se esperaba monto USD 40.50, se obtuvo %s", id, pago.Monto)
+		}
+	}
+}
+
+// servidorIzipay simula la API REST de Izipay
+func servidorIzipay(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if usuario, clave, ok := r.BasicAuth(); !ok || usuario != "tienda" || clave != "clave-api" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var solicitud map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&solicitud)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api-payment/V4/Charge/CreatePayment":
+			if solicitud["amount"] != float64(15000) || solicitud["orderId"] != "RESERVA-42" {
+				fmt.Fprint(w, `{"status":"ERROR","answer":{"errorCode":"INT_902","errorMessage":"datos inválidos"}}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":"SUCCESS","answer":{"formToken":"token-formulario"}}`)
+		case "/api-payment/V4/Transaction/Get":
+			fmt.Fprint(w, `{"status":"SUCCESS","answer":{"uuid":"abc123","amount":15000,"currency":"PEN",
+				"detailedStatus":"CAPTURED","operationType":"DEBIT","orderDetails":{"orderId":"RESERVA-42"}}}`)
+		case "/api-payment/V4/Order/Get":
+			fmt.Fprint(w, `{"status":"SUCCESS","answer":{"orderId":"RESERVA-42","transactions":[
+				{"uuid":"abc123","amount":15000,"currency":"PEN","detailedStatus":"CAPTURED","operationType":"DEBIT"},
+				{"uuid":"def789","amount":8000,"currency":"PEN","detailedStatus":"CAPTURED","operationType":"DEBIT"},
+				{"uuid":"ref001","amount":15000,"currency":"PEN","detailedStatus":"CAPTURED","operationType":"CREDIT",
+				 "transactionDetails":{"parentTransactionUuid":"abc123"}},
+				{"uuid":"ref002","amount":3000,"currency":"PEN","detailedStatus":"CAPTURED","operationType":"CREDIT",
+				 "transactionDetails":{"parentTransactionUuid":"def789"}}]}}`)
+		case "/api-payment/V4/Transaction/CancelOrRefund":
+			fmt.Fprintf(w, `{"status":"SUCCESS","answer":{"uuid":"ref456","amount":%v,"currency":"PEN",
+				"detailedStatus":"CAPTURED","operationType":"CREDIT"}}`, solicitud["amount"])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// TestIzipayServidorFalso crea, consulta y reembolsa un cobro contra un servidor local que simula Izipay
+func TestIzipayServidorFalso(t *testing.T) {
+	servidor := servidorIzipay(t)
+	defer servidor.Close()
+
+	izipay := servicios.NewIzipayService(servidor.URL, "tienda", "clave-api", "clave-publica", "clave-hmac")
+
+	checkout, err := izipay.CrearCheckout(&entidades.SolicitudCheckout{
+		IDReserva: 42,
+		Monto:     entidades.Soles(15000),
+		Cliente:   &entidades.Cliente{ID: 3, Correo: "cliente@correo.com"},
+	})
+	if err != nil {
+		t.Fatalf("Error al crear el cobro: %v", err)
+	}
+	if checkout.ID != "token-formulario" || checkout.ClavePublica != "clave-publica" {
+		t.Errorf("Cobro inesperado: %+v", checkout)
+	}
+
+	if _, err := izipay.CrearCheckout(&entidades.SolicitudCheckout{IDReserva: 1, Monto: entidades.Soles(100)}); err == nil {
+		t.Errorf("Se esperaba error cuando Izipay rechaza el cobro")
+	}
+
+	pago, err := izipay.ObtenerPago("abc123")
+	if err != nil {
+		t.Fatalf("Error al obtener el pago: %v", err)
+	}
+	if pago.Estado != entidades.EstadoPasarelaAprobado || !pago.Monto.Igual(entidades.Soles(15000)) || pago.ReferenciaExterna != "RESERVA-42" {
+		t.Errorf("Pago inesperado: %+v", pago)
+	}
+
+	reembolso, err := izipay.Reembolsar("abc123", entidades.Soles(5000), "DEVOLUCION-1")
+	if err != nil {
+		t.Fatalf("Error al reembolsar: %v", err)
+	}
+	if reembolso.ID != "ref456" || !reembolso.Monto.Igual(entidades.Soles(5000)) {
+		t.Errorf("Reembolso inesperado: %+v", reembolso)
+	}
+
+	izipay.Clave = "otra-clave"
+	if _, err := izipay.ObtenerPago("abc123"); err == nil {
+		t.Errorf("Se esperaba error con credenciales inválidas")
+	}
+}
+
+// notificacionIzipay arma una notificación IPN firmada con la clave indicada
+func notificacionIzipay(respuesta, clave, tipoClave string) *entidades.NotificacionPasarela {
+	mac := hmac.New(sha256.New, []byte(clave))
+	mac.Write([]byte(respuesta))
+	formulario := url.Values{
+		"kr-answer":   {respuesta},
+		"kr-hash":     {hex.EncodeToString(mac.Sum(nil))},
+		"kr-hash-key": {tipoClave},
+	}
+	return &entidades.NotificacionPasarela{Cabeceras: http.Header{}, Cuerpo: []byte(formulario.Encode())}
+}
+
+// TestIzipayVerificarWebhook prueba la verificación de las notificaciones IPN de Izipay
+func TestIzipayVerificarWebhook(t *testing.T) {
+	izipay := servicios.NewIzipayService("", "tienda", "clave-api", "", "clave-hmac")
+
+	pagado := `{"orderStatus":"PAID","orderDetails":{"orderId":"RESERVA-42"},
+		"transactions":[{"uuid":"abc123","amount":15000,"currency":"PEN","detailedStatus":"CAPTURED","operationType":"DEBIT"}]}`
+	evento, err := izipay.VerificarWebhook(notificacionIzipay(pagado, "clave-api", "password"))
+	if err != nil {
+		t.Fatalf("La notificación firmada debería aceptarse: %v", err)
+	}
+	if evento.Pago == nil || evento.Pago.ID != "abc123" || evento.Pago.Estado != entidades.EstadoPasarelaAprobado ||
+		evento.Pago.ReferenciaExterna != "RESERVA-42" {
+		t.Errorf("Evento inesperado: %+v", evento)
+	}
+
+	// Un reembolso se informa como la devolución de la transacción original
+	reembolsado := `{"orderStatus":"PAID","orderDetails":{"orderId":"RESERVA-42"},
+		"transactions":[{"uuid":"ref456","amount":15000,"currency":"PEN","detailedStatus":"CAPTURED","operationType":"CREDIT",
+		"transactionDetails":{"parentTransactionUuid":"abc123"}}]}`
+	evento, err = izipay.VerificarWebhook(notificacionIzipay(reembolsado, "clave-hmac", "sha256_hmac"))
+	if err != nil {
+		t.Fatalf("La notificación firmada con la clave HMAC debería aceptarse: %v", err)
+	}
+	if evento.Pago.ID != "abc123" || evento.Pago.Estado != entidades.EstadoPasarelaDevuelto {
+		t.Errorf("Se esperaba la devolución del pago abc123: %+v", evento.Pago)
+	}
+
+	// Un reembolso parcial no revierte el pago
+	parcial := `{"orderStatus":"PAID","orderDetails":{"orderId":"RESERVA-42","orderTotalAmount":15000},
+		"transactions":[{"uuid":"ref789","amount":5000,"currency":"PEN","detailedStatus":"CAPTURED","operationType":"CREDIT",
+		"transactionDetails":{"parentTransactionUuid":"abc123"}}]}`
+	evento, err = izipay.VerificarWebhook(notificacionIzipay(parcial, "clave-api", "password"))
+	if err != nil || evento.Pago.Estado != entidades.EstadoPasarelaPendiente {
+		t.Errorf("Un reembolso parcial debería quedar pendiente: %v %+v", err, evento)
+	}
+
+	if _, err := izipay.VerificarWebhook(notificacionIzipay(pagado, "otra-clave", "password")); err == nil {
+		t.Errorf("Se esperaba rechazar la notificación con firma inválida")
+	}
+
+	_, err = izipay.VerificarWebhook(&entidades.NotificacionPasarela{Cuerpo: []byte("kr-answer=")})
+	if !errors.Is(err, servicios.ErrNotificacionIncompleta) {
+		t.Errorf("Se esperaba ErrNotificacionIncompleta, se obtuvo %v", err)
+	}
+}
+
+// TestBuscarPagosPorReferencia prueba la búsqueda de los pagos de una reserva en cada pasarela
+func TestBuscarPagosPorReferencia(t *testing.T) {
+	// Mercado Pago busca por external_reference
+	servidorMP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/payments/search" || r.URL.Query().Get("external_reference") != "RESERVA-7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"results":[
+			{"id":11,"status":"refunded","currency_id":"PEN","transaction_amount":100,"external_reference":"RESERVA-7"},
+			{"id":10,"status":"rejected","currency_id":"PEN","transaction_amount":100,"external_reference":"RESERVA-7"}]}`)
+	}))
+	defer servidorMP.Close()
+
+	mp := servicios.NewMercadoPagoService("token", "", "")
+	mp.ApiBaseURL = servidorMP.URL
+
+	pagos, err := mp.BuscarPagos("RESERVA-7")
+	if err != nil {
+		t.Fatalf("Error al buscar pagos en Mercado Pago: %v", err)
+	}
+	if len(pagos) != 2 || pagos[0].ID != "11" || pagos[0].Estado != entidades.EstadoPasarelaDevuelto ||
+		pagos[1].Estado != entidades.EstadoPasarelaRechazado {
+		t.Errorf("Pagos de Mercado Pago inesperados: %+v", pagos)
+	}
+
+	// Izipay lista los cobros del pedido; solo un reembolso total deja DEVUELTO su cobro
+	servidor := servidorIzipay(t)
+	defer servidor.Close()
+
+	izipay := servicios.NewIzipayService(servidor.URL, "tienda", "clave-api", "", "")
+	pagos, err = izipay.BuscarPagos("RESERVA-42")
+	if err != nil {
+		t.Fatalf("Error al buscar pagos en Izipay: %v", err)
+	}
+	if len(pagos) != 2 {
+		t.Fatalf("Se esperaban 2 cobros, se obtuvieron %d: %+v", len(pagos), pagos)
+	}
+	if pagos[0].ID != "abc123" || pagos[0].Estado != entidades.EstadoPasarelaDevuelto {
+		t.Errorf("El cobro reembolsado por completo debía quedar DEVUELTO: %+v", pagos[0])
+	}
+	if pagos[1].ID != "def789" || pagos[1].Estado != entidades.EstadoPasarelaAprobado {
+		t.Errorf("El cobro reembolsado en parte debía seguir APROBADO: %+v", pagos[1])
+	}
+
+	// La pasarela simulada busca entre sus pagos en memoria
+	fake := servicios.NewPasarelaFake("")
+	for _, idReserva := range []int{5, 6, 5} {
+		checkout, err := fake.CrearCheckout(&entidades.SolicitudCheckout{IDReserva: idReserva, Monto: entidades.Soles(1000)})
+		if err != nil {
+			t.Fatalf("Error al crear el cobro: %v", err)
+		}
+		if _, err := fake.SimularPago(checkout.ID, entidades.EstadoPasarelaAprobado); err != nil {
+			t.Fatalf("Error al simular el pago: %v", err)
+		}
+	}
+	pagos, err = fake.BuscarPagos("RESERVA-5")
+	if err != nil {
+		t.Fatalf("Error al buscar pagos simulados: %v", err)
+	}
+	if len(pagos) != 2 {
+		t.Errorf("Se esperaban 2 pagos de la reserva 5, se obtuvieron %d", len(pagos))
+	}
+}
@@ -0,0 +1,108 @@
+package servicios_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"testing"
+	"time"
+)
+
+// TestCalcularCotizacionCancelacion prueba la elección del tramo de política y el monto a devolver
+func TestCalcularCotizacionCancelacion(t *testing.T) {
+	ahora := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	tramos := []*entidades.PoliticaCancelacion{
+		{ID: 1, Nombre: "Flexible", HorasAntesMinimo: 48, PorcentajeDevolucion: 100},
+		{ID: 2, Nombre: "Parcial", HorasAntesMinimo: 24, PorcentajeDevolucion: 50},
+		{ID: 3, Nombre: "Tardía", HorasAntesMinimo: 6, PorcentajeDevolucion: 10},
+	}
+
+	tests := []struct {
+		nombre             string
+		estado             string
+		horasAntes         float64
+		politicas          []*entidades.PoliticaCancelacion
+		debePermitir       bool
+		porcentajeEsperado float64
+		montoEsperado      float64
+	}{
+		{
+			nombre:             "Con mucha anticipación se devuelve todo",
+			estado:             "CONFIRMADA",
+			horasAntes:         72,
+			politicas:          tramos,
+			debePermitir:       true,
+			porcentajeEsperado: 100,
+			montoEsperado:      120.50,
+		},
+		{
+			nombre:             "Justo en el límite del tramo parcial",
+			estado:             "CONFIRMADA",
+			horasAntes:         24,
+			politicas:          tramos,
+			debePermitir:       true,
+			porcentajeEsperado: 50,
+			montoEsperado:      60.25,
+		},
+		{
+			nombre:             "Fuera de todos los tramos no hay devolución",
+			estado:             "RESERVADO",
+			horasAntes:         2,
+			politicas:          tramos,
+			debePermitir:       true,
+			porcentajeEsperado: 0,
+			montoEsperado:      0,
+		},
+		{
+			nombre:             "Sin política configurada se devuelve todo",
+			estado:             "CONFIRMADA",
+			horasAntes:         1,
+			debePermitir:       true,
+			porcentajeEsperado: 100,
+			montoEsperado:      120.50,
+		},
+		{
+			nombre:       "Tour ya iniciado",
+			estado:       "CONFIRMADA",
+			horasAntes:   -1,
+			politicas:    tramos,
+			debePermitir: false,
+		},
+		{
+			nombre:       "Reserva ya cancelada",
+			estado:       "CANCELADA",
+			horasAntes:   72,
+			politicas:    tramos,
+			debePermitir: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			datos := &entidades.DatosCancelacionReserva{
+				IDReserva:         1,
+				Estado:            tc.estado,
+				InicioTour:        ahora.Add(time.Duration(tc.horasAntes * float64(time.Hour))),
+				Ahora:             ahora,
+				TotalReembolsable: entidades.DineroDesdeFloat(120.50),
+			}
+
+			cotizacion := servicios.CalcularCotizacionCancelacion(datos, tc.politicas)
+
+			if cotizacion.Permitida != tc.debePermitir {
+				t.Fatalf("Esperaba permitida=%v, pero fue %v (%s)", tc.debePermitir, cotizacion.Permitida, cotizacion.Motivo)
+			}
+
+			if !tc.debePermitir {
+				return
+			}
+
+			if cotizacion.PorcentajeDevolucion != tc.porcentajeEsperado {
+				t.Errorf("Esperaba porcentaje %.2f, pero fue %.2f", tc.porcentajeEsperado, cotizacion.PorcentajeDevolucion)
+			}
+
+			if !cotizacion.MontoDevolucion.Igual(entidades.DineroDesdeFloat(tc.montoEsperado)) {
+				t.Errorf("Esperaba devolución %.2f, pero fue %s", tc.montoEsperado, cotizacion.MontoDevolucion)
+			}
+		})
+	}
+}
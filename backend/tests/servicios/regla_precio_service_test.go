@@ -0,0 +1,177 @@
+package servicios_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"testing"
+	"time"
+)
+
+// TestResolverPrecioPasaje verifica las condiciones, el alcance y el orden de aplicación de las reglas de precio
+func TestResolverPrecioPasaje(t *testing.T) {
+	entero := func(valor int) *int { return &valor }
+	decimal := func(valor float64) *float64 { return &valor }
+	fecha := func(anio int, mes time.Month, dia int) *time.Time {
+		valor := time.Date(anio, mes, dia, 0, 0, 0, 0, time.UTC)
+		return &valor
+	}
+
+	tipoPasaje := &entidades.TipoPasaje{ID: 1, IDTipoTour: 10, Nombre: "Adulto", Costo: entidades.DineroDesdeFloat(100)}
+
+	// Sábado 16 de enero de 2027, primera salida, con 85% de los cupos vendidos
+	datosBase := entidades.DatosPrecioInstancia{
+		IDInstancia: 50,
+		IDTipoTour:  10,
+		IDSede:      1,
+		Fecha:       time.Date(2027, 1, 16, 0, 0, 0, 0, time.UTC),
+		HoraInicio:  "07:30",
+		Ocupacion:   85,
+	}
+
+	verano := &entidades.ReglaPrecio{
+		ID: 1, Nombre: "Verano", TipoRegla: "TEMPORADA", FechaInicio: fecha(2026, 12, 15), FechaFin: fecha(2027, 3, 15),
+		Porcentaje: 20, Prioridad: 1, Activo: true,
+	}
+	finDeSemana := &entidades.ReglaPrecio{
+		ID: 2, Nombre: "Fin de semana", TipoRegla: "DIA_SEMANA", DiasSemana: "6,7",
+		Monto: entidades.DineroDesdeFloat(5), Prioridad: 2, Activo: true,
+	}
+	primeraSalida := &entidades.ReglaPrecio{
+		ID: 3, Nombre: "Primera salida", TipoRegla: "HORARIO", HoraInicio: "06:00", HoraFin: "08:00",
+		Porcentaje: -10, Prioridad: 3, Activo: true,
+	}
+	ultimosCupos := &entidades.ReglaPrecio{
+		ID: 4, Nombre: "Últimos cupos", TipoRegla: "OCUPACION", OcupacionMinima: decimal(80),
+		Porcentaje: 15, Prioridad: 4, Activo: true,
+	}
+	conCambios := func(regla *entidades.ReglaPrecio, cambios func(*entidades.ReglaPrecio)) *entidades.ReglaPrecio {
+		copia := *regla
+		cambios(&copia)
+		return &copia
+	}
+
+	tests := []struct {
+		nombre  string
+		reglas  []*entidades.ReglaPrecio
+		datos   func(*entidades.DatosPrecioInstancia)
+		precio  string
+		reglasN int
+	}{
+		{
+			nombre: "Sin reglas",
+			precio: "100.00",
+		},
+		{
+			nombre:  "Temporada alta",
+			reglas:  []*entidades.ReglaPrecio{verano},
+			precio:  "120.00",
+			reglasN: 1,
+		},
+		{
+			nombre: "Fuera de temporada",
+			reglas: []*entidades.ReglaPrecio{verano},
+			datos:  func(d *entidades.DatosPrecioInstancia) { d.Fecha = time.Date(2027, 3, 16, 0, 0, 0, 0, time.UTC) },
+			precio: "100.00",
+		},
+		{
+			nombre:  "Último día de temporada",
+			reglas:  []*entidades.ReglaPrecio{verano},
+			datos:   func(d *entidades.DatosPrecioInstancia) { d.Fecha = time.Date(2027, 3, 15, 0, 0, 0, 0, time.UTC) },
+			precio:  "120.00",
+			reglasN: 1,
+		},
+		{
+			nombre:  "Fin de semana",
+			reglas:  []*entidades.ReglaPrecio{finDeSemana},
+			precio:  "105.00",
+			reglasN: 1,
+		},
+		{
+			nombre: "Día de semana",
+			reglas: []*entidades.ReglaPrecio{finDeSemana},
+			datos:  func(d *entidades.DatosPrecioInstancia) { d.Fecha = time.Date(2027, 1, 18, 0, 0, 0, 0, time.UTC) },
+			precio: "100.00",
+		},
+		{
+			nombre:  "Domingo",
+			reglas:  []*entidades.ReglaPrecio{finDeSemana},
+			datos:   func(d *entidades.DatosPrecioInstancia) { d.Fecha = time.Date(2027, 1, 17, 0, 0, 0, 0, time.UTC) },
+			precio:  "105.00",
+			reglasN: 1,
+		},
+		{
+			nombre:  "Horario con rebaja",
+			reglas:  []*entidades.ReglaPrecio{primeraSalida},
+			precio:  "90.00",
+			reglasN: 1,
+		},
+		{
+			nombre: "Hora de fin exclusiva",
+			reglas: []*entidades.ReglaPrecio{primeraSalida},
+			datos:  func(d *entidades.DatosPrecioInstancia) { d.HoraInicio = "08:00" },
+			precio: "100.00",
+		},
+		{
+			nombre:  "Ocupación alcanzada",
+			reglas:  []*entidades.ReglaPrecio{ultimosCupos},
+			precio:  "115.00",
+			reglasN: 1,
+		},
+		{
+			nombre: "Ocupación insuficiente",
+			reglas: []*entidades.ReglaPrecio{ultimosCupos},
+			datos:  func(d *entidades.DatosPrecioInstancia) { d.Ocupacion = 50 },
+			precio: "100.00",
+		},
+		{
+			// 100 +20% = 120; +5 = 125; -10% = 112.50; +15% = 129.375 -> 129.38
+			nombre:  "Acumuladas en orden de prioridad",
+			reglas:  []*entidades.ReglaPrecio{ultimosCupos, primeraSalida, finDeSemana, verano},
+			precio:  "129.38",
+			reglasN: 4,
+		},
+		{
+			nombre: "Restringida a otro tipo de pasaje",
+			reglas: []*entidades.ReglaPrecio{conCambios(verano, func(r *entidades.ReglaPrecio) { r.IDTipoPasaje = entero(2) })},
+			precio: "100.00",
+		},
+		{
+			nombre: "Restringida a otra sede",
+			reglas: []*entidades.ReglaPrecio{conCambios(verano, func(r *entidades.ReglaPrecio) { r.IDSede = entero(2) })},
+			precio: "100.00",
+		},
+		{
+			nombre: "Regla inactiva",
+			reglas: []*entidades.ReglaPrecio{conCambios(verano, func(r *entidades.ReglaPrecio) { r.Activo = false })},
+			precio: "100.00",
+		},
+		{
+			nombre: "La rebaja no deja el precio negativo",
+			reglas: []*entidades.ReglaPrecio{conCambios(finDeSemana, func(r *entidades.ReglaPrecio) {
+				r.Monto = entidades.DineroDesdeFloat(-150)
+			})},
+			precio:  "0.00",
+			reglasN: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			datos := datosBase
+			if tc.datos != nil {
+				tc.datos(&datos)
+			}
+
+			precio := servicios.ResolverPrecioPasaje(tipoPasaje, tc.reglas, &datos)
+			if precio.PrecioUnitario.String() != tc.precio {
+				t.Errorf("Esperaba un precio de %s, obtuvo %s", tc.precio, precio.PrecioUnitario)
+			}
+			if len(precio.Reglas) != tc.reglasN {
+				t.Errorf("Esperaba %d reglas aplicadas, obtuvo %d", tc.reglasN, len(precio.Reglas))
+			}
+			if precio.PrecioBase.String() != "100.00" {
+				t.Errorf("El precio base no debe cambiar, obtuvo %s", precio.PrecioBase)
+			}
+		})
+	}
+}
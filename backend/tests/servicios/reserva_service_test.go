@@ -0,0 +1,150 @@
+package servicios_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"testing"
+)
+
+// TestCalcularCotizacionReserva verifica el total de una reserva calculado con los precios vigentes
+func TestCalcularCotizacionReserva(t *testing.T) {
+	tiposPasaje := map[int]*entidades.TipoPasaje{
+		1: {ID: 1, IDTipoTour: 10, Nombre: "Adulto", Costo: entidades.DineroDesdeFloat(35.50)},
+		2: {ID: 2, IDTipoTour: 10, Nombre: "Niño", Costo: entidades.DineroDesdeFloat(20)},
+		3: {ID: 3, IDTipoTour: 20, Nombre: "Adulto otro tour", Costo: entidades.DineroDesdeFloat(1)},
+		4: {ID: 4, IDTipoTour: 10, Nombre: "Extranjero", Costo: entidades.DineroDesdeFloat(20).EnMoneda("USD")},
+	}
+	paquetesPasajes := map[int]*entidades.PaquetePasajes{
+		5: {ID: 5, IDTipoTour: 10, Nombre: "Familiar", PrecioTotal: entidades.DineroDesdeFloat(99.90), CantidadTotal: 4},
+		6: {ID: 6, IDTipoTour: 10, Nombre: "Grupo extranjero", PrecioTotal: entidades.DineroDesdeFloat(50).EnMoneda("USD"), CantidadTotal: 2},
+	}
+	dolar := &entidades.TipoCambio{Moneda: "USD", Valor: 3.7512}
+
+	tests := []struct {
+		nombre     string
+		pasajes    []entidades.PasajeCantidadRequest
+		paquetes   []entidades.PaqueteRequest
+		precios    map[int]*entidades.PrecioPasaje
+		tipoCambio *entidades.TipoCambio
+		total      string
+		pasajeros  int
+		lineas     int
+		debeFallar bool
+	}{
+		{
+			nombre:    "Solo pasajes",
+			pasajes:   []entidades.PasajeCantidadRequest{{IDTipoPasaje: 1, Cantidad: 2}, {IDTipoPasaje: 2, Cantidad: 1}},
+			total:     "91.00",
+			pasajeros: 3,
+			lineas:    2,
+		},
+		{
+			nombre:    "Pasajes y paquetes",
+			pasajes:   []entidades.PasajeCantidadRequest{{IDTipoPasaje: 1, Cantidad: 1}},
+			paquetes:  []entidades.PaqueteRequest{{IDPaquete: 5, Cantidad: 2}},
+			total:     "235.30",
+			pasajeros: 9,
+			lineas:    2,
+		},
+		{
+			// El adulto se cotiza con el precio efectivo de temporada; el niño, a su costo
+			nombre:    "Con precio efectivo resuelto",
+			pasajes:   []entidades.PasajeCantidadRequest{{IDTipoPasaje: 1, Cantidad: 2}, {IDTipoPasaje: 2, Cantidad: 1}},
+			precios:   map[int]*entidades.PrecioPasaje{1: {IDTipoPasaje: 1, PrecioUnitario: entidades.DineroDesdeFloat(42.60)}},
+			total:     "105.20",
+			pasajeros: 3,
+			lineas:    2,
+		},
+		{
+			nombre:    "Líneas en cero se omiten",
+			pasajes:   []entidades.PasajeCantidadRequest{{IDTipoPasaje: 1, Cantidad: 1}, {IDTipoPasaje: 2, Cantidad: 0}},
+			total:     "35.50",
+			pasajeros: 1,
+			lineas:    1,
+		},
+		{
+			// 20.00 USD a 3.7512 son 75.02 soles por pasaje
+			nombre:     "Pasajes en dólares",
+			pasajes:    []entidades.PasajeCantidadRequest{{IDTipoPasaje: 4, Cantidad: 2}, {IDTipoPasaje: 2, Cantidad: 1}},
+			tipoCambio: dolar,
+			total:      "170.04",
+			pasajeros:  3,
+			lineas:     2,
+		},
+		{
+			// 50.00 USD a 3.7512 son 187.56 soles por paquete
+			nombre:     "Paquete en dólares",
+			pasajes:    []entidades.PasajeCantidadRequest{{IDTipoPasaje: 1, Cantidad: 1}},
+			paquetes:   []entidades.PaqueteRequest{{IDPaquete: 6, Cantidad: 1}},
+			tipoCambio: dolar,
+			total:      "223.06",
+			pasajeros:  3,
+			lineas:     2,
+		},
+		{
+			nombre:     "Precio en dólares sin tipo de cambio",
+			pasajes:    []entidades.PasajeCantidadRequest{{IDTipoPasaje: 4, Cantidad: 1}},
+			debeFallar: true,
+		},
+		{
+			nombre:     "Sin pasajeros",
+			pasajes:    []entidades.PasajeCantidadRequest{{IDTipoPasaje: 1, Cantidad: 0}},
+			debeFallar: true,
+		},
+		{
+			nombre:     "Tipo de pasaje inexistente",
+			pasajes:    []entidades.PasajeCantidadRequest{{IDTipoPasaje: 99, Cantidad: 1}},
+			debeFallar: true,
+		},
+		{
+			nombre:     "Tipo de pasaje de otro tour",
+			pasajes:    []entidades.PasajeCantidadRequest{{IDTipoPasaje: 3, Cantidad: 1}},
+			debeFallar: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			cotizacion, err := servicios.CalcularCotizacionReserva(10, tc.pasajes, tiposPasaje, tc.precios, tc.paquetes, paquetesPasajes, tc.tipoCambio)
+			if tc.debeFallar {
+				if err == nil {
+					t.Errorf("Esperaba un error, pero se cotizó %s", cotizacion.Total)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error inesperado: %v", err)
+			}
+			if cotizacion.Total.String() != tc.total {
+				t.Errorf("Esperaba un total de %s, obtuvo %s", tc.total, cotizacion.Total)
+			}
+			if cotizacion.TotalPasajeros != tc.pasajeros {
+				t.Errorf("Esperaba %d pasajeros, obtuvo %d", tc.pasajeros, cotizacion.TotalPasajeros)
+			}
+			if len(cotizacion.Lineas) != tc.lineas {
+				t.Errorf("Esperaba %d líneas, obtuvo %d", tc.lineas, len(cotizacion.Lineas))
+			}
+			if cotizacion.Total.Moneda() != entidades.MonedaPorDefecto {
+				t.Errorf("Esperaba el total en soles, obtuvo %s", cotizacion.Total.Moneda())
+			}
+			if (cotizacion.TipoCambio != nil) != (tc.tipoCambio != nil) {
+				t.Errorf("El tipo de cambio de la cotización no corresponde a sus precios")
+			}
+		})
+	}
+}
+
+// TestVerificarTotalDeclarado verifica que un total enviado distinto al calculado se rechace
+func TestVerificarTotalDeclarado(t *testing.T) {
+	cotizacion := &entidades.CotizacionReserva{Total: entidades.DineroDesdeFloat(91)}
+
+	if err := servicios.VerificarTotalDeclarado(entidades.Dinero{}, cotizacion); err != nil {
+		t.Errorf("Un total no enviado debería aceptarse: %v", err)
+	}
+	if err := servicios.VerificarTotalDeclarado(entidades.DineroDesdeFloat(91), cotizacion); err != nil {
+		t.Errorf("Un total igual al calculado debería aceptarse: %v", err)
+	}
+	if err := servicios.VerificarTotalDeclarado(entidades.DineroDesdeFloat(90.99), cotizacion); err == nil {
+		t.Error("Esperaba un error para un total distinto al calculado")
+	}
+}
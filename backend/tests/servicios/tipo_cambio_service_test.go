@@ -0,0 +1,168 @@
+package servicios_test
+
+import (
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/servicios"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestConvertirAMonedaBase verifica la conversión a soles de los precios de una venta
+func TestConvertirAMonedaBase(t *testing.T) {
+	dolar := &entidades.TipoCambio{Moneda: "USD", Valor: 3.7512}
+
+	tests := []struct {
+		nombre     string
+		monto      entidades.Dinero
+		tipoCambio *entidades.TipoCambio
+		esperado   string
+		debeFallar bool
+	}{
+		{nombre: "Soles sin tipo de cambio", monto: entidades.DineroDesdeFloat(35.50), esperado: "35.50"},
+		{nombre: "Soles con tipo de cambio", monto: entidades.DineroDesdeFloat(35.50), tipoCambio: dolar, esperado: "35.50"},
+		{nombre: "Dólares", monto: entidades.DineroDesdeFloat(20).EnMoneda("USD"), tipoCambio: dolar, esperado: "75.02"},
+		{nombre: "Redondeo al céntimo", monto: entidades.DineroDesdeFloat(0.15).EnMoneda("USD"), tipoCambio: dolar, esperado: "0.56"},
+		{nombre: "Dólares sin tipo de cambio", monto: entidades.DineroDesdeFloat(20).EnMoneda("USD"), debeFallar: true},
+		{
+			nombre:     "Tipo de cambio de otra moneda",
+			monto:      entidades.DineroDesdeFloat(20).EnMoneda("EUR"),
+			tipoCambio: dolar,
+			debeFallar: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			convertido, err := servicios.ConvertirAMonedaBase(tc.monto, tc.tipoCambio)
+			if tc.debeFallar {
+				if err == nil {
+					t.Errorf("Esperaba un error, pero se convirtió a %s", convertido)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error inesperado: %v", err)
+			}
+			if convertido.String() != tc.esperado || convertido.Moneda() != entidades.MonedaPorDefecto {
+				t.Errorf("Esperaba %s PEN, obtuvo %s %s", tc.esperado, convertido, convertido.Moneda())
+			}
+		})
+	}
+}
+
+// TestDesdeMonedaBase verifica que el cobro en dólares cubra el monto en soles
+func TestDesdeMonedaBase(t *testing.T) {
+	dolar := &entidades.TipoCambio{Moneda: "USD", Valor: 3.75}
+
+	tests := []struct {
+		soles    float64
+		esperado string
+	}{
+		{soles: 75, esperado: "20.00"},
+		{soles: 100, esperado: "26.67"},
+		{soles: 0.01, esperado: "0.01"},
+	}
+
+	for _, tc := range tests {
+		dolares := dolar.DesdeMonedaBase(entidades.DineroDesdeFloat(tc.soles))
+		if dolares.String() != tc.esperado || dolares.Moneda() != "USD" {
+			t.Errorf("%.2f soles: esperaba %s USD, obtuvo %s %s", tc.soles, tc.esperado, dolares, dolares.Moneda())
+		}
+		if dolar.AMonedaBase(dolares).MenorQue(entidades.DineroDesdeFloat(tc.soles)) {
+			t.Errorf("%.2f soles: el cobro de %s USD no cubre el monto", tc.soles, dolares)
+		}
+	}
+}
+
+// TestConvertirConTipoCambioDelCobro verifica que un pago en dólares por el monto cobrado se registre con el
+// tipo de cambio del cobro y cubra el saldo aunque el tipo de cambio del día haya cambiado
+func TestConvertirConTipoCambioDelCobro(t *testing.T) {
+	tipoCambioService := servicios.NewTipoCambioService(nil)
+	saldo := entidades.Soles(10000)
+
+	tipoCambioCobro := 3.75
+	cobro := (&entidades.TipoCambio{Moneda: "USD", Valor: tipoCambioCobro}).DesdeMonedaBase(saldo)
+
+	conversion, err := tipoCambioService.ConvertirConTipoCambio(cobro, &tipoCambioCobro, time.Now())
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if conversion.TipoCambio != tipoCambioCobro || conversion.MontoBase.MenorQue(saldo) {
+		t.Errorf("Conversión inesperada del cobro de %s USD: %+v", cobro, conversion)
+	}
+	if err := entidades.ValidarPagoPasarela("RESERVADO", saldo, conversion); err != nil {
+		t.Errorf("El pago del monto cobrado debería aceptarse: %v", err)
+	}
+
+	// Con el tipo de cambio de otro día el mismo pago superaría el saldo
+	otroDia := entidades.ConversionMoneda{Monto: cobro, TipoCambio: 3.80}
+	if err := entidades.ValidarPagoPasarela("RESERVADO", saldo, otroDia); err == nil {
+		t.Errorf("Se esperaba que otro tipo de cambio cambie el saldo en dólares")
+	}
+
+	// Los pagos en soles no usan tipo de cambio
+	conversion, err = tipoCambioService.ConvertirConTipoCambio(saldo, &tipoCambioCobro, time.Now())
+	if err != nil || conversion.TipoCambio != 1 || !conversion.MontoBase.Igual(saldo) {
+		t.Errorf("Conversión inesperada de un pago en soles: %+v %v", conversion, err)
+	}
+}
+
+// TestExpresarMontosComprobante verifica los totales de un comprobante emitido en dólares
+func TestExpresarMontosComprobante(t *testing.T) {
+	calculo := &entidades.CalculoImpuestos{
+		Afectacion: "GRAVADO",
+		Subtotal:   entidades.DineroDesdeFloat(84.75),
+		IGV:        entidades.DineroDesdeFloat(15.25),
+		Total:      entidades.DineroDesdeFloat(100),
+	}
+
+	convertido := servicios.ExpresarMontosComprobante(calculo, &entidades.TipoCambio{Moneda: "USD", Valor: 3.75})
+
+	if convertido.Total.String() != "26.67" || convertido.Total.Moneda() != "USD" {
+		t.Errorf("Esperaba un total de 26.67 USD, obtuvo %s %s", convertido.Total, convertido.Total.Moneda())
+	}
+	if convertido.IGV.String() != "4.07" {
+		t.Errorf("Esperaba un IGV de 4.07, obtuvo %s", convertido.IGV)
+	}
+	if !convertido.Subtotal.Sumar(convertido.IGV).Igual(convertido.Total) {
+		t.Errorf("El subtotal %s más el IGV %s debería ser el total %s", convertido.Subtotal, convertido.IGV, convertido.Total)
+	}
+	if calculo.Total.Moneda() != entidades.MonedaPorDefecto {
+		t.Error("El cálculo original no debería modificarse")
+	}
+}
+
+// TestParsearTiposCambioCSV verifica la lectura de un archivo de tipos de cambio
+func TestParsearTiposCambioCSV(t *testing.T) {
+	archivo := strings.Join([]string{
+		"fecha,moneda,valor",
+		"2026-10-01,USD,3.7512",
+		"",
+		"02/10/2026, usd ,3.76",
+		"2026-10-03,EUR,4.05",
+		"2026-10-04,USD,abc",
+		"2026-10-05,USD,0",
+		"31/02/2026,USD,3.70",
+		"2026-10-06,USD",
+	}, "\n")
+
+	tiposCambio, errores := servicios.ParsearTiposCambioCSV(strings.NewReader(archivo))
+
+	if len(tiposCambio) != 2 {
+		t.Fatalf("Esperaba 2 tipos de cambio válidos, obtuvo %d", len(tiposCambio))
+	}
+	if tiposCambio[0].Fecha.Format("2006-01-02") != "2026-10-01" || tiposCambio[0].Valor != 3.7512 {
+		t.Errorf("Primer tipo de cambio inesperado: %+v", tiposCambio[0])
+	}
+	if tiposCambio[1].Fecha.Format("2006-01-02") != "2026-10-02" || tiposCambio[1].Moneda != "USD" {
+		t.Errorf("Segundo tipo de cambio inesperado: %+v", tiposCambio[1])
+	}
+
+	if len(errores) != 5 {
+		t.Fatalf("Esperaba 5 errores, obtuvo %d: %v", len(errores), errores)
+	}
+	if !strings.HasPrefix(errores[0], "línea 5:") {
+		t.Errorf("El primer error debería indicar la línea 5: %s", errores[0])
+	}
+}
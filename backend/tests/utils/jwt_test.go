@@ -0,0 +1,117 @@
+package utils_test
+
+import (
+	"sistema-toursseft/internal/config"
+	"sistema-toursseft/internal/entidades"
+	"sistema-toursseft/internal/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBoardingPassToken verifica que el pase de abordar conserve la reserva y la instancia firmadas
+func TestBoardingPassToken(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+
+	token, err := utils.GenerateBoardingPassToken(15, 8, time.Now().Add(time.Hour), cfg)
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+
+	claims, err := utils.ValidateBoardingPassToken(token, cfg)
+	if err != nil {
+		t.Fatalf("Error inesperado al validar: %v", err)
+	}
+	if claims.IDReserva != 15 || claims.IDInstancia != 8 {
+		t.Errorf("Esperaba reserva 15 e instancia 8, obtuvo %d y %d", claims.IDReserva, claims.IDInstancia)
+	}
+}
+
+// TestBoardingPassTokenInvalido verifica que se rechacen pases vencidos, alterados o de otro tipo
+func TestBoardingPassTokenInvalido(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+
+	vencido, _ := utils.GenerateBoardingPassToken(15, 8, time.Now().Add(-time.Minute), cfg)
+	valido, _ := utils.GenerateBoardingPassToken(15, 8, time.Now().Add(time.Hour), cfg)
+	sesion, _ := utils.GenerateJWT(&entidades.Usuario{ID: 3, Rol: "CLIENTE"}, cfg)
+
+	partes := strings.Split(valido, ".")
+	alterado := partes[0] + "." + partes[1] + "x." + partes[2]
+
+	tests := []struct {
+		nombre string
+		token  string
+		config *config.Config
+	}{
+		{"Pase vencido", vencido, cfg},
+		{"Pase alterado", alterado, cfg},
+		{"Firmado con otra llave", valido, &config.Config{JWTSecret: "otrosecret"}},
+		{"Token de sesión", sesion, cfg},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			if _, err := utils.ValidateBoardingPassToken(tc.token, tc.config); err == nil {
+				t.Error("Esperaba que el pase fuera rechazado")
+			}
+		})
+	}
+
+	// Un pase de abordar tampoco sirve como token de sesión
+	if _, err := utils.ValidateToken(valido, cfg); err == nil {
+		t.Error("Esperaba que el pase de abordar no fuera aceptado como token de sesión")
+	}
+}
+
+// TestBoardingPassTokenDeterministico verifica que el mismo pase produzca el mismo token y la misma huella,
+// requisito para validarlo sin conexión contra el paquete del chofer
+func TestBoardingPassTokenDeterministico(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	expira := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	primero, _ := utils.GenerateBoardingPassToken(15, 8, expira, cfg)
+	segundo, _ := utils.GenerateBoardingPassToken(15, 8, expira, cfg)
+	otro, _ := utils.GenerateBoardingPassToken(16, 8, expira, cfg)
+
+	if primero != segundo {
+		t.Error("Esperaba el mismo token para el mismo pase")
+	}
+	if utils.HuellaBoardingPass(primero) != utils.HuellaBoardingPass(segundo) {
+		t.Error("Esperaba la misma huella para el mismo pase")
+	}
+	if utils.HuellaBoardingPass(primero) == utils.HuellaBoardingPass(otro) {
+		t.Error("Esperaba huellas distintas para reservas distintas")
+	}
+}
+
+// TestValidateBoardingPassTokenAt verifica que un escaneo sin conexión se valide con la hora del escaneo
+func TestValidateBoardingPassTokenAt(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	expira := time.Now().Add(-time.Hour)
+
+	token, _ := utils.GenerateBoardingPassToken(15, 8, expira, cfg)
+
+	if _, err := utils.ValidateBoardingPassTokenAt(token, expira.Add(-2*time.Hour), cfg); err != nil {
+		t.Errorf("Esperaba pase válido al momento del escaneo, obtuvo: %v", err)
+	}
+	if _, err := utils.ValidateBoardingPassTokenAt(token, expira.Add(time.Minute), cfg); err == nil {
+		t.Error("Esperaba pase vencido al momento del escaneo")
+	}
+}
+
+// TestFirmaPaqueteOffline verifica que cualquier cambio en el paquete invalide la firma
+func TestFirmaPaqueteOffline(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "testsecret"}
+	contenido := []byte(`{"id_chofer":4,"instancias":[]}`)
+
+	firma := utils.SignOfflineBundle(contenido, cfg)
+	if !utils.VerifyOfflineBundle(contenido, firma, cfg) {
+		t.Error("Esperaba firma válida")
+	}
+	if utils.VerifyOfflineBundle([]byte(`{"id_chofer":5,"instancias":[]}`), firma, cfg) {
+		t.Error("Esperaba firma inválida para un paquete alterado")
+	}
+	if utils.VerifyOfflineBundle(contenido, firma, &config.Config{JWTSecret: "otrosecret"}) {
+		t.Error("Esperaba firma inválida con otra llave")
+	}
+}
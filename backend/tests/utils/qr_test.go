@@ -0,0 +1,94 @@
+package utils_test
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"sistema-toursseft/internal/utils"
+	"strings"
+	"testing"
+)
+
+// TestQRCorreccionReedSolomon verifica los codewords de corrección del ejemplo 1-M del estándar
+func TestQRCorreccionReedSolomon(t *testing.T) {
+	datos := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	esperado := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	obtenido := utils.QRCorreccionReedSolomon(datos, 10)
+	if !bytes.Equal(obtenido, esperado) {
+		t.Errorf("Esperaba %v, obtuvo %v", esperado, obtenido)
+	}
+}
+
+// TestQRBitsFormatoYVersion verifica los bits BCH de formato y versión contra las tablas del estándar
+func TestQRBitsFormatoYVersion(t *testing.T) {
+	if bits := fmt.Sprintf("%015b", utils.QRBitsFormato(0)); bits != "101010000010010" {
+		t.Errorf("Formato M con máscara 0: esperaba 101010000010010, obtuvo %s", bits)
+	}
+	if bits := fmt.Sprintf("%015b", utils.QRBitsFormato(7)); bits != "100101010100000" {
+		t.Errorf("Formato M con máscara 7: esperaba 100101010100000, obtuvo %s", bits)
+	}
+	if bits := fmt.Sprintf("%018b", utils.QRBitsVersion(7)); bits != "000111110010010100" {
+		t.Errorf("Versión 7: esperaba 000111110010010100, obtuvo %s", bits)
+	}
+}
+
+// TestGenerarQR verifica la versión elegida y los patrones de localización
+func TestGenerarQR(t *testing.T) {
+	tests := []struct {
+		nombre  string
+		texto   string
+		version int
+	}{
+		{"Texto corto", "HOLA", 1},
+		{"Límite de la versión 1", strings.Repeat("a", 14), 1},
+		{"Pasa a la versión 2", strings.Repeat("a", 15), 2},
+		{"Límite de la versión 9", strings.Repeat("x", 180), 9},
+		{"Conteo de 16 bits desde la versión 10", strings.Repeat("x", 200), 10},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nombre, func(t *testing.T) {
+			qr, err := utils.GenerarQR(tc.texto)
+			if err != nil {
+				t.Fatalf("Error inesperado: %v", err)
+			}
+			if qr.Version != tc.version {
+				t.Errorf("Esperaba versión %d, obtuvo %d", tc.version, qr.Version)
+			}
+			if qr.Tamano != tc.version*4+17 {
+				t.Errorf("Tamaño %d no corresponde a la versión %d", qr.Tamano, qr.Version)
+			}
+
+			// Las tres esquinas tienen el patrón de localización: borde oscuro, anillo claro y centro oscuro
+			for _, esquina := range [][2]int{{0, 0}, {qr.Tamano - 7, 0}, {0, qr.Tamano - 7}} {
+				x, y := esquina[0], esquina[1]
+				if !qr.Modulos[y][x] || qr.Modulos[y+1][x+1] || !qr.Modulos[y+3][x+3] {
+					t.Errorf("Patrón de localización incorrecto en (%d, %d)", x, y)
+				}
+			}
+		})
+	}
+
+	if _, err := utils.GenerarQR(strings.Repeat("x", 3000)); err == nil {
+		t.Error("Esperaba error para un texto que no entra en la versión 40")
+	}
+}
+
+// TestGenerarQRPNG verifica que la imagen incluya el margen y la escala indicada
+func TestGenerarQRPNG(t *testing.T) {
+	contenido, err := utils.GenerarQRPNG("HOLA", 4)
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(contenido))
+	if err != nil {
+		t.Fatalf("El PNG no se pudo decodificar: %v", err)
+	}
+
+	// Versión 1: 21 módulos más 4 de margen a cada lado
+	if ancho := img.Bounds().Dx(); ancho != (21+8)*4 {
+		t.Errorf("Esperaba ancho %d, obtuvo %d", (21+8)*4, ancho)
+	}
+}
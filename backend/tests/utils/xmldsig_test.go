@@ -0,0 +1,102 @@
+package utils_test
+
+import (
+	"sistema-toursseft/internal/utils"
+	"strings"
+	"testing"
+)
+
+// documentoPrueba arma un XML pequeño con un contenedor vacío para la firma
+func documentoPrueba() (*utils.NodoXML, *utils.NodoXML) {
+	raiz := utils.NuevoNodoXML("doc:Documento", "xmlns:doc", "urn:prueba", "xmlns:ds", utils.NamespaceXMLDSig)
+	contenedor := raiz.Hijo("doc:Firma")
+	raiz.HijoTexto("doc:Cliente", "Pérez & Hijos <S.A.C.>", "tipo", "6")
+	raiz.HijoTexto("doc:Total", "118.00")
+	return raiz, contenedor
+}
+
+// TestFirmaEnvueltaVerificable verifica que un documento recién firmado pase la verificación
+func TestFirmaEnvueltaVerificable(t *testing.T) {
+	firmante, err := utils.NuevoFirmanteXMLAutofirmado("Prueba")
+	if err != nil {
+		t.Fatalf("Error al crear el firmante: %v", err)
+	}
+
+	raiz, contenedor := documentoPrueba()
+	digest, err := firmante.FirmarEnvuelto(raiz, contenedor, "SIGN1")
+	if err != nil {
+		t.Fatalf("Error al firmar: %v", err)
+	}
+	if digest == "" {
+		t.Error("Esperaba el DigestValue del documento")
+	}
+
+	if err := utils.VerificarFirmaEnvuelta(raiz, contenedor); err != nil {
+		t.Errorf("La firma debería ser válida: %v", err)
+	}
+
+	xml := string(utils.DocumentoXML(raiz))
+	if !strings.Contains(xml, "Pérez &amp; Hijos &lt;S.A.C.&gt;") {
+		t.Errorf("El texto no se escapó correctamente: %s", xml)
+	}
+	if !strings.Contains(xml, `<ds:Signature Id="SIGN1">`) {
+		t.Errorf("La firma no quedó dentro del contenedor: %s", xml)
+	}
+
+	// El contenedor ya firmado no admite otra firma
+	if _, err := firmante.FirmarEnvuelto(raiz, contenedor, "SIGN2"); err == nil {
+		t.Error("Esperaba error al firmar dos veces")
+	}
+}
+
+// TestFirmaEnvueltaDetectaCambios verifica que se rechacen documentos modificados después de firmar
+func TestFirmaEnvueltaDetectaCambios(t *testing.T) {
+	firmante, _ := utils.NuevoFirmanteXMLAutofirmado("Prueba")
+	otroFirmante, _ := utils.NuevoFirmanteXMLAutofirmado("Otro")
+
+	t.Run("contenido alterado", func(t *testing.T) {
+		raiz, contenedor := documentoPrueba()
+		firmante.FirmarEnvuelto(raiz, contenedor, "SIGN1")
+		raiz.Buscar("doc:Total").Texto = "11.80"
+		if err := utils.VerificarFirmaEnvuelta(raiz, contenedor); err == nil {
+			t.Error("Esperaba error por documento alterado")
+		}
+	})
+
+	t.Run("certificado reemplazado", func(t *testing.T) {
+		raiz, contenedor := documentoPrueba()
+		firmante.FirmarEnvuelto(raiz, contenedor, "SIGN1")
+
+		otraRaiz, otroContenedor := documentoPrueba()
+		otroFirmante.FirmarEnvuelto(otraRaiz, otroContenedor, "SIGN1")
+		raiz.Buscar("ds:X509Certificate").Texto = otraRaiz.Buscar("ds:X509Certificate").Texto
+
+		if err := utils.VerificarFirmaEnvuelta(raiz, contenedor); err == nil {
+			t.Error("Esperaba error por certificado que no corresponde a la firma")
+		}
+	})
+}
+
+// TestMontoEnLetras verifica la leyenda del monto en letras
+func TestMontoEnLetras(t *testing.T) {
+	casos := []struct {
+		monto    float64
+		moneda   string
+		esperado string
+	}{
+		{118.50, "PEN", "CIENTO DIECIOCHO CON 50/100 SOLES"},
+		{100, "PEN", "CIEN CON 00/100 SOLES"},
+		{0.5, "PEN", "CERO CON 50/100 SOLES"},
+		{1000, "PEN", "MIL CON 00/100 SOLES"},
+		{21000, "PEN", "VEINTIUN MIL CON 00/100 SOLES"},
+		{1501.01, "USD", "MIL QUINIENTOS UNO CON 01/100 DOLARES AMERICANOS"},
+		{1000000, "PEN", "UN MILLON CON 00/100 SOLES"},
+		{2345678.99, "PEN", "DOS MILLONES TRESCIENTOS CUARENTA Y CINCO MIL SEISCIENTOS SETENTA Y OCHO CON 99/100 SOLES"},
+	}
+
+	for _, caso := range casos {
+		if obtenido := utils.MontoEnLetras(caso.monto, caso.moneda); obtenido != caso.esperado {
+			t.Errorf("MontoEnLetras(%v) = %q, esperaba %q", caso.monto, obtenido, caso.esperado)
+		}
+	}
+}